@@ -0,0 +1,94 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AuditReportPending is the phase of an AuditReport whose audit has not finished
+	AuditReportPending = "Pending"
+	// AuditReportCompleted is the phase of an AuditReport whose audit finished
+	AuditReportCompleted = "Completed"
+	// AuditReportFailed is the phase of an AuditReport whose audit could not review every
+	// object
+	AuditReportFailed = "Failed"
+)
+
+// AuditReportSpec selects what is audited in the namespace of the AuditReport
+type AuditReportSpec struct {
+	// Kinds limits the audit to objects of these kinds. All kinds are audited if empty
+	Kinds []string `json:"kinds,omitempty"`
+}
+
+// AuditReportViolation is an object of the namespace that violates a constraint
+type AuditReportViolation struct {
+	ConstraintKind string `json:"constraintKind"`
+	ConstraintName string `json:"constraintName"`
+	// ConstraintOwner is the owner label of the constraint, if it has one
+	ConstraintOwner   string `json:"constraintOwner,omitempty"`
+	Kind              string `json:"kind"`
+	Name              string `json:"name"`
+	Message           string `json:"message"`
+	EnforcementAction string `json:"enforcementAction"`
+}
+
+// AuditReportStatus holds the results of the audit
+type AuditReportStatus struct {
+	// Phase is Pending, Completed or Failed
+	Phase string `json:"phase,omitempty"`
+	// The generation of the AuditReport that was audited
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// When the audit started
+	AuditTimestamp *metav1.Time `json:"auditTimestamp,omitempty"`
+	// TotalViolations counts every violation, including those not listed
+	TotalViolations int64 `json:"totalViolations"`
+	// Violations lists up to --audit-report-violations-limit violations
+	Violations []AuditReportViolation `json:"violations,omitempty"`
+	// Error is set if the audit failed or some objects could not be reviewed
+	Error string `json:"error,omitempty"`
+}
+
+// +genclient
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Violations",type="integer",JSONPath=".status.totalViolations"
+// +kubebuilder:printcolumn:name="Audited",type="date",JSONPath=".status.auditTimestamp"
+// +kubebuilder:object:root=true
+
+// AuditReport audits its own namespace as soon as it is created, without waiting for the
+// next audit of the whole cluster. Changing its spec audits the namespace again.
+type AuditReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AuditReportSpec   `json:"spec,omitempty"`
+	Status AuditReportStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AuditReportList contains a list of AuditReport
+type AuditReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AuditReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AuditReport{}, &AuditReportList{})
+}
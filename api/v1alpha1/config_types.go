@@ -28,6 +28,28 @@ type ConfigSpec struct {
 
 	// Configuration for validation
 	Validation Validation `json:"validation,omitempty"`
+
+	// Enforcement action applied to constraints that do not set
+	// spec.enforcementAction. Defaults to deny.
+	// +kubebuilder:validation:Enum=deny;dryrun;audit
+	DefaultEnforcementAction string `json:"defaultEnforcementAction,omitempty"`
+
+	// Enforcement action applied to constraints that set spec.severity but not
+	// spec.enforcementAction
+	SeverityActions SeverityActions `json:"severityActions,omitempty"`
+}
+
+// SeverityActions maps each constraint severity to an enforcement action. Unset
+// severities default to deny for critical and high, dryrun for medium and audit for low.
+type SeverityActions struct {
+	// +kubebuilder:validation:Enum=deny;dryrun;audit
+	Critical string `json:"critical,omitempty"`
+	// +kubebuilder:validation:Enum=deny;dryrun;audit
+	High string `json:"high,omitempty"`
+	// +kubebuilder:validation:Enum=deny;dryrun;audit
+	Medium string `json:"medium,omitempty"`
+	// +kubebuilder:validation:Enum=deny;dryrun;audit
+	Low string `json:"low,omitempty"`
 }
 
 type Validation struct {
@@ -66,6 +88,8 @@ type GVK struct {
 	Kind    string `json:"kind,omitempty"`
 }
 
+// +genclient
+// +genclient:noStatus
 // +kubebuilder:resource:scope=Namespaced
 // +kubebuilder:object:root=true
 
@@ -0,0 +1,90 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ConstraintSetSpec lists constraints that share their match criteria and enforcement action
+type ConstraintSetSpec struct {
+	// Match is the spec.match of every constraint in the set
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Match *runtime.RawExtension `json:"match,omitempty"`
+	// EnforcementAction is the spec.enforcementAction of every constraint in the set. If
+	// unset, the constraints use the cluster's default.
+	EnforcementAction string                `json:"enforcementAction,omitempty"`
+	Constraints       []ConstraintSetMember `json:"constraints"`
+}
+
+// ConstraintSetMember is a constraint created by a ConstraintSet
+type ConstraintSetMember struct {
+	// Kind of the constraint, which must have a ConstraintTemplate
+	Kind string `json:"kind"`
+	// Name of the constraint. Defaults to the set's name and the lowercased kind, joined
+	// by a dash.
+	Name string `json:"name,omitempty"`
+	// Parameters are the constraint's spec.parameters
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Parameters *runtime.RawExtension `json:"parameters,omitempty"`
+}
+
+// ConstraintSetStatus defines the observed state of ConstraintSet
+type ConstraintSetStatus struct {
+	// The generation of the ConstraintSet that was last expanded
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Constraints created by the set, as Kind/name
+	Constraints []string `json:"constraints,omitempty"`
+	// Errors are the members that could not be created or updated
+	Errors []ConstraintSetError `json:"errors,omitempty"`
+}
+
+// ConstraintSetError is a member that could not be created or updated
+type ConstraintSetError struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:object:root=true
+
+// ConstraintSet groups constraints that share their match criteria and enforcement
+// action. Gatekeeper creates, updates and deletes the constraints to match the set.
+type ConstraintSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConstraintSetSpec   `json:"spec,omitempty"`
+	Status ConstraintSetStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ConstraintSetList contains a list of ConstraintSet
+type ConstraintSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConstraintSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConstraintSet{}, &ConstraintSetList{})
+}
@@ -0,0 +1,82 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CoverageGap is a namespace in which no constraint matches some workload kinds
+type CoverageGap struct {
+	Namespace string `json:"namespace"`
+	// Kinds no constraint matches in the namespace, as <group>/<version>/<kind>
+	Kinds []string `json:"kinds"`
+}
+
+// CoverageReportStatus lists the namespaces and workload kinds no constraint matches
+type CoverageReportStatus struct {
+	// When the audit that checked coverage started
+	AuditTimestamp *metav1.Time `json:"auditTimestamp,omitempty"`
+	// Constraints that were checked
+	Constraints int64 `json:"constraints"`
+	// Namespaces that were checked
+	Namespaces int64 `json:"namespaces"`
+	// Workload kinds each namespace was checked for, as <group>/<version>/<kind>
+	Kinds []string `json:"kinds,omitempty"`
+	// Namespaces in which no constraint matches any of the kinds, up to
+	// --audit-coverage-gaps-limit
+	UncoveredNamespaces []string `json:"uncoveredNamespaces,omitempty"`
+	// TotalUncoveredNamespaces counts every uncovered namespace, including those not listed
+	TotalUncoveredNamespaces int64 `json:"totalUncoveredNamespaces"`
+	// Kinds no constraint matches in any namespace
+	UncoveredKinds []string `json:"uncoveredKinds,omitempty"`
+	// Gaps lists the namespaces in which no constraint matches some of the kinds, up to
+	// --audit-coverage-gaps-limit
+	Gaps []CoverageGap `json:"gaps,omitempty"`
+	// TotalGaps counts every namespace with a gap, including those not listed
+	TotalGaps int64 `json:"totalGaps"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Constraints",type="integer",JSONPath=".status.constraints"
+// +kubebuilder:printcolumn:name="Namespaces",type="integer",JSONPath=".status.namespaces"
+// +kubebuilder:printcolumn:name="Audited",type="date",JSONPath=".status.auditTimestamp"
+// +kubebuilder:object:root=true
+
+// CoverageReport is written by audit, as the singleton named coverage, to find
+// enforcement gaps: the namespaces and workload kinds no constraint matches
+type CoverageReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status CoverageReportStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// CoverageReportList contains a list of CoverageReport
+type CoverageReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CoverageReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CoverageReport{}, &CoverageReportList{})
+}
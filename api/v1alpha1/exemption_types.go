@@ -0,0 +1,78 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ExemptionSpec waives a single constraint for the resources it matches until ExpiresAt
+type ExemptionSpec struct {
+	// Kind of the waived constraint
+	ConstraintKind string `json:"constraintKind"`
+	// Name of the waived constraint
+	ConstraintName string `json:"constraintName"`
+	// Resources the waiver applies to
+	Match ExemptionMatch `json:"match"`
+	// When the waiver stops applying
+	ExpiresAt metav1.Time `json:"expiresAt"`
+	// Why the waiver was granted
+	Reason string `json:"reason,omitempty"`
+}
+
+// ExemptionMatch selects the waived resources. Empty fields match any value, but
+// Namespace or Name must be set.
+type ExemptionMatch struct {
+	Kind string `json:"kind,omitempty"`
+	// Namespace is a namespace name, or a subtree of the namespace hierarchy such as team-a/*
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// ExemptionStatus defines the observed state of Exemption
+type ExemptionStatus struct {
+	// Expired is true once the waiver no longer applies
+	Expired bool `json:"expired,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:object:root=true
+
+// Exemption is a time-boxed waiver of a constraint for a resource or namespace. The
+// webhook and audit ignore the violations it matches until it expires.
+type Exemption struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ExemptionSpec   `json:"spec,omitempty"`
+	Status ExemptionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ExemptionList contains a list of Exemption
+type ExemptionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Exemption `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Exemption{}, &ExemptionList{})
+}
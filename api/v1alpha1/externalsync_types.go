@@ -0,0 +1,79 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ExternalSyncSpec fetches a JSON document over HTTP(S) into the inventory
+type ExternalSyncSpec struct {
+	// URL of the document, http or https
+	URL string `json:"url"`
+	// How often the document is fetched, such as 10m. Defaults to 5m
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Credentials sent with each request
+	Auth *ExternalSyncAuth `json:"auth,omitempty"`
+	// PEM-encoded CA certificates that verify an https endpoint instead of the system's
+	CABundle string `json:"caBundle,omitempty"`
+}
+
+// ExternalSyncAuth reads credentials from a Secret in Gatekeeper's namespace. A token key
+// is sent as a bearer token, and username and password keys as basic auth.
+type ExternalSyncAuth struct {
+	SecretName string `json:"secretName"`
+}
+
+// ExternalSyncStatus defines the observed state of ExternalSync
+type ExternalSyncStatus struct {
+	// The generation of the ExternalSync last fetched
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Synced is true if the last fetch was loaded
+	Synced bool `json:"synced"`
+	// Error of the last fetch. The document of the last successful fetch is kept
+	Error string `json:"error,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="URL",type="string",JSONPath=".spec.url"
+// +kubebuilder:printcolumn:name="Synced",type="boolean",JSONPath=".status.synced"
+// +kubebuilder:object:root=true
+
+// ExternalSync periodically loads a JSON document, such as a CMDB export or an allowlist,
+// into data.inventory.external[<name>], so policies can read it like synced objects
+type ExternalSync struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ExternalSyncSpec   `json:"spec,omitempty"`
+	Status ExternalSyncStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ExternalSyncList contains a list of ExternalSync
+type ExternalSyncList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ExternalSync `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ExternalSync{}, &ExternalSyncList{})
+}
@@ -0,0 +1,68 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GatekeeperClusterStatusStatus summarizes the policy posture of a single cluster
+type GatekeeperClusterStatusStatus struct {
+	// Name of the cluster reporting this status
+	ClusterName string `json:"clusterName,omitempty"`
+	// When the reporting cluster last pushed this status
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+	// Sum of the violations reported by audit across all constraints
+	TotalViolations int64 `json:"totalViolations,omitempty"`
+	// Constraints present on the reporting cluster
+	Constraints []ConstraintSummary `json:"constraints,omitempty"`
+}
+
+// ConstraintSummary is the audit result of a single constraint
+type ConstraintSummary struct {
+	Kind              string `json:"kind,omitempty"`
+	Name              string `json:"name,omitempty"`
+	EnforcementAction string `json:"enforcementAction,omitempty"`
+	TotalViolations   int64  `json:"totalViolations,omitempty"`
+	AuditTimestamp    string `json:"auditTimestamp,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:object:root=true
+
+// GatekeeperClusterStatus is written to a hub cluster by Gatekeeper agents running in member
+// clusters. There is one object per member cluster.
+type GatekeeperClusterStatus struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status GatekeeperClusterStatusStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// GatekeeperClusterStatusList contains a list of GatekeeperClusterStatus
+type GatekeeperClusterStatusList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GatekeeperClusterStatus `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GatekeeperClusterStatus{}, &GatekeeperClusterStatusList{})
+}
@@ -0,0 +1,78 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GatekeeperReportStatus describes the health of a single Gatekeeper pod
+type GatekeeperReportStatus struct {
+	// Namespace of the reporting pod
+	PodNamespace string `json:"podNamespace,omitempty"`
+	// Gatekeeper version the pod runs
+	Version string `json:"version,omitempty"`
+	// Git commit the pod's binary was built from
+	GitCommit string `json:"gitCommit,omitempty"`
+	// Feature gates enabled on the pod
+	FeatureGates []string `json:"featureGates,omitempty"`
+	// Constraint templates the pod has loaded into OPA
+	Templates int64 `json:"templates"`
+	// Constraint templates the pod failed to load
+	TemplateErrors int64 `json:"templateErrors"`
+	// Constraints the pod enforces
+	Constraints int64 `json:"constraints"`
+	// When the pod last finished an audit. Unset if the pod does not run audit
+	LastAudit *metav1.Time `json:"lastAudit,omitempty"`
+	// When the pod's webhook serving certificate expires
+	WebhookCertExpiry *metav1.Time `json:"webhookCertExpiry,omitempty"`
+	// When the pod last wrote this report
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".status.version"
+// +kubebuilder:printcolumn:name="Templates",type="integer",JSONPath=".status.templates"
+// +kubebuilder:printcolumn:name="Constraints",type="integer",JSONPath=".status.constraints"
+// +kubebuilder:printcolumn:name="Last Audit",type="date",JSONPath=".status.lastAudit"
+// +kubebuilder:printcolumn:name="Cert Expiry",type="date",JSONPath=".status.webhookCertExpiry"
+// +kubebuilder:printcolumn:name="Updated",type="date",JSONPath=".status.lastUpdated"
+// +kubebuilder:object:root=true
+
+// GatekeeperReport is written by each Gatekeeper pod and is named after it, so the health
+// of every pod can be checked with kubectl get gatekeeperreports
+type GatekeeperReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status GatekeeperReportStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// GatekeeperReportList contains a list of GatekeeperReport
+type GatekeeperReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GatekeeperReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GatekeeperReport{}, &GatekeeperReportList{})
+}
@@ -0,0 +1,101 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// PolicyTestSpec evaluates sample objects against a ConstraintTemplate in the cluster
+type PolicyTestSpec struct {
+	// Name of the ConstraintTemplate under test
+	Template string `json:"template"`
+	// Constraint of the template's kind that the cases are reviewed against. It is not
+	// created in the cluster.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Constraint runtime.RawExtension `json:"constraint"`
+	Cases      []PolicyTestCase     `json:"cases"`
+}
+
+// PolicyTestCase reviews an object as if it were being created
+type PolicyTestCase struct {
+	Name string `json:"name"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Object     runtime.RawExtension  `json:"object"`
+	Assertions []PolicyTestAssertion `json:"assertions"`
+}
+
+// PolicyTestAssertion is an expectation about the violations of a case
+type PolicyTestAssertion struct {
+	// Violations is "yes", "no" or the exact number of violations. Defaults to "yes"
+	Violations *intstr.IntOrString `json:"violations,omitempty"`
+	// Message is a regular expression. If set, only violations with a matching message
+	// are counted
+	Message string `json:"message,omitempty"`
+}
+
+// PolicyTestStatus defines the observed state of PolicyTest
+type PolicyTestStatus struct {
+	// Passed is true if every case passed
+	Passed bool `json:"passed"`
+	// The generation of the PolicyTest that was run
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The generation of the ConstraintTemplate that was tested
+	TemplateGeneration int64 `json:"templateGeneration,omitempty"`
+	// Error is set if the template or constraint could not be loaded
+	Error   string             `json:"error,omitempty"`
+	Results []PolicyTestResult `json:"results,omitempty"`
+}
+
+// PolicyTestResult is the outcome of one case
+type PolicyTestResult struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Template",type="string",JSONPath=".spec.template"
+// +kubebuilder:printcolumn:name="Passed",type="boolean",JSONPath=".status.passed"
+// +kubebuilder:object:root=true
+
+// PolicyTest is run by Gatekeeper whenever it or its template changes, so templates can
+// be verified continuously, for example after upgrades
+type PolicyTest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PolicyTestSpec   `json:"spec,omitempty"`
+	Status PolicyTestStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PolicyTestList contains a list of PolicyTest
+type PolicyTestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PolicyTest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PolicyTest{}, &PolicyTestList{})
+}
@@ -20,9 +20,129 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditReport) DeepCopyInto(out *AuditReport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditReport.
+func (in *AuditReport) DeepCopy() *AuditReport {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AuditReport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditReportList) DeepCopyInto(out *AuditReportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AuditReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditReportList.
+func (in *AuditReportList) DeepCopy() *AuditReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AuditReportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditReportSpec) DeepCopyInto(out *AuditReportSpec) {
+	*out = *in
+	if in.Kinds != nil {
+		in, out := &in.Kinds, &out.Kinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditReportSpec.
+func (in *AuditReportSpec) DeepCopy() *AuditReportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditReportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditReportStatus) DeepCopyInto(out *AuditReportStatus) {
+	*out = *in
+	if in.AuditTimestamp != nil {
+		in, out := &in.AuditTimestamp, &out.AuditTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.Violations != nil {
+		in, out := &in.Violations, &out.Violations
+		*out = make([]AuditReportViolation, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditReportStatus.
+func (in *AuditReportStatus) DeepCopy() *AuditReportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditReportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditReportViolation) DeepCopyInto(out *AuditReportViolation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditReportViolation.
+func (in *AuditReportViolation) DeepCopy() *AuditReportViolation {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditReportViolation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Config) DeepCopyInto(out *Config) {
 	*out = *in
@@ -87,6 +207,7 @@ func (in *ConfigSpec) DeepCopyInto(out *ConfigSpec) {
 	*out = *in
 	in.Sync.DeepCopyInto(&out.Sync)
 	in.Validation.DeepCopyInto(&out.Validation)
+	out.SeverityActions = in.SeverityActions
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigSpec.
@@ -115,16 +236,863 @@ func (in *ConfigStatus) DeepCopy() *ConfigStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GVK) DeepCopyInto(out *GVK) {
+func (in *ConstraintSet) DeepCopyInto(out *ConstraintSet) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GVK.
-func (in *GVK) DeepCopy() *GVK {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConstraintSet.
+func (in *ConstraintSet) DeepCopy() *ConstraintSet {
 	if in == nil {
 		return nil
 	}
-	out := new(GVK)
+	out := new(ConstraintSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConstraintSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConstraintSetError) DeepCopyInto(out *ConstraintSetError) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConstraintSetError.
+func (in *ConstraintSetError) DeepCopy() *ConstraintSetError {
+	if in == nil {
+		return nil
+	}
+	out := new(ConstraintSetError)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConstraintSetList) DeepCopyInto(out *ConstraintSetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ConstraintSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConstraintSetList.
+func (in *ConstraintSetList) DeepCopy() *ConstraintSetList {
+	if in == nil {
+		return nil
+	}
+	out := new(ConstraintSetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ConstraintSetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConstraintSetMember) DeepCopyInto(out *ConstraintSetMember) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConstraintSetMember.
+func (in *ConstraintSetMember) DeepCopy() *ConstraintSetMember {
+	if in == nil {
+		return nil
+	}
+	out := new(ConstraintSetMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConstraintSetSpec) DeepCopyInto(out *ConstraintSetSpec) {
+	*out = *in
+	if in.Match != nil {
+		in, out := &in.Match, &out.Match
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Constraints != nil {
+		in, out := &in.Constraints, &out.Constraints
+		*out = make([]ConstraintSetMember, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConstraintSetSpec.
+func (in *ConstraintSetSpec) DeepCopy() *ConstraintSetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConstraintSetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConstraintSetStatus) DeepCopyInto(out *ConstraintSetStatus) {
+	*out = *in
+	if in.Constraints != nil {
+		in, out := &in.Constraints, &out.Constraints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]ConstraintSetError, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConstraintSetStatus.
+func (in *ConstraintSetStatus) DeepCopy() *ConstraintSetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConstraintSetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CoverageGap) DeepCopyInto(out *CoverageGap) {
+	*out = *in
+	if in.Kinds != nil {
+		in, out := &in.Kinds, &out.Kinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CoverageGap.
+func (in *CoverageGap) DeepCopy() *CoverageGap {
+	if in == nil {
+		return nil
+	}
+	out := new(CoverageGap)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CoverageReport) DeepCopyInto(out *CoverageReport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CoverageReport.
+func (in *CoverageReport) DeepCopy() *CoverageReport {
+	if in == nil {
+		return nil
+	}
+	out := new(CoverageReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CoverageReport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CoverageReportList) DeepCopyInto(out *CoverageReportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CoverageReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CoverageReportList.
+func (in *CoverageReportList) DeepCopy() *CoverageReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(CoverageReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CoverageReportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CoverageReportStatus) DeepCopyInto(out *CoverageReportStatus) {
+	*out = *in
+	if in.AuditTimestamp != nil {
+		in, out := &in.AuditTimestamp, &out.AuditTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.Kinds != nil {
+		in, out := &in.Kinds, &out.Kinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UncoveredNamespaces != nil {
+		in, out := &in.UncoveredNamespaces, &out.UncoveredNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UncoveredKinds != nil {
+		in, out := &in.UncoveredKinds, &out.UncoveredKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Gaps != nil {
+		in, out := &in.Gaps, &out.Gaps
+		*out = make([]CoverageGap, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CoverageReportStatus.
+func (in *CoverageReportStatus) DeepCopy() *CoverageReportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CoverageReportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConstraintSummary) DeepCopyInto(out *ConstraintSummary) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConstraintSummary.
+func (in *ConstraintSummary) DeepCopy() *ConstraintSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(ConstraintSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Exemption) DeepCopyInto(out *Exemption) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Exemption.
+func (in *Exemption) DeepCopy() *Exemption {
+	if in == nil {
+		return nil
+	}
+	out := new(Exemption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Exemption) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExemptionList) DeepCopyInto(out *ExemptionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Exemption, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExemptionList.
+func (in *ExemptionList) DeepCopy() *ExemptionList {
+	if in == nil {
+		return nil
+	}
+	out := new(ExemptionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExemptionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExemptionMatch) DeepCopyInto(out *ExemptionMatch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExemptionMatch.
+func (in *ExemptionMatch) DeepCopy() *ExemptionMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(ExemptionMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExemptionSpec) DeepCopyInto(out *ExemptionSpec) {
+	*out = *in
+	out.Match = in.Match
+	in.ExpiresAt.DeepCopyInto(&out.ExpiresAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExemptionSpec.
+func (in *ExemptionSpec) DeepCopy() *ExemptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExemptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExemptionStatus) DeepCopyInto(out *ExemptionStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExemptionStatus.
+func (in *ExemptionStatus) DeepCopy() *ExemptionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ExemptionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalSync) DeepCopyInto(out *ExternalSync) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSync.
+func (in *ExternalSync) DeepCopy() *ExternalSync {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalSync)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExternalSync) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalSyncAuth) DeepCopyInto(out *ExternalSyncAuth) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSyncAuth.
+func (in *ExternalSyncAuth) DeepCopy() *ExternalSyncAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalSyncAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalSyncList) DeepCopyInto(out *ExternalSyncList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ExternalSync, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSyncList.
+func (in *ExternalSyncList) DeepCopy() *ExternalSyncList {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalSyncList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExternalSyncList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalSyncSpec) DeepCopyInto(out *ExternalSyncSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Auth != nil {
+		in, out := &in.Auth, &out.Auth
+		*out = new(ExternalSyncAuth)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSyncSpec.
+func (in *ExternalSyncSpec) DeepCopy() *ExternalSyncSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalSyncSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalSyncStatus) DeepCopyInto(out *ExternalSyncStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSyncStatus.
+func (in *ExternalSyncStatus) DeepCopy() *ExternalSyncStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalSyncStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GVK) DeepCopyInto(out *GVK) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GVK.
+func (in *GVK) DeepCopy() *GVK {
+	if in == nil {
+		return nil
+	}
+	out := new(GVK)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatekeeperClusterStatus) DeepCopyInto(out *GatekeeperClusterStatus) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatekeeperClusterStatus.
+func (in *GatekeeperClusterStatus) DeepCopy() *GatekeeperClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GatekeeperClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GatekeeperClusterStatus) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatekeeperClusterStatusList) DeepCopyInto(out *GatekeeperClusterStatusList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GatekeeperClusterStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatekeeperClusterStatusList.
+func (in *GatekeeperClusterStatusList) DeepCopy() *GatekeeperClusterStatusList {
+	if in == nil {
+		return nil
+	}
+	out := new(GatekeeperClusterStatusList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GatekeeperClusterStatusList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatekeeperClusterStatusStatus) DeepCopyInto(out *GatekeeperClusterStatusStatus) {
+	*out = *in
+	if in.LastUpdated != nil {
+		in, out := &in.LastUpdated, &out.LastUpdated
+		*out = (*in).DeepCopy()
+	}
+	if in.Constraints != nil {
+		in, out := &in.Constraints, &out.Constraints
+		*out = make([]ConstraintSummary, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatekeeperClusterStatusStatus.
+func (in *GatekeeperClusterStatusStatus) DeepCopy() *GatekeeperClusterStatusStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GatekeeperClusterStatusStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatekeeperReport) DeepCopyInto(out *GatekeeperReport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatekeeperReport.
+func (in *GatekeeperReport) DeepCopy() *GatekeeperReport {
+	if in == nil {
+		return nil
+	}
+	out := new(GatekeeperReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GatekeeperReport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatekeeperReportList) DeepCopyInto(out *GatekeeperReportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GatekeeperReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatekeeperReportList.
+func (in *GatekeeperReportList) DeepCopy() *GatekeeperReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(GatekeeperReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GatekeeperReportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatekeeperReportStatus) DeepCopyInto(out *GatekeeperReportStatus) {
+	*out = *in
+	if in.FeatureGates != nil {
+		in, out := &in.FeatureGates, &out.FeatureGates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastAudit != nil {
+		in, out := &in.LastAudit, &out.LastAudit
+		*out = (*in).DeepCopy()
+	}
+	if in.WebhookCertExpiry != nil {
+		in, out := &in.WebhookCertExpiry, &out.WebhookCertExpiry
+		*out = (*in).DeepCopy()
+	}
+	if in.LastUpdated != nil {
+		in, out := &in.LastUpdated, &out.LastUpdated
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatekeeperReportStatus.
+func (in *GatekeeperReportStatus) DeepCopy() *GatekeeperReportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GatekeeperReportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyTest) DeepCopyInto(out *PolicyTest) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyTest.
+func (in *PolicyTest) DeepCopy() *PolicyTest {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyTest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PolicyTest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyTestAssertion) DeepCopyInto(out *PolicyTestAssertion) {
+	*out = *in
+	if in.Violations != nil {
+		in, out := &in.Violations, &out.Violations
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyTestAssertion.
+func (in *PolicyTestAssertion) DeepCopy() *PolicyTestAssertion {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyTestAssertion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyTestCase) DeepCopyInto(out *PolicyTestCase) {
+	*out = *in
+	in.Object.DeepCopyInto(&out.Object)
+	if in.Assertions != nil {
+		in, out := &in.Assertions, &out.Assertions
+		*out = make([]PolicyTestAssertion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyTestCase.
+func (in *PolicyTestCase) DeepCopy() *PolicyTestCase {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyTestCase)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyTestList) DeepCopyInto(out *PolicyTestList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PolicyTest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyTestList.
+func (in *PolicyTestList) DeepCopy() *PolicyTestList {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyTestList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PolicyTestList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyTestResult) DeepCopyInto(out *PolicyTestResult) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyTestResult.
+func (in *PolicyTestResult) DeepCopy() *PolicyTestResult {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyTestResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyTestSpec) DeepCopyInto(out *PolicyTestSpec) {
+	*out = *in
+	in.Constraint.DeepCopyInto(&out.Constraint)
+	if in.Cases != nil {
+		in, out := &in.Cases, &out.Cases
+		*out = make([]PolicyTestCase, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyTestSpec.
+func (in *PolicyTestSpec) DeepCopy() *PolicyTestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyTestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyTestStatus) DeepCopyInto(out *PolicyTestStatus) {
+	*out = *in
+	if in.Results != nil {
+		in, out := &in.Results, &out.Results
+		*out = make([]PolicyTestResult, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyTestStatus.
+func (in *PolicyTestStatus) DeepCopy() *PolicyTestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyTestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeverityActions) DeepCopyInto(out *SeverityActions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeverityActions.
+func (in *SeverityActions) DeepCopy() *SeverityActions {
+	if in == nil {
+		return nil
+	}
+	out := new(SeverityActions)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -0,0 +1,118 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+
+	"github.com/spf13/pflag"
+)
+
+// role is a part of Gatekeeper a deployment can run on its own
+type role string
+
+const (
+	roleWebhook    role = "webhook"
+	roleAudit      role = "audit"
+	roleController role = "controller"
+)
+
+var allRoles = []role{roleWebhook, roleAudit, roleController}
+
+// flagGroups lists the flags only read by each role. Flags in no group, such as logging,
+// client and policy engine flags, are common to all roles.
+var flagGroups = map[role][]string{
+	roleWebhook: {
+		"port",
+		"cleanup-on-shutdown",
+		"decision-log-path",
+		"denial-metrics-hash-usernames",
+		"denial-metrics-requester-classes",
+		"disable-cert-rotation",
+		"disable-enforcementaction-validation",
+		"enable-namespace-deletion-protection",
+		"error-budget",
+		"exempt-namespace",
+		"fail-open-on-error",
+		"log-denies",
+		"max-request-bytes",
+		"oversize-request-policy",
+		"replay-queue-size",
+		"review-api-cert-dir",
+		"review-api-client-ca",
+		"review-api-port",
+		"tls-cipher-suites",
+		"tls-min-version",
+		"webhook-client-burst",
+		"webhook-client-qps",
+	},
+	roleAudit: {
+		"audit-client-burst",
+		"audit-client-impersonate",
+		"audit-client-qps",
+		"audit-constraint-selector",
+		"audit-coverage-kinds",
+		"audit-excluded-kinds",
+		"audit-from-cache",
+		"audit-interval",
+		"audit-interval-jitter",
+		"audit-max-object-size",
+		"audit-namespace-label-changes",
+		"audit-report-violations-limit",
+		"audit-status-update-qps",
+		"audit-transition-events",
+		"auditInterval",
+		"constraint-violations-limit",
+		"constraintViolationsLimit",
+	},
+	roleController: {
+		"default-policies",
+		"hub-cluster-name",
+		"hub-kubeconfig",
+		"hub-push-interval",
+		"pod-security-standards",
+		"self-protection-admin-groups",
+		"self-protection-admin-users",
+		"self-protection-pdb-selector",
+		"templates-path",
+	},
+}
+
+// addFlags adds the flags of roles to fs, or the common flags if roles is empty. The
+// flags stay registered with the flag package, so packages keep reading their own.
+func addFlags(fs *pflag.FlagSet, roles ...role) {
+	grouped := make(map[string]bool)
+	for _, names := range flagGroups {
+		for _, name := range names {
+			grouped[name] = true
+		}
+	}
+	if len(roles) == 0 {
+		flag.CommandLine.VisitAll(func(f *flag.Flag) {
+			if !grouped[f.Name] {
+				fs.AddGoFlag(f)
+			}
+		})
+		return
+	}
+	for _, r := range roles {
+		for _, name := range flagGroups[r] {
+			if f := flag.Lookup(name); f != nil {
+				fs.AddGoFlag(f)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestFlagGroups(t *testing.T) {
+	seen := make(map[string]role)
+	for r, names := range flagGroups {
+		for _, name := range names {
+			if flag.Lookup(name) == nil {
+				t.Errorf("%s flag %s does not exist", r, name)
+			}
+			if other, ok := seen[name]; ok {
+				t.Errorf("flag %s is in groups %s and %s", name, r, other)
+			}
+			seen[name] = r
+		}
+	}
+
+	common := pflag.NewFlagSet("common", pflag.ContinueOnError)
+	addFlags(common)
+	if common.Lookup("log-level") == nil || common.Lookup("feature-gates") == nil {
+		t.Error("common flags are missing log-level or feature-gates")
+	}
+	if common.Lookup("port") != nil {
+		t.Error("webhook flag port is common")
+	}
+	audit := pflag.NewFlagSet("audit", pflag.ContinueOnError)
+	addFlags(audit, roleAudit)
+	if audit.Lookup("audit-interval") == nil || audit.Lookup("port") != nil {
+		t.Error("audit flags should have audit-interval and not port")
+	}
+}
+
+func TestRootCommandFlags(t *testing.T) {
+	root := newRootCommand()
+	// the root command runs every role, so it takes the flags of every role
+	for _, name := range []string{"port", "audit-interval", "templates-path", "log-level"} {
+		if root.Flags().Lookup(name) == nil && root.PersistentFlags().Lookup(name) == nil {
+			t.Errorf("root command does not take --%s", name)
+		}
+	}
+	cmd, _, err := root.Find([]string{"webhook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.ParseFlags([]string{"--port=8443", "--log-level=DEBUG"}); err != nil {
+		t.Errorf("webhook command rejected its flags: %v", err)
+	}
+	if err := cmd.ParseFlags([]string{"--audit-interval=30"}); err == nil {
+		t.Error("webhook command accepted an audit flag")
+	}
+}
@@ -17,12 +17,18 @@ require (
 	github.com/open-policy-agent/frameworks/constraint v0.0.0-20200205035609-98dd039a55b1
 	github.com/open-policy-agent/opa v0.16.2
 	github.com/pkg/errors v0.8.1
+	github.com/prometheus/client_golang v0.9.2
+	github.com/spf13/cobra v0.0.5
+	github.com/spf13/pflag v1.0.3
 	go.opencensus.io v0.22.2
 	go.uber.org/zap v1.10.0
 	golang.org/x/net v0.0.0-20190827160401-ba9fcec4b297
+	golang.org/x/time v0.0.0-20181108054448-85acf8d2951c
 	k8s.io/api v0.16.4
 	k8s.io/apiextensions-apiserver v0.16.4
 	k8s.io/apimachinery v0.16.4
 	k8s.io/client-go v0.16.4
+	k8s.io/component-base v0.16.4
 	sigs.k8s.io/controller-runtime v0.4.0
+	sigs.k8s.io/yaml v1.1.0
 )
@@ -16,24 +16,43 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
+	"io"
 	"os"
 	"time"
 
 	"github.com/go-logr/zapr"
-	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/local"
 	"github.com/open-policy-agent/gatekeeper/api"
 	configv1alpha1 "github.com/open-policy-agent/gatekeeper/api/v1alpha1"
 	"github.com/open-policy-agent/gatekeeper/pkg/audit"
+	"github.com/open-policy-agent/gatekeeper/pkg/bench"
+	"github.com/open-policy-agent/gatekeeper/pkg/bundle"
 	"github.com/open-policy-agent/gatekeeper/pkg/controller"
 	configController "github.com/open-policy-agent/gatekeeper/pkg/controller/config"
+	"github.com/open-policy-agent/gatekeeper/pkg/controller/constraint"
+	"github.com/open-policy-agent/gatekeeper/pkg/controller/constraintset"
 	"github.com/open-policy-agent/gatekeeper/pkg/controller/constrainttemplate"
+	gkdriver "github.com/open-policy-agent/gatekeeper/pkg/driver"
+	"github.com/open-policy-agent/gatekeeper/pkg/engine"
+	"github.com/open-policy-agent/gatekeeper/pkg/externaldata"
+	"github.com/open-policy-agent/gatekeeper/pkg/featuregate"
+	"github.com/open-policy-agent/gatekeeper/pkg/hub"
+	"github.com/open-policy-agent/gatekeeper/pkg/lint"
+	"github.com/open-policy-agent/gatekeeper/pkg/match"
 	"github.com/open-policy-agent/gatekeeper/pkg/metrics"
-	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	"github.com/open-policy-agent/gatekeeper/pkg/readiness"
+	"github.com/open-policy-agent/gatekeeper/pkg/replay"
+	"github.com/open-policy-agent/gatekeeper/pkg/report"
+	"github.com/open-policy-agent/gatekeeper/pkg/selfprotection"
 	"github.com/open-policy-agent/gatekeeper/pkg/upgrade"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	"github.com/open-policy-agent/gatekeeper/pkg/verify"
 	"github.com/open-policy-agent/gatekeeper/pkg/watch"
 	"github.com/open-policy-agent/gatekeeper/pkg/webhook"
+	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -57,8 +76,26 @@ var (
 	metricsAddr = flag.String("metrics-addr", "0", "The address the metric endpoint binds to.")
 	port        = flag.Int("port", 443, "port for the server. defaulted to 443 if unspecified ")
 	certDir     = flag.String("cert-dir", "/certs", "The directory where certs are stored, defaults to /certs")
+
+	controllerClientQPS   = flag.Float64("controller-client-qps", 0, "maximum number of requests per second the controllers send to the API server. The webhook and audit clients keep this limit unless their own flags are set. 0 keeps the client-go default")
+	controllerClientBurst = flag.Int("controller-client-burst", 0, "maximum burst of controller requests above --controller-client-qps. Defaults to --controller-client-qps rounded up")
 )
 
+// tools are run as commands of their own, parsing their own flags
+var tools = []struct {
+	name  string
+	short string
+	run   func(args []string, out io.Writer) error
+}{
+	{bench.Command, "Benchmark the evaluation of templates against resources", bench.Run},
+	{bundle.ExportCommand, "Export the templates and constraints of a cluster as a bundle", bundle.RunExport},
+	{bundle.ApplyCommand, "Apply a bundle of templates and constraints to a cluster", bundle.RunApply},
+	{lint.Command, "Lint constraint templates", lint.Run},
+	{match.Command, "List the constraints that match a kind of object in a namespace", match.Run},
+	{verify.Command, "Run the test suites of constraint templates", verify.Run},
+	{webhook.CleanupCommand, "Clean up the webhook configuration before Gatekeeper is deleted", webhook.RunCleanup},
+}
+
 func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
 
@@ -69,7 +106,74 @@ func init() {
 }
 
 func main() {
-	flag.Parse()
+	if err := newRootCommand().Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// newRootCommand returns the gatekeeper command. Run without a subcommand it runs every
+// role, so existing deployments keep working. Each role subcommand only runs, and only
+// takes the flags of, its own role.
+func newRootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:           "gatekeeper",
+		Short:         "Gatekeeper enforces and audits policies on Kubernetes resources",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// the flags were parsed by cobra; mark them parsed for the packages that check
+			return flag.CommandLine.Parse(nil)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			run(allRoles...)
+		},
+	}
+	addFlags(root.PersistentFlags())
+	addFlags(root.Flags(), allRoles...)
+
+	descriptions := map[role]string{
+		roleWebhook:    "Serve the admission webhook",
+		roleAudit:      "Periodically audit existing resources against constraints",
+		roleController: "Run the singleton tasks: startup policies, constraint sets, upgrades and the hub agent",
+	}
+	for _, r := range allRoles {
+		r := r
+		cmd := &cobra.Command{
+			Use:   string(r),
+			Short: descriptions[r],
+			Args:  cobra.NoArgs,
+			Run: func(cmd *cobra.Command, args []string) {
+				run(r)
+			},
+		}
+		addFlags(cmd.Flags(), r)
+		root.AddCommand(cmd)
+	}
+
+	for _, tool := range tools {
+		tool := tool
+		root.AddCommand(&cobra.Command{
+			Use:                tool.name,
+			Short:              tool.short,
+			DisableFlagParsing: true,
+			PersistentPreRunE:  func(cmd *cobra.Command, args []string) error { return nil },
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return tool.run(args, os.Stdout)
+			},
+		})
+	}
+	return root
+}
+
+// run runs the manager with roles. The policy controllers, readiness checks and pod
+// reports run in every role, as every role evaluates policies.
+func run(roles ...role) {
+	runs := make(map[role]bool)
+	for _, r := range roles {
+		runs[r] = true
+	}
 
 	switch *logLevel {
 	case "DEBUG":
@@ -82,13 +186,14 @@ func main() {
 		ctrl.SetLogger(crzap.Logger(false))
 	}
 	ctrl.SetLogger(crzap.Logger(true))
+	setupLog.Info("feature gates", "enabled", featuregate.EnabledGates())
+	setupLog.Info("roles", "running", roles)
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	cfg := util.ClientConfig(ctrl.GetConfigOrDie(), "", *controllerClientQPS, *controllerClientBurst)
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     *metricsAddr,
 		LeaderElection:         false,
-		Port:                   *port,
-		CertDir:                *certDir,
 		HealthProbeBindAddress: *healthAddr,
 	})
 	if err != nil {
@@ -96,16 +201,26 @@ func main() {
 		os.Exit(1)
 	}
 
+	// external_data must be registered before templates are compiled
+	if featuregate.Enabled(featuregate.ExternalData) {
+		setupLog.Info("setting up external data")
+		if err := externaldata.Setup(); err != nil {
+			setupLog.Error(err, "unable to set up external data")
+			os.Exit(1)
+		}
+	}
+
 	// initialize OPA
-	driver := local.New(local.Tracing(false))
-	backend, err := opa.NewBackend(opa.Driver(driver))
+	driver := gkdriver.Wrap(local.New(local.Tracing(false)))
+	client, err := engine.NewClient(driver)
 	if err != nil {
-		setupLog.Error(err, "unable to set up OPA backend")
+		setupLog.Error(err, "unable to set up OPA client")
 		os.Exit(1)
 	}
-	client, err := backend.NewClient(opa.Targets(&target.K8sValidationTarget{}))
+	queries, err := gkdriver.NewQueries(context.Background(), driver)
 	if err != nil {
-		setupLog.Error(err, "unable to set up OPA client")
+		setupLog.Error(err, "unable to set up OPA queries")
+		os.Exit(1)
 	}
 
 	wm, err := watch.New(mgr.GetConfig())
@@ -118,28 +233,80 @@ func main() {
 		os.Exit(1)
 	}
 
+	// every role runs the constraint controllers, but one role reports rollouts
+	constraint.RecordRollouts = runs[roleController]
+
 	// Setup all Controllers
 	setupLog.Info("Setting up controller")
-	if err := controller.AddToManager(mgr, client, wm); err != nil {
+	if err := controller.AddToManager(mgr, client, queries, wm); err != nil {
 		setupLog.Error(err, "unable to register controllers to the manager")
 		os.Exit(1)
 	}
 
-	setupLog.Info("setting up webhooks")
-	if err := webhook.AddToManager(mgr, client); err != nil {
-		setupLog.Error(err, "unable to register webhooks to the manager")
-		os.Exit(1)
+	// the replay queue and the violation feed are held in memory, so they only connect the
+	// webhook and audit running in the same process
+	if !runs[roleWebhook] || !runs[roleAudit] {
+		setupLog.Info("webhook and audit run separately: replay is disabled and the violation stream only carries admission violations")
+		replay.Pending = nil
+	}
+
+	if runs[roleWebhook] {
+		setupLog.Info("setting up webhooks")
+		if err := webhook.AddToManager(mgr, client, queries, *port, *certDir); err != nil {
+			setupLog.Error(err, "unable to register webhooks to the manager")
+			os.Exit(1)
+		}
 	}
 
-	setupLog.Info("setting up audit")
-	if err := audit.AddToManager(mgr, client); err != nil {
-		setupLog.Error(err, "unable to register audit to the manager")
+	if runs[roleAudit] {
+		setupLog.Info("setting up audit")
+		if err := audit.AddToManager(mgr, client); err != nil {
+			setupLog.Error(err, "unable to register audit to the manager")
+			os.Exit(1)
+		}
+	}
+
+	if runs[roleController] {
+		setupLog.Info("setting up hub agent")
+		if err := hub.AddToManager(mgr); err != nil {
+			setupLog.Error(err, "unable to register hub agent to the manager")
+			os.Exit(1)
+		}
+
+		setupLog.Info("setting up upgrade")
+		if err := upgrade.AddToManager(mgr); err != nil {
+			setupLog.Error(err, "unable to register upgrade to the manager")
+			os.Exit(1)
+		}
+
+		setupLog.Info("setting up startup policies")
+		if err := bundle.AddToManager(mgr); err != nil {
+			setupLog.Error(err, "unable to register startup policies to the manager")
+			os.Exit(1)
+		}
+
+		setupLog.Info("setting up constraint sets")
+		if err := constraintset.AddToManager(mgr); err != nil {
+			setupLog.Error(err, "unable to register constraint sets to the manager")
+			os.Exit(1)
+		}
+
+		setupLog.Info("setting up self-protection")
+		if err := selfprotection.AddToManager(mgr); err != nil {
+			setupLog.Error(err, "unable to register self-protection to the manager")
+			os.Exit(1)
+		}
+	}
+
+	setupLog.Info("setting up GatekeeperReport")
+	if err := report.AddToManager(mgr, *certDir); err != nil {
+		setupLog.Error(err, "unable to register GatekeeperReport to the manager")
 		os.Exit(1)
 	}
 
-	setupLog.Info("setting up upgrade")
-	if err := upgrade.AddToManager(mgr); err != nil {
-		setupLog.Error(err, "unable to register upgrade to the manager")
+	setupLog.Info("setting up startup readiness")
+	if err := readiness.AddToManager(mgr); err != nil {
+		setupLog.Error(err, "unable to register startup readiness to the manager")
 		os.Exit(1)
 	}
 
@@ -193,6 +360,11 @@ func main() {
 	<-syncCleaned
 	<-templatesCleaned
 	setupLog.Info("state cleaned")
+	if runs[roleWebhook] {
+		if err := webhook.CleanupOnShutdown(cli); err != nil {
+			setupLog.Error(err, "unable to clean up the webhook on shutdown")
+		}
+	}
 	if hadError {
 		/// give the cert manager time to generate the cert
 		time.Sleep(5 * time.Second)
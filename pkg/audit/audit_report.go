@@ -0,0 +1,157 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"flag"
+	"sort"
+	"time"
+
+	configv1alpha1 "github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var auditReportViolationsLimit = flag.Int("audit-report-violations-limit", 100, "limit of number of violations listed in the status of an AuditReport")
+
+// +kubebuilder:rbac:groups=config.gatekeeper.sh,resources=auditreports/status,verbs=get;update;patch
+
+type namespaceAuditor interface {
+	auditNamespace(ctx context.Context, namespace string, kinds []string) ([]configv1alpha1.AuditReportViolation, int64, error)
+}
+
+// auditReportReconciler audits the namespace of each AuditReport once per generation
+type auditReportReconciler struct {
+	client  client.Client
+	auditor namespaceAuditor
+}
+
+func addAuditReportController(mgr manager.Manager, auditor namespaceAuditor) error {
+	r := &auditReportReconciler{client: mgr.GetClient(), auditor: auditor}
+	c, err := controller.New("audit-report-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	return c.Watch(&source.Kind{Type: &configv1alpha1.AuditReport{}}, &handler.EnqueueRequestForObject{})
+}
+
+func (r *auditReportReconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx := context.TODO()
+	report := &configv1alpha1.AuditReport{}
+	if err := r.client.Get(ctx, request.NamespacedName, report); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	status := &report.Status
+	if status.ObservedGeneration == report.GetGeneration() && status.Phase != configv1alpha1.AuditReportPending && status.Phase != "" {
+		return reconcile.Result{}, nil
+	}
+
+	if status.Phase != configv1alpha1.AuditReportPending {
+		status.Phase = configv1alpha1.AuditReportPending
+		if err := r.client.Status().Update(ctx, report); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	start := metav1.Now()
+	log.Info("auditing namespace for audit report", logging.ResourceNamespace, report.GetNamespace(), logging.ResourceName, report.GetName())
+	violations, total, err := r.auditor.auditNamespace(ctx, report.GetNamespace(), report.Spec.Kinds)
+	*status = configv1alpha1.AuditReportStatus{
+		Phase:              configv1alpha1.AuditReportCompleted,
+		ObservedGeneration: report.GetGeneration(),
+		AuditTimestamp:     &start,
+		TotalViolations:    total,
+		Violations:         violations,
+	}
+	if err != nil {
+		// the violations of the objects that were reviewed are still reported
+		status.Phase = configv1alpha1.AuditReportFailed
+		status.Error = err.Error()
+	}
+	if err := r.client.Status().Update(ctx, report); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+// auditNamespace reviews the objects of namespace, of kinds if it is not empty, and
+// returns up to --audit-report-violations-limit violations along with their total
+func (am *Manager) auditNamespace(ctx context.Context, namespace string, kinds []string) ([]configv1alpha1.AuditReportViolation, int64, error) {
+	c, err := client.New(am.restConfig, client.Options{Scheme: am.mgr.GetScheme(), Mapper: nil})
+	if err != nil {
+		return nil, 0, err
+	}
+	l := log.WithValues(logging.EventType, "audit_report", logging.ResourceNamespace, namespace)
+	kindSet := make(map[string]bool, len(kinds))
+	for _, k := range kinds {
+		kindSet[k] = true
+	}
+	res, _, reviewErr := am.reviewResources(ctx, c, l, namespace, kindSet, nil)
+
+	var violations []configv1alpha1.AuditReportViolation
+	for _, r := range am.dropExempt(ctx, c, l, namespace, res, time.Now()) {
+		if !am.selects(r.Constraint) {
+			continue
+		}
+		resource := r.Resource.(*unstructured.Unstructured)
+		violations = append(violations, configv1alpha1.AuditReportViolation{
+			ConstraintKind:    r.Constraint.GetKind(),
+			ConstraintName:    r.Constraint.GetName(),
+			ConstraintOwner:   util.GetOwner(r.Constraint),
+			Kind:              resource.GetKind(),
+			Name:              resource.GetName(),
+			Message:           truncateString(r.Msg, msgSize),
+			EnforcementAction: r.EnforcementAction,
+		})
+	}
+	total := int64(len(violations))
+	sortAuditReportViolations(violations)
+	if len(violations) > *auditReportViolationsLimit {
+		violations = violations[:*auditReportViolationsLimit]
+	}
+	l.Info("audited namespace for audit report", "violations", total)
+	return violations, total, reviewErr
+}
+
+// sortAuditReportViolations orders violations by object, then by constraint, so the
+// status does not change between identical runs
+func sortAuditReportViolations(violations []configv1alpha1.AuditReportViolation) {
+	sort.Slice(violations, func(i, j int) bool {
+		a, b := violations[i], violations[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.ConstraintKind != b.ConstraintKind {
+			return a.ConstraintKind < b.ConstraintKind
+		}
+		return a.ConstraintName < b.ConstraintName
+	})
+}
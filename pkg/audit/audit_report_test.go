@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/open-policy-agent/gatekeeper/api"
+	configv1alpha1 "github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type fakeNamespaceAuditor struct {
+	namespaces []string
+	kinds      []string
+	violations []configv1alpha1.AuditReportViolation
+	total      int64
+	err        error
+}
+
+func (f *fakeNamespaceAuditor) auditNamespace(_ context.Context, namespace string, kinds []string) ([]configv1alpha1.AuditReportViolation, int64, error) {
+	f.namespaces = append(f.namespaces, namespace)
+	f.kinds = kinds
+	return f.violations, f.total, f.err
+}
+
+func TestAuditReportReconciler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	key := types.NamespacedName{Namespace: "team-a", Name: "cutover"}
+	report := &configv1alpha1.AuditReport{
+		ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name, Generation: 1},
+		Spec:       configv1alpha1.AuditReportSpec{Kinds: []string{"Deployment"}},
+	}
+	c := fake.NewFakeClientWithScheme(scheme, report)
+	auditor := &fakeNamespaceAuditor{
+		violations: []configv1alpha1.AuditReportViolation{{ConstraintKind: "K8sRequiredLabels", ConstraintName: "owner", Kind: "Deployment", Name: "web", Message: "missing owner", EnforcementAction: "deny"}},
+		total:      3,
+	}
+	r := &auditReportReconciler{client: c, auditor: auditor}
+	req := reconcile.Request{NamespacedName: key}
+
+	reconcileAndGet := func() *configv1alpha1.AuditReport {
+		t.Helper()
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatal(err)
+		}
+		got := &configv1alpha1.AuditReport{}
+		if err := c.Get(context.TODO(), key, got); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	got := reconcileAndGet()
+	if len(auditor.namespaces) != 1 || auditor.namespaces[0] != "team-a" || len(auditor.kinds) != 1 {
+		t.Fatalf("audited namespaces %v with kinds %v, wanted team-a with Deployment", auditor.namespaces, auditor.kinds)
+	}
+	if got.Status.Phase != configv1alpha1.AuditReportCompleted || got.Status.ObservedGeneration != 1 {
+		t.Errorf("phase %q at generation %d, wanted Completed at 1", got.Status.Phase, got.Status.ObservedGeneration)
+	}
+	if got.Status.TotalViolations != 3 || len(got.Status.Violations) != 1 || got.Status.AuditTimestamp == nil {
+		t.Errorf("unexpected status %+v", got.Status)
+	}
+
+	// the same generation is audited only once
+	reconcileAndGet()
+	if len(auditor.namespaces) != 1 {
+		t.Errorf("audited %d times, wanted 1", len(auditor.namespaces))
+	}
+
+	got.SetGeneration(2)
+	if err := c.Update(context.TODO(), got); err != nil {
+		t.Fatal(err)
+	}
+	auditor.violations, auditor.total, auditor.err = nil, 0, errors.New("discovery failed")
+	got = reconcileAndGet()
+	if len(auditor.namespaces) != 2 {
+		t.Errorf("audited %d times, wanted 2", len(auditor.namespaces))
+	}
+	if got.Status.Phase != configv1alpha1.AuditReportFailed || got.Status.Error != "discovery failed" || got.Status.ObservedGeneration != 2 {
+		t.Errorf("unexpected status %+v", got.Status)
+	}
+	if len(got.Status.Violations) != 0 || got.Status.TotalViolations != 0 {
+		t.Errorf("violations of the previous run were kept: %+v", got.Status)
+	}
+}
@@ -0,0 +1,45 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"flag"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const auditUserAgent = "gatekeeper-audit"
+
+var (
+	auditClientQPS         = flag.Float64("audit-client-qps", 0, "maximum number of requests per second audit sends to the API server, shared by its discovery, LIST and status update calls. 0 keeps the limit of the controller client")
+	auditClientBurst       = flag.Int("audit-client-burst", 0, "maximum burst of audit requests above --audit-client-qps. Defaults to --audit-client-qps rounded up")
+	auditClientImpersonate = flag.String("audit-client-impersonate", "", "user audit requests impersonate, so an API Priority and Fairness FlowSchema can give them a lower priority level than the webhook and controllers. Requires permission to impersonate the user")
+)
+
+// auditRestConfig returns a copy of cfg for the requests audit sends. All clients built
+// from it share one rate limiter, so the limit applies to the whole audit run.
+func auditRestConfig(cfg *rest.Config) *rest.Config {
+	c := util.ClientConfig(cfg, auditUserAgent, *auditClientQPS, *auditClientBurst)
+	if *auditClientQPS > 0 {
+		c.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(c.QPS, c.Burst)
+	}
+	if *auditClientImpersonate != "" {
+		c.Impersonate = rest.ImpersonationConfig{UserName: *auditClientImpersonate}
+	}
+	return c
+}
@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestAuditRestConfig(t *testing.T) {
+	defer func(qps float64, burst int, user string) {
+		*auditClientQPS, *auditClientBurst, *auditClientImpersonate = qps, burst, user
+	}(*auditClientQPS, *auditClientBurst, *auditClientImpersonate)
+
+	tc := []struct {
+		Name        string
+		QPS         float64
+		Burst       int
+		Impersonate string
+		WantQPS     float32
+		WantBurst   int
+		WantLimiter bool
+	}{
+		{Name: "Defaults", WantQPS: 20, WantBurst: 30},
+		{Name: "QPS only", QPS: 2.5, WantQPS: 2.5, WantBurst: 3, WantLimiter: true},
+		{Name: "QPS and burst", QPS: 2, Burst: 10, WantQPS: 2, WantBurst: 10, WantLimiter: true},
+		{Name: "Impersonate", Impersonate: "gatekeeper-audit", WantQPS: 20, WantBurst: 30},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			*auditClientQPS, *auditClientBurst, *auditClientImpersonate = tt.QPS, tt.Burst, tt.Impersonate
+			mgrConfig := &rest.Config{Host: "https://example.com", QPS: 20, Burst: 30}
+			c := auditRestConfig(mgrConfig)
+
+			if c.QPS != tt.WantQPS || c.Burst != tt.WantBurst {
+				t.Errorf("qps, burst = %v, %v, wanted %v, %v", c.QPS, c.Burst, tt.WantQPS, tt.WantBurst)
+			}
+			if (c.RateLimiter != nil) != tt.WantLimiter {
+				t.Errorf("rate limiter set: %v, wanted %v", c.RateLimiter != nil, tt.WantLimiter)
+			}
+			if c.Impersonate.UserName != tt.Impersonate {
+				t.Errorf("impersonated user = %q, wanted %q", c.Impersonate.UserName, tt.Impersonate)
+			}
+			if c.UserAgent != auditUserAgent {
+				t.Errorf("user agent = %q, wanted %q", c.UserAgent, auditUserAgent)
+			}
+			if mgrConfig.UserAgent != "" || mgrConfig.QPS != 20 {
+				t.Errorf("the manager's config was modified: %+v", mgrConfig)
+			}
+		})
+	}
+}
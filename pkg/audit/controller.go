@@ -29,5 +29,14 @@ func AddToManager(m manager.Manager, opa *opa.Client) error {
 	if err != nil {
 		return err
 	}
+	if err := addTriggerController(m, am); err != nil {
+		return err
+	}
+	if err := addAuditReportController(m, am); err != nil {
+		return err
+	}
+	if err := addNamespaceLabelsController(m, am); err != nil {
+		return err
+	}
 	return m.Add(am)
 }
@@ -0,0 +1,167 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	configv1alpha1 "github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/engine"
+	"github.com/open-policy-agent/gatekeeper/pkg/match"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// coverageReportName is the name of the singleton CoverageReport
+const coverageReportName = "coverage"
+
+var coverageKinds = flag.String("audit-coverage-kinds", "v1/Pod,apps/v1/Deployment,apps/v1/StatefulSet,apps/v1/DaemonSet,apps/v1/ReplicaSet,batch/v1/Job,batch/v1beta1/CronJob", "comma-separated workload kinds, as <group>/<version>/<kind> or <version>/<kind> for the core group, that audit checks each namespace for constraints matching and writes to the CoverageReport. Empty disables the report")
+
+var coverageGapsLimit = flag.Int("audit-coverage-gaps-limit", 100, "limit of the number of namespaces listed in the gaps and uncovered namespaces of the CoverageReport. All of them are counted")
+
+// +kubebuilder:rbac:groups=config.gatekeeper.sh,resources=coveragereports,verbs=get;create;update
+
+// parseCoverageKinds parses the value of --audit-coverage-kinds
+func parseCoverageKinds(s string) ([]schema.GroupVersionKind, error) {
+	var kinds []schema.GroupVersionKind
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, "/")
+		switch {
+		case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+			kinds = append(kinds, schema.GroupVersionKind{Version: parts[0], Kind: parts[1]})
+		case len(parts) == 3 && parts[0] != "" && parts[1] != "" && parts[2] != "":
+			kinds = append(kinds, schema.GroupVersionKind{Group: parts[0], Version: parts[1], Kind: parts[2]})
+		default:
+			return nil, fmt.Errorf("invalid kind %q, want <group>/<version>/<kind> or <version>/<kind>", entry)
+		}
+	}
+	return kinds, nil
+}
+
+func formatCoverageKind(gvk schema.GroupVersionKind) string {
+	if gvk.Group == "" {
+		return gvk.Version + "/" + gvk.Kind
+	}
+	return gvk.Group + "/" + gvk.Version + "/" + gvk.Kind
+}
+
+// coverage checks, for each namespace, which of kinds no constraint matches. An object
+// of each kind is matched without labels, so constraints that only match labeled
+// objects do not cover a kind. Up to limit gaps and uncovered namespaces are listed.
+func coverage(ctx context.Context, constraints []*unstructured.Unstructured, namespaces []corev1.Namespace, kinds []schema.GroupVersionKind, limit int) (*configv1alpha1.CoverageReportStatus, error) {
+	m, err := match.NewMatcher(ctx, constraints)
+	if err != nil {
+		return nil, err
+	}
+	status := &configv1alpha1.CoverageReportStatus{
+		Constraints: int64(len(constraints)),
+		Namespaces:  int64(len(namespaces)),
+	}
+	for _, gvk := range kinds {
+		status.Kinds = append(status.Kinds, formatCoverageKind(gvk))
+	}
+	uncovered := make(map[string]int)
+	for i := range namespaces {
+		ns := &namespaces[i]
+		var gaps []string
+		for _, gvk := range kinds {
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(gvk)
+			obj.SetNamespace(ns.GetName())
+			obj.SetName(coverageReportName)
+			matched, err := m.Matching(ctx, obj, engine.Namespace(ns))
+			if err != nil {
+				return nil, err
+			}
+			if len(matched) == 0 {
+				kind := formatCoverageKind(gvk)
+				gaps = append(gaps, kind)
+				uncovered[kind]++
+			}
+		}
+		if len(gaps) == 0 {
+			continue
+		}
+		if len(gaps) == len(kinds) {
+			status.TotalUncoveredNamespaces++
+			if len(status.UncoveredNamespaces) < limit {
+				status.UncoveredNamespaces = append(status.UncoveredNamespaces, ns.GetName())
+			}
+		}
+		status.TotalGaps++
+		if len(status.Gaps) < limit {
+			status.Gaps = append(status.Gaps, configv1alpha1.CoverageGap{Namespace: ns.GetName(), Kinds: gaps})
+		}
+	}
+	if len(namespaces) > 0 {
+		for _, kind := range status.Kinds {
+			if uncovered[kind] == len(namespaces) {
+				status.UncoveredKinds = append(status.UncoveredKinds, kind)
+			}
+		}
+	}
+	return status, nil
+}
+
+// reportCoverage writes the CoverageReport for the constraints listed by the audit that
+// started at startTime
+func (am *Manager) reportCoverage(ctx context.Context, constraints map[schema.GroupVersionKind][]unstructured.Unstructured, auditTimestamp metav1.Time) error {
+	if len(am.coverageKinds) == 0 {
+		return nil
+	}
+	var all []*unstructured.Unstructured
+	for _, items := range constraints {
+		for i := range items {
+			if items[i].GetDeletionTimestamp().IsZero() {
+				all = append(all, &items[i])
+			}
+		}
+	}
+	namespaces := &corev1.NamespaceList{}
+	if err := am.client.List(ctx, namespaces); err != nil {
+		return err
+	}
+	status, err := coverage(ctx, all, namespaces.Items, am.coverageKinds, *coverageGapsLimit)
+	if err != nil {
+		return err
+	}
+	status.AuditTimestamp = &auditTimestamp
+
+	existing := &configv1alpha1.CoverageReport{}
+	err = am.client.Get(ctx, types.NamespacedName{Name: coverageReportName}, existing)
+	if apierrors.IsNotFound(err) {
+		return am.client.Create(ctx, &configv1alpha1.CoverageReport{
+			ObjectMeta: metav1.ObjectMeta{Name: coverageReportName},
+			Status:     *status,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	existing.Status = *status
+	return am.client.Update(ctx, existing)
+}
@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	configv1alpha1 "github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestParseCoverageKinds(t *testing.T) {
+	kinds, err := parseCoverageKinds("v1/Pod, apps/v1/Deployment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []schema.GroupVersionKind{{Version: "v1", Kind: "Pod"}, {Group: "apps", Version: "v1", Kind: "Deployment"}}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("kinds = %v, want %v", kinds, want)
+	}
+	if kinds, err := parseCoverageKinds(""); err != nil || len(kinds) != 0 {
+		t.Errorf("parseCoverageKinds(\"\") = %v, %v, want no kinds", kinds, err)
+	}
+	for _, s := range []string{"Pod", "apps//Deployment", "a/b/c/d"} {
+		if _, err := parseCoverageKinds(s); err == nil {
+			t.Errorf("parseCoverageKinds(%q) did not fail", s)
+		}
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	pods := versionedConstraint("pods", kindEntry([]interface{}{""}, []interface{}{"Pod"}, nil))
+	pods.SetAPIVersion("constraints.gatekeeper.sh/v1beta1")
+	pods.SetKind("K8sPSPPrivileged")
+	if err := unstructured.SetNestedStringSlice(pods.Object, []string{"kube-system"}, "spec", "match", "excludedNamespaces"); err != nil {
+		t.Fatal(err)
+	}
+	deployments := versionedConstraint("prod-deployments", kindEntry([]interface{}{"apps"}, []interface{}{"Deployment"}, nil))
+	deployments.SetAPIVersion("constraints.gatekeeper.sh/v1beta1")
+	deployments.SetKind("K8sReplicaLimits")
+	if err := unstructured.SetNestedStringSlice(deployments.Object, []string{"prod"}, "spec", "match", "namespaces"); err != nil {
+		t.Fatal(err)
+	}
+	var namespaces []corev1.Namespace
+	for _, name := range []string{"dev", "kube-system", "prod"} {
+		namespaces = append(namespaces, corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	kinds := []schema.GroupVersionKind{
+		{Version: "v1", Kind: "Pod"},
+		{Group: "apps", Version: "v1", Kind: "Deployment"},
+		{Group: "batch", Version: "v1", Kind: "Job"},
+	}
+
+	got, err := coverage(context.Background(), []*unstructured.Unstructured{pods, deployments}, namespaces, kinds, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &configv1alpha1.CoverageReportStatus{
+		Constraints:              2,
+		Namespaces:               3,
+		Kinds:                    []string{"v1/Pod", "apps/v1/Deployment", "batch/v1/Job"},
+		UncoveredNamespaces:      []string{"kube-system"},
+		TotalUncoveredNamespaces: 1,
+		UncoveredKinds:           []string{"batch/v1/Job"},
+		TotalGaps:                3,
+		Gaps: []configv1alpha1.CoverageGap{
+			{Namespace: "dev", Kinds: []string{"apps/v1/Deployment", "batch/v1/Job"}},
+			{Namespace: "kube-system", Kinds: []string{"v1/Pod", "apps/v1/Deployment", "batch/v1/Job"}},
+			{Namespace: "prod", Kinds: []string{"batch/v1/Job"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("coverage() = %+v, want %+v", got, want)
+	}
+
+	// namespaces beyond the limit are counted but not listed
+	got, err = coverage(context.Background(), []*unstructured.Unstructured{pods, deployments}, namespaces, kinds, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Gaps = want.Gaps[:1]
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("coverage() with limit 1 = %+v, want %+v", got, want)
+	}
+}
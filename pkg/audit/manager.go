@@ -2,26 +2,40 @@ package audit
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
 	constraintTypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/controller/constraint"
+	"github.com/open-policy-agent/gatekeeper/pkg/controller/exemption"
+	"github.com/open-policy-agent/gatekeeper/pkg/feed"
 	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/redact"
+	"github.com/open-policy-agent/gatekeeper/pkg/replay"
 	"github.com/open-policy-agent/gatekeeper/pkg/target"
 	"github.com/open-policy-agent/gatekeeper/pkg/util"
 	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -43,6 +57,9 @@ var (
 	auditIntervalDeprecated             = flag.Int("auditInterval", defaultAuditInterval, "DEPRECATED - use --audit-interval")
 	constraintViolationsLimitDeprecated = flag.Int("constraintViolationsLimit", defaultConstraintViolationsLimit, "DEPRECATED - use --constraint-violations-limit")
 	auditFromCache                      = flag.Bool("audit-from-cache", false, "pull resources from OPA cache when auditing")
+	statusUpdateQPS                     = flag.Float64("audit-status-update-qps", 0, "maximum number of constraint status updates written per second at the end of an audit. 0 for no limit")
+	auditConstraintSelector             = flag.String("audit-constraint-selector", "", "label selector of the constraints this audit reports on, such as audit-tier=continuous. Other constraints are still evaluated, but their violations are dropped and their status is left to the audits that select them. Audits all constraints if empty")
+	auditIntervalJitter                 = flag.Float64("audit-interval-jitter", 0, "maximum fraction of --audit-interval added at random to each wait between audits, e.g. 0.1 for up to 10%. Spreads audit load across replicas and clusters")
 	emptyAuditResults                   []auditResult
 )
 
@@ -57,6 +74,39 @@ type Manager struct {
 	ucloop   *updateConstraintLoop
 	reporter *reporter
 	log      logr.Logger
+	// trigger requests an audit without waiting for the interval to elapse
+	trigger chan struct{}
+	// statusLimiter is shared by all status updates so concurrent update loops
+	// cannot exceed the configured rate together
+	statusLimiter *rate.Limiter
+	// constraintsCache is checked against the live constraints after each audit
+	constraintsCache *constraint.ConstraintsCache
+	// exemptions waive violations until they expire. No violations are waived if nil
+	exemptions *exemption.ExemptionsCache
+	tracker    violationTracker
+	recorder   record.EventRecorder
+	// annotations are the constraint annotations added to violation events
+	annotations []string
+	// replayQueue holds the objects the webhook admitted without review. None are
+	// re-checked if nil
+	replayQueue *replay.Queue
+	// restConfig is used for every request audit sends, and is rate limited separately
+	// from the manager's clients
+	restConfig *rest.Config
+	// feed receives the violations each audit introduces and resolves. Nothing is
+	// published if nil
+	feed *feed.Feed
+	// constraintSelector selects the constraints audit reports on
+	constraintSelector labels.Selector
+	// schedule tracks the interval buckets of the constraints
+	schedule *auditSchedule
+	// plan is what the current audit reports on. All constraints are reported on if nil
+	plan *auditPlan
+	// coverageKinds are the workload kinds the CoverageReport covers. No report is
+	// written if empty
+	coverageKinds []schema.GroupVersionKind
+	// excludedKinds are the kinds not audited via the discovery client
+	excludedKinds map[schema.GroupKind]bool
 }
 
 type auditResult struct {
@@ -67,11 +117,20 @@ type auditResult struct {
 	rkind             string
 	rname             string
 	rnamespace        string
+	ruid              string
 	message           string
 	enforcementAction string
 	constraint        *unstructured.Unstructured
 }
 
+// owner returns the owner label of the constraint that found ar
+func (ar auditResult) owner() string {
+	if ar.constraint == nil {
+		return ""
+	}
+	return util.GetOwner(ar.constraint)
+}
+
 // StatusViolation represents each violation under status
 type StatusViolation struct {
 	Kind              string `json:"kind"`
@@ -79,6 +138,35 @@ type StatusViolation struct {
 	Namespace         string `json:"namespace,omitempty"`
 	Message           string `json:"message"`
 	EnforcementAction string `json:"enforcementAction"`
+	// Owner is the owner label of the constraint, so a violation can be routed from the
+	// status alone
+	Owner string `json:"owner,omitempty"`
+	// Fingerprint identifies the same violation across audit runs
+	Fingerprint string `json:"fingerprint"`
+	// FirstSeen is the auditTimestamp of the first run that found the violation
+	FirstSeen string `json:"firstSeen"`
+	// LastSeen is the auditTimestamp of the latest run that found the violation
+	LastSeen string `json:"lastSeen"`
+}
+
+// lastRun is when this process last finished an audit
+var lastRun struct {
+	mux sync.RWMutex
+	at  time.Time
+}
+
+// LastRun returns the start time of the last audit this process finished, or the zero
+// time if it has not finished one
+func LastRun() time.Time {
+	lastRun.mux.RLock()
+	defer lastRun.mux.RUnlock()
+	return lastRun.at
+}
+
+func setLastRun(t time.Time) {
+	lastRun.mux.Lock()
+	defer lastRun.mux.Unlock()
+	lastRun.at = t
 }
 
 // New creates a new manager for audit
@@ -97,16 +185,79 @@ func New(ctx context.Context, mgr manager.Manager, opa *opa.Client) (*Manager, e
 		mgr:      mgr,
 		ctx:      ctx,
 		reporter: reporter,
+		trigger:  make(chan struct{}, 1),
+
+		constraintsCache: constraint.Cache,
+		exemptions:       exemption.Cache,
+		recorder:         mgr.GetEventRecorderFor("gatekeeper-audit"),
+		annotations:      util.SurfacedAnnotations(),
+		replayQueue:      replay.Pending,
+		restConfig:       auditRestConfig(mgr.GetConfig()),
+		feed:             feed.Violations,
+		schedule:         newAuditSchedule(),
+	}
+	am.statusLimiter = newStatusLimiter(*statusUpdateQPS)
+	selector, err := labels.Parse(*auditConstraintSelector)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid --audit-constraint-selector")
+	}
+	am.constraintSelector = selector
+	if am.coverageKinds, err = parseCoverageKinds(*coverageKinds); err != nil {
+		return nil, errors.Wrap(err, "invalid --audit-coverage-kinds")
+	}
+	if am.excludedKinds, err = parseExcludedKinds(*auditExcludedKinds); err != nil {
+		return nil, errors.Wrap(err, "invalid --audit-excluded-kinds")
 	}
 	return am, nil
 }
 
-// audit performs an audit then updates the status of all constraint resources with the results
-func (am *Manager) audit(ctx context.Context) error {
+// audit performs an audit then updates the status of the constraint resources whose
+// interval bucket is due with the results. Every bucket is due if all is set.
+func (am *Manager) audit(ctx context.Context, all bool) error {
 	startTime := time.Now()
 	timestamp := startTime.UTC().Format(time.RFC3339)
 	am.log = log.WithValues(logging.AuditID, timestamp)
+
+	// new client to get updated restmapper
+	c, err := client.New(am.restConfig, client.Options{Scheme: am.mgr.GetScheme(), Mapper: nil})
+	if err != nil {
+		return err
+	}
+	am.client = c
+	// don't audit anything until the constraintTemplate crd is in the cluster
+	if err := am.ensureCRDExists(ctx); err != nil {
+		am.log.Info("Audit exits, required crd has not been deployed ", "CRD", crdName)
+		return nil
+	}
+
+	// read before listing so that constraints created meanwhile are not evicted
+	cached := am.constraintsCache.Keys()
+	// get all constraint kinds
+	rs, kindsErr := am.getAllConstraintKinds()
+	var constraints map[schema.GroupVersionKind][]unstructured.Unstructured
+	if kindsErr == nil {
+		if constraints, err = am.listConstraints(ctx, rs); err != nil {
+			return err
+		}
+	}
+	var selected []unstructured.Unstructured
+	for _, items := range constraints {
+		for i := range items {
+			if am.selects(&items[i]) {
+				selected = append(selected, items[i])
+			}
+		}
+	}
+	am.plan = am.schedule.plan(selected, time.Duration(*auditInterval)*time.Second, startTime, all)
+	if am.plan == nil {
+		am.log.Info("no audit interval bucket is due, skipping audit")
+		return nil
+	}
+
 	logStart(am.log)
+	if am.plan.due != nil {
+		am.log.Info("auditing the constraints of the due interval buckets", "intervals", am.plan.buckets, "constraints", len(am.plan.due))
+	}
 	// record audit latency
 	defer func() {
 		logFinish(am.log)
@@ -120,18 +271,6 @@ func (am *Manager) audit(ctx context.Context) error {
 		am.log.Error(err, "failed to report run start time")
 	}
 
-	// new client to get updated restmapper
-	c, err := client.New(am.mgr.GetConfig(), client.Options{Scheme: am.mgr.GetScheme(), Mapper: nil})
-	if err != nil {
-		return err
-	}
-	am.client = c
-	// don't audit anything until the constraintTemplate crd is in the cluster
-	if err := am.ensureCRDExists(ctx); err != nil {
-		am.log.Info("Audit exits, required crd has not been deployed ", "CRD", crdName)
-		return nil
-	}
-
 	var resp *constraintTypes.Responses
 	var res []*constraintTypes.Result
 
@@ -145,7 +284,7 @@ func (am *Manager) audit(ctx context.Context) error {
 		am.log.Info("Audit opa.Audit() results", "violations", len(res))
 	} else {
 		am.log.Info("Auditing via discovery client")
-		res, err = am.auditResources(ctx)
+		res, err = am.auditResources(ctx, am.plan.kinds, am.plan.versions)
 		if err != nil {
 			return err
 		}
@@ -156,121 +295,223 @@ func (am *Manager) audit(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	am.schedule.carry(am.plan, updateLists, totalViolationsPerConstraint, totalViolationsPerEnforcementAction)
 	for k, v := range totalViolationsPerEnforcementAction {
 		if err := am.reporter.reportTotalViolations(k, v); err != nil {
 			am.log.Error(err, "failed to report total violations")
 		}
 	}
-	// get all constraint kinds
-	rs, err := am.getAllConstraintKinds()
-	if err != nil {
+	introduced, resolved := am.reportTransitions(updateLists)
+	am.publishTransitions(introduced, resolved, timestamp)
+	live := make(map[string]bool)
+	if kindsErr != nil {
 		// if no constraint is found with the constraint apiversion, then return
 		am.log.Info("no constraint is found with apiversion", "constraint apiversion", constraintsGV)
+		if apierrors.IsNotFound(kindsErr) {
+			am.evictOrphans(cached, live)
+		}
 		return nil
 	}
 	// update constraints for each kind
-	return am.writeAuditResults(ctx, rs, updateLists, timestamp, totalViolationsPerConstraint)
+	if err := am.writeAuditResults(ctx, constraints, updateLists, timestamp, totalViolationsPerConstraint, live); err != nil {
+		return err
+	}
+	am.evictOrphans(cached, live)
+	if err := am.reportCoverage(ctx, constraints, metav1.NewTime(startTime)); err != nil {
+		am.log.Error(err, "could not write the coverage report")
+	}
+	am.schedule.finish(am.plan, startTime)
+	setLastRun(startTime)
+	return nil
+}
+
+// evictOrphans removes the cached constraints that were not listed
+func (am *Manager) evictOrphans(cached []string, live map[string]bool) {
+	if evicted := am.constraintsCache.EvictOrphans(cached, live); evicted > 0 {
+		am.log.Info("evicted deleted constraints from the constraints cache", "count", evicted, "size", am.constraintsCache.Len())
+	}
 }
 
 // Audits server resources via the discovery client, as an alternative to opa.Client.Audit()
-func (am *Manager) auditResources(ctx context.Context) ([]*constraintTypes.Result, error) {
-	discoveryClient, err := discovery.NewDiscoveryClientForConfig(am.mgr.GetConfig())
+// reviewing only objects of kinds if it is not empty
+func (am *Manager) auditResources(ctx context.Context, kinds map[string]bool, versions map[schema.GroupVersion]map[string]bool) ([]*constraintTypes.Result, error) {
+	responses, skipped, err := am.reviewResources(ctx, am.client, am.log, "", kinds, versions)
+	if skipped > 0 {
+		am.log.Info("skipped objects larger than --audit-max-object-size", "count", skipped, "max_size", *auditMaxObjectSize)
+	}
+	if err := am.reporter.reportSkipped(skipped); err != nil {
+		am.log.Error(err, "failed to report skipped objects")
+	}
+	return responses, err
+}
+
+// reviewResources reviews the objects of every kind that can be listed, reading them
+// through c. If namespace is set only the namespaced objects of that namespace are
+// reviewed, and if kinds is not empty only objects of those kinds. Objects are read in
+// their preferred version, and again in each of versions that is served, for the
+// constraints that select those versions only. It also returns the number of objects
+// skipped for their size.
+func (am *Manager) reviewResources(ctx context.Context, c client.Client, l logr.Logger, namespace string, kinds map[string]bool, versions map[schema.GroupVersion]map[string]bool) ([]*constraintTypes.Result, int64, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(am.restConfig)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	serverResourceLists, err := discoveryClient.ServerPreferredResources()
 
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	clusterAPIResources := make(map[metav1.GroupVersion]map[string]bool)
+	// listable returns whether resource is listed by this review
+	listable := func(group string, resource metav1.APIResource) bool {
+		if am.excludedKinds[schema.GroupKind{Group: group, Kind: resource.Kind}] {
+			return false
+		}
+		if namespace != "" && !resource.Namespaced {
+			return false
+		}
+		if len(kinds) > 0 && !kinds[resource.Kind] {
+			return false
+		}
+		for _, verb := range resource.Verbs {
+			if verb == "list" {
+				return true
+			}
+		}
+		return false
+	}
+
+	var listed []schema.GroupVersionKind
+	preferred := make(map[schema.GroupKind]string)
 	for _, rl := range serverResourceLists {
-		gvParsed, err := schema.ParseGroupVersion(rl.GroupVersion)
+		gv, err := schema.ParseGroupVersion(rl.GroupVersion)
 		if err != nil {
-			am.log.Error(err, "Error parsing groupversion", "groupversion", rl.GroupVersion)
+			l.Error(err, "Error parsing groupversion", "groupversion", rl.GroupVersion)
 			continue
 		}
-
-		gv := metav1.GroupVersion{
-			Group:   gvParsed.Group,
-			Version: gvParsed.Version,
+		for _, resource := range rl.APIResources {
+			if !listable(gv.Group, resource) {
+				continue
+			}
+			gk := schema.GroupKind{Group: gv.Group, Kind: resource.Kind}
+			if _, ok := preferred[gk]; !ok {
+				preferred[gk] = gv.Version
+				listed = append(listed, gv.WithKind(resource.Kind))
+			}
 		}
-		if _, ok := clusterAPIResources[gv]; !ok {
-			clusterAPIResources[gv] = make(map[string]bool)
+	}
+	for gv, gvKinds := range versions {
+		rl, err := discoveryClient.ServerResourcesForGroupVersion(gv.String())
+		if err != nil {
+			l.V(1).Info("Skipping group version selected by constraints", "groupversion", gv.String(), "reason", err.Error())
+			continue
 		}
 		for _, resource := range rl.APIResources {
-			for _, verb := range resource.Verbs {
-				if verb == "list" {
-					clusterAPIResources[gv][resource.Kind] = true
-					break
-				}
+			gk := schema.GroupKind{Group: gv.Group, Kind: resource.Kind}
+			if !gvKinds["*"] && !gvKinds[resource.Kind] {
+				continue
+			}
+			if v, ok := preferred[gk]; !ok || v == gv.Version || !listable(gv.Group, resource) {
+				continue
 			}
+			listed = append(listed, gv.WithKind(resource.Kind))
 		}
 	}
 
 	var responses []*constraintTypes.Result
 	var errs opa.Errors
+	var skipped int64
 
-	for gv, gvKinds := range clusterAPIResources {
-		for kind := range gvKinds {
-			objList := &unstructured.UnstructuredList{}
-			objList.SetGroupVersionKind(schema.GroupVersionKind{
-				Group:   gv.Group,
-				Version: gv.Version,
-				Kind:    kind + "List",
-			})
+	for _, gvk := range listed {
+		gk := gvk.GroupKind()
+		objList := &unstructured.UnstructuredList{}
+		objList.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
 
-			err := am.client.List(ctx, objList)
-			if err != nil {
-				am.log.Error(err, "Unable to list objects for gvk", "group", gv.Group, "version", gv.Version, "kind", kind)
+		err := c.List(ctx, objList, client.InNamespace(namespace))
+		if err != nil {
+			l.Error(err, "Unable to list objects for gvk", "group", gvk.Group, "version", gvk.Version, "kind", gvk.Kind)
+			continue
+		}
+
+		for _, obj := range objList.Items {
+			if tooLarge(&obj, *auditMaxObjectSize) {
+				skipped++
 				continue
 			}
-
-			for _, obj := range objList.Items {
-				ns := &corev1.Namespace{}
-				if obj.GetNamespace() != "" {
-					if err := am.client.Get(ctx, types.NamespacedName{Name: obj.GetNamespace()}, ns); err != nil {
-						am.log.Error(err, "Unable to look up object namespace", "group", gv.Group, "version", gv.Version, "kind", kind)
-						continue
-					}
+			ns := &corev1.Namespace{}
+			if obj.GetNamespace() != "" {
+				if err := c.Get(ctx, types.NamespacedName{Name: obj.GetNamespace()}, ns); err != nil {
+					l.Error(err, "Unable to look up object namespace", "group", gvk.Group, "version", gvk.Version, "kind", gvk.Kind)
+					continue
 				}
+			}
 
-				augmentedObj := target.AugmentedUnstructured{
-					Object:    obj,
-					Namespace: ns,
-				}
-				resp, err := am.opa.Review(ctx, augmentedObj)
+			augmentedObj := target.AugmentedUnstructured{
+				Object:    obj,
+				Namespace: ns,
+			}
+			resp, err := am.opa.Review(ctx, augmentedObj)
 
-				if err != nil {
-					errs = append(errs, err)
-				} else if len(resp.Results()) > 0 {
-					responses = append(responses, resp.Results()...)
-				}
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			results := resp.Results()
+			if preferred[gk] != gvk.Version {
+				results = onlyNamedVersionResults(results, gk, gvk.Version, preferred[gk])
+			}
+			if len(results) > 0 {
+				responses = append(responses, results...)
 			}
 		}
 	}
 
 	if len(errs) > 0 {
-		return responses, errs
+		return responses, skipped, errs
 	}
-	return responses, nil
+	return responses, skipped, nil
 }
 
 func (am *Manager) auditManagerLoop(ctx context.Context) {
 	for {
+		// audits on demand report on every constraint, whatever its interval
+		all := false
 		select {
 		case <-ctx.Done():
 			log.Info("Audit Manager close")
 			close(am.stopper)
 			return
-		default:
-			time.Sleep(time.Duration(*auditInterval) * time.Second)
-			if err := am.audit(ctx); err != nil {
-				log.Error(err, "audit manager audit() failed")
-			}
+		case <-time.After(auditWait()):
+		case <-am.trigger:
+			log.Info("audit triggered on demand")
+			all = true
+		case <-am.replayReady():
+			am.replay(ctx, am.mgr.GetAPIReader())
+			continue
 		}
+		if err := am.audit(ctx, all); err != nil {
+			log.Error(err, "audit manager audit() failed")
+		}
+	}
+}
+
+// auditWait returns how long to wait before the next audit
+func auditWait() time.Duration {
+	interval := time.Duration(*auditInterval) * time.Second
+	// wait.Jitter treats a factor of 0 as 1, so only call it when jitter is enabled
+	if *auditIntervalJitter <= 0 {
+		return interval
+	}
+	return wait.Jitter(interval, *auditIntervalJitter)
+}
+
+// Trigger requests an immediate audit. Requests made while an audit is pending are
+// coalesced into it.
+func (am *Manager) Trigger() {
+	select {
+	case am.trigger <- struct{}{}:
+	default:
 	}
 }
 
@@ -291,7 +532,7 @@ func (am *Manager) ensureCRDExists(ctx context.Context) error {
 }
 
 func (am *Manager) getAllConstraintKinds() ([]schema.GroupVersionKind, error) {
-	discoveryClient, err := discovery.NewDiscoveryClientForConfig(am.mgr.GetConfig())
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(am.restConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -314,6 +555,68 @@ func (am *Manager) getAllConstraintKinds() ([]schema.GroupVersionKind, error) {
 	return ret, nil
 }
 
+// selects returns whether c is selected by --audit-constraint-selector
+func (am *Manager) selects(c *unstructured.Unstructured) bool {
+	return am.constraintSelector == nil || am.constraintSelector.Matches(labels.Set(c.GetLabels()))
+}
+
+// listConstraints lists the constraints of each of kinds
+func (am *Manager) listConstraints(ctx context.Context, kinds []schema.GroupVersionKind) (map[schema.GroupVersionKind][]unstructured.Unstructured, error) {
+	constraints := make(map[schema.GroupVersionKind][]unstructured.Unstructured, len(kinds))
+	for _, gvk := range kinds {
+		instanceList := &unstructured.UnstructuredList{}
+		instanceList.SetGroupVersionKind(gvk)
+		if err := am.client.List(ctx, instanceList); err != nil {
+			return nil, err
+		}
+		constraints[gvk] = instanceList.Items
+	}
+	return constraints, nil
+}
+
+// namespaceLabels returns the labels of the namespace, reading each namespace once per
+// audit run through cache
+func (am *Manager) namespaceLabels(cache map[string]map[string]string, namespace string) map[string]string {
+	if namespace == "" {
+		return nil
+	}
+	if labels, ok := cache[namespace]; ok {
+		return labels
+	}
+	ns := &corev1.Namespace{}
+	if err := am.client.Get(context.TODO(), types.NamespacedName{Name: namespace}, ns); err != nil {
+		am.log.Error(err, "Unable to look up namespace labels for exemptions", "namespace", namespace)
+	}
+	cache[namespace] = ns.GetLabels()
+	return cache[namespace]
+}
+
+// dropExempt returns the results of res on the objects of namespace that no exemption
+// waives as of now, reading the labels of namespace with c. Results whose resource is not
+// an object are dropped.
+func (am *Manager) dropExempt(ctx context.Context, c client.Reader, l logr.Logger, namespace string, res []*constraintTypes.Result, now time.Time) []*constraintTypes.Result {
+	var nsLabels map[string]string
+	if am.exemptions != nil && len(res) > 0 {
+		ns := &corev1.Namespace{}
+		if err := c.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+			l.Error(err, "Unable to look up namespace labels for exemptions")
+		}
+		nsLabels = ns.GetLabels()
+	}
+	var kept []*constraintTypes.Result
+	for _, r := range res {
+		resource, ok := r.Resource.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		if am.exemptions != nil && am.exemptions.Exempted(r.Constraint.GetKind(), r.Constraint.GetName(), resource.GetKind(), namespace, nsLabels, resource.GetName(), now) != "" {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
+
 func (am *Manager) getUpdateListsFromAuditResponses(res []*constraintTypes.Result) (map[string][]auditResult, map[string]int64, map[util.EnforcementAction]int64, error) {
 	updateLists := make(map[string][]auditResult)
 	totalViolationsPerConstraint := make(map[string]int64)
@@ -323,36 +626,37 @@ func (am *Manager) getUpdateListsFromAuditResponses(res []*constraintTypes.Resul
 		totalViolationsPerEnforcementAction[action] = 0
 	}
 
+	now := time.Now()
+	nsLabels := make(map[string]map[string]string)
 	for _, r := range res {
-		selfLink := r.Constraint.GetSelfLink()
-		totalViolationsPerConstraint[selfLink]++
-		name := r.Constraint.GetName()
-		namespace := r.Constraint.GetNamespace()
-		apiVersion := r.Constraint.GetAPIVersion()
-		gvk := r.Constraint.GroupVersionKind()
-		enforcementAction := r.EnforcementAction
-		message := r.Msg
 		resource, ok := r.Resource.(*unstructured.Unstructured)
 		if !ok {
 			return nil, nil, nil, errors.Errorf("could not cast resource as reviewResource: %v", r.Resource)
 		}
+		if !am.selects(r.Constraint) || !am.plan.includes(r.Constraint) {
+			continue
+		}
 		rname := resource.GetName()
 		rkind := resource.GetKind()
 		rnamespace := resource.GetNamespace()
-		result := auditResult{
-			cgvk:              gvk,
-			capiversion:       apiVersion,
-			cname:             name,
-			cnamespace:        namespace,
-			rkind:             rkind,
-			rname:             rname,
-			rnamespace:        rnamespace,
-			message:           message,
-			enforcementAction: enforcementAction,
-			constraint:        r.Constraint,
+		if am.exemptions != nil {
+			if exempt := am.exemptions.Exempted(r.Constraint.GetKind(), r.Constraint.GetName(), rkind, rnamespace, am.namespaceLabels(nsLabels, rnamespace), rname, now); exempt != "" {
+				am.log.Info("violation waived by exemption",
+					logging.ExemptionName, exempt,
+					logging.ConstraintKind, r.Constraint.GetKind(),
+					logging.ConstraintName, r.Constraint.GetName(),
+					logging.ResourceKind, rkind,
+					logging.ResourceNamespace, rnamespace,
+					logging.ResourceName, rname,
+				)
+				continue
+			}
 		}
+		selfLink := r.Constraint.GetSelfLink()
+		totalViolationsPerConstraint[selfLink]++
+		result := newAuditResult(r, resource)
 		updateLists[selfLink] = append(updateLists[selfLink], result)
-		ea := util.EnforcementAction(enforcementAction)
+		ea := util.EnforcementAction(result.enforcementAction)
 		totalViolationsPerEnforcementAction[ea]++
 		logViolation(am.log, r.Constraint, r.EnforcementAction, result)
 	}
@@ -364,22 +668,36 @@ func (am *Manager) getUpdateListsFromAuditResponses(res []*constraintTypes.Resul
 	return updateLists, totalViolationsPerConstraint, totalViolationsPerEnforcementAction, nil
 }
 
-func (am *Manager) writeAuditResults(ctx context.Context, resourceList []schema.GroupVersionKind, updateLists map[string][]auditResult, timestamp string, totalViolations map[string]int64) error {
+// newAuditResult returns the audit result of r, the review of resource
+func newAuditResult(r *constraintTypes.Result, resource *unstructured.Unstructured) auditResult {
+	return auditResult{
+		cgvk:              r.Constraint.GroupVersionKind(),
+		capiversion:       r.Constraint.GetAPIVersion(),
+		cname:             r.Constraint.GetName(),
+		cnamespace:        r.Constraint.GetNamespace(),
+		rkind:             resource.GetKind(),
+		rname:             resource.GetName(),
+		rnamespace:        resource.GetNamespace(),
+		ruid:              string(resource.GetUID()),
+		message:           r.Msg,
+		enforcementAction: r.EnforcementAction,
+		constraint:        r.Constraint,
+	}
+}
+
+func (am *Manager) writeAuditResults(ctx context.Context, constraints map[schema.GroupVersionKind][]unstructured.Unstructured, updateLists map[string][]auditResult, timestamp string, totalViolations map[string]int64, live map[string]bool) error {
 	// get constraints for each Kind
-	for _, constraintGvk := range resourceList {
+	for constraintGvk, items := range constraints {
 		am.log.Info("constraint", "resource kind", constraintGvk.Kind)
-		instanceList := &unstructured.UnstructuredList{}
-		instanceList.SetGroupVersionKind(constraintGvk)
-		err := am.client.List(ctx, instanceList)
-		if err != nil {
-			return err
-		}
-		am.log.Info("constraint", "count of constraints", len(instanceList.Items))
+		am.log.Info("constraint", "count of constraints", len(items))
 
-		updateConstraints := make(map[string]unstructured.Unstructured, len(instanceList.Items))
+		updateConstraints := make(map[string]unstructured.Unstructured, len(items))
 		// get each constraint
-		for _, item := range instanceList.Items {
-			updateConstraints[item.GetSelfLink()] = item
+		for _, item := range items {
+			live[constraint.ConstraintKey(item.GetKind(), item.GetName())] = true
+			if am.selects(&item) && am.plan.includes(&item) {
+				updateConstraints[item.GetSelfLink()] = item
+			}
 		}
 		if len(updateConstraints) > 0 {
 			if am.ucloop != nil {
@@ -397,6 +715,7 @@ func (am *Manager) writeAuditResults(ctx context.Context, resourceList []schema.
 				ul:      updateLists,
 				ts:      timestamp,
 				tv:      totalViolations,
+				limiter: am.statusLimiter,
 			}
 			am.log.Info("starting update constraints loop", "updateConstraints", updateConstraints)
 			go am.ucloop.update()
@@ -408,31 +727,16 @@ func (am *Manager) writeAuditResults(ctx context.Context, resourceList []schema.
 func (ucloop *updateConstraintLoop) updateConstraintStatus(ctx context.Context, instance *unstructured.Unstructured, auditResults []auditResult, timestamp string, totalViolations int64) error {
 	constraintName := instance.GetName()
 	log.Info("updating constraint status", "constraintName", constraintName)
+	firstSeen := firstSeenByFingerprint(instance)
 	// create constraint status violations
 	var statusViolations []interface{}
 	for _, ar := range auditResults {
 		// append statusViolations for this constraint until constraintViolationsLimit has reached
 		if len(statusViolations) < *constraintViolationsLimit {
-			msg := ar.message
-			if len(msg) > msgSize {
-				msg = truncateString(msg, msgSize)
-			}
-			statusViolations = append(statusViolations, StatusViolation{
-				Kind:              ar.rkind,
-				Name:              ar.rname,
-				Namespace:         ar.rnamespace,
-				Message:           msg,
-				EnforcementAction: ar.enforcementAction,
-			})
+			statusViolations = append(statusViolations, newStatusViolation(ar, firstSeen, timestamp))
 		}
 	}
-	raw, err := json.Marshal(statusViolations)
-	if err != nil {
-		return err
-	}
-	// need to convert to []interface{}
-	violations := make([]interface{}, 0)
-	err = json.Unmarshal(raw, &violations)
+	violations, err := toUnstructuredViolations(statusViolations)
 	if err != nil {
 		return err
 	}
@@ -444,6 +748,14 @@ func (ucloop *updateConstraintLoop) updateConstraintStatus(ctx context.Context,
 	if err = unstructured.SetNestedField(instance.Object, totalViolations, "status", "totalViolations"); err != nil {
 		return err
 	}
+	// update constraint status violationsByNamespace
+	if byNamespace := violationsByNamespace(auditResults); len(byNamespace) > 0 {
+		if err = unstructured.SetNestedField(instance.Object, byNamespace, "status", "violationsByNamespace"); err != nil {
+			return err
+		}
+	} else {
+		unstructured.RemoveNestedField(instance.Object, "status", "violationsByNamespace")
+	}
 	// update constraint status violations
 	if len(violations) == 0 {
 		_, found, err := unstructured.NestedSlice(instance.Object, "status", "violations")
@@ -454,7 +766,7 @@ func (ucloop *updateConstraintLoop) updateConstraintStatus(ctx context.Context,
 			unstructured.RemoveNestedField(instance.Object, "status", "violations")
 			log.Info("removed status violations", "constraintName", constraintName)
 		}
-		err = ucloop.client.Status().Update(ctx, instance)
+		err = ucloop.writeStatus(ctx, instance)
 		if err != nil {
 			return err
 		}
@@ -462,8 +774,8 @@ func (ucloop *updateConstraintLoop) updateConstraintStatus(ctx context.Context,
 		if err := unstructured.SetNestedSlice(instance.Object, violations, "status", "violations"); err != nil {
 			return err
 		}
-		log.Info("update constraint", "object", instance)
-		err = ucloop.client.Status().Update(ctx, instance)
+		log.Info("update constraint", "object", redact.Unstructured(instance))
+		err = ucloop.writeStatus(ctx, instance)
 		if err != nil {
 			return err
 		}
@@ -472,6 +784,99 @@ func (ucloop *updateConstraintLoop) updateConstraintStatus(ctx context.Context,
 	return nil
 }
 
+// newStatusViolation returns the status entry of ar, found by the audit at timestamp. Its
+// firstSeen is kept from firstSeen if the violation is already in status.
+func newStatusViolation(ar auditResult, firstSeen map[string]string, timestamp string) StatusViolation {
+	msg := ar.message
+	if len(msg) > msgSize {
+		msg = truncateString(msg, msgSize)
+	}
+	fp := fingerprint(ar)
+	seen, ok := firstSeen[fp]
+	if !ok {
+		seen = timestamp
+	}
+	return StatusViolation{
+		Kind:              ar.rkind,
+		Name:              ar.rname,
+		Namespace:         ar.rnamespace,
+		Message:           msg,
+		EnforcementAction: ar.enforcementAction,
+		Owner:             ar.owner(),
+		Fingerprint:       fp,
+		FirstSeen:         seen,
+		LastSeen:          timestamp,
+	}
+}
+
+// toUnstructuredViolations converts statusViolations to the []interface{} of an
+// unstructured status
+func toUnstructuredViolations(statusViolations []interface{}) ([]interface{}, error) {
+	raw, err := json.Marshal(statusViolations)
+	if err != nil {
+		return nil, err
+	}
+	violations := make([]interface{}, 0)
+	if err := json.Unmarshal(raw, &violations); err != nil {
+		return nil, err
+	}
+	return violations, nil
+}
+
+// violationsByNamespace counts the violations of each namespace, including those beyond
+// --constraint-violations-limit. Violations of cluster-scoped resources are not counted.
+func violationsByNamespace(auditResults []auditResult) map[string]interface{} {
+	counts := make(map[string]interface{})
+	for _, ar := range auditResults {
+		if ar.rnamespace == "" {
+			continue
+		}
+		n, _ := counts[ar.rnamespace].(int64)
+		counts[ar.rnamespace] = n + 1
+	}
+	return counts
+}
+
+// fingerprint identifies a violation by its constraint, the UID of the violating resource
+// and the untruncated message, so it stays the same across audit runs until one changes.
+// Resources without a UID fall back to their kind, namespace and name.
+func fingerprint(ar auditResult) string {
+	resource := ar.ruid
+	if resource == "" {
+		resource = strings.Join([]string{ar.rkind, ar.rnamespace, ar.rname}, "/")
+	}
+	msg := sha256.Sum256([]byte(ar.message))
+	h := sha256.New()
+	for _, s := range []string{ar.cgvk.Kind, ar.cname, resource, hex.EncodeToString(msg[:])} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// firstSeenByFingerprint returns the firstSeen of each violation already in the status of
+// instance. Violations beyond --constraint-violations-limit are not kept in status, so
+// their age restarts if they are reported again later.
+func firstSeenByFingerprint(instance *unstructured.Unstructured) map[string]string {
+	firstSeen := make(map[string]string)
+	violations, _, err := unstructured.NestedSlice(instance.Object, "status", "violations")
+	if err != nil {
+		return firstSeen
+	}
+	for _, v := range violations {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		fp, _ := m["fingerprint"].(string)
+		seen, _ := m["firstSeen"].(string)
+		if fp != "" && seen != "" {
+			firstSeen[fp] = seen
+		}
+	}
+	return firstSeen
+}
+
 func truncateString(str string, size int) string {
 	shortenStr := str
 	if len(str) > size {
@@ -491,18 +896,44 @@ type updateConstraintLoop struct {
 	ul      map[string][]auditResult
 	ts      string
 	tv      map[string]int64
+	limiter *rate.Limiter
+	// conflicts counts the status updates of each constraint, by selfLink, that
+	// conflicted with another write
+	conflicts map[string]int
+}
+
+// newStatusLimiter returns a limiter allowing qps status updates per second, or an
+// unlimited one if qps is not positive
+func newStatusLimiter(qps float64) *rate.Limiter {
+	if qps <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(qps), 1)
 }
 
 func (ucloop *updateConstraintLoop) update() {
 	defer close(ucloop.stopped)
+	// waiting on the rate limiter must end when the loop is stopped
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-ucloop.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
 	updateLoop := func() (bool, error) {
 		for _, item := range ucloop.uc {
 			select {
 			case <-ucloop.stop:
 				return true, nil
 			default:
+				if err := ucloop.limiter.Wait(ctx); err != nil {
+					// only returns an error once the loop is stopped
+					return true, nil
+				}
 				failure := false
-				ctx := context.Background()
 				var latestItem unstructured.Unstructured
 				item.DeepCopyInto(&latestItem)
 				name := latestItem.GetName()
@@ -597,8 +1028,9 @@ func logConstraint(l logr.Logger, constraint *unstructured.Unstructured, enforce
 		logging.ConstraintName, constraint.GetName(),
 		logging.ConstraintNamespace, constraint.GetNamespace(),
 		logging.ConstraintAction, enforcementAction,
+		logging.ConstraintOwner, util.GetOwner(constraint),
 		logging.ConstraintStatus, "enforced",
-		logging.ConstraintViolations, string(totalViolations),
+		logging.ConstraintViolations, strconv.FormatInt(totalViolations, 10),
 	)
 }
 
@@ -610,6 +1042,7 @@ func logViolation(l logr.Logger, constraint *unstructured.Unstructured, enforcem
 		logging.ConstraintName, constraint.GetName(),
 		logging.ConstraintNamespace, constraint.GetNamespace(),
 		logging.ConstraintAction, enforcementAction,
+		logging.ConstraintOwner, util.GetOwner(constraint),
 		logging.ResourceKind, violation.rkind,
 		logging.ResourceNamespace, violation.rnamespace,
 		logging.ResourceName, violation.rname,
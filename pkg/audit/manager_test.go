@@ -0,0 +1,241 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	constraintTypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/testutils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUpdateConstraintLoopRateLimit(t *testing.T) {
+	const count = 5
+	const qps = 20
+	gvk := testutils.ConstraintGVK("K8sRequiredLabels")
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+
+	uc := make(map[string]unstructured.Unstructured)
+	var objs []runtime.Object
+	for i := 0; i < count; i++ {
+		c := testutils.NewConstraint("K8sRequiredLabels", fmt.Sprintf("c%d", i))
+		c.SetSelfLink(fmt.Sprintf("/apis/constraints.gatekeeper.sh/v1beta1/k8srequiredlabels/c%d", i))
+		uc[c.GetSelfLink()] = *c
+		objs = append(objs, c)
+	}
+	c := fake.NewFakeClientWithScheme(scheme, objs...)
+	ucloop := &updateConstraintLoop{
+		uc:      uc,
+		client:  c,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		ul:      make(map[string][]auditResult),
+		ts:      "2020-03-01T00:00:00Z",
+		tv:      make(map[string]int64),
+		limiter: newStatusLimiter(qps),
+	}
+
+	start := time.Now()
+	ucloop.update()
+	// the first update uses the limiter's burst, the rest wait 1/qps each
+	if elapsed, min := time.Since(start), (count-1)*time.Second/qps; elapsed < min {
+		t.Errorf("updates took %v, wanted at least %v at %d qps", elapsed, min, qps)
+	}
+	if len(ucloop.uc) != 0 {
+		t.Errorf("%d constraints were not updated", len(ucloop.uc))
+	}
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(gvk)
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: "c0"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if ts, _, _ := unstructured.NestedString(got.Object, "status", "auditTimestamp"); ts != ucloop.ts {
+		t.Errorf("auditTimestamp = %q, wanted %q", ts, ucloop.ts)
+	}
+}
+
+func TestUpdateConstraintLoopStop(t *testing.T) {
+	ucloop := &updateConstraintLoop{
+		uc:      map[string]unstructured.Unstructured{"a": *testutils.NewConstraint("K8sRequiredLabels", "a")},
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		// a limiter that would block for an hour
+		limiter: newStatusLimiter(1.0 / 3600),
+	}
+	ucloop.limiter.Allow()
+	go ucloop.update()
+	close(ucloop.stop)
+	select {
+	case <-ucloop.stopped:
+	case <-time.After(5 * time.Second):
+		t.Error("update loop did not stop while waiting on the rate limiter")
+	}
+}
+
+func TestUpdateConstraintStatusFirstSeen(t *testing.T) {
+	gvk := testutils.ConstraintGVK("K8sRequiredLabels")
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+	c := testutils.NewConstraint("K8sRequiredLabels", "c")
+	client := fake.NewFakeClientWithScheme(scheme, c)
+	ucloop := &updateConstraintLoop{client: client}
+
+	result := func(name, uid, msg string) auditResult {
+		return auditResult{cgvk: gvk, cname: "c", rkind: "Namespace", rname: name, ruid: uid, message: msg}
+	}
+	// update writes the status and returns the violations read back from the cluster
+	update := func(ts string, results ...auditResult) []interface{} {
+		got := &unstructured.Unstructured{}
+		got.SetGroupVersionKind(gvk)
+		if err := client.Get(context.TODO(), types.NamespacedName{Name: "c"}, got); err != nil {
+			t.Fatal(err)
+		}
+		if err := ucloop.updateConstraintStatus(context.TODO(), got, results, ts, int64(len(results))); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Get(context.TODO(), types.NamespacedName{Name: "c"}, got); err != nil {
+			t.Fatal(err)
+		}
+		violations, _, err := unstructured.NestedSlice(got.Object, "status", "violations")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return violations
+	}
+	field := func(v interface{}, key string) string {
+		return v.(map[string]interface{})[key].(string)
+	}
+
+	first := update("t1", result("a", "uid-a", "missing labels"))
+	fp := field(first[0], "fingerprint")
+	if fp == "" {
+		t.Fatal("violation has no fingerprint")
+	}
+
+	second := update("t2", result("a", "uid-a", "missing labels"), result("b", "uid-b", "missing labels"), result("a", "uid-a", "other message"))
+	if len(second) != 3 {
+		t.Fatalf("got %d violations, wanted 3", len(second))
+	}
+	if got := field(second[0], "fingerprint"); got != fp {
+		t.Errorf("fingerprint changed from %q to %q between audits", fp, got)
+	}
+	if got := field(second[0], "firstSeen"); got != "t1" {
+		t.Errorf("firstSeen of a repeated violation = %q, wanted t1", got)
+	}
+	if got := field(second[0], "lastSeen"); got != "t2" {
+		t.Errorf("lastSeen of a repeated violation = %q, wanted t2", got)
+	}
+	for _, v := range second[1:] {
+		if field(v, "fingerprint") == fp {
+			t.Errorf("violation %v has the same fingerprint as a different violation", v)
+		}
+		if got := field(v, "firstSeen"); got != "t2" {
+			t.Errorf("firstSeen of a new violation = %q, wanted t2", got)
+		}
+	}
+}
+
+func TestUpdateConstraintStatusByNamespace(t *testing.T) {
+	gvk := testutils.ConstraintGVK("K8sRequiredLabels")
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+	c := testutils.NewConstraint("K8sRequiredLabels", "c")
+	client := fake.NewFakeClientWithScheme(scheme, c)
+	ucloop := &updateConstraintLoop{client: client}
+
+	defer func(limit int) { *constraintViolationsLimit = limit }(*constraintViolationsLimit)
+	*constraintViolationsLimit = 1
+
+	result := func(namespace, name string) auditResult {
+		return auditResult{cgvk: gvk, cname: "c", rkind: "ConfigMap", rnamespace: namespace, rname: name, message: "missing labels"}
+	}
+	// update writes the status and returns the counts read back from the cluster
+	update := func(results ...auditResult) (map[string]interface{}, bool) {
+		got := &unstructured.Unstructured{}
+		got.SetGroupVersionKind(gvk)
+		if err := client.Get(context.TODO(), types.NamespacedName{Name: "c"}, got); err != nil {
+			t.Fatal(err)
+		}
+		if err := ucloop.updateConstraintStatus(context.TODO(), got, results, "t", int64(len(results))); err != nil {
+			t.Fatal(err)
+		}
+		got = &unstructured.Unstructured{}
+		got.SetGroupVersionKind(gvk)
+		if err := client.Get(context.TODO(), types.NamespacedName{Name: "c"}, got); err != nil {
+			t.Fatal(err)
+		}
+		counts, found, err := unstructured.NestedMap(got.Object, "status", "violationsByNamespace")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return counts, found
+	}
+
+	counts, _ := update(result("team-a", "x"), result("team-a", "y"), result("team-b", "z"), result("", "cluster-scoped"))
+	want := map[string]int64{"team-a": 2, "team-b": 1}
+	if len(counts) != len(want) {
+		t.Errorf("got counts %v, wanted %v", counts, want)
+	}
+	for ns, n := range want {
+		if got, _ := counts[ns].(int64); got != n {
+			t.Errorf("violations in %s = %v, wanted %d", ns, counts[ns], n)
+		}
+	}
+
+	if counts, found := update(); found {
+		t.Errorf("counts were kept once all violations were resolved: %v", counts)
+	}
+}
+
+func TestGetUpdateListsConstraintSelector(t *testing.T) {
+	continuous := testutils.NewConstraint("K8sRequiredLabels", "continuous")
+	continuous.SetLabels(map[string]string{"audit-tier": "continuous"})
+	continuous.SetSelfLink("/continuous")
+	hourly := testutils.NewConstraint("K8sRequiredLabels", "hourly")
+	hourly.SetSelfLink("/hourly")
+	obj := &unstructured.Unstructured{}
+	obj.SetKind("Namespace")
+	obj.SetName("default")
+	res := []*constraintTypes.Result{
+		{Constraint: continuous, Resource: obj, Msg: "missing owner", EnforcementAction: "deny"},
+		{Constraint: hourly, Resource: obj, Msg: "missing owner", EnforcementAction: "deny"},
+	}
+
+	tc := []struct {
+		Name     string
+		Selector string
+		Expected []string
+	}{
+		{Name: "No selector", Expected: []string{"/continuous", "/hourly"}},
+		{Name: "Selector", Selector: "audit-tier=continuous", Expected: []string{"/continuous"}},
+		{Name: "Negated selector", Selector: "audit-tier!=continuous", Expected: []string{"/hourly"}},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			selector, err := labels.Parse(tt.Selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			am := &Manager{log: log, constraintSelector: selector}
+			updateLists, totals, _, err := am.getUpdateListsFromAuditResponses(res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(updateLists) != len(tt.Expected) || len(totals) != len(tt.Expected) {
+				t.Errorf("got violations of %v, wanted %v", updateLists, tt.Expected)
+			}
+			for _, link := range tt.Expected {
+				if len(updateLists[link]) != 1 || totals[link] != 1 {
+					t.Errorf("missing the violation of %s", link)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+     http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"flag"
+	"reflect"
+	"time"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/controller/constraint"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var auditNamespaceLabelChanges = flag.Bool("audit-namespace-label-changes", true, "audit a namespace as soon as its labels change, updating the violations of constraints with a namespaceSelector in that namespace without waiting for the next audit")
+
+type namespaceRelabeler interface {
+	auditRelabeledNamespace(ctx context.Context, namespace string) error
+}
+
+// namespaceLabelsReconciler audits each namespace whose labels change
+type namespaceLabelsReconciler struct {
+	auditor namespaceRelabeler
+}
+
+func addNamespaceLabelsController(mgr manager.Manager, auditor namespaceRelabeler) error {
+	if !*auditNamespaceLabelChanges {
+		return nil
+	}
+	r := &namespaceLabelsReconciler{auditor: auditor}
+	c, err := controller.New("audit-namespace-labels-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	return c.Watch(&source.Kind{Type: &corev1.Namespace{}}, &handler.EnqueueRequestForObject{}, namespaceLabelsChanged)
+}
+
+// namespaceLabelsChanged passes the updates that change the labels of a namespace. The
+// objects of new namespaces are found by the next audit, like any other new object.
+var namespaceLabelsChanged = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return !labels.Equals(e.MetaOld.GetLabels(), e.MetaNew.GetLabels())
+	},
+	DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+	GenericFunc: func(e event.GenericEvent) bool { return false },
+}
+
+func (r *namespaceLabelsReconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	log.Info("namespace labels changed", logging.ResourceNamespace, request.Name)
+	return reconcile.Result{}, r.auditor.auditRelabeledNamespace(context.TODO(), request.Name)
+}
+
+// auditRelabeledNamespace reviews the objects of namespace against the constraints with a
+// namespaceSelector, whose selection of the namespace may have changed with its labels, and
+// replaces the violations of namespace in their status
+func (am *Manager) auditRelabeledNamespace(ctx context.Context, namespace string) error {
+	c, err := client.New(am.restConfig, client.Options{Scheme: am.mgr.GetScheme(), Mapper: nil})
+	if err != nil {
+		return err
+	}
+	gvks, err := am.getAllConstraintKinds()
+	if err != nil {
+		return err
+	}
+	selected := make(map[string]*unstructured.Unstructured)
+	var constraints []*unstructured.Unstructured
+	for _, gvk := range gvks {
+		instanceList := &unstructured.UnstructuredList{}
+		instanceList.SetGroupVersionKind(gvk)
+		if err := c.List(ctx, instanceList); err != nil {
+			return err
+		}
+		for i := range instanceList.Items {
+			item := &instanceList.Items[i]
+			if !am.selects(item) || !hasNamespaceSelector(item) {
+				continue
+			}
+			selected[constraint.ConstraintKey(item.GetKind(), item.GetName())] = item
+			constraints = append(constraints, item)
+		}
+	}
+	if len(constraints) == 0 {
+		return nil
+	}
+
+	l := log.WithValues(logging.EventType, "namespace_labels_audit", logging.ResourceNamespace, namespace)
+	res, _, err := am.reviewResources(ctx, c, l, namespace, matchedKinds(constraints), nil)
+	if err != nil {
+		// writing partial results would drop the violations of the objects not reviewed
+		return err
+	}
+	now := time.Now()
+	results := make(map[string][]auditResult)
+	for _, r := range am.dropExempt(ctx, c, l, namespace, res, now) {
+		key := constraint.ConstraintKey(r.Constraint.GetKind(), r.Constraint.GetName())
+		if _, ok := selected[key]; !ok {
+			continue
+		}
+		result := newAuditResult(r, r.Resource.(*unstructured.Unstructured))
+		results[key] = append(results[key], result)
+		logViolation(l, r.Constraint, r.EnforcementAction, result)
+	}
+
+	timestamp := now.UTC().Format(time.RFC3339)
+	for key, instance := range selected {
+		if err := writeNamespaceViolations(ctx, c, instance, namespace, results[key], timestamp); err != nil {
+			return err
+		}
+	}
+	l.Info("audited namespace after label change", "constraints", len(constraints))
+	return nil
+}
+
+// hasNamespaceSelector returns whether the match of c has a namespaceSelector
+func hasNamespaceSelector(c *unstructured.Unstructured) bool {
+	_, found, err := unstructured.NestedFieldNoCopy(c.Object, "spec", "match", "namespaceSelector")
+	return err == nil && found
+}
+
+// writeNamespaceViolations replaces the violations of namespace in the status of the latest
+// version of instance with auditResults. Constraints deleted since they were listed are
+// skipped.
+func writeNamespaceViolations(ctx context.Context, c client.Client, instance *unstructured.Unstructured, namespace string, auditResults []auditResult, timestamp string) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &unstructured.Unstructured{}
+		latest.SetGroupVersionKind(instance.GroupVersionKind())
+		if err := c.Get(ctx, types.NamespacedName{Name: instance.GetName()}, latest); err != nil {
+			return err
+		}
+		changed, err := mergeNamespaceViolations(latest, namespace, auditResults, timestamp)
+		if err != nil || !changed {
+			return err
+		}
+		return c.Status().Update(ctx, latest)
+	})
+	return client.IgnoreNotFound(err)
+}
+
+// mergeNamespaceViolations replaces the violations of namespace in the status of instance
+// with auditResults, found at timestamp, adjusting totalViolations and
+// violationsByNamespace to match. The violations of other namespaces and the
+// auditTimestamp of the last audit are kept. The new violations are listed first, up to
+// --constraint-violations-limit. It returns whether the status changed.
+func mergeNamespaceViolations(instance *unstructured.Unstructured, namespace string, auditResults []auditResult, timestamp string) (bool, error) {
+	before, _, err := unstructured.NestedFieldCopy(instance.Object, "status")
+	if err != nil {
+		return false, err
+	}
+	previous, _, err := unstructured.NestedInt64(instance.Object, "status", "violationsByNamespace", namespace)
+	if err != nil {
+		return false, err
+	}
+	total, _, err := unstructured.NestedInt64(instance.Object, "status", "totalViolations")
+	if err != nil {
+		return false, err
+	}
+	current := int64(len(auditResults))
+	total += current - previous
+	if total < current {
+		total = current
+	}
+	if err := unstructured.SetNestedField(instance.Object, total, "status", "totalViolations"); err != nil {
+		return false, err
+	}
+
+	byNamespace, _, err := unstructured.NestedMap(instance.Object, "status", "violationsByNamespace")
+	if err != nil {
+		return false, err
+	}
+	if byNamespace == nil {
+		byNamespace = make(map[string]interface{})
+	}
+	if current > 0 {
+		byNamespace[namespace] = current
+	} else {
+		delete(byNamespace, namespace)
+	}
+	if len(byNamespace) > 0 {
+		if err := unstructured.SetNestedMap(instance.Object, byNamespace, "status", "violationsByNamespace"); err != nil {
+			return false, err
+		}
+	} else {
+		unstructured.RemoveNestedField(instance.Object, "status", "violationsByNamespace")
+	}
+
+	existing, _, err := unstructured.NestedSlice(instance.Object, "status", "violations")
+	if err != nil {
+		return false, err
+	}
+	firstSeen := firstSeenByFingerprint(instance)
+	var statusViolations []interface{}
+	for _, ar := range auditResults {
+		if len(statusViolations) < *constraintViolationsLimit {
+			statusViolations = append(statusViolations, newStatusViolation(ar, firstSeen, timestamp))
+		}
+	}
+	violations, err := toUnstructuredViolations(statusViolations)
+	if err != nil {
+		return false, err
+	}
+	for _, v := range existing {
+		if len(violations) >= *constraintViolationsLimit {
+			break
+		}
+		if m, ok := v.(map[string]interface{}); ok && m["namespace"] == namespace {
+			continue
+		}
+		violations = append(violations, v)
+	}
+	if len(violations) > 0 {
+		if err := unstructured.SetNestedSlice(instance.Object, violations, "status", "violations"); err != nil {
+			return false, err
+		}
+	} else {
+		unstructured.RemoveNestedField(instance.Object, "status", "violations")
+	}
+
+	after, _, err := unstructured.NestedFieldNoCopy(instance.Object, "status")
+	if err != nil {
+		return false, err
+	}
+	return !reflect.DeepEqual(before, after), nil
+}
@@ -0,0 +1,156 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/testutils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestNamespaceLabelsChanged(t *testing.T) {
+	ns := func(labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: labels}}
+	}
+	tc := []struct {
+		Name     string
+		Old      map[string]string
+		New      map[string]string
+		Expected bool
+	}{
+		{Name: "Label added", Old: nil, New: map[string]string{"env": "prod"}, Expected: true},
+		{Name: "Label changed", Old: map[string]string{"env": "dev"}, New: map[string]string{"env": "prod"}, Expected: true},
+		{Name: "Label removed", Old: map[string]string{"env": "prod"}, New: map[string]string{}, Expected: true},
+		{Name: "Labels unchanged", Old: map[string]string{"env": "prod"}, New: map[string]string{"env": "prod"}, Expected: false},
+		{Name: "No labels", Old: nil, New: map[string]string{}, Expected: false},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			old, updated := ns(tt.Old), ns(tt.New)
+			e := event.UpdateEvent{MetaOld: old, ObjectOld: old, MetaNew: updated, ObjectNew: updated}
+			if got := namespaceLabelsChanged.Update(e); got != tt.Expected {
+				t.Errorf("Update() = %v, wanted %v", got, tt.Expected)
+			}
+		})
+	}
+	created := ns(map[string]string{"env": "prod"})
+	if namespaceLabelsChanged.Create(event.CreateEvent{Meta: created, Object: created}) {
+		t.Error("Create() = true, wanted new namespaces left to the next audit")
+	}
+}
+
+func TestWriteNamespaceViolations(t *testing.T) {
+	gvk := testutils.ConstraintGVK("K8sRequiredLabels")
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+	c := testutils.NewConstraint("K8sRequiredLabels", "c")
+	client := fake.NewFakeClientWithScheme(scheme, c)
+
+	defer func(limit int) { *constraintViolationsLimit = limit }(*constraintViolationsLimit)
+	*constraintViolationsLimit = 2
+
+	result := func(namespace, name string) auditResult {
+		return auditResult{cgvk: gvk, cname: "c", rkind: "ConfigMap", rnamespace: namespace, rname: name, message: "missing labels"}
+	}
+	get := func() *unstructured.Unstructured {
+		t.Helper()
+		got := &unstructured.Unstructured{}
+		got.SetGroupVersionKind(gvk)
+		if err := client.Get(context.TODO(), types.NamespacedName{Name: "c"}, got); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	// expect checks the status against the total, the count of each namespace and the
+	// namespaces of the listed violations, in order
+	expect := func(total int64, counts map[string]int64, listed ...string) {
+		t.Helper()
+		got := get()
+		if n, _, _ := unstructured.NestedInt64(got.Object, "status", "totalViolations"); n != total {
+			t.Errorf("totalViolations = %d, wanted %d", n, total)
+		}
+		byNamespace, _, _ := unstructured.NestedMap(got.Object, "status", "violationsByNamespace")
+		if len(byNamespace) != len(counts) {
+			t.Errorf("violationsByNamespace = %v, wanted %v", byNamespace, counts)
+		}
+		for ns, n := range counts {
+			if got, _ := byNamespace[ns].(int64); got != n {
+				t.Errorf("violations in %s = %v, wanted %d", ns, byNamespace[ns], n)
+			}
+		}
+		violations, _, _ := unstructured.NestedSlice(got.Object, "status", "violations")
+		var namespaces []string
+		for _, v := range violations {
+			namespaces = append(namespaces, v.(map[string]interface{})["namespace"].(string))
+		}
+		if len(namespaces) != len(listed) {
+			t.Fatalf("violations in namespaces %v, wanted %v", namespaces, listed)
+		}
+		for i := range listed {
+			if namespaces[i] != listed[i] {
+				t.Errorf("violations in namespaces %v, wanted %v", namespaces, listed)
+				break
+			}
+		}
+		if ts, _, _ := unstructured.NestedString(got.Object, "status", "auditTimestamp"); ts != "t1" {
+			t.Errorf("auditTimestamp = %q, wanted the last audit's t1", ts)
+		}
+	}
+
+	// the last full audit found one violation in each of team-a and team-b, and one
+	// of a cluster-scoped resource
+	ucloop := &updateConstraintLoop{client: client}
+	if err := ucloop.updateConstraintStatus(context.TODO(), get(), []auditResult{result("team-a", "x"), result("team-b", "y"), result("", "z")}, "t1", 3); err != nil {
+		t.Fatal(err)
+	}
+	expect(3, map[string]int64{"team-a": 1, "team-b": 1}, "team-a", "team-b")
+
+	// a label change of team-b finds a second violation there. The new violations are
+	// listed first, pushing team-a's out of the limit of 2
+	if err := writeNamespaceViolations(context.TODO(), client, c, "team-b", []auditResult{result("team-b", "y"), result("team-b", "w")}, "t2"); err != nil {
+		t.Fatal(err)
+	}
+	expect(4, map[string]int64{"team-a": 1, "team-b": 2}, "team-b", "team-b")
+	for _, v := range mustViolations(t, get()) {
+		if v["name"] == "y" && v["firstSeen"] != "t1" {
+			t.Errorf("firstSeen of a violation already listed = %v, wanted t1", v["firstSeen"])
+		}
+		if v["name"] == "w" && (v["firstSeen"] != "t2" || v["lastSeen"] != "t2") {
+			t.Errorf("new violation seen %v to %v, wanted t2", v["firstSeen"], v["lastSeen"])
+		}
+	}
+
+	// team-b is no longer selected. team-a's violation is still counted, and listed
+	// again by the next audit
+	if err := writeNamespaceViolations(context.TODO(), client, c, "team-b", nil, "t3"); err != nil {
+		t.Fatal(err)
+	}
+	expect(2, map[string]int64{"team-a": 1})
+
+	// constraints deleted in the meantime are skipped
+	if err := client.Delete(context.TODO(), get()); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeNamespaceViolations(context.TODO(), client, c, "team-a", nil, "t4"); err != nil {
+		t.Errorf("writeNamespaceViolations() of a deleted constraint: error = %v", err)
+	}
+}
+
+func mustViolations(t *testing.T, instance *unstructured.Unstructured) []map[string]interface{} {
+	t.Helper()
+	violations, _, err := unstructured.NestedSlice(instance.Object, "status", "violations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ret []map[string]interface{}
+	for _, v := range violations {
+		ret = append(ret, v.(map[string]interface{}))
+	}
+	return ret
+}
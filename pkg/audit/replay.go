@@ -0,0 +1,99 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// replayReady receives a value when the webhook recovers and objects it admitted without
+// review are waiting to be re-checked. It never receives if there is no replay queue.
+func (am *Manager) replayReady() <-chan struct{} {
+	if am.replayQueue == nil {
+		return nil
+	}
+	return am.replayQueue.Ready()
+}
+
+// replay re-checks the objects the webhook admitted without review, reading them through
+// reader, and flags the ones that violate a constraint with a log entry and a
+// ReplayedViolation event. Constraint statuses are left to the next audit.
+func (am *Manager) replay(ctx context.Context, reader client.Reader) {
+	refs := am.replayQueue.Drain()
+	if len(refs) == 0 {
+		return
+	}
+	l := log.WithValues(logging.EventType, "replay")
+	now := time.Now()
+	var violations int64
+	for _, ref := range refs {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(ref.GroupVersionKind)
+		if err := reader.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, obj); err != nil {
+			if !apierrors.IsNotFound(err) {
+				l.Error(err, "Unable to read object admitted without review", logging.ResourceKind, ref.Kind, logging.ResourceNamespace, ref.Namespace, logging.ResourceName, ref.Name)
+			}
+			continue
+		}
+		ns := &corev1.Namespace{}
+		if ref.Namespace != "" {
+			if err := reader.Get(ctx, types.NamespacedName{Name: ref.Namespace}, ns); err != nil {
+				l.Error(err, "Unable to look up object namespace", logging.ResourceKind, ref.Kind, logging.ResourceNamespace, ref.Namespace, logging.ResourceName, ref.Name)
+				continue
+			}
+		}
+		resp, err := am.opa.Review(ctx, target.AugmentedUnstructured{Object: *obj, Namespace: ns})
+		if err != nil {
+			l.Error(err, "Unable to review object admitted without review", logging.ResourceKind, ref.Kind, logging.ResourceNamespace, ref.Namespace, logging.ResourceName, ref.Name)
+			continue
+		}
+		for _, r := range resp.Results() {
+			if am.exemptions != nil && am.exemptions.Exempted(r.Constraint.GetKind(), r.Constraint.GetName(), ref.Kind, ref.Namespace, ns.GetLabels(), ref.Name, now) != "" {
+				continue
+			}
+			violations++
+			l.Info(
+				r.Msg,
+				logging.EventType, "violation_replayed",
+				logging.ConstraintKind, r.Constraint.GetKind(),
+				logging.ConstraintName, r.Constraint.GetName(),
+				logging.ConstraintAction, r.EnforcementAction,
+				logging.ConstraintOwner, util.GetOwner(r.Constraint),
+				logging.ResourceKind, ref.Kind,
+				logging.ResourceNamespace, ref.Namespace,
+				logging.ResourceName, ref.Name,
+			)
+			if am.recorder != nil {
+				am.recorder.Eventf(obj, corev1.EventTypeWarning, "ReplayedViolation", "admitted without review, but violates %s %s: %s%s%s",
+					r.Constraint.GetKind(), r.Constraint.GetName(), truncateString(r.Msg, msgSize), util.DescribeOwner(r.Constraint), util.DescribeAnnotations(r.Constraint, am.annotations))
+			}
+		}
+	}
+	l.Info("re-checked objects admitted without review", "objects", len(refs), "violations", violations)
+	if err := am.reporter.reportReplayed(violations); err != nil {
+		l.Error(err, "failed to report replayed violations")
+	}
+}
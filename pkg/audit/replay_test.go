@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/local"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/gatekeeper/pkg/replay"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	"github.com/open-policy-agent/gatekeeper/pkg/testutils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReplay(t *testing.T) {
+	backend, err := opa.NewBackend(opa.Driver(local.New(local.Tracing(false))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := backend.NewClient(opa.Targets(&target.K8sValidationTarget{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	templ := &templates.ConstraintTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "k8sdenyall"},
+		Spec: templates.ConstraintTemplateSpec{
+			CRD: templates.CRD{Spec: templates.CRDSpec{Names: templates.Names{Kind: "K8sDenyAll"}}},
+			Targets: []templates.Target{{
+				Target: "admission.k8s.gatekeeper.sh",
+				Rego: `package denyall
+
+violation[{"msg": "denied"}] {
+  true
+}
+`,
+			}},
+		},
+	}
+	ctx := context.Background()
+	if _, err := c.AddTemplate(ctx, templ); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.AddConstraint(ctx, testutils.NewConstraint("K8sDenyAll", "deny-all")); err != nil {
+		t.Fatal(err)
+	}
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "admitted"}}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	reader := fake.NewFakeClient(cm, ns)
+
+	q := replay.NewQueue(func() int { return 10 })
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	q.Add(replay.Ref{GroupVersionKind: gvk, Namespace: "default", Name: "admitted"})
+	// deleted since it was admitted
+	q.Add(replay.Ref{GroupVersionKind: gvk, Namespace: "default", Name: "gone"})
+
+	reporter, err := newStatsReporter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := record.NewFakeRecorder(10)
+	am := &Manager{opa: c, reporter: reporter, recorder: recorder, replayQueue: q}
+	am.replay(ctx, reader)
+
+	if q.Len() != 0 {
+		t.Errorf("queue holds %d refs after replay, wanted none", q.Len())
+	}
+	if len(recorder.Events) != 1 {
+		t.Fatalf("got %d events, wanted 1", len(recorder.Events))
+	}
+	if e := <-recorder.Events; !strings.HasPrefix(e, "Warning ReplayedViolation") || !strings.Contains(e, "K8sDenyAll deny-all: denied") {
+		t.Errorf("event = %q, wanted a ReplayedViolation of deny-all", e)
+	}
+}
@@ -0,0 +1,189 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"sort"
+	"time"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// IntervalAnnotation sets how often audit reports on a constraint, as a duration such as
+// 1h. Intervals are rounded up to a multiple of --audit-interval, which is also the
+// interval of constraints without the annotation.
+const IntervalAnnotation = "audit.gatekeeper.sh/interval"
+
+// constraintInterval returns the audit interval of c, rounded up to a multiple of base
+func constraintInterval(c *unstructured.Unstructured, base time.Duration) time.Duration {
+	value, ok := c.GetAnnotations()[IntervalAnnotation]
+	if !ok || base <= 0 {
+		return base
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Error(err, "ignoring invalid audit interval", "annotation", IntervalAnnotation, "constraintKind", c.GetKind(), "constraintName", c.GetName())
+		return base
+	}
+	if d <= base {
+		return base
+	}
+	return (d + base - 1) / base * base
+}
+
+// auditSchedule groups constraints into buckets by their audit interval, so that each
+// audit only reports on the constraints of the buckets that are due
+type auditSchedule struct {
+	// lastRun is when the bucket of each interval was last audited
+	lastRun map[time.Duration]time.Time
+	// carried holds the results of each constraint, keyed by selfLink, from the last
+	// audit of its bucket. They stand in for the constraint in the audits in between, so
+	// metrics and transitions do not change until it is audited again.
+	carried map[string][]auditResult
+}
+
+func newAuditSchedule() *auditSchedule {
+	return &auditSchedule{
+		lastRun: make(map[time.Duration]time.Time),
+		carried: make(map[string][]auditResult),
+	}
+}
+
+// auditPlan is what a single audit reports on
+type auditPlan struct {
+	// buckets are the intervals of the buckets that are due
+	buckets []time.Duration
+	// due holds the selfLinks of the constraints of the buckets that are due. All
+	// constraints are due if it is nil.
+	due map[string]bool
+	// listed holds the selfLinks of every constraint
+	listed map[string]bool
+	// kinds are the kinds matched by the constraints that are due, or nil if they may
+	// match any kind
+	kinds map[string]bool
+	// versions are the group versions the constraints that are due select explicitly
+	versions map[schema.GroupVersion]map[string]bool
+}
+
+// includes returns whether the results of c are reported by the audit
+func (p *auditPlan) includes(c *unstructured.Unstructured) bool {
+	return p == nil || p.due == nil || p.due[c.GetSelfLink()]
+}
+
+// plan returns the plan of an audit starting at now, where base is --audit-interval. All
+// buckets are due if all is set. It returns nil if there are constraints but none of
+// their buckets is due.
+func (s *auditSchedule) plan(constraints []unstructured.Unstructured, base time.Duration, now time.Time, all bool) *auditPlan {
+	p := &auditPlan{listed: make(map[string]bool, len(constraints))}
+	byInterval := make(map[time.Duration][]*unstructured.Unstructured)
+	for i := range constraints {
+		c := &constraints[i]
+		p.listed[c.GetSelfLink()] = true
+		interval := constraintInterval(c, base)
+		byInterval[interval] = append(byInterval[interval], c)
+	}
+	var due []*unstructured.Unstructured
+	for interval, bucket := range byInterval {
+		last, ok := s.lastRun[interval]
+		if all || !ok || now.Sub(last) >= interval {
+			p.buckets = append(p.buckets, interval)
+			due = append(due, bucket...)
+		}
+	}
+	sort.Slice(p.buckets, func(i, j int) bool { return p.buckets[i] < p.buckets[j] })
+	if len(p.buckets) == len(byInterval) {
+		// every bucket is due, including when there are no constraints
+		p.versions = namedVersions(due)
+		return p
+	}
+	if len(p.buckets) == 0 {
+		return nil
+	}
+	p.due = make(map[string]bool, len(due))
+	for _, c := range due {
+		p.due[c.GetSelfLink()] = true
+	}
+	p.kinds = matchedKinds(due)
+	p.versions = namedVersions(due)
+	return p
+}
+
+// matchedKinds returns the kinds listed by the match of constraints, or nil if any of
+// them may match any kind
+func matchedKinds(constraints []*unstructured.Unstructured) map[string]bool {
+	kinds := make(map[string]bool)
+	for _, c := range constraints {
+		matchKinds, found, err := unstructured.NestedSlice(c.Object, "spec", "match", "kinds")
+		if err != nil || !found || len(matchKinds) == 0 {
+			return nil
+		}
+		for _, mk := range matchKinds {
+			entry, ok := mk.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			names, _, err := unstructured.NestedStringSlice(entry, "kinds")
+			if err != nil || len(names) == 0 {
+				return nil
+			}
+			for _, name := range names {
+				if name == "*" {
+					return nil
+				}
+				kinds[name] = true
+			}
+		}
+	}
+	return kinds
+}
+
+// carry adds the carried results of the constraints that are not due to updateLists and
+// the totals, and carries the results of the due constraints until their next audit
+func (s *auditSchedule) carry(p *auditPlan, updateLists map[string][]auditResult, totalsPerConstraint map[string]int64, totalsPerAction map[util.EnforcementAction]int64) {
+	for link := range s.carried {
+		if !p.listed[link] {
+			delete(s.carried, link)
+		}
+	}
+	for link := range p.listed {
+		if p.due == nil || p.due[link] {
+			if results, ok := updateLists[link]; ok {
+				s.carried[link] = results
+			} else {
+				delete(s.carried, link)
+			}
+			continue
+		}
+		results, ok := s.carried[link]
+		if !ok {
+			continue
+		}
+		updateLists[link] = results
+		totalsPerConstraint[link] = int64(len(results))
+		for _, ar := range results {
+			totalsPerAction[util.EnforcementAction(ar.enforcementAction)]++
+		}
+	}
+}
+
+// finish records that the due buckets of p were audited at start
+func (s *auditSchedule) finish(p *auditPlan, start time.Time) {
+	for _, interval := range p.buckets {
+		s.lastRun[interval] = start
+	}
+}
@@ -0,0 +1,131 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/testutils"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newScheduledConstraint(name, interval string, kinds ...interface{}) unstructured.Unstructured {
+	c := testutils.NewConstraint("K8sRequiredLabels", name)
+	c.SetSelfLink("/" + name)
+	if interval != "" {
+		c.SetAnnotations(map[string]string{IntervalAnnotation: interval})
+	}
+	if len(kinds) > 0 {
+		match := []interface{}{map[string]interface{}{"apiGroups": []interface{}{""}, "kinds": kinds}}
+		if err := unstructured.SetNestedSlice(c.Object, match, "spec", "match", "kinds"); err != nil {
+			panic(err)
+		}
+	}
+	return *c
+}
+
+func TestConstraintInterval(t *testing.T) {
+	base := time.Minute
+	tc := []struct {
+		Name     string
+		Interval string
+		Expected time.Duration
+	}{
+		{Name: "No annotation", Expected: base},
+		{Name: "Multiple of base", Interval: "1h", Expected: time.Hour},
+		{Name: "Rounded up", Interval: "90s", Expected: 2 * time.Minute},
+		{Name: "Shorter than base", Interval: "10s", Expected: base},
+		{Name: "Invalid", Interval: "hourly", Expected: base},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			c := newScheduledConstraint("c", tt.Interval)
+			if got := constraintInterval(&c, base); got != tt.Expected {
+				t.Errorf("got interval %v, wanted %v", got, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestSchedulePlan(t *testing.T) {
+	base := time.Minute
+	constraints := []unstructured.Unstructured{
+		newScheduledConstraint("cheap", "", "Pod"),
+		newScheduledConstraint("expensive", "1h", "Ingress"),
+	}
+	s := newAuditSchedule()
+	start := time.Now()
+
+	p := s.plan(constraints, base, start, false)
+	if p == nil || p.due != nil || p.kinds != nil {
+		t.Fatalf("first audit should report on every constraint, got %+v", p)
+	}
+	s.finish(p, start)
+
+	p = s.plan(constraints, base, start.Add(30*time.Second), false)
+	if p != nil {
+		t.Errorf("no bucket should be due, got %+v", p)
+	}
+
+	p = s.plan(constraints, base, start.Add(2*time.Minute), false)
+	if p == nil {
+		t.Fatal("cheap bucket should be due")
+	}
+	if !reflect.DeepEqual(p.due, map[string]bool{"/cheap": true}) {
+		t.Errorf("got due constraints %v, wanted only /cheap", p.due)
+	}
+	if !reflect.DeepEqual(p.kinds, map[string]bool{"Pod": true}) {
+		t.Errorf("got kinds %v, wanted only Pod", p.kinds)
+	}
+	s.finish(p, start.Add(2*time.Minute))
+
+	if p = s.plan(constraints, base, start.Add(2*time.Minute), true); p == nil || p.due != nil {
+		t.Errorf("on demand audit should report on every constraint, got %+v", p)
+	}
+
+	if p = s.plan(constraints, base, start.Add(time.Hour), false); p == nil || p.due != nil {
+		t.Errorf("both buckets should be due after an hour, got %+v", p)
+	}
+
+	wildcard := append(constraints, newScheduledConstraint("any", "", "*"))
+	p = s.plan(wildcard, base, start.Add(3*time.Minute), false)
+	if p == nil || p.kinds != nil {
+		t.Errorf("kinds should not be narrowed for a constraint matching any kind, got %+v", p)
+	}
+}
+
+func TestScheduleCarry(t *testing.T) {
+	cheap := newScheduledConstraint("cheap", "")
+	expensive := newScheduledConstraint("expensive", "1h")
+	s := newAuditSchedule()
+	all := &auditPlan{listed: map[string]bool{"/cheap": true, "/expensive": true}}
+	s.carry(all, map[string][]auditResult{
+		"/cheap":     {{rname: "a", enforcementAction: "deny"}},
+		"/expensive": {{rname: "b", enforcementAction: "dryrun"}, {rname: "c", enforcementAction: "dryrun"}},
+	}, map[string]int64{}, map[util.EnforcementAction]int64{})
+
+	due := &auditPlan{listed: all.listed, due: map[string]bool{cheap.GetSelfLink(): true}}
+	updateLists := map[string][]auditResult{}
+	totals := map[string]int64{}
+	totalsPerAction := map[util.EnforcementAction]int64{util.Deny: 0, util.Dryrun: 0}
+	s.carry(due, updateLists, totals, totalsPerAction)
+
+	if len(updateLists[cheap.GetSelfLink()]) != 0 {
+		t.Errorf("resolved violations of due constraint should not be carried, got %v", updateLists[cheap.GetSelfLink()])
+	}
+	if len(updateLists[expensive.GetSelfLink()]) != 2 || totals[expensive.GetSelfLink()] != 2 {
+		t.Errorf("violations of constraint that is not due should be carried, got %v", updateLists)
+	}
+	if totalsPerAction[util.Dryrun] != 2 || totalsPerAction[util.Deny] != 0 {
+		t.Errorf("got totals per action %v, wanted 2 dryrun", totalsPerAction)
+	}
+	if _, ok := s.carried[cheap.GetSelfLink()]; ok {
+		t.Error("carried violations of due constraint should be replaced")
+	}
+
+	s.carry(&auditPlan{listed: map[string]bool{"/cheap": true}}, map[string][]auditResult{}, map[string]int64{}, map[util.EnforcementAction]int64{})
+	if _, ok := s.carried[expensive.GetSelfLink()]; ok {
+		t.Error("violations of deleted constraint should not be carried")
+	}
+}
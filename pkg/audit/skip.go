@@ -0,0 +1,62 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+     http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	auditExcludedKinds = flag.String("audit-excluded-kinds", "", "comma-separated list of kinds not audited, as Kind for the core group or group/Kind, e.g. Event,Endpoints,coordination.k8s.io/Lease. Only applies when auditing via the discovery client")
+	auditMaxObjectSize = flag.Int("audit-max-object-size", 0, "objects whose JSON encoding is larger than this many bytes are not audited. 0 for no limit. Only applies when auditing via the discovery client")
+)
+
+// parseExcludedKinds parses a list such as Event,coordination.k8s.io/Lease
+func parseExcludedKinds(s string) (map[schema.GroupKind]bool, error) {
+	kinds := make(map[schema.GroupKind]bool)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		gk := schema.GroupKind{Kind: entry}
+		if i := strings.LastIndex(entry, "/"); i >= 0 {
+			gk = schema.GroupKind{Group: entry[:i], Kind: entry[i+1:]}
+		}
+		if gk.Kind == "" {
+			return nil, fmt.Errorf("invalid excluded kind %q", entry)
+		}
+		kinds[gk] = true
+	}
+	return kinds, nil
+}
+
+// tooLarge reports whether obj is larger than maxSize bytes when encoded as JSON. A
+// maxSize of 0 or less means no limit.
+func tooLarge(obj *unstructured.Unstructured, maxSize int) bool {
+	if maxSize <= 0 {
+		return false
+	}
+	b, err := json.Marshal(obj.Object)
+	if err != nil {
+		// the review would fail to encode it as well
+		return false
+	}
+	return len(b) > maxSize
+}
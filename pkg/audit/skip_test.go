@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestParseExcludedKinds(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Input    string
+		Expected []schema.GroupKind
+		Error    bool
+	}{
+		{Name: "Empty"},
+		{Name: "Core kind", Input: "Event", Expected: []schema.GroupKind{{Kind: "Event"}}},
+		{
+			Name:     "Grouped kinds",
+			Input:    " Endpoints, coordination.k8s.io/Lease ,",
+			Expected: []schema.GroupKind{{Kind: "Endpoints"}, {Group: "coordination.k8s.io", Kind: "Lease"}},
+		},
+		{Name: "Missing kind", Input: "apps/", Error: true},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			got, err := parseExcludedKinds(tt.Input)
+			if (err != nil) != tt.Error {
+				t.Fatalf("parseExcludedKinds() error = %v, wanted error: %v", err, tt.Error)
+			}
+			if len(got) != len(tt.Expected) {
+				t.Errorf("parseExcludedKinds() = %v, wanted %v", got, tt.Expected)
+			}
+			for _, gk := range tt.Expected {
+				if !got[gk] {
+					t.Errorf("parseExcludedKinds() = %v, missing %v", got, gk)
+				}
+			}
+		})
+	}
+}
+
+func TestTooLarge(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"data": map[string]interface{}{"key": strings.Repeat("x", 1000)},
+	}}
+	tc := []struct {
+		Name     string
+		MaxSize  int
+		Expected bool
+	}{
+		{Name: "No limit", MaxSize: 0},
+		{Name: "Under limit", MaxSize: 2000},
+		{Name: "Over limit", MaxSize: 1000, Expected: true},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			if got := tooLarge(obj, tt.MaxSize); got != tt.Expected {
+				t.Errorf("tooLarge() = %v, wanted %v", got, tt.Expected)
+			}
+		})
+	}
+}
@@ -15,12 +15,20 @@ const (
 	violationsMetricName    = "violations"
 	auditDurationMetricName = "audit_duration_seconds"
 	lastRunTimeMetricName   = "audit_last_run_time"
+	newViolationsName       = "violations_new_total"
+	resolvedViolationsName  = "violations_resolved_total"
+	skippedObjectsName      = "audit_skipped_objects_total"
+	replayedViolationsName  = "violations_replayed_total"
 )
 
 var (
-	violationsM    = stats.Int64(violationsMetricName, "Total number of violations per constraint", stats.UnitDimensionless)
-	auditDurationM = stats.Float64(auditDurationMetricName, "Latency of audit operation in seconds", stats.UnitSeconds)
-	lastRunTimeM   = stats.Float64(lastRunTimeMetricName, "Timestamp of last audit run time", stats.UnitSeconds)
+	violationsM         = stats.Int64(violationsMetricName, "Total number of violations per constraint", stats.UnitDimensionless)
+	auditDurationM      = stats.Float64(auditDurationMetricName, "Latency of audit operation in seconds", stats.UnitSeconds)
+	lastRunTimeM        = stats.Float64(lastRunTimeMetricName, "Timestamp of last audit run time", stats.UnitSeconds)
+	newViolationsM      = stats.Int64(newViolationsName, "Violations found by an audit that the previous audit did not find", stats.UnitDimensionless)
+	resolvedViolationsM = stats.Int64(resolvedViolationsName, "Violations found by the previous audit that an audit no longer found", stats.UnitDimensionless)
+	skippedObjectsM     = stats.Int64(skippedObjectsName, "Objects not audited because they are larger than --audit-max-object-size", stats.UnitDimensionless)
+	replayedViolationsM = stats.Int64(replayedViolationsName, "Violations found by re-checking objects the webhook admitted without review", stats.UnitDimensionless)
 
 	enforcementActionKey = tag.MustNewKey("enforcement_action")
 )
@@ -50,6 +58,28 @@ func register() error {
 			Description: "Timestamp of last audit run time",
 			Aggregation: view.LastValue(),
 		},
+		{
+			Name:        newViolationsName,
+			Measure:     newViolationsM,
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{enforcementActionKey},
+		},
+		{
+			Name:        resolvedViolationsName,
+			Measure:     resolvedViolationsM,
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{enforcementActionKey},
+		},
+		{
+			Name:        skippedObjectsName,
+			Measure:     skippedObjectsM,
+			Aggregation: view.Sum(),
+		},
+		{
+			Name:        replayedViolationsName,
+			Measure:     replayedViolationsM,
+			Aggregation: view.Sum(),
+		},
 	}
 	return view.Register(views...)
 }
@@ -65,6 +95,31 @@ func (r *reporter) reportTotalViolations(enforcementAction util.EnforcementActio
 	return r.report(ctx, violationsM.M(v))
 }
 
+// reportTransitions records the violations an audit introduced and resolved
+func (r *reporter) reportTransitions(enforcementAction util.EnforcementAction, introduced, resolved int64) error {
+	ctx, err := tag.New(
+		r.ctx,
+		tag.Insert(enforcementActionKey, string(enforcementAction)))
+	if err != nil {
+		return err
+	}
+
+	if err := r.report(ctx, newViolationsM.M(introduced)); err != nil {
+		return err
+	}
+	return r.report(ctx, resolvedViolationsM.M(resolved))
+}
+
+// reportSkipped records the objects an audit did not review because of their size
+func (r *reporter) reportSkipped(skipped int64) error {
+	return r.report(r.ctx, skippedObjectsM.M(skipped))
+}
+
+// reportReplayed records the violations found by re-checking objects admitted without review
+func (r *reporter) reportReplayed(violations int64) error {
+	return r.report(r.ctx, replayedViolationsM.M(violations))
+}
+
 func (r *reporter) reportLatency(d time.Duration) error {
 	ctx, err := tag.New(r.ctx)
 	if err != nil {
@@ -73,6 +73,51 @@ func TestReportLatency(t *testing.T) {
 	}
 }
 
+func TestReportSkipped(t *testing.T) {
+	r, err := newStatsReporter()
+	if err != nil {
+		t.Errorf("newStatsReporter() error %v", err)
+	}
+	for _, skipped := range []int64{2, 3} {
+		if err := r.reportSkipped(skipped); err != nil {
+			t.Errorf("reportSkipped error %v", err)
+		}
+	}
+	row := checkData(t, skippedObjectsName, 1)
+	value, ok := row.Data.(*view.SumData)
+	if !ok {
+		t.Fatal("reportSkipped should have aggregation Sum()")
+	}
+	if value.Value != 5 {
+		t.Errorf("Metric: %v - Expected 5, got %v", skippedObjectsName, value.Value)
+	}
+}
+
+func TestReportReplayed(t *testing.T) {
+	r, err := newStatsReporter()
+	if err != nil {
+		t.Errorf("newStatsReporter() error %v", err)
+	}
+	// TestReplay may have recorded violations already
+	var before float64
+	if rows, err := view.RetrieveData(replayedViolationsName); err == nil && len(rows) > 0 {
+		before = rows[0].Data.(*view.SumData).Value
+	}
+	for _, violations := range []int64{1, 0} {
+		if err := r.reportReplayed(violations); err != nil {
+			t.Errorf("reportReplayed error %v", err)
+		}
+	}
+	row := checkData(t, replayedViolationsName, 1)
+	value, ok := row.Data.(*view.SumData)
+	if !ok {
+		t.Fatal("reportReplayed should have aggregation Sum()")
+	}
+	if value.Value-before != 1 {
+		t.Errorf("Metric: %v - Expected 1 more, got %v", replayedViolationsName, value.Value-before)
+	}
+}
+
 func checkData(t *testing.T, name string, expectedRowLength int) *view.Row {
 	row, err := view.RetrieveData(name)
 	if err != nil {
@@ -112,3 +157,27 @@ func TestLastRestartCheck(t *testing.T) {
 		t.Errorf("Metric: %v - Expected %v, got %v", lastRunTimeMetricName, expectedTs, value.Value)
 	}
 }
+
+func TestReportTransitions(t *testing.T) {
+	r, err := newStatsReporter()
+	if err != nil {
+		t.Errorf("newStatsReporter() error %v", err)
+	}
+	if err := r.reportTransitions("deny", 3, 1); err != nil {
+		t.Errorf("reportTransitions error %v", err)
+	}
+	if err := r.reportTransitions("deny", 2, 0); err != nil {
+		t.Errorf("reportTransitions error %v", err)
+	}
+	for name, expected := range map[string]float64{newViolationsName: 5, resolvedViolationsName: 1} {
+		row := checkData(t, name, 1)
+		value, ok := row.Data.(*view.SumData)
+		if !ok {
+			t.Errorf("%v should have aggregation Sum()", name)
+			continue
+		}
+		if value.Value != expected {
+			t.Errorf("Metric: %v - Expected %v, got %v", name, expected, value.Value)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	csutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// statusWriteDegradedConflicts is the number of conflicting status updates of a
+// constraint, within one audit, after which it is marked StatusWriteDegraded
+const statusWriteDegradedConflicts = 3
+
+// writeStatus updates the status of instance. Once its earlier updates in this loop
+// conflicted statusWriteDegradedConflicts times, the update also sets the
+// StatusWriteDegraded condition, naming the other field managers of instance. Otherwise
+// the condition is removed.
+func (ucloop *updateConstraintLoop) writeStatus(ctx context.Context, instance *unstructured.Unstructured) error {
+	link := instance.GetSelfLink()
+	conflicts := ucloop.conflicts[link]
+	var condition map[string]interface{}
+	if conflicts >= statusWriteDegradedConflicts {
+		managers := competingManagers(instance)
+		condition = map[string]interface{}{
+			"type":    csutil.StatusWriteDegradedCondition,
+			"message": statusWriteDegradedMessage(conflicts, managers),
+		}
+		log.Info("constraint status updates keep conflicting", "constraintName", instance.GetName(), "conflicts", conflicts, "managers", managers)
+	}
+	if err := setStatusWriteDegraded(instance, condition); err != nil {
+		return err
+	}
+	err := ucloop.client.Status().Update(ctx, instance)
+	if apierrors.IsConflict(err) {
+		if ucloop.conflicts == nil {
+			ucloop.conflicts = make(map[string]int)
+		}
+		ucloop.conflicts[link]++
+	}
+	return err
+}
+
+// setStatusWriteDegraded replaces the StatusWriteDegraded condition in the status of
+// instance with condition, or removes it if condition is nil
+func setStatusWriteDegraded(instance *unstructured.Unstructured, condition map[string]interface{}) error {
+	conditions, _, err := unstructured.NestedSlice(instance.Object, "status", "conditions")
+	if err != nil {
+		return err
+	}
+	var kept []interface{}
+	for _, c := range conditions {
+		if m, ok := c.(map[string]interface{}); ok && m["type"] == csutil.StatusWriteDegradedCondition {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	if condition != nil {
+		kept = append(kept, condition)
+	}
+	if len(kept) == 0 {
+		unstructured.RemoveNestedField(instance.Object, "status", "conditions")
+		return nil
+	}
+	return unstructured.SetNestedSlice(instance.Object, kept, "status", "conditions")
+}
+
+// competingManagers returns the field managers other than audit that wrote instance,
+// most recent first
+func competingManagers(instance *unstructured.Unstructured) []string {
+	var entries []metav1.ManagedFieldsEntry
+	for _, e := range instance.GetManagedFields() {
+		if e.Manager != auditUserAgent {
+			entries = append(entries, e)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[j].Time == nil {
+			return entries[i].Time != nil
+		}
+		return entries[i].Time != nil && entries[j].Time.Before(entries[i].Time)
+	})
+	seen := make(map[string]bool)
+	var managers []string
+	for _, e := range entries {
+		if !seen[e.Manager] {
+			seen[e.Manager] = true
+			managers = append(managers, e.Manager)
+		}
+	}
+	return managers
+}
+
+func statusWriteDegradedMessage(conflicts int, managers []string) string {
+	if len(managers) == 0 {
+		return fmt.Sprintf("%d status updates by audit conflicted with other writes, whose field managers are not known", conflicts)
+	}
+	return fmt.Sprintf("%d status updates by audit conflicted with other writes, by field managers %s", conflicts, strings.Join(managers, ", "))
+}
@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/testutils"
+	csutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// conflictingClient fails the first conflicts status updates with a conflict
+type conflictingClient struct {
+	client.Client
+	conflicts int
+}
+
+func (c *conflictingClient) Status() client.StatusWriter {
+	return &conflictingStatusWriter{StatusWriter: c.Client.Status(), c: c}
+}
+
+type conflictingStatusWriter struct {
+	client.StatusWriter
+	c *conflictingClient
+}
+
+func (w *conflictingStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if w.c.conflicts > 0 {
+		w.c.conflicts--
+		return apierrors.NewConflict(schema.GroupResource{Resource: "k8srequiredlabels"}, "c", nil)
+	}
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func degradedCondition(t *testing.T, u *unstructured.Unstructured) map[string]interface{} {
+	conditions, _, err := unstructured.NestedSlice(u.Object, "status", "conditions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range conditions {
+		if m, ok := c.(map[string]interface{}); ok && m["type"] == csutil.StatusWriteDegradedCondition {
+			return m
+		}
+	}
+	return nil
+}
+
+func TestWriteStatusDegraded(t *testing.T) {
+	gvk := testutils.ConstraintGVK("K8sRequiredLabels")
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+	c := testutils.NewConstraint("K8sRequiredLabels", "c")
+	c.SetSelfLink("/c")
+	earlier, later := metav1.NewTime(time.Unix(100, 0)), metav1.NewTime(time.Unix(200, 0))
+	c.SetManagedFields([]metav1.ManagedFieldsEntry{
+		{Manager: "policy-sync", Operation: metav1.ManagedFieldsOperationUpdate, Time: &earlier},
+		{Manager: auditUserAgent, Operation: metav1.ManagedFieldsOperationUpdate, Time: &later},
+		{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationUpdate, Time: &later},
+	})
+	cl := &conflictingClient{Client: fake.NewFakeClientWithScheme(scheme, c.DeepCopy()), conflicts: statusWriteDegradedConflicts}
+	ucloop := &updateConstraintLoop{client: cl}
+
+	for i := 0; i < statusWriteDegradedConflicts; i++ {
+		if err := ucloop.writeStatus(context.TODO(), c.DeepCopy()); !apierrors.IsConflict(err) {
+			t.Fatalf("wanted a conflict, got %v", err)
+		}
+	}
+	degraded := c.DeepCopy()
+	if err := ucloop.writeStatus(context.TODO(), degraded); err != nil {
+		t.Fatal(err)
+	}
+	condition := degradedCondition(t, degraded)
+	if condition == nil {
+		t.Fatal("StatusWriteDegraded condition was not set")
+	}
+	if msg, _ := condition["message"].(string); !strings.HasSuffix(msg, "by field managers kubectl, policy-sync") {
+		t.Errorf("got message %q, wanted the competing managers, most recent first", msg)
+	}
+
+	next := &updateConstraintLoop{client: cl}
+	if err := next.writeStatus(context.TODO(), degraded); err != nil {
+		t.Fatal(err)
+	}
+	if degradedCondition(t, degraded) != nil {
+		t.Error("StatusWriteDegraded condition was not removed after an update without conflicts")
+	}
+}
@@ -0,0 +1,140 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+     http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/feed"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var transitionEvents = flag.Bool("audit-transition-events", false, "emit an event on the constraint for each violation an audit introduces or resolves")
+
+// violationTracker remembers the violations found by the previous audit, so each audit can
+// report the violations it introduced and resolved
+type violationTracker struct {
+	// previous is nil until the first audit has run
+	previous map[string]auditResult
+}
+
+// update replaces the tracked violations with the ones in updateLists and returns the
+// violations the previous audit did not find and the ones it found that are gone. The
+// first call only records a baseline, as otherwise every violation would be reported as
+// new after a restart.
+func (t *violationTracker) update(updateLists map[string][]auditResult) (introduced, resolved []auditResult) {
+	current := make(map[string]auditResult)
+	for _, results := range updateLists {
+		for _, ar := range results {
+			current[fingerprint(ar)] = ar
+		}
+	}
+	previous := t.previous
+	t.previous = current
+	if previous == nil {
+		return nil, nil
+	}
+	for fp, ar := range current {
+		if _, ok := previous[fp]; !ok {
+			introduced = append(introduced, ar)
+		}
+	}
+	for fp, ar := range previous {
+		if _, ok := current[fp]; !ok {
+			resolved = append(resolved, ar)
+		}
+	}
+	return introduced, resolved
+}
+
+// reportTransitions records the violations introduced and resolved since the previous
+// audit, emits an event for each if --audit-transition-events is set, and returns them
+func (am *Manager) reportTransitions(updateLists map[string][]auditResult) (introduced, resolved []auditResult) {
+	introduced, resolved = am.tracker.update(updateLists)
+	introducedPerAction := make(map[util.EnforcementAction]int64)
+	for _, ar := range introduced {
+		introducedPerAction[util.EnforcementAction(ar.enforcementAction)]++
+	}
+	resolvedPerAction := make(map[util.EnforcementAction]int64)
+	for _, ar := range resolved {
+		resolvedPerAction[util.EnforcementAction(ar.enforcementAction)]++
+	}
+	actions := make(map[util.EnforcementAction]bool)
+	for action := range introducedPerAction {
+		actions[action] = true
+	}
+	for action := range resolvedPerAction {
+		actions[action] = true
+	}
+	for action := range actions {
+		if err := am.reporter.reportTransitions(action, introducedPerAction[action], resolvedPerAction[action]); err != nil {
+			am.log.Error(err, "failed to report violation transitions")
+		}
+	}
+	if len(introduced) > 0 || len(resolved) > 0 {
+		am.log.Info("violations changed since the previous audit", "introduced", len(introduced), "resolved", len(resolved))
+	}
+	if !*transitionEvents || am.recorder == nil {
+		return introduced, resolved
+	}
+	for _, ar := range introduced {
+		am.recorder.Eventf(ar.constraint, corev1.EventTypeWarning, "ViolationIntroduced", "%s violates the constraint: %s%s%s",
+			resourceRef(ar), truncateString(ar.message, msgSize), util.DescribeOwner(ar.constraint), util.DescribeAnnotations(ar.constraint, am.annotations))
+	}
+	for _, ar := range resolved {
+		am.recorder.Eventf(ar.constraint, corev1.EventTypeNormal, "ViolationResolved", "%s no longer violates the constraint%s%s",
+			resourceRef(ar), util.DescribeOwner(ar.constraint), util.DescribeAnnotations(ar.constraint, am.annotations))
+	}
+	return introduced, resolved
+}
+
+// publishTransitions passes the violations introduced and resolved by the audit with ID
+// auditID to the violation stream
+func (am *Manager) publishTransitions(introduced, resolved []auditResult, auditID string) {
+	if am.feed == nil || !am.feed.Active() {
+		return
+	}
+	publish := func(ar auditResult, eventType string) {
+		e := feed.Event{
+			Source:            feed.SourceAudit,
+			Type:              eventType,
+			ConstraintKind:    ar.cgvk.Kind,
+			ConstraintName:    ar.cname,
+			ConstraintOwner:   ar.owner(),
+			EnforcementAction: ar.enforcementAction,
+			Kind:              ar.rkind,
+			Namespace:         ar.rnamespace,
+			Name:              ar.rname,
+			AuditID:           auditID,
+		}
+		if eventType == feed.TypeViolation {
+			e.Message = ar.message
+		}
+		am.feed.Publish(e)
+	}
+	for _, ar := range introduced {
+		publish(ar, feed.TypeViolation)
+	}
+	for _, ar := range resolved {
+		publish(ar, feed.TypeResolved)
+	}
+}
+
+func resourceRef(ar auditResult) string {
+	if ar.rnamespace == "" {
+		return fmt.Sprintf("%s %s", ar.rkind, ar.rname)
+	}
+	return fmt.Sprintf("%s %s/%s", ar.rkind, ar.rnamespace, ar.rname)
+}
@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/feed"
+	"github.com/open-policy-agent/gatekeeper/pkg/testutils"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestTransitionEvents(t *testing.T) {
+	c := testutils.NewConstraint("K8sRequiredLabels", "must-have-owner")
+	c.SetAnnotations(map[string]string{"severity": "high"})
+	c.SetLabels(map[string]string{"owner": "platform"})
+	violation := func(name string) auditResult {
+		return auditResult{
+			cgvk:              c.GroupVersionKind(),
+			cname:             c.GetName(),
+			rkind:             "Namespace",
+			rname:             name,
+			ruid:              name,
+			message:           "missing owner",
+			enforcementAction: "deny",
+			constraint:        c,
+		}
+	}
+	lists := func(names ...string) map[string][]auditResult {
+		var results []auditResult
+		for _, n := range names {
+			results = append(results, violation(n))
+		}
+		return map[string][]auditResult{c.GetSelfLink(): results}
+	}
+
+	reporter, err := newStatsReporter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := record.NewFakeRecorder(10)
+	am := &Manager{reporter: reporter, recorder: recorder, log: log, annotations: []string{"severity"}}
+	*transitionEvents = true
+	defer func() { *transitionEvents = false }()
+
+	// the first audit is the baseline
+	am.reportTransitions(lists("a", "b"))
+	if len(recorder.Events) != 0 {
+		t.Fatalf("got %d events for the first audit, wanted none", len(recorder.Events))
+	}
+
+	am.reportTransitions(lists("b", "c"))
+	got := map[string]bool{}
+	for len(recorder.Events) > 0 {
+		got[<-recorder.Events] = true
+	}
+	want := []string{
+		"Warning ViolationIntroduced Namespace c violates the constraint: missing owner (owner: platform) (severity: high)",
+		"Normal ViolationResolved Namespace a no longer violates the constraint (owner: platform) (severity: high)",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got events %v, wanted %v", got, want)
+	}
+	for _, e := range want {
+		if !got[e] {
+			t.Errorf("missing event %q, got %v", e, got)
+		}
+	}
+
+	am.reportTransitions(lists("b", "c"))
+	if len(recorder.Events) != 0 {
+		t.Errorf("got %d events for an unchanged audit, wanted none", len(recorder.Events))
+	}
+}
+
+func TestPublishTransitions(t *testing.T) {
+	c := testutils.NewConstraint("K8sRequiredLabels", "must-have-owner")
+	c.SetLabels(map[string]string{"owner": "platform"})
+	violation := func(name string) auditResult {
+		return auditResult{cgvk: c.GroupVersionKind(), cname: c.GetName(), rkind: "Namespace", rname: name, message: "missing owner", enforcementAction: "deny", constraint: c}
+	}
+	f := feed.New(func() int { return 10 })
+	am := &Manager{feed: f}
+	// nothing is built without subscribers
+	am.publishTransitions([]auditResult{violation("a")}, nil, "t0")
+
+	sub := f.Subscribe()
+	defer sub.Close()
+	am.publishTransitions([]auditResult{violation("c")}, []auditResult{violation("a")}, "t1")
+	if got := len(sub.Events()); got != 2 {
+		t.Fatalf("got %d events, wanted 2", got)
+	}
+	introduced := <-sub.Events()
+	if introduced.Type != feed.TypeViolation || introduced.Name != "c" || introduced.Message != "missing owner" ||
+		introduced.Source != feed.SourceAudit || introduced.AuditID != "t1" || introduced.ConstraintKind != "K8sRequiredLabels" ||
+		introduced.ConstraintOwner != "platform" {
+		t.Errorf("unexpected event for an introduced violation: %+v", introduced)
+	}
+	resolved := <-sub.Events()
+	if resolved.Type != feed.TypeResolved || resolved.Name != "a" || resolved.Message != "" {
+		t.Errorf("unexpected event for a resolved violation: %+v", resolved)
+	}
+}
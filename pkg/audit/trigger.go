@@ -0,0 +1,78 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+     http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"sync"
+
+	configv1alpha1 "github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/controller/config"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// TriggerAnnotation starts an audit immediately whenever its value on the Config resource
+// changes. Any value works; a timestamp makes repeated triggers easy.
+const TriggerAnnotation = "audit.gatekeeper.sh/trigger"
+
+type triggerer interface {
+	Trigger()
+}
+
+// triggerReconciler watches the Config resource for changes to TriggerAnnotation
+type triggerReconciler struct {
+	client  client.Client
+	auditor triggerer
+
+	mux       sync.Mutex
+	lastValue string
+}
+
+func addTriggerController(mgr manager.Manager, auditor triggerer) error {
+	r := &triggerReconciler{client: mgr.GetClient(), auditor: auditor}
+	c, err := controller.New("audit-trigger-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	return c.Watch(&source.Kind{Type: &configv1alpha1.Config{}}, &handler.EnqueueRequestForObject{})
+}
+
+func (r *triggerReconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	if request.NamespacedName != config.CfgKey {
+		return reconcile.Result{}, nil
+	}
+	cfg := &configv1alpha1.Config{}
+	if err := r.client.Get(context.TODO(), request.NamespacedName, cfg); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	value := cfg.GetAnnotations()[TriggerAnnotation]
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if value == "" || value == r.lastValue {
+		return reconcile.Result{}, nil
+	}
+	r.lastValue = value
+	log.Info("audit requested by annotation", "annotation", TriggerAnnotation, "value", value)
+	r.auditor.Trigger()
+	return reconcile.Result{}, nil
+}
@@ -0,0 +1,70 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-policy-agent/gatekeeper/api"
+	configv1alpha1 "github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/controller/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type countingTriggerer struct {
+	count int
+}
+
+func (c *countingTriggerer) Trigger() {
+	c.count++
+}
+
+func TestTriggerReconciler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &configv1alpha1.Config{ObjectMeta: metav1.ObjectMeta{Name: config.CfgKey.Name, Namespace: config.CfgKey.Namespace}}
+	c := fake.NewFakeClientWithScheme(scheme, cfg)
+	auditor := &countingTriggerer{}
+	r := &triggerReconciler{client: c, auditor: auditor}
+	req := reconcile.Request{NamespacedName: config.CfgKey}
+
+	setAnnotation := func(value string) {
+		if err := c.Get(context.TODO(), config.CfgKey, cfg); err != nil {
+			t.Fatal(err)
+		}
+		cfg.SetAnnotations(map[string]string{TriggerAnnotation: value})
+		if err := c.Update(context.TODO(), cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reconcileAndExpect := func(expected int) {
+		t.Helper()
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatal(err)
+		}
+		if auditor.count != expected {
+			t.Errorf("audits triggered = %d, wanted %d", auditor.count, expected)
+		}
+	}
+
+	reconcileAndExpect(0)
+	setAnnotation("2020-03-01T00:00:00Z")
+	reconcileAndExpect(1)
+	// unrelated changes to the Config do not trigger another audit
+	reconcileAndExpect(1)
+	setAnnotation("2020-03-02T00:00:00Z")
+	reconcileAndExpect(2)
+}
+
+func TestTriggerCoalesces(t *testing.T) {
+	am := &Manager{trigger: make(chan struct{}, 1)}
+	am.Trigger()
+	am.Trigger()
+	if len(am.trigger) != 1 {
+		t.Errorf("pending triggers = %d, wanted 1", len(am.trigger))
+	}
+}
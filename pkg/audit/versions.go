@@ -0,0 +1,111 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	constraintTypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// kindSelector is an entry of a constraint's spec.match.kinds
+type kindSelector struct {
+	groups, kinds, versions []string
+}
+
+func kindSelectors(c *unstructured.Unstructured) []kindSelector {
+	entries, _, err := unstructured.NestedSlice(c.Object, "spec", "match", "kinds")
+	if err != nil {
+		return nil
+	}
+	var selectors []kindSelector
+	for _, e := range entries {
+		entry, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var ks kindSelector
+		ks.groups, _, _ = unstructured.NestedStringSlice(entry, "apiGroups")
+		ks.kinds, _, _ = unstructured.NestedStringSlice(entry, "kinds")
+		ks.versions, _, _ = unstructured.NestedStringSlice(entry, "versions")
+		selectors = append(selectors, ks)
+	}
+	return selectors
+}
+
+func containsOrWildcard(list []string, s string) bool {
+	for _, v := range list {
+		if v == "*" || v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns whether ks matches kind gk in version, as the target's Rego does
+func (ks kindSelector) matches(gk schema.GroupKind, version string) bool {
+	return containsOrWildcard(ks.groups, gk.Group) && containsOrWildcard(ks.kinds, gk.Kind) &&
+		(len(ks.versions) == 0 || containsOrWildcard(ks.versions, version))
+}
+
+// namedVersions returns the group versions that the kind selectors of constraints name
+// explicitly, with the kinds they name in each. "*" stands for every kind of the group
+// version. Selectors of any group or version are left out, as they match the preferred
+// versions audit lists anyway.
+func namedVersions(constraints []*unstructured.Unstructured) map[schema.GroupVersion]map[string]bool {
+	versions := make(map[schema.GroupVersion]map[string]bool)
+	for _, c := range constraints {
+		for _, ks := range kindSelectors(c) {
+			for _, group := range ks.groups {
+				for _, version := range ks.versions {
+					if group == "*" || version == "*" {
+						continue
+					}
+					gv := schema.GroupVersion{Group: group, Version: version}
+					if versions[gv] == nil {
+						versions[gv] = make(map[string]bool)
+					}
+					for _, kind := range ks.kinds {
+						versions[gv][kind] = true
+					}
+				}
+			}
+		}
+	}
+	return versions
+}
+
+// onlyNamedVersionResults returns the results of reviewing an object listed in
+// version, which is not the preferred version of its kind gk. Only the results of
+// constraints that select version but not the preferred version are kept; the others
+// were found when the object was reviewed in its preferred version.
+func onlyNamedVersionResults(results []*constraintTypes.Result, gk schema.GroupKind, version, preferred string) []*constraintTypes.Result {
+	var kept []*constraintTypes.Result
+	for _, r := range results {
+		if r.Constraint == nil {
+			continue
+		}
+		inVersion, inPreferred := false, false
+		for _, ks := range kindSelectors(r.Constraint) {
+			inVersion = inVersion || ks.matches(gk, version)
+			inPreferred = inPreferred || ks.matches(gk, preferred)
+		}
+		if inVersion && !inPreferred {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+
+	constraintTypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func versionedConstraint(name string, kinds ...interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetName(name)
+	if err := unstructured.SetNestedSlice(u.Object, kinds, "spec", "match", "kinds"); err != nil {
+		panic(err)
+	}
+	return u
+}
+
+func kindEntry(groups, kinds, versions []interface{}) interface{} {
+	entry := map[string]interface{}{"apiGroups": groups, "kinds": kinds}
+	if versions != nil {
+		entry["versions"] = versions
+	}
+	return entry
+}
+
+func TestNamedVersions(t *testing.T) {
+	constraints := []*unstructured.Unstructured{
+		versionedConstraint("beta", kindEntry([]interface{}{"apps"}, []interface{}{"Deployment", "DaemonSet"}, []interface{}{"v1beta1", "v1beta2"})),
+		versionedConstraint("any-group", kindEntry([]interface{}{"*"}, []interface{}{"Ingress"}, []interface{}{"v1beta1"})),
+		versionedConstraint("any-version", kindEntry([]interface{}{"batch"}, []interface{}{"Job"}, []interface{}{"*"})),
+		versionedConstraint("unversioned", kindEntry([]interface{}{""}, []interface{}{"Pod"}, nil)),
+	}
+	want := map[schema.GroupVersion]map[string]bool{
+		{Group: "apps", Version: "v1beta1"}: {"Deployment": true, "DaemonSet": true},
+		{Group: "apps", Version: "v1beta2"}: {"Deployment": true, "DaemonSet": true},
+	}
+	if got := namedVersions(constraints); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+}
+
+func TestOnlyNamedVersionResults(t *testing.T) {
+	deployments := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	results := []*constraintTypes.Result{
+		{Constraint: versionedConstraint("beta", kindEntry([]interface{}{"apps"}, []interface{}{"Deployment"}, []interface{}{"v1beta1"}))},
+		{Constraint: versionedConstraint("both", kindEntry([]interface{}{"apps"}, []interface{}{"Deployment"}, []interface{}{"v1beta1", "v1"}))},
+		{Constraint: versionedConstraint("unversioned", kindEntry([]interface{}{"apps"}, []interface{}{"Deployment"}, nil))},
+		{Constraint: versionedConstraint("no-kinds")},
+	}
+	kept := onlyNamedVersionResults(results, deployments, "v1beta1", "v1")
+	if len(kept) != 1 || kept[0].Constraint.GetName() != "beta" {
+		var names []string
+		for _, r := range kept {
+			names = append(names, r.Constraint.GetName())
+		}
+		t.Errorf("kept results of %v, wanted only beta", names)
+	}
+}
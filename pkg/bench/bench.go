@@ -0,0 +1,225 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bench implements the `bench` subcommand, which measures how long each
+// constraint takes to evaluate against a set of sample objects.
+package bench
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/engine"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Command is the name of the subcommand
+const Command = "bench"
+
+// Result is the measured cost of one constraint
+type Result struct {
+	Kind        string
+	Name        string
+	Compile     time.Duration
+	P50         time.Duration
+	P90         time.Duration
+	P99         time.Duration
+	Max         time.Duration
+	Violations  int
+	Evaluations int
+}
+
+// Run parses args, runs the benchmark and writes a table of results to out
+func Run(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet(Command, flag.ContinueOnError)
+	fs.SetOutput(out)
+	templatePaths := fs.String("templates", "", "comma-separated YAML files holding ConstraintTemplates")
+	constraintPaths := fs.String("constraints", "", "comma-separated YAML files holding constraints")
+	objectPaths := fs.String("objects", "", "comma-separated YAML files holding the objects to review")
+	iterations := fs.Int("n", 100, "number of times each object is reviewed against each constraint")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *templatePaths == "" || *constraintPaths == "" || *objectPaths == "" {
+		fs.Usage()
+		return errors.New("--templates, --constraints and --objects are required")
+	}
+	if *iterations < 1 {
+		return errors.New("-n must be at least 1")
+	}
+
+	templs, err := ReadObjects(*templatePaths)
+	if err != nil {
+		return err
+	}
+	constraints, err := ReadObjects(*constraintPaths)
+	if err != nil {
+		return err
+	}
+	objs, err := ReadObjects(*objectPaths)
+	if err != nil {
+		return err
+	}
+	results, err := Benchmark(context.Background(), templs, constraints, objs, *iterations)
+	if err != nil {
+		return err
+	}
+	return writeResults(out, results)
+}
+
+// Benchmark reviews each object n times against each constraint in isolation, so
+// the latencies are not shared with other constraints
+func Benchmark(ctx context.Context, templs, constraints, objs []*unstructured.Unstructured, n int) ([]Result, error) {
+	byKind := make(map[string]*unstructured.Unstructured)
+	for _, u := range templs {
+		templ, err := engine.ToTemplate(u)
+		if err != nil {
+			return nil, err
+		}
+		byKind[templ.Spec.CRD.Spec.Names.Kind] = u
+	}
+	reviews := make([]*target.AugmentedReview, 0, len(objs))
+	for _, obj := range objs {
+		review, err := engine.ToReview(obj)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+
+	var results []Result
+	for _, constraint := range constraints {
+		templ, ok := byKind[constraint.GetKind()]
+		if !ok {
+			return nil, fmt.Errorf("no template for constraint %s %s", constraint.GetKind(), constraint.GetName())
+		}
+		res, err := benchmarkConstraint(ctx, templ, constraint, reviews, n)
+		if err != nil {
+			return nil, errors.Wrapf(err, "while benchmarking %s %s", constraint.GetKind(), constraint.GetName())
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+func benchmarkConstraint(ctx context.Context, templ, constraint *unstructured.Unstructured, reviews []*target.AugmentedReview, n int) (Result, error) {
+	res := Result{Kind: constraint.GetKind(), Name: constraint.GetName()}
+	e, err := engine.New()
+	if err != nil {
+		return res, err
+	}
+	start := time.Now()
+	if err := e.LoadTemplate(ctx, templ); err != nil {
+		return res, err
+	}
+	res.Compile = time.Since(start)
+	// the engine applies the template's parameter defaults, as the API server would
+	if err := e.LoadConstraint(ctx, constraint); err != nil {
+		return res, err
+	}
+	client := e.Client()
+
+	latencies := make([]time.Duration, 0, n*len(reviews))
+	for i := 0; i < n; i++ {
+		for _, review := range reviews {
+			start := time.Now()
+			resp, err := client.Review(ctx, review)
+			if err != nil {
+				return res, err
+			}
+			latencies = append(latencies, time.Since(start))
+			if i == 0 {
+				res.Violations += len(resp.Results())
+			}
+		}
+	}
+	res.Evaluations = len(latencies)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	res.P50 = percentile(latencies, 50)
+	res.P90 = percentile(latencies, 90)
+	res.P99 = percentile(latencies, 99)
+	if len(latencies) > 0 {
+		res.Max = latencies[len(latencies)-1]
+	}
+	return res, nil
+}
+
+// percentile returns the p-th percentile of the sorted durations using the nearest rank
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func writeResults(out io.Writer, results []Result) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tCOMPILE\tP50\tP90\tP99\tMAX\tREVIEWS\tVIOLATIONS")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%v\t%v\t%v\t%v\t%v\t%d\t%d\n", r.Kind, r.Name, r.Compile, r.P50, r.P90, r.P99, r.Max, r.Evaluations, r.Violations)
+	}
+	return w.Flush()
+}
+
+// ReadObjects reads every object in the comma-separated list of YAML files
+func ReadObjects(paths string) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	for _, path := range strings.Split(paths, ",") {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := decodeObjects(f)
+		f.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "while reading %s", path)
+		}
+		objs = append(objs, decoded...)
+	}
+	return objs, nil
+}
+
+func decodeObjects(r io.Reader) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	decoder := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		u := &unstructured.Unstructured{}
+		if err := decoder.Decode(&u.Object); err != nil {
+			if err == io.EOF {
+				return objs, nil
+			}
+			return nil, err
+		}
+		// skip empty documents
+		if len(u.Object) == 0 {
+			continue
+		}
+		objs = append(objs, u)
+	}
+}
@@ -0,0 +1,127 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+const templateYAML = `
+apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: k8srequiredlabels
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sRequiredLabels
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+        package k8srequiredlabels
+
+        violation[{"msg": msg}] {
+          not input.review.object.metadata.labels.owner
+          msg := "missing owner"
+        }
+`
+
+const constraintYAML = `
+apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sRequiredLabels
+metadata:
+  name: must-have-owner
+---
+apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sRequiredLabels
+metadata:
+  name: must-have-owner-dryrun
+spec:
+  enforcementAction: dryrun
+`
+
+const objectYAML = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: unowned
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: owned
+  labels:
+    owner: me
+`
+
+func TestBenchmark(t *testing.T) {
+	templs, err := decodeObjects(strings.NewReader(templateYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cstrs, err := decodeObjects(strings.NewReader(constraintYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs, err := decodeObjects(strings.NewReader(objectYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := Benchmark(context.Background(), templs, cstrs, objs, 3)
+	if err != nil {
+		t.Fatalf("Benchmark() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, wanted 2", len(results))
+	}
+	for _, r := range results {
+		if r.Evaluations != 6 {
+			t.Errorf("%s evaluations = %d, wanted 6", r.Name, r.Evaluations)
+		}
+		if r.Violations != 1 {
+			t.Errorf("%s violations = %d, wanted 1", r.Name, r.Violations)
+		}
+		if r.P50 > r.P99 || r.P99 > r.Max {
+			t.Errorf("%s percentiles out of order: %+v", r.Name, r)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	if err := writeResults(out, results); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "must-have-owner-dryrun") {
+		t.Errorf("output is missing a constraint:\n%s", out.String())
+	}
+
+	if _, err := Benchmark(context.Background(), nil, cstrs, objs, 1); err == nil {
+		t.Error("Benchmark() should fail for a constraint without a template")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i))
+	}
+	tc := []struct {
+		P        int
+		Expected time.Duration
+	}{
+		{P: 50, Expected: 50},
+		{P: 90, Expected: 90},
+		{P: 99, Expected: 99},
+		{P: 100, Expected: 100},
+	}
+	for _, tt := range tc {
+		if got := percentile(sorted, tt.P); got != tt.Expected {
+			t.Errorf("percentile(%d) = %v, wanted %v", tt.P, got, tt.Expected)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile of no samples = %v, wanted 0", got)
+	}
+}
@@ -0,0 +1,211 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const kustomizationFile = "kustomization.yaml"
+
+// ApplyOptions configures Apply
+type ApplyOptions struct {
+	// Prune deletes templates and constraints that are not in the bundle
+	Prune bool
+	// Timeout bounds the wait for a new template's constraint kind to be served
+	Timeout time.Duration
+	// Out receives a line per change
+	Out io.Writer
+}
+
+// Read returns the templates and constraints in every YAML file under dir. The
+// kustomization is not built; each file is read as written.
+func Read(dir string) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && path != dir && strings.HasPrefix(info.Name(), "..") {
+			// a mounted ConfigMap links each file to a copy in a hidden ..data directory
+			return filepath.SkipDir
+		}
+		if info.IsDir() || info.Name() == kustomizationFile {
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(b), 4096)
+		for {
+			u := &unstructured.Unstructured{}
+			if err := decoder.Decode(&u.Object); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return errors.Wrapf(err, "while reading %s", path)
+			}
+			if len(u.Object) == 0 {
+				continue
+			}
+			if !isTemplate(u) && u.GroupVersionKind().Group != constraintGroup {
+				return fmt.Errorf("%s: %s %s is not a constraint template or constraint", path, u.GetKind(), u.GetName())
+			}
+			objs = append(objs, u)
+		}
+	})
+	return objs, err
+}
+
+// Apply server-side applies the templates in objs, then the constraints once their
+// kinds are served, and optionally prunes what the bundle does not contain
+func Apply(ctx context.Context, c client.Client, objs []*unstructured.Unstructured, opts ApplyOptions) error {
+	if opts.Out == nil {
+		opts.Out = ioutil.Discard
+	}
+	var templs, constraints []*unstructured.Unstructured
+	for _, obj := range objs {
+		if isTemplate(obj) {
+			templs = append(templs, obj)
+		} else {
+			constraints = append(constraints, obj)
+		}
+	}
+
+	for _, templ := range templs {
+		if err := apply(ctx, c, templ); err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.Out, "applied %s %s\n", templ.GetKind(), templ.GetName())
+	}
+	for _, constraint := range constraints {
+		err := wait.PollImmediate(time.Second, opts.Timeout, func() (bool, error) {
+			err := apply(ctx, c, constraint)
+			// the template's CRD is created asynchronously by the controller
+			if meta.IsNoMatchError(errors.Cause(err)) {
+				return false, nil
+			}
+			return err == nil, err
+		})
+		if err == wait.ErrWaitTimeout {
+			return fmt.Errorf("timed out waiting for kind %s to be served", constraint.GetKind())
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.Out, "applied %s %s\n", constraint.GetKind(), constraint.GetName())
+	}
+
+	if !opts.Prune {
+		return nil
+	}
+	return prune(ctx, c, objs, opts.Out)
+}
+
+func apply(ctx context.Context, c client.Client, obj *unstructured.Unstructured) error {
+	if err := c.Patch(ctx, obj.DeepCopy(), client.Apply, client.FieldOwner(fieldOwner), client.ForceOwnership); err != nil {
+		return errors.Wrapf(err, "while applying %s %s", obj.GetKind(), obj.GetName())
+	}
+	return nil
+}
+
+// prune deletes live templates that are not in the bundle, and the live constraints of
+// bundled templates that are not in the bundle
+func prune(ctx context.Context, c client.Client, objs []*unstructured.Unstructured, out io.Writer) error {
+	wanted := make(map[schema.GroupKind]map[string]bool)
+	for _, obj := range objs {
+		gk := obj.GroupVersionKind().GroupKind()
+		if wanted[gk] == nil {
+			wanted[gk] = make(map[string]bool)
+		}
+		wanted[gk][obj.GetName()] = true
+	}
+
+	live := &unstructured.UnstructuredList{}
+	live.SetGroupVersionKind(templateGVK.GroupVersion().WithKind(templateKind + "List"))
+	if err := c.List(ctx, live); err != nil {
+		return errors.Wrap(err, "while listing constraint templates")
+	}
+	for i := range live.Items {
+		templ := &live.Items[i]
+		if !wanted[templateGVK.GroupKind()][templ.GetName()] {
+			if err := remove(ctx, c, templ, out); err != nil {
+				return err
+			}
+			continue
+		}
+		kind, _, err := unstructured.NestedString(templ.Object, "spec", "crd", "spec", "names", "kind")
+		if err != nil || kind == "" {
+			continue
+		}
+		constraints := &unstructured.UnstructuredList{}
+		constraints.SetGroupVersionKind(ConstraintGVK(kind + "List"))
+		if err := c.List(ctx, constraints); err != nil {
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			return errors.Wrapf(err, "while listing %s constraints", kind)
+		}
+		for j := range constraints.Items {
+			constraint := &constraints.Items[j]
+			if wanted[ConstraintGVK(kind).GroupKind()][constraint.GetName()] {
+				continue
+			}
+			if err := remove(ctx, c, constraint, out); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func remove(ctx context.Context, c client.Client, obj *unstructured.Unstructured, out io.Writer) error {
+	if err := c.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "while pruning %s %s", obj.GetKind(), obj.GetName())
+	}
+	fmt.Fprintf(out, "pruned %s %s\n", obj.GetKind(), obj.GetName())
+	return nil
+}
+
+func isTemplate(obj *unstructured.Unstructured) bool {
+	return obj.GroupVersionKind().GroupKind() == templateGVK.GroupKind()
+}
+
+// ConstraintGVK returns the version of the constraints of kind that Gatekeeper writes
+func ConstraintGVK(kind string) schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: constraintGroup, Version: constraintVersion, Kind: kind}
+}
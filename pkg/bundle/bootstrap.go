@@ -0,0 +1,128 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/featuregate"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var log = logf.Log.WithName("bundle")
+
+var templatesPath = flag.String("templates-path", "", "directory of constraint templates and constraints, such as a mounted ConfigMap, that are applied at startup so a cluster can be bootstrapped without network access")
+
+const (
+	// bootstrapTimeout bounds the wait for the constraint kinds of bootstrapped templates
+	bootstrapTimeout = 5 * time.Minute
+	// bootstrapRetry is how long to wait before retrying a failed startup task
+	bootstrapRetry = 30 * time.Second
+)
+
+// AddToManager adds the startup tasks set by --templates-path, --default-policies,
+// --pod-security-standards and the SelfProtection feature gate to mgr
+func AddToManager(mgr manager.Manager) error {
+	libraries := []struct {
+		lib  *Library
+		mode string
+	}{
+		{DefaultLibrary, *defaultPolicies},
+		{PodSecurityLibrary, *podSecurityStandards},
+		{SelfProtectionLibrary, selfProtectionMode()},
+	}
+	if err := validLibraryMode("default-policies", *defaultPolicies); err != nil {
+		return err
+	}
+	if err := validLibraryMode("pod-security-standards", *podSecurityStandards); err != nil {
+		return err
+	}
+	c, err := newClientForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	if *templatesPath != "" {
+		// the bundle is read now so that a malformed one fails startup
+		objs, err := Read(*templatesPath)
+		if err != nil {
+			return err
+		}
+		if err := mgr.Add(bootstrapTask(c, objs, *templatesPath)); err != nil {
+			return err
+		}
+	}
+	for _, l := range libraries {
+		if l.mode == "" {
+			continue
+		}
+		if err := mgr.Add(l.lib.task(c, l.mode)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selfProtectionMode installs the self-protection library while its feature gate is on,
+// and removes it once the gate is turned off
+func selfProtectionMode() string {
+	if featuregate.Enabled(featuregate.SelfProtection) {
+		return LibraryInstall
+	}
+	return LibraryRemove
+}
+
+// bootstrapTask applies the bundle read from dir
+func bootstrapTask(c client.Client, objs []*unstructured.Unstructured, dir string) *startupTask {
+	return &startupTask{
+		name: "bootstrap bundle " + dir,
+		run: func(ctx context.Context) error {
+			return Apply(ctx, c, objs, ApplyOptions{Timeout: bootstrapTimeout})
+		},
+	}
+}
+
+// startupTask runs once the manager starts, retrying until it succeeds. Templates'
+// constraint kinds are created by the template controller, so bundles cannot be applied
+// before the manager starts.
+type startupTask struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+var _ manager.Runnable = &startupTask{}
+
+// Start runs the task, retrying every bootstrapRetry until it succeeds or stop is closed
+func (t *startupTask) Start(stop <-chan struct{}) error {
+	err := wait.PollImmediateUntil(bootstrapRetry, func() (bool, error) {
+		if err := t.run(context.Background()); err != nil {
+			log.Error(err, "startup task failed, retrying", "task", t.name)
+			return false, nil
+		}
+		log.Info("startup task done", "task", t.name)
+		return true, nil
+	}, stop)
+	if err == wait.ErrWaitTimeout {
+		// stop was closed before the task succeeded
+		return nil
+	}
+	return err
+}
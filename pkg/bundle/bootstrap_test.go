@@ -0,0 +1,76 @@
+package bundle
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/testutils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// writeConfigMapMount lays out files the way the kubelet mounts a ConfigMap: the data
+// lives in a hidden timestamped directory, linked through ..data
+func writeConfigMapMount(t *testing.T, dir string, files map[string]interface{}) {
+	data := filepath.Join(dir, "..2020_01_01_00_00_00.000000000")
+	if err := os.Mkdir(data, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Base(data), filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	for name, obj := range files {
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(data, name), b, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBootstrapFromConfigMapMount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gatekeeper-bootstrap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeConfigMapMount(t, dir, map[string]interface{}{
+		"template.yaml":   newTemplate("k8srequiredlabels", "K8sRequiredLabels").Object,
+		"constraint.yaml": testutils.NewConstraint("K8sRequiredLabels", "must-have-owner").Object,
+	})
+
+	objs, err := Read(dir)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(objs) != 2 {
+		t.Fatalf("Read() returned %d objects, wanted 2: the hidden copies must be skipped", len(objs))
+	}
+
+	c := &applyClient{Client: newFakeClient()}
+	b := bootstrapTask(c, objs, dir)
+	stop := make(chan struct{})
+	defer close(stop)
+	if err := b.Start(stop); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	expectedApplied := []string{"ConstraintTemplate/k8srequiredlabels", "K8sRequiredLabels/must-have-owner"}
+	if !reflect.DeepEqual(c.applied, expectedApplied) {
+		t.Errorf("applied = %v, wanted %v", c.applied, expectedApplied)
+	}
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(ConstraintGVK("K8sRequiredLabels"))
+	if err := c.Get(context.Background(), client.ObjectKey{Name: "must-have-owner"}, got); err != nil {
+		t.Errorf("bootstrapped constraint not created: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bundle implements the `export` and `apply-bundle` subcommands, which move
+// constraint templates and constraints between a cluster and a directory that can be
+// committed to a GitOps repository.
+package bundle
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/open-policy-agent/gatekeeper/api"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+const (
+	// ExportCommand is the name of the subcommand that writes a bundle
+	ExportCommand = "export"
+	// ApplyCommand is the name of the subcommand that applies a bundle
+	ApplyCommand = "apply-bundle"
+
+	// fieldOwner is the field manager recorded for server-side apply
+	fieldOwner = "gatekeeper-bundle"
+
+	constraintGroup   = "constraints.gatekeeper.sh"
+	constraintVersion = "v1beta1"
+	templateKind      = "ConstraintTemplate"
+)
+
+var (
+	scheme      = runtime.NewScheme()
+	templateGVK = schema.GroupVersionKind{Group: "templates.gatekeeper.sh", Version: "v1beta1", Kind: templateKind}
+)
+
+func init() {
+	if err := api.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+}
+
+// RunExport parses args and writes every live template and constraint to a bundle directory
+func RunExport(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet(ExportCommand, flag.ContinueOnError)
+	fs.SetOutput(out)
+	dir := fs.String("dir", "", "directory to write the bundle to. It is created if missing")
+	kubeconfig := fs.String("kubeconfig", "", "path to a kubeconfig. Defaults to $KUBECONFIG, the in-cluster config or ~/.kube/config")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *dir == "" {
+		fs.Usage()
+		return errors.New("--dir is required")
+	}
+	c, err := newClient(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	files, err := Export(context.Background(), c, *dir)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "exported %d objects to %s\n", files, *dir)
+	return nil
+}
+
+// RunApply parses args and applies a bundle directory to the cluster
+func RunApply(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet(ApplyCommand, flag.ContinueOnError)
+	fs.SetOutput(out)
+	dir := fs.String("dir", "", "bundle directory to apply")
+	kubeconfig := fs.String("kubeconfig", "", "path to a kubeconfig. Defaults to $KUBECONFIG, the in-cluster config or ~/.kube/config")
+	prune := fs.Bool("prune", false, "delete templates and constraints that are not in the bundle. Deleting a template deletes all of its constraints")
+	timeout := fs.Duration("timeout", time.Minute, "how long to wait for the constraint kinds of new templates to be served")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *dir == "" {
+		fs.Usage()
+		return errors.New("--dir is required")
+	}
+	objs, err := Read(*dir)
+	if err != nil {
+		return err
+	}
+	c, err := newClient(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	return Apply(context.Background(), c, objs, ApplyOptions{Prune: *prune, Timeout: *timeout, Out: out})
+}
+
+func newClient(kubeconfig string) (client.Client, error) {
+	var cfg *rest.Config
+	var err error
+	if kubeconfig != "" {
+		cfg, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	} else {
+		cfg, err = config.GetConfig()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newClientForConfig(cfg)
+}
+
+func newClientForConfig(cfg *rest.Config) (client.Client, error) {
+	// Constraint kinds appear as templates are applied, so the mapper must reload on a miss
+	mapper, err := apiutil.NewDynamicRESTMapper(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return client.New(cfg, client.Options{Scheme: scheme, Mapper: mapper})
+}
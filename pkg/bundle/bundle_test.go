@@ -0,0 +1,221 @@
+package bundle
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/testutils"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+)
+
+func newTemplate(name, kind string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(templateGVK)
+	u.SetName(name)
+	if err := unstructured.SetNestedField(u.Object, kind, "spec", "crd", "spec", "names", "kind"); err != nil {
+		panic(err)
+	}
+	return u
+}
+
+func newFakeClient(objs ...runtime.Object) client.Client {
+	s := runtime.NewScheme()
+	for _, kind := range []string{"K8sRequiredLabels", "K8sAllowedRepos"} {
+		gvk := ConstraintGVK(kind)
+		s.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		s.AddKnownTypeWithName(ConstraintGVK(kind+"List"), &unstructured.UnstructuredList{})
+	}
+	s.AddKnownTypeWithName(templateGVK, &unstructured.Unstructured{})
+	s.AddKnownTypeWithName(templateGVK.GroupVersion().WithKind(templateKind+"List"), &unstructured.UnstructuredList{})
+	return fake.NewFakeClientWithScheme(s, objs...)
+}
+
+func TestExport(t *testing.T) {
+	templ := newTemplate("k8srequiredlabels", "K8sRequiredLabels")
+	templ.SetResourceVersion("12")
+	templ.SetAnnotations(map[string]string{lastAppliedAnnotation: "{}", "owner": "security"})
+	if err := unstructured.SetNestedField(templ.Object, "created", "status", "created"); err != nil {
+		t.Fatal(err)
+	}
+	constraint := testutils.NewConstraint("K8sRequiredLabels", "must-have-owner", testutils.WithEnforcementAction("dryrun"))
+	constraint.SetFinalizers([]string{"finalizers.gatekeeper.sh/constraint"})
+	c := newFakeClient(templ, constraint)
+
+	dir, err := ioutil.TempDir("", "bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	stale := filepath.Join(dir, "constraints", "k8srequiredlabels", "deleted.yaml")
+	if err := os.MkdirAll(filepath.Dir(stale), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(stale, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := Export(context.Background(), c, dir)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("exported %d objects, wanted 2", n)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale file was not removed: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, kustomizationFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kustomization := struct{ Resources []string }{}
+	if err := yaml.Unmarshal(b, &kustomization); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"constraints/k8srequiredlabels/must-have-owner.yaml", "templates/k8srequiredlabels.yaml"}
+	if !reflect.DeepEqual(kustomization.Resources, expected) {
+		t.Errorf("resources = %v, wanted %v", kustomization.Resources, expected)
+	}
+
+	objs, err := Read(dir)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(objs) != 2 {
+		t.Fatalf("read %d objects, wanted 2", len(objs))
+	}
+	for _, obj := range objs {
+		if _, found := obj.Object["status"]; found {
+			t.Errorf("%s status was exported", obj.GetName())
+		}
+		if obj.GetResourceVersion() != "" || len(obj.GetFinalizers()) != 0 {
+			t.Errorf("%s server-set metadata was exported: %v", obj.GetName(), obj.Object["metadata"])
+		}
+		if _, found := obj.GetAnnotations()[lastAppliedAnnotation]; found {
+			t.Errorf("%s last applied configuration was exported", obj.GetName())
+		}
+	}
+}
+
+// applyClient stands in for server-side apply, which the fake client does not support
+type applyClient struct {
+	client.Client
+	applied []string
+}
+
+func (c *applyClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	u := obj.(*unstructured.Unstructured)
+	c.applied = append(c.applied, u.GetKind()+"/"+u.GetName())
+	if err := c.Create(ctx, u); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+		existing := &unstructured.Unstructured{}
+		existing.SetGroupVersionKind(u.GroupVersionKind())
+		if err := c.Get(ctx, client.ObjectKey{Name: u.GetName()}, existing); err != nil {
+			return err
+		}
+		u.SetResourceVersion(existing.GetResourceVersion())
+		return c.Update(ctx, u)
+	}
+	return nil
+}
+
+func TestApply(t *testing.T) {
+	live := []runtime.Object{
+		newTemplate("k8srequiredlabels", "K8sRequiredLabels"),
+		newTemplate("k8sallowedrepos", "K8sAllowedRepos"),
+		testutils.NewConstraint("K8sRequiredLabels", "must-have-owner"),
+		testutils.NewConstraint("K8sRequiredLabels", "removed"),
+		testutils.NewConstraint("K8sAllowedRepos", "repos"),
+	}
+	bundle := []*unstructured.Unstructured{
+		testutils.NewConstraint("K8sRequiredLabels", "must-have-owner", testutils.WithEnforcementAction("dryrun")),
+		newTemplate("k8srequiredlabels", "K8sRequiredLabels"),
+	}
+
+	tc := []struct {
+		Name      string
+		Prune     bool
+		Remaining []string
+	}{
+		{
+			Name:      "Apply",
+			Remaining: []string{"K8sAllowedRepos/repos", "K8sRequiredLabels/must-have-owner", "K8sRequiredLabels/removed", "ConstraintTemplate/k8sallowedrepos", "ConstraintTemplate/k8srequiredlabels"},
+		},
+		{
+			// the fake client does not cascade, in a cluster K8sAllowedRepos/repos is
+			// deleted with its template's CRD
+			Name:      "Prune",
+			Prune:     true,
+			Remaining: []string{"K8sAllowedRepos/repos", "K8sRequiredLabels/must-have-owner", "ConstraintTemplate/k8srequiredlabels"},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			var objs []runtime.Object
+			for _, obj := range live {
+				objs = append(objs, obj.DeepCopyObject())
+			}
+			c := &applyClient{Client: newFakeClient(objs...)}
+			if err := Apply(context.Background(), c, bundle, ApplyOptions{Prune: tt.Prune, Timeout: time.Second}); err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			// templates are applied before constraints
+			expectedApplied := []string{"ConstraintTemplate/k8srequiredlabels", "K8sRequiredLabels/must-have-owner"}
+			if !reflect.DeepEqual(c.applied, expectedApplied) {
+				t.Errorf("applied = %v, wanted %v", c.applied, expectedApplied)
+			}
+
+			got := &unstructured.Unstructured{}
+			got.SetGroupVersionKind(ConstraintGVK("K8sRequiredLabels"))
+			if err := c.Get(context.Background(), client.ObjectKey{Name: "must-have-owner"}, got); err != nil {
+				t.Fatal(err)
+			}
+			if action, _, _ := unstructured.NestedString(got.Object, "spec", "enforcementAction"); action != "dryrun" {
+				t.Errorf("enforcementAction = %q, wanted the bundle's dryrun", action)
+			}
+
+			var remaining []string
+			for _, kind := range []string{"K8sAllowedRepos", "K8sRequiredLabels"} {
+				list := &unstructured.UnstructuredList{}
+				list.SetGroupVersionKind(ConstraintGVK(kind + "List"))
+				if err := c.List(context.Background(), list); err != nil {
+					t.Fatal(err)
+				}
+				var names []string
+				for _, item := range list.Items {
+					names = append(names, kind+"/"+item.GetName())
+				}
+				sort.Strings(names)
+				remaining = append(remaining, names...)
+			}
+			templs := &unstructured.UnstructuredList{}
+			templs.SetGroupVersionKind(templateGVK.GroupVersion().WithKind(templateKind + "List"))
+			if err := c.List(context.Background(), templs); err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, item := range templs.Items {
+				names = append(names, templateKind+"/"+item.GetName())
+			}
+			sort.Strings(names)
+			remaining = append(remaining, names...)
+			if !reflect.DeepEqual(remaining, tt.Remaining) {
+				t.Errorf("remaining = %v, wanted %v", remaining, tt.Remaining)
+			}
+		})
+	}
+}
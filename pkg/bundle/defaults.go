@@ -0,0 +1,168 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// LibraryInstall installs a policy library, upgrading it in place
+	LibraryInstall = "install"
+	// LibraryRemove removes every object of a policy library
+	LibraryRemove = "remove"
+
+	// OwnedByLabel marks the objects of a policy library with the library's owner
+	OwnedByLabel = "ownedBy"
+)
+
+var (
+	defaultPolicies      = flag.String("default-policies", "", "manage the built-in baseline policy library: install (or upgrade) it, remove it, or leave it alone if empty. Its objects are labeled ownedBy=gatekeeper-defaults")
+	podSecurityStandards = flag.String("pod-security-standards", "", "manage the constraints that enforce the Pod Security Standards level set by namespace labels: install (or upgrade) them, remove them, or leave them alone if empty. Their objects are labeled ownedBy=gatekeeper-pss")
+)
+
+// Library is a set of templates and constraints built into Gatekeeper that is installed,
+// upgraded and removed as a unit. Its objects are labeled with OwnedByLabel set to Owner.
+type Library struct {
+	Owner   string
+	sources []string
+	// configure, if set, customizes each object from flags before it is labeled
+	configure func(*unstructured.Unstructured) error
+}
+
+var (
+	// DefaultLibrary is the baseline policy library managed by --default-policies
+	DefaultLibrary = &Library{Owner: "gatekeeper-defaults", sources: defaultLibrary}
+	// PodSecurityLibrary enforces the Pod Security Standards, managed by
+	// --pod-security-standards
+	PodSecurityLibrary = &Library{Owner: "gatekeeper-pss", sources: podSecurityLibrary}
+	// SelfProtectionLibrary protects Gatekeeper's own CRDs and deployment, managed by the
+	// SelfProtection feature gate
+	SelfProtectionLibrary = &Library{Owner: "gatekeeper-self-protection", sources: selfProtectionLibrary, configure: setSelfProtectionAdmins}
+)
+
+func validLibraryMode(name, mode string) error {
+	switch mode {
+	case "", LibraryInstall, LibraryRemove:
+		return nil
+	default:
+		return fmt.Errorf("invalid --%s %q, must be %s or %s", name, mode, LibraryInstall, LibraryRemove)
+	}
+}
+
+// Objects returns the templates and constraints of the library, labeled with its owner
+func (l *Library) Objects() ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	for _, src := range l.sources {
+		u := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(src), &u.Object); err != nil {
+			return nil, errors.Wrapf(err, "while reading the %s library", l.Owner)
+		}
+		if l.configure != nil {
+			if err := l.configure(u); err != nil {
+				return nil, errors.Wrapf(err, "while configuring the %s library", l.Owner)
+			}
+		}
+		labels := u.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[OwnedByLabel] = l.Owner
+		u.SetLabels(labels)
+		objs = append(objs, u)
+	}
+	return objs, nil
+}
+
+// task installs or removes the library according to mode
+func (l *Library) task(c client.Client, mode string) *startupTask {
+	return &startupTask{
+		name: mode + " " + l.Owner + " library",
+		run: func(ctx context.Context) error {
+			return l.Sync(ctx, c, mode)
+		},
+	}
+}
+
+// Sync installs the library and deletes the objects of earlier versions that it no
+// longer contains, or with LibraryRemove deletes all of it
+func (l *Library) Sync(ctx context.Context, c client.Client, mode string) error {
+	var objs []*unstructured.Unstructured
+	if mode == LibraryInstall {
+		var err error
+		if objs, err = l.Objects(); err != nil {
+			return err
+		}
+		if err := Apply(ctx, c, objs, ApplyOptions{Timeout: bootstrapTimeout}); err != nil {
+			return err
+		}
+	}
+	return l.prune(ctx, c, objs)
+}
+
+// prune deletes the objects labeled with the library's owner that are not in keep. The
+// constraints of a template are deleted before the template.
+func (l *Library) prune(ctx context.Context, c client.Client, keep []*unstructured.Unstructured) error {
+	wanted := make(map[string]bool)
+	for _, obj := range keep {
+		wanted[obj.GetKind()+"/"+obj.GetName()] = true
+	}
+	owned := client.MatchingLabels{OwnedByLabel: l.Owner}
+
+	templs := &unstructured.UnstructuredList{}
+	templs.SetGroupVersionKind(templateGVK.GroupVersion().WithKind(templateKind + "List"))
+	if err := c.List(ctx, templs, owned); err != nil {
+		return errors.Wrapf(err, "while listing %s constraint templates", l.Owner)
+	}
+	for i := range templs.Items {
+		templ := &templs.Items[i]
+		kind, _, err := unstructured.NestedString(templ.Object, "spec", "crd", "spec", "names", "kind")
+		if err == nil && kind != "" {
+			constraints := &unstructured.UnstructuredList{}
+			constraints.SetGroupVersionKind(ConstraintGVK(kind + "List"))
+			if err := c.List(ctx, constraints, owned); err != nil && !meta.IsNoMatchError(err) {
+				return errors.Wrapf(err, "while listing %s %s constraints", l.Owner, kind)
+			}
+			for j := range constraints.Items {
+				constraint := &constraints.Items[j]
+				if wanted[kind+"/"+constraint.GetName()] {
+					continue
+				}
+				if err := remove(ctx, c, constraint, ioutil.Discard); err != nil {
+					return err
+				}
+				log.Info("removed library constraint", "library", l.Owner, "kind", kind, "name", constraint.GetName())
+			}
+		}
+		if wanted[templateKind+"/"+templ.GetName()] {
+			continue
+		}
+		if err := remove(ctx, c, templ, ioutil.Discard); err != nil {
+			return err
+		}
+		log.Info("removed library constraint template", "library", l.Owner, "name", templ.GetName())
+	}
+	return nil
+}
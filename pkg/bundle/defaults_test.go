@@ -0,0 +1,147 @@
+package bundle
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/testutils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var defaultKinds = []string{"K8sPSPPrivilegedContainer", "K8sPSPHostNamespace", "K8sPSPHostNetworkingPorts", "K8sRequiredLabels"}
+
+func newDefaultsClient(objs ...runtime.Object) client.Client {
+	s := runtime.NewScheme()
+	for _, kind := range defaultKinds {
+		s.AddKnownTypeWithName(ConstraintGVK(kind), &unstructured.Unstructured{})
+		s.AddKnownTypeWithName(ConstraintGVK(kind+"List"), &unstructured.UnstructuredList{})
+	}
+	s.AddKnownTypeWithName(templateGVK, &unstructured.Unstructured{})
+	s.AddKnownTypeWithName(templateGVK.GroupVersion().WithKind(templateKind+"List"), &unstructured.UnstructuredList{})
+	return &applyClient{Client: fake.NewFakeClientWithScheme(s, objs...)}
+}
+
+func ownedByDefaults(u *unstructured.Unstructured) *unstructured.Unstructured {
+	u.SetLabels(map[string]string{OwnedByLabel: DefaultLibrary.Owner})
+	return u
+}
+
+func liveObjects(t *testing.T, c client.Client) []string {
+	var names []string
+	templs := &unstructured.UnstructuredList{}
+	templs.SetGroupVersionKind(templateGVK.GroupVersion().WithKind(templateKind + "List"))
+	if err := c.List(context.Background(), templs); err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range templs.Items {
+		names = append(names, templateKind+"/"+item.GetName())
+	}
+	for _, kind := range defaultKinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(ConstraintGVK(kind + "List"))
+		if err := c.List(context.Background(), list); err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range list.Items {
+			names = append(names, kind+"/"+item.GetName())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestDefaultPolicies(t *testing.T) {
+	objs, err := DefaultLibrary.Objects()
+	if err != nil {
+		t.Fatalf("Objects() error = %v", err)
+	}
+	kinds := make(map[string]bool)
+	for _, obj := range objs {
+		if obj.GetLabels()[OwnedByLabel] != DefaultLibrary.Owner {
+			t.Errorf("%s %s is not labeled %s=%s", obj.GetKind(), obj.GetName(), OwnedByLabel, DefaultLibrary.Owner)
+		}
+		if isTemplate(obj) {
+			kind, _, _ := unstructured.NestedString(obj.Object, "spec", "crd", "spec", "names", "kind")
+			kinds[kind] = true
+		}
+	}
+	for _, obj := range objs {
+		if isTemplate(obj) {
+			continue
+		}
+		if !kinds[obj.GetKind()] {
+			t.Errorf("constraint %s has kind %s, which no default template defines", obj.GetName(), obj.GetKind())
+		}
+		if severity, _, _ := unstructured.NestedString(obj.Object, "spec", "severity"); severity == "" {
+			t.Errorf("constraint %s sets no severity", obj.GetName())
+		}
+	}
+}
+
+func TestSyncDefaultLibrary(t *testing.T) {
+	live := func() []runtime.Object {
+		return []runtime.Object{
+			// a template dropped from the library in an upgrade
+			ownedByDefaults(newTemplate("k8srequiredlabels", "K8sRequiredLabels")),
+			// a constraint dropped from the library in an upgrade
+			ownedByDefaults(testutils.NewConstraint("K8sPSPHostNamespace", "old-default")),
+			// user objects are never touched
+			testutils.NewConstraint("K8sPSPHostNamespace", "mine"),
+		}
+	}
+
+	tc := []struct {
+		Name     string
+		Mode     string
+		Expected []string
+	}{
+		{
+			Name: "Install",
+			Mode: LibraryInstall,
+			Expected: []string{
+				"ConstraintTemplate/k8spsphostnamespace",
+				"ConstraintTemplate/k8spsphostnetworkingports",
+				"ConstraintTemplate/k8spspprivilegedcontainer",
+				"K8sPSPHostNamespace/gatekeeper-defaults-host-namespace",
+				"K8sPSPHostNamespace/mine",
+				"K8sPSPHostNetworkingPorts/gatekeeper-defaults-host-network-ports",
+				"K8sPSPPrivilegedContainer/gatekeeper-defaults-privileged-container",
+			},
+		},
+		{
+			// the fake client does not cascade, in a cluster K8sPSPHostNamespace/mine is
+			// deleted with its template's CRD
+			Name:     "Remove",
+			Mode:     LibraryRemove,
+			Expected: []string{"K8sPSPHostNamespace/mine"},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			c := newDefaultsClient(live()...)
+			if tt.Mode == LibraryRemove {
+				// remove what an earlier install created
+				if err := DefaultLibrary.Sync(context.Background(), c, LibraryInstall); err != nil {
+					t.Fatalf("Sync(install) error = %v", err)
+				}
+			}
+			if err := DefaultLibrary.Sync(context.Background(), c, tt.Mode); err != nil {
+				t.Fatalf("Sync(%s) error = %v", tt.Mode, err)
+			}
+			got := liveObjects(t, c)
+			if len(got) != len(tt.Expected) {
+				t.Fatalf("live objects = %v, wanted %v", got, tt.Expected)
+			}
+			for i := range got {
+				if got[i] != tt.Expected[i] {
+					t.Errorf("live objects = %v, wanted %v", got, tt.Expected)
+					break
+				}
+			}
+		})
+	}
+}
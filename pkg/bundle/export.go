@@ -0,0 +1,129 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// lastAppliedAnnotation is written by `kubectl apply` and would pin the cluster state
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// Export writes every template to templates/<name>.yaml and every constraint to
+// constraints/<kind>/<name>.yaml under dir, with a kustomization.yaml listing them. It
+// returns the number of objects written. Files left by an earlier export are removed
+// so deleted objects do not linger.
+func Export(ctx context.Context, c client.Reader, dir string) (int, error) {
+	for _, sub := range []string{"templates", "constraints"} {
+		if err := os.RemoveAll(filepath.Join(dir, sub)); err != nil {
+			return 0, err
+		}
+	}
+	templs := &unstructured.UnstructuredList{}
+	templs.SetGroupVersionKind(templateGVK.GroupVersion().WithKind(templateKind + "List"))
+	if err := c.List(ctx, templs); err != nil {
+		return 0, errors.Wrap(err, "while listing constraint templates")
+	}
+
+	var resources []string
+	for i := range templs.Items {
+		templ := &templs.Items[i]
+		path := filepath.Join("templates", templ.GetName()+".yaml")
+		if err := writeObject(dir, path, clean(templ)); err != nil {
+			return 0, err
+		}
+		resources = append(resources, path)
+
+		kind, _, err := unstructured.NestedString(templ.Object, "spec", "crd", "spec", "names", "kind")
+		if err != nil || kind == "" {
+			continue
+		}
+		constraints := &unstructured.UnstructuredList{}
+		constraints.SetGroupVersionKind(ConstraintGVK(kind + "List"))
+		if err := c.List(ctx, constraints); err != nil {
+			// the template's CRD may not have been created
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			return 0, errors.Wrapf(err, "while listing %s constraints", kind)
+		}
+		for j := range constraints.Items {
+			constraint := &constraints.Items[j]
+			path := filepath.Join("constraints", strings.ToLower(kind), constraint.GetName()+".yaml")
+			if err := writeObject(dir, path, clean(constraint)); err != nil {
+				return 0, err
+			}
+			resources = append(resources, path)
+		}
+	}
+
+	sort.Strings(resources)
+	kustomization := map[string]interface{}{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"resources":  resources,
+	}
+	if err := writeObject(dir, kustomizationFile, kustomization); err != nil {
+		return 0, err
+	}
+	return len(resources), nil
+}
+
+// clean keeps only the fields a user would write: status, server-set metadata and
+// owner references are dropped
+func clean(obj *unstructured.Unstructured) map[string]interface{} {
+	metadata := map[string]interface{}{"name": obj.GetName()}
+	if labels := obj.GetLabels(); len(labels) > 0 {
+		metadata["labels"] = labels
+	}
+	annotations := obj.GetAnnotations()
+	delete(annotations, lastAppliedAnnotation)
+	if len(annotations) > 0 {
+		metadata["annotations"] = annotations
+	}
+	out := map[string]interface{}{
+		"apiVersion": obj.GetAPIVersion(),
+		"kind":       obj.GetKind(),
+		"metadata":   metadata,
+	}
+	if spec, ok := obj.Object["spec"]; ok {
+		out["spec"] = spec
+	}
+	return out
+}
+
+func writeObject(dir, path string, obj interface{}) error {
+	b, err := yaml.Marshal(obj)
+	if err != nil {
+		return errors.Wrapf(err, "while encoding %s", path)
+	}
+	full := filepath.Join(dir, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(full, b, 0644)
+}
@@ -0,0 +1,316 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+// podSecurityLibrary enforces the baseline and restricted Pod Security Standards on the
+// namespaces labeled for them the way Pod Security Admission reads its labels:
+// pod-security.kubernetes.io/enforce selects constraints that deny, and
+// pod-security.kubernetes.io/audit selects constraints that only report. A restricted
+// namespace is selected by both the baseline and the restricted constraints, since the
+// restricted standard includes the baseline one. Privileged namespaces are not
+// constrained.
+var podSecurityLibrary = []string{
+	`apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: k8spssbaseline
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sPSSBaseline
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+        package k8spssbaseline
+
+        violation[{"msg": msg, "details": {}}] {
+            c := input_containers[_]
+            c.securityContext.privileged
+            msg := sprintf("privileged container %v is not allowed by the baseline Pod Security Standard", [c.name])
+        }
+
+        violation[{"msg": msg, "details": {}}] {
+            field := host_namespace_fields[_]
+            input.review.object.spec[field]
+            msg := sprintf("%v is not allowed by the baseline Pod Security Standard", [field])
+        }
+
+        violation[{"msg": msg, "details": {}}] {
+            v := input.review.object.spec.volumes[_]
+            v.hostPath
+            msg := sprintf("hostPath volume %v is not allowed by the baseline Pod Security Standard", [v.name])
+        }
+
+        violation[{"msg": msg, "details": {}}] {
+            c := input_containers[_]
+            port := c.ports[_].hostPort
+            port != 0
+            msg := sprintf("container %v uses hostPort %v, which is not allowed by the baseline Pod Security Standard", [c.name, port])
+        }
+
+        violation[{"msg": msg, "details": {}}] {
+            c := input_containers[_]
+            capability := c.securityContext.capabilities.add[_]
+            not baseline_capabilities[capability]
+            msg := sprintf("container %v adds capability %v, which is not allowed by the baseline Pod Security Standard", [c.name, capability])
+        }
+
+        violation[{"msg": msg, "details": {}}] {
+            c := input_containers[_]
+            c.securityContext.procMount != "Default"
+            msg := sprintf("container %v sets procMount %v, which is not allowed by the baseline Pod Security Standard", [c.name, c.securityContext.procMount])
+        }
+
+        violation[{"msg": msg, "details": {}}] {
+            sysctl := input.review.object.spec.securityContext.sysctls[_]
+            not safe_sysctls[sysctl.name]
+            msg := sprintf("sysctl %v is not allowed by the baseline Pod Security Standard", [sysctl.name])
+        }
+
+        violation[{"msg": msg, "details": {}}] {
+            input.review.object.spec.securityContext.seccompProfile.type == "Unconfined"
+            msg := "an Unconfined seccomp profile is not allowed by the baseline Pod Security Standard"
+        }
+
+        violation[{"msg": msg, "details": {}}] {
+            c := input_containers[_]
+            c.securityContext.seccompProfile.type == "Unconfined"
+            msg := sprintf("container %v sets an Unconfined seccomp profile, which is not allowed by the baseline Pod Security Standard", [c.name])
+        }
+
+        violation[{"msg": msg, "details": {}}] {
+            annotation := input.review.object.metadata.annotations[key]
+            seccomp_annotation(key)
+            annotation == "unconfined"
+            msg := sprintf("%v: unconfined is not allowed by the baseline Pod Security Standard", [key])
+        }
+
+        seccomp_annotation(key) {
+            key == "seccomp.security.alpha.kubernetes.io/pod"
+        }
+
+        seccomp_annotation(key) {
+            startswith(key, "container.seccomp.security.alpha.kubernetes.io/")
+        }
+
+        host_namespace_fields := ["hostNetwork", "hostPID", "hostIPC"]
+
+        baseline_capabilities := {"AUDIT_WRITE", "CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "KILL", "MKNOD", "NET_BIND_SERVICE", "SETFCAP", "SETGID", "SETPCAP", "SETUID", "SYS_CHROOT"}
+
+        safe_sysctls := {"kernel.shm_rmid_forced", "net.ipv4.ip_local_port_range", "net.ipv4.ip_unprivileged_port_start", "net.ipv4.tcp_syncookies", "net.ipv4.ping_group_range"}
+
+        input_containers[c] {
+            c := input.review.object.spec.containers[_]
+        }
+
+        input_containers[c] {
+            c := input.review.object.spec.initContainers[_]
+        }
+
+        input_containers[c] {
+            c := input.review.object.spec.ephemeralContainers[_]
+        }
+`,
+	`apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: k8spssrestricted
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sPSSRestricted
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+        package k8spssrestricted
+
+        violation[{"msg": msg, "details": {}}] {
+            v := input.review.object.spec.volumes[_]
+            v[volume_type]
+            volume_type != "name"
+            not allowed_volume_types[volume_type]
+            msg := sprintf("volume %v has type %v, which is not allowed by the restricted Pod Security Standard", [v.name, volume_type])
+        }
+
+        violation[{"msg": msg, "details": {}}] {
+            c := input_containers[_]
+            not c.securityContext.allowPrivilegeEscalation == false
+            msg := sprintf("container %v must set allowPrivilegeEscalation to false under the restricted Pod Security Standard", [c.name])
+        }
+
+        violation[{"msg": msg, "details": {}}] {
+            c := input_containers[_]
+            not runs_as_non_root(c)
+            msg := sprintf("container %v must set runAsNonRoot to true under the restricted Pod Security Standard", [c.name])
+        }
+
+        violation[{"msg": msg, "details": {}}] {
+            input.review.object.spec.securityContext.runAsUser == 0
+            msg := "runAsUser 0 is not allowed by the restricted Pod Security Standard"
+        }
+
+        violation[{"msg": msg, "details": {}}] {
+            c := input_containers[_]
+            c.securityContext.runAsUser == 0
+            msg := sprintf("container %v sets runAsUser 0, which is not allowed by the restricted Pod Security Standard", [c.name])
+        }
+
+        violation[{"msg": msg, "details": {}}] {
+            c := input_containers[_]
+            not drops_all_capabilities(c)
+            msg := sprintf("container %v must drop ALL capabilities under the restricted Pod Security Standard", [c.name])
+        }
+
+        violation[{"msg": msg, "details": {}}] {
+            c := input_containers[_]
+            capability := c.securityContext.capabilities.add[_]
+            capability != "NET_BIND_SERVICE"
+            msg := sprintf("container %v adds capability %v, which is not allowed by the restricted Pod Security Standard", [c.name, capability])
+        }
+
+        violation[{"msg": msg, "details": {}}] {
+            c := input_containers[_]
+            not seccomp_confined(c)
+            msg := sprintf("container %v must use a RuntimeDefault or Localhost seccomp profile under the restricted Pod Security Standard", [c.name])
+        }
+
+        allowed_volume_types := {"configMap", "csi", "downwardAPI", "emptyDir", "ephemeral", "persistentVolumeClaim", "projected", "secret"}
+
+        runs_as_non_root(c) {
+            c.securityContext.runAsNonRoot == true
+        }
+
+        runs_as_non_root(c) {
+            not c.securityContext.runAsNonRoot == false
+            input.review.object.spec.securityContext.runAsNonRoot == true
+        }
+
+        drops_all_capabilities(c) {
+            c.securityContext.capabilities.drop[_] == "ALL"
+        }
+
+        allowed_seccomp_types := {"RuntimeDefault", "Localhost"}
+
+        seccomp_confined(c) {
+            allowed_seccomp_types[c.securityContext.seccompProfile.type]
+        }
+
+        seccomp_confined(c) {
+            not c.securityContext.seccompProfile
+            allowed_seccomp_types[input.review.object.spec.securityContext.seccompProfile.type]
+        }
+
+        seccomp_confined(c) {
+            not c.securityContext.seccompProfile
+            confined_seccomp_annotation(input.review.object.metadata.annotations[sprintf("container.seccomp.security.alpha.kubernetes.io/%v", [c.name])])
+        }
+
+        seccomp_confined(c) {
+            not c.securityContext.seccompProfile
+            not input.review.object.spec.securityContext.seccompProfile
+            not input.review.object.metadata.annotations[sprintf("container.seccomp.security.alpha.kubernetes.io/%v", [c.name])]
+            confined_seccomp_annotation(input.review.object.metadata.annotations["seccomp.security.alpha.kubernetes.io/pod"])
+        }
+
+        confined_seccomp_profiles := {"runtime/default", "docker/default"}
+
+        confined_seccomp_annotation(profile) {
+            confined_seccomp_profiles[profile]
+        }
+
+        confined_seccomp_annotation(profile) {
+            startswith(profile, "localhost/")
+        }
+
+        input_containers[c] {
+            c := input.review.object.spec.containers[_]
+        }
+
+        input_containers[c] {
+            c := input.review.object.spec.initContainers[_]
+        }
+
+        input_containers[c] {
+            c := input.review.object.spec.ephemeralContainers[_]
+        }
+`,
+	`apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sPSSBaseline
+metadata:
+  name: pss-baseline
+spec:
+  enforcementAction: deny
+  match:
+    kinds:
+      - apiGroups: [""]
+        kinds: ["Pod"]
+    namespaceSelector:
+      matchExpressions:
+        - key: pod-security.kubernetes.io/enforce
+          operator: In
+          values: ["baseline", "restricted"]
+`,
+	`apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sPSSBaseline
+metadata:
+  name: pss-baseline-audit
+spec:
+  enforcementAction: dryrun
+  match:
+    kinds:
+      - apiGroups: [""]
+        kinds: ["Pod"]
+    namespaceSelector:
+      matchExpressions:
+        - key: pod-security.kubernetes.io/audit
+          operator: In
+          values: ["baseline", "restricted"]
+`,
+	`apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sPSSRestricted
+metadata:
+  name: pss-restricted
+spec:
+  enforcementAction: deny
+  match:
+    kinds:
+      - apiGroups: [""]
+        kinds: ["Pod"]
+    namespaceSelector:
+      matchExpressions:
+        - key: pod-security.kubernetes.io/enforce
+          operator: In
+          values: ["restricted"]
+`,
+	`apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sPSSRestricted
+metadata:
+  name: pss-restricted-audit
+spec:
+  enforcementAction: dryrun
+  match:
+    kinds:
+      - apiGroups: [""]
+        kinds: ["Pod"]
+    namespaceSelector:
+      matchExpressions:
+        - key: pod-security.kubernetes.io/audit
+          operator: In
+          values: ["restricted"]
+`,
+}
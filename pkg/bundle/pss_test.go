@@ -0,0 +1,151 @@
+package bundle
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/engine"
+	"github.com/open-policy-agent/gatekeeper/pkg/verify"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const restrictedPod = `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: restricted
+  namespace: apps
+spec:
+  securityContext:
+    runAsNonRoot: true
+    seccompProfile:
+      type: RuntimeDefault
+  containers:
+  - name: app
+    image: app
+    securityContext:
+      allowPrivilegeEscalation: false
+      capabilities:
+        drop: ["ALL"]
+        add: ["NET_BIND_SERVICE"]
+  volumes:
+  - name: config
+    configMap:
+      name: app
+`
+
+func TestPodSecurityStandards(t *testing.T) {
+	objs, err := PodSecurityLibrary.Objects()
+	if err != nil {
+		t.Fatalf("Objects() error = %v", err)
+	}
+	var templs, constraints []*unstructured.Unstructured
+	for _, obj := range objs {
+		if isTemplate(obj) {
+			templs = append(templs, obj)
+			continue
+		}
+		if strings.HasSuffix(obj.GetName(), "-audit") {
+			continue
+		}
+		// reviews carry no namespace, so match the enforcing constraints everywhere
+		unstructured.RemoveNestedField(obj.Object, "spec", "match", "namespaceSelector")
+		constraints = append(constraints, obj)
+	}
+	ctx := context.Background()
+	client, err := verify.NewClient(ctx, templs, constraints)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	tc := []struct {
+		Name     string
+		Patch    string
+		Expected []string
+	}{
+		{
+			Name: "Restricted",
+		},
+		{
+			Name:     "Privileged container",
+			Patch:    `{"spec": {"containers": [{"name": "app", "securityContext": {"privileged": true, "allowPrivilegeEscalation": false, "capabilities": {"drop": ["ALL"]}}}]}}`,
+			Expected: []string{"pss-baseline"},
+		},
+		{
+			Name:     "Host PID",
+			Patch:    `{"spec": {"hostPID": true}}`,
+			Expected: []string{"pss-baseline"},
+		},
+		{
+			Name:     "Unconfined seccomp",
+			Patch:    `{"spec": {"securityContext": {"runAsNonRoot": true, "seccompProfile": {"type": "Unconfined"}}}}`,
+			Expected: []string{"pss-baseline", "pss-restricted"},
+		},
+		{
+			Name:     "Root allowed",
+			Patch:    `{"spec": {"securityContext": {"runAsNonRoot": false}}}`,
+			Expected: []string{"pss-restricted"},
+		},
+		{
+			Name:     "Host path volume",
+			Patch:    `{"spec": {"volumes": [{"name": "root", "hostPath": {"path": "/"}}]}}`,
+			Expected: []string{"pss-baseline", "pss-restricted"},
+		},
+		{
+			Name:     "Baseline capability",
+			Patch:    `{"spec": {"containers": [{"name": "app", "securityContext": {"allowPrivilegeEscalation": false, "capabilities": {"drop": ["ALL"], "add": ["CHOWN"]}}}]}}`,
+			Expected: []string{"pss-restricted"},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			pod := &unstructured.Unstructured{}
+			if err := yaml.Unmarshal([]byte(restrictedPod), &pod.Object); err != nil {
+				t.Fatal(err)
+			}
+			if tt.Patch != "" {
+				patch := make(map[string]interface{})
+				if err := yaml.Unmarshal([]byte(tt.Patch), &patch); err != nil {
+					t.Fatal(err)
+				}
+				merge(pod.Object, patch)
+			}
+			review, err := engine.ToReview(pod)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Review(ctx, review)
+			if err != nil {
+				t.Fatalf("Review() error = %v", err)
+			}
+			violated := make(map[string]bool)
+			for _, r := range resp.Results() {
+				violated[r.Constraint.GetName()] = true
+			}
+			var got []string
+			for name := range violated {
+				got = append(got, name)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.Expected, ",") {
+				t.Errorf("violated constraints = %v, wanted %v", got, tt.Expected)
+			}
+		})
+	}
+}
+
+// merge sets the fields of patch in obj, merging nested maps and replacing everything else
+func merge(obj, patch map[string]interface{}) {
+	for k, v := range patch {
+		if pm, ok := v.(map[string]interface{}); ok {
+			if om, ok := obj[k].(map[string]interface{}); ok {
+				merge(om, pm)
+				continue
+			}
+		}
+		obj[k] = v
+	}
+}
@@ -0,0 +1,117 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"flag"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const selfProtectionKind = "K8sGatekeeperSelfProtection"
+
+var (
+	selfProtectionAdminGroups = flag.String("self-protection-admin-groups", "system:masters", "comma-separated groups allowed to update and delete Gatekeeper's CRDs and deployment when the SelfProtection feature gate is on")
+	selfProtectionAdminUsers  = flag.String("self-protection-admin-users", "system:serviceaccount:kube-system:generic-garbage-collector,system:serviceaccount:kube-system:namespace-controller", "comma-separated users allowed to update and delete Gatekeeper's CRDs and deployment when the SelfProtection feature gate is on")
+)
+
+// selfProtectionLibrary denies updating and deleting the CRDs and deployments labeled
+// gatekeeper.sh/system: "yes" to everyone but the administrators set in its parameters.
+// Gatekeeper's own service account is always allowed by the webhook. The constraint only
+// matches UPDATE and DELETE, so audit, which reviews existing objects as CREATE, does not
+// report it.
+var selfProtectionLibrary = []string{
+	`apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: k8sgatekeeperselfprotection
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sGatekeeperSelfProtection
+      validation:
+        openAPIV3Schema:
+          properties:
+            adminGroups:
+              type: array
+              items:
+                type: string
+            adminUsers:
+              type: array
+              items:
+                type: string
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+        package k8sgatekeeperselfprotection
+
+        violation[{"msg": msg, "details": {}}] {
+            not admin
+            msg := sprintf("%v %v is part of Gatekeeper and can only be %v by its administrators", [input.review.kind.kind, input.review.name, verbs[input.review.operation]])
+        }
+
+        admin {
+            input.review.userInfo.groups[_] == input.parameters.adminGroups[_]
+        }
+
+        admin {
+            input.review.userInfo.username == input.parameters.adminUsers[_]
+        }
+
+        verbs := {"UPDATE": "updated", "DELETE": "deleted"}
+`,
+	`apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sGatekeeperSelfProtection
+metadata:
+  name: gatekeeper-self-protection
+spec:
+  match:
+    kinds:
+      - apiGroups: ["apiextensions.k8s.io"]
+        kinds: ["CustomResourceDefinition"]
+      - apiGroups: ["apps"]
+        kinds: ["Deployment"]
+    operations: ["UPDATE", "DELETE"]
+    labelSelector:
+      matchLabels:
+        gatekeeper.sh/system: "yes"
+`,
+}
+
+// setSelfProtectionAdmins sets the administrators of --self-protection-admin-groups and
+// --self-protection-admin-users on the self-protection constraint
+func setSelfProtectionAdmins(obj *unstructured.Unstructured) error {
+	if obj.GetKind() != selfProtectionKind {
+		return nil
+	}
+	return unstructured.SetNestedField(obj.Object, map[string]interface{}{
+		"adminGroups": splitList(*selfProtectionAdminGroups),
+		"adminUsers":  splitList(*selfProtectionAdminUsers),
+	}, "spec", "parameters")
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(s string) []interface{} {
+	list := []interface{}{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
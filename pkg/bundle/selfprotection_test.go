@@ -0,0 +1,106 @@
+package bundle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/engine"
+	"github.com/open-policy-agent/gatekeeper/pkg/verify"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSelfProtection(t *testing.T) {
+	objs, err := SelfProtectionLibrary.Objects()
+	if err != nil {
+		t.Fatalf("Objects() error = %v", err)
+	}
+	var templs, constraints []*unstructured.Unstructured
+	for _, obj := range objs {
+		if isTemplate(obj) {
+			templs = append(templs, obj)
+			continue
+		}
+		groups, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "parameters", "adminGroups")
+		if len(groups) != 1 || groups[0] != "system:masters" {
+			t.Errorf("adminGroups = %v, wanted the flag default", groups)
+		}
+		constraints = append(constraints, obj)
+	}
+	ctx := context.Background()
+	client, err := verify.NewClient(ctx, templs, constraints)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	deployment := &unstructured.Unstructured{}
+	deployment.SetAPIVersion("apps/v1")
+	deployment.SetKind("Deployment")
+	deployment.SetName("gatekeeper-controller-manager")
+	deployment.SetNamespace("gatekeeper-system")
+	deployment.SetLabels(map[string]string{"gatekeeper.sh/system": "yes"})
+
+	tc := []struct {
+		Name      string
+		Operation admissionv1beta1.Operation
+		User      authenticationv1.UserInfo
+		Labels    map[string]string
+		Denied    bool
+	}{
+		{
+			Name:      "Update by a user",
+			Operation: admissionv1beta1.Update,
+			User:      authenticationv1.UserInfo{Username: "dev", Groups: []string{"system:authenticated"}},
+			Denied:    true,
+		},
+		{
+			Name:      "Delete by a user",
+			Operation: admissionv1beta1.Delete,
+			User:      authenticationv1.UserInfo{Username: "dev", Groups: []string{"system:authenticated"}},
+			Denied:    true,
+		},
+		{
+			Name:      "Update by an administrator",
+			Operation: admissionv1beta1.Update,
+			User:      authenticationv1.UserInfo{Username: "admin", Groups: []string{"system:masters", "system:authenticated"}},
+		},
+		{
+			Name:      "Delete by the garbage collector",
+			Operation: admissionv1beta1.Delete,
+			User:      authenticationv1.UserInfo{Username: "system:serviceaccount:kube-system:generic-garbage-collector"},
+		},
+		{
+			Name:      "Create",
+			Operation: admissionv1beta1.Create,
+			User:      authenticationv1.UserInfo{Username: "dev"},
+		},
+		{
+			Name:      "Unlabeled deployment",
+			Operation: admissionv1beta1.Update,
+			User:      authenticationv1.UserInfo{Username: "dev"},
+			Labels:    map[string]string{},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			obj := deployment.DeepCopy()
+			if tt.Labels != nil {
+				obj.SetLabels(tt.Labels)
+			}
+			review, err := engine.ToReview(obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			review.AdmissionRequest.Operation = tt.Operation
+			review.AdmissionRequest.UserInfo = tt.User
+			resp, err := client.Review(ctx, review)
+			if err != nil {
+				t.Fatalf("Review() error = %v", err)
+			}
+			if denied := len(resp.Results()) > 0; denied != tt.Denied {
+				t.Errorf("denied = %v, wanted %v: %v", denied, tt.Denied, resp.Results())
+			}
+		})
+	}
+}
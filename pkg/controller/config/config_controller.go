@@ -178,7 +178,7 @@ func (r *ReconcileConfig) Reconcile(request reconcile.Request) (reconcile.Result
 		}
 	}
 	if !r.watched.Equals(newSyncOnly) {
-		// Wipe all data to avoid stale state
+		// Wipe all synced objects to avoid stale state
 		err := r.watcher.Pause()
 		defer func() {
 			if err = r.watcher.Unpause(); err != nil {
@@ -188,8 +188,10 @@ func (r *ReconcileConfig) Reconcile(request reconcile.Request) (reconcile.Result
 		if err != nil {
 			return reconcile.Result{}, err
 		}
-		if _, err := r.opa.RemoveData(context.Background(), target.WipeData{}); err != nil {
-			return reconcile.Result{}, err
+		for _, wipe := range target.ObjectWipes {
+			if _, err := r.opa.RemoveData(context.Background(), wipe); err != nil {
+				return reconcile.Result{}, err
+			}
 		}
 	}
 
@@ -0,0 +1,72 @@
+package constraint
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	csutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+)
+
+var clusterFeatureGates = flag.String("cluster-feature-gates", "", "comma-separated Name=true|false list of the API server's feature gates, for constraints that set spec.match.featureGates. The API server does not publish its feature gates, so gates not listed are taken as disabled")
+
+var (
+	clusterInfoOnce sync.Once
+	clusterInfo     *csutil.ClusterInfo
+)
+
+// getClusterInfo reads the API server's version once per process. Constraints that set
+// spec.match.clusterVersions stay inactive if it cannot be read.
+func getClusterInfo(cfg *rest.Config) *csutil.ClusterInfo {
+	clusterInfoOnce.Do(func() {
+		gates, err := parseFeatureGates(*clusterFeatureGates)
+		if err != nil {
+			log.Error(err, "ignoring --cluster-feature-gates")
+		}
+		clusterInfo = &csutil.ClusterInfo{FeatureGates: gates}
+		dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+		if err != nil {
+			log.Error(err, "could not create a discovery client to read the cluster version")
+			return
+		}
+		info, err := dc.ServerVersion()
+		if err != nil {
+			log.Error(err, "could not read the cluster version")
+			return
+		}
+		v, err := version.ParseGeneric(info.GitVersion)
+		if err != nil {
+			log.Error(err, "could not parse the cluster version", "version", info.GitVersion)
+			return
+		}
+		clusterInfo.Version = v
+	})
+	return clusterInfo
+}
+
+// parseFeatureGates parses a list such as EphemeralContainers=true,CSIInlineVolume=false.
+// A gate without a value is enabled.
+func parseFeatureGates(s string) (map[string]bool, error) {
+	gates := make(map[string]bool)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		enabled := true
+		if len(parts) == 2 {
+			var err error
+			if enabled, err = strconv.ParseBool(strings.TrimSpace(parts[1])); err != nil {
+				return nil, fmt.Errorf("invalid feature gate %q: %v", entry, err)
+			}
+		}
+		gates[strings.TrimSpace(parts[0])] = enabled
+	}
+	return gates, nil
+}
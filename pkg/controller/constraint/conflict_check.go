@@ -0,0 +1,73 @@
+package constraint
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	csutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// conflictingParameters returns the parameter pairs templ declares as contradictory
+func conflictingParameters(templ *templv1beta1.ConstraintTemplate) ([]csutil.ParameterPair, error) {
+	if templ == nil {
+		return nil, nil
+	}
+	s, ok := templ.GetAnnotations()[csutil.ConflictingParametersAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	return csutil.ParseConflictingParameters(s)
+}
+
+// conflicts returns how the parameters of instance contradict those of the other
+// constraints of its kind that may match the same objects, under the parameter pairs
+// its template declares
+func (r *ReconcileConstraint) conflicts(ctx context.Context, templ *templv1beta1.ConstraintTemplate, instance *unstructured.Unstructured) ([]string, error) {
+	pairs, err := conflictingParameters(templ)
+	if err != nil || len(pairs) == 0 {
+		return nil, err
+	}
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(r.gvk.GroupVersion().WithKind(r.gvk.Kind + "List"))
+	if err := r.List(ctx, list); err != nil {
+		return nil, err
+	}
+	var conflicts []string
+	for i := range list.Items {
+		other := &list.Items[i]
+		if other.GetName() == instance.GetName() || !other.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		conflicts = append(conflicts, csutil.Conflicts(instance, other, pairs)...)
+	}
+	return conflicts, nil
+}
+
+func conflictMessage(conflicts []string) string {
+	return fmt.Sprintf("parameters contradict other constraints that may match the same objects: %s", strings.Join(conflicts, "; "))
+}
+
+// conflictingConstraints enqueues every constraint of the kind when one changes, if the
+// kind's template declares conflicting parameters, so a conflict is reported on both
+// constraints and cleared from both
+func conflictingConstraints(c client.Reader, gvk schema.GroupVersionKind) handler.ToRequestsFunc {
+	toConstraints := constraintsOfKind(c, gvk)
+	return func(obj handler.MapObject) []reconcile.Request {
+		templ, err := getTemplate(context.TODO(), c, gvk.Kind)
+		if err != nil {
+			log.Error(err, "could not read template", "kind", gvk.Kind)
+			return nil
+		}
+		if pairs, err := conflictingParameters(templ); err != nil || len(pairs) == 0 {
+			return nil
+		}
+		return toConstraints(obj)
+	}
+}
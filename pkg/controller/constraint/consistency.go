@@ -0,0 +1,223 @@
+package constraint
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/metrics"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var consistencyCheckInterval = flag.Duration("constraint-consistency-check-interval", 10*time.Minute, "how often the constraints in the API server are compared with those in OPA and the constraints cache, repairing any drift. 0 disables the check")
+
+const (
+	constraintsGroup = "constraints.gatekeeper.sh"
+
+	opaStore   = "opa"
+	cacheStore = "cache"
+	// missingDrift is a constraint that exists but is not in a store
+	missingDrift = "missing"
+	// ghostDrift is a constraint in a store that no longer exists
+	ghostDrift = "ghost"
+)
+
+// ConstraintLister lists the constraints loaded into OPA
+type ConstraintLister interface {
+	Constraints(ctx context.Context) ([]*unstructured.Unstructured, error)
+}
+
+// AddConsistencyCheck adds a ConsistencyCheck to mgr, unless it is disabled by flag. lister
+// lists the constraints opa holds.
+func AddConsistencyCheck(mgr manager.Manager, opa OpaClient, lister ConstraintLister, cache *ConstraintsCache) error {
+	if *consistencyCheckInterval <= 0 || lister == nil {
+		return nil
+	}
+	reporter, err := newStatsReporter()
+	if err != nil {
+		return err
+	}
+	return mgr.Add(&ConsistencyCheck{
+		reader:   mgr.GetAPIReader(),
+		opa:      opa,
+		lister:   lister,
+		cache:    cache,
+		reporter: reporter,
+		interval: *consistencyCheckInterval,
+	})
+}
+
+// ConsistencyCheck periodically compares the constraints in the API server with those in
+// OPA and the constraints cache. Watch events that were missed leave constraints
+// unenforced, or enforced after they were deleted, until the pod restarts; the check
+// re-adds the missing constraints and removes the ghosts.
+type ConsistencyCheck struct {
+	// reader bypasses the informer cache, which may have missed the same events
+	reader   client.Reader
+	opa      OpaClient
+	lister   ConstraintLister
+	cache    *ConstraintsCache
+	reporter StatsReporter
+	interval time.Duration
+}
+
+var _ manager.Runnable = &ConsistencyCheck{}
+
+// Start runs the check every interval until stop is closed
+func (c *ConsistencyCheck) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			if err := c.check(context.Background()); err != nil {
+				log.Error(err, "constraint consistency check failed")
+			}
+		}
+	}
+}
+
+// check repairs the drift between the live constraints and OPA and the constraints cache
+func (c *ConsistencyCheck) check(ctx context.Context) error {
+	// read the stores before listing so that constraints created while listing are not
+	// taken for ghosts
+	inOpa, err := c.opaConstraints(ctx)
+	if err != nil {
+		return err
+	}
+	cachedKeys := c.cache.Keys()
+	cached := make(map[string]bool, len(cachedKeys))
+	for _, k := range cachedKeys {
+		cached[k] = true
+	}
+	live, err := liveConstraints(ctx, c.reader)
+	if err != nil {
+		return err
+	}
+	cfg, err := readConfig(ctx, c.reader)
+	if err != nil {
+		return err
+	}
+
+	liveKeys := make(map[string]bool, len(live))
+	for key, obj := range live {
+		liveKeys[key] = true
+		if c.cache.hasError(key) || c.cache.isInactive(key) {
+			// the constraint's status already reports why it is not in OPA
+			continue
+		}
+		_, enforced := inOpa[key]
+		if enforced && cached[key] {
+			continue
+		}
+		// the same constraint the reconciler would add
+		built, err := buildEffective(ctx, c.reader, obj, cfg, time.Now())
+		if err != nil {
+			log.Error(err, "could not build constraint", logging.ConstraintKind, obj.GetKind(), logging.ConstraintName, obj.GetName())
+			continue
+		}
+		if built.rolloutErr != nil {
+			log.Error(built.rolloutErr, "could not apply rollout", logging.ConstraintKind, obj.GetKind(), logging.ConstraintName, obj.GetName())
+			continue
+		}
+		action, err := util.GetEnforcementAction(built.obj.Object)
+		if err != nil {
+			log.Error(err, "could not read enforcement action", logging.ConstraintKind, obj.GetKind(), logging.ConstraintName, obj.GetName())
+			continue
+		}
+		if !enforced {
+			log.Info("re-adding constraint missing from OPA", logging.ConstraintKind, obj.GetKind(), logging.ConstraintName, obj.GetName())
+			c.reportDrift(opaStore, missingDrift, 1)
+			if err := cacheConstraint(ctx, c.opa, built.obj); err != nil {
+				log.Error(err, "could not re-add constraint", logging.ConstraintKind, obj.GetKind(), logging.ConstraintName, obj.GetName())
+				continue
+			}
+		}
+		if !cached[key] {
+			log.Info("re-adding constraint missing from the constraints cache", logging.ConstraintKind, obj.GetKind(), logging.ConstraintName, obj.GetName())
+			c.reportDrift(cacheStore, missingDrift, 1)
+		}
+		c.cache.addConstraintKey(key, tags{enforcementAction: action, status: metrics.ActiveStatus})
+	}
+
+	for key, ghost := range inOpa {
+		if liveKeys[key] {
+			continue
+		}
+		log.Info("removing deleted constraint from OPA", logging.ConstraintKind, ghost.GetKind(), logging.ConstraintName, ghost.GetName())
+		c.reportDrift(opaStore, ghostDrift, 1)
+		if _, err := c.opa.RemoveConstraint(ctx, ghost); err != nil {
+			log.Error(err, "could not remove constraint", logging.ConstraintKind, ghost.GetKind(), logging.ConstraintName, ghost.GetName())
+		}
+	}
+	if evicted := c.cache.EvictOrphans(cachedKeys, liveKeys); evicted > 0 {
+		log.Info("evicted deleted constraints from the constraints cache", "count", evicted)
+		c.reportDrift(cacheStore, ghostDrift, int64(evicted))
+	}
+	c.cache.reportTotalConstraints(c.reporter)
+	return nil
+}
+
+func (c *ConsistencyCheck) reportDrift(store, drift string, v int64) {
+	if err := c.reporter.reportDrift(store, drift, v); err != nil {
+		log.Error(err, "failed to report constraint drift")
+	}
+}
+
+// opaConstraints returns the constraints in OPA, keyed by ConstraintKey
+func (c *ConsistencyCheck) opaConstraints(ctx context.Context) (map[string]*unstructured.Unstructured, error) {
+	list, err := c.lister.Constraints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	constraints := make(map[string]*unstructured.Unstructured, len(list))
+	for _, obj := range list {
+		constraints[ConstraintKey(obj.GetKind(), obj.GetName())] = obj
+	}
+	return constraints, nil
+}
+
+// liveConstraints returns the constraints of every template that are not being deleted,
+// keyed by ConstraintKey
+func liveConstraints(ctx context.Context, reader client.Reader) (map[string]*unstructured.Unstructured, error) {
+	templates := &templv1beta1.ConstraintTemplateList{}
+	if err := reader.List(ctx, templates); err != nil {
+		return nil, err
+	}
+	live := make(map[string]*unstructured.Unstructured)
+	for _, templ := range templates.Items {
+		kind := templ.Spec.CRD.Spec.Names.Kind
+		if kind == "" {
+			continue
+		}
+		gvk := schema.GroupVersionKind{Group: constraintsGroup, Version: "v1beta1", Kind: kind}
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(kind + "List"))
+		if err := reader.List(ctx, list); err != nil {
+			if meta.IsNoMatchError(err) || errors.IsNotFound(err) {
+				// the template's CRD has not been created yet
+				continue
+			}
+			return nil, err
+		}
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if !obj.GetDeletionTimestamp().IsZero() {
+				continue
+			}
+			obj.SetGroupVersionKind(gvk)
+			live[ConstraintKey(kind, obj.GetName())] = obj
+		}
+	}
+	return live, nil
+}
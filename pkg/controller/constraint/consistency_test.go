@@ -0,0 +1,93 @@
+package constraint
+
+import (
+	"context"
+	"testing"
+
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"github.com/open-policy-agent/gatekeeper/pkg/metrics"
+	"github.com/open-policy-agent/gatekeeper/pkg/testutils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestConsistencyCheck(t *testing.T) {
+	defer resetViews(t)
+	templ := &templv1beta1.ConstraintTemplate{ObjectMeta: metav1.ObjectMeta{Name: "k8srequiredlabels"}}
+	templ.Spec.CRD.Spec.Names.Kind = "K8sRequiredLabels"
+	inSync := testutils.NewConstraint("K8sRequiredLabels", "in-sync")
+	notInOpa := testutils.NewConstraint("K8sRequiredLabels", "not-in-opa")
+	notCached := testutils.NewConstraint("K8sRequiredLabels", "not-cached", testutils.WithEnforcementAction("dryrun"))
+	failing := testutils.NewConstraint("K8sRequiredLabels", "failing")
+	deleted := testutils.NewConstraint("K8sRequiredLabels", "deleted")
+	scheme := newScheme(t)
+	// the fake client only lists kinds known to its scheme
+	gvk := testutils.ConstraintGVK("K8sRequiredLabels")
+	scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind("K8sRequiredLabelsList"), &unstructured.UnstructuredList{})
+	// status is not added to OPA
+	if err := unstructured.SetNestedField(notInOpa.Object, int64(1), "status", "totalViolations"); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewFakeClientWithScheme(scheme, templ, inSync, notInOpa, notCached, failing)
+
+	fakeOpa := testutils.NewFakeOpa()
+	cache := NewConstraintsCache()
+	active := tags{enforcementAction: "deny", status: metrics.ActiveStatus}
+	for _, obj := range []*unstructured.Unstructured{inSync, notCached, deleted} {
+		if _, err := fakeOpa.AddConstraint(context.TODO(), obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cache.addConstraintKey(ConstraintKey("K8sRequiredLabels", "in-sync"), active)
+	cache.addConstraintKey(ConstraintKey("K8sRequiredLabels", "not-in-opa"), active)
+	cache.addConstraintKey(ConstraintKey("K8sRequiredLabels", "deleted"), active)
+	cache.addConstraintKey(ConstraintKey("K8sRequiredLabels", "failing"), tags{enforcementAction: "deny", status: metrics.ErrorStatus})
+
+	reporter, err := newStatsReporter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := &ConsistencyCheck{reader: c, opa: fakeOpa, lister: fakeOpa, cache: cache, reporter: reporter}
+	if err := check.check(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, obj := range []*unstructured.Unstructured{inSync, notInOpa, notCached} {
+		if !fakeOpa.HasConstraint(obj) {
+			t.Errorf("%s is not in OPA", obj.GetName())
+		}
+	}
+	added, err := fakeOpa.GetConstraint(context.TODO(), notInOpa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := added.Object["status"]; found {
+		t.Error("constraint was added to OPA with its status")
+	}
+	if fakeOpa.HasConstraint(deleted) {
+		t.Error("deleted constraint is still in OPA")
+	}
+	if fakeOpa.HasConstraint(failing) {
+		t.Error("constraint with an error status was added to OPA")
+	}
+	want := map[string]bool{
+		ConstraintKey("K8sRequiredLabels", "in-sync"):    true,
+		ConstraintKey("K8sRequiredLabels", "not-in-opa"): true,
+		ConstraintKey("K8sRequiredLabels", "not-cached"): true,
+		ConstraintKey("K8sRequiredLabels", "failing"):    true,
+	}
+	keys := cache.Keys()
+	if len(keys) != len(want) {
+		t.Errorf("cache keys = %v, wanted %v", keys, want)
+	}
+	for _, k := range keys {
+		if !want[k] {
+			t.Errorf("unexpected cache key %s", k)
+		}
+	}
+	if got := cache.cache[ConstraintKey("K8sRequiredLabels", "not-cached")].enforcementAction; got != "dryrun" {
+		t.Errorf("cached enforcementAction = %q, wanted dryrun", got)
+	}
+}
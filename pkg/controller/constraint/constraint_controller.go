@@ -17,47 +17,85 @@ package constraint
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
 	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/core/constraints"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	configv1alpha1 "github.com/open-policy-agent/gatekeeper/api/v1alpha1"
 	"github.com/open-policy-agent/gatekeeper/pkg/logging"
 	"github.com/open-policy-agent/gatekeeper/pkg/metrics"
+	"github.com/open-policy-agent/gatekeeper/pkg/readiness"
+	"github.com/open-policy-agent/gatekeeper/pkg/redact"
 	"github.com/open-policy-agent/gatekeeper/pkg/util"
 	csutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
 	"github.com/open-policy-agent/gatekeeper/pkg/watch"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 var (
 	log = logf.Log.WithName("controller").WithValues(logging.Process, "constraint_controller")
+
+	reconcileTimeout = flag.Duration("reconcile-timeout", 0, "maximum time a single constraint reconcile may spend on its OPA and API server calls before they are cancelled and the constraint is retried. 0 means no limit")
 )
 
 const (
 	finalizerName = "finalizers.gatekeeper.sh/constraint"
+	// unknownKindRecheck is how often constraints matching unknown kinds are checked again
+	unknownKindRecheck = time.Minute
 )
 
 type Adder struct {
-	Opa              *opa.Client
+	Opa              OpaClient
 	ConstraintsCache *ConstraintsCache
 }
 
+// OpaClient is the subset of the constraint framework client used by the
+// constraint controller. It is satisfied by *opa.Client and by the fake in
+// pkg/testutils.
+type OpaClient interface {
+	AddConstraint(ctx context.Context, constraint *unstructured.Unstructured) (*types.Responses, error)
+	RemoveConstraint(ctx context.Context, constraint *unstructured.Unstructured) (*types.Responses, error)
+	GetConstraint(ctx context.Context, constraint *unstructured.Unstructured) (*unstructured.Unstructured, error)
+}
+
+// RecordRollouts is whether this process emits the events of rollouts. It is only set in
+// the controller role, so each rollout is reported once rather than by every pod.
+var RecordRollouts = true
+
+// Cache is the ConstraintsCache shared by the constraint controllers and the audit,
+// which evicts the entries of constraints that no longer exist
+var Cache = NewConstraintsCache()
+
+// ConstraintsCache tracks the constraints known to this process, keyed by ConstraintKey,
+// for reporting metrics
 type ConstraintsCache struct {
 	mux   sync.RWMutex
 	cache map[string]tags
+	// constraints whose templates read data.inventory kinds that are not synced
+	missingSync map[string]bool
+	reporter    StatsReporter
 }
 
 type tags struct {
@@ -68,34 +106,60 @@ type tags struct {
 // Add creates a new Constraint Controller and adds it to the Manager with default RBAC. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func (a *Adder) Add(mgr manager.Manager, gvk schema.GroupVersionKind, cs *watch.ControllerSwitch) error {
-	reporter, err := newStatsReporter()
+	r, err := NewReconciler(mgr.GetClient(), mgr.GetScheme(), gvk, a.Opa, cs, a.ConstraintsCache)
 	if err != nil {
-		log.Error(err, "StatsReporter could not start")
 		return err
 	}
-
-	r := newReconciler(mgr, gvk, a.Opa, cs, reporter, a.ConstraintsCache)
+	r.mapper = mgr.GetRESTMapper()
+	r.cluster = getClusterInfo(mgr.GetConfig())
+	if RecordRollouts {
+		r.recorder = mgr.GetEventRecorderFor("gatekeeper-constraint-controller")
+	}
+	// in-flight reconciles are cancelled when the manager stops, either on shutdown or
+	// when the watch manager restarts it for a new set of kinds
+	ctx, cancel := context.WithCancel(context.Background())
+	r.ctx = ctx
+	if err := mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		<-stop
+		cancel()
+		return nil
+	})); err != nil {
+		cancel()
+		return err
+	}
 	return add(mgr, r, gvk)
 }
 
-// newReconciler returns a new reconcile.Reconciler
-func newReconciler(
-	mgr manager.Manager,
+// NewReconciler returns a reconciler for constraints of the given kind. It is exported
+// so that reconcile behavior can be exercised without a running manager, see pkg/testutils.
+func NewReconciler(
+	c client.Client,
+	scheme *runtime.Scheme,
 	gvk schema.GroupVersionKind,
-	opa *opa.Client,
+	opa OpaClient,
 	cs *watch.ControllerSwitch,
-	reporter StatsReporter,
-	constraintsCache *ConstraintsCache) reconcile.Reconciler {
+	constraintsCache *ConstraintsCache) (*ReconcileConstraint, error) {
+	reporter, err := newStatsReporter()
+	if err != nil {
+		log.Error(err, "StatsReporter could not start")
+		return nil, err
+	}
 	return &ReconcileConstraint{
-		Client:           mgr.GetClient(),
+		ctx:              context.Background(),
+		Client:           c,
 		cs:               cs,
-		scheme:           mgr.GetScheme(),
+		scheme:           scheme,
 		opa:              opa,
 		log:              log.WithValues(logging.ConstraintKind, gvk.Kind, logging.ConstraintAPIVersion, gvk.GroupVersion().String()),
 		gvk:              gvk,
 		reporter:         reporter,
 		constraintsCache: constraintsCache,
-	}
+		tracker:          readiness.Startup,
+		now:              time.Now,
+		templateVersions: &templateVersions{
+			byName: make(map[string]string),
+		},
+	}, nil
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -114,6 +178,39 @@ func add(mgr manager.Manager, r reconcile.Reconciler, gvk schema.GroupVersionKin
 		return err
 	}
 
+	// Re-check conflicts with the other constraints of the kind when one is created,
+	// deleted or has its spec changed
+	err = c.Watch(
+		&source.Kind{Type: &instance},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: conflictingConstraints(mgr.GetClient(), gvk)},
+		predicate.GenerationChangedPredicate{})
+	if err != nil {
+		return err
+	}
+
+	// Re-check sync warnings when the sync config changes, and reload constraints into OPA
+	// when the template's spec changes
+	toConstraints := &handler.EnqueueRequestsFromMapFunc{ToRequests: constraintsOfKind(mgr.GetClient(), gvk)}
+	err = c.Watch(&source.Kind{Type: &configv1alpha1.Config{}}, toConstraints)
+	if err != nil {
+		return err
+	}
+	err = c.Watch(
+		&source.Kind{Type: &templv1beta1.ConstraintTemplate{}},
+		toConstraints,
+		predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool { return e.Meta.GetName() == strings.ToLower(gvk.Kind) },
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				// status updates do not change the generation
+				return e.MetaNew.GetName() == strings.ToLower(gvk.Kind) && e.MetaNew.GetGeneration() != e.MetaOld.GetGeneration()
+			},
+			DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+			GenericFunc: func(e event.GenericEvent) bool { return false },
+		})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -121,14 +218,56 @@ var _ reconcile.Reconciler = &ReconcileConstraint{}
 
 // ReconcileSync reconciles an arbitrary constraint object described by Kind
 type ReconcileConstraint struct {
+	// ctx is the parent of each reconcile's context and is cancelled when the
+	// controller stops
+	ctx context.Context
 	client.Client
 	cs               *watch.ControllerSwitch
 	scheme           *runtime.Scheme
-	opa              *opa.Client
+	opa              OpaClient
 	gvk              schema.GroupVersionKind
 	log              logr.Logger
 	reporter         StatsReporter
 	constraintsCache *ConstraintsCache
+	// mapper checks match.kinds against API discovery. The check is skipped if nil
+	mapper meta.RESTMapper
+	// tracker is told when each constraint has been handled, so data sync can start
+	tracker *readiness.Tracker
+	// cluster is checked against spec.match.clusterVersions and spec.match.featureGates
+	cluster *csutil.ClusterInfo
+	// templateVersions holds the template version each constraint was loaded with, so
+	// constraints are reloaded into OPA when their template changes
+	templateVersions *templateVersions
+	// recorder emits the events of rollouts. Events are not emitted if nil
+	recorder record.EventRecorder
+	now      func() time.Time
+}
+
+// templateVersions maps constraint names to the UID and generation of their template
+type templateVersions struct {
+	mux    sync.Mutex
+	byName map[string]string
+}
+
+// changed returns true if the constraint was loaded with a template version other than
+// version. Constraints that were never loaded have not changed.
+func (v *templateVersions) changed(name, version string) bool {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+	loaded, ok := v.byName[name]
+	return ok && loaded != version
+}
+
+func (v *templateVersions) set(name, version string) {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+	v.byName[name] = version
+}
+
+func (v *templateVersions) remove(name string) {
+	v.mux.Lock()
+	defer v.mux.Unlock()
+	delete(v.byName, name)
 }
 
 // +kubebuilder:rbac:groups=constraints.gatekeeper.sh,resources=*,verbs=get;list;watch;create;update;patch;delete
@@ -142,21 +281,42 @@ func (r *ReconcileConstraint) Reconcile(request reconcile.Request) (reconcile.Re
 		r.log.Info("ignoring request, constraint controller disabled", "request", request)
 		return reconcile.Result{}, nil
 	}
+	ctx, cancel := r.reconcileContext()
+	defer cancel()
 	instance := &unstructured.Unstructured{}
 	instance.SetGroupVersionKind(r.gvk)
-	err := r.Get(context.TODO(), request.NamespacedName, instance)
+	err := r.Get(ctx, request.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Object not found, return.  Created objects are automatically garbage collected.
 			// For additional cleanup logic use finalizers.
+			r.tracker.ObserveConstraint(ConstraintKey(r.gvk.Kind, request.Name))
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
 
-	constraintKey := strings.Join([]string{instance.GetKind(), instance.GetName()}, "/")
-	enforcementAction, err := util.GetEnforcementAction(instance.Object)
+	cfg, err := r.getConfig(ctx)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	// built is what OPA enforces: the constraint with cluster and template defaults applied.
+	// An invalid rollout is reported in the status, unless the constraint is being deleted.
+	built, err := buildEffective(ctx, r, instance, cfg, r.now())
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	effective, templ := built.obj, built.templ
+	paramsDefaulted, rolloutEnd, rollingOut, rolloutErr := built.paramsDefaulted, built.rolloutEnd, built.rollingOut, built.rolloutErr
+	// a recreated template starts again from generation 1, so its UID is tracked too
+	var templateVersion string
+	if templ != nil {
+		templateVersion = fmt.Sprintf("%s/%d", templ.GetUID(), templ.GetGeneration())
+	}
+
+	constraintKey := ConstraintKey(instance.GetKind(), instance.GetName())
+	enforcementAction, err := util.GetEnforcementAction(effective.Object)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
@@ -172,7 +332,7 @@ func (r *ReconcileConstraint) Reconcile(request reconcile.Request) (reconcile.Re
 		if !HasFinalizer(instance) {
 			status, _, _ := unstructured.NestedFieldCopy(instance.Object, "status")
 			instance.SetFinalizers(append(instance.GetFinalizers(), finalizerName))
-			if err := r.Update(context.Background(), instance); err != nil {
+			if err := r.Update(ctx, instance); err != nil {
 				return reconcile.Result{Requeue: true}, nil
 			}
 
@@ -182,68 +342,225 @@ func (r *ReconcileConstraint) Reconcile(request reconcile.Request) (reconcile.Re
 				}
 			}
 		}
-		r.log.Info("handling constraint update", "instance", instance)
+		r.log.Info("handling constraint update", "instance", redact.Unstructured(instance))
 		status, err := csutil.GetHAStatus(instance)
 		if err != nil {
 			return reconcile.Result{}, err
 		}
+		wasRollingOut := hasCondition(status.Conditions, csutil.RolloutCondition)
 		status.Errors = nil
+		status.Conditions = nil
 		if err = csutil.SetHAStatus(instance, status); err != nil {
 			return reconcile.Result{}, err
 		}
-		if c, err := r.opa.GetConstraint(context.TODO(), instance); err != nil || !constraints.SemanticEqual(instance, c) {
-			if err := r.cacheConstraint(instance); err != nil {
-				r.constraintsCache.addConstraintKey(constraintKey, tags{
-					enforcementAction: enforcementAction,
-					status:            metrics.ErrorStatus,
-				})
-				status.Errors = append(status.Errors, csutil.Error{Message: err.Error()})
-				if err2 := csutil.SetHAStatus(instance, status); err2 != nil {
-					log.Error(err2, "could not set constraint error status")
-				}
-				if err2 := r.Status().Update(context.TODO(), instance); err2 != nil {
-					log.Error(err2, "could not report constraint error status")
-				}
+		status.EnforcementAction = string(enforcementAction)
+		status.EffectiveParameters = nil
+		if paramsDefaulted {
+			status.EffectiveParameters, _, _ = unstructured.NestedMap(effective.Object, "spec", "parameters")
+		}
+		if rolloutErr != nil {
+			reportMetrics = true
+			return reconcile.Result{}, r.reportError(ctx, instance, status, enforcementAction, csutil.SchemaErrorCode, rolloutErr)
+		}
+		inactive, err := r.cluster.InactiveReason(instance)
+		if err != nil {
+			reportMetrics = true
+			return reconcile.Result{}, r.reportError(ctx, instance, status, enforcementAction, csutil.SchemaErrorCode, err)
+		}
+		if inactive != "" {
+			reportMetrics = true
+			return r.deactivate(ctx, instance, status, enforcementAction, inactive)
+		}
+		templateChanged := r.templateVersions.changed(instance.GetName(), templateVersion)
+		if c, err := r.opa.GetConstraint(ctx, effective); err != nil || !constraints.SemanticEqual(effective, c) || templateChanged {
+			if templateChanged {
+				r.log.Info("re-adding constraint after its template changed", logging.ConstraintName, instance.GetName(), "template_version", templateVersion)
+			}
+			if err := r.cacheConstraint(ctx, effective); err != nil {
 				reportMetrics = true
-				return reconcile.Result{}, err
+				return reconcile.Result{}, r.reportError(ctx, instance, status, enforcementAction, errorCode(err), err)
 			}
-			logAddition(r.log, instance, enforcementAction)
+			logAddition(r.log, effective, enforcementAction)
 		}
+		r.templateVersions.set(instance.GetName(), templateVersion)
 		status.Enforced = true
+		missing, err := r.missingSyncKinds(ctx, cfg)
+		if err != nil {
+			r.log.Error(err, "could not check that referenced data is synced")
+		}
+		if len(missing) > 0 {
+			status.Conditions = append(status.Conditions, csutil.Condition{
+				Type:    csutil.MissingSyncCondition,
+				Message: missingSyncMessage(missing),
+			})
+		}
+		r.constraintsCache.setMissingSync(constraintKey, len(missing) > 0)
+		unknown := r.unknownKinds(instance)
+		if len(unknown) > 0 {
+			status.Conditions = append(status.Conditions, csutil.Condition{
+				Type:    csutil.UnknownKindCondition,
+				Message: unknownKindMessage(unknown),
+			})
+		}
+		if msg := csutil.DeprecatedVersionMessage(instance); msg != "" {
+			status.Conditions = append(status.Conditions, csutil.Condition{
+				Type:    csutil.DeprecatedVersionCondition,
+				Message: msg,
+			})
+		}
+		conflicts, err := r.conflicts(ctx, templ, instance)
+		if err != nil {
+			r.log.Error(err, "could not check for conflicting constraints")
+		}
+		if len(conflicts) > 0 {
+			status.Conditions = append(status.Conditions, csutil.Condition{
+				Type:    csutil.ConflictCondition,
+				Message: conflictMessage(conflicts),
+			})
+		}
+		if rollingOut {
+			status.Conditions = append(status.Conditions, csutil.Condition{
+				Type:    csutil.RolloutCondition,
+				Message: csutil.RolloutMessage(rolloutEnd),
+			})
+		}
+		if built.downgraded != "" {
+			status.Conditions = append(status.Conditions, csutil.Condition{
+				Type:    csutil.DowngradedCondition,
+				Message: csutil.DowngradedMessage(built.downgraded),
+			})
+		}
 		if err = csutil.SetHAStatus(instance, status); err != nil {
 			return reconcile.Result{}, err
 		}
-		if err = r.Status().Update(context.Background(), instance); err != nil {
+		if err = r.Status().Update(ctx, instance); err != nil {
 			return reconcile.Result{Requeue: true}, nil
 		}
+		r.recordRollout(instance, wasRollingOut, rollingOut, rolloutEnd)
 		// adding constraint to cache and sending metrics
 		r.constraintsCache.addConstraintKey(constraintKey, tags{
 			enforcementAction: enforcementAction,
 			status:            metrics.ActiveStatus,
 		})
+		r.tracker.ObserveConstraint(constraintKey)
 		reportMetrics = true
+		var requeue time.Duration
+		if len(unknown) > 0 {
+			// the kinds may be served by CRDs that are not installed yet
+			requeue = unknownKindRecheck
+		}
+		if remaining := rolloutEnd.Sub(r.now()); rollingOut && (requeue == 0 || remaining < requeue) {
+			// reconcile again to start denying
+			requeue = remaining
+		}
+		if requeue > 0 {
+			return reconcile.Result{RequeueAfter: requeue}, nil
+		}
 	} else {
 		// Handle deletion
 		if HasFinalizer(instance) {
-			if _, err := r.opa.RemoveConstraint(context.Background(), instance); err != nil {
+			if _, err := r.opa.RemoveConstraint(ctx, instance); err != nil {
 				if _, ok := err.(*opa.UnrecognizedConstraintError); !ok {
 					logRemoval(r.log, instance, enforcementAction)
 					return reconcile.Result{}, err
 				}
 			}
 			logRemoval(r.log, instance, enforcementAction)
+			r.templateVersions.remove(instance.GetName())
 			RemoveFinalizer(instance)
-			if err := r.Update(context.Background(), instance); err != nil {
+			if err := r.Update(ctx, instance); err != nil {
 				return reconcile.Result{Requeue: true}, nil
 			}
 			// removing constraint entry from cache
 			r.constraintsCache.deleteConstraintKey(constraintKey)
+			r.constraintsCache.setMissingSync(constraintKey, false)
 			reportMetrics = true
 		}
+		r.tracker.ObserveConstraint(constraintKey)
 	}
 	return reconcile.Result{}, nil
 }
 
+// recordRollout emits an event when a rollout's dry-run phase starts or ends, as seen
+// from the status this pod last wrote. Only the controller role has a recorder.
+func (r *ReconcileConstraint) recordRollout(instance *unstructured.Unstructured, wasRollingOut, rollingOut bool, end time.Time) {
+	if r.recorder == nil || wasRollingOut == rollingOut {
+		return
+	}
+	if rollingOut {
+		r.recorder.Event(instance, corev1.EventTypeNormal, "RolloutStarted", csutil.RolloutMessage(end))
+		return
+	}
+	r.log.Info("constraint rollout complete", logging.ConstraintName, instance.GetName())
+	r.recorder.Eventf(instance, corev1.EventTypeNormal, "RolloutComplete", "the dry-run phase ended at %s, the constraint now uses its enforcement action", end.UTC().Format(time.RFC3339))
+}
+
+func hasCondition(conditions []csutil.Condition, conditionType string) bool {
+	for _, c := range conditions {
+		if c.Type == conditionType {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcileContext returns the context of a single reconcile, limited by --reconcile-timeout
+func (r *ReconcileConstraint) reconcileContext() (context.Context, context.CancelFunc) {
+	if *reconcileTimeout > 0 {
+		return context.WithTimeout(r.ctx, *reconcileTimeout)
+	}
+	return context.WithCancel(r.ctx)
+}
+
+// reportError records that the constraint could not be enforced because of err, and
+// returns err
+func (r *ReconcileConstraint) reportError(ctx context.Context, instance *unstructured.Unstructured, status *csutil.ByPodStatus, enforcementAction util.EnforcementAction, code string, err error) error {
+	constraintKey := ConstraintKey(instance.GetKind(), instance.GetName())
+	r.constraintsCache.addConstraintKey(constraintKey, tags{
+		enforcementAction: enforcementAction,
+		status:            metrics.ErrorStatus,
+	})
+	r.tracker.ObserveConstraint(constraintKey)
+	if err2 := r.reporter.reportConstraintError(code); err2 != nil {
+		log.Error(err2, "failed to report constraint error")
+	}
+	status.Errors = append(status.Errors, csutil.Error{Code: code, Message: err.Error()})
+	if err2 := csutil.SetHAStatus(instance, status); err2 != nil {
+		log.Error(err2, "could not set constraint error status")
+	}
+	if err2 := r.Status().Update(ctx, instance); err2 != nil {
+		log.Error(err2, "could not report constraint error status")
+	}
+	return err
+}
+
+// deactivate removes a constraint whose cluster conditions do not hold from OPA and
+// reports why in its status
+func (r *ReconcileConstraint) deactivate(ctx context.Context, instance *unstructured.Unstructured, status *csutil.ByPodStatus, enforcementAction util.EnforcementAction, reason string) (reconcile.Result, error) {
+	if _, err := r.opa.RemoveConstraint(ctx, instance); err != nil {
+		if _, ok := err.(*opa.UnrecognizedConstraintError); !ok {
+			return reconcile.Result{}, err
+		}
+	}
+	r.log.Info("constraint inactive on this cluster", logging.ConstraintName, instance.GetName(), "reason", reason)
+	status.Enforced = false
+	status.Conditions = append(status.Conditions, csutil.Condition{Type: csutil.InactiveCondition, Message: reason})
+	if err := csutil.SetHAStatus(instance, status); err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := r.Status().Update(ctx, instance); err != nil {
+		return reconcile.Result{Requeue: true}, nil
+	}
+	constraintKey := ConstraintKey(instance.GetKind(), instance.GetName())
+	r.constraintsCache.addConstraintKey(constraintKey, tags{
+		enforcementAction: enforcementAction,
+		status:            metrics.InactiveStatus,
+	})
+	r.constraintsCache.setMissingSync(constraintKey, false)
+	r.tracker.ObserveConstraint(constraintKey)
+	return reconcile.Result{}, nil
+}
+
 func logAddition(l logr.Logger, constraint *unstructured.Unstructured, enforcementAction util.EnforcementAction) {
 	l.Info(
 		"constraint added to OPA",
@@ -264,11 +581,15 @@ func logRemoval(l logr.Logger, constraint *unstructured.Unstructured, enforcemen
 	)
 }
 
-func (r *ReconcileConstraint) cacheConstraint(instance *unstructured.Unstructured) error {
+func (r *ReconcileConstraint) cacheConstraint(ctx context.Context, instance *unstructured.Unstructured) error {
+	return cacheConstraint(ctx, r.opa, instance)
+}
+
+// cacheConstraint adds instance to OPA without its status, which OPA does not need
+func cacheConstraint(ctx context.Context, opa OpaClient, instance *unstructured.Unstructured) error {
 	obj := instance.DeepCopy()
-	// Remove the status field since we do not need it for OPA
 	unstructured.RemoveNestedField(obj.Object, "status")
-	_, err := r.opa.AddConstraint(context.Background(), obj)
+	_, err := opa.AddConstraint(ctx, obj)
 	return err
 }
 
@@ -299,9 +620,116 @@ func removeString(s string, items []string) []string {
 	return rval
 }
 
+// ConstraintKey returns the key of a constraint in the ConstraintsCache
+func ConstraintKey(kind, name string) string {
+	return strings.Join([]string{kind, name}, "/")
+}
+
 func NewConstraintsCache() *ConstraintsCache {
 	return &ConstraintsCache{
-		cache: make(map[string]tags),
+		cache:       make(map[string]tags),
+		missingSync: make(map[string]bool),
+		reporter:    &reporter{ctx: context.Background()},
+	}
+}
+
+// Keys returns the keys of every cached constraint
+func (c *ConstraintsCache) Keys() []string {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	keys := make([]string, 0, len(c.cache)+len(c.missingSync))
+	for k := range c.cache {
+		keys = append(keys, k)
+	}
+	for k := range c.missingSync {
+		if _, ok := c.cache[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Len returns the number of cached constraints
+func (c *ConstraintsCache) Len() int {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	return len(c.cache)
+}
+
+// Active returns the number of cached constraints that are enforced
+func (c *ConstraintsCache) Active() int {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	active := 0
+	for _, t := range c.cache {
+		if t.status == metrics.ActiveStatus {
+			active++
+		}
+	}
+	return active
+}
+
+// EvictOrphans removes the keys that are not in live and returns how many were removed.
+// Only keys read before live was listed should be passed, so that constraints created
+// while listing are kept. Entries are orphaned when a constraint kind is removed along
+// with its template, because its constraints are deleted without being reconciled.
+func (c *ConstraintsCache) EvictOrphans(keys []string, live map[string]bool) int {
+	c.mux.Lock()
+	evicted := 0
+	for _, k := range keys {
+		if live[k] {
+			continue
+		}
+		_, cached := c.cache[k]
+		if !cached && !c.missingSync[k] {
+			continue
+		}
+		delete(c.cache, k)
+		delete(c.missingSync, k)
+		evicted++
+	}
+	c.mux.Unlock()
+
+	if evicted == 0 {
+		return 0
+	}
+	if err := c.reporter.reportEvictions(int64(evicted)); err != nil {
+		log.Error(err, "failed to report constraints cache evictions")
+	}
+	c.reportTotalConstraints(c.reporter)
+	return evicted
+}
+
+// hasError returns true if the cached constraint could not be added to OPA
+func (c *ConstraintsCache) hasError(constraintKey string) bool {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	t, ok := c.cache[constraintKey]
+	return ok && t.status == metrics.ErrorStatus
+}
+
+// isInactive returns true if the cached constraint is kept out of OPA because its
+// cluster conditions do not hold
+func (c *ConstraintsCache) isInactive(constraintKey string) bool {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	t, ok := c.cache[constraintKey]
+	return ok && t.status == metrics.InactiveStatus
+}
+
+func (c *ConstraintsCache) setMissingSync(constraintKey string, missing bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if missing {
+		c.missingSync[constraintKey] = true
+	} else {
+		delete(c.missingSync, constraintKey)
 	}
 }
 
@@ -347,4 +775,10 @@ func (c *ConstraintsCache) reportTotalConstraints(reporter StatsReporter) {
 			}
 		}
 	}
+	if err := reporter.reportMissingSync(int64(len(c.missingSync))); err != nil {
+		log.Error(err, "failed to report constraints with missing sync")
+	}
+	if err := reporter.reportCacheSize(int64(len(c.cache))); err != nil {
+		log.Error(err, "failed to report constraints cache size")
+	}
 }
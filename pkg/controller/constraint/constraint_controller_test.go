@@ -1,11 +1,36 @@
 package constraint
 
 import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	gktypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/api"
+	configv1alpha1 "github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/controller/config"
 	"github.com/open-policy-agent/gatekeeper/pkg/metrics"
+	"github.com/open-policy-agent/gatekeeper/pkg/testutils"
 	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	csutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
+	"github.com/open-policy-agent/gatekeeper/pkg/watch"
+	"go.opencensus.io/stats/view"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 func TestTotalConstraintsCache(t *testing.T) {
@@ -27,3 +52,688 @@ func TestTotalConstraintsCache(t *testing.T) {
 		t.Errorf("cache: %v, wanted empty cache", spew.Sdump(constraintsCache.cache))
 	}
 }
+
+func TestEvictOrphans(t *testing.T) {
+	defer resetViews(t)
+	constraintsCache := NewConstraintsCache()
+	active := tags{enforcementAction: util.Deny, status: metrics.ActiveStatus}
+	constraintsCache.addConstraintKey(ConstraintKey("K8sRequiredLabels", "live"), active)
+	constraintsCache.addConstraintKey(ConstraintKey("K8sDeletedKind", "orphan"), active)
+	constraintsCache.setMissingSync(ConstraintKey("K8sDeletedKind", "orphan"), true)
+	keys := constraintsCache.Keys()
+	// added after the keys were read, so it must survive even though it was not listed
+	constraintsCache.addConstraintKey(ConstraintKey("K8sRequiredLabels", "new"), active)
+
+	live := map[string]bool{ConstraintKey("K8sRequiredLabels", "live"): true}
+	if evicted := constraintsCache.EvictOrphans(keys, live); evicted != 1 {
+		t.Errorf("EvictOrphans() = %d, want 1", evicted)
+	}
+	if constraintsCache.Len() != 2 {
+		t.Errorf("cache: %v, wanted cache with 2 elements", spew.Sdump(constraintsCache.cache))
+	}
+	if len(constraintsCache.missingSync) != 0 {
+		t.Errorf("missingSync: %v, wanted empty", constraintsCache.missingSync)
+	}
+	if evicted := constraintsCache.EvictOrphans(keys, live); evicted != 0 {
+		t.Errorf("second EvictOrphans() = %d, want 0", evicted)
+	}
+
+	row := checkData(t, evictionsMetricName, 1)
+	if value, ok := row.Data.(*view.SumData); !ok || value.Value != 1 {
+		t.Errorf("Metric: %v - Expected 1, got %v", evictionsMetricName, row.Data)
+	}
+	row = checkData(t, cacheSizeMetricName, 1)
+	if value, ok := row.Data.(*view.LastValueData); !ok || value.Value != 2 {
+		t.Errorf("Metric: %v - Expected 2, got %v", cacheSizeMetricName, row.Data)
+	}
+}
+
+func TestReconcileConstraint(t *testing.T) {
+	defer resetViews(t)
+	gvk := testutils.ConstraintGVK("K8sRequiredLabels")
+	instance := testutils.NewConstraint("K8sRequiredLabels", "must-have-owner",
+		testutils.WithEnforcementAction("dryrun"),
+		testutils.WithMatchKinds([]string{""}, []string{"Namespace"}))
+	scheme := newScheme(t)
+	c := fake.NewFakeClientWithScheme(scheme, instance)
+	fakeOpa := testutils.NewFakeOpa()
+	r, err := NewReconciler(c, scheme, gvk, fakeOpa, watch.NewSwitch(), NewConstraintsCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "must-have-owner"}}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if !fakeOpa.HasConstraint(instance) {
+		t.Error("constraint was not added to OPA")
+	}
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(gvk)
+	if err := c.Get(context.TODO(), req.NamespacedName, got); err != nil {
+		t.Fatal(err)
+	}
+	if !HasFinalizer(got) {
+		t.Error("finalizer was not added")
+	}
+	status, err := csutil.GetHAStatus(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Enforced {
+		t.Errorf("status = %v, wanted enforced", spew.Sdump(status))
+	}
+
+	fakeOpa.AddConstraintErr = errors.New("compile failure")
+	if err := unstructured.SetNestedField(got.Object, "deny", "spec", "enforcementAction"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Update(context.TODO(), got); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(req); err == nil {
+		t.Error("Reconcile() should propagate OPA errors")
+	}
+	if err := c.Get(context.TODO(), req.NamespacedName, got); err != nil {
+		t.Fatal(err)
+	}
+	status, err = csutil.GetHAStatus(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Errors) != 1 || status.Errors[0].Code != csutil.InternalErrorCode {
+		t.Errorf("status = %v, wanted one %s error", spew.Sdump(status), csutil.InternalErrorCode)
+	}
+}
+
+// ctxOpa records the context constraints are added to OPA with
+type ctxOpa struct {
+	*testutils.FakeOpa
+	ctx context.Context
+}
+
+func (o *ctxOpa) AddConstraint(ctx context.Context, constraint *unstructured.Unstructured) (*gktypes.Responses, error) {
+	o.ctx = ctx
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return o.FakeOpa.AddConstraint(ctx, constraint)
+}
+
+func TestReconcileConstraintContext(t *testing.T) {
+	defer resetViews(t)
+	defer func(d time.Duration) { *reconcileTimeout = d }(*reconcileTimeout)
+	gvk := testutils.ConstraintGVK("K8sRequiredLabels")
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "must-have-owner"}}
+	newReconciler := func(t *testing.T, opa OpaClient) *ReconcileConstraint {
+		instance := testutils.NewConstraint("K8sRequiredLabels", "must-have-owner",
+			testutils.WithMatchKinds([]string{""}, []string{"Namespace"}))
+		scheme := newScheme(t)
+		r, err := NewReconciler(fake.NewFakeClientWithScheme(scheme, instance), scheme, gvk, opa, watch.NewSwitch(), NewConstraintsCache())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	t.Run("Timeout", func(t *testing.T) {
+		*reconcileTimeout = time.Minute
+		opa := &ctxOpa{FakeOpa: testutils.NewFakeOpa()}
+		r := newReconciler(t, opa)
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		if opa.ctx == nil {
+			t.Fatal("constraint was not added to OPA")
+		}
+		if _, ok := opa.ctx.Deadline(); !ok {
+			t.Error("OPA was called without a deadline")
+		}
+		if opa.ctx.Err() == nil {
+			t.Error("the reconcile's context was not cancelled when it returned")
+		}
+	})
+
+	t.Run("Stopped", func(t *testing.T) {
+		*reconcileTimeout = 0
+		opa := &ctxOpa{FakeOpa: testutils.NewFakeOpa()}
+		r := newReconciler(t, opa)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		r.ctx = ctx
+		if _, err := r.Reconcile(req); err == nil {
+			t.Error("Reconcile() should fail once the controller is stopped")
+		}
+		if opa.HasConstraint(testutils.NewConstraint("K8sRequiredLabels", "must-have-owner")) {
+			t.Error("constraint was added to OPA after the controller stopped")
+		}
+	})
+}
+
+func TestReconcileConstraintUnknownKind(t *testing.T) {
+	defer resetViews(t)
+	gvk := testutils.ConstraintGVK("K8sRequiredLabels")
+	instance := testutils.NewConstraint("K8sRequiredLabels", "must-have-owner",
+		testutils.WithMatchKinds([]string{"apps"}, []string{"Deployment", "Deployments", "*"}))
+	scheme := newScheme(t)
+	c := fake.NewFakeClientWithScheme(scheme, instance)
+	r, err := NewReconciler(c, scheme, gvk, testutils.NewFakeOpa(), watch.NewSwitch(), NewConstraintsCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	apps := schema.GroupVersion{Group: "apps", Version: "v1"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{apps})
+	mapper.Add(apps.WithKind("Deployment"), meta.RESTScopeNamespace)
+	r.mapper = mapper
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "must-have-owner"}}
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if res.RequeueAfter != unknownKindRecheck {
+		t.Errorf("RequeueAfter = %v, wanted %v so the kinds are checked again", res.RequeueAfter, unknownKindRecheck)
+	}
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(gvk)
+	if err := c.Get(context.TODO(), req.NamespacedName, got); err != nil {
+		t.Fatal(err)
+	}
+	status, err := csutil.GetHAStatus(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Enforced {
+		t.Errorf("status = %v, unknown kinds should not prevent enforcement", spew.Sdump(status))
+	}
+	want := unknownKindMessage([]string{"Deployments.apps (did you mean Deployment?)"})
+	if len(status.Conditions) != 1 || status.Conditions[0].Type != csutil.UnknownKindCondition || status.Conditions[0].Message != want {
+		t.Errorf("status = %v, wanted a %s condition with message %q", spew.Sdump(status), csutil.UnknownKindCondition, want)
+	}
+}
+
+func TestReconcileConstraintTemplateChange(t *testing.T) {
+	defer resetViews(t)
+	gvk := testutils.ConstraintGVK("K8sRequiredLabels")
+	instance := testutils.NewConstraint("K8sRequiredLabels", "must-have-owner",
+		testutils.WithMatchKinds([]string{""}, []string{"Namespace"}))
+	templ := &templv1beta1.ConstraintTemplate{ObjectMeta: metav1.ObjectMeta{Name: "k8srequiredlabels", UID: "templ-1", Generation: 1}}
+	scheme := newScheme(t)
+	c := fake.NewFakeClientWithScheme(scheme, instance, templ)
+	fakeOpa := testutils.NewFakeOpa()
+	r, err := NewReconciler(c, scheme, gvk, fakeOpa, watch.NewSwitch(), NewConstraintsCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "must-have-owner"}}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	// failing adds show whether the constraint is loaded into OPA again
+	fakeOpa.AddConstraintErr = errors.New("schema failure")
+	if _, err := r.Reconcile(req); err != nil {
+		t.Errorf("Reconcile() error = %v, the unchanged constraint should not be added again", err)
+	}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: templ.Name}, templ); err != nil {
+		t.Fatal(err)
+	}
+	templ.SetGeneration(2)
+	if err := c.Update(context.TODO(), templ); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(req); err == nil {
+		t.Error("Reconcile() succeeded, wanted the constraint added again after its template changed")
+	}
+}
+
+func TestReconcileConstraintDeprecatedVersion(t *testing.T) {
+	defer resetViews(t)
+	gvk := testutils.ConstraintGVK("K8sRequiredLabels")
+	instance := testutils.NewConstraint("K8sRequiredLabels", "must-have-owner")
+	instance.SetManagedFields([]metav1.ManagedFieldsEntry{
+		{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationUpdate, APIVersion: "constraints.gatekeeper.sh/v1alpha1"},
+	})
+	scheme := newScheme(t)
+	c := fake.NewFakeClientWithScheme(scheme, instance)
+	r, err := NewReconciler(c, scheme, gvk, testutils.NewFakeOpa(), watch.NewSwitch(), NewConstraintsCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "must-have-owner"}}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(gvk)
+	if err := c.Get(context.TODO(), req.NamespacedName, got); err != nil {
+		t.Fatal(err)
+	}
+	status, err := csutil.GetHAStatus(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Enforced {
+		t.Errorf("status = %v, a deprecated version should not prevent enforcement", spew.Sdump(status))
+	}
+	if len(status.Conditions) != 1 || status.Conditions[0].Type != csutil.DeprecatedVersionCondition {
+		t.Errorf("status = %v, wanted a %s condition", spew.Sdump(status), csutil.DeprecatedVersionCondition)
+	}
+}
+
+func TestReconcileConstraintMissingSync(t *testing.T) {
+	defer resetViews(t)
+	scheme := newScheme(t)
+	gvk := testutils.ConstraintGVK("K8sUniqueIngressHost")
+	instance := testutils.NewConstraint("K8sUniqueIngressHost", "unique-hosts")
+	templ := &templv1beta1.ConstraintTemplate{ObjectMeta: metav1.ObjectMeta{Name: "k8suniqueingresshost"}}
+	templ.Spec.Targets = []templv1beta1.Target{{
+		Target: "admission.k8s.gatekeeper.sh",
+		Rego: `package k8suniqueingresshost
+
+violation[{"msg": "duplicate host"}] {
+  other := data.inventory.namespace[_]["extensions/v1beta1"]["Ingress"][_]
+  other.spec.rules[_].host == input.review.object.spec.rules[_].host
+}`,
+	}}
+	cfg := &configv1alpha1.Config{ObjectMeta: metav1.ObjectMeta{Name: config.CfgKey.Name, Namespace: config.CfgKey.Namespace}}
+	cfg.Spec.Sync.SyncOnly = []configv1alpha1.SyncOnlyEntry{{Version: "v1", Kind: "Namespace"}}
+	c := fake.NewFakeClientWithScheme(scheme, instance, templ, cfg)
+	constraintsCache := NewConstraintsCache()
+	r, err := NewReconciler(c, scheme, gvk, testutils.NewFakeOpa(), watch.NewSwitch(), constraintsCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "unique-hosts"}}
+
+	getStatus := func() *csutil.ByPodStatus {
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		got := &unstructured.Unstructured{}
+		got.SetGroupVersionKind(gvk)
+		if err := c.Get(context.TODO(), req.NamespacedName, got); err != nil {
+			t.Fatal(err)
+		}
+		status, err := csutil.GetHAStatus(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+
+	status := getStatus()
+	if !status.Enforced {
+		t.Errorf("status = %v, missing sync should not prevent enforcement", spew.Sdump(status))
+	}
+	if len(status.Conditions) != 1 || status.Conditions[0].Type != csutil.MissingSyncCondition {
+		t.Errorf("status = %v, wanted a %s condition", spew.Sdump(status), csutil.MissingSyncCondition)
+	}
+	if len(constraintsCache.missingSync) != 1 {
+		t.Errorf("missingSync = %v, wanted one entry", constraintsCache.missingSync)
+	}
+
+	cfg.Spec.Sync.SyncOnly = append(cfg.Spec.Sync.SyncOnly, configv1alpha1.SyncOnlyEntry{Group: "extensions", Version: "v1beta1", Kind: "Ingress"})
+	if err := c.Update(context.TODO(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	status = getStatus()
+	if len(status.Conditions) != 0 {
+		t.Errorf("status = %v, wanted no conditions once the kind is synced", spew.Sdump(status))
+	}
+	if len(constraintsCache.missingSync) != 0 {
+		t.Errorf("missingSync = %v, wanted no entries", constraintsCache.missingSync)
+	}
+}
+
+func TestReconcileConstraintDefaultEnforcementAction(t *testing.T) {
+	defer resetViews(t)
+	scheme := newScheme(t)
+	gvk := testutils.ConstraintGVK("K8sRequiredLabels")
+	unset := testutils.NewConstraint("K8sRequiredLabels", "unset")
+	explicit := testutils.NewConstraint("K8sRequiredLabels", "explicit", testutils.WithEnforcementAction("deny"))
+	cfg := &configv1alpha1.Config{ObjectMeta: metav1.ObjectMeta{Name: config.CfgKey.Name, Namespace: config.CfgKey.Namespace}}
+	cfg.Spec.DefaultEnforcementAction = "dryrun"
+	c := fake.NewFakeClientWithScheme(scheme, unset, explicit, cfg)
+	fakeOpa := testutils.NewFakeOpa()
+	r, err := NewReconciler(c, scheme, gvk, fakeOpa, watch.NewSwitch(), NewConstraintsCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := []struct {
+		Name     string
+		Expected string
+	}{
+		{Name: "unset", Expected: "dryrun"},
+		{Name: "explicit", Expected: "deny"},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: tt.Name}}
+			if _, err := r.Reconcile(req); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			got := &unstructured.Unstructured{}
+			got.SetGroupVersionKind(gvk)
+			if err := c.Get(context.TODO(), req.NamespacedName, got); err != nil {
+				t.Fatal(err)
+			}
+			status, err := csutil.GetHAStatus(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status.EnforcementAction != tt.Expected {
+				t.Errorf("status enforcementAction = %q, wanted %q", status.EnforcementAction, tt.Expected)
+			}
+			cached, err := fakeOpa.GetConstraint(context.TODO(), got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			action, _, _ := unstructured.NestedString(cached.Object, "spec", "enforcementAction")
+			if action != tt.Expected {
+				t.Errorf("OPA enforcementAction = %q, wanted %q", action, tt.Expected)
+			}
+			// the stored constraint is left as the user wrote it
+			stored, _, _ := unstructured.NestedString(got.Object, "spec", "enforcementAction")
+			if tt.Name == "unset" && stored != "" {
+				t.Errorf("stored enforcementAction = %q, wanted it unset", stored)
+			}
+		})
+	}
+}
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+// resetViews clears the metrics reported while reconciling so other tests see clean views
+func resetViews(t *testing.T) {
+	view.Unregister(view.Find(constraintsMetricName), view.Find(missingSyncMetricName), view.Find(errorsMetricName), view.Find(cacheSizeMetricName), view.Find(evictionsMetricName), view.Find(driftMetricName))
+	if err := register(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReconcileConstraintInactive(t *testing.T) {
+	defer resetViews(t)
+	gvk := testutils.ConstraintGVK("K8sRequiredLabels")
+	instance := testutils.NewConstraint("K8sRequiredLabels", "must-have-owner",
+		testutils.WithMatchKinds([]string{""}, []string{"Namespace"}))
+	if err := unstructured.SetNestedStringSlice(instance.Object, []string{">=1.16"}, "spec", "match", "clusterVersions"); err != nil {
+		t.Fatal(err)
+	}
+	scheme := newScheme(t)
+	c := fake.NewFakeClientWithScheme(scheme, instance)
+	fakeOpa := testutils.NewFakeOpa()
+	constraintsCache := NewConstraintsCache()
+	r, err := NewReconciler(c, scheme, gvk, fakeOpa, watch.NewSwitch(), constraintsCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "must-have-owner"}}
+	key := ConstraintKey("K8sRequiredLabels", "must-have-owner")
+
+	tc := []struct {
+		Name     string
+		Version  string
+		Enforced bool
+	}{
+		{Name: "Condition holds", Version: "v1.16.4", Enforced: true},
+		{Name: "Condition fails", Version: "v1.15.11-gke.3", Enforced: false},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			r.cluster = &csutil.ClusterInfo{Version: version.MustParseGeneric(tt.Version)}
+			if _, err := r.Reconcile(req); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			if got := fakeOpa.HasConstraint(instance); got != tt.Enforced {
+				t.Errorf("constraint in OPA = %v, wanted %v", got, tt.Enforced)
+			}
+			if got := constraintsCache.isInactive(key); got == tt.Enforced {
+				t.Errorf("cached as inactive = %v, wanted %v", got, !tt.Enforced)
+			}
+			got := &unstructured.Unstructured{}
+			got.SetGroupVersionKind(gvk)
+			if err := c.Get(context.TODO(), req.NamespacedName, got); err != nil {
+				t.Fatal(err)
+			}
+			status, err := csutil.GetHAStatus(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status.Enforced != tt.Enforced {
+				t.Errorf("status = %v, wanted enforced = %v", spew.Sdump(status), tt.Enforced)
+			}
+			inactive := len(status.Conditions) == 1 && status.Conditions[0].Type == csutil.InactiveCondition
+			if inactive == tt.Enforced {
+				t.Errorf("status = %v, wanted an %s condition: %v", spew.Sdump(status), csutil.InactiveCondition, !tt.Enforced)
+			}
+		})
+	}
+}
+
+func TestReconcileConstraintParameterDefaults(t *testing.T) {
+	defer resetViews(t)
+	scheme := newScheme(t)
+	gvk := testutils.ConstraintGVK("K8sRequiredLabels")
+	defaulted := testutils.NewConstraint("K8sRequiredLabels", "defaulted",
+		testutils.WithParameters(map[string]interface{}{"labels": []interface{}{"owner"}}))
+	explicit := testutils.NewConstraint("K8sRequiredLabels", "explicit",
+		testutils.WithParameters(map[string]interface{}{"labels": []interface{}{"owner"}, "message": "set an owner"}))
+	templ := &templv1beta1.ConstraintTemplate{ObjectMeta: metav1.ObjectMeta{Name: "k8srequiredlabels"}}
+	templ.Spec.CRD.Spec.Validation = &templv1beta1.Validation{
+		OpenAPIV3Schema: &apiextensionsv1beta1.JSONSchemaProps{
+			Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+				"labels":  {Type: "array", Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1beta1.JSONSchemaProps{Type: "string"}}},
+				"message": {Type: "string", Default: &apiextensionsv1beta1.JSON{Raw: []byte(`"missing required labels"`)}},
+			},
+		},
+	}
+	c := fake.NewFakeClientWithScheme(scheme, defaulted, explicit, templ)
+	fakeOpa := testutils.NewFakeOpa()
+	r, err := NewReconciler(c, scheme, gvk, fakeOpa, watch.NewSwitch(), NewConstraintsCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := []struct {
+		Name            string
+		ExpectedMessage string
+		ExpectStatus    bool
+	}{
+		{Name: "defaulted", ExpectedMessage: "missing required labels", ExpectStatus: true},
+		{Name: "explicit", ExpectedMessage: "set an owner", ExpectStatus: false},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			req := reconcile.Request{NamespacedName: types.NamespacedName{Name: tt.Name}}
+			if _, err := r.Reconcile(req); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			got := &unstructured.Unstructured{}
+			got.SetGroupVersionKind(gvk)
+			if err := c.Get(context.TODO(), req.NamespacedName, got); err != nil {
+				t.Fatal(err)
+			}
+			cached, err := fakeOpa.GetConstraint(context.TODO(), got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			message, _, _ := unstructured.NestedString(cached.Object, "spec", "parameters", "message")
+			if message != tt.ExpectedMessage {
+				t.Errorf("OPA message parameter = %q, wanted %q", message, tt.ExpectedMessage)
+			}
+			status, err := csutil.GetHAStatus(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.ExpectStatus != (status.EffectiveParameters != nil) {
+				t.Errorf("status effectiveParameters = %v, wanted them reported: %v", status.EffectiveParameters, tt.ExpectStatus)
+			}
+			if tt.ExpectStatus && status.EffectiveParameters["message"] != tt.ExpectedMessage {
+				t.Errorf("status effectiveParameters = %v, wanted message %q", status.EffectiveParameters, tt.ExpectedMessage)
+			}
+			// the stored constraint is left as the user wrote it
+			if stored, found, _ := unstructured.NestedString(got.Object, "spec", "parameters", "message"); tt.Name == "defaulted" && found {
+				t.Errorf("stored message parameter = %q, wanted it unset", stored)
+			}
+		})
+	}
+}
+
+func TestReconcileConstraintConflict(t *testing.T) {
+	defer resetViews(t)
+	scheme := newScheme(t)
+	gvk := testutils.ConstraintGVK("K8sLabels")
+	scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind("K8sLabelsList"), &unstructured.UnstructuredList{})
+	require := testutils.NewConstraint("K8sLabels", "require-owner")
+	if err := unstructured.SetNestedStringSlice(require.Object, []string{"owner"}, "spec", "parameters", "required"); err != nil {
+		t.Fatal(err)
+	}
+	forbid := testutils.NewConstraint("K8sLabels", "forbid-owner")
+	if err := unstructured.SetNestedStringSlice(forbid.Object, []string{"owner", "team"}, "spec", "parameters", "forbidden"); err != nil {
+		t.Fatal(err)
+	}
+	templ := &templv1beta1.ConstraintTemplate{ObjectMeta: metav1.ObjectMeta{
+		Name:        "k8slabels",
+		Annotations: map[string]string{csutil.ConflictingParametersAnnotation: "required:forbidden"},
+	}}
+	c := fake.NewFakeClientWithScheme(scheme, require, forbid, templ)
+	r, err := NewReconciler(c, scheme, gvk, testutils.NewFakeOpa(), watch.NewSwitch(), NewConstraintsCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "require-owner"}}
+
+	getStatus := func() *csutil.ByPodStatus {
+		if _, err := r.Reconcile(req); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		got := &unstructured.Unstructured{}
+		got.SetGroupVersionKind(gvk)
+		if err := c.Get(context.TODO(), req.NamespacedName, got); err != nil {
+			t.Fatal(err)
+		}
+		status, err := csutil.GetHAStatus(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+
+	status := getStatus()
+	want := "parameters contradict other constraints that may match the same objects: required and forbidden of forbid-owner both contain owner"
+	if !status.Enforced || len(status.Conditions) != 1 || status.Conditions[0].Type != csutil.ConflictCondition || status.Conditions[0].Message != want {
+		t.Errorf("status = %v, wanted an enforced constraint with a %s condition with message %q", spew.Sdump(status), csutil.ConflictCondition, want)
+	}
+
+	// constraints that cannot match the same namespace do not conflict
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: "forbid-owner"}, forbid); err != nil {
+		t.Fatal(err)
+	}
+	if err := unstructured.SetNestedStringSlice(forbid.Object, []string{"sandbox"}, "spec", "match", "namespaces"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Update(context.TODO(), forbid); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(context.TODO(), req.NamespacedName, require); err != nil {
+		t.Fatal(err)
+	}
+	if err := unstructured.SetNestedStringSlice(require.Object, []string{"production"}, "spec", "match", "namespaces"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Update(context.TODO(), require); err != nil {
+		t.Fatal(err)
+	}
+	status = getStatus()
+	if len(status.Conditions) != 0 {
+		t.Errorf("status = %v, wanted no conditions for constraints in different namespaces", spew.Sdump(status))
+	}
+}
+
+func TestReconcileConstraintRollout(t *testing.T) {
+	defer resetViews(t)
+	scheme := newScheme(t)
+	gvk := testutils.ConstraintGVK("K8sLabels")
+	scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind("K8sLabelsList"), &unstructured.UnstructuredList{})
+	created := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	instance := testutils.NewConstraint("K8sLabels", "require-owner")
+	instance.SetCreationTimestamp(metav1.NewTime(created))
+	if err := unstructured.SetNestedField(instance.Object, "1h", "spec", "rollout", "dryrunDuration"); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewFakeClientWithScheme(scheme, instance)
+	opa := testutils.NewFakeOpa()
+	r, err := NewReconciler(c, scheme, gvk, opa, watch.NewSwitch(), NewConstraintsCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := record.NewFakeRecorder(10)
+	r.recorder = recorder
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "require-owner"}}
+
+	reconcileAt := func(now time.Time) (reconcile.Result, *csutil.ByPodStatus, util.EnforcementAction) {
+		r.now = func() time.Time { return now }
+		result, err := r.Reconcile(req)
+		if err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		got := &unstructured.Unstructured{}
+		got.SetGroupVersionKind(gvk)
+		if err := c.Get(context.TODO(), req.NamespacedName, got); err != nil {
+			t.Fatal(err)
+		}
+		status, err := csutil.GetHAStatus(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := opa.GetConstraint(context.TODO(), got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		action, err := util.GetEnforcementAction(loaded.Object)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result, status, action
+	}
+
+	result, status, action := reconcileAt(created.Add(10 * time.Minute))
+	if action != util.Dryrun || status.EnforcementAction != string(util.Dryrun) {
+		t.Errorf("enforcement action during rollout = %s, status %s, wanted %s", action, status.EnforcementAction, util.Dryrun)
+	}
+	if len(status.Conditions) != 1 || status.Conditions[0].Type != csutil.RolloutCondition {
+		t.Errorf("conditions = %v, wanted %s", status.Conditions, csutil.RolloutCondition)
+	}
+	if result.RequeueAfter != 50*time.Minute {
+		t.Errorf("RequeueAfter = %v, wanted the end of the rollout in 50m", result.RequeueAfter)
+	}
+
+	result, status, action = reconcileAt(created.Add(time.Hour))
+	if action != util.Deny || status.EnforcementAction != string(util.Deny) {
+		t.Errorf("enforcement action after rollout = %s, status %s, wanted %s", action, status.EnforcementAction, util.Deny)
+	}
+	if len(status.Conditions) != 0 || result.RequeueAfter != 0 {
+		t.Errorf("conditions = %v, RequeueAfter = %v, wanted none", status.Conditions, result.RequeueAfter)
+	}
+
+	var reasons []string
+	for len(recorder.Events) > 0 {
+		e := <-recorder.Events
+		reasons = append(reasons, strings.Fields(e)[1])
+	}
+	if want := []string{"RolloutStarted", "RolloutComplete"}; !reflect.DeepEqual(reasons, want) {
+		t.Errorf("events = %v, wanted %v", reasons, want)
+	}
+}
@@ -0,0 +1,153 @@
+package constraint
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	configv1alpha1 "github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/controller/config"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	csutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// getConfig returns the cluster's Config, or an empty Config if none exists
+func (r *ReconcileConstraint) getConfig(ctx context.Context) (*configv1alpha1.Config, error) {
+	return readConfig(ctx, r)
+}
+
+func readConfig(ctx context.Context, c client.Reader) (*configv1alpha1.Config, error) {
+	cfg := &configv1alpha1.Config{}
+	if err := c.Get(ctx, config.CfgKey, cfg); err != nil {
+		if errors.IsNotFound(err) {
+			return &configv1alpha1.Config{}, nil
+		}
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// effectiveConstraint is a constraint as OPA enforces it, and what was applied to it
+type effectiveConstraint struct {
+	obj *unstructured.Unstructured
+	// templ is the constraint's template, or nil if there is none
+	templ *templv1beta1.ConstraintTemplate
+	// paramsDefaulted is whether templ's parameter defaults were applied
+	paramsDefaulted bool
+	// rolloutEnd is when the dry-run phase of the constraint's rollout ends, and rollingOut
+	// whether it lasts, holding the constraint in dryrun
+	rolloutEnd time.Time
+	rollingOut bool
+	// rolloutErr is an invalid rollout. The constraint is not enforced until it is fixed.
+	rolloutErr error
+	// downgraded is why the constraint's deny enforcement action is held back to dryrun
+	// after it exhausted the webhook's error budget, if it is
+	downgraded string
+}
+
+// buildEffective applies to instance the cluster's default enforcement action, its
+// template's parameter defaults, its rollout as of now, and its downgrade. The reconciler and the
+// consistency check both load what it returns, so OPA holds the same constraint whichever
+// added it.
+func buildEffective(ctx context.Context, c client.Reader, instance *unstructured.Unstructured, cfg *configv1alpha1.Config, now time.Time) (*effectiveConstraint, error) {
+	e := &effectiveConstraint{obj: withDefaultEnforcementAction(instance, cfg)}
+	var err error
+	if e.templ, err = getTemplate(ctx, c, instance.GetKind()); err != nil {
+		return nil, err
+	}
+	if e.paramsDefaulted, err = withParameterDefaults(e.templ, e.obj); err != nil {
+		return nil, err
+	}
+	e.rolloutEnd, e.rollingOut, e.rolloutErr = withRollout(instance, e.obj, now)
+	if e.downgraded, err = withDowngrade(instance, e.obj); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// withDefaultEnforcementAction returns a copy of the constraint with an enforcement
+// action applied if the constraint does not set one: the action the Config maps the
+// constraint's severity to, or else the Config's default. Unsupported defaults and
+// severities are ignored, leaving the framework's default of deny.
+func withDefaultEnforcementAction(instance *unstructured.Unstructured, cfg *configv1alpha1.Config) *unstructured.Unstructured {
+	obj := instance.DeepCopy()
+	current, _, err := unstructured.NestedString(obj.Object, "spec", "enforcementAction")
+	if err != nil || current != "" {
+		return obj
+	}
+	action := util.EnforcementAction(cfg.Spec.DefaultEnforcementAction)
+	if severity, err := util.GetSeverity(obj.Object); err == nil && severity != "" {
+		if err := util.ValidateSeverity(severity); err != nil {
+			log.Error(err, "ignoring constraint severity", "severity", severity)
+		} else {
+			action = util.SeverityAction(severity, cfg)
+		}
+	}
+	if action == "" {
+		return obj
+	}
+	if err := util.ValidateEnforcementAction(action); err != nil {
+		log.Error(err, "ignoring default enforcement action in config", "defaultEnforcementAction", action)
+		return obj
+	}
+	if err := unstructured.SetNestedField(obj.Object, string(action), "spec", "enforcementAction"); err != nil {
+		log.Error(err, "could not apply default enforcement action")
+		return instance.DeepCopy()
+	}
+	return obj
+}
+
+// withRollout holds the deny enforcement action of effective back to dryrun while the
+// dry-run phase of instance's rollout lasts, so a new constraint reports what it would deny
+// before it denies it. It returns when that phase ends, and whether it lasts at now.
+func withRollout(instance, effective *unstructured.Unstructured, now time.Time) (time.Time, bool, error) {
+	end, err := csutil.RolloutEnd(instance)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	action, err := util.GetEnforcementAction(effective.Object)
+	if err != nil || action != util.Deny || !now.Before(end) {
+		return end, false, err
+	}
+	return end, true, unstructured.SetNestedField(effective.Object, string(util.Dryrun), "spec", "enforcementAction")
+}
+
+// withDowngrade holds the deny enforcement action of effective back to dryrun while
+// instance carries csutil.DowngradedAnnotation. It returns why instance was downgraded, or ""
+// if the downgrade does not change what effective enforces.
+func withDowngrade(instance, effective *unstructured.Unstructured) (string, error) {
+	reason, ok := csutil.Downgraded(instance)
+	if !ok {
+		return "", nil
+	}
+	action, err := util.GetEnforcementAction(effective.Object)
+	if err != nil || action != util.Deny {
+		return "", err
+	}
+	return reason, unstructured.SetNestedField(effective.Object, string(util.Dryrun), "spec", "enforcementAction")
+}
+
+// getTemplate returns the template of constraints of kind, or nil if there is none
+func getTemplate(ctx context.Context, c client.Reader, kind string) (*templv1beta1.ConstraintTemplate, error) {
+	templ := &templv1beta1.ConstraintTemplate{}
+	if err := c.Get(ctx, client.ObjectKey{Name: strings.ToLower(kind)}, templ); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return templ, nil
+}
+
+// withParameterDefaults applies the parameter defaults declared in templ, the constraint's
+// template, to obj, and reports whether any were applied. A nil templ leaves obj as is.
+func withParameterDefaults(templ *templv1beta1.ConstraintTemplate, obj *unstructured.Unstructured) (bool, error) {
+	if templ == nil || templ.Spec.CRD.Spec.Validation == nil {
+		return false, nil
+	}
+	return csutil.ApplyParameterDefaults(obj, templ.Spec.CRD.Spec.Validation.OpenAPIV3Schema)
+}
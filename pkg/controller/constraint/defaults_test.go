@@ -0,0 +1,80 @@
+package constraint
+
+import (
+	"testing"
+
+	configv1alpha1 "github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/testutils"
+	csutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestWithDefaultEnforcementActionSeverity(t *testing.T) {
+	cfg := &configv1alpha1.Config{}
+	cfg.Spec.DefaultEnforcementAction = "dryrun"
+	cfg.Spec.SeverityActions.High = "dryrun"
+
+	tc := []struct {
+		Name              string
+		Severity          string
+		EnforcementAction string
+		Expected          string
+	}{
+		{Name: "no severity", Expected: "dryrun"},
+		{Name: "critical", Severity: "critical", Expected: "deny"},
+		{Name: "high mapped by config", Severity: "high", Expected: "dryrun"},
+		{Name: "medium", Severity: "medium", Expected: "dryrun"},
+		{Name: "low", Severity: "low", Expected: "audit"},
+		{Name: "unsupported severity", Severity: "urgent", Expected: "dryrun"},
+		{Name: "explicit action", Severity: "low", EnforcementAction: "deny", Expected: "deny"},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			c := testutils.NewConstraint("K8sRequiredLabels", "c", testutils.WithEnforcementAction(tt.EnforcementAction))
+			if tt.Severity != "" {
+				if err := unstructured.SetNestedField(c.Object, tt.Severity, "spec", "severity"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got := withDefaultEnforcementAction(c, cfg)
+			action, _, _ := unstructured.NestedString(got.Object, "spec", "enforcementAction")
+			if action != tt.Expected {
+				t.Errorf("enforcementAction = %q, wanted %q", action, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestWithDowngrade(t *testing.T) {
+	tc := []struct {
+		Name              string
+		Annotated         bool
+		EnforcementAction string
+		Expected          string
+		Downgraded        bool
+	}{
+		{Name: "not downgraded", EnforcementAction: "deny", Expected: "deny"},
+		{Name: "downgraded deny", Annotated: true, EnforcementAction: "deny", Expected: "dryrun", Downgraded: true},
+		{Name: "downgraded dryrun", Annotated: true, EnforcementAction: "dryrun", Expected: "dryrun"},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			c := testutils.NewConstraint("K8sRequiredLabels", "c", testutils.WithEnforcementAction(tt.EnforcementAction))
+			if tt.Annotated {
+				c.SetAnnotations(map[string]string{csutil.DowngradedAnnotation: "too many errors"})
+			}
+			effective := c.DeepCopy()
+			reason, err := withDowngrade(c, effective)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (reason != "") != tt.Downgraded {
+				t.Errorf("downgrade reason = %q, wanted downgraded %v", reason, tt.Downgraded)
+			}
+			action, _, _ := unstructured.NestedString(effective.Object, "spec", "enforcementAction")
+			if action != tt.Expected {
+				t.Errorf("enforcementAction = %q, wanted %q", action, tt.Expected)
+			}
+		})
+	}
+}
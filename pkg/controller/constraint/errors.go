@@ -0,0 +1,35 @@
+package constraint
+
+import (
+	"strings"
+
+	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	csutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
+	"github.com/open-policy-agent/opa/ast"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// errorCode classifies an error returned while adding a constraint to OPA, so that user
+// policy errors can be told apart from controller bugs
+func errorCode(err error) string {
+	switch e := err.(type) {
+	case opa.ErrorMap:
+		// errors are keyed by target; constraints currently have a single target
+		for _, targetErr := range e {
+			return errorCode(targetErr)
+		}
+	case *opa.UnrecognizedConstraintError, *opa.MissingTemplateError:
+		return csutil.UnrecognizedKindErrorCode
+	case utilerrors.Aggregate:
+		// field validation against the template's schema
+		return csutil.SchemaErrorCode
+	case ast.Errors:
+		for _, astErr := range e {
+			if strings.Contains(astErr.Message, "conflict") {
+				return csutil.ConflictErrorCode
+			}
+		}
+		return csutil.CompileErrorCode
+	}
+	return csutil.InternalErrorCode
+}
@@ -0,0 +1,52 @@
+package constraint
+
+import (
+	"errors"
+	"testing"
+
+	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	csutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
+	"github.com/open-policy-agent/opa/ast"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+func TestErrorCode(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Err      error
+		Expected string
+	}{
+		{
+			Name:     "Unrecognized kind",
+			Err:      opa.NewUnrecognizedConstraintError("K8sRequiredLabels"),
+			Expected: csutil.UnrecognizedKindErrorCode,
+		},
+		{
+			Name:     "Schema",
+			Err:      utilerrors.NewAggregate([]error{errors.New("spec.parameters.labels: Invalid value")}),
+			Expected: csutil.SchemaErrorCode,
+		},
+		{
+			Name:     "Compile",
+			Err:      ast.Errors{ast.NewError(ast.CompileErr, nil, "rego_compile_error")},
+			Expected: csutil.CompileErrorCode,
+		},
+		{
+			Name:     "Conflict in target",
+			Err:      opa.ErrorMap{"admission.k8s.gatekeeper.sh": ast.Errors{ast.NewError(ast.CompileErr, nil, "conflicting rule for data path found")}},
+			Expected: csutil.ConflictErrorCode,
+		},
+		{
+			Name:     "Internal",
+			Err:      errors.New("storage failure"),
+			Expected: csutil.InternalErrorCode,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			if code := errorCode(tt.Err); code != tt.Expected {
+				t.Errorf("errorCode() = %s, wanted %s", code, tt.Expected)
+			}
+		})
+	}
+}
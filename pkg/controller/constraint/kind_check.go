@@ -0,0 +1,81 @@
+package constraint
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// unknownKinds returns the entries of spec.match.kinds that API discovery does not know,
+// usually typos such as Deployments for Deployment. The constraint never matches them.
+// Wildcards are skipped.
+func (r *ReconcileConstraint) unknownKinds(instance *unstructured.Unstructured) []string {
+	if r.mapper == nil {
+		return nil
+	}
+	kinds, _, err := unstructured.NestedSlice(instance.Object, "spec", "match", "kinds")
+	if err != nil {
+		return nil
+	}
+	var unknown []string
+	for _, k := range kinds {
+		entry, ok := k.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		groups, _, _ := unstructured.NestedStringSlice(entry, "apiGroups")
+		names, _, _ := unstructured.NestedStringSlice(entry, "kinds")
+		for _, group := range groups {
+			if group == "*" {
+				continue
+			}
+			for _, kind := range names {
+				if kind == "*" {
+					continue
+				}
+				gk := schema.GroupKind{Group: group, Kind: kind}
+				found, err := r.kindExists(gk)
+				if err != nil {
+					r.log.Error(err, "could not check that a matched kind exists", "kind", gk.String())
+					continue
+				}
+				if !found {
+					unknown = append(unknown, describeUnknownKind(gk, r.kindExists))
+				}
+			}
+		}
+	}
+	return unknown
+}
+
+func (r *ReconcileConstraint) kindExists(gk schema.GroupKind) (bool, error) {
+	_, err := r.mapper.RESTMapping(gk)
+	if err == nil {
+		return true, nil
+	}
+	if meta.IsNoMatchError(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// describeUnknownKind names gk, suggesting the singular kind if gk looks like a plural
+func describeUnknownKind(gk schema.GroupKind, exists func(schema.GroupKind) (bool, error)) string {
+	name := gk.Kind
+	if gk.Group != "" {
+		name = gk.String()
+	}
+	if singular := strings.TrimSuffix(gk.Kind, "s"); singular != gk.Kind {
+		if ok, _ := exists(schema.GroupKind{Group: gk.Group, Kind: singular}); ok {
+			return fmt.Sprintf("%s (did you mean %s?)", name, singular)
+		}
+	}
+	return name
+}
+
+func unknownKindMessage(unknown []string) string {
+	return fmt.Sprintf("match.kinds lists kinds not found in the cluster: %s", strings.Join(unknown, ", "))
+}
@@ -0,0 +1,41 @@
+package constraint
+
+import (
+	"context"
+	"time"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/readiness"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// startupListRetry is how often listing the existing constraints is retried
+const startupListRetry = 5 * time.Second
+
+// AddStartupTracking has tracker wait for the constraints that exist when mgr starts to be
+// ingested before data sync begins
+func AddStartupTracking(mgr manager.Manager, tracker *readiness.Tracker) error {
+	reader := mgr.GetAPIReader()
+	return mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		return wait.PollImmediateUntil(startupListRetry, func() (bool, error) {
+			return expectLiveConstraints(context.Background(), reader, tracker), nil
+		}, stop)
+	}))
+}
+
+// expectLiveConstraints records the live constraints as the tracker's expectations. It
+// returns false if they could not be listed.
+func expectLiveConstraints(ctx context.Context, reader client.Reader, tracker *readiness.Tracker) bool {
+	live, err := liveConstraints(ctx, reader)
+	if err != nil {
+		log.Error(err, "could not list the existing constraints, retrying")
+		return false
+	}
+	keys := make([]string, 0, len(live))
+	for key := range live {
+		keys = append(keys, key)
+	}
+	tracker.ExpectConstraints(keys)
+	return true
+}
@@ -11,13 +11,26 @@ import (
 
 const (
 	constraintsMetricName = "constraints"
+	missingSyncMetricName = "constraints_missing_sync"
+	errorsMetricName      = "constraint_errors"
+	cacheSizeMetricName   = "constraints_cache_entries"
+	evictionsMetricName   = "constraints_cache_evictions"
+	driftMetricName       = "constraints_drift"
 )
 
 var (
 	constraintsM = stats.Int64(constraintsMetricName, "Current number of known constraints", stats.UnitDimensionless)
+	errorsM      = stats.Int64(errorsMetricName, "Number of times a constraint could not be added to OPA", stats.UnitDimensionless)
+	missingSyncM = stats.Int64(missingSyncMetricName, "Current number of constraints whose templates read data that is not synced", stats.UnitDimensionless)
+	cacheSizeM   = stats.Int64(cacheSizeMetricName, "Current number of entries in the constraints cache", stats.UnitDimensionless)
+	evictionsM   = stats.Int64(evictionsMetricName, "Number of constraints cache entries removed because their constraint no longer exists", stats.UnitDimensionless)
+	driftM       = stats.Int64(driftMetricName, "Number of constraints the consistency check found missing from, or left over in, OPA or the constraints cache", stats.UnitDimensionless)
 
 	enforcementActionKey = tag.MustNewKey("enforcement_action")
 	statusKey            = tag.MustNewKey("status")
+	errorCodeKey         = tag.MustNewKey("error_code")
+	storeKey             = tag.MustNewKey("store")
+	driftKey             = tag.MustNewKey("drift")
 )
 
 func init() {
@@ -34,6 +47,33 @@ func register() error {
 			Aggregation: view.LastValue(),
 			TagKeys:     []tag.Key{enforcementActionKey, statusKey},
 		},
+		{
+			Name:        missingSyncMetricName,
+			Measure:     missingSyncM,
+			Aggregation: view.LastValue(),
+		},
+		{
+			Name:        errorsMetricName,
+			Measure:     errorsM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{errorCodeKey},
+		},
+		{
+			Name:        cacheSizeMetricName,
+			Measure:     cacheSizeM,
+			Aggregation: view.LastValue(),
+		},
+		{
+			Name:        evictionsMetricName,
+			Measure:     evictionsM,
+			Aggregation: view.Sum(),
+		},
+		{
+			Name:        driftMetricName,
+			Measure:     driftM,
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{storeKey, driftKey},
+		},
 	}
 	return view.Register(views...)
 }
@@ -50,9 +90,42 @@ func (r *reporter) reportConstraints(t tags, v int64) error {
 	return r.report(ctx, constraintsM.M(v))
 }
 
+func (r *reporter) reportMissingSync(v int64) error {
+	return r.report(r.ctx, missingSyncM.M(v))
+}
+
+func (r *reporter) reportCacheSize(v int64) error {
+	return r.report(r.ctx, cacheSizeM.M(v))
+}
+
+func (r *reporter) reportEvictions(v int64) error {
+	return r.report(r.ctx, evictionsM.M(v))
+}
+
+func (r *reporter) reportDrift(store, drift string, v int64) error {
+	ctx, err := tag.New(r.ctx, tag.Insert(storeKey, store), tag.Insert(driftKey, drift))
+	if err != nil {
+		return err
+	}
+	return r.report(ctx, driftM.M(v))
+}
+
+func (r *reporter) reportConstraintError(code string) error {
+	ctx, err := tag.New(r.ctx, tag.Insert(errorCodeKey, code))
+	if err != nil {
+		return err
+	}
+	return r.report(ctx, errorsM.M(1))
+}
+
 // StatsReporter reports audit metrics
 type StatsReporter interface {
 	reportConstraints(t tags, v int64) error
+	reportMissingSync(v int64) error
+	reportConstraintError(code string) error
+	reportCacheSize(v int64) error
+	reportEvictions(v int64) error
+	reportDrift(store, drift string, v int64) error
 }
 
 // newStatsReporter creaters a reporter for audit metrics
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	csutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
 	"go.opencensus.io/stats/view"
 )
 
@@ -37,6 +38,52 @@ func TestReportConstraints(t *testing.T) {
 	}
 }
 
+func TestReportMissingSync(t *testing.T) {
+	const expectedValue int64 = 3
+
+	r, err := newStatsReporter()
+	if err != nil {
+		t.Errorf("newStatsReporter() error %v", err)
+	}
+	if err := r.reportMissingSync(expectedValue); err != nil {
+		t.Errorf("reportMissingSync error %v", err)
+	}
+	row := checkData(t, missingSyncMetricName, 1)
+	value, ok := row.Data.(*view.LastValueData)
+	if !ok {
+		t.Error("reportMissingSync should have aggregation LastValue()")
+	}
+	if int64(value.Value) != expectedValue {
+		t.Errorf("Metric: %v - Expected %v, got %v", missingSyncMetricName, expectedValue, value.Value)
+	}
+}
+
+func TestReportConstraintError(t *testing.T) {
+	defer resetViews(t)
+	r, err := newStatsReporter()
+	if err != nil {
+		t.Errorf("newStatsReporter() error %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := r.reportConstraintError(csutil.SchemaErrorCode); err != nil {
+			t.Errorf("reportConstraintError error %v", err)
+		}
+	}
+	row := checkData(t, errorsMetricName, 1)
+	value, ok := row.Data.(*view.CountData)
+	if !ok {
+		t.Error("reportConstraintError should have aggregation Count()")
+	}
+	for _, tag := range row.Tags {
+		if tag.Value != csutil.SchemaErrorCode {
+			t.Errorf("reportConstraintError tags does not match for %v", tag.Key.Name())
+		}
+	}
+	if value.Value != 2 {
+		t.Errorf("Metric: %v - Expected %v, got %v", errorsMetricName, 2, value.Value)
+	}
+}
+
 func checkData(t *testing.T, name string, expectedRowLength int) *view.Row {
 	row, err := view.RetrieveData(name)
 	if err != nil {
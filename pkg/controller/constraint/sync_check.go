@@ -0,0 +1,85 @@
+package constraint
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	configv1alpha1 "github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/util/regoutil"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// missingSyncKinds returns the kinds the constraint's template reads from data.inventory
+// that are not replicated by the sync config. Without them the template silently
+// evaluates against empty data.
+func (r *ReconcileConstraint) missingSyncKinds(ctx context.Context, cfg *configv1alpha1.Config) ([]regoutil.InventoryKind, error) {
+	templ := &templv1beta1.ConstraintTemplate{}
+	if err := r.Get(ctx, types.NamespacedName{Name: strings.ToLower(r.gvk.Kind)}, templ); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var modules []string
+	for _, target := range templ.Spec.Targets {
+		modules = append(modules, target.Rego)
+		modules = append(modules, regoutil.WithLibs(target.Rego, target.Libs)...)
+	}
+	required, err := regoutil.InventoryKinds(modules...)
+	if err != nil {
+		// unparseable Rego is reported on the template by the template controller
+		return nil, nil
+	}
+	if len(required) == 0 {
+		return nil, nil
+	}
+
+	var missing []regoutil.InventoryKind
+	for _, k := range required {
+		synced := false
+		for _, entry := range cfg.Spec.Sync.SyncOnly {
+			if k.Matches(schema.GroupVersionKind{Group: entry.Group, Version: entry.Version, Kind: entry.Kind}) {
+				synced = true
+				break
+			}
+		}
+		if !synced {
+			missing = append(missing, k)
+		}
+	}
+	return missing, nil
+}
+
+func missingSyncMessage(missing []regoutil.InventoryKind) string {
+	var kinds []string
+	for _, k := range missing {
+		kinds = append(kinds, k.String())
+	}
+	return fmt.Sprintf("template reads data.inventory for kinds that are not synced by the Config: %s", strings.Join(kinds, ", "))
+}
+
+// constraintsOfKind maps any event to a reconcile request for every constraint of the
+// given kind, so that sync warnings are refreshed when the Config or template changes.
+func constraintsOfKind(c client.Reader, gvk schema.GroupVersionKind) handler.ToRequestsFunc {
+	return func(handler.MapObject) []reconcile.Request {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := c.List(context.TODO(), list); err != nil {
+			log.Error(err, "could not list constraints", "kind", gvk.Kind)
+			return nil
+		}
+		var requests []reconcile.Request
+		for _, item := range list.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: item.GetName()}})
+		}
+		return requests
+	}
+}
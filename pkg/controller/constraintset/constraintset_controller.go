@@ -0,0 +1,253 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package constraintset
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/bundle"
+	"github.com/open-policy-agent/gatekeeper/pkg/controller/constraint"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// SetLabel is set on the constraints created by a ConstraintSet to the set's name
+const SetLabel = "gatekeeper.sh/constraint-set"
+
+// retryInterval is how often a set whose constraints could not all be created is
+// expanded again, for example to pick up a template installed after the set
+const retryInterval = time.Minute
+
+var log = logf.Log.WithName("controller").WithValues(logging.Process, "constraintset_controller")
+
+// AddToManager creates a controller that expands each ConstraintSet into its constraints.
+// It only runs in the controller role, so each set is expanded by one pod.
+func AddToManager(mgr manager.Manager) error {
+	r := &ReconcileConstraintSet{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+	c, err := controller.New("constraintset-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	return c.Watch(&source.Kind{Type: &v1alpha1.ConstraintSet{}}, &handler.EnqueueRequestForObject{})
+}
+
+var _ reconcile.Reconciler = &ReconcileConstraintSet{}
+
+// ReconcileConstraintSet creates, updates and deletes the constraints of ConstraintSets.
+// The constraints are owned by their set, so they are garbage collected with it.
+type ReconcileConstraintSet struct {
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=config.gatekeeper.sh,resources=constraintsets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=config.gatekeeper.sh,resources=constraintsets/status,verbs=get;update;patch
+
+func (r *ReconcileConstraintSet) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx := context.TODO()
+	set := &v1alpha1.ConstraintSet{}
+	if err := r.client.Get(ctx, request.NamespacedName, set); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if !set.GetDeletionTimestamp().IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	status := v1alpha1.ConstraintSetStatus{ObservedGeneration: set.GetGeneration()}
+	managed := make(map[string]bool)
+	for _, member := range set.Spec.Constraints {
+		key := constraint.ConstraintKey(member.Kind, memberName(set, member))
+		if err := r.apply(ctx, set, member); err != nil {
+			status.Errors = append(status.Errors, v1alpha1.ConstraintSetError{
+				Kind:    member.Kind,
+				Name:    memberName(set, member),
+				Message: err.Error(),
+			})
+			// a constraint the set created earlier is kept until it can be updated
+			for _, prev := range set.Status.Constraints {
+				if prev == key {
+					managed[key] = true
+				}
+			}
+			continue
+		}
+		managed[key] = true
+	}
+	for _, prev := range set.Status.Constraints {
+		if managed[prev] {
+			continue
+		}
+		if err := r.remove(ctx, set, prev); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	for key := range managed {
+		status.Constraints = append(status.Constraints, key)
+	}
+	sort.Strings(status.Constraints)
+
+	result := reconcile.Result{}
+	if len(status.Errors) > 0 {
+		result.RequeueAfter = retryInterval
+	}
+	if reflect.DeepEqual(status, set.Status) {
+		return result, nil
+	}
+	log.Info("expanded constraint set", "constraint_set", set.GetName(),
+		"constraints", len(status.Constraints), "errors", len(status.Errors))
+	set.Status = status
+	return result, r.client.Status().Update(ctx, set)
+}
+
+// apply creates or updates the constraint of member. Constraints that exist but were not
+// created by the set are left alone.
+func (r *ReconcileConstraintSet) apply(ctx context.Context, set *v1alpha1.ConstraintSet, member v1alpha1.ConstraintSetMember) error {
+	desired, err := newConstraint(set, member)
+	if err != nil {
+		return err
+	}
+	if err := controllerutil.SetControllerReference(set, desired, r.scheme); err != nil {
+		return err
+	}
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(desired.GroupVersionKind())
+	err = r.client.Get(ctx, types.NamespacedName{Name: desired.GetName()}, current)
+	switch {
+	case meta.IsNoMatchError(err):
+		return fmt.Errorf("no ConstraintTemplate defines kind %s", member.Kind)
+	case errors.IsNotFound(err):
+		log.Info("creating constraint", "constraint_set", set.GetName(),
+			logging.ConstraintKind, member.Kind, logging.ConstraintName, desired.GetName())
+		return r.client.Create(ctx, desired)
+	case err != nil:
+		return err
+	}
+	if current.GetLabels()[SetLabel] != set.GetName() {
+		return fmt.Errorf("%s %s exists and is not managed by this set", member.Kind, desired.GetName())
+	}
+	if reflect.DeepEqual(current.Object["spec"], desired.Object["spec"]) &&
+		reflect.DeepEqual(current.GetOwnerReferences(), desired.GetOwnerReferences()) {
+		return nil
+	}
+	current.Object["spec"] = desired.Object["spec"]
+	current.SetOwnerReferences(desired.GetOwnerReferences())
+	log.Info("updating constraint", "constraint_set", set.GetName(),
+		logging.ConstraintKind, member.Kind, logging.ConstraintName, desired.GetName())
+	return r.client.Update(ctx, current)
+}
+
+// remove deletes a constraint that was dropped from the set, unless it has since been
+// taken over by something else
+func (r *ReconcileConstraintSet) remove(ctx context.Context, set *v1alpha1.ConstraintSet, key string) error {
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(bundle.ConstraintGVK(parts[0]))
+	if err := r.client.Get(ctx, types.NamespacedName{Name: parts[1]}, obj); err != nil {
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+	if obj.GetLabels()[SetLabel] != set.GetName() {
+		return nil
+	}
+	log.Info("deleting constraint removed from its set", "constraint_set", set.GetName(),
+		logging.ConstraintKind, parts[0], logging.ConstraintName, parts[1])
+	if err := r.client.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// newConstraint returns the constraint of member, with the set's match and enforcement
+// action
+func newConstraint(set *v1alpha1.ConstraintSet, member v1alpha1.ConstraintSetMember) (*unstructured.Unstructured, error) {
+	if member.Kind == "" {
+		return nil, fmt.Errorf("kind must be set")
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetGroupVersionKind(bundle.ConstraintGVK(member.Kind))
+	obj.SetName(memberName(set, member))
+	obj.SetLabels(map[string]string{SetLabel: set.GetName()})
+	spec := map[string]interface{}{}
+	if set.Spec.Match != nil {
+		match, err := toMap(set.Spec.Match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid match: %v", err)
+		}
+		if match != nil {
+			spec["match"] = match
+		}
+	}
+	if set.Spec.EnforcementAction != "" {
+		spec["enforcementAction"] = set.Spec.EnforcementAction
+	}
+	if member.Parameters != nil {
+		params, err := toMap(member.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameters: %v", err)
+		}
+		if params != nil {
+			spec["parameters"] = params
+		}
+	}
+	obj.Object["spec"] = spec
+	return obj, nil
+}
+
+// toMap decodes raw as unstructured content
+func toMap(raw *runtime.RawExtension) (map[string]interface{}, error) {
+	if len(raw.Raw) == 0 {
+		return nil, nil
+	}
+	var content map[string]interface{}
+	if err := json.Unmarshal(raw.Raw, &content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+func memberName(set *v1alpha1.ConstraintSet, member v1alpha1.ConstraintSetMember) string {
+	if member.Name != "" {
+		return member.Name
+	}
+	return set.GetName() + "-" + strings.ToLower(member.Kind)
+}
@@ -0,0 +1,111 @@
+package constraintset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-policy-agent/gatekeeper/api"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/bundle"
+	"github.com/open-policy-agent/gatekeeper/pkg/testutils"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileConstraintSet(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	set := &v1alpha1.ConstraintSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod", UID: "prod-uid"},
+		Spec: v1alpha1.ConstraintSetSpec{
+			Match:             &runtime.RawExtension{Raw: []byte(`{"namespaces": ["prod"]}`)},
+			EnforcementAction: "dryrun",
+			Constraints: []v1alpha1.ConstraintSetMember{
+				{Kind: "K8sRequiredLabels", Parameters: &runtime.RawExtension{Raw: []byte(`{"labels": ["owner"]}`)}},
+				{Kind: "K8sAllowedRepos", Name: "prod-repos", Parameters: &runtime.RawExtension{Raw: []byte(`{"repos": ["registry.example.com"]}`)}},
+				{Kind: "K8sContainerLimits"},
+			},
+		},
+	}
+	// a constraint of the same name that the set did not create
+	unmanaged := testutils.NewConstraint("K8sContainerLimits", "prod-k8scontainerlimits")
+	c := fake.NewFakeClientWithScheme(scheme, set, unmanaged)
+	r := &ReconcileConstraintSet{client: c, scheme: scheme}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "prod"}}
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RequeueAfter != retryInterval {
+		t.Errorf("RequeueAfter = %v, wanted a retry for the member that was not applied", res.RequeueAfter)
+	}
+
+	labels := getConstraint(t, c, "K8sRequiredLabels", "prod-k8srequiredlabels")
+	if action, _, _ := unstructured.NestedString(labels.Object, "spec", "enforcementAction"); action != "dryrun" {
+		t.Errorf("enforcementAction = %q, wanted the set's", action)
+	}
+	if ns, _, _ := unstructured.NestedStringSlice(labels.Object, "spec", "match", "namespaces"); len(ns) != 1 || ns[0] != "prod" {
+		t.Errorf("match.namespaces = %v, wanted the set's", ns)
+	}
+	if params, _, _ := unstructured.NestedStringSlice(labels.Object, "spec", "parameters", "labels"); len(params) != 1 || params[0] != "owner" {
+		t.Errorf("parameters.labels = %v, wanted the member's", params)
+	}
+	if refs := labels.GetOwnerReferences(); len(refs) != 1 || refs[0].UID != set.UID {
+		t.Errorf("ownerReferences = %v, wanted the set", refs)
+	}
+	getConstraint(t, c, "K8sAllowedRepos", "prod-repos")
+	if got := getConstraint(t, c, "K8sContainerLimits", "prod-k8scontainerlimits"); len(got.GetOwnerReferences()) != 0 {
+		t.Error("the set took over a constraint it did not create")
+	}
+
+	got := &v1alpha1.ConstraintSet{}
+	if err := c.Get(context.TODO(), req.NamespacedName, got); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"K8sAllowedRepos/prod-repos", "K8sRequiredLabels/prod-k8srequiredlabels"}
+	if len(got.Status.Constraints) != 2 || got.Status.Constraints[0] != want[0] || got.Status.Constraints[1] != want[1] {
+		t.Errorf("status.constraints = %v, wanted %v", got.Status.Constraints, want)
+	}
+	if len(got.Status.Errors) != 1 || got.Status.Errors[0].Kind != "K8sContainerLimits" {
+		t.Errorf("status.errors = %v, wanted one for K8sContainerLimits", got.Status.Errors)
+	}
+
+	// changing the set updates its constraints and deletes dropped ones
+	got.Spec.EnforcementAction = "deny"
+	got.Spec.Constraints = got.Spec.Constraints[:1]
+	if err := c.Update(context.TODO(), got); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatal(err)
+	}
+	labels = getConstraint(t, c, "K8sRequiredLabels", "prod-k8srequiredlabels")
+	if action, _, _ := unstructured.NestedString(labels.Object, "spec", "enforcementAction"); action != "deny" {
+		t.Errorf("enforcementAction = %q, wanted the updated set's", action)
+	}
+	repos := &unstructured.Unstructured{}
+	repos.SetGroupVersionKind(bundle.ConstraintGVK("K8sAllowedRepos"))
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: "prod-repos"}, repos); !errors.IsNotFound(err) {
+		t.Errorf("Get() error = %v, wanted the dropped constraint deleted", err)
+	}
+	getConstraint(t, c, "K8sContainerLimits", "prod-k8scontainerlimits")
+}
+
+func getConstraint(t *testing.T, c client.Client, kind, name string) *unstructured.Unstructured {
+	t.Helper()
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(bundle.ConstraintGVK(kind))
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: name}, obj); err != nil {
+		t.Fatalf("could not get %s %s: %v", kind, name, err)
+	}
+	return obj
+}
@@ -25,10 +25,14 @@ import (
 	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
 	"github.com/open-policy-agent/gatekeeper/pkg/controller/constraint"
+	"github.com/open-policy-agent/gatekeeper/pkg/driver"
+	"github.com/open-policy-agent/gatekeeper/pkg/lint"
 	"github.com/open-policy-agent/gatekeeper/pkg/logging"
 	"github.com/open-policy-agent/gatekeeper/pkg/metrics"
+	"github.com/open-policy-agent/gatekeeper/pkg/readiness"
 	"github.com/open-policy-agent/gatekeeper/pkg/util"
 	constraintutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
+	"github.com/open-policy-agent/gatekeeper/pkg/util/regoutil"
 	"github.com/open-policy-agent/gatekeeper/pkg/watch"
 	"github.com/open-policy-agent/opa/ast"
 	errorpkg "github.com/pkg/errors"
@@ -59,6 +63,7 @@ var log = logf.Log.WithName("controller").WithValues("kind", "ConstraintTemplate
 
 type Adder struct {
 	Opa          *opa.Client
+	Queries      *driver.Queries
 	WatchManager *watch.Manager
 }
 
@@ -69,23 +74,33 @@ func (a *Adder) Add(mgr manager.Manager) error {
 	if err != nil {
 		return err
 	}
-	return add(mgr, r)
+	if err := add(mgr, r); err != nil {
+		return err
+	}
+	if err := constraint.AddStartupTracking(mgr, readiness.Startup); err != nil {
+		return err
+	}
+	if a.Queries == nil {
+		return nil
+	}
+	return constraint.AddConsistencyCheck(mgr, a.Opa, a.Queries, constraint.Cache)
 }
 
 func (a *Adder) InjectOpa(o *opa.Client) {
 	a.Opa = o
 }
 
+func (a *Adder) InjectQueries(q *driver.Queries) {
+	a.Queries = q
+}
+
 func (a *Adder) InjectWatchManager(wm *watch.Manager) {
 	a.WatchManager = wm
 }
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager, opa *opa.Client, wm *watch.Manager) (reconcile.Reconciler, error) {
-	// constraintsCache contains total number of constraints and shared mutex
-	constraintsCache := constraint.NewConstraintsCache()
-
-	constraintAdder := constraint.Adder{Opa: opa, ConstraintsCache: constraintsCache}
+	constraintAdder := constraint.Adder{Opa: opa, ConstraintsCache: constraint.Cache}
 	w, err := wm.NewRegistrar(
 		ctrlName,
 		[]watch.AddFunction{constraintAdder.Add})
@@ -164,7 +179,7 @@ func (r *ReconcileConstraintTemplate) Reconcile(request reconcile.Request) (reco
 		logError(request.NamespacedName.Name)
 		return reconcile.Result{}, err
 	}
-	crd, err := r.opa.CreateCRD(context.Background(), versionless)
+	crd, err := r.createCRD(versionless)
 	if err != nil {
 		r.metrics.registry.add(request.NamespacedName, metrics.ErrorStatus)
 		var createErr *v1beta1.CreateCRDError
@@ -186,6 +201,7 @@ func (r *ReconcileConstraintTemplate) Reconcile(request reconcile.Request) (reco
 		logError(request.NamespacedName.Name)
 		return reconcile.Result{}, nil
 	}
+	status.Errors = lintWarnings(versionless)
 	util.SetCTHAStatus(instance, status)
 
 	name := crd.GetName()
@@ -244,6 +260,16 @@ func (r *ReconcileConstraintTemplate) Reconcile(request reconcile.Request) (reco
 	return result, err
 }
 
+// createCRD rejects templates that call disallowed builtins, then builds the template's CRD
+func (r *ReconcileConstraintTemplate) createCRD(templ *templates.ConstraintTemplate) (*apiextensions.CustomResourceDefinition, error) {
+	if err := regoutil.CheckBuiltins(templ, regoutil.DisallowedBuiltins()); err != nil {
+		return nil, err
+	}
+	regoutil.AddLibs(templ)
+	constraintutil.StripSchemaDefaults(templ)
+	return r.opa.CreateCRD(context.Background(), templ)
+}
+
 func (r *ReconcileConstraintTemplate) handleCreate(
 	instance *v1beta1.ConstraintTemplate,
 	crd *apiextensions.CustomResourceDefinition) (reconcile.Result, error) {
@@ -263,6 +289,8 @@ func (r *ReconcileConstraintTemplate) handleCreate(
 		log.Error(err, "conversion error")
 		return reconcile.Result{}, err
 	}
+	regoutil.AddLibs(versionless)
+	constraintutil.StripSchemaDefaults(versionless)
 	beginCompile := time.Now()
 	if _, err := r.opa.AddTemplate(context.Background(), versionless); err != nil {
 		if err := r.metrics.reportIngestDuration(metrics.ErrorStatus, time.Since(beginCompile)); err != nil {
@@ -277,9 +305,13 @@ func (r *ReconcileConstraintTemplate) handleCreate(
 		}
 		return reconcile.Result{}, err
 	}
-	if err := r.metrics.reportIngestDuration(metrics.ActiveStatus, time.Since(beginCompile)); err != nil {
+	compiled := time.Since(beginCompile)
+	if err := r.metrics.reportIngestDuration(metrics.ActiveStatus, compiled); err != nil {
 		log.Error(err, "failed to report constraint template ingestion duration")
 	}
+	if err := r.metrics.reportCompile(versionless.GetName(), compiled, countModules(versionless)); err != nil {
+		log.Error(err, "failed to report constraint template compile metrics")
+	}
 	log.Info("adding to watcher registry")
 	if err := r.watcher.AddWatch(makeGvk(instance.Spec.CRD.Spec.Names.Kind)); err != nil {
 		return reconcile.Result{}, err
@@ -334,6 +366,8 @@ func (r *ReconcileConstraintTemplate) handleUpdate(
 		log.Error(err, "conversion error")
 		return reconcile.Result{}, err
 	}
+	regoutil.AddLibs(versionless)
+	constraintutil.StripSchemaDefaults(versionless)
 	beginCompile := time.Now()
 	if _, err := r.opa.AddTemplate(context.Background(), versionless); err != nil {
 		if err := r.metrics.reportIngestDuration(metrics.ErrorStatus, time.Since(beginCompile)); err != nil {
@@ -348,9 +382,13 @@ func (r *ReconcileConstraintTemplate) handleUpdate(
 		}
 		return reconcile.Result{}, err
 	}
-	if err := r.metrics.reportIngestDuration(metrics.ActiveStatus, time.Since(beginCompile)); err != nil {
+	compiled := time.Since(beginCompile)
+	if err := r.metrics.reportIngestDuration(metrics.ActiveStatus, compiled); err != nil {
 		log.Error(err, "failed to report constraint template ingestion duration")
 	}
+	if err := r.metrics.reportCompile(versionless.GetName(), compiled, countModules(versionless)); err != nil {
+		log.Error(err, "failed to report constraint template compile metrics")
+	}
 	log.Info("making sure constraint is in watcher registry")
 	if err := r.watcher.AddWatch(makeGvk(instance.Spec.CRD.Spec.Names.Kind)); err != nil {
 		log.Error(err, "error adding template to watch registry")
@@ -376,6 +414,23 @@ func (r *ReconcileConstraintTemplate) handleUpdate(
 	return reconcile.Result{}, nil
 }
 
+// lintWarnings returns the lint warnings of templ as status errors. Lint errors are left
+// to the compiler, which reports them when the template is loaded.
+func lintWarnings(templ *templates.ConstraintTemplate) []*v1beta1.CreateCRDError {
+	var warnings []*v1beta1.CreateCRDError
+	for _, d := range lint.Static(templ) {
+		if d.Severity != lint.Warning {
+			continue
+		}
+		w := &v1beta1.CreateCRDError{Code: lint.StatusWarningCode, Message: fmt.Sprintf("%s: %s", d.Code, d.Message)}
+		if d.Row > 0 {
+			w.Location = fmt.Sprintf("%s:%d:%d", d.Target, d.Row, d.Col)
+		}
+		warnings = append(warnings, w)
+	}
+	return warnings
+}
+
 func (r *ReconcileConstraintTemplate) handleDelete(
 	instance *v1beta1.ConstraintTemplate,
 	crd *apiextensions.CustomResourceDefinition) (reconcile.Result, error) {
@@ -415,6 +470,9 @@ func (r *ReconcileConstraintTemplate) handleDelete(
 		if _, err := r.opa.RemoveTemplate(context.Background(), versionless); err != nil {
 			return reconcile.Result{}, err
 		}
+		if err := r.metrics.reportCompile(versionless.GetName(), 0, 0); err != nil {
+			log.Error(err, "failed to report constraint template compile metrics")
+		}
 		RemoveFinalizer(instance)
 
 		if err := r.Update(context.Background(), instance); err != nil {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
 	"github.com/open-policy-agent/gatekeeper/pkg/metrics"
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
@@ -15,6 +16,8 @@ const (
 	ctMetricName   = "constraint_templates"
 	ingestCount    = "constraint_template_ingestion_count"
 	ingestDuration = "constraint_template_ingestion_duration_seconds"
+	compileSeconds = "constraint_template_compile_seconds"
+	regoModules    = "constraint_template_rego_modules"
 
 	ctDesc = "Number of observed constraint templates"
 )
@@ -22,8 +25,11 @@ const (
 var (
 	ctM             = stats.Int64(ctMetricName, ctDesc, stats.UnitDimensionless)
 	ingestDurationM = stats.Float64(ingestDuration, "How long it took to ingest a constraint template in seconds", stats.UnitSeconds)
+	compileSecondsM = stats.Float64(compileSeconds, "How long the last successful compile of a constraint template took in seconds", stats.UnitSeconds)
+	regoModulesM    = stats.Int64(regoModules, "Number of Rego modules compiled for a constraint template", stats.UnitDimensionless)
 
-	statusKey = tag.MustNewKey("status")
+	statusKey   = tag.MustNewKey("status")
+	templateKey = tag.MustNewKey("template")
 
 	views = []*view.View{
 		{
@@ -47,6 +53,20 @@ var (
 			Aggregation: view.Distribution(0.01, 0.02, 0.03, 0.04, 0.05, 0.06, 0.07, 0.08, 0.09, 0.1, 0.2, 0.3, 0.4, 0.5, 1, 2, 3, 4, 5),
 			TagKeys:     []tag.Key{statusKey},
 		},
+		{
+			Name:        compileSeconds,
+			Measure:     compileSecondsM,
+			Description: "How long the last successful compile of each constraint template took in seconds",
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{templateKey},
+		},
+		{
+			Name:        regoModules,
+			Measure:     regoModulesM,
+			Description: "Number of Rego modules, including libraries, compiled for each constraint template",
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{templateKey},
+		},
 	}
 )
 
@@ -88,6 +108,33 @@ func (r *reporter) reportIngestDuration(status metrics.Status, d time.Duration)
 	return metrics.Record(ctx, ingestDurationM.M(d.Seconds()))
 }
 
+// reportCompile records how long the named template took to compile and how many modules
+// it has. Deleted templates are reported with zero modules and duration, as their rows
+// cannot be dropped.
+func (r *reporter) reportCompile(template string, d time.Duration, modules int64) error {
+	ctx, err := tag.New(
+		r.ctx,
+		tag.Insert(templateKey, template),
+	)
+	if err != nil {
+		return err
+	}
+	if err := metrics.Record(ctx, compileSecondsM.M(d.Seconds())); err != nil {
+		return err
+	}
+	return metrics.Record(ctx, regoModulesM.M(modules))
+}
+
+// countModules returns the number of Rego modules compiled for templ: the Rego of each
+// target and its libraries
+func countModules(templ *templates.ConstraintTemplate) int64 {
+	var n int64
+	for _, t := range templ.Spec.Targets {
+		n += 1 + int64(len(t.Libs))
+	}
+	return n
+}
+
 // newStatsReporter creates a reporter for watch metrics
 func newStatsReporter() (*reporter, error) {
 	ctx, err := tag.New(
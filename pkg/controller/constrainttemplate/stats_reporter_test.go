@@ -4,8 +4,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
 	"github.com/open-policy-agent/gatekeeper/pkg/metrics"
 	"go.opencensus.io/stats/view"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestReportIngestion(t *testing.T) {
@@ -116,6 +118,48 @@ func TestGauges(t *testing.T) {
 	}
 }
 
+func TestReportCompile(t *testing.T) {
+	if err := reset(); err != nil {
+		t.Fatalf("Could not reset stats: %v", err)
+	}
+	r, err := newStatsReporter()
+	if err != nil {
+		t.Fatalf("newStatsReporter() error %v", err)
+	}
+	templ := &templates.ConstraintTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "k8srequiredlabels"},
+		Spec: templates.ConstraintTemplateSpec{
+			Targets: []templates.Target{{Target: "admission.k8s.gatekeeper.sh", Rego: "package foo", Libs: []string{"package lib.a", "package lib.b"}}},
+		},
+	}
+	if err := r.reportCompile(templ.GetName(), 2*time.Second, countModules(templ)); err != nil {
+		t.Fatalf("reportCompile error %v", err)
+	}
+
+	tc := []struct {
+		name  string
+		value float64
+	}{
+		{name: compileSeconds, value: 2},
+		{name: regoModules, value: 3},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			row := checkData(t, tt.name, 1)
+			value, ok := row.Data.(*view.LastValueData)
+			if !ok {
+				t.Fatalf("metric %s should have aggregation LastValue()", tt.name)
+			}
+			if len(row.Tags) != 1 || row.Tags[0].Value != templ.GetName() {
+				t.Errorf("%s tags = %v, wanted template %s", tt.name, row.Tags, templ.GetName())
+			}
+			if value.Value != tt.value {
+				t.Errorf("Metric: %v - Expected %v, got %v", tt.name, tt.value, value.Value)
+			}
+		})
+	}
+}
+
 func checkData(t *testing.T, name string, expectedRowLength int) *view.Row {
 	row, err := view.RetrieveData(name)
 	if err != nil {
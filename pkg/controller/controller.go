@@ -17,6 +17,7 @@ package controller
 
 import (
 	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/gatekeeper/pkg/driver"
 	"github.com/open-policy-agent/gatekeeper/pkg/watch"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
@@ -27,6 +28,11 @@ type Injector interface {
 	Add(mgr manager.Manager) error
 }
 
+// QueriesInjector is an Injector that also queries the policy engine directly
+type QueriesInjector interface {
+	InjectQueries(*driver.Queries)
+}
+
 // Injectors is a list of adder structs that need injection. We can convert this
 // to an interface once we create controllers for things like data sync
 var Injectors []Injector
@@ -35,9 +41,12 @@ var Injectors []Injector
 var AddToManagerFuncs []func(manager.Manager) error
 
 // AddToManager adds all Controllers to the Manager
-func AddToManager(m manager.Manager, client *opa.Client, wm *watch.Manager) error {
+func AddToManager(m manager.Manager, client *opa.Client, queries *driver.Queries, wm *watch.Manager) error {
 	for _, a := range Injectors {
 		a.InjectOpa(client)
+		if q, ok := a.(QueriesInjector); ok {
+			q.InjectQueries(queries)
+		}
 		a.InjectWatchManager(wm)
 		if err := a.Add(m); err != nil {
 			return err
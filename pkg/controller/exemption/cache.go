@@ -0,0 +1,81 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exemption
+
+import (
+	"sync"
+	"time"
+
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+)
+
+// Cache holds the exemptions consulted by the webhook and audit
+var Cache = NewExemptionsCache()
+
+// ExemptionsCache holds the specs of valid exemptions, keyed by name. Expiry is checked on
+// every lookup, so an exemption stops applying on time even if the controller has not yet
+// removed it.
+type ExemptionsCache struct {
+	mux        sync.RWMutex
+	exemptions map[string]v1alpha1.ExemptionSpec
+}
+
+func NewExemptionsCache() *ExemptionsCache {
+	return &ExemptionsCache{exemptions: make(map[string]v1alpha1.ExemptionSpec)}
+}
+
+func (c *ExemptionsCache) Add(name string, spec v1alpha1.ExemptionSpec) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.exemptions[name] = spec
+}
+
+func (c *ExemptionsCache) Remove(name string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	delete(c.exemptions, name)
+}
+
+// Exempted returns the name of an exemption that waives the constraint for the resource at
+// now, or "" if there is none. If several apply, the first by name is returned. nsLabels
+// are the labels of the resource's namespace, which place it in a namespace hierarchy.
+func (c *ExemptionsCache) Exempted(constraintKind, constraintName, kind, namespace string, nsLabels map[string]string, name string, now time.Time) string {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	found := ""
+	for n, spec := range c.exemptions {
+		if found != "" && n > found {
+			continue
+		}
+		if spec.ConstraintKind != constraintKind || spec.ConstraintName != constraintName {
+			continue
+		}
+		if !now.Before(spec.ExpiresAt.Time) {
+			continue
+		}
+		if matches(spec.Match, kind, namespace, nsLabels, name) {
+			found = n
+		}
+	}
+	return found
+}
+
+func matches(m v1alpha1.ExemptionMatch, kind, namespace string, nsLabels map[string]string, name string) bool {
+	return (m.Kind == "" || m.Kind == kind) &&
+		(m.Namespace == "" || util.NamespaceMatches(m.Namespace, namespace, nsLabels)) &&
+		(m.Name == "" || m.Name == name)
+}
@@ -0,0 +1,132 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exemption
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("controller").WithValues(logging.Process, "exemption_controller")
+
+// Add creates a controller that keeps Cache in sync with the Exemption resources and marks
+// them expired once their expiresAt passes
+func Add(mgr manager.Manager) error {
+	r := &ReconcileExemption{
+		client:   mgr.GetClient(),
+		cache:    Cache,
+		recorder: mgr.GetEventRecorderFor("gatekeeper-exemption-controller"),
+		now:      time.Now,
+	}
+	c, err := controller.New("exemption-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	return c.Watch(&source.Kind{Type: &v1alpha1.Exemption{}}, &handler.EnqueueRequestForObject{})
+}
+
+var _ reconcile.Reconciler = &ReconcileExemption{}
+
+// ReconcileExemption caches unexpired exemptions and requeues each one for its expiry
+type ReconcileExemption struct {
+	client   client.Client
+	cache    *ExemptionsCache
+	recorder record.EventRecorder
+	now      func() time.Time
+}
+
+// +kubebuilder:rbac:groups=config.gatekeeper.sh,resources=exemptions,verbs=get;list;watch
+// +kubebuilder:rbac:groups=config.gatekeeper.sh,resources=exemptions/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+func (r *ReconcileExemption) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx := context.TODO()
+	exemption := &v1alpha1.Exemption{}
+	if err := r.client.Get(ctx, request.NamespacedName, exemption); err != nil {
+		if errors.IsNotFound(err) {
+			r.cache.Remove(request.Name)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if !exemption.GetDeletionTimestamp().IsZero() {
+		r.cache.Remove(exemption.GetName())
+		return reconcile.Result{}, nil
+	}
+	if err := validate(exemption); err != nil {
+		r.cache.Remove(exemption.GetName())
+		log.Info("ignoring invalid exemption", logging.ExemptionName, exemption.GetName(), "reason", err.Error())
+		r.recorder.Event(exemption, corev1.EventTypeWarning, "Invalid", err.Error())
+		return reconcile.Result{}, nil
+	}
+
+	remaining := exemption.Spec.ExpiresAt.Sub(r.now())
+	if remaining <= 0 {
+		r.cache.Remove(exemption.GetName())
+		if exemption.Status.Expired {
+			return reconcile.Result{}, nil
+		}
+		exemption.Status.Expired = true
+		if err := r.client.Status().Update(ctx, exemption); err != nil {
+			return reconcile.Result{}, err
+		}
+		log.Info("exemption expired", logging.ExemptionName, exemption.GetName(),
+			logging.ConstraintKind, exemption.Spec.ConstraintKind, logging.ConstraintName, exemption.Spec.ConstraintName)
+		r.recorder.Eventf(exemption, corev1.EventTypeNormal, "Expired", "exemption from %s %s expired at %s",
+			exemption.Spec.ConstraintKind, exemption.Spec.ConstraintName, exemption.Spec.ExpiresAt.UTC().Format(time.RFC3339))
+		return reconcile.Result{}, nil
+	}
+
+	r.cache.Add(exemption.GetName(), exemption.Spec)
+	if exemption.Status.Expired {
+		// expiresAt was moved into the future
+		exemption.Status.Expired = false
+		if err := r.client.Status().Update(ctx, exemption); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	return reconcile.Result{RequeueAfter: remaining}, nil
+}
+
+// validate rejects exemptions that would waive a constraint for the whole cluster, which
+// should be done by changing the constraint instead
+func validate(exemption *v1alpha1.Exemption) error {
+	spec := exemption.Spec
+	if spec.ConstraintKind == "" || spec.ConstraintName == "" {
+		return fmt.Errorf("constraintKind and constraintName must be set")
+	}
+	if spec.Match.Namespace == "" && spec.Match.Name == "" {
+		return fmt.Errorf("match.namespace or match.name must be set")
+	}
+	if spec.ExpiresAt.IsZero() {
+		return fmt.Errorf("expiresAt must be set")
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package exemption
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/gatekeeper/api"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestExempted(t *testing.T) {
+	now := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	spec := func(match v1alpha1.ExemptionMatch, expires time.Time) v1alpha1.ExemptionSpec {
+		return v1alpha1.ExemptionSpec{
+			ConstraintKind: "K8sRequiredLabels",
+			ConstraintName: "must-have-owner",
+			Match:          match,
+			ExpiresAt:      metav1.NewTime(expires),
+		}
+	}
+	c := NewExemptionsCache()
+	c.Add("legacy-ns", spec(v1alpha1.ExemptionMatch{Namespace: "legacy"}, now.Add(time.Hour)))
+	c.Add("one-pod", spec(v1alpha1.ExemptionMatch{Kind: "Pod", Namespace: "prod", Name: "debug"}, now.Add(time.Hour)))
+	c.Add("expired", spec(v1alpha1.ExemptionMatch{Namespace: "old"}, now))
+	c.Add("team-a", spec(v1alpha1.ExemptionMatch{Namespace: "team-a/*"}, now.Add(time.Hour)))
+	child := map[string]string{"team-a.tree.hnc.x-k8s.io/depth": "1"}
+
+	tc := []struct {
+		name           string
+		constraintName string
+		kind           string
+		namespace      string
+		nsLabels       map[string]string
+		resource       string
+		want           string
+	}{
+		{name: "namespace", constraintName: "must-have-owner", kind: "Service", namespace: "legacy", resource: "svc", want: "legacy-ns"},
+		{name: "resource", constraintName: "must-have-owner", kind: "Pod", namespace: "prod", resource: "debug", want: "one-pod"},
+		{name: "other resource", constraintName: "must-have-owner", kind: "Pod", namespace: "prod", resource: "web"},
+		{name: "other kind", constraintName: "must-have-owner", kind: "Deployment", namespace: "prod", resource: "debug"},
+		{name: "other constraint", constraintName: "must-have-team", kind: "Service", namespace: "legacy", resource: "svc"},
+		{name: "expired", constraintName: "must-have-owner", kind: "Service", namespace: "old", resource: "svc"},
+		{name: "subtree root", constraintName: "must-have-owner", kind: "Service", namespace: "team-a", resource: "svc", want: "team-a"},
+		{name: "subtree child", constraintName: "must-have-owner", kind: "Service", namespace: "team-a-web", nsLabels: child, resource: "svc", want: "team-a"},
+		{name: "outside subtree", constraintName: "must-have-owner", kind: "Service", namespace: "team-b", resource: "svc"},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Exempted("K8sRequiredLabels", tt.constraintName, tt.kind, tt.namespace, tt.nsLabels, tt.resource, now); got != tt.want {
+				t.Errorf("Exempted() = %q, wanted %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileExemption(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	exemption := &v1alpha1.Exemption{
+		ObjectMeta: metav1.ObjectMeta{Name: "legacy-ns"},
+		Spec: v1alpha1.ExemptionSpec{
+			ConstraintKind: "K8sRequiredLabels",
+			ConstraintName: "must-have-owner",
+			Match:          v1alpha1.ExemptionMatch{Namespace: "legacy"},
+			ExpiresAt:      metav1.NewTime(now.Add(time.Hour)),
+		},
+	}
+	c := fake.NewFakeClientWithScheme(scheme, exemption)
+	recorder := record.NewFakeRecorder(10)
+	r := &ReconcileExemption{client: c, cache: NewExemptionsCache(), recorder: recorder, now: func() time.Time { return now }}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "legacy-ns"}}
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RequeueAfter != time.Hour {
+		t.Errorf("RequeueAfter = %v, wanted the time left until expiry", res.RequeueAfter)
+	}
+	if got := r.cache.Exempted("K8sRequiredLabels", "must-have-owner", "Pod", "legacy", nil, "web", now); got != "legacy-ns" {
+		t.Errorf("exemption was not cached, got %q", got)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.cache.exemptions) != 0 {
+		t.Error("expired exemption is still cached")
+	}
+	got := &v1alpha1.Exemption{}
+	if err := c.Get(context.TODO(), req.NamespacedName, got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Status.Expired {
+		t.Error("expired exemption is not marked expired")
+	}
+	select {
+	case e := <-recorder.Events:
+		t.Logf("event: %s", e)
+	default:
+		t.Error("no event was emitted on expiry")
+	}
+
+	// reconciling again must not repeat the event
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-recorder.Events:
+		t.Errorf("unexpected event %s", e)
+	default:
+	}
+
+	if err := c.Delete(context.TODO(), got); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReconcileInvalidExemption(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	// without a namespace or name the constraint would be waived cluster-wide
+	exemption := &v1alpha1.Exemption{
+		ObjectMeta: metav1.ObjectMeta{Name: "everything"},
+		Spec: v1alpha1.ExemptionSpec{
+			ConstraintKind: "K8sRequiredLabels",
+			ConstraintName: "must-have-owner",
+			ExpiresAt:      metav1.NewTime(time.Now().Add(time.Hour)),
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &ReconcileExemption{
+		client:   fake.NewFakeClientWithScheme(scheme, exemption),
+		cache:    NewExemptionsCache(),
+		recorder: recorder,
+		now:      time.Now,
+	}
+	if _, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: "everything"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.cache.exemptions) != 0 {
+		t.Error("invalid exemption was cached")
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("got %d events, wanted a warning for the invalid exemption", len(recorder.Events))
+	}
+}
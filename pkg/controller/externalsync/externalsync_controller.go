@@ -0,0 +1,251 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalsync
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/featuregate"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	"github.com/open-policy-agent/gatekeeper/pkg/watch"
+	opautil "github.com/open-policy-agent/opa/util"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("controller").WithValues(logging.Process, "externalsync_controller")
+
+const (
+	// defaultInterval is how often documents are fetched if the ExternalSync does not say
+	defaultInterval = 5 * time.Minute
+	// minInterval keeps endpoints from being polled more often than this
+	minInterval = 30 * time.Second
+	// fetchTimeout bounds each fetch, including reading the document
+	fetchTimeout = 30 * time.Second
+	// maxDocumentSize is the largest document loaded, in bytes
+	maxDocumentSize = 16 << 20
+)
+
+// dataClient loads data into the policy engine
+type dataClient interface {
+	AddData(ctx context.Context, data interface{}) (*types.Responses, error)
+	RemoveData(ctx context.Context, data interface{}) (*types.Responses, error)
+}
+
+type Adder struct {
+	Opa *opa.Client
+}
+
+func (a *Adder) InjectOpa(o *opa.Client) {
+	a.Opa = o
+}
+
+func (a *Adder) InjectWatchManager(_ *watch.Manager) {}
+
+// Add creates a controller that loads the document of each ExternalSync into OPA, if the
+// ExternalSync feature gate is on
+func (a *Adder) Add(mgr manager.Manager) error {
+	if !featuregate.Enabled(featuregate.ExternalSync) {
+		return nil
+	}
+	r := &ReconcileExternalSync{
+		client:    mgr.GetClient(),
+		secrets:   mgr.GetAPIReader(),
+		opa:       a.Opa,
+		namespace: util.GetNamespace(),
+	}
+	c, err := controller.New("externalsync-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	// status updates do not change the generation, so they do not cause another fetch
+	return c.Watch(&source.Kind{Type: &v1alpha1.ExternalSync{}}, &handler.EnqueueRequestForObject{}, predicate.GenerationChangedPredicate{})
+}
+
+var _ reconcile.Reconciler = &ReconcileExternalSync{}
+
+// ReconcileExternalSync fetches the document of each ExternalSync every interval
+type ReconcileExternalSync struct {
+	client client.Client
+	// secrets reads auth Secrets without caching every Secret in the cluster
+	secrets   client.Reader
+	opa       dataClient
+	namespace string
+}
+
+// +kubebuilder:rbac:groups=config.gatekeeper.sh,resources=externalsyncs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=config.gatekeeper.sh,resources=externalsyncs/status,verbs=get;update;patch
+
+func (r *ReconcileExternalSync) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx := context.TODO()
+	es := &v1alpha1.ExternalSync{}
+	if err := r.client.Get(ctx, request.NamespacedName, es); err != nil {
+		if apierrors.IsNotFound(err) {
+			_, err := r.opa.RemoveData(ctx, target.ExternalData{Name: request.Name})
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, err
+	}
+	if !es.GetDeletionTimestamp().IsZero() {
+		_, err := r.opa.RemoveData(ctx, target.ExternalData{Name: es.GetName()})
+		return reconcile.Result{}, err
+	}
+
+	status := v1alpha1.ExternalSyncStatus{ObservedGeneration: es.GetGeneration(), Synced: true}
+	if err := r.sync(ctx, es); err != nil {
+		log.Error(err, "could not sync external data", "external_sync", es.GetName())
+		status.Synced = false
+		status.Error = err.Error()
+	}
+	if !reflect.DeepEqual(status, es.Status) {
+		// every replica fetches the document, so only write when the outcome changes
+		es.Status = status
+		if err := r.client.Status().Update(ctx, es); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	return reconcile.Result{RequeueAfter: interval(es)}, nil
+}
+
+// sync fetches the document of es and loads it into OPA. The document already loaded is
+// kept if it cannot be fetched.
+func (r *ReconcileExternalSync) sync(ctx context.Context, es *v1alpha1.ExternalSync) error {
+	doc, err := r.fetch(ctx, es)
+	if err != nil {
+		return err
+	}
+	_, err = r.opa.AddData(ctx, target.ExternalData{Name: es.GetName(), Value: doc})
+	return err
+}
+
+// fetch returns the JSON document at the URL of es
+func (r *ReconcileExternalSync) fetch(ctx context.Context, es *v1alpha1.ExternalSync) (interface{}, error) {
+	u, err := url.Parse(es.Spec.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("url scheme must be http or https, not %q", u.Scheme)
+	}
+	c, err := newHTTPClient(es.Spec.CABundle)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if es.Spec.Auth != nil {
+		if err := r.authorize(ctx, req, es.Spec.Auth); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", u.Host, resp.Status)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxDocumentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxDocumentSize {
+		return nil, fmt.Errorf("document is larger than %d bytes", maxDocumentSize)
+	}
+	var doc interface{}
+	if err := opautil.UnmarshalJSON(body, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %v", err)
+	}
+	return doc, nil
+}
+
+// authorize adds the credentials in the Secret auth names to req: its token as a bearer
+// token, or else its username and password as basic auth
+func (r *ReconcileExternalSync) authorize(ctx context.Context, req *http.Request, auth *v1alpha1.ExternalSyncAuth) error {
+	secret := &corev1.Secret{}
+	key := k8stypes.NamespacedName{Namespace: r.namespace, Name: auth.SecretName}
+	if err := r.secrets.Get(ctx, key, secret); err != nil {
+		return fmt.Errorf("could not read auth secret %s: %v", auth.SecretName, err)
+	}
+	if token := strings.TrimSpace(string(secret.Data["token"])); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+	if username, ok := secret.Data["username"]; ok {
+		req.SetBasicAuth(string(username), string(secret.Data["password"]))
+		return nil
+	}
+	return fmt.Errorf("auth secret %s has neither a token nor a username", auth.SecretName)
+}
+
+// newHTTPClient returns a client that verifies https endpoints with the certificates in
+// caBundle, or with the system's if it is empty
+func newHTTPClient(caBundle string) (*http.Client, error) {
+	c := &http.Client{Timeout: fetchTimeout}
+	if caBundle == "" {
+		return c, nil
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(caBundle)) {
+		return nil, errors.New("caBundle has no PEM certificates")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	c.Transport = transport
+	return c, nil
+}
+
+// interval returns how long to wait before fetching the document of es again
+func interval(es *v1alpha1.ExternalSync) time.Duration {
+	if es.Spec.Interval == nil || es.Spec.Interval.Duration <= 0 {
+		return defaultInterval
+	}
+	if es.Spec.Interval.Duration < minInterval {
+		return minInterval
+	}
+	return es.Spec.Interval.Duration
+}
@@ -0,0 +1,233 @@
+package externalsync
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/api"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const namespace = "gatekeeper-system"
+
+// fakeData records the documents loaded by name
+type fakeData struct {
+	docs map[string]interface{}
+}
+
+func (f *fakeData) AddData(_ context.Context, data interface{}) (*types.Responses, error) {
+	d := data.(target.ExternalData)
+	f.docs[d.Name] = d.Value
+	return types.NewResponses(), nil
+}
+
+func (f *fakeData) RemoveData(_ context.Context, data interface{}) (*types.Responses, error) {
+	delete(f.docs, data.(target.ExternalData).Name)
+	return types.NewResponses(), nil
+}
+
+func newReconciler(t *testing.T, objs ...runtime.Object) (*ReconcileExternalSync, client.Client, *fakeData) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewFakeClientWithScheme(scheme, objs...)
+	data := &fakeData{docs: make(map[string]interface{})}
+	return &ReconcileExternalSync{client: c, secrets: c, opa: data, namespace: namespace}, c, data
+}
+
+func getStatus(t *testing.T, c client.Client, name string) v1alpha1.ExternalSyncStatus {
+	t.Helper()
+	es := &v1alpha1.ExternalSync{}
+	if err := c.Get(context.TODO(), k8stypes.NamespacedName{Name: name}, es); err != nil {
+		t.Fatal(err)
+	}
+	return es.Status
+}
+
+func TestReconcileExternalSync(t *testing.T) {
+	up := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !up {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"registries": ["registry.example.com"]}`))
+	}))
+	defer srv.Close()
+
+	es := &v1alpha1.ExternalSync{
+		ObjectMeta: metav1.ObjectMeta{Name: "allowlist"},
+		Spec: v1alpha1.ExternalSyncSpec{
+			URL:      srv.URL + "/allowlist.json",
+			Interval: &metav1.Duration{Duration: time.Minute},
+			Auth:     &v1alpha1.ExternalSyncAuth{SecretName: "allowlist-auth"},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "allowlist-auth"},
+		Data:       map[string][]byte{"token": []byte("s3cret\n")},
+	}
+	r, c, data := newReconciler(t, es, secret)
+	req := reconcile.Request{NamespacedName: k8stypes.NamespacedName{Name: "allowlist"}}
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if res.RequeueAfter != time.Minute {
+		t.Errorf("RequeueAfter = %v, wanted the interval", res.RequeueAfter)
+	}
+	want := map[string]interface{}{"registries": []interface{}{"registry.example.com"}}
+	if !reflect.DeepEqual(data.docs["allowlist"], want) {
+		t.Errorf("document = %v, wanted %v", data.docs["allowlist"], want)
+	}
+	if status := getStatus(t, c, "allowlist"); !status.Synced || status.Error != "" {
+		t.Errorf("status = %+v, wanted synced", status)
+	}
+
+	// a failed fetch is reported, and the document already loaded is kept
+	up = false
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if status := getStatus(t, c, "allowlist"); status.Synced || !strings.Contains(status.Error, "503") {
+		t.Errorf("status = %+v, wanted the fetch error", status)
+	}
+	if !reflect.DeepEqual(data.docs["allowlist"], want) {
+		t.Errorf("document = %v after a failed fetch, wanted it kept", data.docs["allowlist"])
+	}
+
+	if err := c.Delete(context.TODO(), es); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if _, ok := data.docs["allowlist"]; ok {
+		t.Error("document kept after the ExternalSync was deleted")
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		user, password, ok := req.BasicAuth()
+		if !ok || user != "gatekeeper" || password != "pw" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"truncated": `))
+	}))
+	defer srv.Close()
+	basic := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "basic"},
+		Data:       map[string][]byte{"username": []byte("gatekeeper"), "password": []byte("pw")},
+	}
+	empty := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "empty"}}
+	r, _, _ := newReconciler(t, basic, empty)
+
+	tc := []struct {
+		Name          string
+		Spec          v1alpha1.ExternalSyncSpec
+		ExpectedError string
+	}{
+		{
+			Name:          "Unsupported scheme",
+			Spec:          v1alpha1.ExternalSyncSpec{URL: "file:///etc/passwd"},
+			ExpectedError: "must be http or https",
+		},
+		{
+			Name:          "Invalid CA bundle",
+			Spec:          v1alpha1.ExternalSyncSpec{URL: srv.URL, CABundle: "not a certificate"},
+			ExpectedError: "no PEM certificates",
+		},
+		{
+			Name:          "Missing secret",
+			Spec:          v1alpha1.ExternalSyncSpec{URL: srv.URL, Auth: &v1alpha1.ExternalSyncAuth{SecretName: "missing"}},
+			ExpectedError: "could not read auth secret missing",
+		},
+		{
+			Name:          "Secret without credentials",
+			Spec:          v1alpha1.ExternalSyncSpec{URL: srv.URL, Auth: &v1alpha1.ExternalSyncAuth{SecretName: "empty"}},
+			ExpectedError: "neither a token nor a username",
+		},
+		{
+			Name:          "Unauthorized",
+			Spec:          v1alpha1.ExternalSyncSpec{URL: srv.URL},
+			ExpectedError: "401",
+		},
+		{
+			Name:          "Invalid JSON",
+			Spec:          v1alpha1.ExternalSyncSpec{URL: srv.URL, Auth: &v1alpha1.ExternalSyncAuth{SecretName: "basic"}},
+			ExpectedError: "invalid JSON document",
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			es := &v1alpha1.ExternalSync{ObjectMeta: metav1.ObjectMeta{Name: "feed"}, Spec: tt.Spec}
+			_, err := r.fetch(context.TODO(), es)
+			if err == nil || !strings.Contains(err.Error(), tt.ExpectedError) {
+				t.Errorf("fetch() error = %v, wanted one containing %q", err, tt.ExpectedError)
+			}
+		})
+	}
+}
+
+func TestFetchNumbers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"maxReplicas": 9007199254740993}`))
+	}))
+	defer srv.Close()
+	r, _, _ := newReconciler(t)
+	doc, err := r.fetch(context.TODO(), &v1alpha1.ExternalSync{Spec: v1alpha1.ExternalSyncSpec{URL: srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// numbers are kept exact rather than rounded to float64
+	if got := doc.(map[string]interface{})["maxReplicas"]; got != json.Number("9007199254740993") {
+		t.Errorf("maxReplicas = %v (%T), wanted it exact", got, got)
+	}
+}
+
+func TestInterval(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Interval *metav1.Duration
+		Expected time.Duration
+	}{
+		{Name: "Unset", Expected: defaultInterval},
+		{Name: "Set", Interval: &metav1.Duration{Duration: 10 * time.Minute}, Expected: 10 * time.Minute},
+		{Name: "Too short", Interval: &metav1.Duration{Duration: time.Second}, Expected: minInterval},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			es := &v1alpha1.ExternalSync{Spec: v1alpha1.ExternalSyncSpec{Interval: tt.Interval}}
+			if got := interval(es); got != tt.Expected {
+				t.Errorf("interval() = %v, wanted %v", got, tt.Expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podstatus
+
+import (
+	"context"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	constraintutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("controller").WithValues(logging.Process, "pod_status_controller")
+
+// gcRequest is the single key all pod events map to, so a burst of pod deletions
+// results in one sweep
+var gcRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: "pod-status-gc"}}
+
+// Add creates a controller that removes the byPod status entries of deleted Gatekeeper
+// pods from constraint templates and constraints
+func Add(mgr manager.Manager) error {
+	namespace := util.GetNamespace()
+	// Only pods in Gatekeeper's namespace write status, so avoid caching every pod
+	// in the cluster
+	podCache, err := cache.New(mgr.GetConfig(), cache.Options{
+		Scheme:    mgr.GetScheme(),
+		Mapper:    mgr.GetRESTMapper(),
+		Namespace: namespace,
+	})
+	if err != nil {
+		return err
+	}
+	if err := mgr.Add(podCache); err != nil {
+		return err
+	}
+
+	r := &ReconcilePodStatus{client: mgr.GetClient(), pods: mgr.GetAPIReader(), namespace: namespace}
+	c, err := controller.New("pod-status-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	src := &source.Kind{Type: &corev1.Pod{}}
+	if err := src.InjectCache(podCache); err != nil {
+		return err
+	}
+	return c.Watch(
+		src,
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(
+			func(handler.MapObject) []reconcile.Request { return []reconcile.Request{gcRequest} },
+		)},
+		// Create events are delivered for every existing pod when the watch starts,
+		// which sweeps entries left by pods deleted while no Gatekeeper pod was running
+		predicate.Funcs{
+			UpdateFunc:  func(event.UpdateEvent) bool { return false },
+			GenericFunc: func(event.GenericEvent) bool { return false },
+		},
+	)
+}
+
+var _ reconcile.Reconciler = &ReconcilePodStatus{}
+
+// ReconcilePodStatus removes the status written by pods that no longer exist
+type ReconcilePodStatus struct {
+	client    client.Client
+	pods      client.Reader
+	namespace string
+}
+
+func (r *ReconcilePodStatus) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx := context.TODO()
+	pods := &corev1.PodList{}
+	if err := r.pods.List(ctx, pods, client.InNamespace(r.namespace)); err != nil {
+		return reconcile.Result{}, err
+	}
+	live := make(map[string]bool, len(pods.Items))
+	for _, pod := range pods.Items {
+		live[pod.GetName()] = true
+	}
+	// This pod writes status too, so if it is not listed the pod list cannot be
+	// trusted and nothing should be removed
+	if !live[util.GetID()] {
+		log.Info("own pod not found, skipping status cleanup", "pod", util.GetID(), "namespace", r.namespace)
+		return reconcile.Result{}, nil
+	}
+	keep := func(id string) bool { return live[id] }
+
+	templates := &v1beta1.ConstraintTemplateList{}
+	if err := r.client.List(ctx, templates); err != nil {
+		return reconcile.Result{}, err
+	}
+	var failed bool
+	for i := range templates.Items {
+		templ := &templates.Items[i]
+		if util.PruneCTHAStatus(templ, keep) {
+			log.Info("removing status of deleted pods", logging.TemplateName, templ.GetName())
+			if err := r.client.Status().Update(ctx, templ); err != nil && !errors.IsNotFound(err) {
+				log.Error(err, "could not remove status of deleted pods", logging.TemplateName, templ.GetName())
+				failed = true
+			}
+		}
+		if err := r.pruneConstraints(ctx, templ.Spec.CRD.Spec.Names.Kind, keep); err != nil {
+			log.Error(err, "could not remove status of deleted pods from constraints", logging.TemplateName, templ.GetName())
+			failed = true
+		}
+	}
+	return reconcile.Result{Requeue: failed}, nil
+}
+
+func (r *ReconcilePodStatus) pruneConstraints(ctx context.Context, kind string, keep func(string) bool) error {
+	objs := &unstructured.UnstructuredList{}
+	objs.SetGroupVersionKind(schema.GroupVersionKind{Group: "constraints.gatekeeper.sh", Version: "v1beta1", Kind: kind + "List"})
+	if err := r.client.List(ctx, objs); err != nil {
+		// The template's CRD may not have been created
+		if meta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+	for i := range objs.Items {
+		obj := &objs.Items[i]
+		changed, err := constraintutil.PruneHAStatus(obj, keep)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			continue
+		}
+		if err := r.client.Status().Update(ctx, obj); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
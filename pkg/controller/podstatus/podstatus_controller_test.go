@@ -0,0 +1,121 @@
+package podstatus
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"github.com/open-policy-agent/gatekeeper/api"
+	"github.com/open-policy-agent/gatekeeper/pkg/testutils"
+	constraintutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcilePodStatus(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	gvk := testutils.ConstraintGVK("K8sRequiredLabels")
+	scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+
+	templ := &v1beta1.ConstraintTemplate{ObjectMeta: metav1.ObjectMeta{Name: "k8srequiredlabels"}}
+	templ.Spec.CRD.Spec.Names.Kind = gvk.Kind
+	templ.Status.ByPod = []*v1beta1.ByPodStatus{{ID: "gk-live"}, {ID: "gk-deleted"}}
+	constraint := testutils.NewConstraint(gvk.Kind, "must-have-owner")
+	for _, id := range []string{"gk-live", "gk-deleted"} {
+		if err := os.Setenv("POD_NAME", id); err != nil {
+			t.Fatal(err)
+		}
+		if err := constraintutil.SetHAStatus(constraint, &constraintutil.ByPodStatus{Enforced: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer os.Unsetenv("POD_NAME")
+	pods := []runtime.Object{
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "gk-live", Namespace: "gatekeeper-system"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "gk-deleted", Namespace: "other"}},
+	}
+	c := fake.NewFakeClientWithScheme(scheme, append(pods, templ, constraint)...)
+
+	tc := []struct {
+		Name     string
+		PodName  string
+		Expected []string
+	}{
+		{
+			// the pod list is not trusted if it is missing the running pod
+			Name:     "Own pod missing",
+			PodName:  "gk-unknown",
+			Expected: []string{"gk-live", "gk-deleted"},
+		},
+		{
+			Name:     "Deleted pod removed",
+			PodName:  "gk-live",
+			Expected: []string{"gk-live"},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			if err := os.Setenv("POD_NAME", tt.PodName); err != nil {
+				t.Fatal(err)
+			}
+			r := &ReconcilePodStatus{client: c, pods: c, namespace: "gatekeeper-system"}
+			if _, err := r.Reconcile(gcRequest); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			gotTempl := &v1beta1.ConstraintTemplate{}
+			if err := c.Get(context.TODO(), types.NamespacedName{Name: templ.GetName()}, gotTempl); err != nil {
+				t.Fatal(err)
+			}
+			var templIDs []string
+			for _, s := range gotTempl.Status.ByPod {
+				templIDs = append(templIDs, s.ID)
+			}
+			if !equal(templIDs, tt.Expected) {
+				t.Errorf("template byPod ids = %v, wanted %v", templIDs, tt.Expected)
+			}
+
+			gotConstraint := &unstructured.Unstructured{}
+			gotConstraint.SetGroupVersionKind(gvk)
+			if err := c.Get(context.TODO(), types.NamespacedName{Name: constraint.GetName()}, gotConstraint); err != nil {
+				t.Fatal(err)
+			}
+			statuses, _, err := unstructured.NestedSlice(gotConstraint.Object, "status", "byPod")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var constraintIDs []string
+			for _, s := range statuses {
+				constraintIDs = append(constraintIDs, s.(map[string]interface{})["id"].(string))
+			}
+			if !equal(constraintIDs, tt.Expected) {
+				t.Errorf("constraint byPod ids = %v, wanted %v", constraintIDs, tt.Expected)
+			}
+		})
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,189 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policytest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/verify"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var log = logf.Log.WithName("controller").WithValues(logging.Process, "policytest_controller")
+
+// Add creates a controller that runs each PolicyTest whenever it or the template it tests
+// changes
+func Add(mgr manager.Manager) error {
+	r := &ReconcilePolicyTest{client: mgr.GetClient()}
+	c, err := controller.New("policytest-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	if err := c.Watch(&source.Kind{Type: &v1alpha1.PolicyTest{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		return err
+	}
+	return c.Watch(
+		&source.Kind{Type: &v1beta1.ConstraintTemplate{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.testsOfTemplate)},
+	)
+}
+
+var _ reconcile.Reconciler = &ReconcilePolicyTest{}
+
+// ReconcilePolicyTest runs PolicyTests against the templates in the cluster
+type ReconcilePolicyTest struct {
+	client client.Client
+}
+
+// testsOfTemplate returns a request for every PolicyTest of the template in obj
+func (r *ReconcilePolicyTest) testsOfTemplate(obj handler.MapObject) []reconcile.Request {
+	tests := &v1alpha1.PolicyTestList{}
+	if err := r.client.List(context.TODO(), tests); err != nil {
+		log.Error(err, "could not list policy tests", "template", obj.Meta.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, test := range tests.Items {
+		if test.Spec.Template == obj.Meta.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: test.GetName()}})
+		}
+	}
+	return requests
+}
+
+// +kubebuilder:rbac:groups=config.gatekeeper.sh,resources=policytests,verbs=get;list;watch
+// +kubebuilder:rbac:groups=config.gatekeeper.sh,resources=policytests/status,verbs=get;update;patch
+
+func (r *ReconcilePolicyTest) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx := context.TODO()
+	test := &v1alpha1.PolicyTest{}
+	if err := r.client.Get(ctx, request.NamespacedName, test); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if !test.GetDeletionTimestamp().IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	status, err := r.run(ctx, test)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if reflect.DeepEqual(status, test.Status) {
+		// every replica runs the test, so only write when the outcome changes
+		return reconcile.Result{}, nil
+	}
+	if status.Passed != test.Status.Passed || status.Error != test.Status.Error {
+		log.Info("policy test outcome changed", "policy_test", test.GetName(), "template", test.Spec.Template,
+			"passed", status.Passed, "error", status.Error)
+	}
+	test.Status = status
+	return reconcile.Result{}, r.client.Status().Update(ctx, test)
+}
+
+// run evaluates every case of test. Problems with the test itself, such as a missing
+// template, are reported in the status; only errors reading the template are returned.
+func (r *ReconcilePolicyTest) run(ctx context.Context, test *v1alpha1.PolicyTest) (v1alpha1.PolicyTestStatus, error) {
+	status := v1alpha1.PolicyTestStatus{ObservedGeneration: test.GetGeneration()}
+	templ := &v1beta1.ConstraintTemplate{}
+	if err := r.client.Get(ctx, types.NamespacedName{Name: test.Spec.Template}, templ); err != nil {
+		if errors.IsNotFound(err) {
+			status.Error = fmt.Sprintf("template %s not found", test.Spec.Template)
+			return status, nil
+		}
+		return status, err
+	}
+	status.TemplateGeneration = templ.GetGeneration()
+
+	policies, err := newClient(ctx, templ, test.Spec.Constraint)
+	if err != nil {
+		status.Error = err.Error()
+		return status, nil
+	}
+	status.Passed = true
+	for _, c := range test.Spec.Cases {
+		result := v1alpha1.PolicyTestResult{Name: c.Name, Passed: true}
+		if err := runCase(ctx, policies, c); err != nil {
+			result.Passed = false
+			result.Message = err.Error()
+			status.Passed = false
+		}
+		status.Results = append(status.Results, result)
+	}
+	return status, nil
+}
+
+// newClient returns an OPA client, separate from the one enforcing policy, loaded with
+// templ and constraint
+func newClient(ctx context.Context, templ *v1beta1.ConstraintTemplate, constraint runtime.RawExtension) (*opa.Client, error) {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(templ)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: obj}
+	u.SetGroupVersionKind(v1beta1.SchemeGroupVersion.WithKind("ConstraintTemplate"))
+	c, err := decode("constraint", constraint)
+	if err != nil {
+		return nil, err
+	}
+	return verify.NewClient(ctx, []*unstructured.Unstructured{u}, []*unstructured.Unstructured{c})
+}
+
+func runCase(ctx context.Context, policies *opa.Client, c v1alpha1.PolicyTestCase) error {
+	obj, err := decode("object", c.Object)
+	if err != nil {
+		return err
+	}
+	var assertions []verify.Assertion
+	for _, a := range c.Assertions {
+		assertion := verify.Assertion{Message: a.Message}
+		if a.Violations != nil {
+			assertion.Violations = &verify.Violations{IntOrString: *a.Violations}
+		}
+		assertions = append(assertions, assertion)
+	}
+	return verify.Evaluate(ctx, policies, []*unstructured.Unstructured{obj}, assertions)
+}
+
+// decode reads the embedded object in raw, which is named field in the PolicyTest
+func decode(field string, raw runtime.RawExtension) (*unstructured.Unstructured, error) {
+	if len(raw.Raw) == 0 {
+		return nil, fmt.Errorf("%s is not set", field)
+	}
+	u := &unstructured.Unstructured{}
+	if err := u.UnmarshalJSON(raw.Raw); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", field, err)
+	}
+	return u, nil
+}
@@ -0,0 +1,127 @@
+package policytest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"github.com/open-policy-agent/gatekeeper/api"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/bench"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const constraint = `{
+  "apiVersion": "constraints.gatekeeper.sh/v1beta1",
+  "kind": "K8sRequiredLabels",
+  "metadata": {"name": "ns-must-have-gk"},
+  "spec": {
+    "match": {"kinds": [{"apiGroups": [""], "kinds": ["Namespace"]}]},
+    "parameters": {"labels": ["gatekeeper"]}
+  }
+}`
+
+func demoTemplate(t *testing.T) *v1beta1.ConstraintTemplate {
+	objs, err := bench.ReadObjects("../../../demo/basic/templates/k8srequiredlabels_template.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	templ := &v1beta1.ConstraintTemplate{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(objs[0].Object, templ); err != nil {
+		t.Fatal(err)
+	}
+	return templ
+}
+
+func namespace(labels string) runtime.RawExtension {
+	return runtime.RawExtension{Raw: []byte(`{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "test", "labels": ` + labels + `}}`)}
+}
+
+func TestReconcilePolicyTest(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	no := intstr.FromString("no")
+	one := intstr.FromInt(1)
+	test := &v1alpha1.PolicyTest{
+		ObjectMeta: metav1.ObjectMeta{Name: "required-labels", Generation: 2},
+		Spec: v1alpha1.PolicyTestSpec{
+			Template:   "k8srequiredlabels",
+			Constraint: runtime.RawExtension{Raw: []byte(constraint)},
+			Cases: []v1alpha1.PolicyTestCase{
+				{
+					Name:       "labeled",
+					Object:     namespace(`{"gatekeeper": "true"}`),
+					Assertions: []v1alpha1.PolicyTestAssertion{{Violations: &no}},
+				},
+				{
+					Name:       "unlabeled",
+					Object:     namespace(`{}`),
+					Assertions: []v1alpha1.PolicyTestAssertion{{Violations: &one, Message: "gatekeeper"}},
+				},
+				{
+					Name:       "wrong expectation",
+					Object:     namespace(`{}`),
+					Assertions: []v1alpha1.PolicyTestAssertion{{Violations: &no}},
+				},
+			},
+		},
+	}
+	c := fake.NewFakeClientWithScheme(scheme, test)
+	r := &ReconcilePolicyTest{client: c}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "required-labels"}}
+	get := func() *v1alpha1.PolicyTest {
+		got := &v1alpha1.PolicyTest{}
+		if err := c.Get(context.TODO(), req.NamespacedName, got); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatal(err)
+	}
+	got := get()
+	if got.Status.Passed || !strings.Contains(got.Status.Error, "not found") {
+		t.Errorf("status = %+v, wanted a missing template error", got.Status)
+	}
+
+	templ := demoTemplate(t)
+	templ.SetGeneration(3)
+	if err := c.Create(context.TODO(), templ); err != nil {
+		t.Fatal(err)
+	}
+	if reqs := r.testsOfTemplate(handler.MapObject{Meta: templ, Object: templ}); len(reqs) != 1 || reqs[0] != req {
+		t.Errorf("testsOfTemplate() = %v, wanted %v", reqs, req)
+	}
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatal(err)
+	}
+	got = get()
+	if got.Status.Error != "" {
+		t.Fatalf("unexpected error %s", got.Status.Error)
+	}
+	if got.Status.Passed {
+		t.Error("test passed despite a failing case")
+	}
+	if got.Status.ObservedGeneration != 2 || got.Status.TemplateGeneration != 3 {
+		t.Errorf("generations = %d, %d, wanted 2, 3", got.Status.ObservedGeneration, got.Status.TemplateGeneration)
+	}
+	want := map[string]bool{"labeled": true, "unlabeled": true, "wrong expectation": false}
+	if len(got.Status.Results) != len(want) {
+		t.Fatalf("got %d results, wanted %d", len(got.Status.Results), len(want))
+	}
+	for _, res := range got.Status.Results {
+		if res.Passed != want[res.Name] {
+			t.Errorf("case %s passed = %v, wanted %v: %s", res.Name, res.Passed, want[res.Name], res.Message)
+		}
+	}
+}
@@ -18,10 +18,13 @@ package sync
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
 	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/readiness"
+	"github.com/open-policy-agent/gatekeeper/pkg/redact"
 	"github.com/open-policy-agent/gatekeeper/pkg/watch"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -38,6 +41,10 @@ import (
 
 var log = logf.Log.WithName("controller").WithValues("metaKind", "Sync")
 
+// syncDeferral is how long data sync is put off while the existing constraints are
+// ingested at startup
+const syncDeferral = time.Second
+
 type Adder struct {
 	Opa *opa.Client
 }
@@ -52,12 +59,13 @@ func (a *Adder) Add(mgr manager.Manager, gvk schema.GroupVersionKind, cs *watch.
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager, gvk schema.GroupVersionKind, opa *opa.Client, cs *watch.ControllerSwitch) reconcile.Reconciler {
 	return &ReconcileSync{
-		Client: mgr.GetClient(),
-		cs:     cs,
-		scheme: mgr.GetScheme(),
-		opa:    opa,
-		log:    log.WithValues("kind", gvk.Kind, "apiVersion", gvk.GroupVersion().String()),
-		gvk:    gvk,
+		Client:  mgr.GetClient(),
+		cs:      cs,
+		scheme:  mgr.GetScheme(),
+		opa:     opa,
+		log:     log.WithValues("kind", gvk.Kind, "apiVersion", gvk.GroupVersion().String()),
+		gvk:     gvk,
+		tracker: readiness.Startup,
 	}
 }
 
@@ -90,6 +98,8 @@ type ReconcileSync struct {
 	opa    *opa.Client
 	gvk    schema.GroupVersionKind
 	log    logr.Logger
+	// tracker holds off data sync until the existing constraints are enforced
+	tracker *readiness.Tracker
 }
 
 // +kubebuilder:rbac:groups=constraints.gatekeeper.sh,resources=*,verbs=get;list;watch;create;update;patch;delete
@@ -103,6 +113,10 @@ func (r *ReconcileSync) Reconcile(request reconcile.Request) (reconcile.Result,
 		r.log.Info("ignoring request, sync controller disabled", "request", request)
 		return reconcile.Result{}, nil
 	}
+	if !r.tracker.ConstraintsIngested() {
+		// constraints are ingested first so enforcement resumes sooner after a restart
+		return reconcile.Result{RequeueAfter: syncDeferral}, nil
+	}
 	instance := &unstructured.Unstructured{}
 	instance.SetGroupVersionKind(r.gvk)
 	err := r.Get(context.TODO(), request.NamespacedName, instance)
@@ -121,7 +135,7 @@ func (r *ReconcileSync) Reconcile(request reconcile.Request) (reconcile.Result,
 	}
 	// For some reason 'Status' objects corresponding to rejection messages are being pushed
 	if instance.GroupVersionKind() != r.gvk {
-		r.log.Info("ignoring unexpected data", "data", instance)
+		r.log.Info("ignoring unexpected data", "data", redact.Unstructured(instance))
 		return reconcile.Result{}, nil
 	}
 
@@ -132,7 +146,7 @@ func (r *ReconcileSync) Reconcile(request reconcile.Request) (reconcile.Result,
 		return reconcile.Result{}, nil
 	}
 
-	r.log.V(logging.DebugLevel).Info("data will be added", "data", instance)
+	r.log.V(logging.DebugLevel).Info("data will be added", "data", redact.Unstructured(instance))
 	if _, err := r.opa.AddData(context.Background(), instance); err != nil {
 		return reconcile.Result{}, err
 	}
@@ -0,0 +1,429 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhookrules
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/watch"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	ctrlName    = "webhook-rules-controller"
+	webhookName = "validation.gatekeeper.sh"
+	// resyncPeriod is how often matched kinds the API server does not serve yet are rechecked
+	resyncPeriod = time.Minute
+)
+
+var (
+	log = logf.Log.WithName("controller").WithValues(logging.Process, "webhook_rules_controller")
+
+	dynamicRules = flag.Bool("dynamic-webhook-rules", false, "limit the validating webhook's rules to the kinds matched by constraints, so the API server does not call Gatekeeper for other kinds")
+
+	vwhGVK       = schema.GroupVersionKind{Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "ValidatingWebhookConfiguration"}
+	vwhKey       = types.NamespacedName{Name: "gatekeeper-validating-webhook-configuration"}
+	rulesRequest = reconcile.Request{NamespacedName: vwhKey}
+
+	defaultOperations = []admissionregistrationv1beta1.OperationType{admissionregistrationv1beta1.Create, admissionregistrationv1beta1.Update}
+	wildcardScope     = admissionregistrationv1beta1.AllScopes
+)
+
+type Adder struct {
+	WatchManager *watch.Manager
+}
+
+func (a *Adder) InjectOpa(o *opa.Client) {}
+
+func (a *Adder) InjectWatchManager(wm *watch.Manager) {
+	a.WatchManager = wm
+}
+
+// Add creates the webhook rules controller if --dynamic-webhook-rules is set
+func (a *Adder) Add(mgr manager.Manager) error {
+	if !*dynamicRules {
+		return nil
+	}
+	events := make(chan event.GenericEvent, 1024)
+	kinds := &kindAdder{events: events}
+	registrar, err := a.WatchManager.NewRegistrar(ctrlName, []watch.AddFunction{kinds.Add})
+	if err != nil {
+		return err
+	}
+	r := &ReconcileWebhookRules{client: mgr.GetClient(), mapper: mgr.GetRESTMapper(), watcher: registrar}
+	c, err := controller.New(ctrlName, mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Every change recomputes the rules as a whole
+	toRules := &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(
+		func(handler.MapObject) []reconcile.Request { return []reconcile.Request{rulesRequest} },
+	)}
+	if err := c.Watch(&source.Channel{Source: events}, toRules); err != nil {
+		return err
+	}
+	if err := c.Watch(&source.Kind{Type: &v1beta1.ConstraintTemplate{}}, toRules); err != nil {
+		return err
+	}
+	vwh := &unstructured.Unstructured{}
+	vwh.SetGroupVersionKind(vwhGVK)
+	return c.Watch(&source.Kind{Type: vwh}, toRules)
+}
+
+// kindAdder watches constraints of each kind registered with the watch manager and
+// forwards their events to the rules controller
+type kindAdder struct {
+	events chan<- event.GenericEvent
+}
+
+func (a *kindAdder) Add(mgr manager.Manager, gvk schema.GroupVersionKind, cs *watch.ControllerSwitch) error {
+	r := &forwarder{cs: cs, events: a.events}
+	c, err := controller.New(fmt.Sprintf("%s-webhook-rules-controller", gvk.String()), mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	instance := &unstructured.Unstructured{}
+	instance.SetGroupVersionKind(gvk)
+	return c.Watch(&source.Kind{Type: instance}, &handler.EnqueueRequestForObject{})
+}
+
+type forwarder struct {
+	cs     *watch.ControllerSwitch
+	events chan<- event.GenericEvent
+}
+
+func (f *forwarder) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	enabled := f.cs.Enter()
+	defer f.cs.Exit()
+	if !enabled {
+		return reconcile.Result{}, nil
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetName(request.Name)
+	select {
+	case f.events <- event.GenericEvent{Meta: obj, Object: obj}:
+	default:
+		// a recompute is already pending
+	}
+	return reconcile.Result{}, nil
+}
+
+var _ reconcile.Reconciler = &ReconcileWebhookRules{}
+
+// ReconcileWebhookRules keeps the validating webhook's rules to the kinds matched by
+// constraints
+type ReconcileWebhookRules struct {
+	client  client.Client
+	mapper  meta.RESTMapper
+	watcher *watch.Registrar
+}
+
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations,verbs=get;list;watch;update
+
+func (r *ReconcileWebhookRules) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx := context.TODO()
+	constraints, err := r.listConstraints(ctx)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	vwh := &unstructured.Unstructured{}
+	vwh.SetGroupVersionKind(vwhGVK)
+	if err := r.client.Get(ctx, vwhKey, vwh); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	webhooks, _, err := unstructured.NestedSlice(vwh.Object, "webhooks")
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	var unresolved bool
+	changed := false
+	for i, w := range webhooks {
+		wh, ok := w.(map[string]interface{})
+		if !ok || wh["name"] != webhookName {
+			continue
+		}
+		var current []admissionregistrationv1beta1.RuleWithOperations
+		if err := fromUnstructured(wh["rules"], &current); err != nil {
+			return reconcile.Result{}, err
+		}
+		var rules []admissionregistrationv1beta1.RuleWithOperations
+		rules, unresolved = r.rulesFor(constraints, current)
+		if equalRules(current, rules) {
+			break
+		}
+		out, err := toUnstructured(rules)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		wh["rules"] = out
+		webhooks[i] = wh
+		changed = true
+		break
+	}
+	if changed {
+		if err := unstructured.SetNestedSlice(vwh.Object, webhooks, "webhooks"); err != nil {
+			return reconcile.Result{}, err
+		}
+		log.Info("updating webhook rules", "webhook", webhookName)
+		if err := r.client.Update(ctx, vwh); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	if unresolved {
+		return reconcile.Result{RequeueAfter: resyncPeriod}, nil
+	}
+	return reconcile.Result{}, nil
+}
+
+// listConstraints returns every constraint and watches each constraint kind
+func (r *ReconcileWebhookRules) listConstraints(ctx context.Context) ([]unstructured.Unstructured, error) {
+	templs := &v1beta1.ConstraintTemplateList{}
+	if err := r.client.List(ctx, templs); err != nil {
+		return nil, err
+	}
+	var gvks []schema.GroupVersionKind
+	var constraints []unstructured.Unstructured
+	for _, templ := range templs.Items {
+		kind := templ.Spec.CRD.Spec.Names.Kind
+		if kind == "" {
+			continue
+		}
+		gvk := schema.GroupVersionKind{Group: "constraints.gatekeeper.sh", Version: "v1beta1", Kind: kind}
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(kind + "List"))
+		if err := r.client.List(ctx, list); err != nil {
+			// the CRD is not created yet; the template's status update will trigger a recompute
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, err
+		}
+		gvks = append(gvks, gvk)
+		constraints = append(constraints, list.Items...)
+	}
+	if r.watcher != nil {
+		if err := r.watcher.ReplaceWatch(gvks); err != nil {
+			return nil, err
+		}
+	}
+	return constraints, nil
+}
+
+// rulesFor returns the rules admitting every kind matched by constraints, and whether
+// any matched kind could not be mapped to a resource. Rules in current that only cover
+// DELETE or CONNECT, like the namespace deletion protection rule, are kept as they are.
+func (r *ReconcileWebhookRules) rulesFor(constraints []unstructured.Unstructured, current []admissionregistrationv1beta1.RuleWithOperations) ([]admissionregistrationv1beta1.RuleWithOperations, bool) {
+	var kept []admissionregistrationv1beta1.RuleWithOperations
+	opSet := make(map[admissionregistrationv1beta1.OperationType]bool)
+	for _, rule := range current {
+		if onlyDeleteOrConnect(rule.Operations) {
+			kept = append(kept, rule)
+			continue
+		}
+		for _, op := range rule.Operations {
+			opSet[op] = true
+		}
+	}
+	ops := defaultOperations
+	if len(opSet) > 0 {
+		ops = nil
+		for op := range opSet {
+			ops = append(ops, op)
+		}
+		sort.Slice(ops, func(i, j int) bool { return ops[i] < ops[j] })
+	}
+	newRule := func(group string, resources []string) admissionregistrationv1beta1.RuleWithOperations {
+		return admissionregistrationv1beta1.RuleWithOperations{
+			Operations: ops,
+			Rule: admissionregistrationv1beta1.Rule{
+				APIGroups:   []string{group},
+				APIVersions: []string{"*"},
+				Resources:   resources,
+				Scope:       &wildcardScope,
+			},
+		}
+	}
+
+	kinds, all := matchedKinds(constraints)
+	if all {
+		return append([]admissionregistrationv1beta1.RuleWithOperations{newRule("*", []string{"*"})}, kept...), false
+	}
+
+	// Gatekeeper validates its own resources through the webhook
+	resources := map[string]map[string]bool{
+		"constraints.gatekeeper.sh": {"*": true},
+		"templates.gatekeeper.sh":   {"constrainttemplates": true},
+	}
+	unresolved := false
+	for gk := range kinds {
+		mappings, err := r.mapper.RESTMappings(gk)
+		if err != nil || len(mappings) == 0 {
+			unresolved = true
+			continue
+		}
+		if resources[gk.Group] == nil {
+			resources[gk.Group] = make(map[string]bool)
+		}
+		for _, m := range mappings {
+			resources[gk.Group][m.Resource.Resource] = true
+		}
+		for _, sub := range subresourceKinds[gk] {
+			resources[gk.Group][sub] = true
+		}
+	}
+
+	var groups []string
+	for group := range resources {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	var rules []admissionregistrationv1beta1.RuleWithOperations
+	for _, group := range groups {
+		var names []string
+		for name := range resources[group] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		rules = append(rules, newRule(group, names))
+	}
+	return append(rules, kept...), unresolved
+}
+
+// subresourceKinds are the subresources whose requests are reviewed as objects of a kind.
+// Wildcard rules do not cover subresources, so they are only sent to the webhook for
+// constraints that name the kind.
+var subresourceKinds = map[schema.GroupKind][]string{
+	{Group: "", Kind: "Binding"}: {"pods/binding"},
+}
+
+// matchedKinds returns the kinds named in the constraints' spec.match.kinds, or true if
+// any constraint matches every kind
+func matchedKinds(constraints []unstructured.Unstructured) (map[schema.GroupKind]bool, bool) {
+	kinds := make(map[schema.GroupKind]bool)
+	for _, constraint := range constraints {
+		entries, _, err := unstructured.NestedSlice(constraint.Object, "spec", "match", "kinds")
+		if err != nil || len(entries) == 0 {
+			return nil, true
+		}
+		for _, e := range entries {
+			entry, ok := e.(map[string]interface{})
+			if !ok {
+				return nil, true
+			}
+			groups, _, _ := unstructured.NestedStringSlice(entry, "apiGroups")
+			kindNames, _, _ := unstructured.NestedStringSlice(entry, "kinds")
+			if len(groups) == 0 || len(kindNames) == 0 {
+				return nil, true
+			}
+			for _, group := range groups {
+				for _, kind := range kindNames {
+					if group == "*" || kind == "*" {
+						return nil, true
+					}
+					kinds[schema.GroupKind{Group: group, Kind: kind}] = true
+				}
+			}
+		}
+	}
+	return kinds, false
+}
+
+func onlyDeleteOrConnect(ops []admissionregistrationv1beta1.OperationType) bool {
+	if len(ops) == 0 {
+		return false
+	}
+	for _, op := range ops {
+		if op != admissionregistrationv1beta1.Delete && op != admissionregistrationv1beta1.Connect {
+			return false
+		}
+	}
+	return true
+}
+
+// equalRules compares the fields set by rulesFor; the API server defaults others
+func equalRules(a, b []admissionregistrationv1beta1.RuleWithOperations) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !reflect.DeepEqual(a[i].Operations, b[i].Operations) ||
+			!reflect.DeepEqual(a[i].APIGroups, b[i].APIGroups) ||
+			!reflect.DeepEqual(a[i].APIVersions, b[i].APIVersions) ||
+			!reflect.DeepEqual(a[i].Resources, b[i].Resources) {
+			return false
+		}
+	}
+	return true
+}
+
+func fromUnstructured(in interface{}, rules *[]admissionregistrationv1beta1.RuleWithOperations) error {
+	list, ok := in.([]interface{})
+	if !ok {
+		return nil
+	}
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		rule := admissionregistrationv1beta1.RuleWithOperations{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &rule); err != nil {
+			return err
+		}
+		*rules = append(*rules, rule)
+	}
+	return nil
+}
+
+func toUnstructured(rules []admissionregistrationv1beta1.RuleWithOperations) ([]interface{}, error) {
+	out := make([]interface{}, 0, len(rules))
+	for i := range rules {
+		m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&rules[i])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
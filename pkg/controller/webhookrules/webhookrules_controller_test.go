@@ -0,0 +1,174 @@
+package webhookrules
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"github.com/open-policy-agent/gatekeeper/api"
+	"github.com/open-policy-agent/gatekeeper/pkg/testutils"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newWebhookConfig() *admissionregistrationv1beta1.ValidatingWebhookConfiguration {
+	return &admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: vwhKey.Name},
+		Webhooks: []admissionregistrationv1beta1.ValidatingWebhook{{
+			Name: webhookName,
+			Rules: []admissionregistrationv1beta1.RuleWithOperations{
+				{
+					Operations: []admissionregistrationv1beta1.OperationType{admissionregistrationv1beta1.Create, admissionregistrationv1beta1.Update},
+					Rule:       admissionregistrationv1beta1.Rule{APIGroups: []string{"*"}, APIVersions: []string{"*"}, Resources: []string{"*"}},
+				},
+				{
+					Operations: []admissionregistrationv1beta1.OperationType{admissionregistrationv1beta1.Delete},
+					Rule:       admissionregistrationv1beta1.Rule{APIGroups: []string{""}, APIVersions: []string{"v1"}, Resources: []string{"namespaces"}},
+				},
+			},
+		}},
+	}
+}
+
+func TestReconcileWebhookRules(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	gvk := testutils.ConstraintGVK("K8sRequiredLabels")
+	scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}, {Group: "apps", Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Binding"}, meta.RESTScopeNamespace)
+
+	deleteRule := newWebhookConfig().Webhooks[0].Rules[1]
+	ops := []admissionregistrationv1beta1.OperationType{admissionregistrationv1beta1.Create, admissionregistrationv1beta1.Update}
+	rule := func(group string, resources ...string) admissionregistrationv1beta1.RuleWithOperations {
+		return admissionregistrationv1beta1.RuleWithOperations{
+			Operations: ops,
+			Rule:       admissionregistrationv1beta1.Rule{APIGroups: []string{group}, APIVersions: []string{"*"}, Resources: resources},
+		}
+	}
+
+	tc := []struct {
+		Name        string
+		Constraints []*unstructured.Unstructured
+		Expected    []admissionregistrationv1beta1.RuleWithOperations
+		Requeue     bool
+	}{
+		{
+			Name:     "No constraints",
+			Expected: []admissionregistrationv1beta1.RuleWithOperations{rule("constraints.gatekeeper.sh", "*"), rule("templates.gatekeeper.sh", "constrainttemplates"), deleteRule},
+		},
+		{
+			Name: "Matched kinds",
+			Constraints: []*unstructured.Unstructured{
+				testutils.NewConstraint(gvk.Kind, "ns-must-have-owner", testutils.WithMatchKinds([]string{""}, []string{"Namespace"})),
+				testutils.NewConstraint(gvk.Kind, "deployment-must-have-owner", testutils.WithMatchKinds([]string{"apps"}, []string{"Deployment"})),
+			},
+			Expected: []admissionregistrationv1beta1.RuleWithOperations{rule("", "namespaces"), rule("apps", "deployments"), rule("constraints.gatekeeper.sh", "*"), rule("templates.gatekeeper.sh", "constrainttemplates"), deleteRule},
+		},
+		{
+			Name: "Subresource kind",
+			Constraints: []*unstructured.Unstructured{
+				testutils.NewConstraint(gvk.Kind, "only-scheduler-binds", testutils.WithMatchKinds([]string{""}, []string{"Binding"})),
+			},
+			Expected: []admissionregistrationv1beta1.RuleWithOperations{rule("", "bindings", "pods/binding"), rule("constraints.gatekeeper.sh", "*"), rule("templates.gatekeeper.sh", "constrainttemplates"), deleteRule},
+		},
+		{
+			Name: "Unserved kind",
+			Constraints: []*unstructured.Unstructured{
+				testutils.NewConstraint(gvk.Kind, "widget-must-have-owner", testutils.WithMatchKinds([]string{"example.com"}, []string{"Widget"})),
+			},
+			Expected: []admissionregistrationv1beta1.RuleWithOperations{rule("constraints.gatekeeper.sh", "*"), rule("templates.gatekeeper.sh", "constrainttemplates"), deleteRule},
+			Requeue:  true,
+		},
+		{
+			Name: "Unrestricted constraint",
+			Constraints: []*unstructured.Unstructured{
+				testutils.NewConstraint(gvk.Kind, "ns-must-have-owner", testutils.WithMatchKinds([]string{""}, []string{"Namespace"})),
+				testutils.NewConstraint(gvk.Kind, "all-must-have-owner"),
+			},
+			Expected: []admissionregistrationv1beta1.RuleWithOperations{rule("*", "*"), deleteRule},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			templ := &v1beta1.ConstraintTemplate{ObjectMeta: metav1.ObjectMeta{Name: "k8srequiredlabels"}}
+			templ.Spec.CRD.Spec.Names.Kind = gvk.Kind
+			objs := []runtime.Object{templ, newWebhookConfig()}
+			for _, c := range tt.Constraints {
+				objs = append(objs, c)
+			}
+			c := fake.NewFakeClientWithScheme(scheme, objs...)
+			r := &ReconcileWebhookRules{client: c, mapper: mapper}
+			res, err := r.Reconcile(rulesRequest)
+			if err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			if got := res.RequeueAfter > 0; got != tt.Requeue {
+				t.Errorf("requeue = %v, want %v", got, tt.Requeue)
+			}
+
+			got := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{}
+			if err := c.Get(context.TODO(), vwhKey, got); err != nil {
+				t.Fatal(err)
+			}
+			rules := got.Webhooks[0].Rules
+			for i := range rules {
+				rules[i].Scope = nil
+			}
+			if !reflect.DeepEqual(rules, tt.Expected) {
+				t.Errorf("rules = %+v, want %+v", rules, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestMatchedKinds(t *testing.T) {
+	tc := []struct {
+		Name       string
+		Constraint *unstructured.Unstructured
+		All        bool
+	}{
+		{
+			Name:       "Kinds listed",
+			Constraint: testutils.NewConstraint("K8sRequiredLabels", "c", testutils.WithMatchKinds([]string{""}, []string{"Pod"})),
+		},
+		{
+			Name:       "No match",
+			Constraint: testutils.NewConstraint("K8sRequiredLabels", "c"),
+			All:        true,
+		},
+		{
+			Name:       "Wildcard group",
+			Constraint: testutils.NewConstraint("K8sRequiredLabels", "c", testutils.WithMatchKinds([]string{"*"}, []string{"Pod"})),
+			All:        true,
+		},
+		{
+			Name:       "Wildcard kind",
+			Constraint: testutils.NewConstraint("K8sRequiredLabels", "c", testutils.WithMatchKinds([]string{""}, []string{"*"})),
+			All:        true,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			if _, all := matchedKinds([]unstructured.Unstructured{*tt.Constraint}); all != tt.All {
+				t.Errorf("matchedKinds() all = %v, want %v", all, tt.All)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package driver
+
+import (
+	"context"
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/pkg/errors"
+)
+
+// OPA does not expose memory or instruction limits, but evaluation stops when its context
+// is done, so a deadline is what bounds a runaway policy.
+var regoEvalLimit = flag.Duration("rego-eval-limit", 0, "maximum time a single admission review may spend evaluating Rego before it is aborted, e.g. 3s. Audit queries are not limited. 0 disables the limit")
+
+// Wrap decorates d with query metrics and the behavior configured by flags
+func Wrap(d drivers.Driver) drivers.Driver {
+	if *regoEvalLimit > 0 {
+		d = WithEvalLimit(d, *regoEvalLimit)
+	}
+	// measured outside the limit, so aborted queries are counted as errors
+	return WithMetrics(d)
+}
+
+// WithEvalLimit aborts review queries that run longer than limit. Audit queries evaluate
+// every cached object in one pass and are left unbounded.
+func WithEvalLimit(d drivers.Driver, limit time.Duration) drivers.Driver {
+	return &limitedDriver{Driver: d, limit: limit}
+}
+
+type limitedDriver struct {
+	drivers.Driver
+	limit time.Duration
+}
+
+func (d *limitedDriver) Query(ctx context.Context, path string, input interface{}, opts ...drivers.QueryOpt) (*types.Response, error) {
+	if !strings.HasSuffix(path, ".violation") {
+		return d.Driver.Query(ctx, path, input, opts...)
+	}
+	ctx, cancel := context.WithTimeout(ctx, d.limit)
+	defer cancel()
+	resp, err := d.Driver.Query(ctx, path, input, opts...)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return nil, errors.Wrapf(err, "policy evaluation exceeded the %s limit", d.limit)
+	}
+	return resp, err
+}
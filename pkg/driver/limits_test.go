@@ -0,0 +1,52 @@
+package driver
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/local"
+)
+
+const slowModule = `package hooks
+
+violation[r] {
+  a := data.nums[_]
+  b := data.nums[_]
+  c := data.nums[_]
+  a + b + c == -1
+  r := "never"
+}
+
+audit[r] {
+  data.nums[_] == -1
+  r := "never"
+}
+`
+
+func TestWithEvalLimit(t *testing.T) {
+	ctx := context.Background()
+	d := WithEvalLimit(local.New(), 10*time.Millisecond)
+	if err := d.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutModule(ctx, "slow", slowModule); err != nil {
+		t.Fatal(err)
+	}
+	var nums []interface{}
+	for i := 0; i < 1000; i++ {
+		nums = append(nums, i)
+	}
+	if err := d.PutData(ctx, "/nums", nums); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := d.Query(ctx, "hooks.violation", nil)
+	if err == nil || !strings.Contains(err.Error(), "exceeded") {
+		t.Errorf("Query() error = %v, wanted the evaluation limit to be exceeded", err)
+	}
+	if _, err := d.Query(ctx, "hooks.audit", nil); err != nil {
+		t.Errorf("Query() error = %v, audit queries should not be limited", err)
+	}
+}
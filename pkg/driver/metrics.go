@@ -0,0 +1,101 @@
+package driver
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/metrics"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var log = logf.Log.WithName("driver")
+
+const (
+	queryCountMetricName    = "query_count"
+	queryDurationMetricName = "query_duration_seconds"
+)
+
+var (
+	queryDurationM = stats.Float64(queryDurationMetricName, "How long the policy engine took to evaluate a query in seconds", stats.UnitSeconds)
+
+	targetKey = tag.MustNewKey("target")
+	queryKey  = tag.MustNewKey("query")
+	statusKey = tag.MustNewKey("status")
+
+	views = []*view.View{
+		{
+			Name:        queryCountMetricName,
+			Measure:     queryDurationM,
+			Description: "The number of queries evaluated by the policy engine, by target and query",
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{targetKey, queryKey, statusKey},
+		},
+		{
+			Name:        queryDurationMetricName,
+			Measure:     queryDurationM,
+			Description: "The time the policy engine spent evaluating each query, by target and query",
+			Aggregation: view.Distribution(0.001, 0.002, 0.005, 0.01, 0.02, 0.05, 0.1, 0.2, 0.5, 1, 2, 5, 10, 30, 60),
+			TagKeys:     []tag.Key{targetKey, queryKey, statusKey},
+		},
+	}
+
+	// hookQuery matches the queries the constraint framework runs for each target, such
+	// as hooks["admission.k8s.gatekeeper.sh"].violation
+	hookQuery = regexp.MustCompile(`^hooks\["([^"]+)"\]\.(\w+)$`)
+)
+
+func init() {
+	if err := register(); err != nil {
+		panic(err)
+	}
+}
+
+func register() error {
+	return view.Register(views...)
+}
+
+// WithMetrics records the count and duration of the queries each target runs, such as
+// the admission target's review and audit queries, so their costs can be told apart
+func WithMetrics(d drivers.Driver) drivers.Driver {
+	return &measuredDriver{Driver: d}
+}
+
+type measuredDriver struct {
+	drivers.Driver
+}
+
+func (d *measuredDriver) Query(ctx context.Context, path string, input interface{}, opts ...drivers.QueryOpt) (*types.Response, error) {
+	m := hookQuery.FindStringSubmatch(path)
+	if m == nil {
+		return d.Driver.Query(ctx, path, input, opts...)
+	}
+	start := time.Now()
+	resp, err := d.Driver.Query(ctx, path, input, opts...)
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	if err := reportQuery(m[1], m[2], status, time.Since(start)); err != nil {
+		log.Error(err, "failed to report query metrics", "target", m[1], "query", m[2])
+	}
+	return resp, err
+}
+
+func reportQuery(target, query, status string, elapsed time.Duration) error {
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(targetKey, target),
+		tag.Insert(queryKey, query),
+		tag.Insert(statusKey, status),
+	)
+	if err != nil {
+		return err
+	}
+	return metrics.Record(ctx, queryDurationM.M(elapsed.Seconds()))
+}
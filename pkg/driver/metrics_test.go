@@ -0,0 +1,67 @@
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/local"
+	"go.opencensus.io/stats/view"
+)
+
+const targetModule = `package hooks.test
+
+violation[r] {
+  r := {"msg": "always"}
+}
+`
+
+// queryCount returns the number of queries recorded for target and query
+func queryCount(t *testing.T, target, query string) int64 {
+	t.Helper()
+	rows, err := view.RetrieveData(queryCountMetricName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	for _, row := range rows {
+		tags := make(map[string]string)
+		for _, tag := range row.Tags {
+			tags[tag.Key.Name()] = tag.Value
+		}
+		if tags["target"] == target && tags["query"] == query && tags["status"] == "success" {
+			count += row.Data.(*view.CountData).Value
+		}
+	}
+	return count
+}
+
+func TestWithMetrics(t *testing.T) {
+	ctx := context.Background()
+	d := WithMetrics(local.New())
+	if err := d.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutModule(ctx, "test", targetModule); err != nil {
+		t.Fatal(err)
+	}
+
+	before := queryCount(t, "test", "violation")
+	resp, err := d.Query(ctx, `hooks["test"].violation`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 1 {
+		t.Errorf("Query() returned %d results, wanted 1", len(resp.Results))
+	}
+	if got := queryCount(t, "test", "violation") - before; got != 1 {
+		t.Errorf("recorded %d queries for the test target, wanted 1", got)
+	}
+
+	// queries other than the targets' hooks are not recorded
+	if _, err := d.Query(ctx, "hooks.test.violation", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := queryCount(t, "test", "violation") - before; got != 1 {
+		t.Errorf("recorded %d queries for the test target, wanted 1", got)
+	}
+}
@@ -0,0 +1,126 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// queriesModule answers Gatekeeper's own questions about what the constraint framework
+// loaded. Each rule returns results the driver decodes as types.Result.
+const queriesModule = `package gatekeeper.queries
+
+# constraints are the constraints loaded for every target
+constraints[{"constraint": constraint}] {
+  constraint := data.constraints[_].cluster[_][_][_]
+}
+
+# matching are the constraints of input.target that select input.review
+matching[{"constraint": constraint}] {
+  target := input.target
+  review := input.review
+  data.hooks[target].library.matching_constraints[constraint] with input as {"review": review}
+}
+
+# violations are those of input.constraint alone, evaluated and returned as the violation
+# hook of input.target evaluates and returns those of each constraint
+violations[response] {
+  target := input.target
+  constraint := input.constraint
+  inp := {"review": input.review, "parameters": get_default(get_default(constraint, "spec", {}), "parameters", {})}
+  data.hooks[target].inventory[inv]
+  data.templates[target][constraint.kind].violation[r] with input as inp with data.inventory as inv
+  response := {
+    "msg": r.msg,
+    "metadata": {"details": get_default(r, "details", {})},
+    "constraint": constraint,
+    "review": input.review,
+    "enforcementAction": get_default(get_default(constraint, "spec", {}), "enforcementAction", "deny"),
+  }
+}
+
+has_field(object, field) = true {
+  object[field]
+}
+
+has_field(object, field) = true {
+  object[field] == false
+}
+
+get_default(object, field, _default) = output {
+  has_field(object, field)
+  output = object[field]
+}
+
+get_default(object, field, _default) = output {
+  not has_field(object, field)
+  output = _default
+}
+`
+
+const queriesModuleName = "gatekeeper_queries"
+
+// Queries evaluates what the constraint framework's client has no API for, such as the
+// constraints loaded into the policy engine, without dumping all of its data
+type Queries struct {
+	driver drivers.Driver
+}
+
+// NewQueries adds the module Queries evaluates to d, which must be the driver of the
+// constraint framework's client
+func NewQueries(ctx context.Context, d drivers.Driver) (*Queries, error) {
+	if err := d.PutModule(ctx, queriesModuleName, queriesModule); err != nil {
+		return nil, fmt.Errorf("adding the %s module: %v", queriesModuleName, err)
+	}
+	return &Queries{driver: d}, nil
+}
+
+// Constraints returns the constraints loaded into the policy engine, as they were added
+func (q *Queries) Constraints(ctx context.Context) ([]*unstructured.Unstructured, error) {
+	return q.constraints(ctx, "constraints", nil)
+}
+
+// Matching returns the constraints of target whose match criteria select review, the
+// input the target built for an object
+func (q *Queries) Matching(ctx context.Context, target string, review interface{}) ([]*unstructured.Unstructured, error) {
+	return q.constraints(ctx, "matching", map[string]interface{}{"target": target, "review": review})
+}
+
+// Evaluate evaluates constraint alone against review, returning the number of violations
+// or the error its template raised
+func (q *Queries) Evaluate(ctx context.Context, target string, review interface{}, constraint *unstructured.Unstructured) (int, error) {
+	results, err := q.Violations(ctx, target, review, constraint)
+	return len(results), err
+}
+
+// Violations evaluates constraint alone against review, returning its violations as the
+// client's review returns them, before the target handles them, or the error its template
+// raised
+func (q *Queries) Violations(ctx context.Context, target string, review interface{}, constraint *unstructured.Unstructured) ([]*types.Result, error) {
+	resp, err := q.driver.Query(ctx, "gatekeeper.queries.violations", map[string]interface{}{
+		"target":     target,
+		"review":     review,
+		"constraint": constraint.Object,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+func (q *Queries) constraints(ctx context.Context, rule string, input interface{}) ([]*unstructured.Unstructured, error) {
+	resp, err := q.driver.Query(ctx, "gatekeeper.queries."+rule, input)
+	if err != nil {
+		return nil, err
+	}
+	var constraints []*unstructured.Unstructured
+	for _, r := range resp.Results {
+		if r.Constraint != nil {
+			constraints = append(constraints, r.Constraint)
+		}
+	}
+	return constraints, nil
+}
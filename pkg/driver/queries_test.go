@@ -0,0 +1,146 @@
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/local"
+	"github.com/open-policy-agent/gatekeeper/pkg/engine"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const admissionTarget = "admission.k8s.gatekeeper.sh"
+
+const limitTemplate = `
+apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: k8sreplicalimit
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sReplicaLimit
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+        package k8sreplicalimit
+
+        violation[{"msg": "too many replicas"}] {
+          input.review.object.spec.replicas > to_number(input.parameters.max)
+        }
+`
+
+func decode(t *testing.T, s string) *unstructured.Unstructured {
+	t.Helper()
+	u := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal([]byte(s), &u.Object); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func limitConstraint(t *testing.T, name, max, kind string) *unstructured.Unstructured {
+	t.Helper()
+	return decode(t, `
+apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sReplicaLimit
+metadata:
+  name: `+name+`
+spec:
+  match:
+    kinds:
+      - apiGroups: ["apps"]
+        kinds: ["`+kind+`"]
+  parameters:
+    max: "`+max+`"
+`)
+}
+
+func TestQueries(t *testing.T) {
+	ctx := context.Background()
+	d := local.New()
+	e, err := engine.New(engine.Driver(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := NewQueries(ctx, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.LoadTemplate(ctx, decode(t, limitTemplate)); err != nil {
+		t.Fatal(err)
+	}
+	constraints := []*unstructured.Unstructured{
+		limitConstraint(t, "three", "3", "Deployment"),
+		limitConstraint(t, "broken", "three", "Deployment"),
+		limitConstraint(t, "statefulsets", "three", "StatefulSet"),
+	}
+	for _, c := range constraints {
+		if err := e.LoadConstraint(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	loaded, err := q.Constraints(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != len(constraints) {
+		t.Errorf("Constraints() returned %d constraints, wanted %d", len(loaded), len(constraints))
+	}
+
+	deploy := decode(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 5
+`)
+	if _, err := e.Review(ctx, deploy); err == nil {
+		t.Fatal("wanted the broken constraint to fail the review")
+	}
+
+	review, err := engine.ToReview(deploy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the input the admission target builds for the review
+	_, input, err := (&target.K8sValidationTarget{}).HandleReview(review)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matching, err := q.Matching(ctx, admissionTarget, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matching) != 2 {
+		t.Fatalf("Matching() returned %v, wanted the constraints on Deployments", matching)
+	}
+	for _, c := range matching {
+		n, err := q.Evaluate(ctx, admissionTarget, input, c)
+		switch c.GetName() {
+		case "three":
+			if err != nil || n != 1 {
+				t.Errorf("Evaluate(three) = %d, %v; wanted 1 violation", n, err)
+			}
+			results, err := q.Violations(ctx, admissionTarget, input, c)
+			if err != nil || len(results) != 1 {
+				t.Fatalf("Violations(three) = %v, %v; wanted 1 violation", results, err)
+			}
+			r := results[0]
+			if r.Msg != "too many replicas" || r.EnforcementAction != "deny" || r.Constraint.GetName() != "three" || r.Review == nil {
+				t.Errorf("Violations(three) = %+v, wanted the result the review returns", r)
+			}
+		case "broken":
+			if err == nil {
+				t.Error("Evaluate(broken) succeeded, wanted its template's error")
+			}
+		default:
+			t.Errorf("Matching() returned %s, which does not match Deployments", c.GetName())
+		}
+	}
+}
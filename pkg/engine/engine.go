@@ -0,0 +1,240 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package engine evaluates objects against ConstraintTemplates and constraints the same
+// way the Gatekeeper webhook does, so CI tools and other controllers can embed it.
+package engine
+
+import (
+	"context"
+	"sync"
+
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/local"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/api"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	csutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
+	"github.com/open-policy-agent/gatekeeper/pkg/util/regoutil"
+	"github.com/pkg/errors"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	if err := api.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+}
+
+// NewClient returns an OPA client that evaluates Gatekeeper's admission target using
+// driver. A nil driver evaluates Rego in process without tracing.
+func NewClient(driver drivers.Driver) (*opa.Client, error) {
+	if driver == nil {
+		driver = local.New(local.Tracing(false))
+	}
+	backend, err := opa.NewBackend(opa.Driver(driver))
+	if err != nil {
+		return nil, errors.Wrap(err, "while setting up the OPA backend")
+	}
+	client, err := backend.NewClient(opa.Targets(&target.K8sValidationTarget{}))
+	if err != nil {
+		return nil, errors.Wrap(err, "while setting up the OPA client")
+	}
+	return client, nil
+}
+
+// Engine holds the templates and constraints objects are reviewed against
+type Engine struct {
+	client *opa.Client
+
+	mux sync.RWMutex
+	// schemas are the parameter schemas of the loaded templates, by constraint kind. The
+	// framework is given templates without their defaults, so constraints are defaulted
+	// from these as the constraint controller does.
+	schemas map[string]*apiextensionsv1beta1.JSONSchemaProps
+}
+
+type options struct {
+	driver drivers.Driver
+}
+
+// Option configures an Engine
+type Option func(*options)
+
+// Driver sets the driver that evaluates Rego, for instance one wrapped to limit
+// evaluation time
+func Driver(d drivers.Driver) Option {
+	return func(o *options) {
+		o.driver = d
+	}
+}
+
+// New returns an Engine with no templates or constraints
+func New(opts ...Option) (*Engine, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	client, err := NewClient(o.driver)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{client: client, schemas: make(map[string]*apiextensionsv1beta1.JSONSchemaProps)}, nil
+}
+
+// Client returns the OPA client behind e, for callers that need the rest of its API
+func (e *Engine) Client() *opa.Client {
+	return e.client
+}
+
+// LoadTemplate compiles a v1beta1 ConstraintTemplate, such as one read from YAML. Loading
+// a template again replaces it.
+func (e *Engine) LoadTemplate(ctx context.Context, u *unstructured.Unstructured) error {
+	versioned, err := versionedTemplate(u)
+	if err != nil {
+		return err
+	}
+	templ, err := toTemplate(versioned)
+	if err != nil {
+		return err
+	}
+	if _, err := e.client.AddTemplate(ctx, templ); err != nil {
+		return errors.Wrapf(err, "while adding template %s", u.GetName())
+	}
+	var schema *apiextensionsv1beta1.JSONSchemaProps
+	if versioned.Spec.CRD.Spec.Validation != nil {
+		schema = versioned.Spec.CRD.Spec.Validation.OpenAPIV3Schema
+	}
+	e.mux.Lock()
+	e.schemas[templ.Spec.CRD.Spec.Names.Kind] = schema
+	e.mux.Unlock()
+	return nil
+}
+
+// LoadConstraint adds a constraint, with the parameter defaults its template declares
+// applied as the API server would. Its template must be loaded first. u is not modified.
+func (e *Engine) LoadConstraint(ctx context.Context, u *unstructured.Unstructured) error {
+	e.mux.RLock()
+	schema := e.schemas[u.GetKind()]
+	e.mux.RUnlock()
+	if schema != nil {
+		u = u.DeepCopy()
+		if _, err := csutil.ApplyParameterDefaults(u, schema); err != nil {
+			return errors.Wrapf(err, "while defaulting the parameters of constraint %s %s", u.GetKind(), u.GetName())
+		}
+	}
+	if _, err := e.client.AddConstraint(ctx, u); err != nil {
+		return errors.Wrapf(err, "while adding constraint %s %s", u.GetKind(), u.GetName())
+	}
+	return nil
+}
+
+type reviewOptions struct {
+	namespace *corev1.Namespace
+	operation admissionv1beta1.Operation
+}
+
+// ReviewOption configures a single review
+type ReviewOption func(*reviewOptions)
+
+// Namespace sets the namespace of the reviewed object, which constraints with a
+// namespaceSelector match against. Without it those constraints reject namespaced
+// objects, as the webhook does when a namespace is not cached.
+func Namespace(ns *corev1.Namespace) ReviewOption {
+	return func(o *reviewOptions) {
+		o.namespace = ns
+	}
+}
+
+// Operation sets the admission operation of the review, which constraints that match
+// operations match against. Reviews are of CREATE requests by default.
+func Operation(op admissionv1beta1.Operation) ReviewOption {
+	return func(o *reviewOptions) {
+		o.operation = op
+	}
+}
+
+// Review returns the violations obj would be denied or warned for if it were created
+func (e *Engine) Review(ctx context.Context, obj *unstructured.Unstructured, opts ...ReviewOption) ([]*types.Result, error) {
+	o := &reviewOptions{operation: admissionv1beta1.Create}
+	for _, opt := range opts {
+		opt(o)
+	}
+	review, err := ToReview(obj)
+	if err != nil {
+		return nil, err
+	}
+	review.Namespace = o.namespace
+	review.AdmissionRequest.Operation = o.operation
+	resp, err := e.client.Review(ctx, review)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while reviewing %s %s", obj.GetKind(), obj.GetName())
+	}
+	return resp.Results(), nil
+}
+
+// ToTemplate converts a v1beta1 ConstraintTemplate read from YAML into the version the
+// constraint framework loads, adding the libraries Gatekeeper provides
+func ToTemplate(u *unstructured.Unstructured) (*templates.ConstraintTemplate, error) {
+	versioned, err := versionedTemplate(u)
+	if err != nil {
+		return nil, err
+	}
+	return toTemplate(versioned)
+}
+
+func versionedTemplate(u *unstructured.Unstructured) (*templv1beta1.ConstraintTemplate, error) {
+	versioned := &templv1beta1.ConstraintTemplate{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, versioned); err != nil {
+		return nil, errors.Wrapf(err, "while reading template %s", u.GetName())
+	}
+	return versioned, nil
+}
+
+func toTemplate(versioned *templv1beta1.ConstraintTemplate) (*templates.ConstraintTemplate, error) {
+	templ := &templates.ConstraintTemplate{}
+	if err := scheme.Convert(versioned, templ, nil); err != nil {
+		return nil, errors.Wrapf(err, "while converting template %s", versioned.GetName())
+	}
+	regoutil.AddLibs(templ)
+	csutil.StripSchemaDefaults(templ)
+	return templ, nil
+}
+
+// ToReview wraps obj in the admission request the API server would send to create it
+func ToReview(obj *unstructured.Unstructured) (*target.AugmentedReview, error) {
+	raw, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	gvk := obj.GroupVersionKind()
+	return &target.AugmentedReview{AdmissionRequest: &admissionv1beta1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+		Operation: admissionv1beta1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}}, nil
+}
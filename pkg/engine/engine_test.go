@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const templateYAML = `
+apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: k8srequiredlabels
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sRequiredLabels
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+        package k8srequiredlabels
+
+        violation[{"msg": msg}] {
+          not input.review.object.metadata.labels.owner
+          msg := "missing owner"
+        }
+`
+
+const constraintYAML = `
+apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sRequiredLabels
+metadata:
+  name: must-have-owner
+spec:
+  match:
+    namespaceSelector:
+      matchLabels:
+        team: apps
+`
+
+func decode(t *testing.T, s string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal([]byte(s), &u.Object); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestEngine(t *testing.T) {
+	ctx := context.Background()
+	e, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.LoadConstraint(ctx, decode(t, constraintYAML)); err == nil {
+		t.Error("loaded a constraint before its template")
+	}
+	if err := e.LoadTemplate(ctx, decode(t, templateYAML)); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.LoadConstraint(ctx, decode(t, constraintYAML)); err != nil {
+		t.Fatal(err)
+	}
+
+	cm := &unstructured.Unstructured{}
+	cm.SetAPIVersion("v1")
+	cm.SetKind("ConfigMap")
+	cm.SetNamespace("apps")
+	cm.SetName("settings")
+	apps := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps", Labels: map[string]string{"team": "apps"}}}
+	other := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps", Labels: map[string]string{"team": "other"}}}
+
+	tc := []struct {
+		Name       string
+		Opts       []ReviewOption
+		Violations int
+		Msg        string
+	}{
+		{Name: "Without namespace", Violations: 1, Msg: "Namespace is not cached in OPA."},
+		{Name: "Matching namespace", Opts: []ReviewOption{Namespace(apps)}, Violations: 1, Msg: "missing owner"},
+		{Name: "Other namespace", Opts: []ReviewOption{Namespace(other)}, Violations: 0},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			results, err := e.Review(ctx, cm, tt.Opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != tt.Violations {
+				t.Fatalf("got %d violations, wanted %d: %v", len(results), tt.Violations, results)
+			}
+			if tt.Violations > 0 && results[0].Msg != tt.Msg {
+				t.Errorf("violation message = %q, wanted %q", results[0].Msg, tt.Msg)
+			}
+		})
+	}
+}
+
+const defaultedTemplateYAML = `
+apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: k8sreplicalimit
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sReplicaLimit
+      validation:
+        openAPIV3Schema:
+          properties:
+            max:
+              type: integer
+              default: 3
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+        package k8sreplicalimit
+
+        violation[{"msg": msg}] {
+          input.review.object.spec.replicas > input.parameters.max
+          msg := sprintf("more than %v replicas", [input.parameters.max])
+        }
+`
+
+func TestLoadConstraintDefaults(t *testing.T) {
+	ctx := context.Background()
+	e, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.LoadTemplate(ctx, decode(t, defaultedTemplateYAML)); err != nil {
+		t.Fatal(err)
+	}
+	constraint := decode(t, `
+apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sReplicaLimit
+metadata:
+  name: replica-limit
+`)
+	if err := e.LoadConstraint(ctx, constraint); err != nil {
+		t.Fatal(err)
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(constraint.Object, "spec", "parameters"); found {
+		t.Error("LoadConstraint() modified the constraint it was given")
+	}
+
+	deploy := decode(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 5
+`)
+	results, err := e.Review(ctx, deploy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Msg != "more than 3 replicas" {
+		t.Errorf("got violations %v, wanted one for the default limit", results)
+	}
+}
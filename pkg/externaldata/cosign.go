@@ -0,0 +1,219 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externaldata
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// CosignProvider is the name of the built-in provider that verifies cosign signatures
+	CosignProvider = "cosign"
+
+	// cosignSignatureAnnotation holds the signature of a layer of a cosign signature manifest
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+)
+
+var cosignPublicKeys = flag.String("cosign-public-keys", "", "comma-separated paths of PEM-encoded ECDSA public keys. If set, external_data verifies the cosign signatures of images with provider "+CosignProvider+". An image is verified if any key signed its digest")
+
+// verifiedSignatures holds the images whose signatures were verified, by repository and
+// digest, until --external-data-cache-ttl passes. It is kept apart from responseCache, whose
+// keys are the images templates look up.
+var verifiedSignatures = &cache{entries: make(map[string]cacheEntry)}
+
+// cosignVerifier verifies that images were signed with cosign by one of its keys. Only
+// signatures made with keys are verified; keyless signatures need a transparency log.
+type cosignVerifier struct {
+	keys     []*ecdsa.PublicKey
+	registry *registryClient
+}
+
+func setupCosign() error {
+	if *cosignPublicKeys == "" {
+		return nil
+	}
+	var keys []*ecdsa.PublicKey
+	for _, path := range strings.Split(*cosignPublicKeys, ",") {
+		path = strings.TrimSpace(path)
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading --cosign-public-keys: %v", err)
+		}
+		key, err := parseECDSAPublicKey(b)
+		if err != nil {
+			return fmt.Errorf("invalid --cosign-public-keys key %s: %v", path, err)
+		}
+		keys = append(keys, key)
+	}
+	Register(CosignProvider, &cosignVerifier{
+		keys:     keys,
+		registry: newRegistryClient(&http.Client{Timeout: 10 * time.Second}),
+	})
+	log.Info("cosign provider enabled", "keys", len(keys))
+	return nil
+}
+
+func parseECDSAPublicKey(b []byte) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("key is a %T, not an ECDSA key", pub)
+	}
+	return key, nil
+}
+
+// Lookup verifies each image, keyed by its reference. The value of a verified image holds
+// its digest. Images referenced by tag are resolved on each lookup, and their verification
+// is cached by the digest they resolve to.
+func (v *cosignVerifier) Lookup(ctx context.Context, images []string) (map[string]interface{}, map[string]string, error) {
+	responses := make(map[string]interface{})
+	errs := make(map[string]string)
+	for _, image := range images {
+		digest, err := v.verify(ctx, image)
+		if err != nil {
+			if ctx.Err() != nil {
+				return responses, errs, ctx.Err()
+			}
+			errs[image] = err.Error()
+			continue
+		}
+		responses[image] = map[string]interface{}{"verified": true, "digest": digest}
+	}
+	return responses, errs, nil
+}
+
+// Cacheable returns whether image is referenced by digest
+func (v *cosignVerifier) Cacheable(image string) bool {
+	return isDigestRef(image)
+}
+
+// simpleSigning is the part of a cosign signature payload that names the signed image
+type simpleSigning struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+type ociManifest struct {
+	Layers []struct {
+		Digest      string            `json:"digest"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"layers"`
+}
+
+// verify returns the digest of image if one of its cosign signatures was made by one of
+// the keys
+func (v *cosignVerifier) verify(ctx context.Context, image string) (string, error) {
+	ref, err := parseImageRef(image)
+	if err != nil {
+		return "", err
+	}
+	digest, err := v.registry.resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %v", image, err)
+	}
+	verified := ref.name() + "@" + digest
+	now := time.Now()
+	if _, ok := verifiedSignatures.get(CosignProvider, verified, now); ok {
+		return digest, nil
+	}
+	// cosign stores signatures under a tag named after the digest
+	sigTag := strings.Replace(digest, ":", "-", 1) + ".sig"
+	body, _, err := v.registry.manifest(ctx, ref, sigTag)
+	if err == errNotFound {
+		return "", fmt.Errorf("%s has no cosign signatures", image)
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading signatures of %s: %v", image, err)
+	}
+	var m ociManifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return "", fmt.Errorf("invalid signature manifest of %s: %v", image, err)
+	}
+	for _, layer := range m.Layers {
+		sig, ok := layer.Annotations[cosignSignatureAnnotation]
+		if !ok {
+			continue
+		}
+		payload, err := v.registry.blob(ctx, ref, layer.Digest)
+		if err != nil {
+			log.Error(err, "could not read cosign signature payload", "image", image)
+			continue
+		}
+		if v.verifyPayload(payload, sig, ref, digest) {
+			verifiedSignatures.put(CosignProvider, verified, true, now)
+			return digest, nil
+		}
+	}
+	return "", fmt.Errorf("no cosign signature of %s was made by the configured keys", image)
+}
+
+// verifyPayload returns whether sig, base64-encoded, is a signature of payload by one of
+// the keys, and whether payload names digest in the repository of ref. A signature copied
+// from another repository does not verify, even if it signed the same digest.
+func (v *cosignVerifier) verifyPayload(payload []byte, sig string, ref imageRef, digest string) bool {
+	var ss simpleSigning
+	if err := json.Unmarshal(payload, &ss); err != nil || ss.Critical.Image.DockerManifestDigest != digest {
+		return false
+	}
+	signed, err := parseImageRef(ss.Critical.Identity.DockerReference)
+	if err != nil || signed.name() != ref.name() {
+		return false
+	}
+	raw, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	var rs struct {
+		R, S *big.Int
+	}
+	if rest, err := asn1.Unmarshal(raw, &rs); err != nil || len(rest) > 0 {
+		return false
+	}
+	sum := sha256.Sum256(payload)
+	for _, key := range v.keys {
+		if ecdsa.Verify(key, sum[:], rs.R, rs.S) {
+			return true
+		}
+	}
+	return false
+}
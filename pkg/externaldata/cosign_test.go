@@ -0,0 +1,287 @@
+package externaldata
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRegistry serves manifests and blobs of one repository, behind token authentication
+type fakeRegistry struct {
+	host      string
+	manifests map[string][]byte
+	blobs     map[string][]byte
+	// digests override the digest the registry reports for a manifest
+	digests map[string]string
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		fmt.Fprint(w, `{"token": "secret"}`)
+		return
+	}
+	if req.Header.Get("Authorization") != "Bearer secret" {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="https://%s/token",service="fake",scope="repository:app:pull"`, r.host))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case strings.HasPrefix(req.URL.Path, "/v2/app/manifests/"):
+		reference := strings.TrimPrefix(req.URL.Path, "/v2/app/manifests/")
+		body, ok := r.manifests[reference]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		digest := sha256Digest(body)
+		if d, ok := r.digests[reference]; ok {
+			digest = d
+		}
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.Write(body)
+	case strings.HasPrefix(req.URL.Path, "/v2/app/blobs/"):
+		body, ok := r.blobs[strings.TrimPrefix(req.URL.Path, "/v2/app/blobs/")]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write(body)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+// sign adds a cosign signature of the manifest tagged tag, made with key over the payload
+// naming signedDigest in the repository signedRepo
+func (r *fakeRegistry) sign(t *testing.T, tag string, key *ecdsa.PrivateKey, signedRepo, signedDigest string) {
+	digest := sha256Digest(r.manifests[tag])
+	payload := []byte(fmt.Sprintf(`{"critical": {"identity": {"docker-reference": "%s"}, "image": {"docker-manifest-digest": "%s"}, "type": "cosign container image signature"}, "optional": null}`, signedRepo, signedDigest))
+	sum := sha256.Sum256(payload)
+	sr, ss, err := ecdsa.Sign(rand.Reader, key, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := asn1.Marshal(struct{ R, S *big.Int }{sr, ss})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.blobs[sha256Digest(payload)] = payload
+	manifest, err := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"layers": []interface{}{map[string]interface{}{
+			"mediaType":   "application/vnd.dev.cosign.simplesigning.v1+json",
+			"digest":      sha256Digest(payload),
+			"annotations": map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(sig)},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.manifests[strings.Replace(digest, ":", "-", 1)+".sig"] = manifest
+}
+
+func TestCosignVerify(t *testing.T) {
+	responseCache = &cache{entries: make(map[string]cacheEntry)}
+	verifiedSignatures = &cache{entries: make(map[string]cacheEntry)}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := &fakeRegistry{
+		manifests: map[string][]byte{
+			"signed":   []byte(`{"schemaVersion": 2, "layers": [{"digest": "sha256:1"}]}`),
+			"other":    []byte(`{"schemaVersion": 2, "layers": [{"digest": "sha256:2"}]}`),
+			"tampered": []byte(`{"schemaVersion": 2, "layers": [{"digest": "sha256:3"}]}`),
+			"unsigned": []byte(`{"schemaVersion": 2, "layers": [{"digest": "sha256:4"}]}`),
+			"moved":    []byte(`{"schemaVersion": 2, "layers": [{"digest": "sha256:5"}]}`),
+		},
+		blobs: make(map[string][]byte),
+	}
+	srv := httptest.NewTLSServer(reg)
+	defer srv.Close()
+	reg.host = strings.TrimPrefix(srv.URL, "https://")
+	repo := reg.host + "/app"
+	reg.sign(t, "signed", key, repo, sha256Digest(reg.manifests["signed"]))
+	reg.sign(t, "other", other, repo, sha256Digest(reg.manifests["other"]))
+	// a signature of another image copied onto this one
+	reg.sign(t, "tampered", key, repo, sha256Digest(reg.manifests["signed"]))
+	// a signature of this image made for another repository
+	reg.sign(t, "moved", key, "registry.example.com/app", sha256Digest(reg.manifests["moved"]))
+
+	v := &cosignVerifier{keys: []*ecdsa.PublicKey{&key.PublicKey}, registry: newRegistryClient(srv.Client())}
+	signedDigest := sha256Digest(reg.manifests["signed"])
+	images := []string{
+		reg.host + "/app:signed",
+		reg.host + "/app@" + signedDigest,
+		reg.host + "/app:other",
+		reg.host + "/app:tampered",
+		reg.host + "/app:unsigned",
+		reg.host + "/app:moved",
+		reg.host + "/app:missing",
+	}
+	responses, errs, err := v.Lookup(context.Background(), images)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, image := range images[:2] {
+		want := map[string]interface{}{"verified": true, "digest": signedDigest}
+		if got, ok := responses[image].(map[string]interface{}); !ok || got["digest"] != want["digest"] {
+			t.Errorf("%s: got %v, wanted %v (error %q)", image, responses[image], want, errs[image])
+		}
+	}
+	for _, image := range images[2:] {
+		if _, ok := responses[image]; ok {
+			t.Errorf("%s should not be verified", image)
+		}
+		if errs[image] == "" {
+			t.Errorf("%s: wanted an error", image)
+		}
+	}
+
+	// the verified signature is not mistaken for the response to its image
+	Register(CosignProvider, v)
+	responses, _, err = lookup(context.Background(), request{Provider: CosignProvider, Keys: images[1:2]}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := responses[images[1]].(map[string]interface{}); !ok || got["digest"] != signedDigest {
+		t.Errorf("%s: got %v from external_data, wanted its digest", images[1], responses[images[1]])
+	}
+}
+
+func TestBlobDigest(t *testing.T) {
+	c := newRegistryClient(http.DefaultClient)
+	for _, digest := range []string{"sha256:1", "sha256:" + strings.Repeat("ab", 32) + "/../../x"} {
+		if _, err := c.blob(context.Background(), imageRef{host: "registry.example.com", repo: "app"}, digest); err == nil || !strings.Contains(err.Error(), "unsupported blob digest") {
+			t.Errorf("blob(%s) = %v, wanted an unsupported digest error", digest, err)
+		}
+	}
+}
+
+func TestTokenRealm(t *testing.T) {
+	c := newRegistryClient(http.DefaultClient)
+	for _, realm := range []string{
+		"http://auth.docker.io/token",
+		"https://kubernetes.default.svc/api",
+		"https://169.254.169.254/latest/meta-data",
+		"https://docker.io.example.com/token",
+	} {
+		_, err := c.fetchToken(context.Background(), dockerHubHost, fmt.Sprintf(`Bearer realm="%s",service="registry.docker.io"`, realm))
+		if err == nil || !strings.Contains(err.Error(), "not an https URL on the domain") {
+			t.Errorf("realm %s: got %v, wanted it refused", realm, err)
+		}
+	}
+	for _, tc := range []struct {
+		a, b string
+		want bool
+	}{
+		{a: "registry-1.docker.io", b: "auth.docker.io", want: true},
+		{a: "ghcr.io", b: "ghcr.io:443", want: true},
+		{a: "localhost:5000", b: "localhost:5001", want: true},
+		{a: "127.0.0.1:5000", b: "127.0.0.1", want: true},
+		{a: "quay.io", b: "evil.io", want: false},
+		{a: "10.0.0.1", b: "10.0.0.2", want: false},
+		{a: "localhost", b: "metadata", want: false},
+	} {
+		if got := sameDomain(tc.a, tc.b); got != tc.want {
+			t.Errorf("sameDomain(%s, %s) = %v, wanted %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestParseECDSAPublicKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := parseECDSAPublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.X.Cmp(key.X) != 0 || got.Y.Cmp(key.Y) != 0 {
+		t.Error("parsed key does not match")
+	}
+	if _, err := parseECDSAPublicKey([]byte("not a key")); err == nil {
+		t.Error("wanted an error for data that is not PEM")
+	}
+}
+
+func TestParseImageRef(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("ab", 32)
+	tcs := []struct {
+		image string
+		want  imageRef
+	}{
+		{image: "nginx", want: imageRef{host: dockerHubHost, repo: "library/nginx", tag: "latest"}},
+		{image: "docker.io/org/app:1.0", want: imageRef{host: dockerHubHost, repo: "org/app", tag: "1.0"}},
+		{image: "localhost:5000/app@" + digest, want: imageRef{host: "localhost:5000", repo: "app", digest: digest}},
+		{image: "gcr.io/org/app:v1@" + digest, want: imageRef{host: "gcr.io", repo: "org/app", tag: "v1", digest: digest}},
+	}
+	for _, tc := range tcs {
+		got, err := parseImageRef(tc.image)
+		if err != nil {
+			t.Errorf("%s: %v", tc.image, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %+v, wanted %+v", tc.image, got, tc.want)
+		}
+	}
+	for _, image := range []string{"app@md5:abc", "app@sha256:abc", "app@sha256:" + strings.Repeat("AB", 32), "app@" + digest + "/../../x"} {
+		if _, err := parseImageRef(image); err == nil {
+			t.Errorf("%s: wanted an error for an invalid digest", image)
+		}
+	}
+}
+
+func TestManifestDigest(t *testing.T) {
+	reg := &fakeRegistry{
+		manifests: map[string][]byte{
+			"v1":   []byte(`{"schemaVersion": 2, "layers": [{"digest": "sha256:1"}]}`),
+			"v2":   []byte(`{"schemaVersion": 2, "layers": [{"digest": "sha256:2"}]}`),
+			"lies": []byte(`{"schemaVersion": 2, "layers": [{"digest": "sha256:3"}]}`),
+		},
+		blobs: make(map[string][]byte),
+	}
+	v1 := sha256Digest(reg.manifests["v1"])
+	// the registry serves another manifest under the digest of v1
+	reg.manifests[v1] = reg.manifests["v2"]
+	reg.digests = map[string]string{"lies": v1, v1: v1}
+	srv := httptest.NewTLSServer(reg)
+	defer srv.Close()
+	reg.host = strings.TrimPrefix(srv.URL, "https://")
+	c := newRegistryClient(srv.Client())
+	ref := imageRef{host: reg.host, repo: "app"}
+
+	_, digest, err := c.manifest(context.Background(), ref, "v1")
+	if err != nil || digest != v1 {
+		t.Errorf("manifest(v1) = %s, %v; wanted %s", digest, err, v1)
+	}
+	for _, reference := range []string{"lies", v1} {
+		if _, _, err := c.manifest(context.Background(), ref, reference); err == nil {
+			t.Errorf("manifest(%s): wanted an error for a manifest that does not match its digest", reference)
+		}
+	}
+}
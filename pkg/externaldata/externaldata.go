@@ -0,0 +1,243 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package externaldata implements the external_data Rego builtin, enabled by the
+// ExternalData feature gate. It lets templates look keys up in providers that run in the
+// Gatekeeper process, such as the cosign image signature verifier and the vulnerability
+// report reader.
+package externaldata
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/topdown"
+	"github.com/open-policy-agent/opa/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Builtin is the name of the Rego function that queries a provider
+const Builtin = "external_data"
+
+// maxCacheEntries bounds the number of cached responses of all providers
+const maxCacheEntries = 10000
+
+var log = logf.Log.WithName("external-data")
+
+var (
+	cacheTTL = flag.Duration("external-data-cache-ttl", 5*time.Minute, "how long the responses of external data providers are cached. 0 disables caching")
+	timeout  = flag.Duration("external-data-timeout", 3*time.Second, "maximum time an external_data call waits for its provider")
+)
+
+// Provider looks up keys for external_data
+type Provider interface {
+	// Lookup returns the value of each key it found and the error of each key it could
+	// not look up. An error fails the whole call.
+	Lookup(ctx context.Context, keys []string) (map[string]interface{}, map[string]string, error)
+}
+
+// keyCacher is implemented by providers whose answers for some keys may change before
+// --external-data-cache-ttl passes, such as images referenced by tag. Only the keys it
+// calls cacheable are cached.
+type keyCacher interface {
+	Cacheable(key string) bool
+}
+
+var providers = struct {
+	mux    sync.RWMutex
+	byName map[string]Provider
+}{byName: make(map[string]Provider)}
+
+// Register makes p available to external_data under name
+func Register(name string, p Provider) {
+	providers.mux.Lock()
+	defer providers.mux.Unlock()
+	providers.byName[name] = p
+}
+
+func provider(name string) (Provider, bool) {
+	providers.mux.RLock()
+	defer providers.mux.RUnlock()
+	p, ok := providers.byName[name]
+	return p, ok
+}
+
+var registerBuiltin sync.Once
+
+// Setup registers the external_data builtin and the built-in providers that are
+// configured. It must be called before templates are compiled, as templates that call
+// external_data do not compile without it.
+func Setup() error {
+	registerBuiltin.Do(func() {
+		ast.RegisterBuiltin(&ast.Builtin{
+			Name: Builtin,
+			Decl: types.NewFunction(
+				types.Args(types.NewObject(nil, types.NewDynamicProperty(types.S, types.A))),
+				types.NewObject(nil, types.NewDynamicProperty(types.S, types.A)),
+			),
+		})
+		topdown.RegisterBuiltinFunc(Builtin, builtinExternalData)
+	})
+	if err := setupCosign(); err != nil {
+		return err
+	}
+	return setupVulnerabilities()
+}
+
+// request is the operand of external_data
+type request struct {
+	Provider string   `json:"provider"`
+	Keys     []string `json:"keys"`
+}
+
+// builtinExternalData answers {"provider": name, "keys": [...]} with
+// {"responses": [[key, value], ...], "errors": [[key, error], ...], "system_error": error}.
+// Provider failures are returned rather than raised, so templates decide how to treat them.
+func builtinExternalData(bctx topdown.BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	var req request
+	if err := ast.As(operands[0].Value, &req); err != nil {
+		return fmt.Errorf("%s: invalid request: %v", Builtin, err)
+	}
+	ctx := bctx.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	responses, errs, err := lookup(ctx, req, time.Now())
+	result := map[string]interface{}{
+		"responses":    pairs(responses),
+		"errors":       pairs(errs),
+		"system_error": "",
+	}
+	if err != nil {
+		result["system_error"] = err.Error()
+	}
+	v, err := ast.InterfaceToValue(result)
+	if err != nil {
+		return err
+	}
+	return iter(ast.NewTerm(v))
+}
+
+// lookup answers req from the cache, asking its provider for the keys that are not cached
+func lookup(ctx context.Context, req request, now time.Time) (map[string]interface{}, map[string]string, error) {
+	p, ok := provider(req.Provider)
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown provider %q", req.Provider)
+	}
+	responses := make(map[string]interface{}, len(req.Keys))
+	var missing []string
+	for _, key := range req.Keys {
+		if v, ok := responseCache.get(req.Provider, key, now); ok {
+			responses[key] = v
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return responses, nil, nil
+	}
+	found, errs, err := p.Lookup(ctx, missing)
+	if err != nil {
+		log.Error(err, "external data provider failed", "provider", req.Provider)
+		return responses, errs, err
+	}
+	kc, partial := p.(keyCacher)
+	for key, v := range found {
+		responses[key] = v
+		if !partial || kc.Cacheable(key) {
+			responseCache.put(req.Provider, key, v, now)
+		}
+	}
+	return responses, errs, nil
+}
+
+// pairs turns m into a list of [key, value] pairs sorted by key
+func pairs(m interface{}) []interface{} {
+	var out []interface{}
+	switch m := m.(type) {
+	case map[string]interface{}:
+		for k, v := range m {
+			out = append(out, []interface{}{k, v})
+		}
+	case map[string]string:
+		for k, v := range m {
+			out = append(out, []interface{}{k, v})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].([]interface{})[0].(string) < out[j].([]interface{})[0].(string)
+	})
+	if out == nil {
+		out = []interface{}{}
+	}
+	return out
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// cache holds the values providers found until --external-data-cache-ttl passes. Errors
+// are not cached.
+type cache struct {
+	mux     sync.Mutex
+	entries map[string]cacheEntry
+}
+
+var responseCache = &cache{entries: make(map[string]cacheEntry)}
+
+func cacheKey(provider, key string) string {
+	return provider + "\x00" + key
+}
+
+func (c *cache) get(provider, key string, now time.Time) (interface{}, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	e, ok := c.entries[cacheKey(provider, key)]
+	if !ok || !now.Before(e.expires) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (c *cache) put(provider, key string, value interface{}, now time.Time) {
+	if *cacheTTL <= 0 {
+		return
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if len(c.entries) >= maxCacheEntries {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxCacheEntries {
+			c.entries = make(map[string]cacheEntry)
+		}
+	}
+	c.entries[cacheKey(provider, key)] = cacheEntry{value: value, expires: now.Add(*cacheTTL)}
+}
@@ -0,0 +1,99 @@
+package externaldata
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/open-policy-agent/opa/rego"
+)
+
+type fakeProvider struct {
+	calls [][]string
+	err   error
+}
+
+func (p *fakeProvider) Lookup(ctx context.Context, keys []string) (map[string]interface{}, map[string]string, error) {
+	p.calls = append(p.calls, keys)
+	if p.err != nil {
+		return nil, nil, p.err
+	}
+	responses := make(map[string]interface{})
+	errs := make(map[string]string)
+	for _, k := range keys {
+		if k == "bad" {
+			errs[k] = "bad key"
+			continue
+		}
+		responses[k] = "value-" + k
+	}
+	return responses, errs, nil
+}
+
+func evalExternalData(t *testing.T, query string) interface{} {
+	t.Helper()
+	if err := Setup(); err != nil {
+		t.Fatal(err)
+	}
+	rs, err := rego.New(rego.Query(query)).Eval(context.Background())
+	if err != nil {
+		t.Fatalf("could not evaluate %s: %v", query, err)
+	}
+	if len(rs) != 1 || len(rs[0].Expressions) != 1 {
+		t.Fatalf("got results %v for %s", rs, query)
+	}
+	return rs[0].Expressions[0].Value
+}
+
+func TestBuiltinExternalData(t *testing.T) {
+	p := &fakeProvider{}
+	Register("fake", p)
+	got := evalExternalData(t, `external_data({"provider": "fake", "keys": ["a", "bad"]})`)
+	want := map[string]interface{}{
+		"responses":    []interface{}{[]interface{}{"a", "value-a"}},
+		"errors":       []interface{}{[]interface{}{"bad", "bad key"}},
+		"system_error": "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+
+	got = evalExternalData(t, `external_data({"provider": "missing", "keys": ["a"]})`)
+	if got.(map[string]interface{})["system_error"] == "" {
+		t.Errorf("wanted a system error for an unknown provider, got %v", got)
+	}
+}
+
+func TestLookupCache(t *testing.T) {
+	responseCache = &cache{entries: make(map[string]cacheEntry)}
+	p := &fakeProvider{}
+	Register("cached", p)
+	now := time.Now()
+	req := request{Provider: "cached", Keys: []string{"a", "bad"}}
+	for i := 0; i < 2; i++ {
+		if _, _, err := lookup(context.Background(), req, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// errors are not cached, so only the key that failed is asked for again
+	if want := [][]string{{"a", "bad"}, {"bad"}}; !reflect.DeepEqual(p.calls, want) {
+		t.Errorf("got calls %v, wanted %v", p.calls, want)
+	}
+	if _, _, err := lookup(context.Background(), request{Provider: "cached", Keys: []string{"a"}}, now.Add(*cacheTTL)); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.calls) != 3 {
+		t.Errorf("expired response was not looked up again, calls %v", p.calls)
+	}
+
+	p.err = errors.New("unavailable")
+	responses, _, err := lookup(context.Background(), request{Provider: "cached", Keys: []string{"a", "b"}}, now.Add(*cacheTTL))
+	if err == nil {
+		t.Error("wanted the provider error")
+	}
+	if responses["a"] != "value-a" {
+		t.Errorf("cached response was dropped on a provider error: %v", responses)
+	}
+}
@@ -0,0 +1,313 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externaldata
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	dockerHubHost     = "registry-1.docker.io"
+	manifestMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+	manifestListType  = "application/vnd.docker.distribution.manifest.list.v2+json"
+	ociManifestType   = "application/vnd.oci.image.manifest.v1+json"
+	ociIndexType      = "application/vnd.oci.image.index.v1+json"
+
+	// maxManifestBytes and maxBlobBytes bound what is read from a registry
+	maxManifestBytes = 4 << 20
+	maxBlobBytes     = 1 << 20
+)
+
+// digestPattern matches the digests images may be referenced by. Digests are put in the
+// paths of registry and report requests, so nothing else is accepted.
+var digestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// imageRef is a parsed image reference
+type imageRef struct {
+	host   string
+	repo   string
+	tag    string
+	digest string
+}
+
+// name returns the repository of ref, with its registry
+func (ref imageRef) name() string {
+	return ref.host + "/" + ref.repo
+}
+
+// isDigestRef returns whether image is referenced by digest. The image such a reference
+// names never changes, so what providers say of it can be cached; a tag can be moved to
+// another image at any time.
+func isDigestRef(image string) bool {
+	ref, err := parseImageRef(image)
+	return err == nil && ref.digest != ""
+}
+
+// parseImageRef parses image as the container runtime would, defaulting to Docker Hub
+// and the latest tag
+func parseImageRef(image string) (imageRef, error) {
+	var ref imageRef
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.digest = name[:i], name[i+1:]
+		if !digestPattern.MatchString(ref.digest) {
+			return ref, fmt.Errorf("unsupported digest in image %q", image)
+		}
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.tag = name[:i], name[i+1:]
+	}
+	if name == "" {
+		return ref, fmt.Errorf("invalid image %q", image)
+	}
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.host, ref.repo = parts[0], parts[1]
+	} else {
+		ref.host, ref.repo = dockerHubHost, name
+	}
+	if ref.host == "docker.io" || ref.host == "index.docker.io" {
+		ref.host = dockerHubHost
+	}
+	if ref.host == dockerHubHost && !strings.Contains(ref.repo, "/") {
+		ref.repo = "library/" + ref.repo
+	}
+	if ref.tag == "" && ref.digest == "" {
+		ref.tag = "latest"
+	}
+	return ref, nil
+}
+
+// registryClient reads manifests and blobs from OCI registries anonymously, fetching
+// bearer tokens when a registry asks for them
+type registryClient struct {
+	client *http.Client
+	mux    sync.Mutex
+	// tokens are the bearer tokens of each registry and repository
+	tokens map[string]string
+}
+
+func newRegistryClient(client *http.Client) *registryClient {
+	return &registryClient{client: client, tokens: make(map[string]string)}
+}
+
+// get sends a GET for path in the repository of ref, authenticating if asked to
+func (c *registryClient) get(ctx context.Context, ref imageRef, path string, accept ...string) (*http.Response, error) {
+	u := fmt.Sprintf("https://%s/v2/%s/%s", ref.host, ref.repo, path)
+	tokenKey := ref.name()
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
+		for _, a := range accept {
+			req.Header.Add("Accept", a)
+		}
+		c.mux.Lock()
+		token := c.tokens[tokenKey]
+		c.mux.Unlock()
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return c.client.Do(req)
+	}
+	resp, err := send()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+	token, err := c.fetchToken(ctx, ref.host, challenge)
+	if err != nil {
+		return nil, err
+	}
+	c.mux.Lock()
+	c.tokens[tokenKey] = token
+	c.mux.Unlock()
+	return send()
+}
+
+// fetchToken gets an anonymous bearer token from host as the WWW-Authenticate challenge
+// asks. The challenge comes from the registry, so its realm must be an https URL on the
+// registry's domain; anything else could make the webhook send requests into the cluster.
+func (c *registryClient) fetchToken(ctx context.Context, host, challenge string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("registry requires unsupported authentication %q", challenge)
+	}
+	params := make(map[string]string)
+	for _, p := range strings.Split(strings.TrimPrefix(challenge, "Bearer "), ",") {
+		kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+		if len(kv) == 2 {
+			params[kv[0]] = strings.Trim(kv[1], `"`)
+		}
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid token realm in challenge %q", challenge)
+	}
+	if realm.Scheme != "https" || !sameDomain(host, realm.Host) {
+		return "", fmt.Errorf("token realm %s is not an https URL on the domain of %s", realm, host)
+	}
+	q := realm.Query()
+	for _, k := range []string{"service", "scope"} {
+		if params[k] != "" {
+			q.Set(k, params[k])
+		}
+	}
+	realm.RawQuery = q.Encode()
+	req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request to %s failed with status %d", realm.Host, resp.StatusCode)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestBytes)).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("no token in response from %s", realm.Host)
+}
+
+// sameDomain returns whether the hosts, with optional ports, are the same or share their
+// last two labels, as registry-1.docker.io and auth.docker.io do. IP addresses and
+// single-label hosts must be the same.
+func sameDomain(a, b string) bool {
+	a, b = hostname(a), hostname(b)
+	if a == b {
+		return true
+	}
+	if net.ParseIP(a) != nil || net.ParseIP(b) != nil {
+		return false
+	}
+	la, lb := strings.Split(a, "."), strings.Split(b, ".")
+	if len(la) < 2 || len(lb) < 2 {
+		return false
+	}
+	return strings.Join(la[len(la)-2:], ".") == strings.Join(lb[len(lb)-2:], ".")
+}
+
+// hostname returns host without its port, in lower case
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.Trim(host, "[]"))
+}
+
+// errNotFound is returned for manifests and blobs the registry does not have
+var errNotFound = fmt.Errorf("not found")
+
+// manifest returns the manifest tagged or digested reference names in the repository of
+// ref, and its digest
+func (c *registryClient) manifest(ctx context.Context, ref imageRef, reference string) ([]byte, string, error) {
+	resp, err := c.get(ctx, ref, "manifests/"+reference, ociManifestType, manifestMediaType, ociIndexType, manifestListType)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("reading manifest %s of %s/%s failed with status %d", reference, ref.host, ref.repo, resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxManifestBytes))
+	if err != nil {
+		return nil, "", err
+	}
+	// the digest is computed rather than taken from the registry, so a tag resolves to the
+	// manifest that was actually read
+	digest := sha256Digest(body)
+	if header := resp.Header.Get("Docker-Content-Digest"); header != "" && header != digest {
+		return nil, "", fmt.Errorf("manifest %s of %s/%s has digest %s, not %s as the registry says", reference, ref.host, ref.repo, digest, header)
+	}
+	if strings.HasPrefix(reference, "sha256:") && reference != digest {
+		return nil, "", fmt.Errorf("manifest %s of %s/%s has digest %s", reference, ref.host, ref.repo, digest)
+	}
+	return body, digest, nil
+}
+
+// resolve returns the digest of the manifest ref names, reading its tag if it has no digest
+func (c *registryClient) resolve(ctx context.Context, ref imageRef) (string, error) {
+	if ref.digest != "" {
+		return ref.digest, nil
+	}
+	_, digest, err := c.manifest(ctx, ref, ref.tag)
+	return digest, err
+}
+
+// blob returns the blob with digest from the repository of ref, checking its digest
+func (c *registryClient) blob(ctx context.Context, ref imageRef, digest string) ([]byte, error) {
+	// digests of blobs come from manifests the registry serves, and are put in the path
+	if !digestPattern.MatchString(digest) {
+		return nil, fmt.Errorf("unsupported blob digest %q in %s/%s", digest, ref.host, ref.repo)
+	}
+	resp, err := c.get(ctx, ref, "blobs/"+digest)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading blob %s of %s/%s failed with status %d", digest, ref.host, ref.repo, resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBlobBytes))
+	if err != nil {
+		return nil, err
+	}
+	if got := sha256Digest(body); got != digest {
+		return nil, fmt.Errorf("blob %s of %s/%s has digest %s", digest, ref.host, ref.repo, got)
+	}
+	return body, nil
+}
+
+func sha256Digest(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,132 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externaldata
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// VulnerabilitiesProvider is the name of the built-in provider that reads the
+// vulnerability reports of images
+const VulnerabilitiesProvider = "vulnerabilities"
+
+var vulnerabilityReportURL = flag.String("vulnerability-report-url", "", "base URL of a service that serves the SBOM or vulnerability report of an image at <url>/<digest>. If set, external_data reads the reports of images with provider "+VulnerabilitiesProvider)
+
+// vulnerabilityReports reads the reports of images from a service keyed by image digest.
+// Images referenced by tag are resolved to their digest first, so a report always
+// describes the image that would run.
+type vulnerabilityReports struct {
+	base     *url.URL
+	client   *http.Client
+	registry *registryClient
+}
+
+func setupVulnerabilities() error {
+	if *vulnerabilityReportURL == "" {
+		return nil
+	}
+	base, err := url.Parse(*vulnerabilityReportURL)
+	if err != nil || base.Host == "" {
+		return fmt.Errorf("invalid --vulnerability-report-url %q", *vulnerabilityReportURL)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	Register(VulnerabilitiesProvider, &vulnerabilityReports{
+		base:     base,
+		client:   client,
+		registry: newRegistryClient(client),
+	})
+	log.Info("vulnerabilities provider enabled", "url", base.String())
+	return nil
+}
+
+// digestReports holds the reports read from the service, by repository and digest, until
+// --external-data-cache-ttl passes. It is kept apart from responseCache, whose keys are the
+// images templates look up and whose values wrap the reports with their digest.
+var digestReports = &cache{entries: make(map[string]cacheEntry)}
+
+// Lookup returns the report of each image, keyed by its reference, as
+// {"digest": digest, "report": report}
+func (v *vulnerabilityReports) Lookup(ctx context.Context, images []string) (map[string]interface{}, map[string]string, error) {
+	responses := make(map[string]interface{})
+	errs := make(map[string]string)
+	for _, image := range images {
+		digest, report, err := v.report(ctx, image)
+		if err != nil {
+			if ctx.Err() != nil {
+				return responses, errs, ctx.Err()
+			}
+			errs[image] = err.Error()
+			continue
+		}
+		responses[image] = map[string]interface{}{"digest": digest, "report": report}
+	}
+	return responses, errs, nil
+}
+
+// Cacheable returns whether image is referenced by digest
+func (v *vulnerabilityReports) Cacheable(image string) bool {
+	return isDigestRef(image)
+}
+
+// report returns the digest of image and its report. Images referenced by tag are resolved
+// on each call, and their reports are cached by the digest they resolve to.
+func (v *vulnerabilityReports) report(ctx context.Context, image string) (string, interface{}, error) {
+	ref, err := parseImageRef(image)
+	if err != nil {
+		return "", nil, err
+	}
+	digest, err := v.registry.resolve(ctx, ref)
+	if err != nil {
+		return "", nil, fmt.Errorf("resolving %s: %v", image, err)
+	}
+	key := ref.name() + "@" + digest
+	now := time.Now()
+	if report, ok := digestReports.get(VulnerabilitiesProvider, key, now); ok {
+		return digest, report, nil
+	}
+	u := *v.base
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + digest
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := v.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil, fmt.Errorf("no report for %s (%s)", image, digest)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("reading the report of %s failed with status %d", image, resp.StatusCode)
+	}
+	var report interface{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestBytes)).Decode(&report); err != nil {
+		return "", nil, fmt.Errorf("invalid report for %s: %v", image, err)
+	}
+	digestReports.put(VulnerabilitiesProvider, key, report, now)
+	return digest, report, nil
+}
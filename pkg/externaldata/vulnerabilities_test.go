@@ -0,0 +1,85 @@
+package externaldata
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVulnerabilityReports(t *testing.T) {
+	responseCache = &cache{entries: make(map[string]cacheEntry)}
+	digestReports = &cache{entries: make(map[string]cacheEntry)}
+	reg := &fakeRegistry{
+		manifests: map[string][]byte{
+			"scanned":   []byte(`{"schemaVersion": 2, "layers": [{"digest": "sha256:1"}]}`),
+			"unscanned": []byte(`{"schemaVersion": 2, "layers": [{"digest": "sha256:2"}]}`),
+		},
+		blobs: make(map[string][]byte),
+	}
+	registry := httptest.NewTLSServer(reg)
+	defer registry.Close()
+	reg.host = strings.TrimPrefix(registry.URL, "https://")
+	scanned := sha256Digest(reg.manifests["scanned"])
+
+	var requested []string
+	reports := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requested = append(requested, req.URL.Path)
+		if req.URL.Path != "/reports/"+scanned {
+			http.NotFound(w, req)
+			return
+		}
+		fmt.Fprint(w, `{"vulnerabilities": [{"id": "CVE-2021-0001", "severity": "CRITICAL"}]}`)
+	}))
+	defer reports.Close()
+	base, err := url.Parse(reports.URL + "/reports/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := &vulnerabilityReports{base: base, client: reports.Client(), registry: newRegistryClient(registry.Client())}
+	images := []string{reg.host + "/app:scanned", reg.host + "/app@" + scanned, reg.host + "/app:unscanned", reg.host + "/app:missing"}
+	responses, errs, err := v.Lookup(context.Background(), images)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"digest": scanned,
+		"report": map[string]interface{}{"vulnerabilities": []interface{}{
+			map[string]interface{}{"id": "CVE-2021-0001", "severity": "CRITICAL"},
+		}},
+	}
+	for _, image := range images[:2] {
+		if !reflect.DeepEqual(responses[image], want) {
+			t.Errorf("%s: got %v, wanted %v (error %q)", image, responses[image], want, errs[image])
+		}
+	}
+	for _, image := range images[2:] {
+		if errs[image] == "" {
+			t.Errorf("%s: wanted an error, got %v", image, responses[image])
+		}
+	}
+	// the scanned image is asked for once, as both its references resolve to the same
+	// digest, and the missing image is never resolved, so it is not asked for
+	if len(requested) != 2 {
+		t.Errorf("got report requests %v, wanted 2", requested)
+	}
+	if v.Cacheable(images[0]) || !v.Cacheable(images[1]) {
+		t.Error("wanted only images referenced by digest to be cacheable")
+	}
+
+	// the cached report is not mistaken for the response to its image
+	Register(VulnerabilitiesProvider, v)
+	responses, _, err = lookup(context.Background(), request{Provider: VulnerabilitiesProvider, Keys: images[1:2]}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(responses[images[1]], want) {
+		t.Errorf("%s: got %v from external_data, wanted %v", images[1], responses[images[1]], want)
+	}
+}
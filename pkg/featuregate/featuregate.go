@@ -0,0 +1,114 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package featuregate holds the gates that turn Gatekeeper's experimental subsystems on
+// and off. Gates are set with --feature-gates, for example
+// --feature-gates=Mutation=true,ExternalData=true.
+//
+// A subsystem checks its gate before it registers any controllers or webhooks, so a
+// disabled subsystem costs nothing. Alpha gates default to off and
+// may change or be removed in any release. Beta gates default to on.
+package featuregate
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/component-base/featuregate"
+)
+
+const (
+	// Mutation enables the mutating webhook and its mutator resources
+	Mutation featuregate.Feature = "Mutation"
+
+	// Expansion enables reviewing the resources generated from workload templates, such
+	// as the Pods of a Deployment, when the template is admitted
+	Expansion featuregate.Feature = "Expansion"
+
+	// ExternalData enables Rego to query providers outside the cluster through
+	// external_data
+	ExternalData featuregate.Feature = "ExternalData"
+
+	// CEL enables constraint templates written in the Common Expression Language
+	CEL featuregate.Feature = "CEL"
+
+	// Export enables exporting audit results to sinks outside the cluster
+	Export featuregate.Feature = "Export"
+
+	// SelfProtection enables the built-in constraints and PodDisruptionBudget that
+	// protect Gatekeeper's own CRDs and deployment
+	SelfProtection featuregate.Feature = "SelfProtection"
+
+	// ExternalSync enables loading JSON documents from HTTP(S) endpoints into the
+	// inventory with ExternalSync resources
+	ExternalSync featuregate.Feature = "ExternalSync"
+)
+
+var defaults = map[featuregate.Feature]featuregate.FeatureSpec{
+	Mutation:       {Default: false, PreRelease: featuregate.Alpha},
+	Expansion:      {Default: false, PreRelease: featuregate.Alpha},
+	ExternalData:   {Default: false, PreRelease: featuregate.Alpha},
+	CEL:            {Default: false, PreRelease: featuregate.Alpha},
+	Export:         {Default: false, PreRelease: featuregate.Alpha},
+	SelfProtection: {Default: false, PreRelease: featuregate.Alpha},
+	ExternalSync:   {Default: false, PreRelease: featuregate.Alpha},
+}
+
+// Gates holds the state of every feature gate. It is set from --feature-gates when
+// flags are parsed and must not be changed afterwards.
+var Gates = newGates()
+
+func newGates() featuregate.MutableFeatureGate {
+	g := featuregate.NewFeatureGate()
+	if err := g.Add(defaults); err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// gatesFlag adds the String method flag.Value needs to Gates
+type gatesFlag struct {
+	featuregate.MutableFeatureGate
+}
+
+func (g gatesFlag) String() string {
+	if s, ok := g.MutableFeatureGate.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return ""
+}
+
+func init() {
+	flag.Var(gatesFlag{Gates}, "feature-gates", fmt.Sprintf("comma-separated Name=true|false list of the feature gates for experimental subsystems. Options are:\n%s", strings.Join(Gates.KnownFeatures(), "\n")))
+}
+
+// Enabled returns true if the gate of feature f is on
+func Enabled(f featuregate.Feature) bool {
+	return Gates.Enabled(f)
+}
+
+// EnabledGates returns the names of the gates that are on, sorted
+func EnabledGates() []string {
+	enabled := []string{}
+	for f := range defaults {
+		if Gates.Enabled(f) {
+			enabled = append(enabled, string(f))
+		}
+	}
+	sort.Strings(enabled)
+	return enabled
+}
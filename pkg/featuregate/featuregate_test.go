@@ -0,0 +1,44 @@
+package featuregate
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestGates(t *testing.T) {
+	defer func() {
+		reset := make(map[string]bool)
+		for f := range defaults {
+			reset[string(f)] = false
+		}
+		if err := Gates.SetFromMap(reset); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	for f := range defaults {
+		if Enabled(f) {
+			t.Errorf("alpha gate %s is on by default", f)
+		}
+	}
+	if got := EnabledGates(); len(got) != 0 {
+		t.Errorf("EnabledGates() = %v, wanted none", got)
+	}
+
+	if err := flag.Lookup("feature-gates").Value.Set("Mutation=true,ExternalData=true"); err != nil {
+		t.Fatal(err)
+	}
+	if !Enabled(Mutation) || !Enabled(ExternalData) || Enabled(CEL) {
+		t.Errorf("gates after Set: %s", Gates)
+	}
+	if got, want := EnabledGates(), []string{"ExternalData", "Mutation"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("EnabledGates() = %v, wanted %v", got, want)
+	}
+
+	for _, bad := range []string{"Unknown=true", "Mutation=maybe", "Mutation"} {
+		if err := Gates.Set(bad); err == nil {
+			t.Errorf("Set(%q) did not fail", bad)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package feed passes violations to the clients of the violation stream as they are found
+package feed
+
+import (
+	"flag"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var bufferSize = flag.Int("violation-stream-buffer", 100, "number of violations buffered for each client of the violation stream. Violations are dropped for clients that fall further behind")
+
+const (
+	// SourceAdmission marks violations found by the webhook
+	SourceAdmission = "admission"
+	// SourceAudit marks violations found by audit
+	SourceAudit = "audit"
+
+	// TypeViolation is a violation that was found
+	TypeViolation = "violation"
+	// TypeResolved is a violation the previous audit found that the latest one did not
+	TypeResolved = "resolved"
+)
+
+// Event is a violation published to the stream
+type Event struct {
+	Time           time.Time `json:"time"`
+	Source         string    `json:"source"`
+	Type           string    `json:"type"`
+	ConstraintKind string    `json:"constraintKind"`
+	ConstraintName string    `json:"constraintName"`
+	// ConstraintOwner is the owner label of the constraint, if it has one
+	ConstraintOwner   string `json:"constraintOwner,omitempty"`
+	EnforcementAction string `json:"enforcementAction"`
+	Message           string `json:"message,omitempty"`
+	Kind              string `json:"kind"`
+	Namespace         string `json:"namespace,omitempty"`
+	Name              string `json:"name"`
+	// Operation and DecisionID are only set for admission violations
+	Operation  string `json:"operation,omitempty"`
+	DecisionID string `json:"decisionID,omitempty"`
+	// AuditID is only set for audit violations
+	AuditID string `json:"auditID,omitempty"`
+}
+
+// Violations is the feed the webhook and audit publish to
+var Violations = New(func() int { return *bufferSize })
+
+// Feed passes each published Event to every subscriber, without blocking the publisher
+type Feed struct {
+	mux  sync.RWMutex
+	subs map[*Subscription]bool
+	// size returns the buffer of new subscriptions, read on each Subscribe so it follows
+	// the flag once parsed
+	size func() int
+}
+
+// New returns a Feed without subscribers
+func New(size func() int) *Feed {
+	return &Feed{subs: make(map[*Subscription]bool), size: size}
+}
+
+// Subscription receives the events published after it was made
+type Subscription struct {
+	events  chan Event
+	dropped int64
+	feed    *Feed
+}
+
+// Subscribe returns a Subscription that must be closed once it is no longer read
+func (f *Feed) Subscribe() *Subscription {
+	s := &Subscription{events: make(chan Event, f.size()), feed: f}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.subs[s] = true
+	return s
+}
+
+// Active returns whether anyone is subscribed, so publishers can skip building events
+func (f *Feed) Active() bool {
+	f.mux.RLock()
+	defer f.mux.RUnlock()
+	return len(f.subs) > 0
+}
+
+// Publish passes e to every subscriber whose buffer has room and drops it for the others
+func (f *Feed) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	f.mux.RLock()
+	defer f.mux.RUnlock()
+	for s := range f.subs {
+		select {
+		case s.events <- e:
+		default:
+			atomic.AddInt64(&s.dropped, 1)
+		}
+	}
+}
+
+// Events receives the published events
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped returns the number of events dropped because the buffer was full, and resets it
+func (s *Subscription) Dropped() int64 {
+	return atomic.SwapInt64(&s.dropped, 0)
+}
+
+// Close stops the subscription. Buffered events are discarded.
+func (s *Subscription) Close() {
+	s.feed.mux.Lock()
+	defer s.feed.mux.Unlock()
+	delete(s.feed.subs, s)
+}
@@ -0,0 +1,54 @@
+package feed
+
+import (
+	"testing"
+)
+
+func TestFeed(t *testing.T) {
+	f := New(func() int { return 1 })
+	if f.Active() {
+		t.Error("Active() = true without subscribers, wanted false")
+	}
+	// events published without subscribers are dropped
+	f.Publish(Event{Name: "before"})
+
+	a := f.Subscribe()
+	b := f.Subscribe()
+	if !f.Active() {
+		t.Error("Active() = false with subscribers, wanted true")
+	}
+	f.Publish(Event{Name: "first"})
+	if got := (<-a.Events()).Name; got != "first" {
+		t.Errorf("a received %q, wanted first", got)
+	}
+	// b has not read, so its buffer of 1 is full
+	f.Publish(Event{Name: "second"})
+	e := <-b.Events()
+	if e.Name != "first" {
+		t.Errorf("b received %q, wanted first", e.Name)
+	}
+	if e.Time.IsZero() {
+		t.Error("published event has no time")
+	}
+	if got := b.Dropped(); got != 1 {
+		t.Errorf("b dropped %d events, wanted 1", got)
+	}
+	if got := b.Dropped(); got != 0 {
+		t.Errorf("Dropped() did not reset, got %d", got)
+	}
+	if got := (<-a.Events()).Name; got != "second" {
+		t.Errorf("a received %q, wanted second", got)
+	}
+
+	a.Close()
+	b.Close()
+	if f.Active() {
+		t.Error("Active() = true after all subscriptions closed, wanted false")
+	}
+	f.Publish(Event{Name: "after"})
+	select {
+	case e := <-a.Events():
+		t.Errorf("closed subscription received %v", e)
+	default:
+	}
+}
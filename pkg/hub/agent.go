@@ -0,0 +1,176 @@
+/*
+ Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+     http://www.apache.org/licenses/LICENSE-2.0
+ Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hub
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	configv1alpha1 "github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var log = logf.Log.WithName("hub").WithValues(logging.Process, "hub_agent")
+
+var (
+	hubKubeconfig = flag.String("hub-kubeconfig", "", "path to a kubeconfig for a hub cluster. When set, constraint and violation summaries are pushed to a GatekeeperClusterStatus on the hub")
+	clusterName   = flag.String("hub-cluster-name", "", "name this cluster reports to the hub as. Required with --hub-kubeconfig")
+	pushInterval  = flag.Int("hub-push-interval", 60, "interval to push status to the hub in seconds")
+	hubNamespace  = flag.String("hub-namespace", "gatekeeper-system", "namespace of the hub the GatekeeperClusterStatus is written to")
+)
+
+const constraintsGroupVersion = "constraints.gatekeeper.sh/v1beta1"
+
+// AddToManager adds the hub agent to the Manager if a hub is configured
+func AddToManager(mgr manager.Manager) error {
+	if *hubKubeconfig == "" {
+		log.Info("hub status aggregation is disabled")
+		return nil
+	}
+	if *clusterName == "" {
+		return errors.New("--hub-cluster-name must be set when --hub-kubeconfig is set")
+	}
+	cfg, err := clientcmd.BuildConfigFromFlags("", *hubKubeconfig)
+	if err != nil {
+		return errors.Wrap(err, "could not load hub kubeconfig")
+	}
+	hubClient, err := client.New(cfg, client.Options{Scheme: mgr.GetScheme()})
+	if err != nil {
+		return errors.Wrap(err, "could not create hub client")
+	}
+	return mgr.Add(&Agent{
+		local:       mgr.GetAPIReader(),
+		hub:         hubClient,
+		clusterName: *clusterName,
+		namespace:   *hubNamespace,
+		interval:    time.Duration(*pushInterval) * time.Second,
+	})
+}
+
+// Agent periodically pushes a summary of this cluster's constraints to a hub cluster
+type Agent struct {
+	local       client.Reader
+	hub         client.Client
+	clusterName string
+	namespace   string
+	interval    time.Duration
+}
+
+// Start implements manager.Runnable
+func (a *Agent) Start(stop <-chan struct{}) error {
+	log.Info("starting hub agent", "cluster", a.clusterName)
+	wait.Until(func() {
+		if err := a.push(context.Background()); err != nil {
+			log.Error(err, "could not push status to hub")
+		}
+	}, a.interval, stop)
+	log.Info("stopping hub agent")
+	return nil
+}
+
+// push writes the current summary to this cluster's GatekeeperClusterStatus on the hub
+func (a *Agent) push(ctx context.Context) error {
+	summary, err := a.summarize(ctx)
+	if err != nil {
+		return err
+	}
+	now := metav1.Now()
+	summary.LastUpdated = &now
+
+	existing := &configv1alpha1.GatekeeperClusterStatus{}
+	err = a.hub.Get(ctx, types.NamespacedName{Namespace: a.namespace, Name: a.clusterName}, existing)
+	if apierrors.IsNotFound(err) {
+		obj := &configv1alpha1.GatekeeperClusterStatus{
+			ObjectMeta: metav1.ObjectMeta{Namespace: a.namespace, Name: a.clusterName},
+			Status:     *summary,
+		}
+		return a.hub.Create(ctx, obj)
+	}
+	if err != nil {
+		return err
+	}
+	existing.Status = *summary
+	return a.hub.Update(ctx, existing)
+}
+
+// summarize collects the audit results of every constraint in the cluster
+func (a *Agent) summarize(ctx context.Context) (*configv1alpha1.GatekeeperClusterStatusStatus, error) {
+	summary := &configv1alpha1.GatekeeperClusterStatusStatus{ClusterName: a.clusterName}
+	templs := &templv1beta1.ConstraintTemplateList{}
+	if err := a.local.List(ctx, templs); err != nil {
+		return nil, errors.Wrap(err, "could not list constraint templates")
+	}
+	gv, err := schema.ParseGroupVersion(constraintsGroupVersion)
+	if err != nil {
+		return nil, err
+	}
+	for _, templ := range templs.Items {
+		kind := templ.Spec.CRD.Spec.Names.Kind
+		cstrs := &unstructured.UnstructuredList{}
+		cstrs.SetGroupVersionKind(gv.WithKind(kind + "List"))
+		if err := a.local.List(ctx, cstrs); err != nil {
+			// the CRD may not be established yet
+			log.Error(err, "could not list constraints", "kind", kind)
+			continue
+		}
+		for _, c := range cstrs.Items {
+			enforcementAction := effectiveAction(&c)
+			violations, _, _ := unstructured.NestedInt64(c.Object, "status", "totalViolations")
+			auditTimestamp, _, _ := unstructured.NestedString(c.Object, "status", "auditTimestamp")
+			summary.Constraints = append(summary.Constraints, configv1alpha1.ConstraintSummary{
+				Kind:              kind,
+				Name:              c.GetName(),
+				EnforcementAction: enforcementAction,
+				TotalViolations:   violations,
+				AuditTimestamp:    auditTimestamp,
+			})
+			summary.TotalViolations += violations
+		}
+	}
+	return summary, nil
+}
+
+// effectiveAction returns the enforcement action c is enforced with, as the pods record it
+// in status.byPod once cluster defaults, rollouts and downgrades apply, or else the one its
+// spec sets if no pod has recorded one yet
+func effectiveAction(c *unstructured.Unstructured) string {
+	byPod, _, _ := unstructured.NestedSlice(c.Object, "status", "byPod")
+	for _, s := range byPod {
+		status, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if action, ok := status["enforcementAction"].(string); ok && action != "" {
+			return action
+		}
+	}
+	action, err := util.GetEnforcementAction(c.Object)
+	if err != nil {
+		return string(util.Unrecognized)
+	}
+	return string(action)
+}
@@ -0,0 +1,75 @@
+package hub
+
+import (
+	"context"
+	"testing"
+
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"github.com/open-policy-agent/gatekeeper/api"
+	configv1alpha1 "github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/testutils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPush(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	gvk := testutils.ConstraintGVK("K8sRequiredLabels")
+	scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind("K8sRequiredLabelsList"), &unstructured.UnstructuredList{})
+
+	templ := &templv1beta1.ConstraintTemplate{ObjectMeta: metav1.ObjectMeta{Name: "k8srequiredlabels"}}
+	templ.Spec.CRD.Spec.Names.Kind = "K8sRequiredLabels"
+	audited := testutils.NewConstraint("K8sRequiredLabels", "must-have-owner", testutils.WithEnforcementAction("dryrun"))
+	if err := unstructured.SetNestedField(audited.Object, int64(3), "status", "totalViolations"); err != nil {
+		t.Fatal(err)
+	}
+	unaudited := testutils.NewConstraint("K8sRequiredLabels", "must-have-team")
+	// the pods enforce the deny constraint as dryrun while its rollout lasts
+	rolling := testutils.NewConstraint("K8sRequiredLabels", "must-have-cost-center", testutils.WithEnforcementAction("deny"))
+	if err := unstructured.SetNestedSlice(rolling.Object, []interface{}{
+		map[string]interface{}{"id": "gatekeeper-0", "enforcementAction": "dryrun"},
+	}, "status", "byPod"); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &Agent{
+		local:       fake.NewFakeClientWithScheme(scheme, templ, audited, unaudited, rolling),
+		hub:         fake.NewFakeClientWithScheme(scheme),
+		clusterName: "east",
+		namespace:   "gatekeeper-system",
+	}
+	key := types.NamespacedName{Namespace: "gatekeeper-system", Name: "east"}
+
+	// the first push creates the status, later pushes update it
+	for i := 0; i < 2; i++ {
+		if err := a.push(context.TODO()); err != nil {
+			t.Fatalf("push() error = %v", err)
+		}
+	}
+	got := &configv1alpha1.GatekeeperClusterStatus{}
+	if err := a.hub.Get(context.TODO(), key, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.ClusterName != "east" || got.Status.LastUpdated == nil {
+		t.Errorf("status = %+v, wanted cluster name and update time", got.Status)
+	}
+	if got.Status.TotalViolations != 3 {
+		t.Errorf("TotalViolations = %d, wanted 3", got.Status.TotalViolations)
+	}
+	if len(got.Status.Constraints) != 3 {
+		t.Fatalf("Constraints = %+v, wanted 3 entries", got.Status.Constraints)
+	}
+	want := map[string]string{"must-have-owner": "dryrun", "must-have-team": "deny", "must-have-cost-center": "dryrun"}
+	for _, c := range got.Status.Constraints {
+		if c.EnforcementAction != want[c.Name] {
+			t.Errorf("constraint %s has enforcementAction %s, wanted %s", c.Name, c.EnforcementAction, want[c.Name])
+		}
+	}
+}
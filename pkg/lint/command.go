@@ -0,0 +1,120 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/bench"
+	"github.com/pkg/errors"
+)
+
+// Command is the name of the subcommand
+const Command = "lint"
+
+// templateKind is the only kind lint checks
+const templateKind = "ConstraintTemplate"
+
+// Result holds the diagnostics of one template
+type Result struct {
+	File        string       `json:"file,omitempty"`
+	Template    string       `json:"template"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// Run lints the ConstraintTemplates in the files named by args and writes the
+// diagnostics to out. It fails if any template has errors.
+func Run(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet(Command, flag.ContinueOnError)
+	fs.SetOutput(out)
+	output := fs.String("output", "text", "output format, text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("at least one file is required")
+	}
+	if *output != "text" && *output != "json" {
+		return errors.Errorf("unknown output format %q", *output)
+	}
+
+	var results []Result
+	failed := 0
+	for _, path := range fs.Args() {
+		objs, err := bench.ReadObjects(path)
+		if err != nil {
+			return err
+		}
+		for _, obj := range objs {
+			if obj.GetKind() != templateKind {
+				continue
+			}
+			diags, err := Template(context.Background(), obj)
+			if err != nil {
+				return err
+			}
+			if HasErrors(diags) {
+				failed++
+			}
+			results = append(results, Result{File: path, Template: obj.GetName(), Diagnostics: diags})
+		}
+	}
+
+	if *output == "json" {
+		if results == nil {
+			results = []Result{}
+		}
+		if err := json.NewEncoder(out).Encode(results); err != nil {
+			return err
+		}
+	} else {
+		writeText(out, results)
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d templates have errors", failed, len(results))
+	}
+	return nil
+}
+
+func writeText(out io.Writer, results []Result) {
+	for _, r := range results {
+		if len(r.Diagnostics) == 0 {
+			fmt.Fprintf(out, "%s: %s: ok\n", r.File, r.Template)
+			continue
+		}
+		for _, d := range r.Diagnostics {
+			fmt.Fprintf(out, "%s: %s: %s\n", r.File, r.Template, d)
+		}
+	}
+}
+
+// String formats d for the command line, for example
+// "error rego_parse_error at rego 3:5: unexpected eof token"
+func (d Diagnostic) String() string {
+	where := ""
+	switch {
+	case d.Lib != nil:
+		where = fmt.Sprintf(" at libs[%d] %d:%d", *d.Lib, d.Row, d.Col)
+	case d.Row > 0:
+		where = fmt.Sprintf(" at rego %d:%d", d.Row, d.Col)
+	}
+	return fmt.Sprintf("%s %s%s: %s", d.Severity, d.Code, where, d.Message)
+}
@@ -0,0 +1,250 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lint checks ConstraintTemplates before they are applied. Errors are exactly
+// the problems that make Gatekeeper reject or fail to load a template; warnings are
+// templates that load but are unlikely to work as intended.
+package lint
+
+import (
+	"context"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/gatekeeper/pkg/engine"
+	"github.com/open-policy-agent/gatekeeper/pkg/util/regoutil"
+	"github.com/open-policy-agent/opa/ast"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Severity is how serious a Diagnostic is
+type Severity string
+
+const (
+	// Error diagnostics stop the template from being loaded
+	Error Severity = "error"
+	// Warning diagnostics do not stop the template from being loaded
+	Warning Severity = "warning"
+)
+
+// Diagnostic codes reported in addition to those of the Rego parser and compiler
+const (
+	InvalidTemplateCode    = "invalid_template"
+	TemplateErrorCode      = "template_error"
+	CompileErrorCode       = "compile_error"
+	MissingViolationCode   = "missing_violation"
+	ViolationSignatureCode = "violation_signature"
+	ViolationMsgCode       = "violation_msg"
+	NoSchemaCode           = "parameters_without_schema"
+)
+
+// StatusWarningCode is the code of the lint warnings the template controller adds to
+// the errors of a template's status, which has no field for warnings. Unlike the other
+// errors, they do not stop the template from being loaded.
+const StatusWarningCode = "lint_warning"
+
+// violationRule is the rule every template must define
+const violationRule = "violation"
+
+// Diagnostic is a single problem found in a template. Row and Col are 1-based positions
+// in the Rego of Target, or 0 if the problem is not tied to a position.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+	Target   string   `json:"target,omitempty"`
+	// Lib is the index of the library in the target's libs, or nil for its rego
+	Lib *int `json:"lib,omitempty"`
+	Row int  `json:"row,omitempty"`
+	Col int  `json:"col,omitempty"`
+}
+
+// HasErrors returns true if any of diags is an error
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Template checks a v1beta1 ConstraintTemplate, such as one read from YAML. It runs the
+// same checks, in the same order, as Gatekeeper does when the template is created, then
+// compiles the template in a throwaway OPA client. The returned error is only set if
+// the checks themselves could not run.
+func Template(ctx context.Context, u *unstructured.Unstructured) ([]Diagnostic, error) {
+	templ, err := engine.ToTemplate(u)
+	if err != nil {
+		return []Diagnostic{{Severity: Error, Code: InvalidTemplateCode, Message: err.Error()}}, nil
+	}
+
+	diags := Static(templ)
+	if HasErrors(diags) {
+		return diags, nil
+	}
+
+	client, err := engine.NewClient(nil)
+	if err != nil {
+		return nil, err
+	}
+	// ToTemplate has already added the libraries and stripped schema defaults, as the
+	// webhook and controller do before building the CRD
+	if _, err := client.CreateCRD(ctx, templ); err != nil {
+		return append(diags, Diagnostic{Severity: Error, Code: TemplateErrorCode, Message: err.Error()}), nil
+	}
+	if _, err := client.AddTemplate(ctx, templ); err != nil {
+		if errs, ok := err.(ast.Errors); ok {
+			return append(diags, fromAstErrors(templ, errs)...), nil
+		}
+		return append(diags, Diagnostic{Severity: Error, Code: CompileErrorCode, Message: err.Error()}), nil
+	}
+	return diags, nil
+}
+
+// Static runs the checks of Template that need no OPA client, for templates being
+// loaded by Gatekeeper, which compiles them itself
+func Static(templ *templates.ConstraintTemplate) []Diagnostic {
+	var diags []Diagnostic
+	for _, target := range templ.Spec.Targets {
+		diags = append(diags, checkRego(target)...)
+		if !hasSchema(templ) && readsParameters(target.Rego) {
+			diags = append(diags, Diagnostic{
+				Severity: Warning,
+				Code:     NoSchemaCode,
+				Target:   target.Target,
+				Message:  "rego reads input.parameters but spec.crd.spec.validation has no openAPIV3Schema, so constraint parameters are not validated",
+			})
+		}
+	}
+	if err := regoutil.CheckBuiltins(templ, regoutil.DisallowedBuiltins()); err != nil {
+		diags = append(diags, fromAstErrors(templ, err.(ast.Errors))...)
+	}
+	return diags
+}
+
+// checkRego reports parse errors and violation rules that Gatekeeper cannot use
+func checkRego(target templates.Target) []Diagnostic {
+	var diags []Diagnostic
+	for i, lib := range target.Libs {
+		if _, err := ast.ParseModule("", lib); err != nil {
+			diags = append(diags, parseDiagnostics(target.Target, intPtr(i), err)...)
+		}
+	}
+	m, err := ast.ParseModule("", target.Rego)
+	if err != nil {
+		return append(parseDiagnostics(target.Target, nil, err), diags...)
+	}
+	found := false
+	for _, rule := range m.Rules {
+		if rule.Head.Name != ast.Var(violationRule) {
+			continue
+		}
+		found = true
+		d := Diagnostic{Target: target.Target, Row: rule.Location.Row, Col: rule.Location.Col}
+		switch {
+		case rule.Head.Key == nil || rule.Head.Value != nil:
+			d.Severity, d.Code = Error, ViolationSignatureCode
+			d.Message = `violation must be a partial set rule, such as violation[{"msg": msg}]`
+		case !hasMsg(rule.Head.Key):
+			// Gatekeeper loads the template, but its violations are reported without a message
+			d.Severity, d.Code = Warning, ViolationMsgCode
+			d.Message = `violation results should be objects with a "msg" key, such as violation[{"msg": msg}]`
+		default:
+			continue
+		}
+		diags = append(diags, d)
+	}
+	if !found {
+		diags = append(diags, Diagnostic{
+			Severity: Error,
+			Code:     MissingViolationCode,
+			Target:   target.Target,
+			Message:  `rego does not define a violation rule, such as violation[{"msg": msg}]`,
+		})
+	}
+	return diags
+}
+
+// hasMsg returns false if key is an object without a "msg" key. Other keys, such as a
+// variable bound in the rule body, cannot be checked without evaluating the rule.
+func hasMsg(key *ast.Term) bool {
+	obj, ok := key.Value.(ast.Object)
+	if !ok {
+		return true
+	}
+	return obj.Get(ast.StringTerm("msg")) != nil
+}
+
+func hasSchema(templ *templates.ConstraintTemplate) bool {
+	v := templ.Spec.CRD.Spec.Validation
+	return v != nil && v.OpenAPIV3Schema != nil
+}
+
+// readsParameters returns true if rego refers to input.parameters. Rego that does not
+// parse is reported by checkRego.
+func readsParameters(rego string) bool {
+	m, err := ast.ParseModule("", rego)
+	if err != nil {
+		return false
+	}
+	params := ast.MustParseRef("input.parameters")
+	found := false
+	ast.WalkRefs(m, func(ref ast.Ref) bool {
+		if ref.HasPrefix(params) {
+			found = true
+		}
+		return found
+	})
+	return found
+}
+
+func parseDiagnostics(target string, lib *int, err error) []Diagnostic {
+	errs, ok := err.(ast.Errors)
+	if !ok {
+		return []Diagnostic{{Severity: Error, Code: ast.ParseErr, Message: err.Error(), Target: target, Lib: lib}}
+	}
+	var diags []Diagnostic
+	for _, e := range errs {
+		d := Diagnostic{Severity: Error, Code: e.Code, Message: e.Message, Target: target, Lib: lib}
+		if e.Location != nil {
+			d.Row, d.Col = e.Location.Row, e.Location.Col
+		}
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+// fromAstErrors converts errors found in the rewritten or concatenated modules of templ.
+// Their locations cannot be traced back to a single target, so only a template with one
+// target has its errors attributed to it.
+func fromAstErrors(templ *templates.ConstraintTemplate, errs ast.Errors) []Diagnostic {
+	target := ""
+	if len(templ.Spec.Targets) == 1 {
+		target = templ.Spec.Targets[0].Target
+	}
+	var diags []Diagnostic
+	for _, e := range errs {
+		d := Diagnostic{Severity: Error, Code: e.Code, Message: e.Message, Target: target}
+		if e.Location != nil {
+			d.Row, d.Col = e.Location.Row, e.Location.Col
+		}
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+func intPtr(i int) *int {
+	return &i
+}
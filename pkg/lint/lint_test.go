@@ -0,0 +1,191 @@
+package lint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/engine"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func templateYAML(name, rego string) string {
+	return fmt.Sprintf(`
+apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: %s
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sRequiredLabels
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+%s
+`, name, indent(rego, "        "))
+}
+
+func indent(s, prefix string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i := range lines {
+		lines[i] = prefix + lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func decode(t *testing.T, s string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal([]byte(s), &u.Object); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+const validRego = `
+package k8srequiredlabels
+
+violation[{"msg": msg}] {
+  not input.review.object.metadata.labels.owner
+  msg := "missing owner"
+}
+`
+
+func TestTemplate(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Template string
+		Codes    []string
+		Row      int
+		Loads    bool
+	}{
+		{
+			Name:     "Valid",
+			Template: templateYAML("k8srequiredlabels", validRego),
+			Loads:    true,
+		},
+		{
+			Name:     "Parse error",
+			Template: templateYAML("k8srequiredlabels", "package k8srequiredlabels\n\nviolation[{\"msg\": msg}] {\n  msg := \n}"),
+			Codes:    []string{"rego_parse_error"},
+			Row:      5,
+		},
+		{
+			Name: "Disallowed builtin",
+			Template: templateYAML("k8srequiredlabels", `
+package k8srequiredlabels
+
+violation[{"msg": msg}] {
+  resp := http.send({"method": "get", "url": "https://example.com"})
+  msg := resp.body
+}`),
+			Codes: []string{"disallowed_builtin"},
+			Row:   4,
+		},
+		{
+			Name:     "Missing violation",
+			Template: templateYAML("k8srequiredlabels", "package k8srequiredlabels\n\ndeny[msg] {\n  msg := \"no\"\n}"),
+			Codes:    []string{MissingViolationCode},
+		},
+		{
+			Name:     "Complete violation rule",
+			Template: templateYAML("k8srequiredlabels", "package k8srequiredlabels\n\nviolation = true {\n  true\n}"),
+			Codes:    []string{ViolationSignatureCode},
+			Row:      3,
+		},
+		{
+			Name:     "Parameters without schema",
+			Template: templateYAML("k8srequiredlabels", "package k8srequiredlabels\n\nviolation[{\"msg\": msg}] {\n  msg := input.parameters.message\n}"),
+			Codes:    []string{NoSchemaCode},
+			Loads:    true,
+		},
+		{
+			Name:     "Compile error",
+			Template: templateYAML("k8srequiredlabels", "package k8srequiredlabels\n\nviolation[{\"msg\": msg}] {\n  msg := undefined_function(1)\n}"),
+			Codes:    []string{"rego_type_error"},
+		},
+		{
+			Name:     "Name does not match kind",
+			Template: templateYAML("requiredlabels", validRego),
+			Codes:    []string{TemplateErrorCode},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			diags, err := Template(context.Background(), decode(t, tt.Template))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var codes []string
+			for _, d := range diags {
+				codes = append(codes, d.Code)
+			}
+			if strings.Join(codes, ",") != strings.Join(tt.Codes, ",") {
+				t.Fatalf("codes = %v, wanted %v: %v", codes, tt.Codes, diags)
+			}
+			if tt.Row > 0 && diags[0].Row != tt.Row {
+				t.Errorf("row = %d, wanted %d", diags[0].Row, tt.Row)
+			}
+			if HasErrors(diags) == tt.Loads {
+				t.Errorf("HasErrors() = %v, wanted %v", HasErrors(diags), !tt.Loads)
+			}
+		})
+	}
+}
+
+func TestStatic(t *testing.T) {
+	u := decode(t, templateYAML("k8srequiredlabels", "package k8srequiredlabels\n\nviolation[{\"details\": {}}] {\n  true\n}"))
+	templ, err := engine.ToTemplate(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the compiler then fails on the missing msg, which the warning explains
+	diags := Static(templ)
+	if len(diags) != 1 || diags[0].Code != ViolationMsgCode || diags[0].Severity != Warning || diags[0].Row != 3 {
+		t.Errorf("diagnostics = %v, wanted a %s warning at row 3", diags, ViolationMsgCode)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	valid := filepath.Join(dir, "valid.yaml")
+	if err := ioutil.WriteFile(valid, []byte(templateYAML("k8srequiredlabels", validRego)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := ioutil.WriteFile(invalid, []byte(templateYAML("requiredlabels", validRego)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	if err := Run([]string{valid}, out); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "k8srequiredlabels: ok") {
+		t.Errorf("output = %q", out.String())
+	}
+
+	out.Reset()
+	if err := Run([]string{"--output=json", valid, invalid}, out); err == nil {
+		t.Error("Run() should fail when a template has errors")
+	}
+	var results []Result
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || len(results[0].Diagnostics) != 0 || len(results[1].Diagnostics) != 1 {
+		t.Errorf("results = %+v", results)
+	}
+}
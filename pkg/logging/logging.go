@@ -10,6 +10,8 @@ const (
 	ConstraintAPIVersion = "constraint_api_version"
 	ConstraintStatus     = "constraint_status"
 	ConstraintAction     = "constraint_action"
+	ConstraintOwner      = "constraint_owner"
+	ExemptionName        = "exemption_name"
 	AuditID              = "audit_id"
 	ConstraintViolations = "constraint_violations"
 	ResourceKind         = "resource_kind"
@@ -0,0 +1,269 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package match implements the `match` subcommand, which lists the constraints whose
+// match criteria select a kind of object in a namespace, to debug why a policy was or
+// was not evaluated.
+package match
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/engine"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	"github.com/pkg/errors"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+// Command is the name of the subcommand
+const Command = "match"
+
+var templateListGVK = schema.GroupVersionKind{Group: "templates.gatekeeper.sh", Version: "v1beta1", Kind: "ConstraintTemplateList"}
+
+// Run parses args and prints the constraints in the cluster that match the described
+// object
+func Run(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet(Command, flag.ContinueOnError)
+	fs.SetOutput(out)
+	group := fs.String("group", "", "API group of the object. Empty for the core group")
+	version := fs.String("version", "v1", "API version of the object")
+	kind := fs.String("kind", "", "kind of the object, such as Deployment")
+	namespace := fs.String("namespace", "", "namespace of the object. Its labels are read from the cluster for namespaceSelectors. Empty for cluster-scoped objects")
+	name := fs.String("name", "example", "name of the object")
+	objLabels := fs.String("labels", "", "comma-separated key=value labels of the object, for labelSelectors")
+	operation := fs.String("operation", string(admissionv1beta1.Create), "admission operation, for constraints that match operations")
+	kubeconfig := fs.String("kubeconfig", "", "path to a kubeconfig. Defaults to $KUBECONFIG, the in-cluster config or ~/.kube/config")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *kind == "" {
+		fs.Usage()
+		return errors.New("--kind is required")
+	}
+	lbls, err := labels.ConvertSelectorToLabelsMap(*objLabels)
+	if err != nil {
+		return errors.Wrap(err, "invalid --labels")
+	}
+
+	c, err := newClient(*kubeconfig)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	constraints, err := List(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(schema.GroupVersionKind{Group: *group, Version: *version, Kind: *kind})
+	obj.SetName(*name)
+	obj.SetNamespace(*namespace)
+	if len(lbls) > 0 {
+		obj.SetLabels(lbls)
+	}
+	opts := []engine.ReviewOption{engine.Operation(admissionv1beta1.Operation(strings.ToUpper(*operation)))}
+	if *namespace != "" {
+		ns := &corev1.Namespace{}
+		if err := c.Get(ctx, client.ObjectKey{Name: *namespace}, ns); err != nil {
+			return errors.Wrapf(err, "while reading namespace %s", *namespace)
+		}
+		opts = append(opts, engine.Namespace(ns))
+	}
+
+	matched, err := Matching(ctx, constraints, obj, opts...)
+	if err != nil {
+		return err
+	}
+	if len(matched) == 0 {
+		fmt.Fprintf(out, "no constraints match %s\n", describe(obj))
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tENFORCEMENT ACTION")
+	for _, m := range matched {
+		action, err := util.GetEnforcementAction(m.Object)
+		if err != nil {
+			action = util.Unrecognized
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", m.GetKind(), m.GetName(), action)
+	}
+	return w.Flush()
+}
+
+func describe(obj *unstructured.Unstructured) string {
+	s := obj.GroupVersionKind().String()
+	if obj.GetNamespace() != "" {
+		s += " in namespace " + obj.GetNamespace()
+	}
+	return s
+}
+
+// List returns every constraint of every template in the cluster
+func List(ctx context.Context, c client.Reader) ([]*unstructured.Unstructured, error) {
+	templs := &unstructured.UnstructuredList{}
+	templs.SetGroupVersionKind(templateListGVK)
+	if err := c.List(ctx, templs); err != nil {
+		return nil, errors.Wrap(err, "while listing constraint templates")
+	}
+	var constraints []*unstructured.Unstructured
+	for _, templ := range templs.Items {
+		kind, _, err := unstructured.NestedString(templ.Object, "spec", "crd", "spec", "names", "kind")
+		if err != nil || kind == "" {
+			continue
+		}
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(schema.GroupVersionKind{Group: "constraints.gatekeeper.sh", Version: "v1beta1", Kind: kind + "List"})
+		if err := c.List(ctx, list); err != nil {
+			// the template's CRD may not have been created
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "while listing %s constraints", kind)
+		}
+		for i := range list.Items {
+			constraints = append(constraints, &list.Items[i])
+		}
+	}
+	return constraints, nil
+}
+
+// matchAllTemplate returns a template for kind whose every constraint is violated by
+// every object it matches
+func matchAllTemplate(kind string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("templates.gatekeeper.sh/v1beta1")
+	u.SetKind("ConstraintTemplate")
+	u.SetName(strings.ToLower(kind))
+	u.Object["spec"] = map[string]interface{}{
+		"crd": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"names": map[string]interface{}{"kind": kind},
+			},
+		},
+		"targets": []interface{}{
+			map[string]interface{}{
+				"target": "admission.k8s.gatekeeper.sh",
+				"rego":   "package gatekeeper.match\n\nviolation[{\"msg\": \"matched\"}] {\n  true\n}\n",
+			},
+		},
+	}
+	return u
+}
+
+// Matcher finds the constraints whose match criteria select an object. The criteria are
+// evaluated by the admission target itself: each template is replaced with one that
+// reports every object it is asked about, so only matching decides the result.
+// Parameters are not evaluated, so they are ignored.
+type Matcher struct {
+	engine *engine.Engine
+}
+
+// NewMatcher returns a Matcher for constraints
+func NewMatcher(ctx context.Context, constraints []*unstructured.Unstructured) (*Matcher, error) {
+	e, err := engine.New()
+	if err != nil {
+		return nil, err
+	}
+	loaded := make(map[string]bool)
+	for _, constraint := range constraints {
+		kind := constraint.GetKind()
+		if !loaded[kind] {
+			if err := e.LoadTemplate(ctx, matchAllTemplate(kind)); err != nil {
+				return nil, err
+			}
+			loaded[kind] = true
+		}
+		constraint = constraint.DeepCopy()
+		unstructured.RemoveNestedField(constraint.Object, "spec", "parameters")
+		if err := e.LoadConstraint(ctx, constraint); err != nil {
+			return nil, err
+		}
+	}
+	return &Matcher{engine: e}, nil
+}
+
+// Matching returns the constraints whose match criteria select obj, sorted by kind and
+// name
+func (m *Matcher) Matching(ctx context.Context, obj *unstructured.Unstructured, opts ...engine.ReviewOption) ([]*unstructured.Unstructured, error) {
+	results, err := m.engine.Review(ctx, obj, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var matched []*unstructured.Unstructured
+	for _, r := range results {
+		if r.Constraint != nil {
+			matched = append(matched, r.Constraint)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].GetKind() != matched[j].GetKind() {
+			return matched[i].GetKind() < matched[j].GetKind()
+		}
+		return matched[i].GetName() < matched[j].GetName()
+	})
+	return matched, nil
+}
+
+// Matching returns the constraints whose match criteria select obj, sorted by kind and
+// name. See Matcher.
+func Matching(ctx context.Context, constraints []*unstructured.Unstructured, obj *unstructured.Unstructured, opts ...engine.ReviewOption) ([]*unstructured.Unstructured, error) {
+	m, err := NewMatcher(ctx, constraints)
+	if err != nil {
+		return nil, err
+	}
+	return m.Matching(ctx, obj, opts...)
+}
+
+func newClient(kubeconfig string) (client.Client, error) {
+	var cfg *rest.Config
+	var err error
+	if kubeconfig != "" {
+		cfg, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	} else {
+		cfg, err = config.GetConfig()
+	}
+	if err != nil {
+		return nil, err
+	}
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	// constraint kinds are only known once their templates are listed
+	mapper, err := apiutil.NewDynamicRESTMapper(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return client.New(cfg, client.Options{Scheme: scheme, Mapper: mapper})
+}
@@ -0,0 +1,126 @@
+package match
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/engine"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const constraintsYAML = `
+- apiVersion: constraints.gatekeeper.sh/v1beta1
+  kind: K8sRequiredLabels
+  metadata:
+    name: deployments
+  spec:
+    match:
+      kinds:
+        - apiGroups: ["apps"]
+          kinds: ["Deployment"]
+    parameters:
+      labels: ["owner"]
+- apiVersion: constraints.gatekeeper.sh/v1beta1
+  kind: K8sRequiredLabels
+  metadata:
+    name: team-apps
+  spec:
+    enforcementAction: dryrun
+    match:
+      namespaceSelector:
+        matchLabels:
+          team: apps
+- apiVersion: constraints.gatekeeper.sh/v1beta1
+  kind: K8sAllowedRepos
+  metadata:
+    name: labeled
+  spec:
+    match:
+      labelSelector:
+        matchLabels:
+          tier: web
+- apiVersion: constraints.gatekeeper.sh/v1beta1
+  kind: K8sAllowedRepos
+  metadata:
+    name: pods
+  spec:
+    match:
+      kinds:
+        - apiGroups: [""]
+          kinds: ["Pod"]
+- apiVersion: constraints.gatekeeper.sh/v1beta1
+  kind: K8sAllowedRepos
+  metadata:
+    name: updates
+  spec:
+    match:
+      operations: ["UPDATE"]
+`
+
+func TestMatching(t *testing.T) {
+	var objs []map[string]interface{}
+	if err := yaml.Unmarshal([]byte(constraintsYAML), &objs); err != nil {
+		t.Fatal(err)
+	}
+	var constraints []*unstructured.Unstructured
+	for _, o := range objs {
+		constraints = append(constraints, &unstructured.Unstructured{Object: o})
+	}
+	apps := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps", Labels: map[string]string{"team": "apps"}}}
+
+	deployment := func(lbls map[string]string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("apps/v1")
+		u.SetKind("Deployment")
+		u.SetNamespace("apps")
+		u.SetName("example")
+		u.SetLabels(lbls)
+		return u
+	}
+
+	tc := []struct {
+		Name     string
+		Obj      *unstructured.Unstructured
+		Opts     []engine.ReviewOption
+		Expected []string
+	}{
+		{
+			Name:     "Kind and namespace",
+			Obj:      deployment(nil),
+			Opts:     []engine.ReviewOption{engine.Namespace(apps)},
+			Expected: []string{"K8sRequiredLabels/deployments", "K8sRequiredLabels/team-apps"},
+		},
+		{
+			Name:     "Labels",
+			Obj:      deployment(map[string]string{"tier": "web"}),
+			Opts:     []engine.ReviewOption{engine.Namespace(apps)},
+			Expected: []string{"K8sAllowedRepos/labeled", "K8sRequiredLabels/deployments", "K8sRequiredLabels/team-apps"},
+		},
+		{
+			Name:     "Operation",
+			Obj:      deployment(nil),
+			Opts:     []engine.ReviewOption{engine.Namespace(apps), engine.Operation(admissionv1beta1.Update)},
+			Expected: []string{"K8sAllowedRepos/updates", "K8sRequiredLabels/deployments", "K8sRequiredLabels/team-apps"},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			matched, err := Matching(context.Background(), constraints, tt.Obj, tt.Opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, m := range matched {
+				got = append(got, m.GetKind()+"/"+m.GetName())
+			}
+			if !reflect.DeepEqual(got, tt.Expected) {
+				t.Errorf("got %v, want %v", got, tt.Expected)
+			}
+		})
+	}
+}
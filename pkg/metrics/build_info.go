@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/open-policy-agent/gatekeeper/version"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+const buildInfoName = "build_info"
+
+var (
+	buildInfoM = stats.Int64(buildInfoName, "Always 1. The tags describe the running build", stats.UnitDimensionless)
+
+	versionKey          = tag.MustNewKey("version")
+	gitCommitKey        = tag.MustNewKey("git_commit")
+	frameworkVersionKey = tag.MustNewKey("framework_version")
+
+	buildInfoView = &view.View{
+		Name:        buildInfoName,
+		Measure:     buildInfoM,
+		Description: "Always 1. The tags describe the running build",
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{versionKey, gitCommitKey, frameworkVersionKey},
+	}
+)
+
+func init() {
+	if err := view.Register(buildInfoView); err != nil {
+		panic(err)
+	}
+}
+
+// reportBuildInfo records the build_info metric for the running binary
+func reportBuildInfo() error {
+	info := version.Get()
+	ctx, err := tag.New(
+		context.Background(),
+		tag.Insert(versionKey, info.Version),
+		tag.Insert(gitCommitKey, info.GitCommit),
+		tag.Insert(frameworkVersionKey, info.FrameworkVersion),
+	)
+	if err != nil {
+		return err
+	}
+	return Record(ctx, buildInfoM.M(1))
+}
@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/open-policy-agent/gatekeeper/version"
+	"go.opencensus.io/stats/view"
+)
+
+func TestReportBuildInfo(t *testing.T) {
+	if err := reportBuildInfo(); err != nil {
+		t.Fatal(err)
+	}
+	row := checkData(t, buildInfoName, 1)
+	if value, ok := row.Data.(*view.LastValueData); !ok || value.Value != 1 {
+		t.Errorf("build_info = %v, wanted a last value of 1", row.Data)
+	}
+	tags := make(map[string]string)
+	for _, tg := range row.Tags {
+		tags[tg.Key.Name()] = tg.Value
+	}
+	if tags["version"] != version.Version || tags["framework_version"] == "" {
+		t.Errorf("tags = %v, wanted version %s and a framework version", tags, version.Version)
+	}
+}
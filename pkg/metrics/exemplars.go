@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"go.opencensus.io/metric/metricdata"
+	"go.opencensus.io/stats/view"
+)
+
+// Exemplar is the latest value recorded with attachments in a bucket of a histogram
+type Exemplar struct {
+	// Tags are the tags of the histogram series
+	Tags map[string]string `json:"tags"`
+	// UpperBound is the le of the bucket, or +Inf for the last one
+	UpperBound  string                 `json:"le"`
+	Value       float64                `json:"value"`
+	Timestamp   time.Time              `json:"timestamp"`
+	Attachments metricdata.Attachments `json:"attachments"`
+}
+
+// Exemplars returns the exemplars of each bucket of the named distribution view. The
+// Prometheus exporter predates exemplars, so they are only available from here.
+func Exemplars(name string) ([]Exemplar, error) {
+	v := view.Find(name)
+	if v == nil {
+		return nil, fmt.Errorf("no metric named %q", name)
+	}
+	if v.Aggregation.Type != view.AggTypeDistribution {
+		return nil, fmt.Errorf("metric %q is not a histogram", name)
+	}
+	rows, err := view.RetrieveData(name)
+	if err != nil {
+		return nil, err
+	}
+	exemplars := []Exemplar{}
+	for _, row := range rows {
+		data, ok := row.Data.(*view.DistributionData)
+		if !ok {
+			continue
+		}
+		tags := make(map[string]string, len(row.Tags))
+		for _, t := range row.Tags {
+			tags[t.Key.Name()] = t.Value
+		}
+		for i, e := range data.ExemplarsPerBucket {
+			if e == nil {
+				continue
+			}
+			exemplars = append(exemplars, Exemplar{
+				Tags:        tags,
+				UpperBound:  upperBound(v.Aggregation.Buckets, i),
+				Value:       e.Value,
+				Timestamp:   e.Timestamp,
+				Attachments: e.Attachments,
+			})
+		}
+	}
+	return exemplars, nil
+}
+
+func upperBound(bounds []float64, i int) string {
+	if i >= len(bounds) {
+		return fmt.Sprint(math.Inf(1))
+	}
+	return fmt.Sprint(bounds[i])
+}
+
+// ExemplarsHandler serves the exemplars of the histogram named by the metric query
+// parameter as JSON
+func ExemplarsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		exemplars, err := Exemplars(req.URL.Query().Get("metric"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(exemplars); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
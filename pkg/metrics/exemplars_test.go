@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opencensus.io/metric/metricdata"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+)
+
+func TestExemplars(t *testing.T) {
+	m := stats.Float64("test_exemplar_seconds", "test", stats.UnitSeconds)
+	v := &view.View{Name: "test_exemplar_seconds", Measure: m, Aggregation: view.Distribution(0.1, 1)}
+	if err := view.Register(v); err != nil {
+		t.Fatal(err)
+	}
+	defer view.Unregister(v)
+
+	ctx := context.Background()
+	if err := Record(ctx, m.M(0.05)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Record(ctx, m.M(2), stats.WithAttachments(metricdata.Attachments{"decision_id": "slow"})); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := httptest.NewRecorder()
+	ExemplarsHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/exemplars?metric=test_exemplar_seconds", nil))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, wanted %d: %s", resp.Code, http.StatusOK, resp.Body)
+	}
+	var got []Exemplar
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	// only the sample recorded with attachments is an exemplar
+	if len(got) != 1 {
+		t.Fatalf("got exemplars %v, wanted 1", got)
+	}
+	if got[0].UpperBound != "+Inf" || got[0].Value != 2 || got[0].Attachments["decision_id"] != "slow" {
+		t.Errorf("got exemplar %+v, wanted the 2s sample of decision slow in the +Inf bucket", got[0])
+	}
+
+	for _, name := range []string{"missing", buildInfoName} {
+		resp := httptest.NewRecorder()
+		ExemplarsHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/exemplars?metric="+name, nil))
+		if resp.Code != http.StatusNotFound {
+			t.Errorf("status for %s = %d, wanted %d", name, resp.Code, http.StatusNotFound)
+		}
+	}
+}
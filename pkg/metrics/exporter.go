@@ -41,6 +41,9 @@ func new(mgr manager.Manager) (*runner, error) {
 // Start implements the Runnable interface
 func (r *runner) Start(stop <-chan struct{}) error {
 	log.Info("Starting metrics runner")
+	if err := reportBuildInfo(); err != nil {
+		log.Error(err, "failed to report build info")
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	defer log.Info("Stopping metrics runner workers")
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"contrib.go.opencensus.io/exporter/prometheus"
+	"github.com/open-policy-agent/gatekeeper/version"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -35,6 +36,8 @@ func newPrometheusExporter() error {
 func startNewPromSrv(e *prometheus.Exporter, port int) *http.Server {
 	sm := http.NewServeMux()
 	sm.Handle("/metrics", e)
+	sm.Handle("/version", version.Handler())
+	sm.Handle("/exemplars", ExemplarsHandler())
 	curPromSrv = &http.Server{
 		Addr:    fmt.Sprintf(":%v", port),
 		Handler: sm,
@@ -5,8 +5,10 @@ type Status string
 const (
 	ActiveStatus Status = "active"
 	ErrorStatus  Status = "error"
+	// InactiveStatus is a constraint whose cluster conditions do not hold
+	InactiveStatus Status = "inactive"
 )
 
 var (
-	AllStatuses = []Status{ActiveStatus, ErrorStatus}
+	AllStatuses = []Status{ActiveStatus, ErrorStatus, InactiveStatus}
 )
@@ -0,0 +1,152 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readiness
+
+import (
+	"flag"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var log = logf.Log.WithName("readiness")
+
+var constraintsTimeout = flag.Duration("startup-constraints-timeout", time.Minute, "how long data sync waits at startup for the existing constraints to be ingested into OPA. Data sync starts, and the pod reports ready, once they are ingested or the timeout expires")
+
+// Startup is the Tracker shared by the controllers of this process
+var Startup = NewTracker()
+
+// Tracker sequences startup so that enforcement resumes before the slower data sync. The
+// constraints phase ends once every constraint that existed at startup has been
+// ingested, or after --startup-constraints-timeout, and only then do the sync
+// controllers replicate data into OPA.
+type Tracker struct {
+	mux sync.Mutex
+	// populated is true once the constraints that existed at startup are known
+	populated bool
+	expected  map[string]bool
+	// observed are constraints ingested before the expectations were populated
+	observed  map[string]bool
+	start     time.Time
+	ingested  chan struct{}
+	closeOnce sync.Once
+}
+
+var _ manager.Runnable = &Tracker{}
+
+// NewTracker returns a Tracker whose constraints phase has not ended
+func NewTracker() *Tracker {
+	return &Tracker{
+		expected: make(map[string]bool),
+		observed: make(map[string]bool),
+		start:    time.Now(),
+		ingested: make(chan struct{}),
+	}
+}
+
+// AddToManager adds Startup to mgr, ending the constraints phase on timeout, and makes
+// the pod report ready only once the phase has ended
+func AddToManager(mgr manager.Manager) error {
+	if err := mgr.Add(Startup); err != nil {
+		return err
+	}
+	return mgr.AddReadyzCheck("constraints", Startup.CheckConstraints)
+}
+
+// ExpectConstraints records the keys of the constraints that existed at startup. The
+// constraints phase ends once each has been observed.
+func (t *Tracker) ExpectConstraints(keys []string) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if t.populated {
+		return
+	}
+	for _, k := range keys {
+		if !t.observed[k] {
+			t.expected[k] = true
+		}
+	}
+	t.populated = true
+	t.observed = nil
+	log.Info("waiting for the existing constraints to be ingested", "count", len(t.expected))
+	t.checkDone()
+}
+
+// ObserveConstraint records that the constraint with the given key has been handled,
+// whether or not it could be ingested
+func (t *Tracker) ObserveConstraint(key string) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if !t.populated {
+		t.observed[key] = true
+		return
+	}
+	delete(t.expected, key)
+	t.checkDone()
+}
+
+// checkDone ends the constraints phase if nothing is left to observe. The lock must be held.
+func (t *Tracker) checkDone() {
+	if t.populated && len(t.expected) == 0 {
+		t.endConstraints("all existing constraints ingested")
+	}
+}
+
+func (t *Tracker) endConstraints(reason string) {
+	t.closeOnce.Do(func() {
+		log.Info("constraints phase ended, starting data sync", "reason", reason, "duration", time.Since(t.start).String())
+		close(t.ingested)
+	})
+}
+
+// ConstraintsIngested returns true once the constraints phase has ended
+func (t *Tracker) ConstraintsIngested() bool {
+	select {
+	case <-t.ingested:
+		return true
+	default:
+		return false
+	}
+}
+
+// CheckConstraints is a readyz check that fails until the constraints phase has ended
+func (t *Tracker) CheckConstraints(_ *http.Request) error {
+	if !t.ConstraintsIngested() {
+		return errors.New("existing constraints are not ingested yet")
+	}
+	return nil
+}
+
+// Start ends the constraints phase after --startup-constraints-timeout if the existing
+// constraints have not all been ingested by then
+func (t *Tracker) Start(stop <-chan struct{}) error {
+	timer := time.NewTimer(*constraintsTimeout)
+	defer timer.Stop()
+	select {
+	case <-stop:
+	case <-t.ingested:
+	case <-timer.C:
+		t.mux.Lock()
+		defer t.mux.Unlock()
+		log.Info("timed out waiting for the existing constraints", "pending", len(t.expected), "populated", t.populated)
+		t.endConstraints("timeout")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package readiness
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrackerEndsOnceExpectedConstraintsObserved(t *testing.T) {
+	tr := NewTracker()
+	tr.ObserveConstraint("Kind/early")
+	if tr.ConstraintsIngested() {
+		t.Fatal("constraints phase ended before expectations were populated")
+	}
+	if err := tr.CheckConstraints(nil); err == nil {
+		t.Error("readyz check passed before the constraints phase ended")
+	}
+
+	tr.ExpectConstraints([]string{"Kind/early", "Kind/a", "Kind/b"})
+	tr.ObserveConstraint("Kind/a")
+	if tr.ConstraintsIngested() {
+		t.Fatal("constraints phase ended with Kind/b pending")
+	}
+	tr.ObserveConstraint("Kind/b")
+	if !tr.ConstraintsIngested() {
+		t.Fatal("constraints phase did not end once every constraint was observed")
+	}
+	if err := tr.CheckConstraints(nil); err != nil {
+		t.Errorf("readyz check failed after the constraints phase ended: %s", err)
+	}
+	// later observations are no-ops
+	tr.ObserveConstraint("Kind/c")
+}
+
+func TestTrackerNoConstraints(t *testing.T) {
+	tr := NewTracker()
+	tr.ExpectConstraints(nil)
+	if !tr.ConstraintsIngested() {
+		t.Error("constraints phase did not end without constraints")
+	}
+}
+
+func TestTrackerTimeout(t *testing.T) {
+	old := *constraintsTimeout
+	*constraintsTimeout = 10 * time.Millisecond
+	defer func() { *constraintsTimeout = old }()
+
+	tr := NewTracker()
+	tr.ExpectConstraints([]string{"Kind/never"})
+	stop := make(chan struct{})
+	defer close(stop)
+	if err := tr.Start(stop); err != nil {
+		t.Fatalf("Start returned %s", err)
+	}
+	if !tr.ConstraintsIngested() {
+		t.Error("constraints phase did not end on timeout")
+	}
+}
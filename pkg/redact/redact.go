@@ -0,0 +1,253 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package redact keeps the data of Secrets, and of fields configured as sensitive, out of
+// violation messages, logs, status and events.
+package redact
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Redacted replaces each sensitive value
+const Redacted = "[REDACTED]"
+
+// minValueLength is the length below which values are left in messages, as replacing
+// them would mangle the rest of the message
+const minValueLength = 4
+
+// lastAppliedAnnotation is written by `kubectl apply` and holds a copy of the object
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+var sensitiveFields = flag.String("redact-fields", "", "comma-separated list of dotted field paths, such as spec.containers.env.value, whose values are redacted from violation messages and logged objects of any kind. Lists along a path are walked element by element. The data of Secrets is always redacted")
+
+// Fields returns the sensitive field paths configured by flag
+func Fields() [][]string {
+	var fields [][]string
+	for _, field := range strings.Split(*sensitiveFields, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, strings.Split(field, "."))
+		}
+	}
+	return fields
+}
+
+// Applies returns whether objects of the kind can hold values that are redacted
+func Applies(group, kind string) bool {
+	return (group == "" && kind == "Secret") || len(Fields()) > 0
+}
+
+func isSecret(obj map[string]interface{}) bool {
+	return obj["apiVersion"] == "v1" && obj["kind"] == "Secret"
+}
+
+// Object returns obj with the values of its sensitive fields replaced by Redacted. obj is
+// copied only if it may have sensitive fields, so it is never modified. The keys of a
+// Secret's data are kept, so logs still say what it holds.
+func Object(obj map[string]interface{}) map[string]interface{} {
+	fields := Fields()
+	if obj == nil || (!isSecret(obj) && len(fields) == 0) {
+		return obj
+	}
+	out := runtime.DeepCopyJSON(obj)
+	if isSecret(out) {
+		for _, field := range []string{"data", "stringData"} {
+			if data, ok := out[field].(map[string]interface{}); ok {
+				for k := range data {
+					data[k] = Redacted
+				}
+			}
+		}
+		if md, ok := out["metadata"].(map[string]interface{}); ok {
+			if annotations, ok := md["annotations"].(map[string]interface{}); ok {
+				if _, ok := annotations[lastAppliedAnnotation]; ok {
+					annotations[lastAppliedAnnotation] = Redacted
+				}
+			}
+		}
+	}
+	for _, path := range fields {
+		visit(out, path, func(parent map[string]interface{}, key string) {
+			parent[key] = Redacted
+		})
+	}
+	return out
+}
+
+// Unstructured returns obj with its sensitive values redacted, for logging
+func Unstructured(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	if obj == nil {
+		return nil
+	}
+	return &unstructured.Unstructured{Object: Object(obj.Object)}
+}
+
+// Values returns the sensitive values of objs, as they could appear in a message: the
+// data of Secrets, both base64-encoded and decoded, and the values of the configured
+// fields. Longer values come first, so a value is replaced before any value it contains.
+func Values(objs ...map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	add := func(v string) {
+		if len(v) >= minValueLength {
+			seen[v] = true
+		}
+	}
+	fields := Fields()
+	for _, obj := range objs {
+		if obj == nil {
+			continue
+		}
+		if isSecret(obj) {
+			if data, ok := obj["data"].(map[string]interface{}); ok {
+				for _, v := range data {
+					s, ok := v.(string)
+					if !ok {
+						continue
+					}
+					add(s)
+					if decoded, err := base64.StdEncoding.DecodeString(s); err == nil {
+						add(string(decoded))
+						add(strings.TrimSpace(string(decoded)))
+					}
+				}
+			}
+			if data, ok := obj["stringData"].(map[string]interface{}); ok {
+				for _, v := range data {
+					scalars(v, add)
+				}
+			}
+		}
+		for _, path := range fields {
+			visit(obj, path, func(parent map[string]interface{}, key string) {
+				scalars(parent[key], add)
+			})
+		}
+	}
+	values := make([]string, 0, len(seen))
+	for v := range seen {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+	return values
+}
+
+// Message returns msg with each of values replaced by Redacted
+func Message(msg string, values []string) string {
+	if len(values) == 0 {
+		return msg
+	}
+	oldnew := make([]string, 0, 2*len(values))
+	for _, v := range values {
+		oldnew = append(oldnew, v, Redacted)
+	}
+	return strings.NewReplacer(oldnew...).Replace(msg)
+}
+
+// JSON returns the JSON document js with every Kubernetes object in it redacted, such as
+// the objects synced into a dump of the policy engine's data
+func JSON(js string) (string, error) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(js), &doc); err != nil {
+		return "", err
+	}
+	b, err := json.MarshalIndent(tree(doc), "", "   ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Tree returns the decoded JSON value v with every Kubernetes object in it redacted. v
+// is not modified.
+func Tree(v interface{}) interface{} {
+	return tree(v)
+}
+
+// tree redacts every map under v that has an apiVersion and a kind
+func tree(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		_, hasVersion := v["apiVersion"].(string)
+		_, hasKind := v["kind"].(string)
+		if hasVersion && hasKind {
+			return Object(v)
+		}
+		out := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			out[k] = tree(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = tree(child)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// visit calls fn with the map holding the last field of path, for each such map in v.
+// Lists along path are walked element by element.
+func visit(v interface{}, path []string, fn func(parent map[string]interface{}, key string)) {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			visit(item, path, fn)
+		}
+	case map[string]interface{}:
+		child, ok := v[path[0]]
+		if !ok {
+			return
+		}
+		if len(path) == 1 {
+			fn(v, path[0])
+			return
+		}
+		visit(child, path[1:], fn)
+	}
+}
+
+// scalars calls add with every string and number under v
+func scalars(v interface{}, add func(string)) {
+	switch v := v.(type) {
+	case string:
+		add(v)
+	case int64, float64, json.Number:
+		add(fmt.Sprint(v))
+	case []interface{}:
+		for _, item := range v {
+			scalars(item, add)
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			scalars(item, add)
+		}
+	}
+}
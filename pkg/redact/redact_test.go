@@ -0,0 +1,156 @@
+package redact
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, js string) map[string]interface{} {
+	t.Helper()
+	obj := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(js), &obj); err != nil {
+		t.Fatal(err)
+	}
+	return obj
+}
+
+const secret = `
+{
+	"apiVersion": "v1",
+	"kind": "Secret",
+	"metadata": {
+		"name": "db",
+		"annotations": {"kubectl.kubernetes.io/last-applied-configuration": "{\"data\": {\"password\": \"aHVudGVyMjIK\"}}"}
+	},
+	"data": {"password": "aHVudGVyMjIK", "pin": "MTIz"},
+	"stringData": {"user": "admin"}
+}
+`
+
+const pod = `
+{
+	"apiVersion": "v1",
+	"kind": "Pod",
+	"metadata": {"name": "app"},
+	"spec": {"containers": [
+		{"name": "a", "env": [{"name": "API_KEY", "value": "abcd1234"}, {"name": "PORT", "value": 8080}]},
+		{"name": "b"}
+	]}
+}
+`
+
+func TestObject(t *testing.T) {
+	defer func(v string) { *sensitiveFields = v }(*sensitiveFields)
+	*sensitiveFields = "spec.containers.env.value, spec.missing"
+
+	tc := []struct {
+		Name     string
+		Obj      string
+		Expected string
+	}{
+		{
+			Name: "Secret",
+			Obj:  secret,
+			Expected: `
+{
+	"apiVersion": "v1",
+	"kind": "Secret",
+	"metadata": {
+		"name": "db",
+		"annotations": {"kubectl.kubernetes.io/last-applied-configuration": "[REDACTED]"}
+	},
+	"data": {"password": "[REDACTED]", "pin": "[REDACTED]"},
+	"stringData": {"user": "[REDACTED]"}
+}
+`,
+		},
+		{
+			Name: "Configured fields",
+			Obj:  pod,
+			Expected: `
+{
+	"apiVersion": "v1",
+	"kind": "Pod",
+	"metadata": {"name": "app"},
+	"spec": {"containers": [
+		{"name": "a", "env": [{"name": "API_KEY", "value": "[REDACTED]"}, {"name": "PORT", "value": "[REDACTED]"}]},
+		{"name": "b"}
+	]}
+}
+`,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			obj := parse(t, tt.Obj)
+			got := Object(obj)
+			if expected := parse(t, tt.Expected); !reflect.DeepEqual(got, expected) {
+				t.Errorf("Object() = %v, wanted %v", got, expected)
+			}
+			if !reflect.DeepEqual(obj, parse(t, tt.Obj)) {
+				t.Error("Object() changed its argument")
+			}
+		})
+	}
+}
+
+func TestMessage(t *testing.T) {
+	defer func(v string) { *sensitiveFields = v }(*sensitiveFields)
+	*sensitiveFields = "spec.containers.env.value"
+
+	tc := []struct {
+		Name     string
+		Obj      string
+		Msg      string
+		Expected string
+	}{
+		{
+			Name:     "Encoded and decoded Secret data",
+			Obj:      secret,
+			Msg:      "password hunter22 (aHVudGVyMjIK) of user admin is weak",
+			Expected: "password [REDACTED] ([REDACTED]) of user [REDACTED] is weak",
+		},
+		{
+			// the pin decodes to 123, too short to replace without mangling the message
+			Name:     "Short values are kept",
+			Obj:      secret,
+			Msg:      "pin 123 is too short",
+			Expected: "pin 123 is too short",
+		},
+		{
+			Name:     "Configured fields",
+			Obj:      pod,
+			Msg:      "env API_KEY=abcd1234 and PORT=8080 are not allowed",
+			Expected: "env API_KEY=[REDACTED] and PORT=[REDACTED] are not allowed",
+		},
+		{
+			Name:     "Other kinds",
+			Obj:      `{"apiVersion": "v1", "kind": "ConfigMap", "data": {"password": "hunter22"}}`,
+			Msg:      "password hunter22 is weak",
+			Expected: "password hunter22 is weak",
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			if got := Message(tt.Msg, Values(parse(t, tt.Obj))); got != tt.Expected {
+				t.Errorf("Message() = %q, wanted %q", got, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	dump := `{"data": {"inventory": {"namespace": {"default": {"v1": {"Secret": {"db": ` + secret + `}}}}}}}`
+	got, err := JSON(dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "aHVudGVyMjIK") || !strings.Contains(got, `"password": "[REDACTED]"`) {
+		t.Errorf("JSON() = %s, wanted the Secret data redacted", got)
+	}
+	if _, err := JSON("not json"); err == nil {
+		t.Error("JSON() error = nil for an invalid document")
+	}
+}
@@ -0,0 +1,118 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package replay queues the objects the webhook admitted without reviewing them, so audit
+// can re-check them as soon as reviews succeed again. Only requests the webhook received
+// are queued: those it failed to review, and those whose review finished after the API
+// server stopped waiting. Requests the API server admitted by its failure policy while no
+// webhook was reachable never reach Gatekeeper, so they are left to the next audit.
+package replay
+
+import (
+	"flag"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var queueSize = flag.Int("replay-queue-size", 1000, "maximum number of objects admitted without review that are kept for audit to re-check. Further objects are left to the next audit. 0 to disable")
+
+// Pending holds the objects the webhook admitted without review, until audit re-checks them.
+// It is nil when the webhook and audit run in separate processes, as neither would see
+// the other's queue.
+var Pending = NewQueue(func() int { return *queueSize })
+
+// Ref identifies an object admitted without review
+type Ref struct {
+	schema.GroupVersionKind
+	Namespace string
+	Name      string
+}
+
+// Queue is a bounded set of Refs. Adding a Ref that is already queued has no effect.
+type Queue struct {
+	mux  sync.Mutex
+	refs map[Ref]bool
+	// order keeps the refs in the order they were added
+	order []Ref
+	// size returns the capacity, read on each Add so it follows the flag once parsed
+	size  func() int
+	ready chan struct{}
+}
+
+// NewQueue returns an empty queue holding up to size() refs
+func NewQueue(size func() int) *Queue {
+	return &Queue{
+		refs:  make(map[Ref]bool),
+		size:  size,
+		ready: make(chan struct{}, 1),
+	}
+}
+
+// Add queues ref and returns whether it is queued. Refs without a name, such as creates
+// relying on generateName, cannot be read back and are not queued.
+func (q *Queue) Add(ref Ref) bool {
+	if ref.Name == "" {
+		return false
+	}
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	if q.refs[ref] {
+		return true
+	}
+	if len(q.order) >= q.size() {
+		return false
+	}
+	q.refs[ref] = true
+	q.order = append(q.order, ref)
+	return true
+}
+
+// Recovered signals that reviews succeed again, so the queued refs can be re-checked.
+// Signals are coalesced, and ignored while the queue is empty.
+func (q *Queue) Recovered() {
+	q.mux.Lock()
+	empty := len(q.order) == 0
+	q.mux.Unlock()
+	if empty {
+		return
+	}
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Ready receives a value after Recovered is called on a non-empty queue
+func (q *Queue) Ready() <-chan struct{} {
+	return q.ready
+}
+
+// Drain empties the queue and returns its refs in the order they were added
+func (q *Queue) Drain() []Ref {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	refs := q.order
+	q.refs = make(map[Ref]bool)
+	q.order = nil
+	return refs
+}
+
+// Len returns the number of queued refs
+func (q *Queue) Len() int {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	return len(q.order)
+}
@@ -0,0 +1,68 @@
+package replay
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func newRef(name string) Ref {
+	return Ref{
+		GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+		Namespace:        "default",
+		Name:             name,
+	}
+}
+
+func TestQueue(t *testing.T) {
+	q := NewQueue(func() int { return 2 })
+	if !q.Add(newRef("a")) || !q.Add(newRef("b")) {
+		t.Fatal("Add() = false below capacity, wanted true")
+	}
+	if !q.Add(newRef("a")) {
+		t.Error("Add() = false for a queued ref, wanted true")
+	}
+	if q.Add(newRef("c")) {
+		t.Error("Add() = true beyond capacity, wanted false")
+	}
+	if q.Add(newRef("")) {
+		t.Error("Add() = true for a ref without a name, wanted false")
+	}
+	if got := q.Len(); got != 2 {
+		t.Errorf("Len() = %d, wanted 2", got)
+	}
+
+	q.Recovered()
+	q.Recovered()
+	select {
+	case <-q.Ready():
+	default:
+		t.Fatal("queue not ready after Recovered()")
+	}
+	select {
+	case <-q.Ready():
+		t.Error("Recovered() signals not coalesced")
+	default:
+	}
+
+	if got, want := q.Drain(), []Ref{newRef("a"), newRef("b")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Drain() = %v, wanted %v", got, want)
+	}
+	if got := q.Len(); got != 0 {
+		t.Errorf("Len() after Drain() = %d, wanted 0", got)
+	}
+	if !q.Add(newRef("a")) {
+		t.Error("Add() = false after Drain(), wanted true")
+	}
+}
+
+func TestRecoveredEmpty(t *testing.T) {
+	q := NewQueue(func() int { return 2 })
+	q.Recovered()
+	select {
+	case <-q.Ready():
+		t.Error("empty queue ready after Recovered(), wanted no signal")
+	default:
+	}
+}
@@ -0,0 +1,246 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package report keeps a GatekeeperReport up to date with the health of this pod
+package report
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	configv1alpha1 "github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/audit"
+	"github.com/open-policy-agent/gatekeeper/pkg/controller/constraint"
+	"github.com/open-policy-agent/gatekeeper/pkg/featuregate"
+	"github.com/open-policy-agent/gatekeeper/pkg/lint"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	"github.com/open-policy-agent/gatekeeper/version"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var log = logf.Log.WithName("report").WithValues(logging.Process, "report")
+
+var reportInterval = flag.Int("report-interval", 60, "interval to update this pod's GatekeeperReport in seconds. 0 disables the report")
+
+// certName is the webhook's serving certificate in its --cert-dir
+const certName = "tls.crt"
+
+// staleIntervals is how many report intervals a report may go without an update before
+// it is deleted, if its pod no longer exists
+const staleIntervals = 3
+
+// +kubebuilder:rbac:groups=config.gatekeeper.sh,resources=gatekeeperreports,verbs=get;list;create;update;delete
+
+// AddToManager adds the reporter to the Manager unless reports are disabled
+func AddToManager(mgr manager.Manager, certDir string) error {
+	if *reportInterval == 0 {
+		log.Info("GatekeeperReport is disabled")
+		return nil
+	}
+	name := util.GetID()
+	if name == "" {
+		log.Info("GatekeeperReport is disabled, POD_NAME is not set")
+		return nil
+	}
+	return mgr.Add(&Reporter{
+		client:      mgr.GetClient(),
+		pods:        mgr.GetAPIReader(),
+		name:        name,
+		namespace:   util.GetNamespace(),
+		certPath:    filepath.Join(certDir, certName),
+		interval:    time.Duration(*reportInterval) * time.Second,
+		constraints: constraint.Cache.Active,
+		lastAudit:   audit.LastRun,
+	})
+}
+
+// Reporter periodically writes the GatekeeperReport named after this pod
+type Reporter struct {
+	client client.Client
+	// pods reads around the cache, so pods need not be watched
+	pods      client.Reader
+	name      string
+	namespace string
+	certPath  string
+	interval  time.Duration
+	// constraints returns the number of constraints this pod enforces
+	constraints func() int
+	// lastAudit returns when this pod last finished an audit
+	lastAudit func() time.Time
+}
+
+// Start implements manager.Runnable
+func (r *Reporter) Start(stop <-chan struct{}) error {
+	log.Info("starting reporter", "report", r.name)
+	wait.Until(func() {
+		if err := r.write(context.Background()); err != nil {
+			log.Error(err, "could not write GatekeeperReport")
+		}
+		if err := r.collectGarbage(context.Background(), time.Now()); err != nil {
+			log.Error(err, "could not delete stale GatekeeperReports")
+		}
+	}, r.interval, stop)
+	// a cluster-scoped report cannot be owned by the pod, so it is removed on the way out.
+	// The reports of pods that did not shut down cleanly are collected by the other pods.
+	report := &configv1alpha1.GatekeeperReport{ObjectMeta: metav1.ObjectMeta{Name: r.name}}
+	if err := r.client.Delete(context.Background(), report); err != nil && !apierrors.IsNotFound(err) {
+		log.Error(err, "could not delete GatekeeperReport")
+	}
+	log.Info("stopping reporter")
+	return nil
+}
+
+// write creates or updates this pod's report
+func (r *Reporter) write(ctx context.Context) error {
+	status, err := r.collect(ctx)
+	if err != nil {
+		return err
+	}
+	existing := &configv1alpha1.GatekeeperReport{}
+	err = r.client.Get(ctx, types.NamespacedName{Name: r.name}, existing)
+	if apierrors.IsNotFound(err) {
+		return r.client.Create(ctx, &configv1alpha1.GatekeeperReport{
+			ObjectMeta: metav1.ObjectMeta{Name: r.name},
+			Status:     *status,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	existing.Status = *status
+	return r.client.Update(ctx, existing)
+}
+
+// collectGarbage deletes the reports of other pods that were not updated for
+// staleIntervals as of now and whose pod no longer exists
+func (r *Reporter) collectGarbage(ctx context.Context, now time.Time) error {
+	reports := &configv1alpha1.GatekeeperReportList{}
+	if err := r.client.List(ctx, reports); err != nil {
+		return errors.Wrap(err, "could not list GatekeeperReports")
+	}
+	for i := range reports.Items {
+		report := &reports.Items[i]
+		if report.Name == r.name || report.Status.PodNamespace == "" {
+			continue
+		}
+		updated := report.CreationTimestamp.Time
+		if report.Status.LastUpdated != nil {
+			updated = report.Status.LastUpdated.Time
+		}
+		if now.Sub(updated) < staleIntervals*r.interval {
+			continue
+		}
+		pod := &corev1.Pod{}
+		err := r.pods.Get(ctx, types.NamespacedName{Namespace: report.Status.PodNamespace, Name: report.Name}, pod)
+		if err == nil {
+			continue
+		}
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		if err := r.client.Delete(ctx, report); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		log.Info("deleted the GatekeeperReport of a pod that no longer exists", "report", report.Name)
+	}
+	return nil
+}
+
+// collect gathers the current state of this pod
+func (r *Reporter) collect(ctx context.Context) (*configv1alpha1.GatekeeperReportStatus, error) {
+	now := metav1.Now()
+	status := &configv1alpha1.GatekeeperReportStatus{
+		PodNamespace: r.namespace,
+		Version:      version.Version,
+		GitCommit:    version.Vcs,
+		FeatureGates: featuregate.EnabledGates(),
+		Constraints:  int64(r.constraints()),
+		LastUpdated:  &now,
+	}
+	templs := &templv1beta1.ConstraintTemplateList{}
+	if err := r.client.List(ctx, templs); err != nil {
+		return nil, errors.Wrap(err, "could not list constraint templates")
+	}
+	for i := range templs.Items {
+		for _, byPod := range templs.Items[i].Status.ByPod {
+			if byPod.ID != r.name {
+				continue
+			}
+			if hasErrors(byPod) {
+				status.TemplateErrors++
+			} else {
+				status.Templates++
+			}
+		}
+	}
+	if t := r.lastAudit(); !t.IsZero() {
+		status.LastAudit = &metav1.Time{Time: t}
+	}
+	expiry, err := certExpiry(r.certPath)
+	if err != nil {
+		log.Error(err, "could not read the webhook certificate", "path", r.certPath)
+	}
+	if !expiry.IsZero() {
+		status.WebhookCertExpiry = &metav1.Time{Time: expiry}
+	}
+	return status, nil
+}
+
+// certExpiry returns when the first certificate in path expires, or the zero time if
+// the file does not exist yet
+func certExpiry(path string) (time.Time, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return time.Time{}, errors.Errorf("%s holds no PEM data", path)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
+
+// hasErrors returns whether status reports errors other than lint warnings
+func hasErrors(status *templv1beta1.ByPodStatus) bool {
+	for _, e := range status.Errors {
+		if e.Code != lint.StatusWarningCode {
+			return true
+		}
+	}
+	return false
+}
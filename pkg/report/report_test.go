@@ -0,0 +1,158 @@
+package report
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"github.com/open-policy-agent/gatekeeper/api"
+	configv1alpha1 "github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/lint"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func writeCert(t *testing.T, path string, notAfter time.Time) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gatekeeper-webhook-service"},
+		NotBefore:    notAfter.Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func template(name string, byPod ...*templv1beta1.ByPodStatus) *templv1beta1.ConstraintTemplate {
+	templ := &templv1beta1.ConstraintTemplate{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	templ.Status.ByPod = byPod
+	return templ
+}
+
+func TestWrite(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certPath := filepath.Join(dir, certName)
+	expiry := time.Now().Add(24 * time.Hour).Truncate(time.Second).UTC()
+	writeCert(t, certPath, expiry)
+	audited := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	c := fake.NewFakeClientWithScheme(scheme,
+		template("k8srequiredlabels", &templv1beta1.ByPodStatus{ID: "gatekeeper-0"}, &templv1beta1.ByPodStatus{ID: "gatekeeper-1"}),
+		template("k8sallowedrepos", &templv1beta1.ByPodStatus{ID: "gatekeeper-0", Errors: []*templv1beta1.CreateCRDError{{Code: "rego_parse_error"}}}),
+		template("k8sdisallowedtags", &templv1beta1.ByPodStatus{ID: "gatekeeper-1"}),
+		template("k8sblockwildcard", &templv1beta1.ByPodStatus{ID: "gatekeeper-0", Errors: []*templv1beta1.CreateCRDError{{Code: lint.StatusWarningCode}}}),
+	)
+	r := &Reporter{
+		client:      c,
+		name:        "gatekeeper-0",
+		namespace:   "gatekeeper-system",
+		certPath:    certPath,
+		constraints: func() int { return 4 },
+		lastAudit:   func() time.Time { return audited },
+	}
+
+	// the first write creates the report, later writes update it
+	for i := 0; i < 2; i++ {
+		if err := r.write(context.TODO()); err != nil {
+			t.Fatalf("write() error = %v", err)
+		}
+	}
+	got := &configv1alpha1.GatekeeperReport{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: "gatekeeper-0"}, got); err != nil {
+		t.Fatal(err)
+	}
+	s := got.Status
+	if s.Templates != 2 || s.TemplateErrors != 1 || s.Constraints != 4 {
+		t.Errorf("templates, template errors, constraints = %d, %d, %d, wanted 2, 1, 4", s.Templates, s.TemplateErrors, s.Constraints)
+	}
+	if s.PodNamespace != "gatekeeper-system" || s.Version == "" || s.LastUpdated == nil {
+		t.Errorf("status = %+v, wanted pod namespace, version and update time", s)
+	}
+	if s.LastAudit == nil || !s.LastAudit.Time.Equal(audited) {
+		t.Errorf("LastAudit = %v, wanted %v", s.LastAudit, audited)
+	}
+	if s.WebhookCertExpiry == nil || !s.WebhookCertExpiry.Time.Equal(expiry) {
+		t.Errorf("WebhookCertExpiry = %v, wanted %v", s.WebhookCertExpiry, expiry)
+	}
+
+	// pods that do not run audit and have no certificate yet leave those fields unset
+	r.lastAudit = func() time.Time { return time.Time{} }
+	r.certPath = filepath.Join(dir, "missing.crt")
+	if err := r.write(context.TODO()); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+	got = &configv1alpha1.GatekeeperReport{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: "gatekeeper-0"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.LastAudit != nil || got.Status.WebhookCertExpiry != nil {
+		t.Errorf("status = %+v, wanted no audit time or certificate expiry", got.Status)
+	}
+}
+
+func TestCollectGarbage(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	report := func(name string, updated time.Time) *configv1alpha1.GatekeeperReport {
+		lastUpdated := metav1.NewTime(updated)
+		return &configv1alpha1.GatekeeperReport{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     configv1alpha1.GatekeeperReportStatus{PodNamespace: "gatekeeper-system", LastUpdated: &lastUpdated},
+		}
+	}
+	c := fake.NewFakeClientWithScheme(scheme,
+		report("gatekeeper-0", now.Add(-time.Hour)),
+		report("gatekeeper-1", now.Add(-time.Hour)),
+		report("gatekeeper-2", now.Add(-time.Hour)),
+		report("gatekeeper-3", now.Add(-time.Minute)),
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "gatekeeper-system", Name: "gatekeeper-1"}},
+	)
+	r := &Reporter{client: c, pods: c, name: "gatekeeper-0", interval: time.Minute}
+	if err := r.collectGarbage(context.TODO(), now); err != nil {
+		t.Fatalf("collectGarbage() error = %v", err)
+	}
+	// only the stale report of a deleted pod other than this one is deleted
+	for name, wantDeleted := range map[string]bool{"gatekeeper-0": false, "gatekeeper-1": false, "gatekeeper-2": true, "gatekeeper-3": false} {
+		err := c.Get(context.TODO(), types.NamespacedName{Name: name}, &configv1alpha1.GatekeeperReport{})
+		if deleted := apierrors.IsNotFound(err); deleted != wantDeleted {
+			t.Errorf("report %s deleted = %v, wanted %v (err %v)", name, deleted, wantDeleted, err)
+		}
+	}
+}
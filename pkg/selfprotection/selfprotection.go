@@ -0,0 +1,334 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package selfprotection keeps the webhook entry and PodDisruptionBudget that protect
+// Gatekeeper itself while the SelfProtection feature gate is on. The constraints that
+// decide who may change Gatekeeper are the self-protection library of package bundle.
+package selfprotection
+
+import (
+	"context"
+	"flag"
+	"reflect"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/bundle"
+	"github.com/open-policy-agent/gatekeeper/pkg/featuregate"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	"github.com/pkg/errors"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	ctrlName = "self-protection-controller"
+
+	// WebhookName is the entry added to Gatekeeper's ValidatingWebhookConfiguration to
+	// send changes to Gatekeeper's own CRDs and deployment to the webhook
+	WebhookName = "self-protection.gatekeeper.sh"
+	// validationWebhookName is the entry whose clientConfig the self-protection entry uses
+	validationWebhookName = "validation.gatekeeper.sh"
+
+	// PDBName is the name of the PodDisruptionBudget of the Gatekeeper pods
+	PDBName = "gatekeeper-controller-manager"
+
+	systemLabel = "gatekeeper.sh/system"
+)
+
+var (
+	log = logf.Log.WithName("controller").WithValues(logging.Process, "self_protection_controller")
+
+	pdbSelector = flag.String("self-protection-pdb-selector", "control-plane=controller-manager", "label selector of the Gatekeeper pods covered by the PodDisruptionBudget kept while the SelfProtection feature gate is on")
+
+	vwhGVK  = schema.GroupVersionKind{Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "ValidatingWebhookConfiguration"}
+	vwhKey  = types.NamespacedName{Name: "gatekeeper-validating-webhook-configuration"}
+	request = reconcile.Request{NamespacedName: vwhKey}
+)
+
+// AddToManager adds the self-protection controller to mgr if the SelfProtection feature
+// gate is on. Otherwise it adds a startup task that removes what an earlier run with the
+// gate on left behind.
+func AddToManager(mgr manager.Manager) error {
+	selector, err := metav1.ParseToLabelSelector(*pdbSelector)
+	if err != nil {
+		return errors.Wrap(err, "invalid --self-protection-pdb-selector")
+	}
+	if !featuregate.Enabled(featuregate.SelfProtection) {
+		// a direct client, so the manager does not start watching these kinds
+		c, err := client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper()})
+		if err != nil {
+			return err
+		}
+		r := &ReconcileSelfProtection{client: c, namespace: util.GetNamespace(), selector: selector}
+		return mgr.Add(manager.RunnableFunc(func(<-chan struct{}) error {
+			// a failure must not stop the manager, so it is only logged
+			if err := r.remove(context.Background()); err != nil {
+				log.Error(err, "unable to remove self-protection")
+			}
+			return nil
+		}))
+	}
+
+	r := &ReconcileSelfProtection{client: mgr.GetClient(), namespace: util.GetNamespace(), selector: selector}
+	c, err := controller.New(ctrlName, mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	// the webhook configuration and the PDB are reconciled together
+	toRequest := func(name, namespace string) *handler.EnqueueRequestsFromMapFunc {
+		return &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(
+			func(obj handler.MapObject) []reconcile.Request {
+				if obj.Meta.GetName() != name || obj.Meta.GetNamespace() != namespace {
+					return nil
+				}
+				return []reconcile.Request{request}
+			},
+		)}
+	}
+	vwh := &unstructured.Unstructured{}
+	vwh.SetGroupVersionKind(vwhGVK)
+	if err := c.Watch(&source.Kind{Type: vwh}, toRequest(vwhKey.Name, "")); err != nil {
+		return err
+	}
+	return c.Watch(&source.Kind{Type: &policyv1beta1.PodDisruptionBudget{}}, toRequest(PDBName, r.namespace))
+}
+
+var _ reconcile.Reconciler = &ReconcileSelfProtection{}
+
+// ReconcileSelfProtection keeps the self-protection webhook entry and the
+// PodDisruptionBudget of the Gatekeeper pods
+type ReconcileSelfProtection struct {
+	client    client.Client
+	namespace string
+	selector  *metav1.LabelSelector
+}
+
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=policy,namespace=gatekeeper-system,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;delete
+
+func (r *ReconcileSelfProtection) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx := context.TODO()
+	if err := r.ensureWebhook(ctx); err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := r.ensurePDB(ctx); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+// protectionWebhook returns the self-protection entry, served by the same endpoint as the
+// validation entry. It is only sent updates and deletions of the CRDs and deployments labeled
+// gatekeeper.sh/system, and fails open like the validation entry so a Gatekeeper outage
+// cannot lock administrators out.
+func protectionWebhook(validation map[string]interface{}) map[string]interface{} {
+	rule := func(group, resource string) interface{} {
+		return map[string]interface{}{
+			"apiGroups":   []interface{}{group},
+			"apiVersions": []interface{}{"*"},
+			"operations":  []interface{}{"UPDATE", "DELETE"},
+			"resources":   []interface{}{resource},
+			"scope":       "*",
+		}
+	}
+	return map[string]interface{}{
+		"name":         WebhookName,
+		"clientConfig": runtime.DeepCopyJSONValue(validation["clientConfig"]),
+		"rules": []interface{}{
+			rule("apiextensions.k8s.io", "customresourcedefinitions"),
+			rule("apps", "deployments"),
+		},
+		"objectSelector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{systemLabel: "yes"},
+		},
+		"failurePolicy":  "Ignore",
+		"sideEffects":    "None",
+		"timeoutSeconds": int64(5),
+	}
+}
+
+// ensureWebhook adds the self-protection entry to the webhook configuration, or updates
+// it to match the validation entry
+func (r *ReconcileSelfProtection) ensureWebhook(ctx context.Context) error {
+	vwh, webhooks, err := r.getWebhooks(ctx)
+	if err != nil || vwh == nil {
+		return err
+	}
+	var validation map[string]interface{}
+	current := -1
+	for i, w := range webhooks {
+		wh, ok := w.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch wh["name"] {
+		case validationWebhookName:
+			validation = wh
+		case WebhookName:
+			current = i
+		}
+	}
+	if validation == nil {
+		log.Info("the webhook configuration has no validation entry, not adding self-protection", "webhook", validationWebhookName)
+		return nil
+	}
+	wanted := protectionWebhook(validation)
+	if current < 0 {
+		webhooks = append(webhooks, wanted)
+	} else {
+		existing := webhooks[current].(map[string]interface{})
+		if setFieldsEqual(existing, wanted) {
+			return nil
+		}
+		// keep the fields the API server defaulted
+		for k, v := range wanted {
+			existing[k] = v
+		}
+		webhooks[current] = existing
+	}
+	if err := unstructured.SetNestedSlice(vwh.Object, webhooks, "webhooks"); err != nil {
+		return err
+	}
+	log.Info("updating self-protection webhook", "webhook", WebhookName)
+	return r.client.Update(ctx, vwh)
+}
+
+// setFieldsEqual compares the fields of wanted with those of existing
+func setFieldsEqual(existing, wanted map[string]interface{}) bool {
+	for k, v := range wanted {
+		if !reflect.DeepEqual(existing[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// getWebhooks returns the webhook configuration and its webhooks, or nil if it does not
+// exist
+func (r *ReconcileSelfProtection) getWebhooks(ctx context.Context) (*unstructured.Unstructured, []interface{}, error) {
+	vwh := &unstructured.Unstructured{}
+	vwh.SetGroupVersionKind(vwhGVK)
+	if err := r.client.Get(ctx, vwhKey, vwh); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	webhooks, _, err := unstructured.NestedSlice(vwh.Object, "webhooks")
+	if err != nil {
+		return nil, nil, err
+	}
+	return vwh, webhooks, nil
+}
+
+// ensurePDB creates or updates the PodDisruptionBudget, which lets one Gatekeeper pod be
+// evicted at a time. A PodDisruptionBudget of the same name that Gatekeeper did not
+// create is left alone.
+func (r *ReconcileSelfProtection) ensurePDB(ctx context.Context) error {
+	maxUnavailable := intstr.FromInt(1)
+	spec := policyv1beta1.PodDisruptionBudgetSpec{
+		MaxUnavailable: &maxUnavailable,
+		Selector:       r.selector,
+	}
+	pdb := &policyv1beta1.PodDisruptionBudget{}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: r.namespace, Name: PDBName}, pdb)
+	if apierrors.IsNotFound(err) {
+		pdb = &policyv1beta1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      PDBName,
+				Namespace: r.namespace,
+				Labels: map[string]string{
+					systemLabel:         "yes",
+					bundle.OwnedByLabel: bundle.SelfProtectionLibrary.Owner,
+				},
+			},
+			Spec: spec,
+		}
+		log.Info("creating PodDisruptionBudget", "namespace", r.namespace, "name", PDBName)
+		return r.client.Create(ctx, pdb)
+	}
+	if err != nil {
+		return err
+	}
+	if !owned(pdb) {
+		log.Info("PodDisruptionBudget was not created by Gatekeeper, leaving it alone", "namespace", r.namespace, "name", PDBName)
+		return nil
+	}
+	if reflect.DeepEqual(pdb.Spec.MaxUnavailable, spec.MaxUnavailable) && reflect.DeepEqual(pdb.Spec.Selector, spec.Selector) && pdb.Spec.MinAvailable == nil {
+		return nil
+	}
+	pdb.Spec = spec
+	log.Info("updating PodDisruptionBudget", "namespace", r.namespace, "name", PDBName)
+	return r.client.Update(ctx, pdb)
+}
+
+func owned(obj metav1.Object) bool {
+	return obj.GetLabels()[bundle.OwnedByLabel] == bundle.SelfProtectionLibrary.Owner
+}
+
+// remove deletes the self-protection entry and the PodDisruptionBudget Gatekeeper created
+func (r *ReconcileSelfProtection) remove(ctx context.Context) error {
+	vwh, webhooks, err := r.getWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+	if vwh != nil {
+		kept := make([]interface{}, 0, len(webhooks))
+		for _, w := range webhooks {
+			if wh, ok := w.(map[string]interface{}); ok && wh["name"] == WebhookName {
+				continue
+			}
+			kept = append(kept, w)
+		}
+		if len(kept) != len(webhooks) {
+			if err := unstructured.SetNestedSlice(vwh.Object, kept, "webhooks"); err != nil {
+				return err
+			}
+			log.Info("removing self-protection webhook", "webhook", WebhookName)
+			if err := r.client.Update(ctx, vwh); err != nil {
+				return err
+			}
+		}
+	}
+
+	pdb := &policyv1beta1.PodDisruptionBudget{}
+	if err := r.client.Get(ctx, types.NamespacedName{Namespace: r.namespace, Name: PDBName}, pdb); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !owned(pdb) {
+		return nil
+	}
+	log.Info("removing PodDisruptionBudget", "namespace", r.namespace, "name", PDBName)
+	if err := r.client.Delete(ctx, pdb); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package selfprotection
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/bundle"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const namespace = "gatekeeper-system"
+
+func newWebhookConfig() *admissionregistrationv1beta1.ValidatingWebhookConfiguration {
+	path := "/v1/admit"
+	return &admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: vwhKey.Name},
+		Webhooks: []admissionregistrationv1beta1.ValidatingWebhook{{
+			Name: validationWebhookName,
+			ClientConfig: admissionregistrationv1beta1.WebhookClientConfig{
+				Service:  &admissionregistrationv1beta1.ServiceReference{Namespace: namespace, Name: "gatekeeper-webhook-service", Path: &path},
+				CABundle: []byte("ca"),
+			},
+		}},
+	}
+}
+
+func newReconciler(objs ...runtime.Object) (*ReconcileSelfProtection, client.Client) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	c := fake.NewFakeClientWithScheme(scheme, objs...)
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"control-plane": "controller-manager"}}
+	return &ReconcileSelfProtection{client: c, namespace: namespace, selector: selector}, c
+}
+
+func getWebhooks(t *testing.T, c client.Client) *admissionregistrationv1beta1.ValidatingWebhookConfiguration {
+	vwh := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{}
+	if err := c.Get(context.Background(), vwhKey, vwh); err != nil {
+		t.Fatal(err)
+	}
+	return vwh
+}
+
+func TestReconcileSelfProtection(t *testing.T) {
+	r, c := newReconciler(newWebhookConfig())
+	if _, err := r.Reconcile(reconcile.Request{NamespacedName: vwhKey}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	vwh := getWebhooks(t, c)
+	if len(vwh.Webhooks) != 2 || vwh.Webhooks[1].Name != WebhookName {
+		t.Fatalf("webhooks = %+v, wanted the validation and self-protection entries", vwh.Webhooks)
+	}
+	wh := vwh.Webhooks[1]
+	if string(wh.ClientConfig.CABundle) != "ca" || wh.ClientConfig.Service == nil || *wh.ClientConfig.Service.Path != "/v1/admit" {
+		t.Errorf("clientConfig = %+v, wanted the validation entry's", wh.ClientConfig)
+	}
+	if wh.ObjectSelector == nil || wh.ObjectSelector.MatchLabels[systemLabel] != "yes" {
+		t.Errorf("objectSelector = %v, wanted %s: yes", wh.ObjectSelector, systemLabel)
+	}
+	if wh.FailurePolicy == nil || *wh.FailurePolicy != admissionregistrationv1beta1.Ignore {
+		t.Errorf("failurePolicy = %v, wanted Ignore", wh.FailurePolicy)
+	}
+	if len(wh.Rules) != 2 || wh.Rules[0].Resources[0] != "customresourcedefinitions" || wh.Rules[1].Resources[0] != "deployments" {
+		t.Errorf("rules = %+v, wanted CRDs and deployments", wh.Rules)
+	}
+
+	pdb := &policyv1beta1.PodDisruptionBudget{}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: PDBName}, pdb); err != nil {
+		t.Fatalf("PodDisruptionBudget not created: %v", err)
+	}
+	if pdb.Spec.MaxUnavailable == nil || pdb.Spec.MaxUnavailable.IntValue() != 1 || pdb.Spec.Selector.MatchLabels["control-plane"] != "controller-manager" {
+		t.Errorf("PodDisruptionBudget spec = %+v", pdb.Spec)
+	}
+
+	// a second pass changes nothing
+	version := vwh.ResourceVersion
+	if _, err := r.Reconcile(reconcile.Request{NamespacedName: vwhKey}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if got := getWebhooks(t, c).ResourceVersion; got != version {
+		t.Errorf("webhook configuration updated again, resourceVersion %s -> %s", version, got)
+	}
+
+	if err := r.remove(context.Background()); err != nil {
+		t.Fatalf("remove() error = %v", err)
+	}
+	if vwh := getWebhooks(t, c); len(vwh.Webhooks) != 1 || vwh.Webhooks[0].Name != validationWebhookName {
+		t.Errorf("webhooks after remove = %+v, wanted only the validation entry", vwh.Webhooks)
+	}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: PDBName}, pdb)
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("PodDisruptionBudget after remove: error = %v, wanted not found", err)
+	}
+}
+
+func TestForeignPDBLeftAlone(t *testing.T) {
+	minAvailable := intstr.FromInt(1)
+	foreign := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: PDBName, Labels: map[string]string{"team": "platform"}},
+		Spec:       policyv1beta1.PodDisruptionBudgetSpec{MinAvailable: &minAvailable},
+	}
+	r, c := newReconciler(foreign)
+	if _, err := r.Reconcile(reconcile.Request{NamespacedName: vwhKey}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := r.remove(context.Background()); err != nil {
+		t.Fatalf("remove() error = %v", err)
+	}
+	pdb := &policyv1beta1.PodDisruptionBudget{}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: PDBName}, pdb); err != nil {
+		t.Fatalf("PodDisruptionBudget not kept: %v", err)
+	}
+	if pdb.Labels[bundle.OwnedByLabel] != "" || pdb.Spec.MaxUnavailable != nil {
+		t.Errorf("PodDisruptionBudget was changed: %+v", pdb)
+	}
+}
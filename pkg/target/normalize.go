@@ -0,0 +1,104 @@
+package target
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var stripMetadata = flag.Bool("strip-bulky-metadata", true, "remove managedFields and the kubectl last-applied-configuration annotation from objects before they are synced into OPA or reviewed. Disable if policies read either")
+
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// bulkyMetadata are markers of the fields normalize removes, used to skip decoding
+// objects that have none of them
+var bulkyMetadata = [][]byte{[]byte(`"managedFields"`), []byte(lastAppliedAnnotation)}
+
+// hasBulkyMetadata returns true if obj has fields normalize removes
+func hasBulkyMetadata(obj map[string]interface{}) bool {
+	if _, found, _ := unstructured.NestedFieldNoCopy(obj, "metadata", "managedFields"); found {
+		return true
+	}
+	_, found, _ := unstructured.NestedFieldNoCopy(obj, "metadata", "annotations", lastAppliedAnnotation)
+	return found
+}
+
+// stripBulkyMetadata removes managedFields and the last-applied annotation from obj in place
+func stripBulkyMetadata(obj map[string]interface{}) {
+	unstructured.RemoveNestedField(obj, "metadata", "managedFields")
+	annotations, found, err := unstructured.NestedFieldNoCopy(obj, "metadata", "annotations")
+	if err != nil || !found {
+		return
+	}
+	m, ok := annotations.(map[string]interface{})
+	if !ok {
+		return
+	}
+	delete(m, lastAppliedAnnotation)
+	if len(m) == 0 {
+		unstructured.RemoveNestedField(obj, "metadata", "annotations")
+	}
+}
+
+// normalizeObject returns obj without bulky metadata, copying it only if something is
+// removed so the caller's object is never modified
+func normalizeObject(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	if !*stripMetadata || !hasBulkyMetadata(obj.Object) {
+		return obj
+	}
+	obj = obj.DeepCopy()
+	stripBulkyMetadata(obj.Object)
+	return obj
+}
+
+// normalizeRaw returns raw without bulky metadata. raw is returned as is if it has none
+// or cannot be decoded, which leaves the error for OPA to report.
+func normalizeRaw(raw []byte) []byte {
+	found := false
+	for _, marker := range bulkyMetadata {
+		if bytes.Contains(raw, marker) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return raw
+	}
+	// numbers are kept as written, rather than rounded to float64 and re-encoded
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	obj := make(map[string]interface{})
+	if err := dec.Decode(&obj); err != nil {
+		return raw
+	}
+	if !hasBulkyMetadata(obj) {
+		return raw
+	}
+	stripBulkyMetadata(obj)
+	out, err := json.Marshal(obj)
+	if err != nil {
+		return raw
+	}
+	return out
+}
+
+// normalizeRequest returns a copy of req whose object and old object have no bulky
+// metadata, or req itself if there is nothing to remove
+func normalizeRequest(req *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionRequest {
+	if !*stripMetadata || req == nil {
+		return req
+	}
+	object := normalizeRaw(req.Object.Raw)
+	oldObject := normalizeRaw(req.OldObject.Raw)
+	if bytes.Equal(object, req.Object.Raw) && bytes.Equal(oldObject, req.OldObject.Raw) {
+		return req
+	}
+	out := *req
+	out.Object = runtime.RawExtension{Raw: object}
+	out.OldObject = runtime.RawExtension{Raw: oldObject}
+	return &out
+}
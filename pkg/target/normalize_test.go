@@ -0,0 +1,115 @@
+package target
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func bulkyObject() map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":          "bulky",
+			"managedFields": []interface{}{map[string]interface{}{"manager": "kubectl"}},
+			"annotations": map[string]interface{}{
+				lastAppliedAnnotation: "{}",
+				"owner":               "me",
+			},
+		},
+	}
+}
+
+func strippedObject() map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":        "bulky",
+			"annotations": map[string]interface{}{"owner": "me"},
+		},
+	}
+}
+
+func TestNormalizeObject(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: bulkyObject()}
+	got := normalizeObject(obj)
+	if diff := cmp.Diff(strippedObject(), got.Object); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(bulkyObject(), obj.Object); diff != "" {
+		t.Errorf("original object modified: %s", diff)
+	}
+
+	clean := &unstructured.Unstructured{Object: strippedObject()}
+	if got := normalizeObject(clean); got != clean {
+		t.Error("object without bulky metadata was copied")
+	}
+
+	onlyLastApplied := &unstructured.Unstructured{Object: bulkyObject()}
+	unstructured.RemoveNestedField(onlyLastApplied.Object, "metadata", "annotations", "owner")
+	got = normalizeObject(onlyLastApplied)
+	if _, found, _ := unstructured.NestedFieldNoCopy(got.Object, "metadata", "annotations"); found {
+		t.Errorf("empty annotations left behind: %v", got.Object)
+	}
+}
+
+func TestNormalizeObjectDisabled(t *testing.T) {
+	*stripMetadata = false
+	defer func() { *stripMetadata = true }()
+	obj := &unstructured.Unstructured{Object: bulkyObject()}
+	if got := normalizeObject(obj); got != obj {
+		t.Error("object normalized with --strip-bulky-metadata=false")
+	}
+}
+
+func TestNormalizeRequest(t *testing.T) {
+	raw, err := json.Marshal(bulkyObject())
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &admissionv1beta1.AdmissionRequest{
+		Name:      "bulky",
+		Object:    runtime.RawExtension{Raw: raw},
+		OldObject: runtime.RawExtension{Raw: raw},
+	}
+	got := normalizeRequest(req)
+	if got == req {
+		t.Fatal("request was not copied")
+	}
+	if string(req.Object.Raw) != string(raw) {
+		t.Error("original request modified")
+	}
+	for name, ext := range map[string]runtime.RawExtension{"object": got.Object, "oldObject": got.OldObject} {
+		obj := make(map[string]interface{})
+		if err := json.Unmarshal(ext.Raw, &obj); err != nil {
+			t.Fatalf("could not decode %s: %s", name, err)
+		}
+		if diff := cmp.Diff(strippedObject(), obj); diff != "" {
+			t.Errorf("%s: %s", name, diff)
+		}
+	}
+
+	clean, err := json.Marshal(strippedObject())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanReq := &admissionv1beta1.AdmissionRequest{Object: runtime.RawExtension{Raw: clean}}
+	if got := normalizeRequest(cleanReq); got != cleanReq {
+		t.Error("request without bulky metadata was copied")
+	}
+}
+
+func TestNormalizeRawKeepsNumbers(t *testing.T) {
+	// 2^53 + 1 cannot be represented as a float64
+	raw := []byte(`{"metadata":{"managedFields":[{}]},"spec":{"replicas":9007199254740993,"ratio":0.1}}`)
+	want := `{"metadata":{},"spec":{"ratio":0.1,"replicas":9007199254740993}}`
+	if got := string(normalizeRaw(raw)); got != want {
+		t.Errorf("normalizeRaw() = %s, wanted %s", got, want)
+	}
+}
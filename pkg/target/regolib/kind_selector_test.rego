@@ -77,6 +77,46 @@ test_nonempty_group_constant_kind_kind_selector_negative {
 	}) with input as bar_review
 }
 
+test_version_kind_selector {
+	any_kind_selector_matches({
+    "kinds": [
+      {"apiGroups": ["apps"], "kinds": ["Deployment"], "versions": ["v1beta1"]},
+    ]
+	}) with input as deployment_v1beta1_review
+}
+
+test_version_kind_selector_negative {
+	not any_kind_selector_matches({
+    "kinds": [
+      {"apiGroups": ["apps"], "kinds": ["Deployment"], "versions": ["v1beta1"]},
+    ]
+	}) with input as deployment_v1_review
+}
+
+test_wildcard_version_kind_selector {
+	any_kind_selector_matches({
+    "kinds": [
+      {"apiGroups": ["apps"], "kinds": ["Deployment"], "versions": ["*"]},
+    ]
+	}) with input as deployment_v1_review
+}
+
+test_version_kind_selector_request_kind {
+	any_kind_selector_matches({
+    "kinds": [
+      {"apiGroups": ["extensions"], "kinds": ["Deployment"], "versions": ["v1beta1"]},
+    ]
+	}) with input as deployment_converted_review
+}
+
+test_kind_selector_without_versions_ignores_request_kind {
+	not any_kind_selector_matches({
+    "kinds": [
+      {"apiGroups": ["extensions"], "kinds": ["Deployment"]},
+    ]
+	}) with input as deployment_converted_review
+}
+
 pod_review = {
   "review": {
     "kind": {
@@ -102,4 +142,39 @@ bar_review = {
       "kind": "Bar"
     }
   }
-}
\ No newline at end of file
+}
+deployment_v1_review = {
+  "review": {
+    "kind": {
+      "group": "apps",
+      "version": "v1",
+      "kind": "Deployment"
+    }
+  }
+}
+
+deployment_v1beta1_review = {
+  "review": {
+    "kind": {
+      "group": "apps",
+      "version": "v1beta1",
+      "kind": "Deployment"
+    }
+  }
+}
+
+# an extensions/v1beta1 request converted to apps/v1 for the webhook
+deployment_converted_review = {
+  "review": {
+    "kind": {
+      "group": "apps",
+      "version": "v1",
+      "kind": "Deployment"
+    },
+    "requestKind": {
+      "group": "extensions",
+      "version": "v1beta1",
+      "kind": "Deployment"
+    }
+  }
+}
@@ -0,0 +1,29 @@
+package target
+
+test_undefined_operations_match_any {
+	matches_operations({}) with input as {"review": {"operation": "DELETE"}}
+}
+
+test_empty_operations_match_any {
+	matches_operations({"operations": []}) with input as {"review": {"operation": "UPDATE"}}
+}
+
+test_wildcard_operation {
+	matches_operations({"operations": ["*"]}) with input as {"review": {"operation": "UPDATE"}}
+}
+
+test_listed_operation {
+	matches_operations({"operations": ["CREATE", "UPDATE"]}) with input as {"review": {"operation": "UPDATE"}}
+}
+
+test_unlisted_operation {
+	not matches_operations({"operations": ["CREATE"]}) with input as {"review": {"operation": "UPDATE"}}
+}
+
+test_review_without_operation_is_create {
+	matches_operations({"operations": ["CREATE"]}) with input as {"review": {}}
+}
+
+test_review_without_operation_is_not_update {
+	not matches_operations({"operations": ["UPDATE"]}) with input as {"review": {}}
+}
@@ -0,0 +1,78 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package target
+
+import (
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+)
+
+const (
+	serviceAccountPrefix = "system:serviceaccount:"
+	nodePrefix           = "system:node:"
+	nodesGroup           = "system:nodes"
+
+	// requesterServiceAccount, requesterNode and requesterUser are the types of requester
+	requesterServiceAccount = "ServiceAccount"
+	requesterNode           = "Node"
+	requesterUser           = "User"
+)
+
+// requester is the sender of an admission request, parsed from its userInfo so policies
+// need not know Kubernetes' username conventions. Impersonated requests carry the
+// impersonated user; the API server does not tell webhooks who impersonated it.
+type requester struct {
+	// Type is ServiceAccount, Node or User
+	Type string `json:"type"`
+	// Username is userInfo.username
+	Username string `json:"username"`
+	// ServiceAccount is the service account of requests of type ServiceAccount
+	ServiceAccount *serviceAccountRef `json:"serviceAccount,omitempty"`
+	// Node is the node of the kubelet of requests of type Node
+	Node string `json:"node,omitempty"`
+	// Groups holds userInfo.groups as a set
+	Groups map[string]bool `json:"groups"`
+}
+
+type serviceAccountRef struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// newRequester returns the requester of user, or nil for a review without a user, such
+// as one of audit
+func newRequester(user authenticationv1.UserInfo) *requester {
+	if user.Username == "" {
+		return nil
+	}
+	r := &requester{Type: requesterUser, Username: user.Username, Groups: make(map[string]bool, len(user.Groups))}
+	for _, g := range user.Groups {
+		r.Groups[g] = true
+	}
+	switch {
+	case strings.HasPrefix(user.Username, serviceAccountPrefix):
+		parts := strings.SplitN(strings.TrimPrefix(user.Username, serviceAccountPrefix), ":", 2)
+		if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+			r.Type = requesterServiceAccount
+			r.ServiceAccount = &serviceAccountRef{Namespace: parts[0], Name: parts[1]}
+		}
+	case strings.HasPrefix(user.Username, nodePrefix) && r.Groups[nodesGroup]:
+		r.Type = requesterNode
+		r.Node = strings.TrimPrefix(user.Username, nodePrefix)
+	}
+	return r
+}
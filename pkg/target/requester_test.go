@@ -0,0 +1,143 @@
+package target
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/local"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestNewRequester(t *testing.T) {
+	tcs := []struct {
+		name string
+		user authenticationv1.UserInfo
+		want *requester
+	}{
+		{
+			name: "no user",
+		},
+		{
+			name: "service account",
+			user: authenticationv1.UserInfo{Username: "system:serviceaccount:kube-system:default-scheduler", Groups: []string{"system:serviceaccounts"}},
+			want: &requester{
+				Type:           requesterServiceAccount,
+				Username:       "system:serviceaccount:kube-system:default-scheduler",
+				ServiceAccount: &serviceAccountRef{Namespace: "kube-system", Name: "default-scheduler"},
+				Groups:         map[string]bool{"system:serviceaccounts": true},
+			},
+		},
+		{
+			name: "node",
+			user: authenticationv1.UserInfo{Username: "system:node:worker-1", Groups: []string{"system:nodes"}},
+			want: &requester{Type: requesterNode, Username: "system:node:worker-1", Node: "worker-1", Groups: map[string]bool{"system:nodes": true}},
+		},
+		{
+			name: "node name outside the nodes group",
+			user: authenticationv1.UserInfo{Username: "system:node:worker-1"},
+			want: &requester{Type: requesterUser, Username: "system:node:worker-1", Groups: map[string]bool{}},
+		},
+		{
+			name: "user",
+			user: authenticationv1.UserInfo{Username: "alice", Groups: []string{"dev"}},
+			want: &requester{Type: requesterUser, Username: "alice", Groups: map[string]bool{"dev": true}},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := newRequester(tc.user); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %+v, wanted %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+const schedulerBindsTemplate = `
+apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: onlyschedulerbinds
+spec:
+  crd:
+    spec:
+      names:
+        kind: OnlySchedulerBinds
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+        package onlyschedulerbinds
+
+        violation[{"msg": msg}] {
+          input.review.subResource == "binding"
+          not input.review.requester.serviceAccount.name == "default-scheduler"
+          msg := sprintf("%v may not bind pods", [input.review.requester.username])
+        }
+`
+
+func TestRequesterInput(t *testing.T) {
+	backend, err := client.NewBackend(client.Driver(local.New()))
+	if err != nil {
+		t.Fatalf("Could not initialize backend: %s", err)
+	}
+	c, err := backend.NewClient(client.Targets(&K8sValidationTarget{}))
+	if err != nil {
+		t.Fatalf("unable to set up OPA client: %s", err)
+	}
+	tmpl := &templates.ConstraintTemplate{}
+	if err := yaml.Unmarshal([]byte(schedulerBindsTemplate), tmpl); err != nil {
+		t.Fatalf("unable to unmarshal template: %s", err)
+	}
+	if _, err := c.AddTemplate(context.Background(), tmpl); err != nil {
+		t.Fatalf("unable to add template: %s", err)
+	}
+	constraint := &unstructured.Unstructured{}
+	constraint.SetName("only-scheduler-binds")
+	constraint.SetGroupVersionKind(schema.GroupVersionKind{Group: "constraints.gatekeeper.sh", Version: "v1beta1", Kind: "OnlySchedulerBinds"})
+	if _, err := c.AddConstraint(context.Background(), constraint); err != nil {
+		t.Fatalf("unable to add constraint: %s", err)
+	}
+
+	binding, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Binding",
+		"metadata":   map[string]interface{}{"name": "my-pod", "namespace": "my-ns"},
+		"target":     map[string]interface{}{"kind": "Node", "name": "worker-1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		username string
+		allowed  bool
+	}{
+		{username: "system:serviceaccount:kube-system:default-scheduler", allowed: true},
+		{username: "alice", allowed: false},
+	} {
+		res, err := c.Review(context.Background(), &AugmentedReview{Namespace: makeNamespace("my-ns"), AdmissionRequest: &admissionv1beta1.AdmissionRequest{
+			Kind:        metav1.GroupVersionKind{Version: "v1", Kind: "Binding"},
+			Resource:    metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+			SubResource: "binding",
+			Name:        "my-pod",
+			Namespace:   "my-ns",
+			Operation:   admissionv1beta1.Create,
+			Object:      runtime.RawExtension{Raw: binding},
+			UserInfo:    authenticationv1.UserInfo{Username: tc.username},
+		}})
+		if err != nil {
+			t.Fatalf("Error reviewing binding of %s: %s", tc.username, err)
+		}
+		if got := len(res.Results()) == 0; got != tc.allowed {
+			t.Errorf("binding by %s allowed = %v, wanted %v", tc.username, got, tc.allowed)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package target
+
+import (
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/redact"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+)
+
+// SharedReview is an admission request prepared for review once. Its input, and the
+// object its violations report, are built when it is created rather than for each
+// constraint that is violated. It must not be modified.
+type SharedReview struct {
+	review *gkReview
+	// object is the reviewed object, redacted, or nil if it could not be decoded
+	object *unstructured.Unstructured
+	// values are the sensitive values of the reviewed objects
+	values []string
+}
+
+// NewSharedReview prepares review to be reviewed. The returned SharedReview is passed to
+// the OPA client's Review in place of review, and shares its object with the results
+// once they are returned.
+func NewSharedReview(review AugmentedReview) *SharedReview {
+	r := &SharedReview{review: augmentedReviewToGKReview(&review)}
+	objects := reviewedObjects(r.review)
+	if req := r.review.AdmissionRequest; req != nil && redact.Applies(req.Kind.Group, req.Kind.Kind) {
+		var maps []map[string]interface{}
+		for _, obj := range objects {
+			maps = append(maps, obj.Object)
+		}
+		// the oldObject of an update is part of the input too, so its values are redacted
+		r.values = redact.Values(maps...)
+	}
+	if len(objects) > 0 {
+		r.object = redact.Unstructured(objects[0])
+	}
+	return r
+}
+
+// Values returns the sensitive values of the reviewed objects, to redact from anything
+// else that may echo them, such as a trace
+func (r *SharedReview) Values() []string {
+	return r.values
+}
+
+// Share points the results of the admission target in resp at the shared object, so the
+// objects HandleViolation decoded from each result's copy of the review can be dropped
+func (r *SharedReview) Share(resp *types.Responses) {
+	if r.object == nil || resp == nil {
+		return
+	}
+	tr, ok := resp.ByTarget[(&K8sValidationTarget{}).GetName()]
+	if !ok {
+		return
+	}
+	for _, result := range tr.Results {
+		result.Resource = r.object
+	}
+}
+
+// reviewedObjects decodes the object and the oldObject of review that are set, with the
+// apiVersion and kind they are reviewed as. Violations report the first.
+func reviewedObjects(review *gkReview) []*unstructured.Unstructured {
+	req := review.AdmissionRequest
+	if req == nil {
+		return nil
+	}
+	var objects []*unstructured.Unstructured
+	for _, raw := range [][]byte{req.Object.Raw, req.OldObject.Raw} {
+		if len(raw) == 0 {
+			continue
+		}
+		var obj map[string]interface{}
+		if err := utiljson.Unmarshal(raw, &obj); err != nil || obj == nil {
+			continue
+		}
+		u := &unstructured.Unstructured{Object: obj}
+		u.SetAPIVersion(apiVersion(req.Kind.Group, req.Kind.Version))
+		u.SetKind(req.Kind.Kind)
+		objects = append(objects, u)
+	}
+	return objects
+}
@@ -0,0 +1,71 @@
+package target
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// violation returns a result for review as the constraint framework would, with its own
+// copy of the review decoded from the query output
+func violation(t *testing.T, review interface{}, msg string) *types.Result {
+	t.Helper()
+	b, err := json.Marshal(review)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	return &types.Result{Msg: msg, Review: m}
+}
+
+func TestSharedReview(t *testing.T) {
+	req := &admissionv1beta1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Secret"},
+		Operation: admissionv1beta1.Create,
+		Object:    runtime.RawExtension{Raw: []byte(`{"metadata": {"name": "db"}, "data": {"password": "aHVudGVyMjI="}}`)},
+	}
+	shared := NewSharedReview(AugmentedReview{AdmissionRequest: req})
+	h := &K8sValidationTarget{}
+	handled, review, err := h.HandleReview(shared)
+	if err != nil || !handled {
+		t.Fatalf("HandleReview() = %v, %v; wanted the review handled", handled, err)
+	}
+	if review != shared.review {
+		t.Error("HandleReview() built the input again, wanted the shared input")
+	}
+
+	first := violation(t, review, "password hunter22 is weak")
+	second := violation(t, review, "password hunter22 is reused")
+	resp := types.NewResponses()
+	resp.ByTarget[h.GetName()] = &types.Response{Target: h.GetName(), Results: []*types.Result{first, second}}
+	for _, r := range resp.Results() {
+		if err := h.HandleViolation(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if first.Msg != "password [REDACTED] is weak" {
+		t.Errorf("result.Msg = %q, wanted the password redacted", first.Msg)
+	}
+
+	shared.Share(resp)
+	if first.Resource != second.Resource {
+		t.Error("the violations of a shared review have different objects, wanted them to share one")
+	}
+	obj := shared.object
+	if obj.GetName() != "db" || obj.GetKind() != "Secret" || obj.GetAPIVersion() != "v1" {
+		t.Errorf("shared object = %v, wanted the reviewed Secret", obj)
+	}
+	if data := obj.Object["data"].(map[string]interface{}); data["password"] != "[REDACTED]" {
+		t.Errorf("shared object data = %v, wanted it redacted", data)
+	}
+	if len(shared.Values()) == 0 {
+		t.Error("wanted the Secret's values to redact from traces")
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/redact"
 	"github.com/pkg/errors"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	corev1 "k8s.io/api/core/v1"
@@ -34,10 +35,32 @@ func (h *K8sValidationTarget) Library() *template.Template {
 	return libTempl
 }
 
-type WipeData struct{}
+// WipeData removes the data synced into the inventory, or only the subtree at Path if it
+// is set
+type WipeData struct {
+	Path string
+}
+
+// ObjectWipes remove the Kubernetes objects synced into the inventory, keeping the
+// documents loaded by ExternalSyncs
+var ObjectWipes = []WipeData{{Path: "cluster"}, {Path: "namespace"}}
 
-func processWipeData() (bool, string, interface{}, error) {
-	return true, "", nil, nil
+func processWipeData(data *WipeData) (bool, string, interface{}, error) {
+	return true, data.Path, nil, nil
+}
+
+// ExternalData is a document synced from outside the cluster, stored at
+// data.inventory.external[Name]
+type ExternalData struct {
+	Name  string
+	Value interface{}
+}
+
+func processExternalData(data *ExternalData) (bool, string, interface{}, error) {
+	if data.Name == "" {
+		return true, "", nil, errors.New("external data has no name")
+	}
+	return true, path.Join("external", url.PathEscape(data.Name)), data.Value, nil
 }
 
 type AugmentedReview struct {
@@ -47,7 +70,12 @@ type AugmentedReview struct {
 
 type gkReview struct {
 	*admissionv1beta1.AdmissionRequest
-	Unstable *unstable `json:"_unstable,omitempty"`
+	// NamespaceObject is the namespace of a namespaced object under review, so policies
+	// can read its labels without syncing namespaces
+	NamespaceObject *corev1.Namespace `json:"namespaceObject,omitempty"`
+	// Requester is the parsed userInfo of the request
+	Requester *requester `json:"requester,omitempty"`
+	Unstable  *unstable  `json:"_unstable,omitempty"`
 }
 
 type AugmentedUnstructured struct {
@@ -68,6 +96,7 @@ func processUnstructured(o *unstructured.Unstructured) (bool, string, interface{
 	if gvk.Kind == "" {
 		return true, "", nil, fmt.Errorf("resource %s has no kind", o.GetName())
 	}
+	o = normalizeObject(o)
 
 	if o.GetNamespace() == "" {
 		return true, path.Join("cluster", url.PathEscape(gvk.GroupVersion().String()), gvk.Kind, o.GetName()), o.Object, nil
@@ -81,8 +110,14 @@ func (h *K8sValidationTarget) ProcessData(obj interface{}) (bool, string, interf
 		return processUnstructured(&data)
 	case *unstructured.Unstructured:
 		return processUnstructured(data)
-	case WipeData, *WipeData:
-		return processWipeData()
+	case WipeData:
+		return processWipeData(&data)
+	case *WipeData:
+		return processWipeData(data)
+	case ExternalData:
+		return processExternalData(&data)
+	case *ExternalData:
+		return processExternalData(data)
 	default:
 		return false, "", nil, nil
 	}
@@ -91,13 +126,15 @@ func (h *K8sValidationTarget) ProcessData(obj interface{}) (bool, string, interf
 func (h *K8sValidationTarget) HandleReview(obj interface{}) (bool, interface{}, error) {
 	switch data := obj.(type) {
 	case admissionv1beta1.AdmissionRequest:
-		return true, data, nil
+		return true, normalizeRequest(&data), nil
 	case *admissionv1beta1.AdmissionRequest:
-		return true, data, nil
+		return true, normalizeRequest(data), nil
 	case AugmentedReview:
-		return true, &gkReview{AdmissionRequest: data.AdmissionRequest, Unstable: &unstable{Namespace: data.Namespace}}, nil
+		return true, augmentedReviewToGKReview(&data), nil
 	case *AugmentedReview:
-		return true, &gkReview{AdmissionRequest: data.AdmissionRequest, Unstable: &unstable{Namespace: data.Namespace}}, nil
+		return true, augmentedReviewToGKReview(data), nil
+	case *SharedReview:
+		return true, data.review, nil
 	case AugmentedUnstructured:
 		admissionRequest, err := augmentedUnstructuredToAdmissionRequest(data)
 		if err != nil {
@@ -126,13 +163,34 @@ func (h *K8sValidationTarget) HandleReview(obj interface{}) (bool, interface{},
 	return false, nil, nil
 }
 
+func augmentedReviewToGKReview(data *AugmentedReview) *gkReview {
+	review := &gkReview{
+		AdmissionRequest: normalizeRequest(data.AdmissionRequest),
+		NamespaceObject:  namespaceObject(data.Namespace),
+		Unstable:         &unstable{Namespace: data.Namespace},
+	}
+	if data.AdmissionRequest != nil {
+		review.Requester = newRequester(data.AdmissionRequest.UserInfo)
+	}
+	return review
+}
+
+// namespaceObject returns ns, or nil for the empty namespace audit passes with
+// cluster-scoped objects
+func namespaceObject(ns *corev1.Namespace) *corev1.Namespace {
+	if ns == nil || ns.Name == "" {
+		return nil
+	}
+	return ns
+}
+
 func augmentedUnstructuredToAdmissionRequest(obj AugmentedUnstructured) (gkReview, error) {
 	req, err := unstructuredToAdmissionRequest(obj.Object)
 	if err != nil {
 		return gkReview{}, err
 	}
 
-	review := gkReview{AdmissionRequest: &req, Unstable: &unstable{Namespace: obj.Namespace}}
+	review := gkReview{AdmissionRequest: &req, NamespaceObject: namespaceObject(obj.Namespace), Unstable: &unstable{Namespace: obj.Namespace}}
 
 	if obj.Namespace != nil {
 		review.Namespace = obj.Namespace.Name
@@ -142,7 +200,7 @@ func augmentedUnstructuredToAdmissionRequest(obj AugmentedUnstructured) (gkRevie
 }
 
 func unstructuredToAdmissionRequest(obj unstructured.Unstructured) (admissionv1beta1.AdmissionRequest, error) {
-	resourceJSON, err := json.Marshal(obj.Object)
+	resourceJSON, err := json.Marshal(normalizeObject(&obj).Object)
 	if err != nil {
 		return admissionv1beta1.AdmissionRequest{}, errors.New("Unable to marshal JSON encoding of object")
 	}
@@ -157,6 +215,8 @@ func unstructuredToAdmissionRequest(obj unstructured.Unstructured) (admissionv1b
 			Raw: resourceJSON,
 		},
 		Name: obj.GetName(),
+		// existing objects are reviewed as if they were being created
+		Operation: admissionv1beta1.Create,
 	}
 
 	return req, nil
@@ -177,16 +237,18 @@ func getString(m map[string]interface{}, k string) (string, error) {
 	return s, nil
 }
 
-// nestedMap augments unstructured.NestedMap to interpret a nil-valued field
-// as missing
+// nestedMap augments unstructured.NestedFieldNoCopy to interpret a nil-valued field
+// as missing. The map is not copied: the constraint framework decodes a review for each
+// result, so the result owns it.
 func nestedMap(rmap map[string]interface{}, field string) (map[string]interface{}, bool, error) {
-	objMap, found, err := unstructured.NestedMap(rmap, field)
-	if err != nil || !found {
-		if val, found, err2 := unstructured.NestedFieldNoCopy(rmap, field); val == nil && found && err2 == nil {
-			return nil, false, nil
-		}
+	val, found, err := unstructured.NestedFieldNoCopy(rmap, field)
+	if err != nil || !found || val == nil {
 		return nil, false, err
 	}
+	objMap, ok := val.(map[string]interface{})
+	if !ok {
+		return nil, false, fmt.Errorf("review[%s] is a %T, not a map", field, val)
+	}
 	return objMap, true, nil
 }
 
@@ -207,13 +269,6 @@ func (h *K8sValidationTarget) HandleViolation(result *types.Result) error {
 	if err != nil {
 		return err
 	}
-	var apiVersion string
-	if group == "" {
-		apiVersion = version
-	} else {
-		apiVersion = fmt.Sprintf("%s/%s", group, version)
-	}
-
 	objMap, found, err := nestedMap(rmap, "object")
 	if err != nil {
 		return errors.Wrap(err, "HandleViolation:NestedMap")
@@ -228,21 +283,37 @@ func (h *K8sValidationTarget) HandleViolation(result *types.Result) error {
 		}
 	}
 
-	objMap["apiVersion"] = apiVersion
+	objMap["apiVersion"] = apiVersion(group, version)
 	objMap["kind"] = kind
 
-	js, err := json.Marshal(objMap)
-	if err != nil {
-		return errors.Wrap(err, "HandleViolation:Marshal(Object)")
-	}
-	obj := &unstructured.Unstructured{}
-	if err := json.Unmarshal(js, obj); err != nil {
-		return errors.Wrap(err, "HandleViolation:Unmarshal(unstructured)")
+	obj := &unstructured.Unstructured{Object: objMap}
+	// violation messages reach logs, status and events, so they must not echo secrets of
+	// either object the template was given
+	if redact.Applies(group, kind) {
+		maps := []map[string]interface{}{obj.Object}
+		if oldMap, found, err := nestedMap(rmap, "oldObject"); err == nil && found {
+			old := make(map[string]interface{}, len(oldMap)+2)
+			for k, v := range oldMap {
+				old[k] = v
+			}
+			old["apiVersion"] = objMap["apiVersion"]
+			old["kind"] = kind
+			maps = append(maps, old)
+		}
+		result.Msg = redact.Message(result.Msg, redact.Values(maps...))
 	}
-	result.Resource = obj
+	result.Resource = redact.Unstructured(obj)
 	return nil
 }
 
+// apiVersion returns the apiVersion of objects of group and version
+func apiVersion(group, version string) string {
+	if group == "" {
+		return version
+	}
+	return fmt.Sprintf("%s/%s", group, version)
+}
+
 func (h *K8sValidationTarget) MatchSchema() apiextensions.JSONSchemaProps {
 	stringList := &apiextensions.JSONSchemaPropsOrArray{
 		Schema: &apiextensions.JSONSchemaProps{Type: "string"}}
@@ -291,6 +362,7 @@ func (h *K8sValidationTarget) MatchSchema() apiextensions.JSONSchemaProps {
 						Properties: map[string]apiextensions.JSONSchemaProps{
 							"apiGroups": {Items: stringList},
 							"kinds":     {Items: stringList},
+							"versions":  {Items: stringList},
 						},
 					},
 				},
@@ -303,8 +375,25 @@ func (h *K8sValidationTarget) MatchSchema() apiextensions.JSONSchemaProps {
 				Type: "array",
 				Items: &apiextensions.JSONSchemaPropsOrArray{
 					Schema: &apiextensions.JSONSchemaProps{Type: "string"}}},
+			"operations": apiextensions.JSONSchemaProps{
+				Type: "array",
+				Items: &apiextensions.JSONSchemaPropsOrArray{
+					Schema: &apiextensions.JSONSchemaProps{
+						Type: "string",
+						Enum: []apiextensions.JSON{"*", "CREATE", "UPDATE", "DELETE", "CONNECT"},
+					},
+				},
+			},
 			"labelSelector":     labelSelectorSchema,
 			"namespaceSelector": labelSelectorSchema,
+			// checked by the constraint controller, which keeps constraints whose
+			// conditions do not hold out of OPA
+			"clusterVersions": apiextensions.JSONSchemaProps{
+				Type:  "array",
+				Items: stringList},
+			"featureGates": apiextensions.JSONSchemaProps{
+				Type:  "array",
+				Items: stringList},
 		},
 	}
 }
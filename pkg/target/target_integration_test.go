@@ -165,6 +165,34 @@ func TestConstraintEnforcement(t *testing.T) {
 			constraint: makeConstraint(setExcludedNamespaceName("not-my-ns")),
 			allowed:    false,
 		},
+		{
+			name:       "match namespace subtree",
+			obj:        makeResource("some", "Thing"),
+			ns:         makeNamespace("my-ns", map[string]string{"parent-ns.tree.hnc.x-k8s.io/depth": "1"}),
+			constraint: makeConstraint(setNamespaceName("parent-ns/*")),
+			allowed:    false,
+		},
+		{
+			name:       "match namespace subtree root",
+			obj:        makeResource("some", "Thing"),
+			ns:         makeNamespace("my-ns"),
+			constraint: makeConstraint(setNamespaceName("my-ns/*")),
+			allowed:    false,
+		},
+		{
+			name:       "no match namespace subtree",
+			obj:        makeResource("some", "Thing"),
+			ns:         makeNamespace("my-ns", map[string]string{"parent-ns.tree.hnc.x-k8s.io/depth": "1"}),
+			constraint: makeConstraint(setNamespaceName("other-ns/*")),
+			allowed:    true,
+		},
+		{
+			name:       "match excludedNamespaces subtree",
+			obj:        makeResource("some", "Thing"),
+			ns:         makeNamespace("my-ns", map[string]string{"parent-ns.tree.hnc.x-k8s.io/depth": "1"}),
+			constraint: makeConstraint(setExcludedNamespaceName("parent-ns/*")),
+			allowed:    true,
+		},
 		{
 			name:       "match labelselector",
 			obj:        makeResource("some", "Thing", map[string]string{"a": "label"}),
@@ -361,3 +389,185 @@ func TestConstraintEnforcement(t *testing.T) {
 		})
 	}
 }
+
+const namespaceObjectTemplate = `
+apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: namespaceteam
+spec:
+  crd:
+    spec:
+      names:
+        kind: NamespaceTeam
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+        package namespaceteam
+
+        violation[{"msg": msg}] {
+          not input.review.namespaceObject.metadata.labels.team
+          msg := "namespace has no team"
+        }
+`
+
+func TestNamespaceObjectInput(t *testing.T) {
+	backend, err := client.NewBackend(client.Driver(local.New()))
+	if err != nil {
+		t.Fatalf("Could not initialize backend: %s", err)
+	}
+	c, err := backend.NewClient(client.Targets(&K8sValidationTarget{}))
+	if err != nil {
+		t.Fatalf("unable to set up OPA client: %s", err)
+	}
+	tmpl := &templates.ConstraintTemplate{}
+	if err := yaml.Unmarshal([]byte(namespaceObjectTemplate), tmpl); err != nil {
+		t.Fatalf("unable to unmarshal template: %s", err)
+	}
+	if _, err := c.AddTemplate(context.Background(), tmpl); err != nil {
+		t.Fatalf("unable to add template: %s", err)
+	}
+	constraint := &unstructured.Unstructured{}
+	constraint.SetName("my-constraint")
+	constraint.SetGroupVersionKind(schema.GroupVersionKind{Group: "constraints.gatekeeper.sh", Version: "v1beta1", Kind: "NamespaceTeam"})
+	if _, err := c.AddConstraint(context.Background(), constraint); err != nil {
+		t.Fatalf("unable to add constraint: %s", err)
+	}
+
+	tcs := []struct {
+		name    string
+		ns      *corev1.Namespace
+		allowed bool
+	}{
+		{
+			name:    "labeled namespace",
+			ns:      makeNamespace("my-ns", map[string]string{"team": "a"}),
+			allowed: true,
+		},
+		{
+			name:    "unlabeled namespace",
+			ns:      makeNamespace("my-ns"),
+			allowed: false,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := makeResource("some", "Thing")
+			obj.SetNamespace(tc.ns.Name)
+			objData, err := json.Marshal(obj.Object)
+			if err != nil {
+				t.Fatalf("unable to marshal obj: %s", err)
+			}
+			for _, review := range []interface{}{
+				&AugmentedUnstructured{Namespace: tc.ns, Object: *obj},
+				&AugmentedReview{Namespace: tc.ns, AdmissionRequest: &admissionv1beta1.AdmissionRequest{
+					Kind:      metav1.GroupVersionKind{Group: "some", Version: "v1", Kind: "Thing"},
+					Object:    runtime.RawExtension{Raw: objData},
+					Namespace: tc.ns.Name,
+				}},
+			} {
+				res, err := c.Review(context.Background(), review)
+				if err != nil {
+					t.Fatalf("Error reviewing request: %s", err)
+				}
+				if (len(res.Results()) == 0) != tc.allowed {
+					t.Errorf("%T: allowed = %v, expected %v", review, !tc.allowed, tc.allowed)
+				}
+			}
+		})
+	}
+}
+
+func setOperations(operations ...string) buildArg {
+	return func(obj *unstructured.Unstructured) {
+		var iOps []interface{}
+		for _, op := range operations {
+			iOps = append(iOps, op)
+		}
+		if err := unstructured.SetNestedSlice(obj.Object, iOps, "spec", "match", "operations"); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestOperationMatch(t *testing.T) {
+	tcs := []struct {
+		name       string
+		constraint *unstructured.Unstructured
+		// deniedOps are the admission operations the constraint denies
+		deniedOps []admissionv1beta1.Operation
+		// auditDenied is whether the constraint denies existing objects
+		auditDenied bool
+	}{
+		{
+			name:        "no operations",
+			constraint:  makeConstraint(),
+			deniedOps:   []admissionv1beta1.Operation{admissionv1beta1.Create, admissionv1beta1.Update},
+			auditDenied: true,
+		},
+		{
+			name:        "create only",
+			constraint:  makeConstraint(setOperations("CREATE")),
+			deniedOps:   []admissionv1beta1.Operation{admissionv1beta1.Create},
+			auditDenied: true,
+		},
+		{
+			name:        "update only",
+			constraint:  makeConstraint(setOperations("UPDATE")),
+			deniedOps:   []admissionv1beta1.Operation{admissionv1beta1.Update},
+			auditDenied: false,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			backend, err := client.NewBackend(client.Driver(local.New()))
+			if err != nil {
+				t.Fatalf("Could not initialize backend: %s", err)
+			}
+			c, err := backend.NewClient(client.Targets(&K8sValidationTarget{}))
+			if err != nil {
+				t.Fatalf("unable to set up OPA client: %s", err)
+			}
+			tmpl := &templates.ConstraintTemplate{}
+			if err := yaml.Unmarshal([]byte(testTemplate), tmpl); err != nil {
+				t.Fatalf("unable to unmarshal template: %s", err)
+			}
+			if _, err := c.AddTemplate(context.Background(), tmpl); err != nil {
+				t.Fatalf("unable to add template: %s", err)
+			}
+			if _, err := c.AddConstraint(context.Background(), tc.constraint); err != nil {
+				t.Fatalf("unable to add constraint: %s", err)
+			}
+
+			obj := makeResource("some", "Thing")
+			objData, err := json.Marshal(obj.Object)
+			if err != nil {
+				t.Fatalf("unable to marshal obj: %s", err)
+			}
+			for _, op := range []admissionv1beta1.Operation{admissionv1beta1.Create, admissionv1beta1.Update} {
+				denied := false
+				for _, d := range tc.deniedOps {
+					denied = denied || d == op
+				}
+				res, err := c.Review(context.Background(), &AugmentedReview{Namespace: makeNamespace(""), AdmissionRequest: &admissionv1beta1.AdmissionRequest{
+					Kind:      metav1.GroupVersionKind{Group: "some", Version: "v1", Kind: "Thing"},
+					Object:    runtime.RawExtension{Raw: objData},
+					Operation: op,
+				}})
+				if err != nil {
+					t.Fatalf("Error reviewing %s request: %s", op, err)
+				}
+				if got := len(res.Results()) > 0; got != denied {
+					t.Errorf("%s denied = %v, wanted %v", op, got, denied)
+				}
+			}
+			res, err := c.Review(context.Background(), &AugmentedUnstructured{Namespace: makeNamespace(""), Object: *obj})
+			if err != nil {
+				t.Fatalf("Error reviewing existing object: %s", err)
+			}
+			if got := len(res.Results()) > 0; got != tc.auditDenied {
+				t.Errorf("existing object denied = %v, wanted %v", got, tc.auditDenied)
+			}
+		})
+	}
+}
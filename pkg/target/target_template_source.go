@@ -31,6 +31,8 @@ matching_constraints[constraint] {
 
   any_kind_selector_matches(match)
 
+  matches_operations(match)
+
   matches_namespaces(match)
 
   does_not_match_excludednamespaces(match)
@@ -60,6 +62,7 @@ make_review(obj, api_version, kind, name) = review {
   [group, version] := make_group_version(api_version)
   review := {
     "kind": {"group": group, "version": version, "kind": kind},
+    "operation": "CREATE",
     "name": name,
     "object": obj
   }
@@ -133,24 +136,68 @@ any_kind_selector_matches(match) {
 }
 
 kind_selector_matches(ks) {
-  group_matches(ks)
-  kind_matches(ks)
+  gvk_matches(ks, input.review.kind)
+}
+
+# A selector that lists versions also matches the version the request was made with, which
+# differs from input.review.kind when the API server converted the object for the webhook
+kind_selector_matches(ks) {
+  count(get_default(ks, "versions", [])) > 0
+  gvk_matches(ks, input.review.requestKind)
+}
+
+gvk_matches(ks, gvk) {
+  group_matches(ks, gvk)
+  kind_matches(ks, gvk)
+  version_matches(ks, gvk)
 }
 
-group_matches(ks) {
+group_matches(ks, gvk) {
   ks.apiGroups[_] == "*"
 }
 
-group_matches(ks) {
-  ks.apiGroups[_] == input.review.kind.group
+group_matches(ks, gvk) {
+  ks.apiGroups[_] == gvk.group
 }
 
-kind_matches(ks) {
+kind_matches(ks, gvk) {
   ks.kinds[_] == "*"
 }
 
-kind_matches(ks) {
-  ks.kinds[_] == input.review.kind.kind
+kind_matches(ks, gvk) {
+  ks.kinds[_] == gvk.kind
+}
+
+version_matches(ks, gvk) {
+  versions := get_default(ks, "versions", [])
+  count(versions) == 0
+}
+
+version_matches(ks, gvk) {
+  ks.versions[_] == "*"
+}
+
+version_matches(ks, gvk) {
+  ks.versions[_] == gvk.version
+}
+
+############################
+# Operation Selector Logic #
+############################
+
+matches_operations(match) {
+  operations := get_default(match, "operations", [])
+  count(operations) == 0
+}
+
+matches_operations(match) {
+  match.operations[_] == "*"
+}
+
+# Reviews without an operation, such as those of audit, are of existing objects and are
+# taken as CREATE
+matches_operations(match) {
+  match.operations[_] == get_default(input.review, "operation", "CREATE")
 }
 
 ########################
@@ -291,8 +338,7 @@ matches_namespaces(match) {
 matches_namespaces(match) {
   has_field(match, "namespaces")
   get_ns_name[ns]
-  nss := {n | n = match.namespaces[_]}
-  count({ns} - nss) == 0
+  in_namespaces(ns, match.namespaces)
 }
 
 does_not_match_excludednamespaces(match) {
@@ -302,8 +348,47 @@ does_not_match_excludednamespaces(match) {
 does_not_match_excludednamespaces(match) {
   has_field(match, "excludedNamespaces")
   get_ns_name[ns]
-  nss := {n | n = match.excludedNamespaces[_]}
-  count({ns} - nss) != 0
+  not in_namespaces(ns, match.excludedNamespaces)
+}
+
+# patterns are namespace names or subtrees such as team-a/*, which match team-a and
+# its descendants in the hierarchy labeled by the Hierarchical Namespace Controller
+in_namespaces(ns, patterns) {
+  patterns[_] == ns
+}
+
+in_namespaces(ns, patterns) {
+  pattern := patterns[_]
+  endswith(pattern, "/*")
+  in_subtree(ns, trim_suffix(pattern, "/*"))
+}
+
+in_subtree(ns, root) {
+  ns == root
+}
+
+# HNC labels a namespace <ancestor>.tree.hnc.x-k8s.io/depth for itself and each ancestor
+in_subtree(ns, root) {
+  get_ns_labels[labels]
+  labels[concat("", [root, ".tree.hnc.x-k8s.io/depth"])]
+}
+
+get_ns_labels[labels] {
+  is_ns(input.review.kind)
+  obj := get_default(input.review, "object", {})
+  labels := get_default(get_default(obj, "metadata", {}), "labels", {})
+}
+
+get_ns_labels[labels] {
+  is_ns(input.review.kind)
+  obj := get_default(input.review, "oldObject", {})
+  labels := get_default(get_default(obj, "metadata", {}), "labels", {})
+}
+
+get_ns_labels[labels] {
+  not is_ns(input.review.kind)
+  get_ns[ns]
+  labels := get_default(get_default(ns, "metadata", {}), "labels", {})
 }
 
 matches_nsselector(match) {
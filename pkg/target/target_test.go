@@ -270,8 +270,10 @@ func TestHandleViolation(t *testing.T) {
 	tc := []struct {
 		Name          string
 		Review        string
+		Msg           string
 		ErrorExpected bool
 		ExpectedObj   string
+		ExpectedMsg   string
 	}{
 		{
 			Name: "Valid Review",
@@ -325,6 +327,66 @@ func TestHandleViolation(t *testing.T) {
 }
 `,
 		},
+		{
+			Name: "Secret Review",
+			Review: `
+{
+	"kind": {
+		"group": "",
+		"version": "v1",
+		"kind": "Secret"
+	},
+	"name": "somename",
+	"operation": "CREATE",
+  "object": {
+		"metadata": {"name": "somename"},
+		"data": {"token": "aHVudGVyMjIK"}
+	}
+}
+`,
+			Msg: "token hunter22 is too weak",
+			ExpectedObj: `
+{
+	"apiVersion": "v1",
+	"kind": "Secret",
+	"metadata": {"name": "somename"},
+	"data": {"token": "[REDACTED]"}
+}
+`,
+			ExpectedMsg: "token [REDACTED] is too weak",
+		},
+		{
+			Name: "Secret Update Review",
+			Review: `
+{
+	"kind": {
+		"group": "",
+		"version": "v1",
+		"kind": "Secret"
+	},
+	"name": "somename",
+	"operation": "UPDATE",
+  "object": {
+		"metadata": {"name": "somename"},
+		"data": {"token": "aHVudGVyMjIK"}
+	},
+  "oldObject": {
+		"metadata": {"name": "somename"},
+		"data": {"token": "b2xkc2VjcmV0"}
+	}
+}
+`,
+			Msg: "token changed from oldsecret to hunter22",
+			ExpectedObj: `
+{
+	"apiVersion": "v1",
+	"kind": "Secret",
+	"metadata": {"name": "somename"},
+	"data": {"token": "[REDACTED]"}
+}
+`,
+			ExpectedMsg: "token changed from [REDACTED] to [REDACTED]",
+		},
 		{
 			Name:          "No Review",
 			Review:        `["list is wrong"]`,
@@ -333,7 +395,7 @@ func TestHandleViolation(t *testing.T) {
 	}
 	for _, tt := range tc {
 		t.Run(tt.Name, func(t *testing.T) {
-			r := &types.Result{}
+			r := &types.Result{Msg: tt.Msg}
 			var i interface{}
 			err := json.Unmarshal([]byte(tt.Review), &i)
 			if err != nil {
@@ -358,6 +420,9 @@ func TestHandleViolation(t *testing.T) {
 					t.Errorf("result.Resource = %s; wanted %s", spew.Sdump(r.Resource), spew.Sdump(expected))
 				}
 			}
+			if tt.ExpectedMsg != "" && r.Msg != tt.ExpectedMsg {
+				t.Errorf("result.Msg = %q; wanted %q", r.Msg, tt.ExpectedMsg)
+			}
 		})
 	}
 }
@@ -426,3 +491,32 @@ func TestProcessData(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessDataPaths(t *testing.T) {
+	tc := []struct {
+		Name          string
+		Data          interface{}
+		ErrorExpected bool
+		ExpectedPath  string
+	}{
+		{Name: "Wipe", Data: WipeData{}, ExpectedPath: ""},
+		{Name: "Wipe Cluster Objects", Data: &WipeData{Path: "cluster"}, ExpectedPath: "cluster"},
+		{Name: "External Data", Data: ExternalData{Name: "allow.list", Value: map[string]interface{}{}}, ExpectedPath: "external/allow.list"},
+		{Name: "External Data Without Name", Data: &ExternalData{}, ErrorExpected: true},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			h := &K8sValidationTarget{}
+			handled, path, _, err := h.ProcessData(tt.Data)
+			if !handled {
+				t.Errorf("handled = false; want true")
+			}
+			if (err != nil) != tt.ErrorExpected {
+				t.Errorf("err = %v; want error: %v", err, tt.ErrorExpected)
+			}
+			if path != tt.ExpectedPath {
+				t.Errorf("path = %s; want %s", path, tt.ExpectedPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package testutils
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ConstraintOpt mutates a constraint built by NewConstraint
+type ConstraintOpt func(*unstructured.Unstructured) error
+
+// ConstraintGVK returns the GroupVersionKind of a constraint of the given kind
+func ConstraintGVK(kind string) schema.GroupVersionKind {
+	return schema.GroupVersionKind{
+		Group:   "constraints.gatekeeper.sh",
+		Version: "v1beta1",
+		Kind:    kind,
+	}
+}
+
+// NewConstraint builds an unstructured constraint of the given kind and name. It panics
+// if an option fails, as a malformed fixture is a bug in the test.
+func NewConstraint(kind, name string, opts ...ConstraintOpt) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(ConstraintGVK(kind))
+	u.SetName(name)
+	for _, opt := range opts {
+		if err := opt(u); err != nil {
+			panic(err)
+		}
+	}
+	return u
+}
+
+// WithEnforcementAction sets spec.enforcementAction
+func WithEnforcementAction(action string) ConstraintOpt {
+	return func(u *unstructured.Unstructured) error {
+		return unstructured.SetNestedField(u.Object, action, "spec", "enforcementAction")
+	}
+}
+
+// WithMatchKinds adds an entry to spec.match.kinds
+func WithMatchKinds(apiGroups []string, kinds []string) ConstraintOpt {
+	return func(u *unstructured.Unstructured) error {
+		existing, _, err := unstructured.NestedSlice(u.Object, "spec", "match", "kinds")
+		if err != nil {
+			return err
+		}
+		entry := map[string]interface{}{
+			"apiGroups": toInterfaces(apiGroups),
+			"kinds":     toInterfaces(kinds),
+		}
+		return unstructured.SetNestedSlice(u.Object, append(existing, entry), "spec", "match", "kinds")
+	}
+}
+
+// WithParameters sets spec.parameters. Values must be JSON-compatible.
+func WithParameters(params map[string]interface{}) ConstraintOpt {
+	return func(u *unstructured.Unstructured) error {
+		return unstructured.SetNestedField(u.Object, params, "spec", "parameters")
+	}
+}
+
+// WithLabels sets the constraint's labels
+func WithLabels(labels map[string]string) ConstraintOpt {
+	return func(u *unstructured.Unstructured) error {
+		u.SetLabels(labels)
+		return nil
+	}
+}
+
+func toInterfaces(strs []string) []interface{} {
+	out := make([]interface{}, 0, len(strs))
+	for _, s := range strs {
+		out = append(out, s)
+	}
+	return out
+}
@@ -0,0 +1,125 @@
+package testutils
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+
+	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const fakeTarget = "admission.k8s.gatekeeper.sh"
+
+// FakeOpa is an in-memory stand-in for the constraint framework client. It records
+// constraints without compiling templates or evaluating any Rego, which makes it
+// suitable for exercising controllers in unit tests.
+type FakeOpa struct {
+	mux         sync.RWMutex
+	constraints map[string]*unstructured.Unstructured
+
+	// AddConstraintErr, if set, is returned by AddConstraint and the constraint is not stored
+	AddConstraintErr error
+	// RemoveConstraintErr, if set, is returned by RemoveConstraint and the constraint is not removed
+	RemoveConstraintErr error
+}
+
+// NewFakeOpa returns an empty FakeOpa
+func NewFakeOpa() *FakeOpa {
+	return &FakeOpa{
+		constraints: make(map[string]*unstructured.Unstructured),
+	}
+}
+
+// constraintKey mirrors the storage path used by the constraint framework
+func constraintKey(constraint *unstructured.Unstructured) (string, error) {
+	if constraint.GetName() == "" {
+		return "", fmt.Errorf("Constraint has no name")
+	}
+	gvk := constraint.GroupVersionKind()
+	if gvk.Group == "" {
+		return "", fmt.Errorf("Empty group for the constraint named %s", constraint.GetName())
+	}
+	if gvk.Kind == "" {
+		return "", fmt.Errorf("Empty kind for the constraint named %s", constraint.GetName())
+	}
+	return path.Join("cluster", gvk.Group, gvk.Kind, constraint.GetName()), nil
+}
+
+func handled() *types.Responses {
+	resp := types.NewResponses()
+	resp.Handled[fakeTarget] = true
+	return resp
+}
+
+// AddConstraint stores a copy of the constraint
+func (f *FakeOpa) AddConstraint(ctx context.Context, constraint *unstructured.Unstructured) (*types.Responses, error) {
+	key, err := constraintKey(constraint)
+	if err != nil {
+		return types.NewResponses(), err
+	}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if f.AddConstraintErr != nil {
+		return types.NewResponses(), f.AddConstraintErr
+	}
+	f.constraints[key] = constraint.DeepCopy()
+	return handled(), nil
+}
+
+// RemoveConstraint removes the constraint, if present
+func (f *FakeOpa) RemoveConstraint(ctx context.Context, constraint *unstructured.Unstructured) (*types.Responses, error) {
+	key, err := constraintKey(constraint)
+	if err != nil {
+		return types.NewResponses(), err
+	}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if f.RemoveConstraintErr != nil {
+		return types.NewResponses(), f.RemoveConstraintErr
+	}
+	delete(f.constraints, key)
+	return handled(), nil
+}
+
+// GetConstraint returns a copy of the stored constraint, or a MissingConstraintError
+func (f *FakeOpa) GetConstraint(ctx context.Context, constraint *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	key, err := constraintKey(constraint)
+	if err != nil {
+		return nil, err
+	}
+	f.mux.RLock()
+	defer f.mux.RUnlock()
+	c, ok := f.constraints[key]
+	if !ok {
+		return nil, opa.NewMissingConstraintError(key)
+	}
+	return c.DeepCopy(), nil
+}
+
+// HasConstraint returns true if the constraint has been added and not removed
+func (f *FakeOpa) HasConstraint(constraint *unstructured.Unstructured) bool {
+	_, err := f.GetConstraint(context.Background(), constraint)
+	return err == nil
+}
+
+// Len returns the number of stored constraints
+func (f *FakeOpa) Len() int {
+	f.mux.RLock()
+	defer f.mux.RUnlock()
+	return len(f.constraints)
+}
+
+// Constraints returns copies of the stored constraints, as the queries of the policy
+// engine list them
+func (f *FakeOpa) Constraints(ctx context.Context) ([]*unstructured.Unstructured, error) {
+	f.mux.RLock()
+	defer f.mux.RUnlock()
+	constraints := make([]*unstructured.Unstructured, 0, len(f.constraints))
+	for _, c := range f.constraints {
+		constraints = append(constraints, c.DeepCopy())
+	}
+	return constraints, nil
+}
@@ -0,0 +1,67 @@
+package util
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var surfacedAnnotations = flag.String("surface-constraint-annotations", "", "comma-separated list of constraint annotations, such as a severity or ticket URL, to add to deny messages, violation events and the labels of the admission violations metric. Each annotation adds a metric label, so only list annotations with few distinct values")
+
+// SurfacedAnnotations returns the constraint annotations allowlisted by flag, in order
+func SurfacedAnnotations() []string {
+	var keys []string
+	for _, key := range strings.Split(*surfacedAnnotations, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// SelectAnnotations returns the annotations of obj named in keys, or nil if it has none
+// of them
+func SelectAnnotations(obj metav1.Object, keys []string) map[string]string {
+	var selected map[string]string
+	annotations := obj.GetAnnotations()
+	for _, key := range keys {
+		value, ok := annotations[key]
+		if !ok {
+			continue
+		}
+		if selected == nil {
+			selected = make(map[string]string)
+		}
+		selected[key] = value
+	}
+	return selected
+}
+
+// DescribeAnnotations formats the annotations of obj named in keys for a message, for
+// example " (severity: high)". It returns "" if obj has none of them.
+func DescribeAnnotations(obj metav1.Object, keys []string) string {
+	annotations := obj.GetAnnotations()
+	var parts []string
+	for _, key := range keys {
+		if value, ok := annotations[key]; ok {
+			parts = append(parts, fmt.Sprintf("%s: %s", key, value))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// AnnotationLabel returns the metric label for the annotation key. Characters that are
+// not allowed in Prometheus label names are replaced with underscores.
+func AnnotationLabel(key string) string {
+	return "annotation_" + strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, key)
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSurfacedAnnotations(t *testing.T) {
+	defer func(old string) { *surfacedAnnotations = old }(*surfacedAnnotations)
+	*surfacedAnnotations = "severity, example.com/ticket,"
+	want := []string{"severity", "example.com/ticket"}
+	if got := SurfacedAnnotations(); !reflect.DeepEqual(got, want) {
+		t.Errorf("SurfacedAnnotations() = %v, want %v", got, want)
+	}
+}
+
+func TestDescribeAnnotations(t *testing.T) {
+	keys := []string{"severity", "example.com/ticket"}
+	tc := []struct {
+		Name        string
+		Annotations map[string]string
+		Selected    map[string]string
+		Expected    string
+	}{
+		{Name: "No annotations"},
+		{Name: "Other annotations", Annotations: map[string]string{"team": "a"}},
+		{
+			Name:        "Allowlisted annotations",
+			Annotations: map[string]string{"team": "a", "example.com/ticket": "https://example.com/1", "severity": "high"},
+			Selected:    map[string]string{"example.com/ticket": "https://example.com/1", "severity": "high"},
+			Expected:    " (severity: high, example.com/ticket: https://example.com/1)",
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{}
+			obj.SetAnnotations(tt.Annotations)
+			if got := DescribeAnnotations(obj, keys); got != tt.Expected {
+				t.Errorf("DescribeAnnotations() = %q, want %q", got, tt.Expected)
+			}
+			if got := SelectAnnotations(obj, keys); !reflect.DeepEqual(got, tt.Selected) {
+				t.Errorf("SelectAnnotations() = %v, want %v", got, tt.Selected)
+			}
+		})
+	}
+}
+
+func TestAnnotationLabel(t *testing.T) {
+	if got := AnnotationLabel("example.com/ticket-url"); got != "annotation_example_com_ticket_url" {
+		t.Errorf("AnnotationLabel() = %q", got)
+	}
+}
@@ -0,0 +1,130 @@
+package constraint
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// InactiveCondition is set when a constraint's spec.match.clusterVersions or
+// spec.match.featureGates do not hold on this cluster, so it is not enforced
+const InactiveCondition = "Inactive"
+
+// ClusterInfo describes the cluster that spec.match.clusterVersions and
+// spec.match.featureGates are checked against
+type ClusterInfo struct {
+	// Version is the API server's version, nil if it could not be read
+	Version *version.Version
+	// FeatureGates are the API server's feature gates known to Gatekeeper
+	FeatureGates map[string]bool
+}
+
+var versionOperators = []string{">=", "<=", ">", "<", "="}
+
+// versionCondition is an entry of spec.match.clusterVersions, such as >=1.16
+type versionCondition struct {
+	op      string
+	version *version.Version
+}
+
+func parseVersionCondition(s string) (*versionCondition, error) {
+	s = strings.TrimSpace(s)
+	for _, op := range versionOperators {
+		if !strings.HasPrefix(s, op) {
+			continue
+		}
+		v, err := version.ParseGeneric(strings.TrimSpace(strings.TrimPrefix(s, op)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid spec.match.clusterVersions entry %q: %v", s, err)
+		}
+		return &versionCondition{op: op, version: v}, nil
+	}
+	return nil, fmt.Errorf("invalid spec.match.clusterVersions entry %q: must start with one of %s", s, strings.Join(versionOperators, ", "))
+}
+
+func (c *versionCondition) holds(v *version.Version) bool {
+	switch c.op {
+	case ">=":
+		return v.AtLeast(c.version)
+	case "<=":
+		return !c.version.LessThan(v)
+	case ">":
+		return c.version.LessThan(v)
+	case "<":
+		return v.LessThan(c.version)
+	default:
+		// =1.16 matches every 1.16 patch release
+		want, got := c.version.Components(), v.Components()
+		for i := range want {
+			if i >= len(got) || got[i] != want[i] {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+type clusterConditions struct {
+	versions     []*versionCondition
+	featureGates []string
+}
+
+func readClusterConditions(obj *unstructured.Unstructured) (*clusterConditions, error) {
+	versions, _, err := unstructured.NestedStringSlice(obj.Object, "spec", "match", "clusterVersions")
+	if err != nil {
+		return nil, err
+	}
+	gates, _, err := unstructured.NestedStringSlice(obj.Object, "spec", "match", "featureGates")
+	if err != nil {
+		return nil, err
+	}
+	conds := &clusterConditions{featureGates: gates}
+	for _, s := range versions {
+		c, err := parseVersionCondition(s)
+		if err != nil {
+			return nil, err
+		}
+		conds.versions = append(conds.versions, c)
+	}
+	return conds, nil
+}
+
+// ValidateClusterConditions returns an error if the constraint's spec.match.clusterVersions
+// or spec.match.featureGates are malformed
+func ValidateClusterConditions(obj *unstructured.Unstructured) error {
+	_, err := readClusterConditions(obj)
+	return err
+}
+
+// InactiveReason returns why the constraint should not be enforced on the cluster, or an
+// empty string if it should be. The constraint is enforced only if the cluster's version
+// satisfies every entry of spec.match.clusterVersions and every feature gate in
+// spec.match.featureGates is enabled.
+func (ci *ClusterInfo) InactiveReason(obj *unstructured.Unstructured) (string, error) {
+	conds, err := readClusterConditions(obj)
+	if err != nil {
+		return "", err
+	}
+	if len(conds.versions) > 0 {
+		if ci == nil || ci.Version == nil {
+			return "spec.match.clusterVersions is set but the cluster version is unknown", nil
+		}
+		for _, c := range conds.versions {
+			if !c.holds(ci.Version) {
+				return fmt.Sprintf("cluster version %s does not satisfy %s%s", ci.Version, c.op, c.version), nil
+			}
+		}
+	}
+	var disabled []string
+	for _, gate := range conds.featureGates {
+		if ci == nil || !ci.FeatureGates[gate] {
+			disabled = append(disabled, gate)
+		}
+	}
+	if len(disabled) > 0 {
+		return fmt.Sprintf("feature gates not enabled on the cluster: %s", strings.Join(disabled, ", ")), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,59 @@
+package constraint
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+func TestInactiveReason(t *testing.T) {
+	cluster := &ClusterInfo{
+		Version:      version.MustParseGeneric("v1.16.4-gke.1"),
+		FeatureGates: map[string]bool{"EphemeralContainers": true, "CSIInlineVolume": false},
+	}
+	tc := []struct {
+		Name     string
+		Versions []string
+		Gates    []string
+		Cluster  *ClusterInfo
+		Inactive bool
+		Error    bool
+	}{
+		{Name: "No conditions", Cluster: &ClusterInfo{}},
+		{Name: "At least", Versions: []string{">=1.16"}, Cluster: cluster},
+		{Name: "Range", Versions: []string{">1.15", "<1.17"}, Cluster: cluster},
+		{Name: "Too old", Versions: []string{">=1.17"}, Cluster: cluster, Inactive: true},
+		{Name: "Too new", Versions: []string{"<=1.16.3"}, Cluster: cluster, Inactive: true},
+		{Name: "Minor", Versions: []string{"=1.16"}, Cluster: cluster},
+		{Name: "Other minor", Versions: []string{"= 1.15"}, Cluster: cluster, Inactive: true},
+		{Name: "Unknown version", Versions: []string{">=1.16"}, Cluster: &ClusterInfo{}, Inactive: true},
+		{Name: "Gate enabled", Gates: []string{"EphemeralContainers"}, Cluster: cluster},
+		{Name: "Gate disabled", Gates: []string{"CSIInlineVolume"}, Cluster: cluster, Inactive: true},
+		{Name: "Gate unknown", Gates: []string{"Unknown"}, Cluster: cluster, Inactive: true},
+		{Name: "No operator", Versions: []string{"1.16"}, Cluster: cluster, Error: true},
+		{Name: "Bad version", Versions: []string{">=one"}, Cluster: cluster, Error: true},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			if tt.Versions != nil {
+				if err := unstructured.SetNestedStringSlice(obj.Object, tt.Versions, "spec", "match", "clusterVersions"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.Gates != nil {
+				if err := unstructured.SetNestedStringSlice(obj.Object, tt.Gates, "spec", "match", "featureGates"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			reason, err := tt.Cluster.InactiveReason(obj)
+			if (err != nil) != tt.Error {
+				t.Fatalf("InactiveReason() error = %v, wanted error = %v", err, tt.Error)
+			}
+			if (reason != "") != tt.Inactive {
+				t.Errorf("InactiveReason() = %q, wanted inactive = %v", reason, tt.Inactive)
+			}
+		})
+	}
+}
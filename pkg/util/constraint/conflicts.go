@@ -0,0 +1,220 @@
+package constraint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// ConflictingParametersAnnotation, set on a ConstraintTemplate, lists pairs of its
+	// parameters that contradict each other when two of its constraints give them a
+	// common value, such as requiredLabels:forbiddenLabels. Pairs are comma-separated.
+	ConflictingParametersAnnotation = "gatekeeper.sh/conflicting-parameters"
+
+	// ConflictCondition is set when a constraint's parameters contradict those of another
+	// constraint of the same kind that may match the same objects
+	ConflictCondition = "Conflict"
+)
+
+// ParameterPair is a pair of parameters that contradict each other
+type ParameterPair [2]string
+
+// ParseConflictingParameters parses the value of ConflictingParametersAnnotation
+func ParseConflictingParameters(s string) ([]ParameterPair, error) {
+	var pairs []ParameterPair
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid %s entry %q, want <parameter>:<parameter>", ConflictingParametersAnnotation, entry)
+		}
+		pairs = append(pairs, ParameterPair{parts[0], parts[1]})
+	}
+	return pairs, nil
+}
+
+// Conflicts describes each value that a parameter of a and the contradicting parameter
+// of b, as declared by pairs, have in common. It returns nil if the match criteria of a
+// and b cannot select the same object. The check of match criteria is conservative:
+// criteria it cannot compare, such as matchExpressions, are taken to overlap.
+func Conflicts(a, b *unstructured.Unstructured, pairs []ParameterPair) []string {
+	if len(pairs) == 0 || !MatchesOverlap(a, b) {
+		return nil
+	}
+	var conflicts []string
+	for _, pair := range pairs {
+		for _, p := range []ParameterPair{pair, {pair[1], pair[0]}} {
+			common := intersect(parameterValues(a, p[0]), parameterValues(b, p[1]))
+			if len(common) == 0 {
+				continue
+			}
+			conflicts = append(conflicts, fmt.Sprintf("%s and %s of %s both contain %s", p[0], p[1], b.GetName(), strings.Join(common, ", ")))
+		}
+	}
+	return conflicts
+}
+
+// parameterValues returns the value of a top-level parameter, or the values of a list
+// parameter, as strings
+func parameterValues(obj *unstructured.Unstructured, name string) []string {
+	v, found, err := unstructured.NestedFieldNoCopy(obj.Object, "spec", "parameters", name)
+	if err != nil || !found || v == nil {
+		return nil
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return []string{fmt.Sprint(v)}
+	}
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		values = append(values, fmt.Sprint(item))
+	}
+	return values
+}
+
+func intersect(a, b []string) []string {
+	in := make(map[string]bool, len(a))
+	for _, v := range a {
+		in[v] = true
+	}
+	var common []string
+	for _, v := range b {
+		if in[v] {
+			common = append(common, v)
+			in[v] = false
+		}
+	}
+	sort.Strings(common)
+	return common
+}
+
+// MatchesOverlap returns false if the spec.match of a and b cannot select the same
+// object, comparing kinds, operations, namespaces and the matchLabels of selectors
+func MatchesOverlap(a, b *unstructured.Unstructured) bool {
+	ma, _, _ := unstructured.NestedMap(a.Object, "spec", "match")
+	mb, _, _ := unstructured.NestedMap(b.Object, "spec", "match")
+	return kindsOverlap(ma, mb) &&
+		wildcardListsOverlap(stringList(ma, "operations"), stringList(mb, "operations")) &&
+		namespacesOverlap(ma, mb) &&
+		namespacesOverlap(mb, ma) &&
+		matchLabelsOverlap(ma, mb, "labelSelector") &&
+		matchLabelsOverlap(ma, mb, "namespaceSelector")
+}
+
+// stringList returns the list at field of m, or nil if it is not set
+func stringList(m map[string]interface{}, field string) []string {
+	list, found, err := unstructured.NestedStringSlice(m, field)
+	if err != nil || !found {
+		return nil
+	}
+	return list
+}
+
+// wildcardListsOverlap compares lists where an unset or empty list, or *, matches anything
+func wildcardListsOverlap(a, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+	return listsOverlap(a, b)
+}
+
+// listsOverlap compares lists where * matches anything and an empty list nothing
+func listsOverlap(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == "*" || y == "*" || x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// kindsOverlap compares match.kinds. Unset kinds match every kind.
+func kindsOverlap(ma, mb map[string]interface{}) bool {
+	ka, foundA, errA := unstructured.NestedSlice(ma, "kinds")
+	kb, foundB, errB := unstructured.NestedSlice(mb, "kinds")
+	if !foundA || !foundB || errA != nil || errB != nil {
+		return true
+	}
+	for _, a := range ka {
+		sa, ok := a.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, b := range kb {
+			sb, ok := b.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if listsOverlap(stringList(sa, "apiGroups"), stringList(sb, "apiGroups")) &&
+				listsOverlap(stringList(sa, "kinds"), stringList(sb, "kinds")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// namespacesOverlap returns false if every namespace ma is limited to is left out by
+// either match. Subtree patterns are taken to overlap, as the hierarchy is not known.
+func namespacesOverlap(ma, mb map[string]interface{}) bool {
+	namespaces, found, err := unstructured.NestedStringSlice(ma, "namespaces")
+	if err != nil || !found {
+		return true
+	}
+	included, limited := stringList(mb, "namespaces"), false
+	if _, found, _ := unstructured.NestedStringSlice(mb, "namespaces"); found {
+		limited = true
+	}
+	excluded := append(stringList(ma, "excludedNamespaces"), stringList(mb, "excludedNamespaces")...)
+	for _, ns := range namespaces {
+		if strings.HasSuffix(ns, "/*") {
+			return true
+		}
+		if contains(excluded, ns) {
+			continue
+		}
+		if !limited || contains(included, ns) || hasSubtreePattern(included) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSubtreePattern(patterns []string) bool {
+	for _, p := range patterns {
+		if strings.HasSuffix(p, "/*") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchLabelsOverlap returns false if the matchLabels of the selectors at field require
+// different values of the same label
+func matchLabelsOverlap(ma, mb map[string]interface{}, field string) bool {
+	la, _, _ := unstructured.NestedStringMap(ma, field, "matchLabels")
+	lb, _, _ := unstructured.NestedStringMap(mb, field, "matchLabels")
+	for k, v := range la {
+		if w, ok := lb[k]; ok && w != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package constraint
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func newConflictConstraint(t *testing.T, name, spec string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	if err := yaml.Unmarshal([]byte(spec), &u.Object); err != nil {
+		t.Fatal(err)
+	}
+	u.SetName(name)
+	return u
+}
+
+func TestParseConflictingParameters(t *testing.T) {
+	pairs, err := ParseConflictingParameters("required:forbidden, minReplicas:maxReplicas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ParameterPair{{"required", "forbidden"}, {"minReplicas", "maxReplicas"}}
+	if !reflect.DeepEqual(pairs, want) {
+		t.Errorf("pairs = %v, want %v", pairs, want)
+	}
+	for _, s := range []string{"required", "required:", "a:b:c"} {
+		if _, err := ParseConflictingParameters(s); err == nil {
+			t.Errorf("ParseConflictingParameters(%q) did not fail", s)
+		}
+	}
+}
+
+func TestMatchesOverlap(t *testing.T) {
+	tc := []struct {
+		Name     string
+		A        string
+		B        string
+		Expected bool
+	}{
+		{
+			Name:     "No match criteria",
+			A:        `spec: {}`,
+			B:        `spec: {}`,
+			Expected: true,
+		},
+		{
+			Name:     "Same kind",
+			A:        `spec: {match: {kinds: [{apiGroups: ["apps"], kinds: ["Deployment", "StatefulSet"]}]}}`,
+			B:        `spec: {match: {kinds: [{apiGroups: ["*"], kinds: ["Deployment"]}]}}`,
+			Expected: true,
+		},
+		{
+			Name:     "Different kinds",
+			A:        `spec: {match: {kinds: [{apiGroups: ["apps"], kinds: ["Deployment"]}]}}`,
+			B:        `spec: {match: {kinds: [{apiGroups: [""], kinds: ["Pod"]}]}}`,
+			Expected: false,
+		},
+		{
+			Name:     "Different operations",
+			A:        `spec: {match: {operations: ["CREATE"]}}`,
+			B:        `spec: {match: {operations: ["UPDATE"]}}`,
+			Expected: false,
+		},
+		{
+			Name:     "Different namespaces",
+			A:        `spec: {match: {namespaces: ["production"]}}`,
+			B:        `spec: {match: {namespaces: ["sandbox"]}}`,
+			Expected: false,
+		},
+		{
+			Name:     "Excluded namespace",
+			A:        `spec: {match: {namespaces: ["production"]}}`,
+			B:        `spec: {match: {excludedNamespaces: ["production"]}}`,
+			Expected: false,
+		},
+		{
+			Name:     "Namespace subtree",
+			A:        `spec: {match: {namespaces: ["team-a/*"]}}`,
+			B:        `spec: {match: {namespaces: ["sandbox"]}}`,
+			Expected: true,
+		},
+		{
+			Name:     "Different label values",
+			A:        `spec: {match: {labelSelector: {matchLabels: {tier: web}}}}`,
+			B:        `spec: {match: {labelSelector: {matchLabels: {tier: db}}}}`,
+			Expected: false,
+		},
+		{
+			Name:     "Different namespace labels",
+			A:        `spec: {match: {namespaceSelector: {matchLabels: {env: prod}}}}`,
+			B:        `spec: {match: {namespaceSelector: {matchLabels: {team: apps}}}}`,
+			Expected: true,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			a, b := newConflictConstraint(t, "a", tt.A), newConflictConstraint(t, "b", tt.B)
+			if got := MatchesOverlap(a, b); got != tt.Expected {
+				t.Errorf("MatchesOverlap(a, b) = %v, want %v", got, tt.Expected)
+			}
+			if got := MatchesOverlap(b, a); got != tt.Expected {
+				t.Errorf("MatchesOverlap(b, a) = %v, want %v", got, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	pairs := []ParameterPair{{"required", "forbidden"}, {"minReplicas", "maxReplicas"}}
+	require := newConflictConstraint(t, "require", `spec: {parameters: {required: [owner, team], minReplicas: 3}}`)
+	forbid := newConflictConstraint(t, "forbid", `spec: {parameters: {forbidden: [team, owner]}}`)
+	sandbox := newConflictConstraint(t, "sandbox", `spec: {match: {namespaces: [sandbox]}, parameters: {forbidden: [owner]}}`)
+	production := newConflictConstraint(t, "production", `spec: {match: {namespaces: [production]}, parameters: {required: [owner]}}`)
+
+	if got, want := Conflicts(require, forbid, pairs), []string{"required and forbidden of forbid both contain owner, team"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Conflicts(require, forbid) = %v, want %v", got, want)
+	}
+	if got, want := Conflicts(forbid, require, pairs), []string{"forbidden and required of require both contain owner, team"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Conflicts(forbid, require) = %v, want %v", got, want)
+	}
+	if got := Conflicts(sandbox, production, pairs); got != nil {
+		t.Errorf("Conflicts(sandbox, production) = %v, constraints in different namespaces do not conflict", got)
+	}
+	if got := Conflicts(require, forbid, nil); got != nil {
+		t.Errorf("Conflicts() = %v without declared pairs", got)
+	}
+}
@@ -13,14 +13,54 @@ type Error struct {
 	Location string `json:"location,omitempty"`
 }
 
+// Error codes reported on constraints that could not be added to OPA
+const (
+	// SchemaErrorCode means the constraint does not match the schema declared by its template
+	SchemaErrorCode = "schema_error"
+	// CompileErrorCode means OPA rejected the constraint's data
+	CompileErrorCode = "compile_error"
+	// ConflictErrorCode means the constraint's data conflicts with a rule or other data in OPA
+	ConflictErrorCode = "conflict_error"
+	// UnrecognizedKindErrorCode means the constraint's template is not loaded
+	UnrecognizedKindErrorCode = "unrecognized_kind"
+	// InternalErrorCode covers failures inside the framework or controller
+	InternalErrorCode = "internal_error"
+)
+
+// Condition types reported on constraints
+const (
+	// MissingSyncCondition is set when a constraint's template reads kinds from
+	// data.inventory that are not replicated by the sync config
+	MissingSyncCondition = "MissingSync"
+	// UnknownKindCondition is set when spec.match.kinds lists kinds that API discovery
+	// does not know, so the constraint never matches them
+	UnknownKindCondition = "UnknownKind"
+	// StatusWriteDegradedCondition is set by audit in status.conditions when its status
+	// updates keep conflicting with writes by other field managers
+	StatusWriteDegradedCondition = "StatusWriteDegraded"
+)
+
+// Condition represents a problem that does not prevent a constraint from being
+// enforced but may cause it to behave unexpectedly
+type Condition struct {
+	Type    string `json:"type"`
+	Message string `json:"message,omitempty"`
+}
+
 // ByPodStatus defines the observed state of a constraint as seen by
 // an individual controller
 type ByPodStatus struct {
 	// a unique identifier for the pod that wrote the status
-	ID                 string  `json:"id,omitempty"`
-	ObservedGeneration int64   `json:"observedGeneration,omitempty"`
-	Errors             []Error `json:"errors,omitempty"`
-	Enforced           bool    `json:"enforced,omitempty"`
+	ID                 string      `json:"id,omitempty"`
+	ObservedGeneration int64       `json:"observedGeneration,omitempty"`
+	Errors             []Error     `json:"errors,omitempty"`
+	Conditions         []Condition `json:"conditions,omitempty"`
+	Enforced           bool        `json:"enforced,omitempty"`
+	// the enforcement action in effect after cluster defaults are applied
+	EnforcementAction string `json:"enforcementAction,omitempty"`
+	// the parameters in effect after the template's defaults are applied, set only if
+	// any default was applied
+	EffectiveParameters map[string]interface{} `json:"effectiveParameters,omitempty"`
 }
 
 func GetHAStatus(obj *unstructured.Unstructured) (*ByPodStatus, error) {
@@ -54,3 +94,9 @@ func SetHAStatus(obj *unstructured.Unstructured, status *ByPodStatus) error {
 func DeleteHAStatus(obj *unstructured.Unstructured) error {
 	return deleteHAStatus(obj)
 }
+
+// PruneHAStatus removes the status of every pod for which keep returns false. It
+// reports whether any status was removed.
+func PruneHAStatus(obj *unstructured.Unstructured, keep func(id string) bool) (bool, error) {
+	return pruneHAStatus(obj, keep)
+}
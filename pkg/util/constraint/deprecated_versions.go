@@ -0,0 +1,73 @@
+package constraint
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// DeprecatedVersionCondition is set when a constraint was written through a deprecated
+	// version of its kind
+	DeprecatedVersionCondition = "DeprecatedVersion"
+
+	// StorageVersion is the version constraint CRDs store, which cannot be deprecated
+	StorageVersion = "v1beta1"
+
+	constraintsGroup = "constraints.gatekeeper.sh"
+)
+
+var deprecatedVersions = flag.String("deprecated-constraint-versions", "v1alpha1", "comma-separated constraint versions, such as v1alpha1, that are deprecated. Constraints written through them get a DeprecatedVersion status condition. "+StorageVersion+" cannot be deprecated")
+
+// IsDeprecatedVersion returns true if constraints should no longer be written through version
+func IsDeprecatedVersion(version string) bool {
+	if version == StorageVersion {
+		return false
+	}
+	for _, v := range strings.Split(*deprecatedVersions, ",") {
+		if strings.TrimSpace(v) == version {
+			return true
+		}
+	}
+	return false
+}
+
+// DeprecationMessage asks for writes through the deprecated version to move to the
+// storage version
+func DeprecationMessage(version string) string {
+	return fmt.Sprintf("%s is deprecated, use %s",
+		schema.GroupVersion{Group: constraintsGroup, Version: version},
+		schema.GroupVersion{Group: constraintsGroup, Version: StorageVersion})
+}
+
+// DeprecatedVersionMessage describes the deprecated versions obj was written through, or
+// returns "" if there are none. Versions are read from the managed fields, which the API
+// server only records with the ServerSideApply feature gate.
+func DeprecatedVersionMessage(obj *unstructured.Unstructured) string {
+	managers := make(map[string][]string)
+	for _, f := range obj.GetManagedFields() {
+		gv, err := schema.ParseGroupVersion(f.APIVersion)
+		if err != nil || gv.Group != constraintsGroup || !IsDeprecatedVersion(gv.Version) {
+			continue
+		}
+		managers[gv.Version] = append(managers[gv.Version], f.Manager)
+	}
+	var versions []string
+	for v := range managers {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	var msgs []string
+	for _, v := range versions {
+		sort.Strings(managers[v])
+		msgs = append(msgs, fmt.Sprintf("written through %s by %s", schema.GroupVersion{Group: constraintsGroup, Version: v}, strings.Join(managers[v], ", ")))
+	}
+	if len(msgs) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s; re-apply it as %s", strings.Join(msgs, "; "), schema.GroupVersion{Group: constraintsGroup, Version: StorageVersion})
+}
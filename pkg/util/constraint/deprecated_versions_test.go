@@ -0,0 +1,65 @@
+package constraint
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestIsDeprecatedVersion(t *testing.T) {
+	defer func(v string) { *deprecatedVersions = v }(*deprecatedVersions)
+	*deprecatedVersions = "v1alpha1, v1beta1"
+	tc := []struct {
+		Version    string
+		Deprecated bool
+	}{
+		{Version: "v1alpha1", Deprecated: true},
+		{Version: StorageVersion, Deprecated: false},
+		{Version: "v1", Deprecated: false},
+	}
+	for _, tt := range tc {
+		if got := IsDeprecatedVersion(tt.Version); got != tt.Deprecated {
+			t.Errorf("IsDeprecatedVersion(%q) = %v, wanted %v", tt.Version, got, tt.Deprecated)
+		}
+	}
+}
+
+func TestDeprecatedVersionMessage(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Managers []metav1.ManagedFieldsEntry
+		Expected string
+	}{
+		{
+			Name:     "No managed fields",
+			Expected: "",
+		},
+		{
+			Name: "Storage version only",
+			Managers: []metav1.ManagedFieldsEntry{
+				{Manager: "kubectl", APIVersion: "constraints.gatekeeper.sh/v1beta1"},
+				{Manager: "manager", APIVersion: "constraints.gatekeeper.sh/v1beta1"},
+			},
+			Expected: "",
+		},
+		{
+			Name: "Deprecated version",
+			Managers: []metav1.ManagedFieldsEntry{
+				{Manager: "kubectl", APIVersion: "constraints.gatekeeper.sh/v1alpha1"},
+				{Manager: "argocd", APIVersion: "constraints.gatekeeper.sh/v1alpha1"},
+				{Manager: "manager", APIVersion: "constraints.gatekeeper.sh/v1beta1"},
+			},
+			Expected: "written through constraints.gatekeeper.sh/v1alpha1 by argocd, kubectl; re-apply it as constraints.gatekeeper.sh/v1beta1",
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{}
+			obj.SetManagedFields(tt.Managers)
+			if got := DeprecatedVersionMessage(obj); got != tt.Expected {
+				t.Errorf("DeprecatedVersionMessage() = %q, wanted %q", got, tt.Expected)
+			}
+		})
+	}
+}
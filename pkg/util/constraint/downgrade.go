@@ -0,0 +1,28 @@
+package constraint
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// DowngradedAnnotation is set on a constraint that exhausted the webhook's error budget.
+	// Its value says why. The constraint is enforced again once it is removed.
+	DowngradedAnnotation = "gatekeeper.sh/enforcement-downgraded"
+
+	// DowngradedCondition is set while DowngradedAnnotation holds a constraint's deny
+	// enforcement action back to dryrun
+	DowngradedCondition = "Downgraded"
+)
+
+// Downgraded returns why obj was downgraded, and whether it is
+func Downgraded(obj *unstructured.Unstructured) (string, bool) {
+	reason, ok := obj.GetAnnotations()[DowngradedAnnotation]
+	return reason, ok
+}
+
+// DowngradedMessage describes the downgrade of a constraint for reason
+func DowngradedMessage(reason string) string {
+	return fmt.Sprintf("violations are only reported after the constraint exhausted the error budget (%s). Remove the %s annotation to enforce it again", reason, DowngradedAnnotation)
+}
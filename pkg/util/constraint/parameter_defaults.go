@@ -0,0 +1,125 @@
+package constraint
+
+import (
+	"fmt"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/json"
+)
+
+// StripSchemaDefaults removes the default values from the template's parameter schema.
+// The constraint CRDs are v1beta1 CRDs that preserve unknown fields, which the API server
+// does not allow defaults in, so defaults are applied by the constraint controller
+// instead, see ApplyParameterDefaults.
+func StripSchemaDefaults(templ *templates.ConstraintTemplate) {
+	if templ.Spec.CRD.Spec.Validation == nil {
+		return
+	}
+	stripDefaults(templ.Spec.CRD.Spec.Validation.OpenAPIV3Schema)
+}
+
+func stripDefaults(schema *apiextensions.JSONSchemaProps) {
+	if schema == nil {
+		return
+	}
+	schema.Default = nil
+	for name, prop := range schema.Properties {
+		stripDefaults(&prop)
+		schema.Properties[name] = prop
+	}
+	if schema.Items != nil {
+		stripDefaults(schema.Items.Schema)
+		for i := range schema.Items.JSONSchemas {
+			stripDefaults(&schema.Items.JSONSchemas[i])
+		}
+	}
+	if schema.AdditionalProperties != nil {
+		stripDefaults(schema.AdditionalProperties.Schema)
+	}
+}
+
+// ApplyParameterDefaults sets the defaults declared by the parameter schema of the
+// constraint's template on the fields of spec.parameters that are not set, and reports
+// whether any were. As with CRD defaulting, the fields of an object are only defaulted
+// if the object is set or itself defaulted; spec.parameters is always taken as set.
+func ApplyParameterDefaults(obj *unstructured.Unstructured, schema *apiextensionsv1beta1.JSONSchemaProps) (bool, error) {
+	if schema == nil {
+		return false, nil
+	}
+	params, _, err := unstructured.NestedMap(obj.Object, "spec", "parameters")
+	if err != nil {
+		return false, err
+	}
+	if params == nil {
+		params = make(map[string]interface{})
+	}
+	defaulted, err := applyDefaults(params, schema)
+	if err != nil || !defaulted {
+		return false, err
+	}
+	return true, unstructured.SetNestedMap(obj.Object, params, "spec", "parameters")
+}
+
+func applyDefaults(value map[string]interface{}, schema *apiextensionsv1beta1.JSONSchemaProps) (bool, error) {
+	defaulted := false
+	for name := range schema.Properties {
+		prop := schema.Properties[name]
+		v, ok := value[name]
+		if !ok && prop.Default != nil {
+			var err error
+			if v, err = decodeDefault(prop.Default); err != nil {
+				return false, err
+			}
+			value[name] = v
+			defaulted, ok = true, true
+		}
+		if !ok {
+			continue
+		}
+		nested, err := applyNestedDefaults(v, &prop)
+		if err != nil {
+			return false, err
+		}
+		defaulted = defaulted || nested
+	}
+	return defaulted, nil
+}
+
+func applyNestedDefaults(v interface{}, schema *apiextensionsv1beta1.JSONSchemaProps) (bool, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return applyDefaults(v, schema)
+	case []interface{}:
+		if schema.Items == nil || schema.Items.Schema == nil {
+			return false, nil
+		}
+		defaulted := false
+		for _, item := range v {
+			nested, err := applyNestedDefaults(item, schema.Items.Schema)
+			if err != nil {
+				return false, err
+			}
+			defaulted = defaulted || nested
+		}
+		return defaulted, nil
+	}
+	return false, nil
+}
+
+// decodeDefault decodes a default as unstructured content, where integers are int64s.
+// Only maps and slices have their numbers converted, so the value is decoded as the
+// single element of a slice.
+func decodeDefault(def *apiextensionsv1beta1.JSON) (interface{}, error) {
+	var values []interface{}
+	raw := append(append([]byte("["), def.Raw...), ']')
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, err
+	}
+	if len(values) != 1 {
+		return nil, fmt.Errorf("invalid default %s", def.Raw)
+	}
+	return values[0], nil
+}
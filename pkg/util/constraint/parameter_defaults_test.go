@@ -0,0 +1,110 @@
+package constraint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func jsonDefault(raw string) *apiextensionsv1beta1.JSON {
+	return &apiextensionsv1beta1.JSON{Raw: []byte(raw)}
+}
+
+func TestApplyParameterDefaults(t *testing.T) {
+	schema := &apiextensionsv1beta1.JSONSchemaProps{
+		Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+			"message": {Type: "string", Default: jsonDefault(`"denied"`)},
+			"limit":   {Type: "integer", Default: jsonDefault(`3`)},
+			"labels": {Type: "array", Items: &apiextensionsv1beta1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1beta1.JSONSchemaProps{
+				Type: "object",
+				Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+					"key":   {Type: "string"},
+					"regex": {Type: "string", Default: jsonDefault(`".*"`)},
+				},
+			}}},
+			"exemptions": {Type: "object", Properties: map[string]apiextensionsv1beta1.JSONSchemaProps{
+				"images": {Type: "array", Default: jsonDefault(`[]`)},
+			}},
+		},
+	}
+	tc := []struct {
+		Name      string
+		Params    map[string]interface{}
+		Expected  map[string]interface{}
+		Defaulted bool
+	}{
+		{
+			Name:      "No parameters",
+			Expected:  map[string]interface{}{"message": "denied", "limit": int64(3)},
+			Defaulted: true,
+		},
+		{
+			Name:     "All set",
+			Params:   map[string]interface{}{"message": "custom", "limit": int64(1)},
+			Expected: map[string]interface{}{"message": "custom", "limit": int64(1)},
+		},
+		{
+			Name:   "Array items",
+			Params: map[string]interface{}{"message": "custom", "limit": int64(1), "labels": []interface{}{map[string]interface{}{"key": "owner"}}},
+			Expected: map[string]interface{}{"message": "custom", "limit": int64(1), "labels": []interface{}{
+				map[string]interface{}{"key": "owner", "regex": ".*"},
+			}},
+			Defaulted: true,
+		},
+		{
+			Name:      "Set object",
+			Params:    map[string]interface{}{"message": "custom", "limit": int64(1), "exemptions": map[string]interface{}{}},
+			Expected:  map[string]interface{}{"message": "custom", "limit": int64(1), "exemptions": map[string]interface{}{"images": []interface{}{}}},
+			Defaulted: true,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			if tt.Params != nil {
+				if err := unstructured.SetNestedMap(obj.Object, tt.Params, "spec", "parameters"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			defaulted, err := ApplyParameterDefaults(obj, schema)
+			if err != nil {
+				t.Fatalf("ApplyParameterDefaults() error = %v", err)
+			}
+			if defaulted != tt.Defaulted {
+				t.Errorf("ApplyParameterDefaults() = %v, wanted %v", defaulted, tt.Defaulted)
+			}
+			params, _, _ := unstructured.NestedMap(obj.Object, "spec", "parameters")
+			if !reflect.DeepEqual(params, tt.Expected) {
+				t.Errorf("parameters = %v, wanted %v", params, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestStripSchemaDefaults(t *testing.T) {
+	var value apiextensions.JSON = "denied"
+	def := &value
+	templ := &templates.ConstraintTemplate{}
+	templ.Spec.CRD.Spec.Validation = &templates.Validation{
+		OpenAPIV3Schema: &apiextensions.JSONSchemaProps{
+			Properties: map[string]apiextensions.JSONSchemaProps{
+				"message": {Type: "string", Default: def},
+				"labels": {Type: "array", Items: &apiextensions.JSONSchemaPropsOrArray{
+					Schema: &apiextensions.JSONSchemaProps{Type: "string", Default: def},
+				}},
+			},
+		},
+	}
+	StripSchemaDefaults(templ)
+	props := templ.Spec.CRD.Spec.Validation.OpenAPIV3Schema.Properties
+	if props["message"].Default != nil {
+		t.Errorf("message default = %v, wanted it removed", props["message"].Default)
+	}
+	if props["labels"].Items.Schema.Default != nil {
+		t.Errorf("labels item default = %v, wanted it removed", props["labels"].Items.Schema.Default)
+	}
+}
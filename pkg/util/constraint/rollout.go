@@ -0,0 +1,38 @@
+package constraint
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// RolloutCondition is set while a constraint's spec.rollout.dryrunDuration holds its
+// deny enforcement action back to dryrun
+const RolloutCondition = "RollingOut"
+
+// RolloutEnd returns when the dry-run phase of a constraint's rollout ends: its creation
+// plus spec.rollout.dryrunDuration, a duration such as 72h. It returns the zero time if the
+// constraint has no rollout.
+func RolloutEnd(obj *unstructured.Unstructured) (time.Time, error) {
+	s, found, err := unstructured.NestedString(obj.Object, "spec", "rollout", "dryrunDuration")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid spec.rollout.dryrunDuration: %v", err)
+	}
+	if !found || s == "" {
+		return time.Time{}, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid spec.rollout.dryrunDuration %q: %v", s, err)
+	}
+	if d < 0 {
+		return time.Time{}, fmt.Errorf("invalid spec.rollout.dryrunDuration %q: must not be negative", s)
+	}
+	return obj.GetCreationTimestamp().Add(d), nil
+}
+
+// RolloutMessage describes the dry-run phase of a rollout that ends at end
+func RolloutMessage(end time.Time) string {
+	return fmt.Sprintf("violations are only reported until %s, when the constraint starts to deny", end.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,43 @@
+package constraint
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRolloutEnd(t *testing.T) {
+	created := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	tc := []struct {
+		Name     string
+		Duration interface{}
+		Expected time.Time
+		Error    bool
+	}{
+		{Name: "No rollout"},
+		{Name: "Duration", Duration: "72h", Expected: created.Add(72 * time.Hour)},
+		{Name: "Invalid duration", Duration: "3 days", Error: true},
+		{Name: "Negative duration", Duration: "-1h", Error: true},
+		{Name: "Not a string", Duration: int64(3), Error: true},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			obj.SetCreationTimestamp(metav1.NewTime(created))
+			if tt.Duration != nil {
+				if err := unstructured.SetNestedField(obj.Object, tt.Duration, "spec", "rollout", "dryrunDuration"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := RolloutEnd(obj)
+			if (err != nil) != tt.Error {
+				t.Fatalf("RolloutEnd() error = %v, wanted error %v", err, tt.Error)
+			}
+			if !got.Equal(tt.Expected) {
+				t.Errorf("RolloutEnd() = %v, wanted %v", got, tt.Expected)
+			}
+		})
+	}
+}
@@ -131,3 +131,36 @@ func deleteHAStatus(obj *unstructured.Unstructured) error {
 	}
 	return nil
 }
+
+func pruneHAStatus(obj *unstructured.Unstructured, keep func(id string) bool) (bool, error) {
+	statuses, exists, err := unstructured.NestedSlice(obj.Object, "status", "byPod")
+	if err != nil {
+		return false, errors.Wrap(err, "while pruning HA status")
+	}
+	if !exists {
+		return false, nil
+	}
+
+	newStatus := make([]interface{}, 0, len(statuses))
+	for i, s := range statuses {
+		curStatus, ok := s.(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf("element %d in byPod status is malformed", i)
+		}
+		curID, ok := curStatus["id"].(string)
+		if !ok {
+			return false, fmt.Errorf("element %d in byPod status' `id` field is missing or not a string", i)
+		}
+		if !keep(curID) {
+			continue
+		}
+		newStatus = append(newStatus, s)
+	}
+	if len(newStatus) == len(statuses) {
+		return false, nil
+	}
+	if err := unstructured.SetNestedSlice(obj.Object, newStatus, "status", "byPod"); err != nil {
+		return false, errors.Wrap(err, "while writing pruned byPod status")
+	}
+	return true, nil
+}
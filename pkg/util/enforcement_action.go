@@ -9,13 +9,15 @@ import (
 type EnforcementAction string
 
 const (
-	Deny         EnforcementAction = "deny"
-	Dryrun       EnforcementAction = "dryrun"
+	Deny   EnforcementAction = "deny"
+	Dryrun EnforcementAction = "dryrun"
+	// Audit violations are only reported by audit. The webhook ignores them.
+	Audit        EnforcementAction = "audit"
 	Unrecognized EnforcementAction = "unrecognized"
 )
 
-var supportedEnforcementActions = []EnforcementAction{Deny, Dryrun}
-var KnownEnforcementActions = []EnforcementAction{Deny, Dryrun, Unrecognized}
+var supportedEnforcementActions = []EnforcementAction{Deny, Dryrun, Audit}
+var KnownEnforcementActions = []EnforcementAction{Deny, Dryrun, Audit, Unrecognized}
 
 func ValidateEnforcementAction(input EnforcementAction) error {
 	for _, n := range supportedEnforcementActions {
@@ -48,3 +48,20 @@ func DeleteCTHAStatus(template *v1beta1.ConstraintTemplate) {
 	}
 	template.Status.ByPod = newStatus
 }
+
+// PruneCTHAStatus removes the status of every pod for which keep returns false. It
+// reports whether any status was removed.
+func PruneCTHAStatus(template *v1beta1.ConstraintTemplate, keep func(id string) bool) bool {
+	var newStatus []*v1beta1.ByPodStatus
+	for _, status := range template.Status.ByPod {
+		if !keep(status.ID) {
+			continue
+		}
+		newStatus = append(newStatus, status)
+	}
+	if len(newStatus) == len(template.Status.ByPod) {
+		return false
+	}
+	template.Status.ByPod = newStatus
+	return true
+}
@@ -0,0 +1,40 @@
+package util
+
+import "strings"
+
+// subtreeSuffix marks a namespace pattern such as team-a/* that matches the namespace and
+// all of its descendants
+const subtreeSuffix = "/*"
+
+// hierarchyLabelSuffix follows the Hierarchical Namespace Controller's convention of
+// labeling a namespace <ancestor>.tree.hnc.x-k8s.io/depth for itself and each ancestor
+const hierarchyLabelSuffix = ".tree.hnc.x-k8s.io/depth"
+
+// NamespaceMatches reports whether the namespace ns, with labels nsLabels, matches
+// pattern: either a namespace name or a subtree such as team-a/*
+func NamespaceMatches(pattern, ns string, nsLabels map[string]string) bool {
+	if !isSubtree(pattern) {
+		return pattern == ns
+	}
+	root := strings.TrimSuffix(pattern, subtreeSuffix)
+	if root == ns {
+		return true
+	}
+	_, ok := nsLabels[root+hierarchyLabelSuffix]
+	return ok
+}
+
+// AnyNamespaceMatches reports whether the namespace matches any of patterns
+func AnyNamespaceMatches(patterns []string, ns string, nsLabels map[string]string) bool {
+	for _, p := range patterns {
+		if NamespaceMatches(p, ns, nsLabels) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSubtree reports whether pattern matches a subtree rather than a single namespace
+func isSubtree(pattern string) bool {
+	return strings.HasSuffix(pattern, subtreeSuffix)
+}
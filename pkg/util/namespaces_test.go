@@ -0,0 +1,33 @@
+package util
+
+import "testing"
+
+func TestNamespaceMatches(t *testing.T) {
+	// team-a-web is a child of team-a, as labeled by the Hierarchical Namespace Controller
+	child := map[string]string{
+		"team-a.tree.hnc.x-k8s.io/depth":     "1",
+		"team-a-web.tree.hnc.x-k8s.io/depth": "0",
+	}
+	tc := []struct {
+		Name     string
+		Pattern  string
+		NS       string
+		Labels   map[string]string
+		Expected bool
+	}{
+		{Name: "Name", Pattern: "team-a", NS: "team-a", Expected: true},
+		{Name: "Other name", Pattern: "team-a", NS: "team-a-web", Labels: child},
+		{Name: "Subtree root", Pattern: "team-a/*", NS: "team-a", Expected: true},
+		{Name: "Subtree child", Pattern: "team-a/*", NS: "team-a-web", Labels: child, Expected: true},
+		{Name: "Child's subtree", Pattern: "team-a-web/*", NS: "team-a-web", Labels: child, Expected: true},
+		{Name: "Unlabeled namespace", Pattern: "team-a/*", NS: "team-a-web"},
+		{Name: "Other subtree", Pattern: "team-b/*", NS: "team-a-web", Labels: child},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			if got := NamespaceMatches(tt.Pattern, tt.NS, tt.Labels); got != tt.Expected {
+				t.Errorf("NamespaceMatches(%q, %q) = %v, want %v", tt.Pattern, tt.NS, got, tt.Expected)
+			}
+		})
+	}
+}
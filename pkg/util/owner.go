@@ -0,0 +1,24 @@
+package util
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OwnerLabel names the team responsible for a constraint. Its value is copied into
+// the logs and reports of the constraint's violations so they can be routed.
+const OwnerLabel = "owner"
+
+// GetOwner returns the owner of obj, or "" if it has none
+func GetOwner(obj metav1.Object) string {
+	return obj.GetLabels()[OwnerLabel]
+}
+
+// DescribeOwner formats the owner of obj for a message, for example " (owner: platform)".
+// It returns "" if obj has none.
+func DescribeOwner(obj metav1.Object) string {
+	owner := GetOwner(obj)
+	if owner == "" {
+		return ""
+	}
+	return " (" + OwnerLabel + ": " + owner + ")"
+}
@@ -0,0 +1,35 @@
+package util
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGetOwner(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Labels   map[string]string
+		Expected string
+	}{
+		{Name: "No labels"},
+		{Name: "Other labels", Labels: map[string]string{"team": "a"}},
+		{Name: "Owner label", Labels: map[string]string{OwnerLabel: "platform"}, Expected: "platform"},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{}
+			obj.SetLabels(tt.Labels)
+			if got := GetOwner(obj); got != tt.Expected {
+				t.Errorf("GetOwner() = %q, want %q", got, tt.Expected)
+			}
+			described := ""
+			if tt.Expected != "" {
+				described = " (owner: " + tt.Expected + ")"
+			}
+			if got := DescribeOwner(obj); got != described {
+				t.Errorf("DescribeOwner() = %q, want %q", got, described)
+			}
+		})
+	}
+}
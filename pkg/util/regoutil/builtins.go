@@ -0,0 +1,78 @@
+package regoutil
+
+import (
+	"flag"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// DisallowedBuiltinCode is the error code reported for calls to disallowed builtins
+const DisallowedBuiltinCode = "disallowed_builtin"
+
+var disallowedBuiltins = flag.String("disallowed-rego-builtins", "http.send,net.lookup_ip_addr,opa.runtime", "comma-separated list of Rego builtins that constraint templates may not call. Set to an empty string to allow all builtins")
+
+// DisallowedBuiltins returns the builtins templates may not call, as configured by flag
+func DisallowedBuiltins() map[string]bool {
+	denied := make(map[string]bool)
+	for _, name := range strings.Split(*disallowedBuiltins, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			denied[name] = true
+		}
+	}
+	return denied
+}
+
+// CheckBuiltins returns an ast.Errors listing every call the template's Rego makes to a
+// denied builtin, or nil if there are none. Rego that fails to parse is left for the
+// framework to report.
+func CheckBuiltins(templ *templates.ConstraintTemplate, denied map[string]bool) error {
+	if len(denied) == 0 {
+		return nil
+	}
+	var errs ast.Errors
+	for _, target := range templ.Spec.Targets {
+		for _, src := range append([]string{target.Rego}, target.Libs...) {
+			m, err := ast.ParseModule("", src)
+			if err != nil {
+				continue
+			}
+			errs = append(errs, deniedCalls(m, denied)...)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Location.Compare(errs[j].Location) < 0
+	})
+	return errs
+}
+
+func deniedCalls(m *ast.Module, denied map[string]bool) ast.Errors {
+	var errs ast.Errors
+	check := func(op ast.Ref, loc *ast.Location) {
+		name := op.String()
+		if denied[name] {
+			errs = append(errs, ast.NewError(DisallowedBuiltinCode, loc, "call to disallowed builtin %s", name))
+		}
+	}
+	// calls can appear either as whole expressions or nested inside terms
+	ast.WalkExprs(m, func(expr *ast.Expr) bool {
+		if expr.IsCall() {
+			check(expr.Operator(), expr.Location)
+		}
+		return false
+	})
+	ast.WalkTerms(m, func(t *ast.Term) bool {
+		if call, ok := t.Value.(ast.Call); ok && len(call) > 0 {
+			if op, ok := call[0].Value.(ast.Ref); ok {
+				check(op, t.Location)
+			}
+		}
+		return false
+	})
+	return errs
+}
@@ -0,0 +1,100 @@
+package regoutil
+
+import (
+	"testing"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/opa/ast"
+)
+
+func templateWithRego(rego string, libs ...string) *templates.ConstraintTemplate {
+	return &templates.ConstraintTemplate{
+		Spec: templates.ConstraintTemplateSpec{
+			Targets: []templates.Target{{Target: "admission.k8s.gatekeeper.sh", Rego: rego, Libs: libs}},
+		},
+	}
+}
+
+func TestCheckBuiltins(t *testing.T) {
+	denied := map[string]bool{"http.send": true, "opa.runtime": true}
+	tc := []struct {
+		Name           string
+		Template       *templates.ConstraintTemplate
+		ExpectedErrors int
+	}{
+		{
+			Name: "No builtins",
+			Template: templateWithRego(`package foo
+
+violation[{"msg": "denied"}] {
+  input.review.object.metadata.name == "bad"
+}`),
+		},
+		{
+			Name: "Allowed builtins",
+			Template: templateWithRego(`package foo
+
+violation[{"msg": msg}] {
+  msg := sprintf("%v is bad", [input.review.object.metadata.name])
+}`),
+		},
+		{
+			Name: "Assigned call",
+			Template: templateWithRego(`package foo
+
+violation[{"msg": msg}] {
+  resp := http.send({"method": "get", "url": "http://example.com"})
+  msg := resp.body
+}`),
+			ExpectedErrors: 1,
+		},
+		{
+			Name: "Calls in rego and libs",
+			Template: templateWithRego(`package foo
+
+violation[{"msg": "denied"}] {
+  rt := opa.runtime()
+  rt.env.SECRET
+}`, `package lib.foo
+
+fetch(url) = resp {
+  http.send({"method": "get", "url": url}, resp)
+}`),
+			ExpectedErrors: 2,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := CheckBuiltins(tt.Template, denied)
+			if tt.ExpectedErrors == 0 {
+				if err != nil {
+					t.Errorf("CheckBuiltins() = %v, wanted nil", err)
+				}
+				return
+			}
+			errs, ok := err.(ast.Errors)
+			if !ok {
+				t.Fatalf("CheckBuiltins() = %v, wanted ast.Errors", err)
+			}
+			if len(errs) != tt.ExpectedErrors {
+				t.Errorf("CheckBuiltins() = %v, wanted %d errors", errs, tt.ExpectedErrors)
+			}
+			for _, e := range errs {
+				if e.Code != DisallowedBuiltinCode {
+					t.Errorf("error code = %s, wanted %s", e.Code, DisallowedBuiltinCode)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckBuiltinsNoDenylist(t *testing.T) {
+	templ := templateWithRego(`package foo
+
+violation[{"msg": "denied"}] {
+  http.send({"method": "get", "url": "http://example.com"}).status_code == 200
+}`)
+	if err := CheckBuiltins(templ, nil); err != nil {
+		t.Errorf("CheckBuiltins() = %v, wanted nil with an empty denylist", err)
+	}
+}
@@ -0,0 +1,93 @@
+package regoutil
+
+import (
+	"sort"
+
+	"github.com/open-policy-agent/opa/ast"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// InventoryKind is a kind read by a policy from data.inventory. An empty GroupVersion
+// means the policy reads the kind under any group/version.
+type InventoryKind struct {
+	GroupVersion string
+	Kind         string
+}
+
+// String returns the kind in the same form as an object's apiVersion/kind
+func (k InventoryKind) String() string {
+	if k.GroupVersion == "" {
+		return "*/" + k.Kind
+	}
+	return k.GroupVersion + "/" + k.Kind
+}
+
+// Matches returns true if objects of the given GVK satisfy the reference
+func (k InventoryKind) Matches(gvk schema.GroupVersionKind) bool {
+	if k.Kind != gvk.Kind {
+		return false
+	}
+	return k.GroupVersion == "" || k.GroupVersion == gvk.GroupVersion().String()
+}
+
+var inventoryRef = ast.MustParseRef("data.inventory")
+
+// InventoryKinds returns the kinds a Rego module reads from data.inventory. Inventory is
+// laid out as data.inventory.cluster[groupVersion][kind][name] and
+// data.inventory.namespace[namespace][groupVersion][kind][name]. References whose kind is
+// not a constant cannot be resolved statically and are skipped.
+func InventoryKinds(modules ...string) ([]InventoryKind, error) {
+	found := make(map[InventoryKind]bool)
+	for _, src := range modules {
+		m, err := ast.ParseModule("", src)
+		if err != nil {
+			return nil, err
+		}
+		ast.WalkRefs(m, func(ref ast.Ref) bool {
+			if k, ok := inventoryKind(ref); ok {
+				found[k] = true
+			}
+			return false
+		})
+	}
+	var kinds []InventoryKind
+	for k := range found {
+		kinds = append(kinds, k)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		return kinds[i].String() < kinds[j].String()
+	})
+	return kinds, nil
+}
+
+func inventoryKind(ref ast.Ref) (InventoryKind, bool) {
+	if !ref.HasPrefix(inventoryRef) || len(ref) < 3 {
+		return InventoryKind{}, false
+	}
+	var gvIdx int
+	switch constant(ref[2]) {
+	case "cluster":
+		gvIdx = 3
+	case "namespace":
+		gvIdx = 4
+	default:
+		return InventoryKind{}, false
+	}
+	if len(ref) <= gvIdx+1 {
+		return InventoryKind{}, false
+	}
+	kind := constant(ref[gvIdx+1])
+	if kind == "" {
+		return InventoryKind{}, false
+	}
+	return InventoryKind{GroupVersion: constant(ref[gvIdx]), Kind: kind}, true
+}
+
+// constant returns the value of a string term, or "" if the term is not a string
+func constant(t *ast.Term) string {
+	s, ok := t.Value.(ast.String)
+	if !ok {
+		return ""
+	}
+	return string(s)
+}
@@ -0,0 +1,80 @@
+package regoutil
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestInventoryKinds(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Rego     string
+		Expected []InventoryKind
+	}{
+		{
+			Name: "No inventory",
+			Rego: `package foo
+
+violation[{"msg": "denied"}] {
+  input.review.object.metadata.name == "bad"
+}`,
+		},
+		{
+			Name: "Cluster and namespaced kinds",
+			Rego: `package foo
+
+violation[{"msg": "duplicate"}] {
+  other := data.inventory.namespace[ns]["networking.k8s.io/v1beta1"]["Ingress"][name]
+  ns2 := data.inventory.cluster["v1"].Namespace[_]
+  other.spec == input.review.object.spec
+}`,
+			Expected: []InventoryKind{
+				{GroupVersion: "networking.k8s.io/v1beta1", Kind: "Ingress"},
+				{GroupVersion: "v1", Kind: "Namespace"},
+			},
+		},
+		{
+			Name: "Any group version",
+			Rego: `package foo
+
+violation[{"msg": "duplicate"}] {
+  data.inventory.namespace[_][_]["Service"][_]
+}`,
+			Expected: []InventoryKind{{Kind: "Service"}},
+		},
+		{
+			Name: "Variable kind",
+			Rego: `package foo
+
+violation[{"msg": "duplicate"}] {
+  data.inventory.cluster["v1"][kind][_]
+}`,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			kinds, err := InventoryKinds(tt.Rego)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(kinds, tt.Expected) {
+				t.Errorf("InventoryKinds() = %v, wanted %v", kinds, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestInventoryKindMatches(t *testing.T) {
+	svc := schema.GroupVersionKind{Version: "v1", Kind: "Service"}
+	if !(InventoryKind{Kind: "Service"}).Matches(svc) {
+		t.Error("wildcard group version should match")
+	}
+	if !(InventoryKind{GroupVersion: "v1", Kind: "Service"}).Matches(svc) {
+		t.Error("exact group version should match")
+	}
+	if (InventoryKind{GroupVersion: "apps/v1", Kind: "Service"}).Matches(svc) {
+		t.Error("different group version should not match")
+	}
+}
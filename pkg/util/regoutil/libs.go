@@ -0,0 +1,92 @@
+package regoutil
+
+import (
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// gkLib holds helpers for reading replicated data, so template authors do not need to
+// know the layout of data.inventory. Namespaces must be replicated by the sync config;
+// otherwise every helper is undefined.
+const gkLib = `package lib.gk
+
+# namespace is the replicated Namespace named ns
+namespace(ns) = obj {
+  obj := data.inventory.cluster["v1"]["Namespace"][ns]
+}
+
+# namespace_labels is the labels of the replicated Namespace named ns, or {} if it has none
+namespace_labels(ns) = labels {
+  obj := namespace(ns)
+  labels := obj.metadata.labels
+}
+
+namespace_labels(ns) = {} {
+  obj := namespace(ns)
+  not obj.metadata.labels
+}
+
+# namespace_annotations is the annotations of the replicated Namespace named ns, or {} if
+# it has none
+namespace_annotations(ns) = annotations {
+  obj := namespace(ns)
+  annotations := obj.metadata.annotations
+}
+
+namespace_annotations(ns) = {} {
+  obj := namespace(ns)
+  not obj.metadata.annotations
+}
+
+# namespace_has_label is true if the replicated Namespace named ns has the label key
+namespace_has_label(ns, key) {
+  labels := namespace_labels(ns)
+  labels[key]
+}
+`
+
+// providedLibs are the libraries Gatekeeper adds to templates that import them, keyed by
+// package
+var providedLibs = map[string]string{
+	"data.lib.gk": gkLib,
+}
+
+// WithLibs returns a copy of libs plus each library Gatekeeper provides that rego or libs
+// refer to, unless libs already declare a module with the same package. Modules that fail
+// to parse are left for the framework to report.
+func WithLibs(rego string, libs []string) []string {
+	declared := make(map[string]bool)
+	referenced := make(map[string]bool)
+	for i, src := range append([]string{rego}, libs...) {
+		m, err := ast.ParseModule("", src)
+		if err != nil || m == nil {
+			continue
+		}
+		if i > 0 {
+			declared[m.Package.Path.String()] = true
+		}
+		ast.WalkRefs(m, func(ref ast.Ref) bool {
+			for pkg := range providedLibs {
+				if ref.HasPrefix(ast.MustParseRef(pkg)) {
+					referenced[pkg] = true
+				}
+			}
+			return false
+		})
+	}
+	out := append([]string(nil), libs...)
+	for pkg, src := range providedLibs {
+		if referenced[pkg] && !declared[pkg] {
+			out = append(out, src)
+		}
+	}
+	return out
+}
+
+// AddLibs adds the libraries Gatekeeper provides to each target of templ that refers to them
+func AddLibs(templ *templates.ConstraintTemplate) {
+	for i := range templ.Spec.Targets {
+		target := &templ.Spec.Targets[i]
+		target.Libs = WithLibs(target.Rego, target.Libs)
+	}
+}
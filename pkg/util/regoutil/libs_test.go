@@ -0,0 +1,160 @@
+package regoutil
+
+import (
+	"context"
+	"testing"
+
+	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/local"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const nsLabelRego = `package k8snamespacelabel
+
+import data.lib.gk
+
+violation[{"msg": msg}] {
+  ns := input.review.object.metadata.namespace
+  not gk.namespace_has_label(ns, input.parameters.label)
+  msg := sprintf("namespace %v must have label %v", [ns, input.parameters.label])
+}`
+
+func TestWithLibs(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Rego     string
+		Libs     []string
+		Expected int
+	}{
+		{
+			Name: "No reference",
+			Rego: `package foo
+
+violation[{"msg": "denied"}] {
+  true
+}`,
+		},
+		{
+			Name:     "Imported",
+			Rego:     nsLabelRego,
+			Expected: 1,
+		},
+		{
+			Name: "Referenced from a lib",
+			Rego: `package foo
+
+import data.lib.mine
+
+violation[{"msg": "denied"}] {
+  mine.bad
+}`,
+			Libs: []string{`package lib.mine
+
+bad {
+  labels := data.lib.gk.namespace_labels("default")
+  labels.bad
+}`},
+			Expected: 2,
+		},
+		{
+			Name: "Declared by the template",
+			Rego: nsLabelRego,
+			Libs: []string{`package lib.gk
+
+namespace_has_label(ns, key) {
+  true
+}`},
+			Expected: 1,
+		},
+		{
+			Name:     "Already added",
+			Rego:     nsLabelRego,
+			Libs:     []string{gkLib},
+			Expected: 1,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			if got := WithLibs(tt.Rego, tt.Libs); len(got) != tt.Expected {
+				t.Errorf("WithLibs() returned %d libs, want %d", len(got), tt.Expected)
+			}
+		})
+	}
+}
+
+func TestGkLib(t *testing.T) {
+	ctx := context.Background()
+	backend, err := opa.NewBackend(opa.Driver(local.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := backend.NewClient(opa.Targets(&target.K8sValidationTarget{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templ := &templates.ConstraintTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "k8snamespacelabel"},
+		Spec: templates.ConstraintTemplateSpec{
+			CRD:     templates.CRD{Spec: templates.CRDSpec{Names: templates.Names{Kind: "K8sNamespaceLabel"}}},
+			Targets: []templates.Target{{Target: "admission.k8s.gatekeeper.sh", Rego: nsLabelRego}},
+		},
+	}
+	AddLibs(templ)
+	if _, err := c.AddTemplate(ctx, templ); err != nil {
+		t.Fatalf("AddTemplate() error = %v", err)
+	}
+	constraint := &unstructured.Unstructured{}
+	constraint.SetAPIVersion("constraints.gatekeeper.sh/v1beta1")
+	constraint.SetKind("K8sNamespaceLabel")
+	constraint.SetName("owner")
+	if err := unstructured.SetNestedField(constraint.Object, "owner", "spec", "parameters", "label"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.AddConstraint(ctx, constraint); err != nil {
+		t.Fatalf("AddConstraint() error = %v", err)
+	}
+
+	for _, ns := range []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "labeled", Labels: map[string]string{"owner": "me"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "unlabeled"}},
+	} {
+		ns.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.AddData(ctx, &unstructured.Unstructured{Object: obj}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tc := []struct {
+		Namespace  string
+		Violations int
+	}{
+		{Namespace: "labeled"},
+		{Namespace: "unlabeled", Violations: 1},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Namespace, func(t *testing.T) {
+			pod := &unstructured.Unstructured{}
+			pod.SetAPIVersion("v1")
+			pod.SetKind("Pod")
+			pod.SetName("p")
+			pod.SetNamespace(tt.Namespace)
+			resp, err := c.Review(ctx, pod)
+			if err != nil {
+				t.Fatalf("Review() error = %v", err)
+			}
+			if got := len(resp.Results()); got != tt.Violations {
+				t.Errorf("got %d violations, want %d: %v", got, tt.Violations, resp.Results())
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package regoutil
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"sync"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/redact"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/topdown"
+	"github.com/open-policy-agent/opa/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// PrintBuiltin is the name of the builtin that logs a value while a template is evaluated.
+// The OPA version Gatekeeper is built with has no print() of its own.
+const PrintBuiltin = "print"
+
+// PrintLog logs the output of print() calls at debug level
+var PrintLog = logf.Log.WithName("rego-print")
+
+// printed receives the output of each print() call made by a query whose context has no
+// Prints. Output is logged at debug level.
+var printed = func(p Print) {
+	PrintLog.V(1).Info(p.Msg, "template_kind", p.Kind, "row", p.Row)
+}
+
+// TemplateModule matches the name the constraint framework gives a template's Rego. The
+// first submatch is the kind of the template's constraints.
+var TemplateModule = regexp.MustCompile(`templates\["[^"]*"\]\["([^"]+)"\]`)
+
+// Print is the output of a print() call, along with the kind of the template and the line
+// that made it
+type Print struct {
+	Kind string
+	Row  int
+	Msg  string
+}
+
+type printsKey struct{}
+
+// Prints collects the output of the print() calls of the queries evaluated with the
+// context it was added to, so their caller can say which constraint made them
+type Prints struct {
+	mux    sync.Mutex
+	prints []Print
+}
+
+// WithPrints returns ctx with Prints collecting the output of its queries' print() calls
+// in place of logging it
+func WithPrints(ctx context.Context) (context.Context, *Prints) {
+	p := &Prints{}
+	return context.WithValue(ctx, printsKey{}, p), p
+}
+
+// List returns the output collected so far
+func (p *Prints) List() []Print {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return append([]Print(nil), p.prints...)
+}
+
+func contextPrints(ctx context.Context) (*Prints, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	p, ok := ctx.Value(printsKey{}).(*Prints)
+	return p, ok
+}
+
+func (p *Prints) add(print Print) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.prints = append(p.prints, print)
+}
+
+func init() {
+	ast.RegisterBuiltin(&ast.Builtin{
+		Name: PrintBuiltin,
+		Decl: types.NewFunction(types.Args(types.A), types.B),
+	})
+	topdown.RegisterBuiltinFunc(PrintBuiltin, builtinPrint)
+}
+
+// builtinPrint logs its operand and is always true, so it can be added to any rule body.
+// Kubernetes objects in the operand are redacted.
+func builtinPrint(bctx topdown.BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	var p Print
+	if loc := bctx.Location; loc != nil {
+		p.Row = loc.Row
+		if m := TemplateModule.FindStringSubmatch(loc.File); m != nil {
+			p.Kind = m[1]
+		} else {
+			p.Kind = loc.File
+		}
+	}
+	p.Msg = printedValue(operands[0])
+	if prints, ok := contextPrints(bctx.Context); ok {
+		prints.add(p)
+	} else {
+		printed(p)
+	}
+	return iter(ast.BooleanTerm(true))
+}
+
+// printedValue returns a string operand as is, and any other operand as JSON, or as Rego
+// if it has no JSON form
+func printedValue(operand *ast.Term) string {
+	if s, ok := operand.Value.(ast.String); ok {
+		return string(s)
+	}
+	v, err := ast.JSON(operand.Value)
+	if err != nil {
+		return operand.String()
+	}
+	out, err := json.Marshal(redact.Tree(v))
+	if err != nil {
+		return operand.String()
+	}
+	return string(out)
+}
@@ -0,0 +1,102 @@
+package regoutil
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/local"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	"github.com/open-policy-agent/opa/ast"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const printRego = `package k8sprint
+
+violation[{"msg": msg}] {
+  print("checking")
+  print(input.parameters)
+  msg := "denied"
+}`
+
+func TestPrint(t *testing.T) {
+	var got []Print
+	old := printed
+	printed = func(p Print) {
+		got = append(got, p)
+	}
+	defer func() { printed = old }()
+
+	ctx := context.Background()
+	backend, err := opa.NewBackend(opa.Driver(local.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := backend.NewClient(opa.Targets(&target.K8sValidationTarget{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	templ := &templates.ConstraintTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "k8sprint"},
+		Spec: templates.ConstraintTemplateSpec{
+			CRD:     templates.CRD{Spec: templates.CRDSpec{Names: templates.Names{Kind: "K8sPrint"}}},
+			Targets: []templates.Target{{Target: "admission.k8s.gatekeeper.sh", Rego: printRego}},
+		},
+	}
+	if _, err := c.AddTemplate(ctx, templ); err != nil {
+		t.Fatalf("AddTemplate() error = %v", err)
+	}
+	constraint := &unstructured.Unstructured{}
+	constraint.SetAPIVersion("constraints.gatekeeper.sh/v1beta1")
+	constraint.SetKind("K8sPrint")
+	constraint.SetName("print")
+	if err := unstructured.SetNestedField(constraint.Object, "bar", "spec", "parameters", "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.AddConstraint(ctx, constraint); err != nil {
+		t.Fatalf("AddConstraint() error = %v", err)
+	}
+
+	ns := &unstructured.Unstructured{}
+	ns.SetAPIVersion("v1")
+	ns.SetKind("Namespace")
+	ns.SetName("foo")
+	resp, err := c.Review(ctx, ns)
+	if err != nil {
+		t.Fatalf("Review() error = %v", err)
+	}
+	if n := len(resp.Results()); n != 1 {
+		t.Errorf("got %d violations, want 1: print() must not stop evaluation", n)
+	}
+	want := []Print{{"K8sPrint", 4, "checking"}, {"K8sPrint", 5, `{"foo":"bar"}`}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("printed %v, want %v", got, want)
+	}
+
+	// a query whose context has Prints collects the output rather than logging it
+	got = nil
+	printsCtx, prints := WithPrints(ctx)
+	if _, err := c.Review(printsCtx, ns); err != nil {
+		t.Fatalf("Review() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("printed %v, want nothing logged", got)
+	}
+	if !reflect.DeepEqual(prints.List(), want) {
+		t.Errorf("collected %v, want %v", prints.List(), want)
+	}
+}
+
+func TestPrintedValue(t *testing.T) {
+	secret := ast.MustParseTerm(`{"apiVersion": "v1", "kind": "Secret", "data": {"password": "aHVudGVyMg=="}}`)
+	if got := printedValue(secret); strings.Contains(got, "aHVudGVyMg==") {
+		t.Errorf("printedValue() = %s, want the secret's data redacted", got)
+	}
+	if got := printedValue(ast.StringTerm("plain")); got != "plain" {
+		t.Errorf("printedValue() = %q, want %q", got, "plain")
+	}
+}
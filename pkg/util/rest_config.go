@@ -0,0 +1,27 @@
+package util
+
+import (
+	"math"
+
+	"k8s.io/client-go/rest"
+)
+
+// ClientConfig returns a copy of cfg that sends userAgent and, when qps is positive,
+// allows qps requests per second with bursts of burst. A burst of 0 defaults to qps
+// rounded up. An empty userAgent or a qps of 0 keeps the values of cfg.
+func ClientConfig(cfg *rest.Config, userAgent string, qps float64, burst int) *rest.Config {
+	c := rest.CopyConfig(cfg)
+	if userAgent != "" {
+		c.UserAgent = userAgent
+	}
+	if qps > 0 {
+		if burst <= 0 {
+			burst = int(math.Ceil(qps))
+		}
+		c.QPS = float32(qps)
+		c.Burst = burst
+		// a limiter copied from cfg would ignore the new limits
+		c.RateLimiter = nil
+	}
+	return c
+}
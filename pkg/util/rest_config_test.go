@@ -0,0 +1,50 @@
+package util
+
+import (
+	"testing"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+func TestClientConfig(t *testing.T) {
+	tc := []struct {
+		Name          string
+		UserAgent     string
+		QPS           float64
+		Burst         int
+		WantUserAgent string
+		WantQPS       float32
+		WantBurst     int
+		WantLimiter   bool
+	}{
+		{Name: "Defaults", WantUserAgent: "gatekeeper", WantQPS: 20, WantBurst: 30, WantLimiter: true},
+		{Name: "User agent", UserAgent: "gatekeeper-webhook", WantUserAgent: "gatekeeper-webhook", WantQPS: 20, WantBurst: 30, WantLimiter: true},
+		{Name: "QPS only", QPS: 2.5, WantUserAgent: "gatekeeper", WantQPS: 2.5, WantBurst: 3},
+		{Name: "QPS and burst", QPS: 2, Burst: 10, WantUserAgent: "gatekeeper", WantQPS: 2, WantBurst: 10},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			cfg := &rest.Config{
+				Host:        "https://example.com",
+				UserAgent:   "gatekeeper",
+				QPS:         20,
+				Burst:       30,
+				RateLimiter: flowcontrol.NewTokenBucketRateLimiter(20, 30),
+			}
+			c := ClientConfig(cfg, tt.UserAgent, tt.QPS, tt.Burst)
+			if c.UserAgent != tt.WantUserAgent {
+				t.Errorf("user agent = %q, wanted %q", c.UserAgent, tt.WantUserAgent)
+			}
+			if c.QPS != tt.WantQPS || c.Burst != tt.WantBurst {
+				t.Errorf("qps, burst = %v, %v, wanted %v, %v", c.QPS, c.Burst, tt.WantQPS, tt.WantBurst)
+			}
+			if (c.RateLimiter != nil) != tt.WantLimiter {
+				t.Errorf("rate limiter set: %v, wanted %v", c.RateLimiter != nil, tt.WantLimiter)
+			}
+			if cfg.UserAgent != "gatekeeper" || cfg.QPS != 20 || cfg.RateLimiter == nil {
+				t.Errorf("the original config was modified: %+v", cfg)
+			}
+		})
+	}
+}
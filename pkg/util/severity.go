@@ -0,0 +1,67 @@
+package util
+
+import (
+	"fmt"
+
+	configv1alpha1 "github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Severity is how serious a violation of a constraint is, set in spec.severity. It
+// selects the constraint's enforcement action unless spec.enforcementAction is set.
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityHigh     Severity = "high"
+	SeverityMedium   Severity = "medium"
+	SeverityLow      Severity = "low"
+)
+
+var supportedSeverities = []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow}
+
+// defaultSeverityActions apply to severities the Config does not map
+var defaultSeverityActions = map[Severity]EnforcementAction{
+	SeverityCritical: Deny,
+	SeverityHigh:     Deny,
+	SeverityMedium:   Dryrun,
+	SeverityLow:      Audit,
+}
+
+func ValidateSeverity(input Severity) error {
+	for _, s := range supportedSeverities {
+		if input == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("Could not find the provided severity value within the supported list %v", supportedSeverities)
+}
+
+// GetSeverity returns the severity of the constraint in item, or "" if it sets none
+func GetSeverity(item map[string]interface{}) (Severity, error) {
+	severity, _, err := unstructured.NestedString(item, "spec", "severity")
+	if err != nil {
+		return "", err
+	}
+	return Severity(severity), nil
+}
+
+// SeverityAction returns the enforcement action cfg maps severity to, falling back to
+// the default for the severity. It returns "" for an unsupported severity.
+func SeverityAction(severity Severity, cfg *configv1alpha1.Config) EnforcementAction {
+	var configured string
+	switch severity {
+	case SeverityCritical:
+		configured = cfg.Spec.SeverityActions.Critical
+	case SeverityHigh:
+		configured = cfg.Spec.SeverityActions.High
+	case SeverityMedium:
+		configured = cfg.Spec.SeverityActions.Medium
+	case SeverityLow:
+		configured = cfg.Spec.SeverityActions.Low
+	}
+	if action := EnforcementAction(configured); action != "" && ValidateEnforcementAction(action) == nil {
+		return action
+	}
+	return defaultSeverityActions[severity]
+}
@@ -0,0 +1,336 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package verify implements the `verify` subcommand, which runs policy test suites:
+// templates and constraints evaluated against sample objects with expected violations.
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/bench"
+	"github.com/open-policy-agent/gatekeeper/pkg/engine"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Command is the name of the subcommand
+const Command = "verify"
+
+// SuiteKind is the kind every suite file must declare
+const SuiteKind = "Suite"
+
+// Suite is a set of policy tests. Relative paths in a suite are relative to the suite
+// file.
+type Suite struct {
+	Kind  string `json:"kind"`
+	Tests []Test `json:"tests"`
+}
+
+// Test evaluates the constraints in Constraint, using the templates in Template, against
+// each case
+type Test struct {
+	Name       string `json:"name"`
+	Template   string `json:"template"`
+	Constraint string `json:"constraint"`
+	Cases      []Case `json:"cases"`
+}
+
+// Case reviews the objects in Object, as if each were being created, and checks the
+// violations against every assertion
+type Case struct {
+	Name       string      `json:"name"`
+	Object     string      `json:"object"`
+	Assertions []Assertion `json:"assertions"`
+}
+
+// Assertion is an expectation about the violations of a case
+type Assertion struct {
+	// Violations is "yes", "no" or the exact number of violations. Defaults to "yes"
+	Violations *Violations `json:"violations,omitempty"`
+	// Message is a regular expression. If set, only violations with a matching message
+	// are counted
+	Message string `json:"message,omitempty"`
+}
+
+// Violations is "yes", "no" or a count. It also accepts the booleans YAML reads from an
+// unquoted yes or no.
+type Violations struct {
+	intstr.IntOrString
+}
+
+func (v *Violations) UnmarshalJSON(b []byte) error {
+	var yes bool
+	if err := json.Unmarshal(b, &yes); err == nil {
+		if yes {
+			v.IntOrString = intstr.FromString("yes")
+		} else {
+			v.IntOrString = intstr.FromString("no")
+		}
+		return nil
+	}
+	return v.IntOrString.UnmarshalJSON(b)
+}
+
+// Result is the outcome of one case. Err is nil if every assertion held.
+type Result struct {
+	Test string
+	Case string
+	Err  error
+}
+
+// Run runs the suites named in args and writes the outcome of each case to out. It
+// returns an error if any case failed.
+func Run(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet(Command, flag.ContinueOnError)
+	fs.SetOutput(out)
+	fs.Usage = func() {
+		fmt.Fprintf(out, "Usage: %s SUITE...\n", Command)
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("at least one suite is required")
+	}
+	failed, total := 0, 0
+	for _, path := range fs.Args() {
+		results, err := RunSuite(context.Background(), path)
+		if err != nil {
+			return errors.Wrapf(err, "while running %s", path)
+		}
+		for _, r := range results {
+			total++
+			if r.Err != nil {
+				failed++
+				fmt.Fprintf(out, "FAIL  %s/%s: %v\n", r.Test, r.Case, r.Err)
+				continue
+			}
+			fmt.Fprintf(out, "ok    %s/%s\n", r.Test, r.Case)
+		}
+	}
+	fmt.Fprintf(out, "%d cases, %d failed\n", total, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d cases failed", failed, total)
+	}
+	return nil
+}
+
+// ReadSuite reads and validates the suite at path
+func ReadSuite(path string) (*Suite, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	suite := &Suite{}
+	if err := yaml.NewYAMLOrJSONDecoder(f, 4096).Decode(suite); err != nil {
+		return nil, errors.Wrapf(err, "while reading %s", path)
+	}
+	if suite.Kind != SuiteKind {
+		return nil, fmt.Errorf("%s is not a %s", path, SuiteKind)
+	}
+	for _, test := range suite.Tests {
+		for _, c := range test.Cases {
+			if len(c.Assertions) == 0 {
+				return nil, fmt.Errorf("case %s/%s has no assertions", test.Name, c.Name)
+			}
+			for _, a := range c.Assertions {
+				if _, err := a.compile(); err != nil {
+					return nil, errors.Wrapf(err, "in case %s/%s", test.Name, c.Name)
+				}
+			}
+		}
+	}
+	return suite, nil
+}
+
+// RunSuite runs every case of the suite at path. Errors loading a test's templates or
+// constraints are reported as the failure of each of its cases.
+func RunSuite(ctx context.Context, path string) ([]Result, error) {
+	suite, err := ReadSuite(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(path)
+	var results []Result
+	for _, test := range suite.Tests {
+		results = append(results, runTest(ctx, dir, test)...)
+	}
+	return results, nil
+}
+
+func runTest(ctx context.Context, dir string, test Test) []Result {
+	results := make([]Result, 0, len(test.Cases))
+	client, err := readClient(ctx, resolve(dir, test.Template), resolve(dir, test.Constraint))
+	for _, c := range test.Cases {
+		r := Result{Test: test.Name, Case: c.Name, Err: err}
+		if err == nil {
+			r.Err = runCase(ctx, client, dir, c)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// resolve returns path relative to the suite's directory, unless it is absolute
+func resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// readClient returns an OPA client loaded with the templates and constraints in the files
+func readClient(ctx context.Context, templatePath, constraintPath string) (*opa.Client, error) {
+	templs, err := bench.ReadObjects(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	constraints, err := bench.ReadObjects(constraintPath)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(ctx, templs, constraints)
+}
+
+// NewClient returns an OPA client loaded with templs and constraints
+func NewClient(ctx context.Context, templs, constraints []*unstructured.Unstructured) (*opa.Client, error) {
+	e, err := engine.New()
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range templs {
+		if err := e.LoadTemplate(ctx, u); err != nil {
+			return nil, err
+		}
+	}
+	for _, constraint := range constraints {
+		if err := e.LoadConstraint(ctx, constraint); err != nil {
+			return nil, err
+		}
+	}
+	return e.Client(), nil
+}
+
+func runCase(ctx context.Context, client *opa.Client, dir string, c Case) error {
+	objs, err := bench.ReadObjects(resolve(dir, c.Object))
+	if err != nil {
+		return err
+	}
+	return Evaluate(ctx, client, objs, c.Assertions)
+}
+
+// Evaluate reviews objs, as if each were being created, and returns an error unless every
+// assertion holds for their violations
+func Evaluate(ctx context.Context, client *opa.Client, objs []*unstructured.Unstructured, assertions []Assertion) error {
+	var results []*types.Result
+	for _, obj := range objs {
+		review, err := engine.ToReview(obj)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Review(ctx, review)
+		if err != nil {
+			return err
+		}
+		results = append(results, resp.Results()...)
+	}
+	for _, a := range assertions {
+		if err := a.check(results); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expectation is a validated Assertion
+type expectation struct {
+	message *regexp.Regexp
+	// count is the exact number of violations wanted, or -1 for at least one
+	count int
+}
+
+func (a Assertion) compile() (*expectation, error) {
+	e := &expectation{count: -1}
+	if a.Message != "" {
+		re, err := regexp.Compile(a.Message)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid message")
+		}
+		e.message = re
+	}
+	if a.Violations == nil {
+		return e, nil
+	}
+	switch {
+	case a.Violations.Type == intstr.Int && a.Violations.IntValue() >= 0:
+		e.count = a.Violations.IntValue()
+	case a.Violations.Type == intstr.String && a.Violations.StrVal == "yes":
+	case a.Violations.Type == intstr.String && a.Violations.StrVal == "no":
+		e.count = 0
+	default:
+		return nil, fmt.Errorf("invalid violations %q, must be yes, no or a count", a.Violations.String())
+	}
+	return e, nil
+}
+
+func (a Assertion) check(results []*types.Result) error {
+	e, err := a.compile()
+	if err != nil {
+		return err
+	}
+	got := 0
+	for _, r := range results {
+		if e.message == nil || e.message.MatchString(r.Msg) {
+			got++
+		}
+	}
+	matching := ""
+	if e.message != nil {
+		matching = fmt.Sprintf(" matching %q", a.Message)
+	}
+	switch {
+	case e.count < 0 && got == 0:
+		return fmt.Errorf("got no violations%s, wanted at least one%s", matching, describe(results))
+	case e.count >= 0 && got != e.count:
+		return fmt.Errorf("got %d violations%s, wanted %d%s", got, matching, e.count, describe(results))
+	}
+	return nil
+}
+
+// describe lists the messages of results, to show why an assertion failed
+func describe(results []*types.Result) string {
+	if len(results) == 0 {
+		return ""
+	}
+	s := "; violations:"
+	for _, r := range results {
+		s += fmt.Sprintf(" [%s %s] %s;", r.Constraint.GetKind(), r.Constraint.GetName(), r.Msg)
+	}
+	return s[:len(s)-1]
+}
@@ -0,0 +1,97 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/testutils"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestRunSuite(t *testing.T) {
+	results, err := RunSuite(context.Background(), "../../demo/basic/suite.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, wanted 2", len(results))
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Errorf("%s/%s failed: %v", r.Test, r.Case, r.Err)
+		}
+	}
+}
+
+func TestRunFailingSuite(t *testing.T) {
+	demo, err := filepath.Abs("../../demo/basic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	suite := `kind: Suite
+tests:
+- name: required-labels
+  template: ` + filepath.Join(demo, "templates/k8srequiredlabels_template.yaml") + `
+  constraint: ` + filepath.Join(demo, "constraints/all_ns_must_have_gatekeeper.yaml") + `
+  cases:
+  - name: unlabeled-namespace
+    object: ` + filepath.Join(demo, "bad/bad_ns.yaml") + `
+    assertions:
+    - violations: no
+`
+	path := filepath.Join(dir, "suite.yaml")
+	if err := ioutil.WriteFile(path, []byte(suite), 0600); err != nil {
+		t.Fatal(err)
+	}
+	out := &bytes.Buffer{}
+	if err := Run([]string{path}, out); err == nil {
+		t.Error("Run() should fail when a case fails")
+	}
+	if !strings.Contains(out.String(), "FAIL  required-labels/unlabeled-namespace: got 1 violations, wanted 0") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestAssertionCheck(t *testing.T) {
+	constraint := testutils.NewConstraint("K8sRequiredLabels", "must-have-owner")
+	results := []*types.Result{
+		{Msg: "missing owner", Constraint: constraint},
+		{Msg: "missing team", Constraint: constraint},
+	}
+	count := func(v intstr.IntOrString) *Violations { return &Violations{v} }
+	tc := []struct {
+		name      string
+		assertion Assertion
+		results   []*types.Result
+		wantErr   bool
+	}{
+		{name: "default is yes", results: results},
+		{name: "default is yes, none", wantErr: true},
+		{name: "yes", assertion: Assertion{Violations: count(intstr.FromString("yes"))}, results: results},
+		{name: "no", assertion: Assertion{Violations: count(intstr.FromString("no"))}, results: results, wantErr: true},
+		{name: "count", assertion: Assertion{Violations: count(intstr.FromInt(2))}, results: results},
+		{name: "wrong count", assertion: Assertion{Violations: count(intstr.FromInt(1))}, results: results, wantErr: true},
+		{name: "message", assertion: Assertion{Violations: count(intstr.FromInt(1)), Message: "owner$"}, results: results},
+		{name: "unmatched message", assertion: Assertion{Message: "labels"}, results: results, wantErr: true},
+		{name: "invalid", assertion: Assertion{Violations: count(intstr.FromString("maybe"))}, results: results, wantErr: true},
+	}
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.assertion.check(tt.results)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
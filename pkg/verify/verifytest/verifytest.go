@@ -0,0 +1,47 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package verifytest runs policy test suites from `go test`:
+//
+//	func TestPolicies(t *testing.T) {
+//		verifytest.Run(t, "policies/suite.yaml")
+//	}
+package verifytest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/verify"
+)
+
+// Run runs each case of the suites at paths as a subtest of t, named test/case
+func Run(t *testing.T, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		results, err := verify.RunSuite(context.Background(), path)
+		if err != nil {
+			t.Fatalf("could not run %s: %v", path, err)
+		}
+		for _, r := range results {
+			r := r
+			t.Run(r.Test+"/"+r.Case, func(t *testing.T) {
+				if r.Err != nil {
+					t.Error(r.Err)
+				}
+			})
+		}
+	}
+}
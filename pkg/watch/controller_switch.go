@@ -9,7 +9,9 @@ type ControllerSwitch struct {
 	runningLock sync.RWMutex
 }
 
-func newSwitch() *ControllerSwitch {
+// NewSwitch returns a ControllerSwitch in the running state. The watch manager
+// creates one per sub-manager; tests may create their own.
+func NewSwitch() *ControllerSwitch {
 	return &ControllerSwitch{running: true}
 }
 
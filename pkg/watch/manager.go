@@ -34,6 +34,9 @@ type Manager struct {
 	managedKinds *recordKeeper
 	// watchedKinds are the kinds that have a currently running constraint controller
 	watchedKinds map[schema.GroupVersionKind]vitals
+	// goneKinds are the watched kinds the API server stopped serving, e.g. because their
+	// CRD was deleted
+	goneKinds    map[schema.GroupVersionKind]bool
 	cfg          *rest.Config
 	newDiscovery func(*rest.Config) (Discovery, error)
 	metrics      *reporter
@@ -60,6 +63,7 @@ func New(cfg *rest.Config) (*Manager, error) {
 		stopper:      func() {},
 		managedKinds: newRecordKeeper(),
 		watchedKinds: make(map[schema.GroupVersionKind]vitals),
+		goneKinds:    make(map[schema.GroupVersionKind]bool),
 		cfg:          cfg,
 		newDiscovery: newDiscovery,
 		metrics:      metrics,
@@ -98,7 +102,14 @@ func (wm *Manager) updateManager() (bool, error) {
 		return false, errp.Wrap(err, "error gathering watch changes, not restarting watch manager")
 	}
 	started := wm.started.Load().(bool)
-	if started && len(added) == 0 && len(removed) == 0 && len(changed) == 0 {
+	var recreated map[schema.GroupVersionKind]vitals
+	if started {
+		recreated, err = wm.recreatedKinds()
+		if err != nil {
+			return false, errp.Wrap(err, "error checking for recreated kinds, not restarting watch manager")
+		}
+	}
+	if started && len(added) == 0 && len(removed) == 0 && len(changed) == 0 && len(recreated) == 0 {
 		return false, nil
 	}
 	var a, r, c []string
@@ -111,14 +122,18 @@ func (wm *Manager) updateManager() (bool, error) {
 	for k := range changed {
 		a = append(c, k.String())
 	}
-	log.Info("Watcher registry found changes and/or needs restarting", "started", started, "add", a, "remove", r, "change", c)
+	var rc []string
+	for k := range recreated {
+		rc = append(rc, k.String())
+	}
+	log.Info("Watcher registry found changes and/or needs restarting", "started", started, "add", a, "remove", r, "change", c, "recreated", rc)
 
 	readyToAdd, err := wm.filterPendingResources(added)
 	if err != nil {
 		return false, errp.Wrap(err, "could not filter pending resources, not restarting watch manager")
 	}
 
-	if started && len(readyToAdd) == 0 && len(removed) == 0 && len(changed) == 0 {
+	if started && len(readyToAdd) == 0 && len(removed) == 0 && len(changed) == 0 && len(recreated) == 0 {
 		log.Info("Only changes are pending additions; not restarting watch manager")
 		return false, nil
 	}
@@ -241,7 +256,7 @@ func (wm *Manager) restartManager(kinds map[schema.GroupVersionKind]vitals) erro
 		return err
 	}
 
-	sw := newSwitch()
+	sw := NewSwitch()
 	for gvk, v := range kinds {
 		for _, fn := range v.addFns() {
 			if err := fn(mgr, gvk, sw); err != nil {
@@ -313,6 +328,38 @@ func (wm *Manager) gatherChanges(managedKinds map[schema.GroupVersionKind]vitals
 	return added, removed, changed, nil
 }
 
+// recreatedKinds returns the watched kinds that the API server stopped serving and serves
+// again, e.g. because a template was reapplied and its CRD recreated. The informers of
+// such kinds no longer receive events, so the manager is restarted to re-establish the
+// watches and replay the objects created in the meantime. A kind deleted and recreated
+// between two checks is not noticed.
+func (wm *Manager) recreatedKinds() (map[schema.GroupVersionKind]vitals, error) {
+	served, err := wm.filterPendingResources(wm.watchedKinds)
+	if err != nil {
+		return nil, err
+	}
+	recreated := make(map[schema.GroupVersionKind]vitals)
+	for gvk, vitals := range wm.watchedKinds {
+		if _, ok := served[gvk]; !ok {
+			if !wm.goneKinds[gvk] {
+				log.Info("watched kind is no longer served, waiting for it to return", "kind", gvk.String())
+			}
+			wm.goneKinds[gvk] = true
+			continue
+		}
+		if wm.goneKinds[gvk] {
+			delete(wm.goneKinds, gvk)
+			recreated[gvk] = vitals
+		}
+	}
+	for gvk := range wm.goneKinds {
+		if _, ok := wm.watchedKinds[gvk]; !ok {
+			delete(wm.goneKinds, gvk)
+		}
+	}
+	return recreated, nil
+}
+
 func (wm *Manager) filterPendingResources(kinds map[schema.GroupVersionKind]vitals) (map[schema.GroupVersionKind]vitals, error) {
 	gvs := make(map[schema.GroupVersion]bool)
 	for gvk := range kinds {
@@ -29,6 +29,7 @@ func newForTest(fn func(*rest.Config) (Discovery, error)) (*Manager, error) {
 		stopper:      func() {},
 		managedKinds: newRecordKeeper(),
 		watchedKinds: make(map[schema.GroupVersionKind]vitals),
+		goneKinds:    make(map[schema.GroupVersionKind]bool),
 		cfg:          nil,
 		newDiscovery: fn,
 		metrics:      metrics,
@@ -452,3 +453,62 @@ func TestRegistrar(t *testing.T) {
 		}
 	})
 }
+
+func TestRecreatedKind(t *testing.T) {
+	wm, err := newForTest(newDiscoveryFactory(false, "FooCRD"))
+	if err != nil {
+		t.Fatalf("Error creating Manager: %s", err)
+	}
+	defer wm.close()
+	reg, err := wm.NewRegistrar("foo", nil)
+	if err != nil {
+		t.Fatalf("Error setting up registrar: %s", err)
+	}
+	if err := reg.AddWatch(makeGvk("FooCRD")); err != nil {
+		t.Fatalf("Error adding watch: %s", err)
+	}
+	if _, err := wm.updateManager(); err != nil {
+		t.Fatalf("Could not update manager: %s", err)
+	}
+	if waitForWatchManagerStart(wm) == false {
+		t.Fatalf("Watch manager was not set to started")
+	}
+
+	wm.newDiscovery = newDiscoveryFactory(false)
+	b, err := wm.updateManager()
+	if err != nil {
+		t.Errorf("Could not update manager: %s", err)
+	}
+	if b == true {
+		t.Errorf("Manager restarted while the kind is not served, wanted no op")
+	}
+	if !wm.goneKinds[makeGvk("FooCRD")] {
+		t.Errorf("FooCRD not recorded as gone: %v", wm.goneKinds)
+	}
+
+	wm.newDiscovery = newDiscoveryFactory(false, "FooCRD")
+	b, err = wm.updateManager()
+	if err != nil {
+		t.Errorf("Could not update manager: %s", err)
+	}
+	if b == false {
+		t.Errorf("Manager not restarted when the kind was served again")
+	}
+	if _, ok := wm.watchedKinds[makeGvk("FooCRD")]; !ok {
+		t.Errorf("FooCRD not watched after restart: %v", wm.watchedKinds)
+	}
+	if len(wm.goneKinds) != 0 {
+		t.Errorf("goneKinds = %v, wanted empty", wm.goneKinds)
+	}
+	if waitForWatchManagerStart(wm) == false {
+		t.Fatalf("Watch manager was not set to started")
+	}
+
+	b, err = wm.updateManager()
+	if err != nil {
+		t.Errorf("Could not update manager: %s", err)
+	}
+	if b == true {
+		t.Errorf("Manager restarted again, wanted no op")
+	}
+}
@@ -0,0 +1,216 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+const (
+	// CleanupCommand is the subcommand a pre-delete hook runs to remove the webhook
+	CleanupCommand = "cleanup"
+
+	// CleanupDelete deletes the ValidatingWebhookConfiguration
+	CleanupDelete = "delete"
+	// CleanupIgnore sets the failurePolicy of every webhook to Ignore
+	CleanupIgnore = "ignore"
+)
+
+var cleanupOnShutdown = flag.String("cleanup-on-shutdown", "", "what to do with the ValidatingWebhookConfiguration when the pod stops because the Deployment that runs it is being removed, so an uninstall cannot leave the API server calling a webhook that is gone: delete, ignore (set failurePolicy to Ignore) or empty to leave it alone")
+
+func validCleanupMode(mode string) error {
+	switch mode {
+	case CleanupDelete, CleanupIgnore:
+		return nil
+	default:
+		return fmt.Errorf("invalid cleanup mode %q, must be %s or %s", mode, CleanupDelete, CleanupIgnore)
+	}
+}
+
+// Cleanup deletes the ValidatingWebhookConfiguration, or sets the failurePolicy of each
+// of its webhooks to Ignore, depending on mode. A missing configuration is not an error.
+func Cleanup(ctx context.Context, c client.Client, mode string) error {
+	if err := validCleanupMode(mode); err != nil {
+		return err
+	}
+	vwh := &unstructured.Unstructured{}
+	vwh.SetGroupVersionKind(vwhGVK)
+	if err := c.Get(ctx, vwhKey, vwh); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if mode == CleanupDelete {
+		log.Info("deleting the ValidatingWebhookConfiguration", "name", vwhKey.Name)
+		if err := c.Delete(ctx, vwh); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+	webhooks, _, err := unstructured.NestedSlice(vwh.Object, "webhooks")
+	if err != nil {
+		return err
+	}
+	for i, h := range webhooks {
+		hook, ok := h.(map[string]interface{})
+		if !ok {
+			return errors.Errorf("webhook %d is not well-formed", i)
+		}
+		hook["failurePolicy"] = "Ignore"
+		webhooks[i] = hook
+	}
+	if err := unstructured.SetNestedSlice(vwh.Object, webhooks, "webhooks"); err != nil {
+		return err
+	}
+	log.Info("setting the failurePolicy of the ValidatingWebhookConfiguration to Ignore", "name", vwhKey.Name)
+	return c.Update(ctx, vwh)
+}
+
+// CleanupOnShutdown runs Cleanup with the mode set by --cleanup-on-shutdown if the
+// Deployment that runs the pod is gone or being deleted. A pod that stops for any other
+// reason, such as a rollout, leaves the webhook alone.
+func CleanupOnShutdown(c client.Client) error {
+	if *cleanupOnShutdown == "" {
+		return nil
+	}
+	if err := validCleanupMode(*cleanupOnShutdown); err != nil {
+		return errors.Wrap(err, "--cleanup-on-shutdown")
+	}
+	ctx := context.Background()
+	removed, err := deploymentRemoved(ctx, c)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return nil
+	}
+	return Cleanup(ctx, c, *cleanupOnShutdown)
+}
+
+// deploymentRemoved returns whether the Deployment that runs the pod is gone or being
+// deleted. A pod that cannot be traced to a Deployment leaves the webhook alone.
+func deploymentRemoved(ctx context.Context, c client.Client) (bool, error) {
+	name, err := owningDeployment(ctx, c)
+	if err != nil {
+		return false, err
+	}
+	if name == "" {
+		log.Info("pod is not run by a Deployment, leaving the webhook alone", "pod", util.GetID())
+		return false, nil
+	}
+	deploy := &appsv1.Deployment{}
+	err = c.Get(ctx, types.NamespacedName{Namespace: util.GetNamespace(), Name: name}, deploy)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !deploy.GetDeletionTimestamp().IsZero(), nil
+}
+
+// owningDeployment returns the name of the Deployment that runs the pod, following the
+// ownerReferences of the pod and of its ReplicaSet, or "" if a Deployment does not run it.
+// Each Deployment of an install split by role thus cleans up only when it is removed
+// itself.
+func owningDeployment(ctx context.Context, c client.Client) (string, error) {
+	ns := util.GetNamespace()
+	pod := &corev1.Pod{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: ns, Name: util.GetID()}, pod); err != nil {
+		return "", err
+	}
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil || owner.Kind != "ReplicaSet" {
+		return "", nil
+	}
+	rs := &appsv1.ReplicaSet{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: ns, Name: owner.Name}, rs)
+	if apierrors.IsNotFound(err) {
+		// removing a Deployment deletes its ReplicaSets before their pods. A ReplicaSet
+		// is named after its Deployment and the hash of its pod template.
+		hash := pod.GetLabels()[appsv1.DefaultDeploymentUniqueLabelKey]
+		if hash == "" || !strings.HasSuffix(owner.Name, "-"+hash) {
+			return "", nil
+		}
+		return strings.TrimSuffix(owner.Name, "-"+hash), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	owner = metav1.GetControllerOf(rs)
+	if owner == nil || owner.Kind != "Deployment" {
+		return "", nil
+	}
+	return owner.Name, nil
+}
+
+// RunCleanup parses args and cleans up the webhook, for use by a pre-delete hook
+func RunCleanup(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet(CleanupCommand, flag.ContinueOnError)
+	fs.SetOutput(out)
+	mode := fs.String("mode", CleanupDelete, "delete the ValidatingWebhookConfiguration, or ignore to set its failurePolicy to Ignore")
+	kubeconfig := fs.String("kubeconfig", "", "path to a kubeconfig. Defaults to $KUBECONFIG, the in-cluster config or ~/.kube/config")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := validCleanupMode(*mode); err != nil {
+		fs.Usage()
+		return err
+	}
+	var cfg *rest.Config
+	var err error
+	if *kubeconfig != "" {
+		cfg, err = clientcmd.BuildConfigFromFlags("", *kubeconfig)
+	} else {
+		cfg, err = config.GetConfig()
+	}
+	if err != nil {
+		return err
+	}
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return err
+	}
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+	if err := Cleanup(context.Background(), c, *mode); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "cleaned up %s (%s)\n", vwhKey.Name, *mode)
+	return nil
+}
@@ -0,0 +1,135 @@
+package webhook
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newCleanupClient(t *testing.T, objs ...runtime.Object) client.Client {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	fail := admissionregistrationv1beta1.Fail
+	vwh := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: vwhKey.Name},
+		Webhooks: []admissionregistrationv1beta1.ValidatingWebhook{
+			{Name: "validation.gatekeeper.sh", FailurePolicy: &fail},
+			{Name: "check-ignore-label.gatekeeper.sh", FailurePolicy: &fail},
+		},
+	}
+	return fake.NewFakeClientWithScheme(scheme, append(objs, vwh)...)
+}
+
+func TestCleanup(t *testing.T) {
+	t.Run("delete", func(t *testing.T) {
+		c := newCleanupClient(t)
+		if err := Cleanup(context.TODO(), c, CleanupDelete); err != nil {
+			t.Fatalf("Cleanup() error = %v", err)
+		}
+		err := c.Get(context.TODO(), vwhKey, &admissionregistrationv1beta1.ValidatingWebhookConfiguration{})
+		if !apierrors.IsNotFound(err) {
+			t.Errorf("webhook configuration not deleted, Get() error = %v", err)
+		}
+		// a second run finds nothing to clean up
+		if err := Cleanup(context.TODO(), c, CleanupDelete); err != nil {
+			t.Errorf("Cleanup() of a missing configuration error = %v", err)
+		}
+	})
+
+	t.Run("ignore", func(t *testing.T) {
+		c := newCleanupClient(t)
+		if err := Cleanup(context.TODO(), c, CleanupIgnore); err != nil {
+			t.Fatalf("Cleanup() error = %v", err)
+		}
+		got := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{}
+		if err := c.Get(context.TODO(), vwhKey, got); err != nil {
+			t.Fatal(err)
+		}
+		for _, wh := range got.Webhooks {
+			if wh.FailurePolicy == nil || *wh.FailurePolicy != admissionregistrationv1beta1.Ignore {
+				t.Errorf("webhook %s failurePolicy = %v, want Ignore", wh.Name, wh.FailurePolicy)
+			}
+		}
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		if err := Cleanup(context.TODO(), newCleanupClient(t), "explode"); err == nil {
+			t.Error("Cleanup() with an invalid mode returned no error")
+		}
+	})
+}
+
+func TestCleanupOnShutdown(t *testing.T) {
+	old := *cleanupOnShutdown
+	*cleanupOnShutdown = CleanupDelete
+	defer func() { *cleanupOnShutdown = old }()
+	defer func(name string) { os.Setenv("POD_NAME", name) }(os.Getenv("POD_NAME"))
+	if err := os.Setenv("POD_NAME", "gatekeeper-audit-5d8f7-abcde"); err != nil {
+		t.Fatal(err)
+	}
+
+	isController := true
+	ownedBy := func(kind, name string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: kind, Name: name, Controller: &isController}}
+	}
+	pod := func(owners []metav1.OwnerReference) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "gatekeeper-system",
+			Name:            "gatekeeper-audit-5d8f7-abcde",
+			Labels:          map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "5d8f7"},
+			OwnerReferences: owners,
+		}}
+	}
+	rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Namespace:       "gatekeeper-system",
+		Name:            "gatekeeper-audit-5d8f7",
+		OwnerReferences: ownedBy("Deployment", "gatekeeper-audit"),
+	}}
+	deploy := func(name string, deleting bool) *appsv1.Deployment {
+		d := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "gatekeeper-system", Name: name}}
+		if deleting {
+			now := metav1.NewTime(time.Now())
+			d.DeletionTimestamp = &now
+		}
+		return d
+	}
+	owned := pod(ownedBy("ReplicaSet", "gatekeeper-audit-5d8f7"))
+	tc := []struct {
+		Name    string
+		Objs    []runtime.Object
+		Removed bool
+	}{
+		{Name: "Deployment running", Objs: []runtime.Object{owned, rs, deploy("gatekeeper-audit", false)}, Removed: false},
+		{Name: "Deployment being deleted", Objs: []runtime.Object{owned, rs, deploy("gatekeeper-audit", true)}, Removed: true},
+		{Name: "Deployment gone", Objs: []runtime.Object{owned, rs}, Removed: true},
+		{Name: "Deployment and ReplicaSet gone", Objs: []runtime.Object{owned}, Removed: true},
+		{Name: "ReplicaSet gone in a rollout", Objs: []runtime.Object{owned, deploy("gatekeeper-audit", false)}, Removed: false},
+		{Name: "another Deployment gone", Objs: []runtime.Object{owned, rs, deploy("gatekeeper-audit", false), deploy("gatekeeper-controller-manager", true)}, Removed: false},
+		{Name: "pod without a Deployment", Objs: []runtime.Object{pod(nil)}, Removed: false},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			c := newCleanupClient(t, tt.Objs...)
+			if err := CleanupOnShutdown(c); err != nil {
+				t.Fatalf("CleanupOnShutdown() error = %v", err)
+			}
+			err := c.Get(context.TODO(), vwhKey, &admissionregistrationv1beta1.ValidatingWebhookConfiguration{})
+			if got := apierrors.IsNotFound(err); got != tt.Removed {
+				t.Errorf("webhook configuration removed = %v, want %v (err = %v)", got, tt.Removed, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	"github.com/pkg/errors"
+	"go.opencensus.io/metric/metricdata"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// DecisionIDAnnotation is the audit annotation holding the ID of an admission decision
+	DecisionIDAnnotation = "gatekeeper.sh/decision-id"
+	// DeniedByAnnotation is the audit annotation listing the constraints that denied a
+	// request, as comma-separated kind/name pairs
+	DeniedByAnnotation = "gatekeeper.sh/denied-by"
+	// WarnedByAnnotation is the audit annotation listing the dryrun constraints a request
+	// violated, as comma-separated kind/name pairs
+	WarnedByAnnotation = "gatekeeper.sh/warned-by"
+	// DeprecatedVersionAnnotation is the audit annotation warning that a constraint was
+	// written through a deprecated version
+	DeprecatedVersionAnnotation = "gatekeeper.sh/deprecated-version"
+)
+
+var decisionLogPath = flag.String("decision-log-path", "", "append a JSON record of every admission decision of the constraint webhook to this file. Decisions are not recorded if empty")
+
+// Decision records how the constraint webhook answered an admission request
+type Decision struct {
+	ID         string                  `json:"id"`
+	Time       time.Time               `json:"time"`
+	RequestUID string                  `json:"requestUID"`
+	Operation  string                  `json:"operation"`
+	Kind       metav1.GroupVersionKind `json:"kind"`
+	Namespace  string                  `json:"namespace,omitempty"`
+	Name       string                  `json:"name,omitempty"`
+	User       string                  `json:"user"`
+	DryRun     bool                    `json:"dryRun,omitempty"`
+	// InputHash is the SHA-256 of the reviewed object, so a replayed object can be
+	// matched to the decision
+	InputHash   string               `json:"inputHash,omitempty"`
+	Outcome     requestResponse      `json:"outcome"`
+	Message     string               `json:"message,omitempty"`
+	Constraints []DecisionConstraint `json:"constraints,omitempty"`
+	// Traced is set if the OPA trace of the review was logged, with the decision ID
+	Traced bool `json:"traced,omitempty"`
+}
+
+// DecisionConstraint is a violated constraint of a Decision
+type DecisionConstraint struct {
+	Kind              string `json:"kind"`
+	Name              string `json:"name"`
+	EnforcementAction string `json:"enforcementAction"`
+	Message           string `json:"message"`
+}
+
+// DecisionSink persists decisions
+type DecisionSink interface {
+	Record(d *Decision) error
+}
+
+// jsonSink writes each decision to w as a line of JSON
+type jsonSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *jsonSink) Record(d *Decision) error {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
+
+// newDecisionSink returns the sink set by --decision-log-path, or nil if there is none
+func newDecisionSink() (DecisionSink, error) {
+	if *decisionLogPath == "" {
+		return nil, nil
+	}
+	f, err := os.OpenFile(*decisionLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "while opening the decision log")
+	}
+	return &jsonSink{w: f}, nil
+}
+
+// newDecision starts the decision on req, assigning it a new ID
+func newDecision(req admission.Request) *Decision {
+	d := &Decision{
+		ID:         string(uuid.NewUUID()),
+		Time:       time.Now(),
+		RequestUID: string(req.AdmissionRequest.UID),
+		Operation:  string(req.AdmissionRequest.Operation),
+		Kind:       req.AdmissionRequest.Kind,
+		Namespace:  req.AdmissionRequest.Namespace,
+		Name:       req.AdmissionRequest.Name,
+		User:       req.AdmissionRequest.UserInfo.Username,
+		DryRun:     isDryRun(req),
+	}
+	raw := req.AdmissionRequest.Object.Raw
+	if raw == nil {
+		raw = req.AdmissionRequest.OldObject.Raw
+	}
+	if raw != nil {
+		sum := sha256.Sum256(raw)
+		d.InputHash = hex.EncodeToString(sum[:])
+	}
+	return d
+}
+
+// exemplar attaches the decision to the latency sample of its request, so a slow request
+// can be matched to its trace
+func (d *Decision) exemplar() metricdata.Attachments {
+	constraints := make([]string, 0, len(d.Constraints))
+	for _, c := range d.Constraints {
+		constraints = append(constraints, c.Kind+"/"+c.Name)
+	}
+	return metricdata.Attachments{
+		"decision_id": d.ID,
+		"constraints": strings.Join(constraints, ","),
+	}
+}
+
+// addResults records the violations in res
+func (d *Decision) addResults(res []*rtypes.Result) {
+	for _, r := range res {
+		d.Constraints = append(d.Constraints, DecisionConstraint{
+			Kind:              r.Constraint.GetKind(),
+			Name:              r.Constraint.GetName(),
+			EnforcementAction: r.EnforcementAction,
+			Message:           r.Msg,
+		})
+	}
+}
+
+// finish records the outcome of resp and adds the decision ID and the constraints that
+// affected the request to its audit annotations
+func (d *Decision) finish(resp *admission.Response) {
+	switch {
+	case resp.Allowed && resp.AuditAnnotations[FailedOpenAnnotation] != "":
+		d.Outcome = failOpenResponse
+		d.Message = resp.AuditAnnotations[FailedOpenAnnotation]
+	case resp.Allowed:
+		d.Outcome = allowResponse
+	case resp.Result != nil && resp.Result.Code == http.StatusForbidden:
+		d.Outcome = denyResponse
+	default:
+		d.Outcome = errorResponse
+	}
+	if resp.Result != nil && d.Message == "" {
+		d.Message = string(resp.Result.Reason)
+		if d.Message == "" {
+			d.Message = resp.Result.Message
+		}
+	}
+	if resp.AuditAnnotations == nil {
+		resp.AuditAnnotations = make(map[string]string)
+	}
+	resp.AuditAnnotations[DecisionIDAnnotation] = d.ID
+	if deniedBy := d.constraintsWith(util.Deny); deniedBy != "" {
+		resp.AuditAnnotations[DeniedByAnnotation] = deniedBy
+	}
+	if warnedBy := d.constraintsWith(util.Dryrun); warnedBy != "" {
+		resp.AuditAnnotations[WarnedByAnnotation] = warnedBy
+	}
+}
+
+// constraintsWith lists the violated constraints with action as sorted, comma-separated
+// kind/name pairs
+func (d *Decision) constraintsWith(action util.EnforcementAction) string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, c := range d.Constraints {
+		name := c.Kind + "/" + c.Name
+		if c.EnforcementAction != string(action) || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,146 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"go.opencensus.io/stats/view"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// resetViews drops the metrics recorded by a test that ran the handler
+func resetViews(t *testing.T) {
+	for _, name := range []string{requestCountMetricName, requestDurationMetricName, violationsMetricName, failOpenMetricName, templateDurationMetricName} {
+		if v := view.Find(name); v != nil {
+			view.Unregister(v)
+		}
+	}
+	if err := register(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecisions(t *testing.T) {
+	defer resetViews(t)
+	opa, err := makeOpaClient()
+	if err != nil {
+		t.Fatalf("Could not initialize OPA: %s", err)
+	}
+	cstr := &templv1beta1.ConstraintTemplate{}
+	if err := yaml.Unmarshal([]byte(goodRegoTemplate), cstr); err != nil {
+		t.Fatal(err)
+	}
+	unversioned := &templates.ConstraintTemplate{}
+	if err := runtimeScheme.Convert(cstr, unversioned, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := opa.AddTemplate(context.Background(), unversioned); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := opa.AddConstraint(context.Background(), newConstraint("K8sGoodRego", "always", "deny", t)); err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	handler := validationHandler{opa: opa, injectedConfig: &v1alpha1.Config{}, decisions: &jsonSink{w: out}}
+	req := atypes.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			UID:       "request-1",
+			Operation: admissionv1beta1.Create,
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+			Name:      "foo",
+			Object:    runtime.RawExtension{Raw: []byte(`{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "foo"}}`)},
+		},
+	}
+	resp := handler.Handle(context.Background(), req)
+	if resp.Allowed {
+		t.Fatal("request allowed, wanted a deny")
+	}
+	id := resp.AuditAnnotations[DecisionIDAnnotation]
+	if id == "" {
+		t.Fatalf("response audit annotations = %v, wanted %s", resp.AuditAnnotations, DecisionIDAnnotation)
+	}
+
+	d := &Decision{}
+	if err := json.Unmarshal(out.Bytes(), d); err != nil {
+		t.Fatalf("decision log %q: %v", out.String(), err)
+	}
+	if d.ID != id {
+		t.Errorf("recorded decision ID = %s, wanted %s", d.ID, id)
+	}
+	if d.RequestUID != "request-1" || d.Outcome != denyResponse || d.InputHash == "" {
+		t.Errorf("recorded decision = %+v, wanted a deny of request-1 with an input hash", d)
+	}
+	if len(d.Constraints) != 1 || d.Constraints[0].Name != "always" {
+		t.Errorf("recorded constraints = %+v, wanted always", d.Constraints)
+	}
+
+	// every decision gets its own ID, even for the same request
+	if next := handler.Handle(context.Background(), req); next.AuditAnnotations[DecisionIDAnnotation] == id {
+		t.Errorf("decision ID %s reused", id)
+	}
+}
+
+func TestDecisionAuditAnnotations(t *testing.T) {
+	tc := []struct {
+		Name        string
+		Constraints []DecisionConstraint
+		Allowed     bool
+		DeniedBy    string
+		WarnedBy    string
+	}{
+		{
+			Name:    "No violations",
+			Allowed: true,
+		},
+		{
+			Name: "Denied and warned",
+			Constraints: []DecisionConstraint{
+				{Kind: "K8sRequiredLabels", Name: "b", EnforcementAction: "deny", Message: "one"},
+				{Kind: "K8sRequiredLabels", Name: "b", EnforcementAction: "deny", Message: "two"},
+				{Kind: "K8sAllowedRepos", Name: "a", EnforcementAction: "deny"},
+				{Kind: "K8sRequiredLabels", Name: "c", EnforcementAction: "dryrun"},
+				{Kind: "K8sRequiredLabels", Name: "d", EnforcementAction: "audit"},
+			},
+			DeniedBy: "K8sAllowedRepos/a,K8sRequiredLabels/b",
+			WarnedBy: "K8sRequiredLabels/c",
+		},
+		{
+			Name:        "Only warned",
+			Constraints: []DecisionConstraint{{Kind: "K8sRequiredLabels", Name: "c", EnforcementAction: "dryrun"}},
+			Allowed:     true,
+			WarnedBy:    "K8sRequiredLabels/c",
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			d := &Decision{ID: "id", Constraints: tt.Constraints}
+			resp := atypes.ValidationResponse(tt.Allowed, "")
+			d.finish(&resp)
+			want := map[string]string{DecisionIDAnnotation: "id"}
+			if tt.DeniedBy != "" {
+				want[DeniedByAnnotation] = tt.DeniedBy
+			}
+			if tt.WarnedBy != "" {
+				want[WarnedByAnnotation] = tt.WarnedBy
+			}
+			if len(resp.AuditAnnotations) != len(want) {
+				t.Fatalf("audit annotations = %v, wanted %v", resp.AuditAnnotations, want)
+			}
+			for k, v := range want {
+				if resp.AuditAnnotations[k] != v {
+					t.Errorf("audit annotation %s = %q, wanted %q", k, resp.AuditAnnotations[k], v)
+				}
+			}
+		})
+	}
+}
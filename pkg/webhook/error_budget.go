@@ -0,0 +1,252 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
+	"github.com/open-policy-agent/gatekeeper/pkg/controller/constraint"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	csutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// errorBudgetWindow is the period over which review errors are counted
+const errorBudgetWindow = time.Minute
+
+// pendingDowngrades is how many exhausted constraints may wait to be downgraded. The
+// webhook drops any beyond it; they are charged again by their next errors.
+const pendingDowngrades = 64
+
+var errorBudgetLimit = flag.Int("error-budget", 0, "number of review errors per minute a constraint may cause on each webhook replica before it is downgraded to dryrun, until the "+csutil.DowngradedAnnotation+" annotation is removed from the constraint. 0 disables the error budget")
+
+// errorBudget counts the review errors of each constraint, by ConstraintKey, over
+// errorBudgetWindow
+type errorBudget struct {
+	mux    sync.Mutex
+	limit  int
+	errors map[string][]time.Time
+}
+
+// newErrorBudget returns a budget of limit errors per constraint, or nil if limit is not
+// positive
+func newErrorBudget(limit int) *errorBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &errorBudget{limit: limit, errors: make(map[string][]time.Time)}
+}
+
+// spend records an error of each of keys at now and returns the keys whose errors in the
+// window exceeded the limit. Their count starts over, so a constraint that is enabled
+// again gets a full budget.
+func (b *errorBudget) spend(keys []string, now time.Time) []string {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	var exhausted []string
+	for _, key := range keys {
+		recent := b.errors[key][:0]
+		for _, t := range b.errors[key] {
+			if now.Sub(t) < errorBudgetWindow {
+				recent = append(recent, t)
+			}
+		}
+		recent = append(recent, now)
+		if len(recent) > b.limit {
+			exhausted = append(exhausted, key)
+			delete(b.errors, key)
+			continue
+		}
+		b.errors[key] = recent
+	}
+	return exhausted
+}
+
+// reviewEach evaluates each constraint matching req on its own, once the review of req
+// failed. An error in one template aborts the whole review, so none of the other
+// constraints were evaluated. It returns the violations of the constraints that could be
+// evaluated, handled by the target as the review handles them, and the constraints that
+// failed.
+func (h *validationHandler) reviewEach(ctx context.Context, req admission.Request) ([]*rtypes.Result, []*unstructured.Unstructured, error) {
+	augmented, err := h.augmentedReview(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	tgt := &target.K8sValidationTarget{}
+	_, review, err := tgt.HandleReview(augmented)
+	if err != nil {
+		return nil, nil, err
+	}
+	matching, err := h.queries.Matching(ctx, tgt.GetName(), review)
+	if err != nil {
+		return nil, nil, err
+	}
+	var res []*rtypes.Result
+	var erroring []*unstructured.Unstructured
+	for _, c := range matching {
+		violations, err := h.queries.Violations(ctx, tgt.GetName(), review, c)
+		if err != nil {
+			erroring = append(erroring, c)
+			continue
+		}
+		for _, r := range violations {
+			if err := tgt.HandleViolation(r); err != nil {
+				return nil, nil, err
+			}
+			res = append(res, r)
+		}
+	}
+	return res, erroring, nil
+}
+
+// reviewAfterError decides whether req, whose review failed, may be admitted anyway: with
+// --fail-open-on-error, or when every constraint that fails is downgraded. The violations
+// of the constraints that do not fail are returned, so they still deny req. The failing
+// constraints are charged to the error budget.
+func (h *validationHandler) reviewAfterError(ctx context.Context, req admission.Request, log logr.Logger) ([]*rtypes.Result, bool) {
+	if h.queries == nil {
+		return nil, *failOpenOnError
+	}
+	res, erroring, err := h.reviewEach(ctx, req)
+	if err != nil {
+		log.Error(err, "could not evaluate constraints one at a time")
+		return nil, *failOpenOnError
+	}
+	h.spendErrorBudget(req, erroring, log)
+	if *failOpenOnError {
+		return res, true
+	}
+	if len(erroring) == 0 {
+		// the error cannot be attributed to a constraint
+		return nil, false
+	}
+	for _, c := range erroring {
+		if _, ok := csutil.Downgraded(c); !ok {
+			return nil, false
+		}
+	}
+	return res, true
+}
+
+// spendErrorBudget charges a review error to each erroring constraint and queues those that
+// exhausted their budget to be downgraded. Dry-run requests are not charged, so they cannot
+// downgrade a constraint.
+func (h *validationHandler) spendErrorBudget(req admission.Request, erroring []*unstructured.Unstructured, log logr.Logger) {
+	if h.budget == nil || len(erroring) == 0 || isDryRun(req) {
+		return
+	}
+	byKey := make(map[string]*unstructured.Unstructured, len(erroring))
+	keys := make([]string, 0, len(erroring))
+	for _, c := range erroring {
+		key := constraint.ConstraintKey(c.GetKind(), c.GetName())
+		byKey[key] = c
+		keys = append(keys, key)
+	}
+	for _, key := range h.budget.spend(keys, time.Now()) {
+		h.downgrades.queue(byKey[key], log)
+	}
+}
+
+// downgrader annotates the constraints that exhausted the error budget, off the admission
+// path. The constraint controller then loads them into OPA as dryrun.
+type downgrader struct {
+	client   client.Client
+	recorder record.EventRecorder
+	reporter StatsReporter
+	pending  chan *unstructured.Unstructured
+}
+
+var _ manager.Runnable = &downgrader{}
+
+func newDowngrader(mgr manager.Manager) (*downgrader, error) {
+	reporter, err := newStatsReporter()
+	if err != nil {
+		return nil, err
+	}
+	return &downgrader{
+		client:   mgr.GetClient(),
+		recorder: mgr.GetEventRecorderFor("gatekeeper-webhook"),
+		reporter: reporter,
+		pending:  make(chan *unstructured.Unstructured, pendingDowngrades),
+	}, nil
+}
+
+// queue schedules the downgrade of c without blocking. Nothing is downgraded if d is nil.
+func (d *downgrader) queue(c *unstructured.Unstructured, log logr.Logger) {
+	if d == nil {
+		return
+	}
+	select {
+	case d.pending <- c:
+	default:
+		log.Info("too many pending downgrades, dropping one", logging.ConstraintKind, c.GetKind(), logging.ConstraintName, c.GetName())
+	}
+}
+
+// Start downgrades the queued constraints until stop is closed
+func (d *downgrader) Start(stop <-chan struct{}) error {
+	for {
+		select {
+		case <-stop:
+			return nil
+		case c := <-d.pending:
+			d.downgrade(context.Background(), c)
+		}
+	}
+}
+
+// downgrade annotates c so it stops denying requests
+func (d *downgrader) downgrade(ctx context.Context, c *unstructured.Unstructured) {
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(c.GroupVersionKind())
+	if err := d.client.Get(ctx, client.ObjectKey{Name: c.GetName()}, live); err != nil {
+		log.Error(err, "could not read constraint to downgrade", logging.ConstraintKind, c.GetKind(), logging.ConstraintName, c.GetName())
+		return
+	}
+	if _, ok := csutil.Downgraded(live); ok {
+		return
+	}
+	reason := fmt.Sprintf("more than %d review errors per minute at %s", *errorBudgetLimit, time.Now().UTC().Format(time.RFC3339))
+	annotations := live.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[csutil.DowngradedAnnotation] = reason
+	live.SetAnnotations(annotations)
+	if err := d.client.Update(ctx, live); err != nil {
+		log.Error(err, "could not downgrade constraint", logging.ConstraintKind, c.GetKind(), logging.ConstraintName, c.GetName())
+		return
+	}
+	log.Info("downgraded constraint to dryrun after exhausting the error budget",
+		"event_type", "enforcement_downgraded", logging.ConstraintKind, c.GetKind(), logging.ConstraintName, c.GetName(), "reason", reason)
+	if err := d.reporter.ReportDowngrade(c.GetKind(), c.GetName()); err != nil {
+		log.Error(err, "failed to report downgrade")
+	}
+	d.recorder.Eventf(live, corev1.EventTypeWarning, "EnforcementDowngraded",
+		"constraint downgraded to dryrun: %s. Remove the %s annotation to enforce it again", reason, csutil.DowngradedAnnotation)
+}
@@ -0,0 +1,190 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/local"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	gkdriver "github.com/open-policy-agent/gatekeeper/pkg/driver"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	csutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const conflictingTemplate = `
+apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: k8sconflicting
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sConflicting
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+        package conflicting
+
+        name = "a" { true }
+        name = "b" { true }
+
+        violation[{"msg": name}] { true }
+`
+
+func TestErrorBudgetSpend(t *testing.T) {
+	if newErrorBudget(0) != nil {
+		t.Error("error budget should be disabled by a limit of 0")
+	}
+	b := newErrorBudget(2)
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if exhausted := b.spend([]string{"K8sA/a"}, start); len(exhausted) != 0 {
+			t.Fatalf("budget exhausted after %d errors", i+1)
+		}
+	}
+	if exhausted := b.spend([]string{"K8sA/a", "K8sA/b"}, start.Add(time.Second)); !reflect.DeepEqual(exhausted, []string{"K8sA/a"}) {
+		t.Errorf("got exhausted constraints %v, wanted K8sA/a", exhausted)
+	}
+	if exhausted := b.spend([]string{"K8sA/a"}, start.Add(2*time.Second)); len(exhausted) != 0 {
+		t.Error("budget should start over once exhausted")
+	}
+	b.spend([]string{"K8sA/b"}, start.Add(2*time.Second))
+	if exhausted := b.spend([]string{"K8sA/b"}, start.Add(2*time.Minute)); len(exhausted) != 0 {
+		t.Error("errors older than the window should not count")
+	}
+}
+
+func TestErrorBudgetDowngrade(t *testing.T) {
+	ctx := context.Background()
+	d := local.New(local.Tracing(false))
+	backend, err := client.NewBackend(client.Driver(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opa, err := backend.NewClient(client.Targets(&target.K8sValidationTarget{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	queries, err := gkdriver.NewQueries(ctx, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	templ := &templv1beta1.ConstraintTemplate{}
+	if err := yaml.Unmarshal([]byte(conflictingTemplate), templ); err != nil {
+		t.Fatalf("Could not instantiate template: %s", err)
+	}
+	unversioned := &templates.ConstraintTemplate{}
+	if err := runtimeScheme.Convert(templ, unversioned, nil); err != nil {
+		t.Fatalf("Could not convert to unversioned: %v", err)
+	}
+	if _, err := opa.AddTemplate(ctx, unversioned); err != nil {
+		t.Fatalf("Could not add template: %s", err)
+	}
+	conflicting := newConstraint("K8sConflicting", "conflicting", "deny", t)
+	if _, err := opa.AddConstraint(ctx, conflicting); err != nil {
+		t.Fatalf("Could not add constraint: %s", err)
+	}
+
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName(conflicting.GroupVersionKind(), &unstructured.Unstructured{})
+	c := fake.NewFakeClientWithScheme(scheme, conflicting.DeepCopy())
+	reporter, err := newStatsReporter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := record.NewFakeRecorder(1)
+	downgrades := &downgrader{client: c, recorder: recorder, reporter: reporter, pending: make(chan *unstructured.Unstructured, 1)}
+	h := &validationHandler{opa: opa, queries: queries, budget: newErrorBudget(1), downgrades: downgrades, injectedConfig: &v1alpha1.Config{}}
+	req := atypes.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Kind:   metav1.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+			Object: runtime.RawExtension{Raw: []byte(`{"apiVersion": "v1", "kind": "Namespace"}`)},
+		},
+	}
+	_, reviewErr := h.reviewRequest(ctx, req, nil)
+	if reviewErr == nil {
+		t.Fatal("wanted a review error")
+	}
+
+	dryRun := req
+	dryRun.AdmissionRequest.DryRun = func(b bool) *bool { return &b }(true)
+	for i := 0; i < 2; i++ {
+		if _, ok := h.reviewAfterError(ctx, dryRun, log); ok {
+			t.Error("request should not be admitted before the constraint is downgraded")
+		}
+	}
+	if len(downgrades.pending) != 0 {
+		t.Fatal("dry-run requests exhausted the error budget")
+	}
+	for i := 0; i < 2; i++ {
+		if _, ok := h.reviewAfterError(ctx, req, log); ok {
+			t.Error("request should not be admitted before the constraint is downgraded")
+		}
+	}
+	if len(downgrades.pending) != 1 {
+		t.Fatalf("got %d pending downgrades, wanted 1", len(downgrades.pending))
+	}
+	downgrades.downgrade(ctx, <-downgrades.pending)
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(conflicting.GroupVersionKind())
+	if err := c.Get(ctx, types.NamespacedName{Name: "conflicting"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := csutil.Downgraded(got); !ok {
+		t.Fatalf("constraint was not annotated with %s", csutil.DowngradedAnnotation)
+	}
+	if len(recorder.Events) != 1 {
+		t.Error("no EnforcementDowngraded event was recorded")
+	}
+
+	// the constraint controller reloads the annotated constraint as dryrun
+	if err := unstructured.SetNestedField(got.Object, "dryrun", "spec", "enforcementAction"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := opa.AddConstraint(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := h.reviewAfterError(ctx, req, log); !ok {
+		t.Error("request should be admitted once the constraint is downgraded")
+	}
+	if resp := h.Handle(ctx, req); !resp.Allowed {
+		t.Errorf("response = %+v, wanted the request admitted", resp.Result)
+	}
+
+	// the error of the downgraded constraint does not admit what another constraint denies
+	templ = &templv1beta1.ConstraintTemplate{}
+	if err := yaml.Unmarshal([]byte(denyAllTemplate), templ); err != nil {
+		t.Fatalf("Could not instantiate template: %s", err)
+	}
+	unversioned = &templates.ConstraintTemplate{}
+	if err := runtimeScheme.Convert(templ, unversioned, nil); err != nil {
+		t.Fatalf("Could not convert to unversioned: %v", err)
+	}
+	if _, err := opa.AddTemplate(ctx, unversioned); err != nil {
+		t.Fatalf("Could not add template: %s", err)
+	}
+	if _, err := opa.AddConstraint(ctx, newConstraint("K8sDenyAll", "denyall", "deny", t)); err != nil {
+		t.Fatalf("Could not add constraint: %s", err)
+	}
+	resp := h.Handle(ctx, req)
+	if resp.Allowed || resp.Result.Code != http.StatusForbidden || !strings.Contains(string(resp.Result.Reason), "[denied by denyall]") {
+		t.Errorf("response = %+v, wanted the request denied by K8sDenyAll", resp.Result)
+	}
+}
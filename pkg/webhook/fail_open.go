@@ -0,0 +1,94 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"flag"
+
+	"github.com/go-logr/logr"
+	"github.com/open-policy-agent/gatekeeper/pkg/replay"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// FailedOpenAnnotation is the audit annotation holding the error of a request that was
+// admitted without review
+const FailedOpenAnnotation = "gatekeeper.sh/failed-open"
+
+var failOpenOnError = flag.Bool("fail-open-on-error", false, "admit requests the constraint webhook fails to review, rather than returning an error. Each one is logged, counted by fail_open_count and reported with a FailedOpen event. The other matching constraints are then evaluated one at a time, and their violations still deny requests")
+
+// failOpen admits req, which could not be reviewed because of err, and reports it so
+// the object can be audited later. Dry-run requests change nothing, so they get no event.
+func (h *validationHandler) failOpen(req admission.Request, err error, log logr.Logger) admission.Response {
+	log.Error(err, "admitting request that could not be reviewed",
+		"event_type", "fail_open",
+		"request_uid", req.AdmissionRequest.UID,
+		"operation", req.AdmissionRequest.Operation,
+		"user", req.AdmissionRequest.UserInfo.Username,
+		"resource_group", req.AdmissionRequest.Kind.Group,
+		"resource_kind", req.AdmissionRequest.Kind.Kind,
+		"resource_namespace", req.AdmissionRequest.Namespace,
+		"resource_name", req.AdmissionRequest.Name,
+	)
+	if h.reporter != nil {
+		if err := h.reporter.ReportFailOpen(); err != nil {
+			log.Error(err, "failed to report fail open")
+		}
+	}
+	if h.recorder != nil && !isDryRun(req) {
+		// the object may not exist yet, so the event refers to it by reference
+		ref := &corev1.ObjectReference{
+			APIVersion: req.AdmissionRequest.Kind.Version,
+			Kind:       req.AdmissionRequest.Kind.Kind,
+			Namespace:  req.AdmissionRequest.Namespace,
+			Name:       req.AdmissionRequest.Name,
+		}
+		if req.AdmissionRequest.Kind.Group != "" {
+			ref.APIVersion = req.AdmissionRequest.Kind.Group + "/" + ref.APIVersion
+		}
+		h.recorder.Eventf(ref, corev1.EventTypeWarning, "FailedOpen",
+			"%s by %s admitted without policy review: %v", req.AdmissionRequest.Operation, req.AdmissionRequest.UserInfo.Username, err)
+	}
+	resp := admission.ValidationResponse(true, "")
+	resp.AuditAnnotations = map[string]string{FailedOpenAnnotation: err.Error()}
+	return resp
+}
+
+// queueReplay asks audit to re-check the object of req, which may have been admitted
+// without review. Dry-run requests and deletes leave nothing to check.
+func (h *validationHandler) queueReplay(req admission.Request, log logr.Logger) {
+	if h.replay == nil || isDryRun(req) || req.AdmissionRequest.Operation == admissionv1beta1.Delete {
+		return
+	}
+	ref := replay.Ref{
+		GroupVersionKind: schema.GroupVersionKind{
+			Group:   req.AdmissionRequest.Kind.Group,
+			Version: req.AdmissionRequest.Kind.Version,
+			Kind:    req.AdmissionRequest.Kind.Kind,
+		},
+		Namespace: req.AdmissionRequest.Namespace,
+		Name:      req.AdmissionRequest.Name,
+	}
+	if !h.replay.Add(ref) {
+		log.Info("object admitted without review left to the next audit",
+			"resource_kind", ref.Kind,
+			"resource_namespace", ref.Namespace,
+			"resource_name", ref.Name,
+		)
+	}
+}
@@ -0,0 +1,170 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/local"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	gkdriver "github.com/open-policy-agent/gatekeeper/pkg/driver"
+	"github.com/open-policy-agent/gatekeeper/pkg/replay"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestFailOpen(t *testing.T) {
+	defer resetViews(t)
+	defer func(v bool) { *failOpenOnError = v }(*failOpenOnError)
+	opa, err := makeOpaClient()
+	if err != nil {
+		t.Fatalf("Could not initialize OPA: %s", err)
+	}
+	// the review fails because the namespace of the request cannot be read
+	req := atypes.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			UID:       "request-1",
+			Operation: admissionv1beta1.Create,
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Namespace: "missing",
+			Name:      "foo",
+			Object:    runtime.RawExtension{Raw: []byte(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "foo", "namespace": "missing"}}`)},
+		},
+	}
+
+	tc := []struct {
+		Name     string
+		FailOpen bool
+		DryRun   bool
+		Allowed  bool
+		Outcome  requestResponse
+		Events   int
+		Queued   int
+	}{
+		{Name: "Disabled", FailOpen: false, Allowed: false, Outcome: errorResponse, Queued: 1},
+		{Name: "Enabled", FailOpen: true, Allowed: true, Outcome: failOpenResponse, Events: 1, Queued: 1},
+		{Name: "Dry run", FailOpen: true, DryRun: true, Allowed: true, Outcome: failOpenResponse},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			*failOpenOnError = tt.FailOpen
+			out := &bytes.Buffer{}
+			recorder := record.NewFakeRecorder(10)
+			queue := replay.NewQueue(func() int { return 10 })
+			handler := validationHandler{
+				opa:            opa,
+				client:         fake.NewFakeClient(),
+				injectedConfig: &v1alpha1.Config{},
+				decisions:      &jsonSink{w: out},
+				recorder:       recorder,
+				replay:         queue,
+			}
+			req := req
+			if tt.DryRun {
+				dryRun := true
+				req.AdmissionRequest.DryRun = &dryRun
+			}
+			resp := handler.Handle(context.Background(), req)
+			if resp.Allowed != tt.Allowed {
+				t.Fatalf("response allowed = %v, wanted %v", resp.Allowed, tt.Allowed)
+			}
+			if !tt.Allowed && resp.Result.Code != http.StatusInternalServerError {
+				t.Errorf("response code = %d, wanted %d", resp.Result.Code, http.StatusInternalServerError)
+			}
+			if _, ok := resp.AuditAnnotations[FailedOpenAnnotation]; ok != tt.FailOpen {
+				t.Errorf("response audit annotations = %v, wanted %s set: %v", resp.AuditAnnotations, FailedOpenAnnotation, tt.FailOpen)
+			}
+
+			d := &Decision{}
+			if err := json.Unmarshal(out.Bytes(), d); err != nil {
+				t.Fatalf("decision log %q: %v", out.String(), err)
+			}
+			if d.Outcome != tt.Outcome || d.Message == "" {
+				t.Errorf("recorded decision = %+v, wanted outcome %s with a message", d, tt.Outcome)
+			}
+
+			// with failurePolicy Ignore an error admits the request too, unless it is a dry run
+			if queue.Len() != tt.Queued {
+				t.Errorf("queued %d objects for replay, wanted %d", queue.Len(), tt.Queued)
+			}
+
+			if len(recorder.Events) != tt.Events {
+				t.Fatalf("recorded %d events, wanted %d", len(recorder.Events), tt.Events)
+			}
+			if tt.Events > 0 {
+				if e := <-recorder.Events; !strings.Contains(e, "FailedOpen") {
+					t.Errorf("event = %q, wanted a FailedOpen event", e)
+				}
+			}
+		})
+	}
+}
+
+func TestFailOpenStillDenies(t *testing.T) {
+	defer func(v bool) { *failOpenOnError = v }(*failOpenOnError)
+	*failOpenOnError = true
+	ctx := context.Background()
+	d := local.New(local.Tracing(false))
+	backend, err := client.NewBackend(client.Driver(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opa, err := backend.NewClient(client.Targets(&target.K8sValidationTarget{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	queries, err := gkdriver.NewQueries(ctx, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, src := range []string{denyAllTemplate, conflictingTemplate} {
+		templ := &templv1beta1.ConstraintTemplate{}
+		if err := yaml.Unmarshal([]byte(src), templ); err != nil {
+			t.Fatalf("Could not instantiate template: %s", err)
+		}
+		unversioned := &templates.ConstraintTemplate{}
+		if err := runtimeScheme.Convert(templ, unversioned, nil); err != nil {
+			t.Fatalf("Could not convert to unversioned: %v", err)
+		}
+		if _, err := opa.AddTemplate(ctx, unversioned); err != nil {
+			t.Fatalf("Could not add template: %s", err)
+		}
+	}
+	if _, err := opa.AddConstraint(ctx, newConstraint("K8sConflicting", "conflicting", "deny", t)); err != nil {
+		t.Fatalf("Could not add constraint: %s", err)
+	}
+	handler := validationHandler{opa: opa, queries: queries, injectedConfig: &v1alpha1.Config{}}
+	req := atypes.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Kind:   metav1.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+			Object: runtime.RawExtension{Raw: []byte(`{"apiVersion": "v1", "kind": "Namespace"}`)},
+		},
+	}
+	if resp := handler.Handle(ctx, req); !resp.Allowed {
+		t.Fatalf("response = %+v, wanted the request admitted", resp.Result)
+	}
+
+	// the constraints that do not fail are still enforced
+	if _, err := opa.AddConstraint(ctx, newConstraint("K8sDenyAll", "denyall", "deny", t)); err != nil {
+		t.Fatalf("Could not add constraint: %s", err)
+	}
+	resp := handler.Handle(ctx, req)
+	if resp.Allowed || resp.Result.Code != http.StatusForbidden {
+		t.Errorf("response = %+v, wanted the request denied by K8sDenyAll", resp.Result)
+	}
+	if _, ok := resp.AuditAnnotations[FailedOpenAnnotation]; ok {
+		t.Errorf("denied request has the %s audit annotation", FailedOpenAnnotation)
+	}
+}
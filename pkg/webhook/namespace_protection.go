@@ -0,0 +1,209 @@
+package webhook
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/engine"
+	"github.com/open-policy-agent/gatekeeper/pkg/match"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// protectLabel marks constraints whose matched resources block namespace deletion
+const protectLabel = "protect"
+
+// protectionPageSize is how many resources of a kind are listed at a time while looking
+// for a protected one
+const protectionPageSize = 100
+
+var protectNamespaceDeletion = flag.Bool("enable-namespace-deletion-protection", false, "deny deletion of namespaces containing resources matched by constraints labeled protect=true. The webhook must also be registered for DELETE operations on namespaces")
+
+func isNamespaceDeletion(req admission.Request) bool {
+	return req.AdmissionRequest.Operation == admissionv1beta1.Delete &&
+		req.AdmissionRequest.Kind.Group == "" &&
+		req.AdmissionRequest.Kind.Kind == "Namespace"
+}
+
+// checkNamespaceDeletion returns a denial if the namespace being deleted still contains
+// resources matched by a protected constraint. Rego only sees the namespace itself, not its
+// contents (unless everything is synced), so this is special-cased outside of policy evaluation.
+func (h *validationHandler) checkNamespaceDeletion(ctx context.Context, req admission.Request) *admission.Response {
+	ns := &corev1.Namespace{}
+	if _, _, err := deserializer.Decode(req.AdmissionRequest.OldObject.Raw, nil, ns); err != nil {
+		return errorResponseFor(err)
+	}
+	found, err := h.protectedResources(ctx, ns)
+	if err != nil {
+		log.Error(err, "error checking namespace for protected resources", "namespace", ns.GetName())
+		return errorResponseFor(err)
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	vResp := admission.ValidationResponse(false, fmt.Sprintf("namespace %s contains protected resources: %s", ns.GetName(), strings.Join(found, ", ")))
+	if vResp.Result == nil {
+		vResp.Result = &metav1.Status{}
+	}
+	vResp.Result.Code = http.StatusForbidden
+	return &vResp
+}
+
+func errorResponseFor(err error) *admission.Response {
+	vResp := admission.ValidationResponse(false, err.Error())
+	if vResp.Result == nil {
+		vResp.Result = &metav1.Status{}
+	}
+	vResp.Result.Code = http.StatusInternalServerError
+	return &vResp
+}
+
+// protectedResources returns a description of the first resource of each kind in the
+// namespace that a protected constraint would deny deleting. Constraints are read from the
+// policy engine, so only those enforced with the deny action protect anything, and the
+// admission target decides whether they match a resource being deleted, as it would for
+// the deletion of the resource itself. Exemptions apply as they do to reviews.
+func (h *validationHandler) protectedResources(ctx context.Context, ns *corev1.Namespace) ([]string, error) {
+	if h.queries == nil {
+		return nil, nil
+	}
+	loaded, err := h.queries.Constraints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var protected []*unstructured.Unstructured
+	for _, c := range loaded {
+		if c.GetLabels()[protectLabel] != "true" {
+			continue
+		}
+		if action, err := util.GetEnforcementAction(c.Object); err != nil || action != util.Deny {
+			continue
+		}
+		protected = append(protected, c)
+	}
+	if len(protected) == 0 {
+		return nil, nil
+	}
+	matcher, err := match.NewMatcher(ctx, protected)
+	if err != nil {
+		return nil, err
+	}
+	var found []string
+	for _, gvk := range h.protectedKinds(protected) {
+		res, err := h.firstProtected(ctx, matcher, gvk, ns)
+		if err != nil {
+			// a kind that cannot be listed does not block the deletion
+			log.Error(err, "could not check resources for namespace deletion protection", "namespace", ns.GetName(), "resource_kind", gvk.String())
+			continue
+		}
+		if res != "" {
+			found = append(found, res)
+		}
+	}
+	return found, nil
+}
+
+// protectedKinds returns the namespaced kinds the constraints select, at each version a
+// kind selector lists, or else at the version the cluster prefers. Wildcard groups and
+// kinds cannot be enumerated and are skipped, as are kinds the cluster does not serve.
+func (h *validationHandler) protectedKinds(constraints []*unstructured.Unstructured) []schema.GroupVersionKind {
+	seen := make(map[schema.GroupVersionKind]bool)
+	var gvks []schema.GroupVersionKind
+	for _, c := range constraints {
+		kinds, _, err := unstructured.NestedSlice(c.Object, "spec", "match", "kinds")
+		if err != nil {
+			continue
+		}
+		for _, k := range kinds {
+			entry, ok := k.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			groups, _, _ := unstructured.NestedStringSlice(entry, "apiGroups")
+			kindNames, _, _ := unstructured.NestedStringSlice(entry, "kinds")
+			versions, _, _ := unstructured.NestedStringSlice(entry, "versions")
+			for _, v := range versions {
+				if v == "*" {
+					versions = nil
+					break
+				}
+			}
+			for _, group := range groups {
+				for _, kind := range kindNames {
+					if group == "*" || kind == "*" {
+						continue
+					}
+					var mappings []*meta.RESTMapping
+					gk := schema.GroupKind{Group: group, Kind: kind}
+					if len(versions) == 0 {
+						if m, err := h.mapper.RESTMapping(gk); err == nil {
+							mappings = append(mappings, m)
+						}
+					}
+					for _, v := range versions {
+						if m, err := h.mapper.RESTMapping(gk, v); err == nil {
+							mappings = append(mappings, m)
+						}
+					}
+					for _, m := range mappings {
+						if m.Scope.Name() != meta.RESTScopeNameNamespace || seen[m.GroupVersionKind] {
+							continue
+						}
+						seen[m.GroupVersionKind] = true
+						gvks = append(gvks, m.GroupVersionKind)
+					}
+				}
+			}
+		}
+	}
+	sort.Slice(gvks, func(i, j int) bool {
+		return gvks[i].String() < gvks[j].String()
+	})
+	return gvks
+}
+
+// firstProtected returns a description of the first resource of gvk in the namespace that
+// a protected constraint matches, or "" if there is none
+func (h *validationHandler) firstProtected(ctx context.Context, matcher *match.Matcher, gvk schema.GroupVersionKind, ns *corev1.Namespace) (string, error) {
+	now := time.Now()
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	for {
+		if err := h.reader.List(ctx, list, client.InNamespace(ns.GetName()), client.Limit(protectionPageSize), client.Continue(list.GetContinue())); err != nil {
+			return "", err
+		}
+		for i := range list.Items {
+			obj := &list.Items[i]
+			obj.SetGroupVersionKind(gvk)
+			matched, err := matcher.Matching(ctx, obj, engine.Namespace(ns), engine.Operation(admissionv1beta1.Delete))
+			if err != nil {
+				return "", err
+			}
+			var by []string
+			for _, c := range matched {
+				if h.exemptions != nil && h.exemptions.Exempted(c.GetKind(), c.GetName(), gvk.Kind, ns.GetName(), ns.GetLabels(), obj.GetName(), now) != "" {
+					continue
+				}
+				by = append(by, c.GetKind()+" "+c.GetName())
+			}
+			if len(by) > 0 {
+				return fmt.Sprintf("%s %s (protected by %s)", gvk.Kind, obj.GetName(), strings.Join(by, ", ")), nil
+			}
+		}
+		if list.GetContinue() == "" {
+			return "", nil
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	opaclient "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/local"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/controller/exemption"
+	gkdriver "github.com/open-policy-agent/gatekeeper/pkg/driver"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	"github.com/open-policy-agent/gatekeeper/pkg/testutils"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8schema "k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// fakeReader serves namespaced objects from memory, failing to list the kinds in errs
+type fakeReader struct {
+	objs []*unstructured.Unstructured
+	errs map[string]error
+}
+
+func (r *fakeReader) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	return nil
+}
+
+func (r *fakeReader) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	o := (&client.ListOptions{}).ApplyOptions(opts)
+	if l, ok := list.(*unstructured.UnstructuredList); ok {
+		kind := strings.TrimSuffix(l.GroupVersionKind().Kind, "List")
+		if err := r.errs[kind]; err != nil {
+			return err
+		}
+		for _, obj := range r.objs {
+			if obj.GetKind() != kind || obj.GroupVersionKind().Group != l.GroupVersionKind().Group {
+				continue
+			}
+			if o.Namespace != "" && obj.GetNamespace() != o.Namespace {
+				continue
+			}
+			l.Items = append(l.Items, *obj)
+		}
+	}
+	return nil
+}
+
+func newTestObj(gvk k8schema.GroupVersionKind, namespace, name string, objLabels map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	u.SetLabels(objLabels)
+	return u
+}
+
+func namespaceDeleteRequest(t *testing.T, name string, nsLabels map[string]string) atypes.Request {
+	ns := &corev1.Namespace{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: nsLabels},
+	}
+	raw, err := json.Marshal(ns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return atypes.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Group: "", Version: "v1", Kind: "Namespace"},
+			Operation: admissionv1beta1.Delete,
+			Name:      name,
+			OldObject: runtime.RawExtension{Raw: raw},
+		},
+	}
+}
+
+const protectTemplate = `
+apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: k8sprotect
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sProtect
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+        package k8sprotect
+
+        violation[{"msg": "protected"}] { true }
+`
+
+func TestCheckNamespaceDeletion(t *testing.T) {
+	pvcGVK := k8schema.GroupVersionKind{Version: "v1", Kind: "PersistentVolumeClaim"}
+	pvGVK := k8schema.GroupVersionKind{Version: "v1", Kind: "PersistentVolume"}
+	mapper := meta.NewDefaultRESTMapper([]k8schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(pvcGVK, meta.RESTScopeNamespace)
+	mapper.Add(pvGVK, meta.RESTScopeRoot)
+
+	protected := map[string]string{protectLabel: "true"}
+	pvcs := func(name string, opts ...testutils.ConstraintOpt) *unstructured.Unstructured {
+		opts = append([]testutils.ConstraintOpt{testutils.WithLabels(protected), testutils.WithMatchKinds([]string{""}, []string{"PersistentVolumeClaim"})}, opts...)
+		return testutils.NewConstraint("K8sProtect", name, opts...)
+	}
+	future := metav1.NewTime(time.Now().Add(time.Hour))
+
+	tc := []struct {
+		Name            string
+		Namespace       string
+		NamespaceLabels map[string]string
+		Constraints     []*unstructured.Unstructured
+		Objs            []*unstructured.Unstructured
+		ListErrs        map[string]error
+		Exemption       *v1alpha1.ExemptionSpec
+		ExpectDeny      bool
+	}{
+		{
+			Name:      "No protected constraints",
+			Namespace: "data",
+			Constraints: []*unstructured.Unstructured{
+				testutils.NewConstraint("K8sProtect", "unlabeled", testutils.WithMatchKinds([]string{""}, []string{"PersistentVolumeClaim"})),
+			},
+			Objs: []*unstructured.Unstructured{newTestObj(pvcGVK, "data", "db", nil)},
+		},
+		{
+			Name:        "Protected resource present",
+			Namespace:   "data",
+			Constraints: []*unstructured.Unstructured{pvcs("pvcs")},
+			Objs:        []*unstructured.Unstructured{newTestObj(pvcGVK, "data", "db", nil)},
+			ExpectDeny:  true,
+		},
+		{
+			Name:        "Protected resource in another namespace",
+			Namespace:   "scratch",
+			Constraints: []*unstructured.Unstructured{pvcs("pvcs")},
+			Objs:        []*unstructured.Unstructured{newTestObj(pvcGVK, "data", "db", nil)},
+		},
+		{
+			Name:        "Namespace excluded",
+			Namespace:   "data",
+			Constraints: []*unstructured.Unstructured{withMatchField(pvcs("pvcs"), "excludedNamespaces", []interface{}{"data"})},
+			Objs:        []*unstructured.Unstructured{newTestObj(pvcGVK, "data", "db", nil)},
+		},
+		{
+			Name:            "Namespace selector does not match",
+			Namespace:       "data",
+			NamespaceLabels: map[string]string{"env": "dev"},
+			Constraints: []*unstructured.Unstructured{
+				withMatchField(pvcs("pvcs"), "namespaceSelector", map[string]interface{}{"matchLabels": map[string]interface{}{"env": "prod"}}),
+			},
+			Objs: []*unstructured.Unstructured{newTestObj(pvcGVK, "data", "db", nil)},
+		},
+		{
+			Name:      "Label selector does not match",
+			Namespace: "data",
+			Constraints: []*unstructured.Unstructured{
+				withMatchField(pvcs("pvcs"), "labelSelector", map[string]interface{}{"matchLabels": map[string]interface{}{"tier": "prod"}}),
+			},
+			Objs: []*unstructured.Unstructured{newTestObj(pvcGVK, "data", "db", map[string]string{"tier": "dev"})},
+		},
+		{
+			Name:      "Later resource matches",
+			Namespace: "data",
+			Constraints: []*unstructured.Unstructured{
+				withMatchField(pvcs("pvcs"), "labelSelector", map[string]interface{}{"matchLabels": map[string]interface{}{"tier": "prod"}}),
+			},
+			Objs: []*unstructured.Unstructured{
+				newTestObj(pvcGVK, "data", "scratch", map[string]string{"tier": "dev"}),
+				newTestObj(pvcGVK, "data", "db", map[string]string{"tier": "prod"}),
+			},
+			ExpectDeny: true,
+		},
+		{
+			Name:      "Cluster scoped kinds are ignored",
+			Namespace: "data",
+			Constraints: []*unstructured.Unstructured{
+				testutils.NewConstraint("K8sProtect", "pvs", testutils.WithLabels(protected), testutils.WithMatchKinds([]string{""}, []string{"PersistentVolume"})),
+			},
+			Objs: []*unstructured.Unstructured{newTestObj(pvGVK, "data", "vol", nil)},
+		},
+		{
+			Name:        "Dryrun constraints do not protect",
+			Namespace:   "data",
+			Constraints: []*unstructured.Unstructured{pvcs("pvcs", testutils.WithEnforcementAction("dryrun"))},
+			Objs:        []*unstructured.Unstructured{newTestObj(pvcGVK, "data", "db", nil)},
+		},
+		{
+			Name:        "Operations without DELETE",
+			Namespace:   "data",
+			Constraints: []*unstructured.Unstructured{withMatchField(pvcs("pvcs"), "operations", []interface{}{"CREATE", "UPDATE"})},
+			Objs:        []*unstructured.Unstructured{newTestObj(pvcGVK, "data", "db", nil)},
+		},
+		{
+			Name:      "Version not served",
+			Namespace: "data",
+			Constraints: []*unstructured.Unstructured{withMatchField(pvcs("pvcs"), "kinds", []interface{}{
+				map[string]interface{}{"apiGroups": []interface{}{""}, "kinds": []interface{}{"PersistentVolumeClaim"}, "versions": []interface{}{"v2"}},
+			})},
+			Objs: []*unstructured.Unstructured{newTestObj(pvcGVK, "data", "db", nil)},
+		},
+		{
+			Name:      "Version served",
+			Namespace: "data",
+			Constraints: []*unstructured.Unstructured{withMatchField(pvcs("pvcs"), "kinds", []interface{}{
+				map[string]interface{}{"apiGroups": []interface{}{""}, "kinds": []interface{}{"PersistentVolumeClaim"}, "versions": []interface{}{"v2", "v1"}},
+			})},
+			Objs:       []*unstructured.Unstructured{newTestObj(pvcGVK, "data", "db", nil)},
+			ExpectDeny: true,
+		},
+		{
+			Name:        "Exempted resource",
+			Namespace:   "data",
+			Constraints: []*unstructured.Unstructured{pvcs("pvcs")},
+			Objs:        []*unstructured.Unstructured{newTestObj(pvcGVK, "data", "db", nil)},
+			Exemption: &v1alpha1.ExemptionSpec{
+				ConstraintKind: "K8sProtect",
+				ConstraintName: "pvcs",
+				Match:          v1alpha1.ExemptionMatch{Namespace: "data"},
+				ExpiresAt:      future,
+			},
+		},
+		{
+			Name:        "List error does not block deletion",
+			Namespace:   "data",
+			Constraints: []*unstructured.Unstructured{pvcs("pvcs")},
+			Objs:        []*unstructured.Unstructured{newTestObj(pvcGVK, "data", "db", nil)},
+			ListErrs:    map[string]error{"PersistentVolumeClaim": errors.New("the server is currently unable to handle the request")},
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			ctx := context.Background()
+			d := local.New(local.Tracing(false))
+			backend, err := opaclient.NewBackend(opaclient.Driver(d))
+			if err != nil {
+				t.Fatal(err)
+			}
+			opa, err := backend.NewClient(opaclient.Targets(&target.K8sValidationTarget{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			queries, err := gkdriver.NewQueries(ctx, d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			templ := &templv1beta1.ConstraintTemplate{}
+			if err := yaml.Unmarshal([]byte(protectTemplate), templ); err != nil {
+				t.Fatalf("Could not instantiate template: %s", err)
+			}
+			unversioned := &templates.ConstraintTemplate{}
+			if err := runtimeScheme.Convert(templ, unversioned, nil); err != nil {
+				t.Fatalf("Could not convert to unversioned: %v", err)
+			}
+			if _, err := opa.AddTemplate(ctx, unversioned); err != nil {
+				t.Fatalf("Could not add template: %s", err)
+			}
+			for _, c := range tt.Constraints {
+				if _, err := opa.AddConstraint(ctx, c); err != nil {
+					t.Fatalf("Could not add constraint: %s", err)
+				}
+			}
+			h := &validationHandler{reader: &fakeReader{objs: tt.Objs, errs: tt.ListErrs}, mapper: mapper, queries: queries}
+			if tt.Exemption != nil {
+				h.exemptions = exemption.NewExemptionsCache()
+				h.exemptions.Add("exempt", *tt.Exemption)
+			}
+
+			resp := h.checkNamespaceDeletion(ctx, namespaceDeleteRequest(t, tt.Namespace, tt.NamespaceLabels))
+			if !tt.ExpectDeny {
+				if resp != nil {
+					t.Errorf("expected namespace deletion to be allowed, got %v", resp.Result)
+				}
+				return
+			}
+			if resp == nil {
+				t.Fatal("expected namespace deletion to be denied")
+			}
+			if resp.Allowed || resp.Result.Code != http.StatusForbidden {
+				t.Errorf("expected a 403 denial, got %v", resp.Result)
+			}
+		})
+	}
+}
+
+func withMatchField(u *unstructured.Unstructured, field string, value interface{}) *unstructured.Unstructured {
+	if err := unstructured.SetNestedField(u.Object, value, "spec", "match", field); err != nil {
+		panic(err)
+	}
+	return u
+}
@@ -15,16 +15,27 @@ import (
 	"github.com/open-policy-agent/gatekeeper/api"
 	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
 	"github.com/open-policy-agent/gatekeeper/pkg/controller/config"
+	"github.com/open-policy-agent/gatekeeper/pkg/controller/exemption"
+	"github.com/open-policy-agent/gatekeeper/pkg/driver"
+	"github.com/open-policy-agent/gatekeeper/pkg/feed"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
+	"github.com/open-policy-agent/gatekeeper/pkg/redact"
+	"github.com/open-policy-agent/gatekeeper/pkg/replay"
 	"github.com/open-policy-agent/gatekeeper/pkg/target"
 	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	csutil "github.com/open-policy-agent/gatekeeper/pkg/util/constraint"
+	"github.com/open-policy-agent/gatekeeper/pkg/util/regoutil"
+	"go.opencensus.io/metric/metricdata"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -41,6 +52,8 @@ func init() {
 
 var log = logf.Log.WithName("webhook")
 
+const webhookUserAgent = "gatekeeper-webhook"
+
 var (
 	runtimeScheme                      = k8sruntime.NewScheme()
 	codecs                             = serializer.NewCodecFactory(runtimeScheme)
@@ -48,16 +61,90 @@ var (
 	disableEnforcementActionValidation = flag.Bool("disable-enforcementaction-validation", false, "disable validation of the enforcementAction field of a constraint")
 	disableCertRotation                = flag.Bool("disable-cert-rotation", false, "disable automatic generation and rotation of webhook TLS certificates/keys")
 	logDenies                          = flag.Bool("log-denies", false, "log detailed info on each deny")
+	webhookClientQPS                   = flag.Float64("webhook-client-qps", 0, "maximum number of requests per second the webhook sends to the API server while reviewing requests. 0 keeps the limit of the controller client")
+	webhookClientBurst                 = flag.Int("webhook-client-burst", 0, "maximum burst of webhook requests above --webhook-client-qps. Defaults to --webhook-client-qps rounded up")
 	// webhookName is deprecated, set this on the manifest YAML if needed"
 )
 
 // +kubebuilder:webhook:verbs=create;update,path=/v1/admit,mutating=false,failurePolicy=ignore,groups=*,resources=*,versions=*,name=validation.gatekeeper.sh
 // +kubebuilder:rbac:groups=*,resources=*,verbs=get;list;watch
 
-// AddPolicyWebhook registers the policy webhook server with the manager
-func AddPolicyWebhook(mgr manager.Manager, opa *opa.Client) error {
-	wh := &admission.Webhook{Handler: &validationHandler{opa: opa, client: mgr.GetClient()}}
-	mgr.GetWebhookServer().Register("/v1/admit", wh)
+// AddPolicyWebhook registers the policy webhook with the webhook server
+func AddPolicyWebhook(mgr manager.Manager, opa *opa.Client, queries *driver.Queries, srv *Server) error {
+	annotations := util.SurfacedAnnotations()
+	if len(annotations) > 0 {
+		if err := registerViolationsView(annotations); err != nil {
+			return err
+		}
+	}
+	decisions, err := newDecisionSink()
+	if err != nil {
+		return err
+	}
+	// the webhook reads around the cache with its own client, so its requests are not
+	// held up by the controllers' rate limit
+	reader, err := client.New(
+		util.ClientConfig(mgr.GetConfig(), webhookUserAgent, *webhookClientQPS, *webhookClientBurst),
+		client.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper()})
+	if err != nil {
+		return err
+	}
+	var hashKey []byte
+	if *hashUsernames {
+		if hashKey, err = usernameHashKey(context.Background(), reader); err != nil {
+			return err
+		}
+		if err := registerDeniedRequestsView(true); err != nil {
+			return err
+		}
+	}
+	requesters, err := newRequesterClassifier(*requesterClasses, hashKey)
+	if err != nil {
+		return err
+	}
+	budget := newErrorBudget(*errorBudgetLimit)
+	var downgrades *downgrader
+	if budget != nil {
+		if downgrades, err = newDowngrader(mgr); err != nil {
+			return err
+		}
+		if err := mgr.Add(downgrades); err != nil {
+			return err
+		}
+	}
+	timings, err := newTemplateTimer(queries, *templateTimingSampleRate)
+	if err != nil {
+		return err
+	}
+	if timings != nil {
+		if err := mgr.Add(timings); err != nil {
+			return err
+		}
+	}
+	wh := &admission.Webhook{Handler: &validationHandler{
+		opa:         opa,
+		queries:     queries,
+		client:      mgr.GetClient(),
+		reader:      reader,
+		mapper:      mgr.GetRESTMapper(),
+		exemptions:  exemption.Cache,
+		annotations: annotations,
+		decisions:   decisions,
+		recorder:    mgr.GetEventRecorderFor("gatekeeper-webhook"),
+		replay:      replay.Pending,
+		requesters:  requesters,
+		feed:        feed.Violations,
+		budget:      budget,
+		downgrades:  downgrades,
+		timings:     timings,
+	}}
+	// the namespace label webhook is not limited: namespaces are small, and allowing an
+	// oversize request there would bypass the label checks
+	limited, err := limitRequestSize(wh)
+	if err != nil {
+		return err
+	}
+	srv.Register("/v1/admit", limited)
 
 	if !*disableCertRotation {
 		log.Info("cert rotation is enabled")
@@ -73,9 +160,38 @@ func AddPolicyWebhook(mgr manager.Manager, opa *opa.Client) error {
 var _ admission.Handler = &validationHandler{}
 
 type validationHandler struct {
-	opa      *opa.Client
+	opa *opa.Client
+	// queries attributes review errors to constraints for the error budget
+	queries  *driver.Queries
 	client   client.Client
 	reporter StatsReporter
+	// reader bypasses the cache, so namespace contents need not be watched
+	reader client.Reader
+	mapper meta.RESTMapper
+	// exemptions waive violations until they expire. No violations are waived if nil
+	exemptions *exemption.ExemptionsCache
+	// annotations are the constraint annotations added to deny messages and metrics
+	annotations []string
+	// decisions persists every decision. Decisions are only logged if nil
+	decisions DecisionSink
+	// recorder reports requests admitted by --fail-open-on-error. No events are sent if nil
+	recorder record.EventRecorder
+	// replay collects the objects that may have been admitted without review, for audit
+	// to re-check. No objects are collected if nil
+	replay *replay.Queue
+	// requesters classifies the users whose requests are denied for the denied_requests
+	// metric. Denials are not counted by requester if nil
+	requesters *requesterClassifier
+	// feed receives the violations of each reviewed request. Nothing is published if nil
+	feed *feed.Feed
+	// budget counts the review errors of each constraint to downgrade the ones that exceed
+	// --error-budget. Nothing is downgraded if nil
+	budget *errorBudget
+	// downgrades annotates the constraints that exhausted the budget
+	downgrades *downgrader
+	// timings times the templates of a sample of the reviewed requests. Nothing is timed
+	// if nil
+	timings *templateTimer
 
 	// for testing
 	injectedConfig *v1alpha1.Config
@@ -88,11 +204,22 @@ const (
 	denyResponse    requestResponse = "deny"
 	allowResponse   requestResponse = "allow"
 	unknownResponse requestResponse = "unknown"
+	// failOpenResponse is an error admitted by --fail-open-on-error
+	failOpenResponse requestResponse = "fail_open"
 )
 
 // Handle the validation request
-func (h *validationHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
-	log := log.WithValues("hookType", "validation")
+func (h *validationHandler) Handle(ctx context.Context, req admission.Request) (result admission.Response) {
+	decision := newDecision(req)
+	log := log.WithValues("hookType", "validation", "decision_id", decision.ID)
+	defer func() {
+		decision.finish(&result)
+		if h.decisions != nil {
+			if err := h.decisions.Record(decision); err != nil {
+				log.Error(err, "failed to record decision")
+			}
+		}
+	}()
 
 	var timeStart = time.Now()
 	reporter, err := newStatsReporter()
@@ -122,6 +249,17 @@ func (h *validationHandler) Handle(ctx context.Context, req admission.Request) a
 		req.AdmissionRequest.Object = req.AdmissionRequest.OldObject
 	}
 
+	if warning := deprecatedVersionWarning(req); warning != "" {
+		// admission responses cannot carry warnings to the client in this API version
+		log.Info(warning, "resource_kind", req.AdmissionRequest.Kind.Kind, "resource_name", req.AdmissionRequest.Name, "user", req.AdmissionRequest.UserInfo.Username)
+		defer func() {
+			if result.AuditAnnotations == nil {
+				result.AuditAnnotations = make(map[string]string)
+			}
+			result.AuditAnnotations[DeprecatedVersionAnnotation] = warning
+		}()
+	}
+
 	if userErr, err := h.validateGatekeeperResources(ctx, req); err != nil {
 		vResp := admission.ValidationResponse(false, err.Error())
 		if vResp.Result == nil {
@@ -135,30 +273,74 @@ func (h *validationHandler) Handle(ctx context.Context, req admission.Request) a
 		return vResp
 	}
 
+	// Dry-run requests are reviewed as usual, and tagged in logs, metrics and the decision
+	// log. They are not published to the violation stream, queued for replay or charged to
+	// the error budget, and get no FailedOpen event, since the API server persists nothing.
+	dryRun := isDryRun(req)
 	requestResponse := unknownResponse
 	defer func() {
 		if h.reporter != nil {
+			var exemplar metricdata.Attachments
+			if decision.Traced {
+				exemplar = decision.exemplar()
+			}
 			if err := h.reporter.ReportRequest(
-				requestResponse, time.Since(timeStart)); err != nil {
+				requestResponse, dryRun, time.Since(timeStart), exemplar); err != nil {
 				log.Error(err, "failed to report request")
 			}
+			if requestResponse == denyResponse && h.requesters != nil {
+				class, userHash := h.requesters.classify(req.AdmissionRequest.UserInfo)
+				if err := h.reporter.ReportDenial(class, userHash); err != nil {
+					log.Error(err, "failed to report denial")
+				}
+			}
 		}
 	}()
 
-	resp, err := h.reviewRequest(ctx, req)
-	if err != nil {
-		log.Error(err, "error executing query")
-		vResp := admission.ValidationResponse(false, err.Error())
-		if vResp.Result == nil {
-			vResp.Result = &metav1.Status{}
+	if *protectNamespaceDeletion && isNamespaceDeletion(req) {
+		if vResp := h.checkNamespaceDeletion(ctx, req); vResp != nil {
+			if vResp.Result.Code == http.StatusForbidden {
+				requestResponse = denyResponse
+			} else {
+				requestResponse = errorResponse
+			}
+			return *vResp
 		}
-		vResp.Result.Code = http.StatusInternalServerError
-		requestResponse = errorResponse
-		return vResp
 	}
 
-	res := resp.Results()
-	msgs := h.getDenyMessages(res, req)
+	var res []*rtypes.Result
+	resp, reviewErr := h.reviewRequest(ctx, req, decision)
+	if reviewErr != nil {
+		// with --fail-open-on-error, or when only downgraded constraints failed, the request
+		// is admitted unless the other constraints deny it
+		var ok bool
+		if res, ok = h.reviewAfterError(ctx, req, log); !ok {
+			// failurePolicy Ignore may admit it anyway
+			h.queueReplay(req, log)
+			log.Error(reviewErr, "error executing query")
+			vResp := admission.ValidationResponse(false, reviewErr.Error())
+			if vResp.Result == nil {
+				vResp.Result = &metav1.Status{}
+			}
+			vResp.Result.Code = http.StatusInternalServerError
+			requestResponse = errorResponse
+			return vResp
+		}
+	} else {
+		if ctx.Err() != nil {
+			// the API server stopped waiting, so its failure policy decided the request
+			h.queueReplay(req, log)
+		} else if h.replay != nil {
+			h.replay.Recovered()
+		}
+		res = resp.Results()
+	}
+
+	res = h.dropExempt(ctx, res, req)
+	decision.addResults(res)
+	h.reportViolations(res)
+	h.publishViolations(res, req, decision.ID)
+	msgs := h.getDenyMessages(res, req, decision.ID)
 	if len(msgs) > 0 {
 		vResp := admission.ValidationResponse(false, strings.Join(msgs, "\n"))
 		if vResp.Result == nil {
@@ -169,11 +351,108 @@ func (h *validationHandler) Handle(ctx context.Context, req admission.Request) a
 		return vResp
 	}
 
+	if reviewErr != nil {
+		h.queueReplay(req, log)
+		requestResponse = failOpenResponse
+		return h.failOpen(req, reviewErr, log)
+	}
 	requestResponse = allowResponse
 	return admission.ValidationResponse(true, "")
 }
 
-func (h *validationHandler) getDenyMessages(res []*rtypes.Result, req admission.Request) []string {
+// augmentedReview returns req with the namespace of the object it reviews
+func (h *validationHandler) augmentedReview(ctx context.Context, req admission.Request) (target.AugmentedReview, error) {
+	augmented := target.AugmentedReview{AdmissionRequest: &req.AdmissionRequest}
+	if req.AdmissionRequest.Namespace != "" {
+		ns := &corev1.Namespace{}
+		if err := h.client.Get(ctx, types.NamespacedName{Name: req.AdmissionRequest.Namespace}, ns); err != nil {
+			return augmented, err
+		}
+		augmented.Namespace = ns
+	}
+	return augmented, nil
+}
+
+// dropExempt removes the violations waived by an unexpired exemption
+func (h *validationHandler) dropExempt(ctx context.Context, res []*rtypes.Result, req admission.Request) []*rtypes.Result {
+	if h.exemptions == nil {
+		return res
+	}
+	now := time.Now()
+	var nsLabels map[string]string
+	if len(res) > 0 && req.AdmissionRequest.Namespace != "" {
+		ns := &corev1.Namespace{}
+		if err := h.client.Get(ctx, types.NamespacedName{Name: req.AdmissionRequest.Namespace}, ns); err != nil {
+			log.Error(err, "could not read namespace labels for exemptions", "namespace", req.AdmissionRequest.Namespace)
+		}
+		nsLabels = ns.GetLabels()
+	}
+	var kept []*rtypes.Result
+	for _, r := range res {
+		name := h.exemptions.Exempted(r.Constraint.GetKind(), r.Constraint.GetName(),
+			req.AdmissionRequest.Kind.Kind, req.AdmissionRequest.Namespace, nsLabels, req.AdmissionRequest.Name, now)
+		if name != "" {
+			log.Info("violation waived by exemption",
+				"exemption_name", name,
+				"constraint_name", r.Constraint.GetName(),
+				"constraint_kind", r.Constraint.GetKind(),
+				"resource_kind", req.AdmissionRequest.Kind.Kind,
+				"resource_namespace", req.AdmissionRequest.Namespace,
+				"resource_name", req.AdmissionRequest.Name,
+			)
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
+
+// reportViolations counts the violations of each template. Constraints are evaluated in
+// a single query, so the time spent on each template is measured by timings instead.
+func (h *validationHandler) reportViolations(res []*rtypes.Result) {
+	if h.reporter == nil {
+		return
+	}
+	for _, r := range res {
+		if r.EnforcementAction == string(util.Audit) {
+			continue
+		}
+		// a template is named after the lowercase kind of its constraints
+		template := strings.ToLower(r.Constraint.GetKind())
+		annotations := util.SelectAnnotations(r.Constraint, h.annotations)
+		if err := h.reporter.ReportTemplateViolation(template, r.EnforcementAction, annotations); err != nil {
+			log.Error(err, "failed to report template violation")
+		}
+	}
+}
+
+// publishViolations passes the violations of req to the violation stream
+func (h *validationHandler) publishViolations(res []*rtypes.Result, req admission.Request, decisionID string) {
+	if h.feed == nil || !h.feed.Active() || isDryRun(req) {
+		return
+	}
+	for _, r := range res {
+		if r.EnforcementAction == string(util.Audit) {
+			continue
+		}
+		h.feed.Publish(feed.Event{
+			Source:            feed.SourceAdmission,
+			Type:              feed.TypeViolation,
+			ConstraintKind:    r.Constraint.GetKind(),
+			ConstraintName:    r.Constraint.GetName(),
+			ConstraintOwner:   util.GetOwner(r.Constraint),
+			EnforcementAction: r.EnforcementAction,
+			Message:           r.Msg,
+			Kind:              req.AdmissionRequest.Kind.Kind,
+			Namespace:         req.AdmissionRequest.Namespace,
+			Name:              req.AdmissionRequest.Name,
+			Operation:         string(req.AdmissionRequest.Operation),
+			DecisionID:        decisionID,
+		})
+	}
+}
+
+func (h *validationHandler) getDenyMessages(res []*rtypes.Result, req admission.Request, decisionID string) []string {
 	var msgs []string
 	for _, r := range res {
 		if r.EnforcementAction == "deny" || r.EnforcementAction == "dryrun" {
@@ -181,23 +460,31 @@ func (h *validationHandler) getDenyMessages(res []*rtypes.Result, req admission.
 				log.WithValues(
 					"process", "admission",
 					"event_type", "violation",
+					"decision_id", decisionID,
 					"constraint_name", r.Constraint.GetName(),
 					"constraint_kind", r.Constraint.GetKind(),
 					"constraint_action", r.EnforcementAction,
+					"constraint_owner", util.GetOwner(r.Constraint),
 					"resource_kind", req.AdmissionRequest.Kind.Kind,
 					"resource_namespace", req.AdmissionRequest.Namespace,
 					"resource_name", req.AdmissionRequest.Name,
+					"request_dry_run", isDryRun(req),
 				).Info("denied admission")
 			}
 		}
 		// only deny enforcementAction should prompt deny admission response
 		if r.EnforcementAction == "deny" {
-			msgs = append(msgs, fmt.Sprintf("[denied by %s] %s", r.Constraint.GetName(), r.Msg))
+			msgs = append(msgs, fmt.Sprintf("[denied by %s] %s%s", r.Constraint.GetName(), r.Msg, util.DescribeAnnotations(r.Constraint, h.annotations)))
 		}
 	}
 	return msgs
 }
 
+// isDryRun returns true if the API server will not persist the request's changes
+func isDryRun(req admission.Request) bool {
+	return req.AdmissionRequest.DryRun != nil && *req.AdmissionRequest.DryRun
+}
+
 func (h *validationHandler) getConfig(ctx context.Context) (*v1alpha1.Config, error) {
 	if h.injectedConfig != nil {
 		return h.injectedConfig, nil
@@ -240,6 +527,11 @@ func (h *validationHandler) validateTemplate(ctx context.Context, req admission.
 	if err := runtimeScheme.Convert(templ, unversioned, nil); err != nil {
 		return false, err
 	}
+	if err := regoutil.CheckBuiltins(unversioned, regoutil.DisallowedBuiltins()); err != nil {
+		return true, err
+	}
+	regoutil.AddLibs(unversioned)
+	csutil.StripSchemaDefaults(unversioned)
 	if _, err := h.opa.CreateCRD(ctx, unversioned); err != nil {
 		return true, err
 	}
@@ -254,6 +546,19 @@ func (h *validationHandler) validateConstraint(ctx context.Context, req admissio
 	if err := h.opa.ValidateConstraint(ctx, obj); err != nil {
 		return true, err
 	}
+	if err := csutil.ValidateClusterConditions(obj); err != nil {
+		return true, err
+	}
+
+	severity, err := util.GetSeverity(obj.Object)
+	if err != nil {
+		return false, err
+	}
+	if severity != "" {
+		if err := util.ValidateSeverity(severity); err != nil {
+			return true, err
+		}
+	}
 
 	enforcementActionString, found, err := unstructured.NestedString(obj.Object, "spec", "enforcementAction")
 	if err != nil {
@@ -273,8 +578,22 @@ func (h *validationHandler) validateConstraint(ctx context.Context, req admissio
 	return false, nil
 }
 
-// traceSwitch returns true if a request should be traced
-func (h *validationHandler) reviewRequest(ctx context.Context, req admission.Request) (*rtypes.Responses, error) {
+// deprecatedVersionWarning returns a warning if req writes a constraint through a
+// deprecated version, or "" otherwise
+func deprecatedVersionWarning(req admission.Request) string {
+	kind := req.AdmissionRequest.Kind
+	if kind.Group != "constraints.gatekeeper.sh" || req.AdmissionRequest.Operation == admissionv1beta1.Delete {
+		return ""
+	}
+	if !csutil.IsDeprecatedVersion(kind.Version) {
+		return ""
+	}
+	return csutil.DeprecationMessage(kind.Version)
+}
+
+// reviewRequest reviews req, logging the OPA trace if the Config traces it. A traced
+// review is marked on decision, if it is set.
+func (h *validationHandler) reviewRequest(ctx context.Context, req admission.Request, decision *Decision) (*rtypes.Responses, error) {
 	cfg, _ := h.getConfig(ctx)
 	traceEnabled := false
 	dump := false
@@ -294,21 +613,41 @@ func (h *validationHandler) reviewRequest(ctx context.Context, req admission.Req
 			}
 		}
 	}
-	review := &target.AugmentedReview{AdmissionRequest: &req.AdmissionRequest}
-	if req.AdmissionRequest.Namespace != "" {
-		ns := &corev1.Namespace{}
-		if err := h.client.Get(ctx, types.NamespacedName{Name: req.AdmissionRequest.Namespace}, ns); err != nil {
-			return nil, err
-		}
-		review.Namespace = ns
+	augmented, err := h.augmentedReview(ctx, req)
+	if err != nil {
+		return nil, err
 	}
 
-	resp, err := h.opa.Review(ctx, review, opa.Tracing(traceEnabled))
+	// the input and the reviewed object are built once and shared by every violation
+	review := target.NewSharedReview(augmented)
+	var prints *regoutil.Prints
+	reviewCtx := ctx
+	if regoutil.PrintLog.V(1).Enabled() {
+		reviewCtx, prints = regoutil.WithPrints(ctx)
+	}
+	resp, err := h.opa.Review(reviewCtx, review, opa.Tracing(traceEnabled))
+	review.Share(resp)
+	if prints != nil {
+		h.logPrints(ctx, augmented, prints.List(), review.Values(), decision)
+	}
+	if err == nil {
+		h.timings.sample(augmented)
+	}
 	if traceEnabled {
-		log.Info(resp.TraceDump())
+		// the trace holds the reviewed object as input
+		trace := redact.Message(resp.TraceDump(), review.Values())
+		if decision != nil {
+			decision.Traced = true
+			log.Info(trace, "decision_id", decision.ID)
+		} else {
+			log.Info(trace)
+		}
 	}
 	if dump {
 		dump, err := h.opa.Dump(ctx)
+		if err == nil {
+			dump, err = redact.JSON(dump)
+		}
 		if err != nil {
 			log.Error(err, "dump error")
 		} else {
@@ -317,3 +656,56 @@ func (h *validationHandler) reviewRequest(ctx context.Context, req admission.Req
 	}
 	return resp, err
 }
+
+// logPrints logs the print() output of a review, with the sensitive values of the
+// reviewed object redacted. A template's constraints are evaluated in a single query, so
+// the matching constraints of each template that printed are evaluated again one at a
+// time to tell whose output it is. Output that cannot be attributed is logged with the
+// template's kind only.
+func (h *validationHandler) logPrints(ctx context.Context, augmented target.AugmentedReview, prints []regoutil.Print, values []string, decision *Decision) {
+	if len(prints) == 0 {
+		return
+	}
+	printLog := regoutil.PrintLog
+	if decision != nil {
+		printLog = printLog.WithValues("decision_id", decision.ID)
+	}
+	l := printLog.V(1)
+	kinds := make(map[string]bool)
+	for _, p := range prints {
+		kinds[p.Kind] = true
+	}
+	if h.queries != nil {
+		tgt := &target.K8sValidationTarget{}
+		_, review, err := tgt.HandleReview(augmented)
+		var matching []*unstructured.Unstructured
+		if err == nil {
+			matching, err = h.queries.Matching(ctx, tgt.GetName(), review)
+		}
+		if err != nil {
+			log.Error(err, "could not attribute print() output to constraints")
+		}
+		attributed := make(map[string]bool)
+		for _, c := range matching {
+			if !kinds[c.GetKind()] {
+				continue
+			}
+			cctx, cprints := regoutil.WithPrints(ctx)
+			// a failing constraint may still have printed
+			_, _ = h.queries.Evaluate(cctx, tgt.GetName(), review, c)
+			for _, p := range cprints.List() {
+				l.Info(redact.Message(p.Msg, values), "template_kind", p.Kind, "row", p.Row,
+					logging.ConstraintKind, c.GetKind(), logging.ConstraintName, c.GetName())
+			}
+			attributed[c.GetKind()] = true
+		}
+		for kind := range attributed {
+			delete(kinds, kind)
+		}
+	}
+	for _, p := range prints {
+		if kinds[p.Kind] {
+			l.Info(redact.Message(p.Msg, values), "template_kind", p.Kind, "row", p.Row)
+		}
+	}
+}
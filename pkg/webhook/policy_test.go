@@ -2,22 +2,32 @@ package webhook
 
 import (
 	"context"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/ghodss/yaml"
+	"github.com/go-logr/logr"
 	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/local"
 	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
 	rtypes "github.com/open-policy-agent/frameworks/constraint/pkg/types"
 	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/controller/exemption"
+	gkdriver "github.com/open-policy-agent/gatekeeper/pkg/driver"
+	"github.com/open-policy-agent/gatekeeper/pkg/feed"
+	"github.com/open-policy-agent/gatekeeper/pkg/logging"
 	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	"github.com/open-policy-agent/gatekeeper/pkg/util/regoutil"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	k8schema "k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	atypes "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
@@ -41,6 +51,27 @@ spec:
         msg := "I'm sure this will work"
 `
 
+	httpSendTemplate = `
+apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: k8shttpsend
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sHttpSend
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+        package httpsend
+
+        violation[{"msg": msg}] {
+          resp := http.send({"method": "get", "url": "http://example.com"})
+          msg := resp.body
+        }
+`
+
 	goodRegoTemplate = `
 apiVersion: templates.gatekeeper.sh/v1beta1
 kind: ConstraintTemplate
@@ -152,6 +183,32 @@ spec:
       - apiGroups: [""]
         kinds: ["Pod"]
 `
+
+	goodSeverity = `
+apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sGoodRego
+metadata:
+  name: good-severity
+spec:
+  severity: medium
+  match:
+    kinds:
+      - apiGroups: [""]
+        kinds: ["Pod"]
+`
+
+	badSeverity = `
+apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sGoodRego
+metadata:
+  name: bad-severity
+spec:
+  severity: urgent
+  match:
+    kinds:
+      - apiGroups: [""]
+        kinds: ["Pod"]
+`
 )
 
 func makeOpaClient() (*client.Client, error) {
@@ -184,6 +241,11 @@ func TestTemplateValidation(t *testing.T) {
 			Template:      badRegoTemplate,
 			ErrorExpected: true,
 		},
+		{
+			Name:          "Disallowed Builtin",
+			Template:      httpSendTemplate,
+			ErrorExpected: true,
+		},
 	}
 	for _, tt := range tc {
 		t.Run(tt.Name, func(t *testing.T) {
@@ -262,6 +324,18 @@ func TestConstraintValidation(t *testing.T) {
 			Constraint:    badEnforcementAction,
 			ErrorExpected: true,
 		},
+		{
+			Name:          "Valid Constraint severity",
+			Template:      goodRegoTemplate,
+			Constraint:    goodSeverity,
+			ErrorExpected: false,
+		},
+		{
+			Name:          "Invalid Constraint severity",
+			Template:      goodRegoTemplate,
+			Constraint:    badSeverity,
+			ErrorExpected: true,
+		},
 	}
 	for _, tt := range tc {
 		t.Run(tt.Name, func(t *testing.T) {
@@ -419,7 +493,7 @@ func TestTracing(t *testing.T) {
 					},
 				},
 			}
-			resp, err := handler.reviewRequest(context.Background(), review)
+			resp, err := handler.reviewRequest(context.Background(), review, nil)
 			if err != nil {
 				t.Errorf("Unexpected error: %s", err)
 			}
@@ -540,10 +614,266 @@ func TestGetDenyMessages(t *testing.T) {
 					},
 				},
 			}
-			msgs := handler.getDenyMessages(tt.Result, review)
+			msgs := handler.getDenyMessages(tt.Result, review, "")
 			if len(msgs) != tt.ExpectedMsgCount {
 				t.Errorf("expected count = %d; actual count = %d", tt.ExpectedMsgCount, len(msgs))
 			}
 		})
 	}
 }
+
+func TestGetDenyMessagesAnnotations(t *testing.T) {
+	c := newConstraint("Foo", "ph", "deny", t)
+	c.SetAnnotations(map[string]string{"severity": "high", "team": "a"})
+	res := []*rtypes.Result{{Msg: "test", Constraint: c, EnforcementAction: "deny"}}
+	handler := validationHandler{annotations: []string{"severity", "example.com/ticket"}}
+	msgs := handler.getDenyMessages(res, atypes.Request{}, "")
+	want := "[denied by ph] test (severity: high)"
+	if len(msgs) != 1 || msgs[0] != want {
+		t.Errorf("getDenyMessages() = %v, wanted [%s]", msgs, want)
+	}
+}
+
+func TestPublishViolations(t *testing.T) {
+	res := []*rtypes.Result{
+		{Msg: "denied", Constraint: newConstraint("Foo", "deny", "deny", t), EnforcementAction: "deny"},
+		{Msg: "audited", Constraint: newConstraint("Foo", "audit", "audit", t), EnforcementAction: "audit"},
+	}
+	f := feed.New(func() int { return 10 })
+	sub := f.Subscribe()
+	defer sub.Close()
+	handler := validationHandler{feed: f}
+	req := atypes.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Namespace: "team-a",
+		Name:      "web",
+		Operation: admissionv1beta1.Create,
+	}}
+	handler.publishViolations(res, req, "decision")
+	if got := len(sub.Events()); got != 1 {
+		t.Fatalf("published %d violations, wanted 1", got)
+	}
+	e := <-sub.Events()
+	want := feed.Event{
+		Time:              e.Time,
+		Source:            feed.SourceAdmission,
+		Type:              feed.TypeViolation,
+		ConstraintKind:    "Foo",
+		ConstraintName:    "deny",
+		EnforcementAction: "deny",
+		Message:           "denied",
+		Kind:              "Pod",
+		Namespace:         "team-a",
+		Name:              "web",
+		Operation:         "CREATE",
+		DecisionID:        "decision",
+	}
+	if e != want {
+		t.Errorf("published %+v, wanted %+v", e, want)
+	}
+
+	dryRun := true
+	req.AdmissionRequest.DryRun = &dryRun
+	handler.publishViolations(res, req, "dry-run")
+	if got := len(sub.Events()); got != 0 {
+		t.Errorf("published %d violations of a dry-run request, wanted none", got)
+	}
+}
+
+func TestIsDryRun(t *testing.T) {
+	yes, no := true, false
+	tc := []struct {
+		Name     string
+		DryRun   *bool
+		Expected bool
+	}{
+		{Name: "Unset"},
+		{Name: "False", DryRun: &no},
+		{Name: "True", DryRun: &yes, Expected: true},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			req := atypes.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{DryRun: tt.DryRun}}
+			if got := isDryRun(req); got != tt.Expected {
+				t.Errorf("isDryRun() = %v, want %v", got, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestDeprecatedVersionWarning(t *testing.T) {
+	tc := []struct {
+		Name      string
+		Kind      metav1.GroupVersionKind
+		Operation admissionv1beta1.Operation
+		Warned    bool
+	}{
+		{
+			Name:      "Deprecated constraint version",
+			Kind:      metav1.GroupVersionKind{Group: "constraints.gatekeeper.sh", Version: "v1alpha1", Kind: "K8sRequiredLabels"},
+			Operation: admissionv1beta1.Create,
+			Warned:    true,
+		},
+		{
+			Name:      "Storage version",
+			Kind:      metav1.GroupVersionKind{Group: "constraints.gatekeeper.sh", Version: "v1beta1", Kind: "K8sRequiredLabels"},
+			Operation: admissionv1beta1.Update,
+		},
+		{
+			Name:      "Deleting through a deprecated version",
+			Kind:      metav1.GroupVersionKind{Group: "constraints.gatekeeper.sh", Version: "v1alpha1", Kind: "K8sRequiredLabels"},
+			Operation: admissionv1beta1.Delete,
+		},
+		{
+			Name:      "Other group",
+			Kind:      metav1.GroupVersionKind{Group: "example.com", Version: "v1alpha1", Kind: "Widget"},
+			Operation: admissionv1beta1.Create,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			req := atypes.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{Kind: tt.Kind, Operation: tt.Operation}}
+			if got := deprecatedVersionWarning(req); (got != "") != tt.Warned {
+				t.Errorf("deprecatedVersionWarning() = %q, wanted a warning: %v", got, tt.Warned)
+			}
+		})
+	}
+}
+
+func TestDropExempt(t *testing.T) {
+	exempted := &rtypes.Result{Msg: "test", Constraint: newConstraint("Foo", "exempted", "deny", t), EnforcementAction: "deny"}
+	other := &rtypes.Result{Msg: "test", Constraint: newConstraint("Foo", "other", "deny", t), EnforcementAction: "deny"}
+	cache := exemption.NewExemptionsCache()
+	cache.Add("legacy", v1alpha1.ExemptionSpec{
+		ConstraintKind: "Foo",
+		ConstraintName: "exempted",
+		Match:          v1alpha1.ExemptionMatch{Namespace: "legacy"},
+		ExpiresAt:      metav1.NewTime(time.Now().Add(time.Hour)),
+	})
+	cache.Add("team-a", v1alpha1.ExemptionSpec{
+		ConstraintKind: "Foo",
+		ConstraintName: "exempted",
+		Match:          v1alpha1.ExemptionMatch{Namespace: "team-a/*"},
+		ExpiresAt:      metav1.NewTime(time.Now().Add(time.Hour)),
+	})
+	child := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "team-a-web",
+		Labels: map[string]string{"team-a.tree.hnc.x-k8s.io/depth": "1"},
+	}}
+	prod := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod"}}
+	h := &validationHandler{exemptions: cache, client: fake.NewFakeClient(child, prod)}
+
+	req := atypes.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{Namespace: "legacy", Name: "pod"}}
+	if got := h.dropExempt(context.TODO(), []*rtypes.Result{exempted, other}, req); len(got) != 1 || got[0] != other {
+		t.Errorf("dropExempt() = %v, wanted only the violation of the other constraint", got)
+	}
+	req.AdmissionRequest.Namespace = "team-a-web"
+	if got := h.dropExempt(context.TODO(), []*rtypes.Result{exempted, other}, req); len(got) != 1 || got[0] != other {
+		t.Errorf("dropExempt() = %v, wanted the violation in the exempted subtree dropped", got)
+	}
+	req.AdmissionRequest.Namespace = "prod"
+	if got := h.dropExempt(context.TODO(), []*rtypes.Result{exempted, other}, req); len(got) != 2 {
+		t.Errorf("dropExempt() kept %d violations outside the exempted namespace, wanted 2", len(got))
+	}
+}
+
+const printTemplate = `
+apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: k8sprint
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sPrint
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+        package k8sprint
+
+        violation[{"msg": "denied"}] {
+          print(sprintf("checking %v for %v", [input.review.object.metadata.name, input.parameters.team]))
+        }
+`
+
+// printRecorder records the messages and key/value pairs logged to it
+type printRecorder struct {
+	values  []interface{}
+	entries *[][]interface{}
+}
+
+func (r printRecorder) Info(msg string, keysAndValues ...interface{}) {
+	entry := append([]interface{}{msg}, r.values...)
+	*r.entries = append(*r.entries, append(entry, keysAndValues...))
+}
+func (r printRecorder) Enabled() bool                                  { return true }
+func (r printRecorder) Error(err error, msg string, kv ...interface{}) {}
+func (r printRecorder) V(level int) logr.InfoLogger                    { return r }
+func (r printRecorder) WithName(name string) logr.Logger               { return r }
+func (r printRecorder) WithValues(kv ...interface{}) logr.Logger {
+	return printRecorder{values: append(append([]interface{}(nil), r.values...), kv...), entries: r.entries}
+}
+
+func TestLogPrints(t *testing.T) {
+	var entries [][]interface{}
+	old := regoutil.PrintLog
+	regoutil.PrintLog = printRecorder{entries: &entries}
+	defer func() { regoutil.PrintLog = old }()
+
+	ctx := context.Background()
+	d := local.New(local.Tracing(false))
+	backend, err := client.NewBackend(client.Driver(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opa, err := backend.NewClient(client.Targets(&target.K8sValidationTarget{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	queries, err := gkdriver.NewQueries(ctx, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	templ := &templv1beta1.ConstraintTemplate{}
+	if err := yaml.Unmarshal([]byte(printTemplate), templ); err != nil {
+		t.Fatal(err)
+	}
+	unversioned := &templates.ConstraintTemplate{}
+	if err := runtimeScheme.Convert(templ, unversioned, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := opa.AddTemplate(ctx, unversioned); err != nil {
+		t.Fatal(err)
+	}
+	for _, team := range []string{"a", "b"} {
+		c := newConstraint("K8sPrint", "team-"+team, "deny", t)
+		if err := unstructured.SetNestedField(c.Object, team, "spec", "parameters", "team"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := opa.AddConstraint(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := &validationHandler{opa: opa, queries: queries, injectedConfig: &v1alpha1.Config{}}
+	req := atypes.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Kind:   metav1.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+		Object: runtime.RawExtension{Raw: []byte(`{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "prod"}}`)},
+	}}
+	if _, err := h.reviewRequest(ctx, req, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"team-a": "checking prod for a", "team-b": "checking prod for b"}
+	got := make(map[string]string)
+	for _, entry := range entries {
+		for i := 1; i+1 < len(entry); i += 2 {
+			if entry[i] == logging.ConstraintName {
+				got[entry[i+1].(string)] = entry[0].(string)
+			}
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("logged %v by constraint, want %v", got, want)
+	}
+}
@@ -0,0 +1,190 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	oversizeDeny  = "deny"
+	oversizeAllow = "allow"
+)
+
+var (
+	maxRequestBytes       = flag.Int64("max-request-bytes", 0, "largest admission request body, in bytes, the constraint webhook reviews. 0 for no limit")
+	oversizeRequestPolicy = flag.String("oversize-request-policy", oversizeDeny, "how the constraint webhook answers requests larger than --max-request-bytes: deny or allow")
+)
+
+// sizeLimiter answers admission requests larger than max without decoding them into
+// memory, allowing or denying them according to policy
+type sizeLimiter struct {
+	max   int64
+	allow bool
+	next  http.Handler
+}
+
+// limitRequestSize wraps next with the limit set by --max-request-bytes
+func limitRequestSize(next http.Handler) (http.Handler, error) {
+	if *maxRequestBytes <= 0 {
+		return next, nil
+	}
+	switch *oversizeRequestPolicy {
+	case oversizeDeny, oversizeAllow:
+	default:
+		return nil, fmt.Errorf("invalid --oversize-request-policy %q, must be %s or %s", *oversizeRequestPolicy, oversizeDeny, oversizeAllow)
+	}
+	return &sizeLimiter{
+		max:   *maxRequestBytes,
+		allow: *oversizeRequestPolicy == oversizeAllow,
+		next:  next,
+	}, nil
+}
+
+func (l *sizeLimiter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ContentLength > l.max {
+		l.oversize(w, r.Body)
+		return
+	}
+	buf := &bytes.Buffer{}
+	n, err := io.CopyN(buf, r.Body, l.max+1)
+	if err != nil && err != io.EOF {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if n > l.max {
+		// only the request UID is needed, so the rest of the body is streamed
+		l.oversize(w, io.MultiReader(buf, r.Body))
+		return
+	}
+	r.Body = ioutil.NopCloser(buf)
+	l.next.ServeHTTP(w, r)
+}
+
+func (l *sizeLimiter) oversize(w http.ResponseWriter, body io.Reader) {
+	uid, err := requestUID(body)
+	if err != nil {
+		log.Error(err, "could not read the UID of an oversize admission request")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Info("oversize admission request", "uid", uid, "limit", l.max, "allowed", l.allow)
+	resp := &admissionv1beta1.AdmissionResponse{
+		UID:     uid,
+		Allowed: l.allow,
+	}
+	if !l.allow {
+		resp.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusRequestEntityTooLarge,
+			Reason:  metav1.StatusReasonRequestEntityTooLarge,
+			Message: fmt.Sprintf("admission request is larger than the %d bytes Gatekeeper reviews", l.max),
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&admissionv1beta1.AdmissionReview{Response: resp}); err != nil {
+		log.Error(err, "could not write admission response")
+	}
+}
+
+// requestUID returns request.uid from an AdmissionReview, reading one token at a time so
+// the object is never held in memory as a whole
+func requestUID(r io.Reader) (types.UID, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return "", err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return "", err
+		}
+		if key != "request" {
+			if err := skipValue(dec); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if err := expectDelim(dec, '{'); err != nil {
+			return "", err
+		}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return "", err
+			}
+			if key != "uid" {
+				if err := skipValue(dec); err != nil {
+					return "", err
+				}
+				continue
+			}
+			tok, err := dec.Token()
+			if err != nil {
+				return "", err
+			}
+			uid, ok := tok.(string)
+			if !ok {
+				return "", errors.New("request.uid is not a string")
+			}
+			return types.UID(uid), nil
+		}
+		return "", errors.New("admission request has no uid")
+	}
+	return "", errors.New("admission review has no request")
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("expected %v, got %v", want, tok)
+	}
+	return nil
+}
+
+// skipValue consumes the next value, including any nested arrays and objects
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+)
+
+func TestRequestUID(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Body     string
+		Expected string
+		Err      bool
+	}{
+		{
+			Name:     "UID first",
+			Body:     `{"request":{"uid":"abc","object":{"data":{"a":"b"}}}}`,
+			Expected: "abc",
+		},
+		{
+			Name:     "UID after object",
+			Body:     `{"kind":"AdmissionReview","request":{"object":{"data":{"a":["b",{"c":1}]}},"uid":"abc"}}`,
+			Expected: "abc",
+		},
+		{
+			Name: "No request",
+			Body: `{"kind":"AdmissionReview"}`,
+			Err:  true,
+		},
+		{
+			Name: "Truncated",
+			Body: `{"request":{"object":{"data":`,
+			Err:  true,
+		},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			uid, err := requestUID(strings.NewReader(tt.Body))
+			if (err != nil) != tt.Err {
+				t.Fatalf("requestUID() error = %v, want error %v", err, tt.Err)
+			}
+			if string(uid) != tt.Expected {
+				t.Errorf("requestUID() = %q, want %q", uid, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestSizeLimiter(t *testing.T) {
+	small := `{"request":{"uid":"small"}}`
+	large := `{"request":{"uid":"large","object":{"data":{"key":"` + strings.Repeat("x", 100) + `"}}}}`
+	tc := []struct {
+		Name      string
+		Allow     bool
+		Body      string
+		ChunkSize bool
+		Reviewed  bool
+		Allowed   bool
+	}{
+		{Name: "Under limit", Body: small, Reviewed: true},
+		{Name: "Over limit denied", Body: large},
+		{Name: "Over limit allowed", Allow: true, Body: large, Allowed: true},
+		{Name: "Over limit without content length", Body: large, ChunkSize: true},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			reviewed := false
+			l := &sizeLimiter{max: 64, allow: tt.Allow, next: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reviewed = true
+			})}
+			req := httptest.NewRequest(http.MethodPost, "/v1/admit", strings.NewReader(tt.Body))
+			if tt.ChunkSize {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			l.ServeHTTP(rec, req)
+			if reviewed != tt.Reviewed {
+				t.Fatalf("reviewed = %v, want %v", reviewed, tt.Reviewed)
+			}
+			if reviewed {
+				return
+			}
+			review := &admissionv1beta1.AdmissionReview{}
+			if err := json.NewDecoder(rec.Body).Decode(review); err != nil {
+				t.Fatal(err)
+			}
+			if review.Response == nil || review.Response.UID != "large" {
+				t.Fatalf("response = %+v, want UID large", review.Response)
+			}
+			if review.Response.Allowed != tt.Allowed {
+				t.Errorf("allowed = %v, want %v", review.Response.Allowed, tt.Allowed)
+			}
+			if !tt.Allowed && review.Response.Result.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("code = %d, want %d", review.Response.Result.Code, http.StatusRequestEntityTooLarge)
+			}
+		})
+	}
+}
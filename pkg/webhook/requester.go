@@ -0,0 +1,146 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	controllerRequester = "controller"
+	humanRequester      = "human"
+	otherRequester      = "other"
+
+	// userHashLength is the number of hex digits of a username's hash kept as a label
+	userHashLength = 12
+
+	// usernameHashKeySecret holds the key usernames are hashed with, so the hashes of a
+	// cluster cannot be matched against those of known usernames computed elsewhere
+	usernameHashKeySecret = "gatekeeper-username-hash-key"
+	usernameHashKeyData   = "key"
+	usernameHashKeyLength = 32
+)
+
+var (
+	requesterClasses = flag.String("denial-metrics-requester-classes", "", "comma-separated list of class=regexp. Denied requests are counted by the first class whose regular expression matches the requesting username, or other. Defaults to controller for system: users, such as service accounts, and human for everyone else")
+	hashUsernames    = flag.Bool("denial-metrics-hash-usernames", false, "label denied requests with a hash of the requesting username. Adds a metric series per user that is denied")
+)
+
+type requesterClass struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// requesterClassifier sorts the users whose requests are denied into the classes set by
+// --denial-metrics-requester-classes. Admission requests do not carry the client's user
+// agent, so classes match the username.
+type requesterClassifier struct {
+	classes []requesterClass
+	// hashKey is the HMAC key usernames are hashed with. Usernames are not hashed if nil
+	hashKey []byte
+}
+
+// newRequesterClassifier parses a comma-separated list of class=regexp. An empty spec
+// tells controllers, which use system: users, from humans. Usernames are hashed with
+// hashKey, unless it is nil.
+func newRequesterClassifier(spec string, hashKey []byte) (*requesterClassifier, error) {
+	c := &requesterClassifier{hashKey: hashKey}
+	if spec == "" {
+		c.classes = []requesterClass{
+			{name: controllerRequester, re: regexp.MustCompile("^system:")},
+			{name: humanRequester, re: regexp.MustCompile("")},
+		}
+		return c, nil
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid --denial-metrics-requester-classes entry %q, must be class=regexp", entry)
+		}
+		re, err := regexp.Compile(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid --denial-metrics-requester-classes regexp for %s: %v", parts[0], err)
+		}
+		c.classes = append(c.classes, requesterClass{name: parts[0], re: re})
+	}
+	return c, nil
+}
+
+// classify returns the class of user and, if usernames are hashed, the hash of its name
+func (c *requesterClassifier) classify(user authenticationv1.UserInfo) (class, userHash string) {
+	class = otherRequester
+	for _, rc := range c.classes {
+		if rc.re.MatchString(user.Username) {
+			class = rc.name
+			break
+		}
+	}
+	if c.hashKey != nil {
+		mac := hmac.New(sha256.New, c.hashKey)
+		mac.Write([]byte(user.Username))
+		userHash = hex.EncodeToString(mac.Sum(nil))[:userHashLength]
+	}
+	return class, userHash
+}
+
+// usernameHashKey returns the key of this install from the usernameHashKeySecret in
+// Gatekeeper's namespace, creating the secret with a random key if it does not exist.
+// Every replica reads the same key, so a user has the same hash on each of them.
+func usernameHashKey(ctx context.Context, c client.Client) ([]byte, error) {
+	name := types.NamespacedName{Namespace: util.GetNamespace(), Name: usernameHashKeySecret}
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, name, secret)
+	if apierrors.IsNotFound(err) {
+		key := make([]byte, usernameHashKeyLength)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: name.Namespace, Name: name.Name},
+			Data:       map[string][]byte{usernameHashKeyData: key},
+		}
+		err = c.Create(ctx, secret)
+		if apierrors.IsAlreadyExists(err) {
+			// another replica created it first
+			secret = &corev1.Secret{}
+			err = c.Get(ctx, name, secret)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading the username hash key from secret %s: %v", name, err)
+	}
+	key := secret.Data[usernameHashKeyData]
+	if len(key) == 0 {
+		return nil, fmt.Errorf("secret %s has no %s", name, usernameHashKeyData)
+	}
+	return key, nil
+}
@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRequesterClassifier(t *testing.T) {
+	tc := []struct {
+		Name      string
+		Spec      string
+		Hash      bool
+		Username  string
+		WantClass string
+		WantHash  bool
+		WantErr   bool
+	}{
+		{Name: "Default service account", Username: "system:serviceaccount:ci:deployer", WantClass: "controller"},
+		{Name: "Default user", Username: "jane@example.com", WantClass: "human"},
+		{Name: "Configured class", Spec: "ci=^system:serviceaccount:ci:, controller=^system:", Username: "system:serviceaccount:ci:deployer", WantClass: "ci"},
+		{Name: "Later class", Spec: "ci=^system:serviceaccount:ci:,controller=^system:", Username: "system:kube-scheduler", WantClass: "controller"},
+		{Name: "No match", Spec: "ci=^system:serviceaccount:ci:", Username: "jane@example.com", WantClass: "other"},
+		{Name: "Hashed", Hash: true, Username: "jane@example.com", WantClass: "human", WantHash: true},
+		{Name: "Missing regexp", Spec: "ci", WantErr: true},
+		{Name: "Bad regexp", Spec: "ci=(", WantErr: true},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			var key []byte
+			if tt.Hash {
+				key = []byte("install-key")
+			}
+			c, err := newRequesterClassifier(tt.Spec, key)
+			if (err != nil) != tt.WantErr {
+				t.Fatalf("err = %v, wanted error: %v", err, tt.WantErr)
+			}
+			if err != nil {
+				return
+			}
+			class, userHash := c.classify(authenticationv1.UserInfo{Username: tt.Username})
+			if class != tt.WantClass {
+				t.Errorf("class = %q, wanted %q", class, tt.WantClass)
+			}
+			if (userHash != "") != tt.WantHash {
+				t.Errorf("user hash = %q, wanted hash: %v", userHash, tt.WantHash)
+			}
+			if tt.WantHash {
+				if len(userHash) != userHashLength {
+					t.Errorf("user hash %q has %d digits, wanted %d", userHash, len(userHash), userHashLength)
+				}
+				if _, again := c.classify(authenticationv1.UserInfo{Username: tt.Username}); again != userHash {
+					t.Errorf("hash %q changed to %q", userHash, again)
+				}
+				if _, other := c.classify(authenticationv1.UserInfo{Username: "someone-else"}); other == userHash {
+					t.Errorf("different users share hash %q", userHash)
+				}
+				otherInstall, err := newRequesterClassifier(tt.Spec, []byte("other-install-key"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, other := otherInstall.classify(authenticationv1.UserInfo{Username: tt.Username}); other == userHash {
+					t.Errorf("installs with different keys share hash %q", userHash)
+				}
+			}
+		})
+	}
+}
+
+func TestUsernameHashKey(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewFakeClient()
+	key, err := usernameHashKey(ctx, c)
+	if err != nil {
+		t.Fatalf("usernameHashKey() error = %v", err)
+	}
+	if len(key) != usernameHashKeyLength {
+		t.Errorf("key has %d bytes, wanted %d", len(key), usernameHashKeyLength)
+	}
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: util.GetNamespace(), Name: usernameHashKeySecret}, secret); err != nil {
+		t.Fatalf("secret was not created: %v", err)
+	}
+	// other replicas and restarts read the same key
+	again, err := usernameHashKey(ctx, c)
+	if err != nil {
+		t.Fatalf("usernameHashKey() error = %v", err)
+	}
+	if !bytes.Equal(key, again) {
+		t.Error("the key changed once the secret existed")
+	}
+}
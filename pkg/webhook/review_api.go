@@ -0,0 +1,278 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	opa "github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/gatekeeper/pkg/controller/exemption"
+	"github.com/open-policy-agent/gatekeeper/pkg/driver"
+	"github.com/open-policy-agent/gatekeeper/pkg/feed"
+	"github.com/open-policy-agent/gatekeeper/pkg/lint"
+	"github.com/open-policy-agent/gatekeeper/pkg/redact"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	"github.com/pkg/errors"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var (
+	reviewAPIPort     = flag.Int("review-api-port", 0, "port for the review and debug API. The API is disabled if 0")
+	reviewAPICertDir  = flag.String("review-api-cert-dir", "", "directory holding tls.crt and tls.key for the review and debug API. Defaults to the webhook's --cert-dir")
+	reviewAPIClientCA = flag.String("review-api-client-ca", "", "PEM bundle of the CAs that sign client certificates for the review and debug API. Required if the API is enabled")
+)
+
+const (
+	reviewPath = "/v1/review"
+	dumpPath   = "/v1/debug/dump"
+	lintPath   = "/v1/lint"
+	streamPath = "/v1/violations/stream"
+)
+
+func init() {
+	AddToManagerFuncs = append(AddToManagerFuncs, AddReviewAPI)
+}
+
+// reviewResult is a violation reported by the review API
+type reviewResult struct {
+	ConstraintKind    string `json:"constraintKind"`
+	ConstraintName    string `json:"constraintName"`
+	ConstraintOwner   string `json:"constraintOwner,omitempty"`
+	EnforcementAction string `json:"enforcementAction"`
+	Message           string `json:"message"`
+}
+
+// AddReviewAPI serves the review and debug API on its own port. Unlike the webhooks,
+// which only the API server calls, every client must present a certificate signed by
+// --review-api-client-ca.
+func AddReviewAPI(mgr manager.Manager, opa *opa.Client, _ *driver.Queries, webhookSrv *Server) error {
+	if *reviewAPIPort == 0 {
+		return nil
+	}
+	if *reviewAPIClientCA == "" {
+		return errors.New("--review-api-client-ca must be set to serve the review API")
+	}
+	pem, err := ioutil.ReadFile(*reviewAPIClientCA)
+	if err != nil {
+		return errors.Wrap(err, "while reading the review API client CA")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("no certificates found in %s", *reviewAPIClientCA)
+	}
+	certDir := *reviewAPICertDir
+	if certDir == "" {
+		certDir = webhookSrv.CertDir
+	}
+	srv, err := NewServer(*reviewAPIPort, certDir)
+	if err != nil {
+		return err
+	}
+	srv.ClientCAs = pool
+
+	h := &reviewHandler{validationHandler: &validationHandler{
+		opa:        opa,
+		client:     mgr.GetClient(),
+		exemptions: exemption.Cache,
+	}}
+	srv.Register(reviewPath, http.HandlerFunc(h.review))
+	srv.Register(dumpPath, http.HandlerFunc(h.dump))
+	srv.Register(lintPath, http.HandlerFunc(lintTemplate))
+	srv.Register(streamPath, &violationStream{feed: feed.Violations, stop: srv.Stopping()})
+	return mgr.Add(srv)
+}
+
+type reviewHandler struct {
+	*validationHandler
+}
+
+// review evaluates the request in an AdmissionReview against all constraints and
+// returns the violations without admitting or denying anything
+func (h *reviewHandler) review(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	limitBody(w, r)
+	ar := &admissionv1beta1.AdmissionReview{}
+	if err := json.NewDecoder(r.Body).Decode(ar); err != nil {
+		bodyError(w, err)
+		return
+	}
+	if ar.Request == nil {
+		http.Error(w, "AdmissionReview has no request", http.StatusBadRequest)
+		return
+	}
+	req := admission.Request{AdmissionRequest: *ar.Request}
+	resp, err := h.reviewRequest(r.Context(), req, nil)
+	if err != nil {
+		log.Error(err, "error executing query for the review API")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	results := []reviewResult{}
+	for _, res := range h.dropExempt(r.Context(), resp.Results(), req) {
+		results = append(results, reviewResult{
+			ConstraintKind:    res.Constraint.GetKind(),
+			ConstraintName:    res.Constraint.GetName(),
+			ConstraintOwner:   util.GetOwner(res.Constraint),
+			EnforcementAction: res.EnforcementAction,
+			Message:           res.Msg,
+		})
+	}
+	writeJSON(w, results)
+}
+
+// dump returns the templates, constraints and data loaded into OPA, with the synced
+// objects redacted
+func (h *reviewHandler) dump(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	dump, err := h.opa.Dump(r.Context())
+	if err == nil {
+		dump, err = redact.JSON(dump)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write([]byte(dump)); err != nil {
+		log.Error(err, "could not write the debug dump")
+	}
+}
+
+// lintTemplate checks the ConstraintTemplate in the request body, as JSON or YAML,
+// without loading it
+func lintTemplate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	limitBody(w, r)
+	obj := &unstructured.Unstructured{}
+	if err := yaml.NewYAMLOrJSONDecoder(r.Body, 4096).Decode(&obj.Object); err != nil {
+		bodyError(w, err)
+		return
+	}
+	if obj.GetKind() != "ConstraintTemplate" {
+		http.Error(w, fmt.Sprintf("expected a ConstraintTemplate, got kind %q", obj.GetKind()), http.StatusBadRequest)
+		return
+	}
+	diags, err := lint.Template(r.Context(), obj)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if diags == nil {
+		diags = []lint.Diagnostic{}
+	}
+	writeJSON(w, lint.Result{Template: obj.GetName(), Diagnostics: diags})
+}
+
+// errBodyTooLarge is the message of the error http.MaxBytesReader returns once the body
+// exceeds its limit
+const errBodyTooLarge = "http: request body too large"
+
+// limitBody caps the body of r at --max-request-bytes, as the constraint webhook does, so
+// the review API cannot be made to decode larger objects
+func limitBody(w http.ResponseWriter, r *http.Request) {
+	if *maxRequestBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, *maxRequestBytes)
+	}
+}
+
+// bodyError answers a request whose body could not be decoded: 413 if the body exceeded
+// --max-request-bytes, 400 otherwise
+func bodyError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), errBodyTooLarge) {
+		http.Error(w, fmt.Sprintf("request body is larger than %d bytes", *maxRequestBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+// streamEvent is a violation written to the stream
+type streamEvent struct {
+	feed.Event
+	// Dropped is the number of violations dropped since the previous one was written,
+	// because the client did not keep up
+	Dropped int64 `json:"dropped,omitempty"`
+}
+
+// violationStream writes each violation published after the request was made as a line
+// of JSON, until the client disconnects or the server stops. The source and namespace
+// query parameters only keep violations from that source or in that namespace.
+type violationStream struct {
+	feed *feed.Feed
+	stop <-chan struct{}
+}
+
+func (s *violationStream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	source := r.URL.Query().Get("source")
+	namespace := r.URL.Query().Get("namespace")
+	sub := s.feed.Subscribe()
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.stop:
+			return
+		case e := <-sub.Events():
+			if (source != "" && e.Source != source) || (namespace != "" && e.Namespace != namespace) {
+				continue
+			}
+			if err := enc.Encode(streamEvent{Event: e, Dropped: sub.Dropped()}); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error(err, "could not write the review API response")
+	}
+}
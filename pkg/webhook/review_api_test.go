@@ -0,0 +1,147 @@
+package webhook
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/gatekeeper/api/v1alpha1"
+	"github.com/open-policy-agent/gatekeeper/pkg/feed"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const lintTemplateYAML = `
+apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: k8sdenyall
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sDenyAll
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+        package k8sdenyall
+
+        violation[{"msg": "denied"}] {
+          true
+        }
+`
+
+func TestReviewAPI(t *testing.T) {
+	opa, err := makeOpaClient()
+	if err != nil {
+		t.Fatalf("Could not initialize OPA: %s", err)
+	}
+	h := &reviewHandler{validationHandler: &validationHandler{opa: opa, injectedConfig: &v1alpha1.Config{}}}
+
+	ar := &admissionv1beta1.AdmissionReview{Request: &admissionv1beta1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+		Operation: admissionv1beta1.Create,
+		Name:      "test",
+		Object:    runtime.RawExtension{Raw: []byte(`{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "test"}}`)},
+	}}
+	body, err := json.Marshal(ar)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := []struct {
+		Name     string
+		Method   string
+		Path     string
+		Body     []byte
+		Handler  http.HandlerFunc
+		Expected int
+	}{
+		{Name: "Review", Method: http.MethodPost, Path: reviewPath, Body: body, Handler: h.review, Expected: http.StatusOK},
+		{Name: "Review wrong method", Method: http.MethodGet, Path: reviewPath, Handler: h.review, Expected: http.StatusMethodNotAllowed},
+		{Name: "Review no request", Method: http.MethodPost, Path: reviewPath, Body: []byte(`{}`), Handler: h.review, Expected: http.StatusBadRequest},
+		{Name: "Dump", Method: http.MethodGet, Path: dumpPath, Handler: h.dump, Expected: http.StatusOK},
+		{Name: "Lint", Method: http.MethodPost, Path: lintPath, Body: []byte(lintTemplateYAML), Handler: lintTemplate, Expected: http.StatusOK},
+		{Name: "Lint wrong method", Method: http.MethodGet, Path: lintPath, Handler: lintTemplate, Expected: http.StatusMethodNotAllowed},
+		{Name: "Lint wrong kind", Method: http.MethodPost, Path: lintPath, Body: []byte(`{"apiVersion": "v1", "kind": "Namespace"}`), Handler: lintTemplate, Expected: http.StatusBadRequest},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.Handler(rec, httptest.NewRequest(tt.Method, tt.Path, bytes.NewReader(tt.Body)))
+			if rec.Code != tt.Expected {
+				t.Errorf("status = %d, wanted %d: %s", rec.Code, tt.Expected, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestReviewAPIBodyLimit(t *testing.T) {
+	defer func(max int64) { *maxRequestBytes = max }(*maxRequestBytes)
+	*maxRequestBytes = 64
+
+	h := &reviewHandler{validationHandler: &validationHandler{injectedConfig: &v1alpha1.Config{}}}
+	tc := []struct {
+		Name     string
+		Body     string
+		Handler  http.HandlerFunc
+		Expected int
+	}{
+		{Name: "Review too large", Body: `{"request": {"uid": "` + strings.Repeat("a", 64) + `"}}`, Handler: h.review, Expected: http.StatusRequestEntityTooLarge},
+		{Name: "Review invalid", Body: `{`, Handler: h.review, Expected: http.StatusBadRequest},
+		{Name: "Lint too large", Body: lintTemplateYAML, Handler: lintTemplate, Expected: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.Handler(rec, httptest.NewRequest(http.MethodPost, reviewPath, strings.NewReader(tt.Body)))
+			if rec.Code != tt.Expected {
+				t.Errorf("status = %d, wanted %d: %s", rec.Code, tt.Expected, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestViolationStream(t *testing.T) {
+	f := feed.New(func() int { return 10 })
+	stop := make(chan struct{})
+	srv := httptest.NewServer(&violationStream{feed: f, stop: stop})
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?source=admission")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, wanted %d", resp.StatusCode, http.StatusOK)
+	}
+	// the headers are sent once the client is subscribed
+	if !f.Active() {
+		t.Fatal("the stream did not subscribe to the feed")
+	}
+	f.Publish(feed.Event{Source: feed.SourceAudit, Name: "filtered"})
+	f.Publish(feed.Event{Source: feed.SourceAdmission, Name: "web"})
+
+	scanner := bufio.NewScanner(resp.Body)
+	if !scanner.Scan() {
+		t.Fatalf("stream ended early: %v", scanner.Err())
+	}
+	e := &streamEvent{}
+	if err := json.Unmarshal(scanner.Bytes(), e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Name != "web" || e.Source != feed.SourceAdmission {
+		t.Errorf("got %+v, wanted the admission violation of web", e)
+	}
+
+	close(stop)
+	if scanner.Scan() {
+		t.Errorf("got %q after the server stopped, wanted the stream to end", scanner.Text())
+	}
+}
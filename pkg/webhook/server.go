@@ -0,0 +1,235 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	cliflag "k8s.io/component-base/cli/flag"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+)
+
+var (
+	tlsMinVersion   = flag.String("tls-min-version", "", fmt.Sprintf("minimum TLS version accepted by the webhook server. One of %s. Defaults to the Go default", strings.Join(cliflag.TLSPossibleVersions(), ", ")))
+	tlsCipherSuites = flag.String("tls-cipher-suites", "", "comma-separated list of cipher suites the webhook server may use with TLS 1.2 and below. Defaults to the Go defaults")
+)
+
+// requestLatency is the latency histogram reported by the controller-runtime webhook
+// server, which Server replaces
+var requestLatency = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name: "controller_runtime_webhook_latency_seconds",
+		Help: "Histogram of the latency of processing admission requests",
+	},
+	[]string{"webhook"},
+)
+
+func init() {
+	if err := metrics.Registry.Register(requestLatency); err != nil {
+		are, ok := err.(prometheus.AlreadyRegisteredError)
+		if !ok {
+			panic(err)
+		}
+		requestLatency = are.ExistingCollector.(*prometheus.HistogramVec)
+	}
+}
+
+// Server serves the admission webhooks over TLS. It takes the place of the
+// controller-runtime webhook server, which does not allow the TLS configuration to
+// be changed.
+type Server struct {
+	Port    int
+	CertDir string
+
+	// MinVersion and CipherSuites are passed to the tls.Config; zero values use the Go defaults
+	MinVersion   uint16
+	CipherSuites []uint16
+	// ClientCAs, if set, requires clients to present a certificate signed by one of the CAs
+	ClientCAs *x509.CertPool
+
+	mux       *http.ServeMux
+	webhooks  map[string]http.Handler
+	setFields inject.Func
+	// stopping is closed when the server starts shutting down
+	stopping chan struct{}
+}
+
+// NewServer returns a Server listening on port using the certificate in certDir, with
+// the TLS settings given by --tls-min-version and --tls-cipher-suites
+func NewServer(port int, certDir string) (*Server, error) {
+	s := &Server{
+		Port:     port,
+		CertDir:  certDir,
+		mux:      http.NewServeMux(),
+		webhooks: make(map[string]http.Handler),
+		stopping: make(chan struct{}),
+	}
+	if *tlsMinVersion != "" {
+		v, err := cliflag.TLSVersion(*tlsMinVersion)
+		if err != nil {
+			return nil, err
+		}
+		s.MinVersion = v
+	}
+	if *tlsCipherSuites != "" {
+		suites, err := cliflag.TLSCipherSuites(strings.Split(*tlsCipherSuites, ","))
+		if err != nil {
+			return nil, err
+		}
+		s.CipherSuites = suites
+	}
+	return s, nil
+}
+
+// Register serves hook at path. It panics if two hooks are registered on the same path.
+func (s *Server) Register(path string, hook http.Handler) {
+	if _, found := s.webhooks[path]; found {
+		panic(fmt.Errorf("can't register duplicate path: %v", path))
+	}
+	s.webhooks[path] = hook
+	s.mux.Handle(path, http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		startTS := time.Now()
+		defer func() { requestLatency.WithLabelValues(path).Observe(time.Since(startTS).Seconds()) }()
+		hook.ServeHTTP(resp, req)
+	}))
+	log.Info("registering webhook", "path", path)
+}
+
+// Stopping is closed when the server starts shutting down, so handlers that stream their
+// responses can return instead of holding up the shutdown
+func (s *Server) Stopping() <-chan struct{} {
+	return s.stopping
+}
+
+// NeedLeaderElection implements the LeaderElectionRunnable interface; every pod serves webhooks
+func (*Server) NeedLeaderElection() bool {
+	return false
+}
+
+// InjectFunc injects the field setter used to prepare the registered hooks
+func (s *Server) InjectFunc(f inject.Func) error {
+	s.setFields = f
+	return nil
+}
+
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	kp := &keyPair{certPath: filepath.Join(s.CertDir, certName), keyPath: filepath.Join(s.CertDir, keyName)}
+	if _, err := kp.GetCertificate(nil); err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{
+		NextProtos:     []string{"h2"},
+		GetCertificate: kp.GetCertificate,
+		MinVersion:     s.MinVersion,
+		CipherSuites:   s.CipherSuites,
+	}
+	if s.ClientCAs != nil {
+		cfg.ClientCAs = s.ClientCAs
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// Start serves the registered webhooks until stop is closed
+func (s *Server) Start(stop <-chan struct{}) error {
+	for path, hook := range s.webhooks {
+		if err := s.setFields(hook); err != nil {
+			return err
+		}
+		if _, err := inject.LoggerInto(log.WithValues("webhook", path), hook); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := s.tlsConfig()
+	if err != nil {
+		return err
+	}
+	listener, err := tls.Listen("tcp", net.JoinHostPort("", strconv.Itoa(s.Port)), cfg)
+	if err != nil {
+		return err
+	}
+	log.Info("serving webhook server", "port", s.Port, "minTLSVersion", *tlsMinVersion)
+
+	srv := &http.Server{Handler: s.mux}
+	idleConnsClosed := make(chan struct{})
+	go func() {
+		<-stop
+		log.Info("shutting down webhook server")
+		if s.stopping != nil {
+			close(s.stopping)
+		}
+		if err := srv.Shutdown(context.Background()); err != nil {
+			log.Error(err, "error shutting down the HTTP server")
+		}
+		close(idleConnsClosed)
+	}()
+
+	if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	<-idleConnsClosed
+	return nil
+}
+
+// keyPair reloads the serving certificate whenever the certificate file changes, as
+// happens when the kubelet updates a mounted secret
+type keyPair struct {
+	certPath string
+	keyPath  string
+
+	mux     sync.Mutex
+	modTime time.Time
+	cert    *tls.Certificate
+}
+
+func (kp *keyPair) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	info, err := os.Stat(kp.certPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "while reading webhook certificate")
+	}
+	kp.mux.Lock()
+	defer kp.mux.Unlock()
+	if kp.cert != nil && info.ModTime().Equal(kp.modTime) {
+		return kp.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(kp.certPath, kp.keyPath)
+	if err != nil {
+		// the key may not be written yet, keep serving the previous certificate
+		if kp.cert != nil {
+			return kp.cert, nil
+		}
+		return nil, errors.Wrap(err, "while loading webhook certificate")
+	}
+	kp.cert = &cert
+	kp.modTime = info.ModTime()
+	return kp.cert, nil
+}
@@ -0,0 +1,183 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNewServerTLSFlags(t *testing.T) {
+	tc := []struct {
+		Name          string
+		MinVersion    string
+		CipherSuites  string
+		Expected      uint16
+		ErrorExpected bool
+	}{
+		{Name: "Defaults"},
+		{Name: "TLS 1.3", MinVersion: "VersionTLS13", Expected: tls.VersionTLS13},
+		{Name: "Unknown version", MinVersion: "1.3", ErrorExpected: true},
+		{Name: "Cipher suites", CipherSuites: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+		{Name: "Unknown cipher suite", CipherSuites: "TLS_NULL", ErrorExpected: true},
+	}
+	defer func() { *tlsMinVersion, *tlsCipherSuites = "", "" }()
+	for _, tt := range tc {
+		t.Run(tt.Name, func(t *testing.T) {
+			*tlsMinVersion, *tlsCipherSuites = tt.MinVersion, tt.CipherSuites
+			s, err := NewServer(443, "/certs")
+			if (err != nil) != tt.ErrorExpected {
+				t.Fatalf("NewServer() error = %v, wanted error: %v", err, tt.ErrorExpected)
+			}
+			if err != nil {
+				return
+			}
+			if s.MinVersion != tt.Expected {
+				t.Errorf("MinVersion = %x, wanted %x", s.MinVersion, tt.Expected)
+			}
+			if tt.CipherSuites != "" && len(s.CipherSuites) != 2 {
+				t.Errorf("CipherSuites = %v, wanted 2 suites", s.CipherSuites)
+			}
+		})
+	}
+}
+
+func TestServerMinVersion(t *testing.T) {
+	dir, _ := writeServingCert(t)
+	defer os.RemoveAll(dir)
+	addr, stop := startServer(t, &Server{CertDir: dir, MinVersion: tls.VersionTLS13})
+	defer close(stop)
+
+	if err := dial(addr, &tls.Config{InsecureSkipVerify: true, MaxVersion: tls.VersionTLS13}); err != nil {
+		t.Fatalf("could not connect with TLS 1.3: %v", err)
+	}
+	if err := dial(addr, &tls.Config{InsecureSkipVerify: true, MaxVersion: tls.VersionTLS12}); err == nil {
+		t.Error("connected with TLS 1.2, wanted the handshake to fail")
+	}
+}
+
+func TestServerClientCA(t *testing.T) {
+	dir, ca := writeServingCert(t)
+	defer os.RemoveAll(dir)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Cert)
+	addr, stop := startServer(t, &Server{CertDir: dir, ClientCAs: pool})
+	defer close(stop)
+
+	clientCert := createClientCert(t, ca)
+	if err := dial(addr, &tls.Config{InsecureSkipVerify: true, Certificates: []tls.Certificate{clientCert}}); err != nil {
+		t.Fatalf("could not connect with a client certificate: %v", err)
+	}
+	if err := dial(addr, &tls.Config{InsecureSkipVerify: true}); err == nil {
+		t.Error("connected without a client certificate, wanted the handshake to fail")
+	}
+	other, err := createCACert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	untrusted := createClientCert(t, other)
+	if err := dial(addr, &tls.Config{InsecureSkipVerify: true, Certificates: []tls.Certificate{untrusted}}); err == nil {
+		t.Error("connected with an untrusted client certificate, wanted the handshake to fail")
+	}
+}
+
+// writeServingCert writes a serving certificate to a new directory and returns the
+// directory and the signing CA
+func writeServingCert(t *testing.T) (string, *KeyPairArtifacts) {
+	dir, err := ioutil.TempDir("", "webhook-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := createCACert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, key, err := createCertPEM(ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, certName), cert, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, keyName), key, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return dir, ca
+}
+
+func createClientCert(t *testing.T, ca *KeyPairArtifacts) tls.Certificate {
+	templ := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "review-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, templ, ca.Cert, key.Public(), ca.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// startServer starts s on a free port and waits until it accepts connections
+func startServer(t *testing.T, s *Server) (string, chan struct{}) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Port = l.Addr().(*net.TCPAddr).Port
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s.mux, s.webhooks = http.NewServeMux(), map[string]http.Handler{}
+	if err := s.InjectFunc(func(interface{}) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	go func() {
+		if err := s.Start(stop); err != nil {
+			t.Errorf("Start() error = %v", err)
+		}
+	}()
+
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(s.Port))
+	for i := 0; i < 50; i++ {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			if err := conn.Close(); err != nil {
+				t.Fatal(err)
+			}
+			return addr, stop
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatal("server did not start")
+	return "", nil
+}
+
+// dial completes a TLS handshake and a request, as TLS 1.3 servers report client
+// certificate failures after the client's handshake returns
+func dial(addr string, cfg *tls.Config) error {
+	client := &http.Client{Timeout: time.Second, Transport: &http.Transport{TLSClientConfig: cfg}}
+	resp, err := client.Get("https://" + addr + "/")
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -2,17 +2,26 @@ package webhook
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/open-policy-agent/gatekeeper/pkg/metrics"
+	"github.com/open-policy-agent/gatekeeper/pkg/util"
+	"github.com/pkg/errors"
+	"go.opencensus.io/metric/metricdata"
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
 )
 
 const (
-	requestCountMetricName    = "request_count"
-	requestDurationMetricName = "request_duration_seconds"
+	requestCountMetricName     = "request_count"
+	requestDurationMetricName  = "request_duration_seconds"
+	violationsMetricName       = "template_violations"
+	failOpenMetricName         = "fail_open_count"
+	deniedRequestsMetricName   = "denied_requests"
+	downgradesMetricName       = "enforcement_downgrades"
+	templateDurationMetricName = "template_evaluation_duration_seconds"
 )
 
 var (
@@ -21,7 +30,43 @@ var (
 		"The response time in seconds",
 		stats.UnitSeconds)
 
-	admissionStatusKey = tag.MustNewKey("admission_status")
+	violationsM = stats.Int64(
+		violationsMetricName,
+		"The number of admission violations reported by constraints of each template",
+		stats.UnitDimensionless)
+
+	failOpenM = stats.Int64(
+		failOpenMetricName,
+		"The number of requests admitted without review by --fail-open-on-error",
+		stats.UnitDimensionless)
+
+	deniedRequestsM = stats.Int64(
+		deniedRequestsMetricName,
+		"The number of requests denied by the constraint webhook, by the class of user that sent them",
+		stats.UnitDimensionless)
+
+	downgradesM = stats.Int64(
+		downgradesMetricName,
+		"The number of times a constraint was downgraded to dryrun for exceeding --error-budget",
+		stats.UnitDimensionless)
+
+	templateDurationM = stats.Float64(
+		templateDurationMetricName,
+		"The time the constraints of each template took to evaluate a sampled request in seconds",
+		stats.UnitSeconds)
+
+	admissionStatusKey   = tag.MustNewKey("admission_status")
+	dryRunKey            = tag.MustNewKey("dryrun")
+	templateKey          = tag.MustNewKey("template")
+	enforcementActionKey = tag.MustNewKey("enforcement_action")
+	requesterClassKey    = tag.MustNewKey("requester_class")
+	userHashKey          = tag.MustNewKey("user_hash")
+	constraintKindKey    = tag.MustNewKey("constraint_kind")
+	constraintNameKey    = tag.MustNewKey("constraint_name")
+
+	// annotationKeys label violations with the constraint annotations surfaced by
+	// --surface-constraint-annotations, keyed by annotation
+	annotationKeys map[string]tag.Key
 )
 
 func init() {
@@ -32,7 +77,12 @@ func init() {
 
 // StatsReporter reports webhook metrics
 type StatsReporter interface {
-	ReportRequest(response requestResponse, d time.Duration) error
+	ReportRequest(response requestResponse, dryRun bool, d time.Duration, exemplar metricdata.Attachments) error
+	ReportTemplateViolation(template, enforcementAction string, annotations map[string]string) error
+	ReportFailOpen() error
+	ReportDenial(requesterClass, userHash string) error
+	ReportDowngrade(kind, name string) error
+	ReportTemplateDuration(template string, d time.Duration) error
 }
 
 // reporter implements StatsReporter interface
@@ -52,19 +102,83 @@ func newStatsReporter() (StatsReporter, error) {
 	return &reporter{ctx: ctx}, nil
 }
 
-// Captures req count metric, recording the count and the duration
-func (r *reporter) ReportRequest(response requestResponse, d time.Duration) error {
+// Captures req count metric, recording the count and the duration. A non-nil exemplar is
+// attached to the duration sample.
+func (r *reporter) ReportRequest(response requestResponse, dryRun bool, d time.Duration, exemplar metricdata.Attachments) error {
 	ctx, err := tag.New(
 		r.ctx,
 		tag.Insert(admissionStatusKey, string(response)),
+		tag.Insert(dryRunKey, strconv.FormatBool(dryRun)),
 	)
 	if err != nil {
 		return err
 	}
 
+	if exemplar != nil {
+		return metrics.Record(ctx, responseTimeInSecM.M(d.Seconds()), stats.WithAttachments(exemplar))
+	}
 	return r.report(ctx, responseTimeInSecM.M(d.Seconds()))
 }
 
+// ReportTemplateViolation counts a violation of a constraint of the named template.
+// annotations are the constraint's surfaced annotations; those without a label are ignored.
+func (r *reporter) ReportTemplateViolation(template, enforcementAction string, annotations map[string]string) error {
+	mutators := []tag.Mutator{
+		tag.Insert(templateKey, template),
+		tag.Insert(enforcementActionKey, enforcementAction),
+	}
+	for annotation, value := range annotations {
+		if key, ok := annotationKeys[annotation]; ok {
+			mutators = append(mutators, tag.Insert(key, value))
+		}
+	}
+	ctx, err := tag.New(r.ctx, mutators...)
+	if err != nil {
+		return err
+	}
+
+	return r.report(ctx, violationsM.M(1))
+}
+
+// ReportFailOpen counts a request that was admitted because it could not be reviewed
+func (r *reporter) ReportFailOpen() error {
+	return r.report(r.ctx, failOpenM.M(1))
+}
+
+// ReportDenial counts a denied request. userHash is only recorded if the view was
+// registered with it.
+func (r *reporter) ReportDenial(requesterClass, userHash string) error {
+	mutators := []tag.Mutator{tag.Insert(requesterClassKey, requesterClass)}
+	if userHash != "" {
+		mutators = append(mutators, tag.Insert(userHashKey, userHash))
+	}
+	ctx, err := tag.New(r.ctx, mutators...)
+	if err != nil {
+		return err
+	}
+
+	return r.report(ctx, deniedRequestsM.M(1))
+}
+
+// ReportDowngrade counts a downgrade of the constraint of kind and name
+func (r *reporter) ReportDowngrade(kind, name string) error {
+	ctx, err := tag.New(r.ctx, tag.Insert(constraintKindKey, kind), tag.Insert(constraintNameKey, name))
+	if err != nil {
+		return err
+	}
+	return r.report(ctx, downgradesM.M(1))
+}
+
+// ReportTemplateDuration records how long the constraints of the named template took to
+// evaluate a request
+func (r *reporter) ReportTemplateDuration(template string, d time.Duration) error {
+	ctx, err := tag.New(r.ctx, tag.Insert(templateKey, template))
+	if err != nil {
+		return err
+	}
+	return r.report(ctx, templateDurationM.M(d.Seconds()))
+}
+
 func (r *reporter) report(ctx context.Context, m stats.Measurement) error {
 	return metrics.Record(ctx, m)
 }
@@ -76,15 +190,91 @@ func register() error {
 			Description: "The number of requests that are routed to webhook",
 			Measure:     responseTimeInSecM,
 			Aggregation: view.Count(),
-			TagKeys:     []tag.Key{admissionStatusKey},
+			TagKeys:     []tag.Key{admissionStatusKey, dryRunKey},
 		},
 		{
 			Name:        requestDurationMetricName,
 			Description: responseTimeInSecM.Description(),
 			Measure:     responseTimeInSecM,
 			Aggregation: view.Distribution(0.001, 0.002, 0.003, 0.004, 0.005, 0.006, 0.007, 0.008, 0.009, 0.01, 0.02, 0.03, 0.04, 0.05),
-			TagKeys:     []tag.Key{admissionStatusKey},
+			TagKeys:     []tag.Key{admissionStatusKey, dryRunKey},
 		},
+		{
+			Name:        failOpenMetricName,
+			Description: failOpenM.Description(),
+			Measure:     failOpenM,
+			Aggregation: view.Count(),
+		},
+		{
+			Name:        downgradesMetricName,
+			Description: downgradesM.Description(),
+			Measure:     downgradesM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{constraintKindKey, constraintNameKey},
+		},
+		{
+			Name:        templateDurationMetricName,
+			Description: templateDurationM.Description(),
+			Measure:     templateDurationM,
+			Aggregation: view.Distribution(0.0001, 0.0002, 0.0005, 0.001, 0.002, 0.005, 0.01, 0.02, 0.05, 0.1, 0.2, 0.5, 1),
+			TagKeys:     []tag.Key{templateKey},
+		},
+	}
+	if err := view.Register(views...); err != nil {
+		return err
+	}
+	if err := registerDeniedRequestsView(false); err != nil {
+		return err
+	}
+	return registerViolationsView(nil)
+}
+
+// registerDeniedRequestsView (re-)registers the denied requests view, labeled with the
+// hash of the requesting username if userHash is set. Re-registering drops the counts
+// recorded so far.
+func registerDeniedRequestsView(userHash bool) error {
+	tagKeys := []tag.Key{requesterClassKey}
+	if userHash {
+		tagKeys = append(tagKeys, userHashKey)
+	}
+	if v := view.Find(deniedRequestsMetricName); v != nil {
+		view.Unregister(v)
+	}
+	return view.Register(&view.View{
+		Name:        deniedRequestsMetricName,
+		Description: deniedRequestsM.Description(),
+		Measure:     deniedRequestsM,
+		Aggregation: view.Count(),
+		TagKeys:     tagKeys,
+	})
+}
+
+// registerViolationsView (re-)registers the violations view with a label for each of the
+// surfaced constraint annotations. Re-registering drops the counts recorded so far.
+func registerViolationsView(annotations []string) error {
+	keys := make(map[string]tag.Key)
+	tagKeys := []tag.Key{templateKey, enforcementActionKey}
+	for _, annotation := range annotations {
+		key, err := tag.NewKey(util.AnnotationLabel(annotation))
+		if err != nil {
+			return errors.Wrapf(err, "invalid metric label for annotation %s", annotation)
+		}
+		keys[annotation] = key
+		tagKeys = append(tagKeys, key)
+	}
+	if v := view.Find(violationsMetricName); v != nil {
+		view.Unregister(v)
+	}
+	err := view.Register(&view.View{
+		Name:        violationsMetricName,
+		Description: violationsM.Description(),
+		Measure:     violationsM,
+		Aggregation: view.Count(),
+		TagKeys:     tagKeys,
+	})
+	if err != nil {
+		return err
 	}
-	return view.Register(views...)
+	annotationKeys = keys
+	return nil
 }
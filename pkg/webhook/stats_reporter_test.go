@@ -4,12 +4,29 @@ import (
 	"testing"
 	"time"
 
+	"github.com/open-policy-agent/gatekeeper/pkg/metrics"
 	"go.opencensus.io/stats/view"
 )
 
+// resetView drops the data recorded so far for the view of name, such as the requests
+// reviewed by the handler tests
+func resetView(t *testing.T, name string) {
+	v := view.Find(name)
+	if v == nil {
+		return
+	}
+	view.Unregister(v)
+	if err := view.Register(v); err != nil {
+		t.Fatalf("could not register view %s: %v", name, err)
+	}
+}
+
 func TestReportRequest(t *testing.T) {
+	resetView(t, requestCountMetricName)
+	resetView(t, requestDurationMetricName)
 	expectedTags := map[string]string{
 		"admission_status": "allow",
+		"dryrun":           "false",
 	}
 	const expectedDurationValueMin = time.Duration(1 * time.Second)
 	const expectedDurationValueMax = time.Duration(5 * time.Second)
@@ -22,11 +39,11 @@ func TestReportRequest(t *testing.T) {
 	if err != nil {
 		t.Errorf("newStatsReporter() error %v", err)
 	}
-	err = r.ReportRequest(allowResponse, expectedDurationValueMin)
+	err = r.ReportRequest(allowResponse, false, expectedDurationValueMin, nil)
 	if err != nil {
 		t.Errorf("ReportRequest error %v", err)
 	}
-	err = r.ReportRequest(allowResponse, expectedDurationValueMax)
+	err = r.ReportRequest(allowResponse, false, expectedDurationValueMax, nil)
 	if err != nil {
 		t.Errorf("ReportRequest error %v", err)
 	}
@@ -65,6 +82,125 @@ func TestReportRequest(t *testing.T) {
 	}
 }
 
+func TestReportRequestExemplar(t *testing.T) {
+	r, err := newStatsReporter()
+	if err != nil {
+		t.Fatalf("newStatsReporter() error %v", err)
+	}
+	d := &Decision{ID: "decision", Constraints: []DecisionConstraint{{Kind: "K8sRequiredLabels", Name: "owner"}}}
+	if err := r.ReportRequest(denyResponse, false, 20*time.Millisecond, d.exemplar()); err != nil {
+		t.Fatalf("ReportRequest error %v", err)
+	}
+	exemplars, err := metrics.Exemplars(requestDurationMetricName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range exemplars {
+		if e.Attachments["decision_id"] == "decision" {
+			if e.Attachments["constraints"] != "K8sRequiredLabels/owner" || e.Tags["admission_status"] != "deny" {
+				t.Errorf("unexpected exemplar %+v", e)
+			}
+			return
+		}
+	}
+	t.Errorf("no exemplar for the decision in %v", exemplars)
+}
+
+func TestReportTemplateViolation(t *testing.T) {
+	if err := registerViolationsView(nil); err != nil {
+		t.Fatal(err)
+	}
+	r, err := newStatsReporter()
+	if err != nil {
+		t.Errorf("newStatsReporter() error %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := r.ReportTemplateViolation("k8srequiredlabels", "deny", nil); err != nil {
+			t.Errorf("ReportTemplateViolation error %v", err)
+		}
+	}
+	row := checkData(t, violationsMetricName, 1)
+	count, ok := row.Data.(*view.CountData)
+	if !ok {
+		t.Fatal("ReportTemplateViolation should have aggregation Count()")
+	}
+	expectedTags := map[string]string{"template": "k8srequiredlabels", "enforcement_action": "deny"}
+	for _, tag := range row.Tags {
+		if tag.Value != expectedTags[tag.Key.Name()] {
+			t.Errorf("ReportTemplateViolation tags does not match for %v", tag.Key.Name())
+		}
+	}
+	if count.Value != 2 {
+		t.Errorf("Metric: %v - Expected %v, got %v. ", violationsMetricName, 2, count.Value)
+	}
+}
+
+func TestReportTemplateViolationAnnotations(t *testing.T) {
+	if err := registerViolationsView([]string{"severity", "example.com/ticket"}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := registerViolationsView(nil); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r, err := newStatsReporter()
+	if err != nil {
+		t.Fatalf("newStatsReporter() error %v", err)
+	}
+	annotations := map[string]string{"severity": "high", "team": "a"}
+	if err := r.ReportTemplateViolation("k8srequiredlabels", "deny", annotations); err != nil {
+		t.Errorf("ReportTemplateViolation error %v", err)
+	}
+	row := checkData(t, violationsMetricName, 1)
+	expectedTags := map[string]string{"template": "k8srequiredlabels", "enforcement_action": "deny", "annotation_severity": "high"}
+	if len(row.Tags) != len(expectedTags) {
+		t.Errorf("got tags %v, wanted %v", row.Tags, expectedTags)
+	}
+	for _, tag := range row.Tags {
+		if tag.Value != expectedTags[tag.Key.Name()] {
+			t.Errorf("ReportTemplateViolation tags does not match for %v", tag.Key.Name())
+		}
+	}
+}
+
+func TestReportDenial(t *testing.T) {
+	if err := registerDeniedRequestsView(true); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := registerDeniedRequestsView(false); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r, err := newStatsReporter()
+	if err != nil {
+		t.Fatalf("newStatsReporter() error %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := r.ReportDenial("human", "0123456789ab"); err != nil {
+			t.Errorf("ReportDenial error %v", err)
+		}
+	}
+	row := checkData(t, deniedRequestsMetricName, 1)
+	count, ok := row.Data.(*view.CountData)
+	if !ok {
+		t.Fatal("ReportDenial should have aggregation Count()")
+	}
+	if count.Value != 2 {
+		t.Errorf("Metric: %v - Expected %v, got %v", deniedRequestsMetricName, 2, count.Value)
+	}
+	expectedTags := map[string]string{"requester_class": "human", "user_hash": "0123456789ab"}
+	if len(row.Tags) != len(expectedTags) {
+		t.Errorf("got tags %v, wanted %v", row.Tags, expectedTags)
+	}
+	for _, tag := range row.Tags {
+		if tag.Value != expectedTags[tag.Key.Name()] {
+			t.Errorf("ReportDenial tags does not match for %v", tag.Key.Name())
+		}
+	}
+}
+
 func checkData(t *testing.T, name string, expectedRowLength int) *view.Row {
 	row, err := view.RetrieveData(name)
 	if err != nil {
@@ -78,3 +214,26 @@ func checkData(t *testing.T, name string, expectedRowLength int) *view.Row {
 	}
 	return row[0]
 }
+
+func TestReportTemplateDuration(t *testing.T) {
+	r, err := newStatsReporter()
+	if err != nil {
+		t.Fatalf("newStatsReporter() error %v", err)
+	}
+	if err := r.ReportTemplateDuration("k8srequiredlabels", 2*time.Millisecond); err != nil {
+		t.Errorf("ReportTemplateDuration error %v", err)
+	}
+	row := checkData(t, templateDurationMetricName, 1)
+	dist, ok := row.Data.(*view.DistributionData)
+	if !ok {
+		t.Fatal("ReportTemplateDuration should have aggregation Distribution()")
+	}
+	if dist.Count != 1 {
+		t.Errorf("Metric: %v - Expected count %v, got %v", templateDurationMetricName, 1, dist.Count)
+	}
+	for _, tag := range row.Tags {
+		if tag.Key.Name() == "template" && tag.Value != "k8srequiredlabels" {
+			t.Errorf("ReportTemplateDuration template tag = %q", tag.Value)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"flag"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/gatekeeper/pkg/driver"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// pendingTimings is how many sampled reviews may wait to be timed. Samples beyond it
+// are dropped.
+const pendingTimings = 16
+
+var templateTimingSampleRate = flag.Float64("template-timing-sample-rate", 0.01, "fraction of reviewed requests whose matching constraints are evaluated again, one at a time and off the admission path, to report the "+templateDurationMetricName+" metric. 0 disables it")
+
+// templateTimer reports how long the constraints of each template take to evaluate a
+// sample of the reviewed requests. The review evaluates every template in one query, so
+// the sampled requests are evaluated again one constraint at a time.
+type templateTimer struct {
+	queries  *driver.Queries
+	reporter StatsReporter
+	rate     float64
+	pending  chan target.AugmentedReview
+}
+
+var _ manager.Runnable = &templateTimer{}
+
+// newTemplateTimer returns a timer sampling rate of the reviews, or nil if rate is not
+// positive
+func newTemplateTimer(queries *driver.Queries, rate float64) (*templateTimer, error) {
+	if rate <= 0 || queries == nil {
+		return nil, nil
+	}
+	reporter, err := newStatsReporter()
+	if err != nil {
+		return nil, err
+	}
+	return &templateTimer{
+		queries:  queries,
+		reporter: reporter,
+		rate:     rate,
+		pending:  make(chan target.AugmentedReview, pendingTimings),
+	}, nil
+}
+
+// sample queues review to be timed, if it is sampled, without blocking. Nothing is timed
+// if t is nil.
+func (t *templateTimer) sample(review target.AugmentedReview) {
+	if t == nil || rand.Float64() >= t.rate {
+		return
+	}
+	select {
+	case t.pending <- review:
+	default:
+	}
+}
+
+// Start times the queued reviews until stop is closed
+func (t *templateTimer) Start(stop <-chan struct{}) error {
+	for {
+		select {
+		case <-stop:
+			return nil
+		case review := <-t.pending:
+			t.time(context.Background(), review)
+		}
+	}
+}
+
+// time evaluates each constraint matching review and reports the time spent by the
+// constraints of each template. A template whose evaluation fails is not reported.
+func (t *templateTimer) time(ctx context.Context, augmented target.AugmentedReview) {
+	tgt := &target.K8sValidationTarget{}
+	_, review, err := tgt.HandleReview(augmented)
+	if err != nil {
+		log.Error(err, "could not time templates")
+		return
+	}
+	matching, err := t.queries.Matching(ctx, tgt.GetName(), review)
+	if err != nil {
+		log.Error(err, "could not time templates")
+		return
+	}
+	durations := make(map[string]time.Duration)
+	failed := make(map[string]bool)
+	for _, c := range matching {
+		// a template is named after the lowercase kind of its constraints
+		template := strings.ToLower(c.GetKind())
+		start := time.Now()
+		if _, err := t.queries.Evaluate(ctx, tgt.GetName(), review, c); err != nil {
+			failed[template] = true
+			continue
+		}
+		durations[template] += time.Since(start)
+	}
+	for template, d := range durations {
+		if failed[template] {
+			continue
+		}
+		if err := t.reporter.ReportTemplateDuration(template, d); err != nil {
+			log.Error(err, "failed to report template duration")
+		}
+	}
+}
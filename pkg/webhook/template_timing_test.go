@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	templv1beta1 "github.com/open-policy-agent/frameworks/constraint/pkg/apis/templates/v1beta1"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/client/drivers/local"
+	"github.com/open-policy-agent/frameworks/constraint/pkg/core/templates"
+	gkdriver "github.com/open-policy-agent/gatekeeper/pkg/driver"
+	"github.com/open-policy-agent/gatekeeper/pkg/target"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const denyAllTemplate = `
+apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: k8sdenyall
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sDenyAll
+  targets:
+    - target: admission.k8s.gatekeeper.sh
+      rego: |
+        package denyall
+
+        violation[{"msg": "denied"}] { true }
+`
+
+// durationRecorder records the template durations it is reported
+type durationRecorder struct {
+	StatsReporter
+	durations map[string]time.Duration
+}
+
+func (r *durationRecorder) ReportTemplateDuration(template string, d time.Duration) error {
+	r.durations[template] += d
+	return nil
+}
+
+func TestTemplateTimer(t *testing.T) {
+	ctx := context.Background()
+	d := local.New(local.Tracing(false))
+	backend, err := client.NewBackend(client.Driver(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opa, err := backend.NewClient(client.Targets(&target.K8sValidationTarget{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	queries, err := gkdriver.NewQueries(ctx, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, src := range []string{denyAllTemplate, conflictingTemplate} {
+		templ := &templv1beta1.ConstraintTemplate{}
+		if err := yaml.Unmarshal([]byte(src), templ); err != nil {
+			t.Fatalf("Could not instantiate template: %s", err)
+		}
+		unversioned := &templates.ConstraintTemplate{}
+		if err := runtimeScheme.Convert(templ, unversioned, nil); err != nil {
+			t.Fatalf("Could not convert to unversioned: %v", err)
+		}
+		if _, err := opa.AddTemplate(ctx, unversioned); err != nil {
+			t.Fatalf("Could not add template: %s", err)
+		}
+	}
+	for _, kind := range []string{"K8sDenyAll", "K8sConflicting"} {
+		if _, err := opa.AddConstraint(ctx, newConstraint(kind, "c", "deny", t)); err != nil {
+			t.Fatalf("Could not add constraint: %s", err)
+		}
+	}
+
+	if timer, err := newTemplateTimer(queries, 0); timer != nil || err != nil {
+		t.Errorf("newTemplateTimer(0) = %v, %v, wanted no timer", timer, err)
+	}
+	timer, err := newTemplateTimer(queries, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := &durationRecorder{durations: make(map[string]time.Duration)}
+	timer.reporter = recorder
+	req := admissionv1beta1.AdmissionRequest{
+		Kind:   metav1.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+		Object: runtime.RawExtension{Raw: []byte(`{"apiVersion": "v1", "kind": "Namespace"}`)},
+	}
+	timer.sample(target.AugmentedReview{AdmissionRequest: &req})
+	if len(timer.pending) != 1 {
+		t.Fatalf("got %d pending reviews, wanted 1", len(timer.pending))
+	}
+	timer.time(ctx, <-timer.pending)
+	if _, ok := recorder.durations["k8sdenyall"]; !ok {
+		t.Error("no duration was reported for k8sdenyall")
+	}
+	if _, ok := recorder.durations["k8sconflicting"]; ok {
+		t.Error("a duration was reported for the failing k8sconflicting template")
+	}
+}
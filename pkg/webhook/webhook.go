@@ -17,11 +17,12 @@ package webhook
 
 import (
 	"github.com/open-policy-agent/frameworks/constraint/pkg/client"
+	"github.com/open-policy-agent/gatekeeper/pkg/driver"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
 // AddToManagerFuncs is a list of functions to add all Controllers to the Manager
-var AddToManagerFuncs []func(manager.Manager, *client.Client) error
+var AddToManagerFuncs []func(manager.Manager, *client.Client, *driver.Queries, *Server) error
 
 // The below autogen directive is currently disabled because controller-gen has
 // no way of specifying the resource name restriction
@@ -29,12 +30,17 @@ var AddToManagerFuncs []func(manager.Manager, *client.Client) error
 
 // +kubebuilder:rbac:groups="",namespace=gatekeeper-system,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 
-// AddToManager adds all Controllers to the Manager
-func AddToManager(m manager.Manager, opa *client.Client) error {
+// AddToManager adds all Controllers to the Manager and serves the webhooks on port
+// using the certificate in certDir. queries must query the policy engine of opa.
+func AddToManager(m manager.Manager, opa *client.Client, queries *driver.Queries, port int, certDir string) error {
+	srv, err := NewServer(port, certDir)
+	if err != nil {
+		return err
+	}
 	for _, f := range AddToManagerFuncs {
-		if err := f(m, opa); err != nil {
+		if err := f(m, opa, queries, srv); err != nil {
 			return err
 		}
 	}
-	return nil
+	return m.Add(srv)
 }
@@ -0,0 +1,91 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package version holds the build information set by the Makefile's LDFLAGS
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+// frameworkModule is the constraint framework Gatekeeper evaluates policies with
+const frameworkModule = "github.com/open-policy-agent/frameworks/constraint"
+
+var (
+	// Version is the release, for example v3.1.0-beta.7
+	Version = "unknown"
+	// Vcs is the git commit the binary was built from
+	Vcs = ""
+	// Timestamp is when the binary was built
+	Timestamp = ""
+	// Hostname is the host the binary was built on
+	Hostname = ""
+)
+
+// Info describes the running binary
+type Info struct {
+	Version          string `json:"version"`
+	GitCommit        string `json:"gitCommit"`
+	BuildTimestamp   string `json:"buildTimestamp,omitempty"`
+	FrameworkVersion string `json:"frameworkVersion"`
+	GoVersion        string `json:"goVersion"`
+}
+
+// Get returns the build information of the running binary
+func Get() Info {
+	return Info{
+		Version:          Version,
+		GitCommit:        Vcs,
+		BuildTimestamp:   Timestamp,
+		FrameworkVersion: FrameworkVersion(),
+		GoVersion:        runtime.Version(),
+	}
+}
+
+// FrameworkVersion returns the module version of the constraint framework compiled into
+// the binary, or "unknown" if the binary was built without module information
+func FrameworkVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != frameworkModule {
+			continue
+		}
+		if dep.Replace != nil {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return "unknown"
+}
+
+// Handler serves Get as JSON
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(Get()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,32 @@
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	defer func(v, vcs string) { Version, Vcs = v, vcs }(Version, Vcs)
+	Version, Vcs = "v3.1.0", "abc123"
+
+	resp := httptest.NewRecorder()
+	Handler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, wanted %d", resp.Code, http.StatusOK)
+	}
+	got := Info{}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != "v3.1.0" || got.GitCommit != "abc123" || got.FrameworkVersion == "" || got.GoVersion == "" {
+		t.Errorf("version info = %+v", got)
+	}
+
+	resp = httptest.NewRecorder()
+	Handler().ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/version", nil))
+	if resp.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, wanted %d", resp.Code, http.StatusMethodNotAllowed)
+	}
+}